@@ -1,15 +1,19 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 
 	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/abi"
 )
 
 const (
 	EOutOfGas = iota + jsonrpc.FirstUserCode
 	EActorNotFound
+	EStatePruned
 )
 
 type ErrOutOfGas struct{}
@@ -24,6 +28,29 @@ func (e *ErrActorNotFound) Error() string {
 	return "actor not found"
 }
 
+// ErrStatePruned is returned when the state at a height has been pruned from the node, as
+// configured by Chainstore.StateRetentionEpochs.
+type ErrStatePruned struct {
+	Height abi.ChainEpoch
+	// NearestRetained is the closest epoch to Height whose state is still available
+	NearestRetained abi.ChainEpoch
+}
+
+func (e *ErrStatePruned) Error() string {
+	return fmt.Sprintf("state pruned at height %d; nearest retained epoch is %d", e.Height, e.NearestRetained)
+}
+
+type errStatePruned ErrStatePruned
+
+// MarshalJSON and UnmarshalJSON carry the heights across the RPC boundary.
+func (e *ErrStatePruned) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*errStatePruned)(e))
+}
+
+func (e *ErrStatePruned) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, (*errStatePruned)(e))
+}
+
 var RPCErrors = jsonrpc.NewErrors()
 
 func ErrorIsIn(err error, errorTypes []error) bool {
@@ -39,4 +66,5 @@ func ErrorIsIn(err error, errorTypes []error) bool {
 func init() {
 	RPCErrors.Register(EOutOfGas, new(*ErrOutOfGas))
 	RPCErrors.Register(EActorNotFound, new(*ErrActorNotFound))
+	RPCErrors.Register(EStatePruned, new(*ErrStatePruned))
 }
@@ -25,6 +25,7 @@ import (
 	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	abinetwork "github.com/filecoin-project/go-state-types/network"
 
 	apitypes "github.com/filecoin-project/lotus/api/types"
@@ -34,6 +35,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/types/ethtypes"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/tracer"
 	"github.com/filecoin-project/lotus/node/repo/imports"
 )
 
@@ -201,6 +203,27 @@ type FullNode interface {
 	// ChainGetEvents returns the events under an event AMT root CID.
 	ChainGetEvents(context.Context, cid.Cid) ([]types.Event, error) //perm:read
 
+	// ChainImportOnline imports a snapshot, optionally zstd compressed, into the running node
+	// in the background, and switches its head to the root of the snapshot once its state has
+	// been computed, so that stale nodes can be refreshed without being restarted. The source
+	// is either an http(s) URL or a path on the node. Only one import runs at a time.
+	ChainImportOnline(ctx context.Context, source string) (*ChainImportStatus, error) //perm:admin
+
+	// ChainImportStatus returns the status of the running online chain import, or of the last one.
+	ChainImportStatus(context.Context) (*ChainImportStatus, error) //perm:admin
+
+	// ChainGC starts online garbage collection on the chainstore in the background: the store
+	// is compacted, and value log files with more than the threshold fraction of garbage are
+	// rewritten one at a time, pausing for the throttle duration between rewrites. Only one GC
+	// runs at a time.
+	ChainGC(ctx context.Context, opts ChainGCOpts) (*ChainGCStatus, error) //perm:admin
+
+	// ChainGCStatus returns the progress of the running chainstore GC, or of the last one.
+	ChainGCStatus(context.Context) (*ChainGCStatus, error) //perm:admin
+
+	// ChainGCCancel cancels the running chainstore GC.
+	ChainGCCancel(context.Context) (*ChainGCStatus, error) //perm:admin
+
 	// GasEstimateFeeCap estimates gas fee cap
 	GasEstimateFeeCap(context.Context, *types.Message, int64, types.TipSetKey) (types.BigInt, error) //perm:read
 
@@ -217,6 +240,18 @@ type FullNode interface {
 	// GasEstimateMessageGas estimates gas values for unset message gas fields
 	GasEstimateMessageGas(context.Context, *types.Message, *MessageSendSpec, types.TipSetKey) (*types.Message, error) //perm:read
 
+	// GasFeeHistory returns the fee market history of the given number of tipsets before the
+	// heaviest tipset, with the given percentiles of their gas premiums, in ascending order.
+	GasFeeHistory(ctx context.Context, tipsets int, percentiles []float64) (*FeeHistory, error) //perm:read
+
+	// GasBaseFeeForecast projects the base fee over the given number of epochs after the heaviest
+	// tipset, from the linear trend of the gas limit per block over the given number of tipsets.
+	GasBaseFeeForecast(ctx context.Context, epochs, window int) (*BaseFeeForecast, error) //perm:read
+
+	// GasPriceOracle recommends gas premiums for a low, medium and high inclusion speed, from the
+	// gas premiums the given number of recent tipsets included messages at.
+	GasPriceOracle(ctx context.Context, window int) (*GasPriceOracle, error) //perm:read
+
 	// MethodGroup: Sync
 	// The Sync method group contains methods for interacting with and
 	// observing the lotus sync service.
@@ -224,6 +259,14 @@ type FullNode interface {
 	// SyncState returns the current status of the lotus sync system.
 	SyncState(context.Context) (*SyncState, error) //perm:read
 
+	// SyncComputeProgress returns the progress of the tipset executions currently
+	// in flight on the node.
+	SyncComputeProgress(context.Context) ([]ComputeProgress, error) //perm:read
+
+	// SyncImportProgress returns the progress of the running chain import, or of the last
+	// one, so that orchestration tooling can monitor imports; nil if there was none.
+	SyncImportProgress(context.Context) (*ImportProgress, error) //perm:read
+
 	// SyncSubmitBlock can be used to submit a newly created block to the.
 	// network through this node
 	SyncSubmitBlock(ctx context.Context, blk *types.BlockMsg) error //perm:write
@@ -235,6 +278,17 @@ type FullNode interface {
 	// SyncCheckpoint marks a blocks as checkpointed, meaning that it won't ever fork away from it.
 	SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error //perm:admin
 
+	// SyncTrustedCheckpoints lists the trusted checkpoints, the tipsets the syncer requires the
+	// chain to go through.
+	SyncTrustedCheckpoints(context.Context) ([]TrustedCheckpoint, error) //perm:read
+
+	// SyncAddTrustedCheckpoints adds trusted checkpoints; the node will refuse to sync chains
+	// not going through them.
+	SyncAddTrustedCheckpoints(context.Context, []TrustedCheckpoint) error //perm:admin
+
+	// SyncRemoveTrustedCheckpoint removes the trusted checkpoint at an epoch.
+	SyncRemoveTrustedCheckpoint(context.Context, abi.ChainEpoch) error //perm:admin
+
 	// SyncMarkBad marks a blocks as bad, meaning that it won't ever by synced.
 	// Use with extreme caution.
 	SyncMarkBad(ctx context.Context, bcid cid.Cid) error //perm:admin
@@ -277,9 +331,13 @@ type FullNode interface {
 	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *MessageSendSpec) (*types.SignedMessage, error) //perm:sign
 
 	// MpoolBatchPush batch pushes a signed message to mempool.
+	// Either all the messages are added, or none: when a message is refused, the
+	// error identifies its index in the batch.
 	MpoolBatchPush(context.Context, []*types.SignedMessage) ([]cid.Cid, error) //perm:write
 
 	// MpoolBatchPushUntrusted batch pushes a signed message to mempool from untrusted sources.
+	// Either all the messages are added, or none: when a message is refused, the
+	// error identifies its index in the batch.
 	MpoolBatchPushUntrusted(context.Context, []*types.SignedMessage) ([]cid.Cid, error) //perm:write
 
 	// MpoolBatchPushMessage batch pushes a unsigned message to mempool.
@@ -296,6 +354,10 @@ type FullNode interface {
 	// Note that this method may not be atomic. Use MpoolPushMessage instead.
 	MpoolGetNonce(context.Context, address.Address) (uint64, error) //perm:read
 	MpoolSub(context.Context) (<-chan MpoolUpdate, error)           //perm:read
+	// MpoolSubFiltered is like MpoolSub, but only sends the updates of the messages matching
+	// the filter, which is evaluated by the node. Senders are also matched by their key
+	// address, so that they can be given as ID addresses.
+	MpoolSubFiltered(context.Context, MpoolUpdateFilter) (<-chan MpoolUpdate, error) //perm:read
 
 	// MpoolClear clears pending messages from the mpool.
 	// If clearLocal is true, ALL messages will be cleared.
@@ -307,6 +369,36 @@ type FullNode interface {
 	// MpoolSetConfig sets the mpool config to (a copy of) the supplied config
 	MpoolSetConfig(context.Context, *types.MpoolConfig) error //perm:admin
 
+	// MpoolPersistedLocal returns the local messages persisted by the mpool, which are
+	// re-added to it when the node restarts.
+	MpoolPersistedLocal(context.Context) ([]*types.SignedMessage, error) //perm:read
+	// MpoolClearPersistedLocal removes the persisted local messages from the datastore,
+	// leaving the pending messages of the mpool alone, and returns the number of messages
+	// removed.
+	MpoolClearPersistedLocal(context.Context) (int, error) //perm:admin
+
+	// MpoolGetRBFPolicy returns the replace-by-fee policy the messages of a sender must
+	// satisfy, or the default policy of the node for address.Undef.
+	MpoolGetRBFPolicy(ctx context.Context, from address.Address) (*RBFPolicy, error) //perm:read
+
+	// MpoolNonceGaps returns the state nonce of a sender, and the gaps between it and the
+	// pending messages of the sender, which hold them back.
+	MpoolNonceGaps(context.Context, address.Address) (*NonceGaps, error) //perm:read
+
+	// MpoolHistory returns the history of the messages pushed through the node from a sender,
+	// with their outcome on chain, in nonce order.
+	MpoolHistory(context.Context, address.Address) ([]*LocalMessageRecord, error) //perm:read
+
+	// MpoolScheduled returns the messages scheduled for submission by the node, by target epoch.
+	MpoolScheduled(context.Context) ([]*ScheduledMessage, error) //perm:read
+
+	// MpoolSchedule schedules a signed message for submission at or after an epoch, and returns
+	// its CID. The message is only checked for its syntax and signature until it's pushed.
+	MpoolSchedule(context.Context, *types.SignedMessage, abi.ChainEpoch) (cid.Cid, error) //perm:write
+
+	// MpoolCancelScheduled cancels a scheduled message.
+	MpoolCancelScheduled(context.Context, cid.Cid) error //perm:write
+
 	// MethodGroup: Miner
 
 	MinerGetBaseInfo(context.Context, address.Address, abi.ChainEpoch, types.TipSetKey) (*MiningBaseInfo, error) //perm:read
@@ -345,6 +437,27 @@ type FullNode interface {
 	WalletDelete(context.Context, address.Address) error //perm:admin
 	// WalletValidateAddress validates whether a given string can be decoded as a well-formed address
 	WalletValidateAddress(context.Context, string) (address.Address, error) //perm:read
+	// WalletHDNew generates a BIP39 mnemonic of the given number of words, makes its seed,
+	// protected by the passphrase, the HD seed of the wallet and adds the address at index 0.
+	// The mnemonic isn't stored and is the only way to restore the addresses.
+	WalletHDNew(ctx context.Context, words int, passphrase string) (*HDMnemonic, error) //perm:admin
+	// WalletHDDerive adds the address at an index of the HD seed to the wallet; a negative index
+	// adds the lowest index which isn't in the wallet yet.
+	WalletHDDerive(ctx context.Context, index int64) (*HDKey, error) //perm:admin
+	// WalletHDRestore makes the seed of a mnemonic the HD seed of the wallet and adds the
+	// addresses at the first count indexes.
+	WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]HDKey, error) //perm:admin
+	// WalletEncrypt encrypts the keys stored in the keystore of the wallet with a passphrase, and
+	// leaves it unlocked until locked. It resumes an interrupted encryption with the same
+	// passphrase.
+	WalletEncrypt(ctx context.Context, passphrase string) error //perm:admin
+	// WalletUnlock decrypts the keys of the wallet with a passphrase, until locked or until the
+	// timeout passes when positive. A locked wallet can't sign nor add keys.
+	WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error //perm:admin
+	// WalletLock forgets the decrypted keys of the wallet.
+	WalletLock(ctx context.Context) error //perm:admin
+	// WalletLockStatus returns whether the keys of the wallet are encrypted and locked.
+	WalletLockStatus(ctx context.Context) (*LockStatus, error) //perm:admin
 
 	// Other
 
@@ -427,6 +540,15 @@ type FullNode interface {
 	// message is not applied on-top-of the messages in the passed-in
 	// tipset.
 	StateCall(context.Context, *types.Message, types.TipSetKey) (*InvocResult, error) //perm:read
+	// StateCallWithOverrides is like StateCall, but applies the given actor
+	// overrides to a temporary copy of the state before executing the message,
+	// eg. to simulate a call from an account which holds no funds yet.
+	// Overridden state heads must be in the node's blockstore.
+	StateCallWithOverrides(context.Context, *types.Message, types.TipSetKey, map[address.Address]ActorOverride) (*InvocResult, error) //perm:read
+	// StateCallBatch applies the given messages in order, like StateCall, to a
+	// single copy of the tipset's parent state, so that each message observes
+	// the effects of the previous ones.
+	StateCallBatch(context.Context, []*types.Message, types.TipSetKey) ([]*InvocResult, error) //perm:read
 	// StateReplay replays a given message, assuming it was included in a block in the specified tipset.
 	//
 	// If a tipset key is provided, and a replacing message is not found on chain,
@@ -445,6 +567,27 @@ type FullNode interface {
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
 	StateReplay(context.Context, types.TipSetKey, cid.Cid) (*InvocResult, error) //perm:read
+	// StateReplayEx replays a given message like StateReplay, with the given
+	// options; it can return the execution trace as a flat list of call frames,
+	// with parameters and return values decoded, and replay the message with
+	// some of its fields overridden.
+	StateReplayEx(context.Context, types.TipSetKey, cid.Cid, ReplayOptions) (*ReplayResult, error) //perm:read
+	// StateGasProfile breaks the gas charged while executing the given tipset down
+	// by receiver actor code and method.
+	StateGasProfile(context.Context, types.TipSetKey) (*GasProfile, error) //perm:read
+	// StateImplicitTrace returns the invocation results of the implicit messages
+	// (block rewards and cron ticks) applied while executing the given tipset, in
+	// execution order.
+	StateImplicitTrace(context.Context, types.TipSetKey) ([]*InvocResult, error) //perm:read
+	// StateCronTrace returns the invocation results of the cron ticks run while
+	// executing the given tipset: one per epoch since its parent, including null
+	// rounds, the last one being the tick for the epoch of the tipset itself.
+	StateCronTrace(context.Context, types.TipSetKey) ([]*InvocResult, error) //perm:read
+	// StateGasStats returns the gas usage and fee statistics of every tipset on
+	// the chain ending at the given tipset, from the given height, ordered by
+	// height. Tipsets whose state isn't known are executed, so the range is
+	// limited to a day of epochs.
+	StateGasStats(ctx context.Context, from abi.ChainEpoch, to types.TipSetKey) ([]*EpochGasStats, error) //perm:read
 	// StateGetActor returns the indicated actor's nonce and balance.
 	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) //perm:read
 	// StateReadState returns the indicated actor's state.
@@ -518,6 +661,10 @@ type FullNode interface {
 	// A replacing message is a message with a different CID, any of Gas values, and
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
+	//
+	// MsgLookup.Replaced is set when a replacing message is returned. When the message
+	// with the same sender and nonce found on chain isn't a replacing message, or
+	// `allowReplaced` is false, an error naming it is returned.
 	StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*MsgLookup, error) //perm:read
 	// StateWaitMsg looks back up to limit epochs in the chain for a message.
 	// If not found, it blocks until the message arrives on chain, and gets to the
@@ -537,6 +684,10 @@ type FullNode interface {
 	// A replacing message is a message with a different CID, any of Gas values, and
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
+	//
+	// MsgLookup.Replaced is set when a replacing message is returned. When the message
+	// with the same sender and nonce found on chain isn't a replacing message, or
+	// `allowReplaced` is false, an error naming it is returned.
 	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*MsgLookup, error) //perm:read
 	// StateListMiners returns the addresses of every miner that has claimed power in the Power Actor
 	StateListMiners(context.Context, types.TipSetKey) ([]address.Address, error) //perm:read
@@ -609,6 +760,13 @@ type FullNode interface {
 	// Messages in the `apply` parameter must have the correct nonces, and gas
 	// values set.
 	StateCompute(context.Context, abi.ChainEpoch, []*types.Message, types.TipSetKey) (*ComputeStateOutput, error) //perm:read
+	// StateComputeWithDiff is like StateCompute, but also returns the actors created, modified or
+	// deleted by the messages in the `apply` set, relative to the state they were applied on.
+	StateComputeWithDiff(context.Context, abi.ChainEpoch, []*types.Message, types.TipSetKey) (*ComputeStateDiffOutput, error) //perm:read
+	// StatePurgePersistentCache drops every tipset state kept in the persistent state cache (see
+	// Chainstore.PersistentStateCacheSize in the node config). It does nothing if the persistent
+	// cache is disabled.
+	StatePurgePersistentCache(context.Context) error //perm:admin
 	// StateVerifierStatus returns the data cap for the given address.
 	// Returns nil if there is no entry in the data cap table for the
 	// address.
@@ -629,6 +787,16 @@ type FullNode interface {
 	// StateVMCirculatingSupplyInternal returns an approximation of the circulating supply of Filecoin at the given tipset.
 	// This is the value reported by the runtime interface to actors code.
 	StateVMCirculatingSupplyInternal(context.Context, types.TipSetKey) (CirculatingSupply, error) //perm:read
+	// StateCirculatingSupplyRange returns the detailed circulating supply, as
+	// StateVMCirculatingSupplyInternal computes it, of the tipsets of the chain
+	// ending at the given tipset, from the given height, sampled every interval
+	// epochs. When a sampled height is a null round, the tipset before it is
+	// used. At most 10000 samples are returned per call.
+	StateCirculatingSupplyRange(ctx context.Context, from abi.ChainEpoch, to types.TipSetKey, interval abi.ChainEpoch) ([]CirculatingSupplyAt, error) //perm:read
+
+	// StateBackfillStatus returns the progress of the archival state backfill, including the
+	// earliest epoch from which the state is available, whether or not the backfill is enabled.
+	StateBackfillStatus(context.Context) (*StateBackfillStatus, error) //perm:read
 	// StateNetworkVersion returns the network version at the given tipset
 	StateNetworkVersion(context.Context, types.TipSetKey) (apitypes.NetworkVersion, error) //perm:read
 	// StateActorCodeCIDs returns the CIDs of all the builtin actors for the given network version
@@ -646,6 +814,10 @@ type FullNode interface {
 	// becomes available
 	StateGetBeaconEntry(ctx context.Context, epoch abi.ChainEpoch) (*types.BeaconEntry, error) //perm:read
 
+	// StateBeaconStatus returns the status of the drand beacons of the beacon schedule: their
+	// groups of endpoints, their failures, and which group serves randomness.
+	StateBeaconStatus(context.Context) ([]BeaconStatus, error) //perm:read
+
 	// StateGetNetworkParams return current network params
 	StateGetNetworkParams(ctx context.Context) (*NetworkParams, error) //perm:read
 
@@ -780,6 +952,15 @@ type FullNode interface {
 
 	NodeStatus(ctx context.Context, inclChainStatus bool) (NodeStatus, error) //perm:read
 
+	// NetPubsubTraceStatus returns the status of the trace of pubsub message deliveries,
+	// duplicates and rejections.
+	NetPubsubTraceStatus(context.Context) (*tracer.DeliveryTraceStatus, error) //perm:read
+	// NetPubsubTraceStart starts tracing pubsub deliveries to a file on the node or a remote
+	// collector, replacing the current trace.
+	NetPubsubTraceStart(context.Context, tracer.DeliveryTraceConfig) (*tracer.DeliveryTraceStatus, error) //perm:admin
+	// NetPubsubTraceStop stops the pubsub delivery trace.
+	NetPubsubTraceStop(context.Context) (*tracer.DeliveryTraceStatus, error) //perm:admin
+
 	// MethodGroup: Eth
 	// These methods are used for Ethereum-compatible JSON-RPC calls
 	//
@@ -953,6 +1134,8 @@ type MsgLookup struct {
 	ReturnDec interface{}
 	TipSet    types.TipSetKey
 	Height    abi.ChainEpoch
+	// Replaced is set when Message is a replacing message rather than the requested one
+	Replaced bool
 }
 
 type MsgGasCost struct {
@@ -1131,6 +1314,15 @@ type RetrievalOrder struct {
 
 type RemoteStoreID = uuid.UUID
 
+// ActorOverride replaces fields of an actor for the duration of a call. Unset fields are left as
+// they are. Overriding a BLS or secp256k1 address which has no actor yet creates an account actor
+// for it.
+type ActorOverride struct {
+	Balance *abi.TokenAmount
+	Nonce   *uint64
+	Head    *cid.Cid
+}
+
 type InvocResult struct {
 	MsgCid         cid.Cid
 	Msg            *types.Message
@@ -1139,6 +1331,113 @@ type InvocResult struct {
 	ExecutionTrace types.ExecutionTrace
 	Error          string
 	Duration       time.Duration
+
+	// Truncated is set when nodes were omitted from ExecutionTrace to keep the trace within the
+	// node's limit (Chainstore.ExecutionTraceMaxNodes), OmittedNodes being their number.
+	Truncated    bool `json:",omitempty"`
+	OmittedNodes int  `json:",omitempty"`
+}
+
+// TraceFrame is a single call of an execution trace, flattened in call (depth-first) order. The
+// frames of a message start with its top-level call, at depth 0; a call at depth n is made by the
+// closest preceding frame at depth n-1.
+type TraceFrame struct {
+	// MsgCid is the CID of the message the call belongs to.
+	MsgCid cid.Cid
+	// Depth is the call depth, 0 for the message itself.
+	Depth int
+
+	From   address.Address
+	To     address.Address
+	Value  abi.TokenAmount
+	Method abi.MethodNum
+	// ToCode is the code CID of the receiver, if it still exists after execution.
+	ToCode cid.Cid
+
+	// Params holds the raw parameters, encoded with the IPLD codec ParamsCodec. ParamsDecoded
+	// holds them as JSON, if the receiver and method are known; it is null otherwise.
+	Params        []byte
+	ParamsCodec   uint64
+	ParamsDecoded json.RawMessage
+
+	ExitCode exitcode.ExitCode
+	// Return and ReturnDecoded hold the return value, like Params and ParamsDecoded.
+	Return        []byte
+	ReturnCodec   uint64
+	ReturnDecoded json.RawMessage
+
+	// GasCharged is the gas charged within this call, excluding its subcalls. It is only known
+	// for traces computed with gas tracing enabled.
+	GasCharged int64
+}
+
+// EpochGasStats summarizes the gas usage and fees of the messages included in a tipset.
+type EpochGasStats struct {
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+	Blocks int
+	// BaseFee is the base fee paid by the messages of the tipset.
+	BaseFee abi.TokenAmount
+
+	// Messages is the number of distinct messages included in the tipset.
+	Messages int
+	GasUsed  int64
+	GasLimit int64
+	// Utilization is GasLimit over the block gas limit of all the blocks of the tipset. This is
+	// what the base fee reacts to.
+	Utilization float64
+	// OverEstimation is GasLimit over GasUsed, or zero if no gas was used.
+	OverEstimation float64
+
+	// MessagesByActor counts the messages by the name of the receiver's actor code.
+	MessagesByActor map[string]int
+}
+
+// GasProfileEntry is the gas charged by all calls of a method of an actor code.
+type GasProfileEntry struct {
+	// Code is the receiver's code CID, or cid.Undef for receivers which no longer exist.
+	Code       cid.Cid
+	Method     abi.MethodNum
+	Calls      int
+	GasCharged int64
+}
+
+// GasProfile breaks the gas charged while executing a tipset down by actor code and method.
+type GasProfile struct {
+	TipSet types.TipSetKey
+	// TotalGasCharged is the sum of the gas charged by all entries.
+	TotalGasCharged int64
+	// Entries are ordered by decreasing gas charged.
+	Entries []GasProfileEntry
+}
+
+// TraceFormatFrames selects, in ReplayOptions, traces returned as a list of
+// TraceFrame.
+const TraceFormatFrames = "frames"
+
+type ReplayOptions struct {
+	// TraceFormat is the format of the returned execution trace: empty for the
+	// ExecutionTrace tree of the InvocResult, or TraceFormatFrames.
+	TraceFormat string
+	// Overrides, if set, modifies the replayed message; the messages preceding it
+	// in the tipset are applied as they were.
+	Overrides *MessageOverrides `json:",omitempty"`
+}
+
+// MessageOverrides modifies a message replayed by StateReplayEx. Unset fields are left as
+// they are; a non-nil Params replaces the parameters, even if empty.
+type MessageOverrides struct {
+	GasLimit  *int64
+	GasFeeCap *abi.TokenAmount
+	Value     *abi.TokenAmount
+	Params    []byte
+}
+
+type ReplayResult struct {
+	InvocResult *InvocResult
+	// Frames holds the execution trace when TraceFormatFrames is requested; the
+	// ExecutionTrace of InvocResult is then left empty.
+	Frames []TraceFrame `json:",omitempty"`
 }
 
 type MethodCall struct {
@@ -1190,6 +1489,15 @@ type ActiveSync struct {
 	Start   time.Time
 	End     time.Time
 	Message string
+
+	// EpochsPerSec is the recent rate of tipset execution, and ETA the time
+	// to reach the target at that rate; both are zero until it can be estimated.
+	EpochsPerSec float64
+	ETA          time.Duration
+	// ValidationTime and ExecutionTime split the time spent processing
+	// tipsets between validating them and computing their parent states.
+	ValidationTime time.Duration
+	ExecutionTime  time.Duration
 }
 
 type SyncState struct {
@@ -1198,6 +1506,18 @@ type SyncState struct {
 	VMApplied uint64
 }
 
+// ComputeProgress describes how far the execution of a tipset has progressed.
+// Implicit messages (rewards and cron) count towards GasUsed, but not towards
+// MessagesApplied.
+type ComputeProgress struct {
+	TipSet          types.TipSetKey
+	Height          abi.ChainEpoch
+	Started         time.Time
+	MessagesApplied int
+	MessagesTotal   int
+	GasUsed         int64
+}
+
 type SyncStateStage int
 
 const (
@@ -1243,9 +1563,70 @@ type MpoolUpdate struct {
 	Message *types.SignedMessage
 }
 
+// FeeHistory is the fee market history of a range of recent tipsets, oldest first.
+type FeeHistory struct {
+	Epochs []abi.ChainEpoch
+	// BaseFees are the base fees the messages of the tipsets were charged.
+	BaseFees []abi.TokenAmount
+	// GasLimitRatios are the ratios of the gas limit of the messages of the tipsets to the
+	// gas limit of their blocks.
+	GasLimitRatios []float64
+	// Premiums are the requested percentiles of the gas premiums of the messages of the
+	// tipsets, weighted by their gas limits.
+	Premiums [][]abi.TokenAmount
+}
+
+// BaseFeeForecast is the projection of the base fee over the next epochs, assuming the gas
+// limit of the blocks follows its recent trend.
+type BaseFeeForecast struct {
+	Epochs   []abi.ChainEpoch
+	BaseFees []abi.TokenAmount
+	// GasPerBlock is the gas limit per block assumed at each epoch.
+	GasPerBlock []int64
+}
+
+// GasPriceOracle recommends gas premiums for a low, medium and high inclusion speed, from the
+// gas premiums recent tipsets included messages at.
+type GasPriceOracle struct {
+	// Epoch is the epoch of the heaviest tipset the recommendations were computed at.
+	Epoch abi.ChainEpoch
+	// BaseFee is the base fee of the next epoch.
+	BaseFee abi.TokenAmount
+	Low     GasPremiumRecommendation
+	Medium  GasPremiumRecommendation
+	High    GasPremiumRecommendation
+}
+
+// GasPremiumRecommendation is a gas premium and the number of epochs a message paying it is
+// expected to wait before being included, provided its fee cap covers the base fee.
+type GasPremiumRecommendation struct {
+	GasPremium     abi.TokenAmount
+	InclusionDelay float64
+}
+
 type ComputeStateOutput struct {
 	Root  cid.Cid
 	Trace []*InvocResult
+
+	// Truncated is set when nodes were omitted from the execution traces in Trace, OmittedNodes
+	// being their total number.
+	Truncated    bool `json:",omitempty"`
+	OmittedNodes int  `json:",omitempty"`
+}
+
+type ComputeStateDiffOutput struct {
+	ComputeStateOutput
+
+	// Changes are the actors created, modified or deleted by the applied messages.
+	Changes []ActorChange
+}
+
+// ActorChange is an actor which differs between two states. Old is nil for actors created in the
+// new state, and New is nil for actors deleted from it.
+type ActorChange struct {
+	Address address.Address
+	Old     *types.Actor
+	New     *types.Actor
 }
 
 type DealCollateralBounds struct {
@@ -1262,6 +1643,14 @@ type CirculatingSupply struct {
 	FilReserveDisbursed abi.TokenAmount
 }
 
+// CirculatingSupplyAt is the detailed circulating supply at a tipset, computed from its parent
+// state as StateVMCirculatingSupplyInternal does.
+type CirculatingSupplyAt struct {
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+	CirculatingSupply
+}
+
 type MiningBaseInfo struct {
 	MinerPower        types.BigInt
 	NetworkPower      types.BigInt
@@ -1368,6 +1757,185 @@ type HotGCOpts struct {
 	Moving    bool
 }
 
+// MpoolUpdateFilter selects the mpool updates of the messages matching all its non-empty
+// criteria; an empty filter matches every message.
+type MpoolUpdateFilter struct {
+	// From matches the messages sent by any of these addresses.
+	From []address.Address
+	// To matches the messages sent to any of these addresses, as written in the messages.
+	To []address.Address
+	// Methods matches the messages calling any of these methods.
+	Methods []abi.MethodNum
+}
+
+// LocalMessageRecord is the history of a nonce of a local sender: the messages the node pushed
+// for it and their outcome on chain.
+type LocalMessageRecord struct {
+	From  address.Address
+	Nonce uint64
+	// Attempts are the messages pushed for the nonce, in push order, so all but the last one
+	// were replaced.
+	Attempts []LocalMessageAttempt
+	// Outcome is the outcome on chain of the nonce, nil until it is known.
+	Outcome *LocalMessageOutcome
+}
+
+// LocalMessageAttempt is a message pushed by the node.
+type LocalMessageAttempt struct {
+	Cid     cid.Cid
+	Message *types.SignedMessage
+	Pushed  time.Time
+}
+
+// LocalMessageOutcome is the outcome on chain of a nonce of a local sender.
+type LocalMessageOutcome struct {
+	// Cid is the message which landed, undefined when the nonce was used by a message which
+	// couldn't be found, for example one pushed through another node.
+	Cid cid.Cid
+	// TipSet is the tipset the message was executed in.
+	TipSet   types.TipSetKey
+	Epoch    abi.ChainEpoch
+	ExitCode exitcode.ExitCode
+	GasUsed  int64
+}
+
+// Landed returns the attempt which landed on chain, or nil when none did.
+func (r *LocalMessageRecord) Landed() *LocalMessageAttempt {
+	if r.Outcome == nil || !r.Outcome.Cid.Defined() {
+		return nil
+	}
+	for i := range r.Attempts {
+		if r.Attempts[i].Cid == r.Outcome.Cid {
+			return &r.Attempts[i]
+		}
+	}
+	return nil
+}
+
+// ScheduledMessage is a signed message pushed once the chain reaches an epoch.
+type ScheduledMessage struct {
+	Cid     cid.Cid
+	Message *types.SignedMessage
+	// Epoch is the epoch at or after which the message is pushed.
+	Epoch     abi.ChainEpoch
+	Scheduled time.Time
+	// LastError is the error of the last attempt to push the message, if it failed; the push is
+	// retried at every epoch until a finality after Epoch.
+	LastError string `json:",omitempty"`
+}
+
+// NonceGaps are the gaps between the state nonce of a sender and its pending messages.
+type NonceGaps struct {
+	StateNonce uint64
+	Gaps       []NonceGap
+}
+
+// NonceGap is a range of nonces, from Start up to End excluded, missing from the pending
+// messages of a sender, preventing the messages after it from being included on chain.
+type NonceGap struct {
+	Start uint64
+	End   uint64
+}
+
+// RBFPolicy is the policy a message must satisfy to replace a pending message with the same
+// nonce (replace-by-fee). The gas premium of the replacement must be at least the greatest of
+// MinPercent percent of the replaced premium plus one, and the replaced premium plus MinIncrease.
+type RBFPolicy struct {
+	MinPercent types.Percent
+	// MinIncrease is in attoFIL per gas unit
+	MinIncrease abi.TokenAmount
+}
+
+// TrustedCheckpoint is a tipset the chain must go through at its epoch. Unlike the checkpoint set
+// with SyncCheckpoint, any number of trusted checkpoints can be set, at any epoch, including ahead
+// of the current head, and they are enforced by the syncer when collecting new chains.
+type TrustedCheckpoint struct {
+	Epoch  abi.ChainEpoch
+	TipSet types.TipSetKey
+}
+
+// ChainImportStatus reports an online chain import.
+type ChainImportStatus struct {
+	Source   string
+	Running  bool
+	Started  time.Time
+	Finished time.Time       `json:",omitempty"`
+	TipSet   types.TipSetKey `json:",omitempty"`
+	Height   abi.ChainEpoch  `json:",omitempty"`
+	Error    string          `json:",omitempty"`
+
+	Progress *ImportProgress `json:",omitempty"`
+}
+
+// ImportProgress is the progress of a chain import, with its throughput and, when the size of
+// the CAR is known, the estimated remaining time.
+type ImportProgress struct {
+	Running bool
+	Started time.Time
+	// Blocks is the number of blocks verified and written
+	Blocks int64
+	// Bytes is the number of bytes of the CAR read
+	Bytes int64
+	// Size is the size of the CAR, when known
+	Size int64 `json:",omitempty"`
+
+	BytesPerSec  float64
+	BlocksPerSec float64
+	ETA          time.Duration `json:",omitempty"`
+}
+
+type ChainGCOpts struct {
+	// fraction of garbage in a value log file above which it is rewritten; defaults to 0.01
+	Threshold float64
+	// pause between value log rewrites
+	Throttle time.Duration
+}
+
+// ChainGCStatus reports the progress of an online chainstore GC.
+type ChainGCStatus struct {
+	Running   bool
+	Started   time.Time
+	Finished  time.Time `json:",omitempty"`
+	Threshold float64
+	Throttle  time.Duration
+	// whether the store has been compacted; value log GC starts after it
+	Compacted bool
+	// number of value log files rewritten
+	Rewrites int
+	// size of the store before GC and, once finished, after it, when the store reports it
+	SizeBefore int64  `json:",omitempty"`
+	SizeAfter  int64  `json:",omitempty"`
+	Error      string `json:",omitempty"`
+}
+
+// BeaconStatus is the status of the drand beacon of a point of the beacon schedule.
+type BeaconStatus struct {
+	Start  abi.ChainEpoch
+	Groups []BeaconGroupStatus
+}
+
+// BeaconGroupStatus is the health of a group of drand endpoints.
+type BeaconGroupStatus struct {
+	Servers []string
+	Active  bool
+
+	Failures    uint64    // requests the group failed to serve
+	LastError   string    `json:",omitempty"`
+	LastErrorAt time.Time `json:",omitempty"`
+}
+
+// StateBackfillStatus reports the progress of the archival state backfill.
+type StateBackfillStatus struct {
+	Running bool
+	// EarliestState is the height of the lowest tipset whose parent state is available; all
+	// the tipsets above it have theirs
+	EarliestState abi.ChainEpoch
+	Started       time.Time `json:",omitempty"`
+	// number of epochs backfilled since the backfill started
+	Epochs int64
+	Error  string `json:",omitempty"`
+}
+
 type EthTxReceipt struct {
 	TransactionHash   ethtypes.EthHash     `json:"transactionHash"`
 	TransactionIndex  ethtypes.EthUint64   `json:"transactionIndex"`
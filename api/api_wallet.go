@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"time"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/crypto"
@@ -44,4 +45,49 @@ type Wallet interface {
 	WalletExport(context.Context, address.Address) (*types.KeyInfo, error) //perm:admin
 	WalletImport(context.Context, *types.KeyInfo) (address.Address, error) //perm:admin
 	WalletDelete(context.Context, address.Address) error                   //perm:admin
+
+	// WalletHDNew generates a mnemonic of the given number of words, makes its seed, protected
+	// by the passphrase, the HD seed of the wallet and adds the address at index 0. The mnemonic
+	// isn't stored and is the only way to restore the addresses.
+	WalletHDNew(ctx context.Context, words int, passphrase string) (*HDMnemonic, error) //perm:admin
+	// WalletHDDerive adds the address at an index to the wallet; a negative index adds the
+	// lowest index which isn't in the wallet yet.
+	WalletHDDerive(ctx context.Context, index int64) (*HDKey, error) //perm:admin
+	// WalletHDRestore makes the seed of a mnemonic the HD seed of the wallet and adds the
+	// addresses at the first count indexes.
+	WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]HDKey, error) //perm:admin
+
+	// WalletEncrypt encrypts the keys of the wallet with a passphrase, and leaves it unlocked
+	// until locked. It resumes an interrupted encryption with the same passphrase.
+	WalletEncrypt(ctx context.Context, passphrase string) error //perm:admin
+	// WalletUnlock decrypts the keys of the wallet with a passphrase, until locked or until the
+	// timeout passes when positive.
+	WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error //perm:admin
+	// WalletLock forgets the decrypted keys of the wallet.
+	WalletLock(ctx context.Context) error //perm:admin
+	// WalletLockStatus returns whether the keys of the wallet are encrypted and locked.
+	WalletLockStatus(ctx context.Context) (*LockStatus, error) //perm:admin
+}
+
+// LockStatus is the encryption state of a wallet keystore. An encrypted wallet starts locked,
+// and can't sign nor add keys until unlocked.
+type LockStatus struct {
+	Encrypted bool
+	Locked    bool
+	// LockAt is when the wallet locks itself, zero when it stays unlocked until locked.
+	LockAt time.Time
+}
+
+// HDKey is an address derived from the HD seed of a wallet, along the Filecoin BIP44 path
+// m/44'/461'/0'/0/index.
+type HDKey struct {
+	Address address.Address
+	Path    string
+}
+
+// HDMnemonic is a BIP39 mnemonic generated as the HD seed of a wallet, with the address at
+// index 0.
+type HDMnemonic struct {
+	Mnemonic string
+	Key      *HDKey
 }
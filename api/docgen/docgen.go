@@ -405,6 +405,14 @@ func init() {
 	percent := types.Percent(123)
 	addExample(percent)
 	addExample(&percent)
+
+	gasLimit := int64(9)
+	addExample(&gasLimit)
+
+	overrideBalance, overrideNonce := types.NewInt(1000), uint64(42)
+	addExample(map[address.Address]api.ActorOverride{
+		addr: {Balance: &overrideBalance, Nonce: &overrideNonce, Head: &c},
+	})
 }
 
 func GetAPIType(name, pkg string) (i interface{}, t reflect.Type, permStruct []reflect.Type) {
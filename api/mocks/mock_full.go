@@ -169,6 +169,51 @@ func (mr *MockFullNodeMockRecorder) ChainExportRangeInternal(arg0, arg1, arg2, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExportRangeInternal", reflect.TypeOf((*MockFullNode)(nil).ChainExportRangeInternal), arg0, arg1, arg2, arg3)
 }
 
+// ChainGC mocks base method.
+func (m *MockFullNode) ChainGC(arg0 context.Context, arg1 api.ChainGCOpts) (*api.ChainGCStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGC", arg0, arg1)
+	ret0, _ := ret[0].(*api.ChainGCStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGC indicates an expected call of ChainGC.
+func (mr *MockFullNodeMockRecorder) ChainGC(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGC", reflect.TypeOf((*MockFullNode)(nil).ChainGC), arg0, arg1)
+}
+
+// ChainGCCancel mocks base method.
+func (m *MockFullNode) ChainGCCancel(arg0 context.Context) (*api.ChainGCStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGCCancel", arg0)
+	ret0, _ := ret[0].(*api.ChainGCStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGCCancel indicates an expected call of ChainGCCancel.
+func (mr *MockFullNodeMockRecorder) ChainGCCancel(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGCCancel", reflect.TypeOf((*MockFullNode)(nil).ChainGCCancel), arg0)
+}
+
+// ChainGCStatus mocks base method.
+func (m *MockFullNode) ChainGCStatus(arg0 context.Context) (*api.ChainGCStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGCStatus", arg0)
+	ret0, _ := ret[0].(*api.ChainGCStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGCStatus indicates an expected call of ChainGCStatus.
+func (mr *MockFullNodeMockRecorder) ChainGCStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGCStatus", reflect.TypeOf((*MockFullNode)(nil).ChainGCStatus), arg0)
+}
+
 // ChainGetBlock mocks base method.
 func (m *MockFullNode) ChainGetBlock(arg0 context.Context, arg1 cid.Cid) (*types.BlockHeader, error) {
 	m.ctrl.T.Helper()
@@ -408,6 +453,36 @@ func (mr *MockFullNodeMockRecorder) ChainHotGC(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainHotGC", reflect.TypeOf((*MockFullNode)(nil).ChainHotGC), arg0, arg1)
 }
 
+// ChainImportOnline mocks base method.
+func (m *MockFullNode) ChainImportOnline(arg0 context.Context, arg1 string) (*api.ChainImportStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainImportOnline", arg0, arg1)
+	ret0, _ := ret[0].(*api.ChainImportStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainImportOnline indicates an expected call of ChainImportOnline.
+func (mr *MockFullNodeMockRecorder) ChainImportOnline(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainImportOnline", reflect.TypeOf((*MockFullNode)(nil).ChainImportOnline), arg0, arg1)
+}
+
+// ChainImportStatus mocks base method.
+func (m *MockFullNode) ChainImportStatus(arg0 context.Context) (*api.ChainImportStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainImportStatus", arg0)
+	ret0, _ := ret[0].(*api.ChainImportStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainImportStatus indicates an expected call of ChainImportStatus.
+func (mr *MockFullNodeMockRecorder) ChainImportStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainImportStatus", reflect.TypeOf((*MockFullNode)(nil).ChainImportStatus), arg0)
+}
+
 // ChainNotify mocks base method.
 func (m *MockFullNode) ChainNotify(arg0 context.Context) (<-chan []*api.HeadChange, error) {
 	m.ctrl.T.Helper()
@@ -1521,6 +1596,21 @@ func (mr *MockFullNodeMockRecorder) FilecoinAddressToEthAddress(arg0, arg1 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilecoinAddressToEthAddress", reflect.TypeOf((*MockFullNode)(nil).FilecoinAddressToEthAddress), arg0, arg1)
 }
 
+// GasBaseFeeForecast mocks base method.
+func (m *MockFullNode) GasBaseFeeForecast(arg0 context.Context, arg1 int, arg2 int) (*api.BaseFeeForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GasBaseFeeForecast", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.BaseFeeForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GasBaseFeeForecast indicates an expected call of GasBaseFeeForecast.
+func (mr *MockFullNodeMockRecorder) GasBaseFeeForecast(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasBaseFeeForecast", reflect.TypeOf((*MockFullNode)(nil).GasBaseFeeForecast), arg0, arg1, arg2)
+}
+
 // GasEstimateFeeCap mocks base method.
 func (m *MockFullNode) GasEstimateFeeCap(arg0 context.Context, arg1 *types.Message, arg2 int64, arg3 types.TipSetKey) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -1581,6 +1671,36 @@ func (mr *MockFullNodeMockRecorder) GasEstimateMessageGas(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasEstimateMessageGas", reflect.TypeOf((*MockFullNode)(nil).GasEstimateMessageGas), arg0, arg1, arg2, arg3)
 }
 
+// GasFeeHistory mocks base method.
+func (m *MockFullNode) GasFeeHistory(arg0 context.Context, arg1 int, arg2 []float64) (*api.FeeHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GasFeeHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.FeeHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GasFeeHistory indicates an expected call of GasFeeHistory.
+func (mr *MockFullNodeMockRecorder) GasFeeHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasFeeHistory", reflect.TypeOf((*MockFullNode)(nil).GasFeeHistory), arg0, arg1, arg2)
+}
+
+// GasPriceOracle mocks base method.
+func (m *MockFullNode) GasPriceOracle(arg0 context.Context, arg1 int) (*api.GasPriceOracle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GasPriceOracle", arg0, arg1)
+	ret0, _ := ret[0].(*api.GasPriceOracle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GasPriceOracle indicates an expected call of GasPriceOracle.
+func (mr *MockFullNodeMockRecorder) GasPriceOracle(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasPriceOracle", reflect.TypeOf((*MockFullNode)(nil).GasPriceOracle), arg0, arg1)
+}
+
 // ID mocks base method.
 func (m *MockFullNode) ID(arg0 context.Context) (peer.ID, error) {
 	m.ctrl.T.Helper()
@@ -1789,6 +1909,20 @@ func (mr *MockFullNodeMockRecorder) MpoolBatchPushUntrusted(arg0, arg1 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolBatchPushUntrusted", reflect.TypeOf((*MockFullNode)(nil).MpoolBatchPushUntrusted), arg0, arg1)
 }
 
+// MpoolCancelScheduled mocks base method.
+func (m *MockFullNode) MpoolCancelScheduled(arg0 context.Context, arg1 cid.Cid) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolCancelScheduled", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MpoolCancelScheduled indicates an expected call of MpoolCancelScheduled.
+func (mr *MockFullNodeMockRecorder) MpoolCancelScheduled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolCancelScheduled", reflect.TypeOf((*MockFullNode)(nil).MpoolCancelScheduled), arg0, arg1)
+}
+
 // MpoolCheckMessages mocks base method.
 func (m *MockFullNode) MpoolCheckMessages(arg0 context.Context, arg1 []*api.MessagePrototype) ([][]api.MessageCheckStatus, error) {
 	m.ctrl.T.Helper()
@@ -1848,6 +1982,21 @@ func (mr *MockFullNodeMockRecorder) MpoolClear(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolClear", reflect.TypeOf((*MockFullNode)(nil).MpoolClear), arg0, arg1)
 }
 
+// MpoolClearPersistedLocal mocks base method.
+func (m *MockFullNode) MpoolClearPersistedLocal(arg0 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolClearPersistedLocal", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolClearPersistedLocal indicates an expected call of MpoolClearPersistedLocal.
+func (mr *MockFullNodeMockRecorder) MpoolClearPersistedLocal(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolClearPersistedLocal", reflect.TypeOf((*MockFullNode)(nil).MpoolClearPersistedLocal), arg0)
+}
+
 // MpoolGetConfig mocks base method.
 func (m *MockFullNode) MpoolGetConfig(arg0 context.Context) (*types.MpoolConfig, error) {
 	m.ctrl.T.Helper()
@@ -1878,6 +2027,51 @@ func (mr *MockFullNodeMockRecorder) MpoolGetNonce(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolGetNonce", reflect.TypeOf((*MockFullNode)(nil).MpoolGetNonce), arg0, arg1)
 }
 
+// MpoolGetRBFPolicy mocks base method.
+func (m *MockFullNode) MpoolGetRBFPolicy(arg0 context.Context, arg1 address.Address) (*api.RBFPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolGetRBFPolicy", arg0, arg1)
+	ret0, _ := ret[0].(*api.RBFPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolGetRBFPolicy indicates an expected call of MpoolGetRBFPolicy.
+func (mr *MockFullNodeMockRecorder) MpoolGetRBFPolicy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolGetRBFPolicy", reflect.TypeOf((*MockFullNode)(nil).MpoolGetRBFPolicy), arg0, arg1)
+}
+
+// MpoolHistory mocks base method.
+func (m *MockFullNode) MpoolHistory(arg0 context.Context, arg1 address.Address) ([]*api.LocalMessageRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolHistory", arg0, arg1)
+	ret0, _ := ret[0].([]*api.LocalMessageRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolHistory indicates an expected call of MpoolHistory.
+func (mr *MockFullNodeMockRecorder) MpoolHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolHistory", reflect.TypeOf((*MockFullNode)(nil).MpoolHistory), arg0, arg1)
+}
+
+// MpoolNonceGaps mocks base method.
+func (m *MockFullNode) MpoolNonceGaps(arg0 context.Context, arg1 address.Address) (*api.NonceGaps, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolNonceGaps", arg0, arg1)
+	ret0, _ := ret[0].(*api.NonceGaps)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolNonceGaps indicates an expected call of MpoolNonceGaps.
+func (mr *MockFullNodeMockRecorder) MpoolNonceGaps(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolNonceGaps", reflect.TypeOf((*MockFullNode)(nil).MpoolNonceGaps), arg0, arg1)
+}
+
 // MpoolPending mocks base method.
 func (m *MockFullNode) MpoolPending(arg0 context.Context, arg1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1893,6 +2087,21 @@ func (mr *MockFullNodeMockRecorder) MpoolPending(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPending", reflect.TypeOf((*MockFullNode)(nil).MpoolPending), arg0, arg1)
 }
 
+// MpoolPersistedLocal mocks base method.
+func (m *MockFullNode) MpoolPersistedLocal(arg0 context.Context) ([]*types.SignedMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolPersistedLocal", arg0)
+	ret0, _ := ret[0].([]*types.SignedMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolPersistedLocal indicates an expected call of MpoolPersistedLocal.
+func (mr *MockFullNodeMockRecorder) MpoolPersistedLocal(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPersistedLocal", reflect.TypeOf((*MockFullNode)(nil).MpoolPersistedLocal), arg0)
+}
+
 // MpoolPush mocks base method.
 func (m *MockFullNode) MpoolPush(arg0 context.Context, arg1 *types.SignedMessage) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -1938,6 +2147,36 @@ func (mr *MockFullNodeMockRecorder) MpoolPushUntrusted(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPushUntrusted", reflect.TypeOf((*MockFullNode)(nil).MpoolPushUntrusted), arg0, arg1)
 }
 
+// MpoolSchedule mocks base method.
+func (m *MockFullNode) MpoolSchedule(arg0 context.Context, arg1 *types.SignedMessage, arg2 abi.ChainEpoch) (cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolSchedule", arg0, arg1, arg2)
+	ret0, _ := ret[0].(cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolSchedule indicates an expected call of MpoolSchedule.
+func (mr *MockFullNodeMockRecorder) MpoolSchedule(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSchedule", reflect.TypeOf((*MockFullNode)(nil).MpoolSchedule), arg0, arg1, arg2)
+}
+
+// MpoolScheduled mocks base method.
+func (m *MockFullNode) MpoolScheduled(arg0 context.Context) ([]*api.ScheduledMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolScheduled", arg0)
+	ret0, _ := ret[0].([]*api.ScheduledMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolScheduled indicates an expected call of MpoolScheduled.
+func (mr *MockFullNodeMockRecorder) MpoolScheduled(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolScheduled", reflect.TypeOf((*MockFullNode)(nil).MpoolScheduled), arg0)
+}
+
 // MpoolSelect mocks base method.
 func (m *MockFullNode) MpoolSelect(arg0 context.Context, arg1 types.TipSetKey, arg2 float64) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1982,6 +2221,21 @@ func (mr *MockFullNodeMockRecorder) MpoolSub(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSub", reflect.TypeOf((*MockFullNode)(nil).MpoolSub), arg0)
 }
 
+// MpoolSubFiltered mocks base method.
+func (m *MockFullNode) MpoolSubFiltered(arg0 context.Context, arg1 api.MpoolUpdateFilter) (<-chan api.MpoolUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolSubFiltered", arg0, arg1)
+	ret0, _ := ret[0].(<-chan api.MpoolUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolSubFiltered indicates an expected call of MpoolSubFiltered.
+func (mr *MockFullNodeMockRecorder) MpoolSubFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSubFiltered", reflect.TypeOf((*MockFullNode)(nil).MpoolSubFiltered), arg0, arg1)
+}
+
 // MsigAddApprove mocks base method.
 func (m *MockFullNode) MsigAddApprove(arg0 context.Context, arg1, arg2 address.Address, arg3 uint64, arg4, arg5 address.Address, arg6 bool) (*api.MessagePrototype, error) {
 	m.ctrl.T.Helper()
@@ -2561,6 +2815,51 @@ func (mr *MockFullNodeMockRecorder) NetPubsubScores(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPubsubScores", reflect.TypeOf((*MockFullNode)(nil).NetPubsubScores), arg0)
 }
 
+// NetPubsubTraceStart mocks base method.
+func (m *MockFullNode) NetPubsubTraceStart(arg0 context.Context, arg1 tracer.DeliveryTraceConfig) (*tracer.DeliveryTraceStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPubsubTraceStart", arg0, arg1)
+	ret0, _ := ret[0].(*tracer.DeliveryTraceStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPubsubTraceStart indicates an expected call of NetPubsubTraceStart.
+func (mr *MockFullNodeMockRecorder) NetPubsubTraceStart(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPubsubTraceStart", reflect.TypeOf((*MockFullNode)(nil).NetPubsubTraceStart), arg0, arg1)
+}
+
+// NetPubsubTraceStatus mocks base method.
+func (m *MockFullNode) NetPubsubTraceStatus(arg0 context.Context) (*tracer.DeliveryTraceStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPubsubTraceStatus", arg0)
+	ret0, _ := ret[0].(*tracer.DeliveryTraceStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPubsubTraceStatus indicates an expected call of NetPubsubTraceStatus.
+func (mr *MockFullNodeMockRecorder) NetPubsubTraceStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPubsubTraceStatus", reflect.TypeOf((*MockFullNode)(nil).NetPubsubTraceStatus), arg0)
+}
+
+// NetPubsubTraceStop mocks base method.
+func (m *MockFullNode) NetPubsubTraceStop(arg0 context.Context) (*tracer.DeliveryTraceStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPubsubTraceStop", arg0)
+	ret0, _ := ret[0].(*tracer.DeliveryTraceStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPubsubTraceStop indicates an expected call of NetPubsubTraceStop.
+func (mr *MockFullNodeMockRecorder) NetPubsubTraceStop(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPubsubTraceStop", reflect.TypeOf((*MockFullNode)(nil).NetPubsubTraceStop), arg0)
+}
+
 // NetSetLimit mocks base method.
 func (m *MockFullNode) NetSetLimit(arg0 context.Context, arg1 string, arg2 api.NetLimit) error {
 	m.ctrl.T.Helper()
@@ -3008,6 +3307,36 @@ func (mr *MockFullNodeMockRecorder) StateAllMinerFaults(arg0, arg1, arg2 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateAllMinerFaults", reflect.TypeOf((*MockFullNode)(nil).StateAllMinerFaults), arg0, arg1, arg2)
 }
 
+// StateBackfillStatus mocks base method.
+func (m *MockFullNode) StateBackfillStatus(arg0 context.Context) (*api.StateBackfillStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateBackfillStatus", arg0)
+	ret0, _ := ret[0].(*api.StateBackfillStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateBackfillStatus indicates an expected call of StateBackfillStatus.
+func (mr *MockFullNodeMockRecorder) StateBackfillStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateBackfillStatus", reflect.TypeOf((*MockFullNode)(nil).StateBackfillStatus), arg0)
+}
+
+// StateBeaconStatus mocks base method.
+func (m *MockFullNode) StateBeaconStatus(arg0 context.Context) ([]api.BeaconStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateBeaconStatus", arg0)
+	ret0, _ := ret[0].([]api.BeaconStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateBeaconStatus indicates an expected call of StateBeaconStatus.
+func (mr *MockFullNodeMockRecorder) StateBeaconStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateBeaconStatus", reflect.TypeOf((*MockFullNode)(nil).StateBeaconStatus), arg0)
+}
+
 // StateCall mocks base method.
 func (m *MockFullNode) StateCall(arg0 context.Context, arg1 *types.Message, arg2 types.TipSetKey) (*api.InvocResult, error) {
 	m.ctrl.T.Helper()
@@ -3023,6 +3352,36 @@ func (mr *MockFullNodeMockRecorder) StateCall(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCall", reflect.TypeOf((*MockFullNode)(nil).StateCall), arg0, arg1, arg2)
 }
 
+// StateCallBatch mocks base method.
+func (m *MockFullNode) StateCallBatch(arg0 context.Context, arg1 []*types.Message, arg2 types.TipSetKey) ([]*api.InvocResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateCallBatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.InvocResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateCallBatch indicates an expected call of StateCallBatch.
+func (mr *MockFullNodeMockRecorder) StateCallBatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCallBatch", reflect.TypeOf((*MockFullNode)(nil).StateCallBatch), arg0, arg1, arg2)
+}
+
+// StateCallWithOverrides mocks base method.
+func (m *MockFullNode) StateCallWithOverrides(arg0 context.Context, arg1 *types.Message, arg2 types.TipSetKey, arg3 map[address.Address]api.ActorOverride) (*api.InvocResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateCallWithOverrides", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.InvocResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateCallWithOverrides indicates an expected call of StateCallWithOverrides.
+func (mr *MockFullNodeMockRecorder) StateCallWithOverrides(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCallWithOverrides", reflect.TypeOf((*MockFullNode)(nil).StateCallWithOverrides), arg0, arg1, arg2, arg3)
+}
+
 // StateChangedActors mocks base method.
 func (m *MockFullNode) StateChangedActors(arg0 context.Context, arg1, arg2 cid.Cid) (map[string]types.ActorV5, error) {
 	m.ctrl.T.Helper()
@@ -3053,6 +3412,21 @@ func (mr *MockFullNodeMockRecorder) StateCirculatingSupply(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCirculatingSupply", reflect.TypeOf((*MockFullNode)(nil).StateCirculatingSupply), arg0, arg1)
 }
 
+// StateCirculatingSupplyRange mocks base method.
+func (m *MockFullNode) StateCirculatingSupplyRange(arg0 context.Context, arg1 abi.ChainEpoch, arg2 types.TipSetKey, arg3 abi.ChainEpoch) ([]api.CirculatingSupplyAt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateCirculatingSupplyRange", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]api.CirculatingSupplyAt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateCirculatingSupplyRange indicates an expected call of StateCirculatingSupplyRange.
+func (mr *MockFullNodeMockRecorder) StateCirculatingSupplyRange(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCirculatingSupplyRange", reflect.TypeOf((*MockFullNode)(nil).StateCirculatingSupplyRange), arg0, arg1, arg2, arg3)
+}
+
 // StateCompute mocks base method.
 func (m *MockFullNode) StateCompute(arg0 context.Context, arg1 abi.ChainEpoch, arg2 []*types.Message, arg3 types.TipSetKey) (*api.ComputeStateOutput, error) {
 	m.ctrl.T.Helper()
@@ -3083,6 +3457,36 @@ func (mr *MockFullNodeMockRecorder) StateComputeDataCID(arg0, arg1, arg2, arg3,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateComputeDataCID", reflect.TypeOf((*MockFullNode)(nil).StateComputeDataCID), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StateComputeWithDiff mocks base method.
+func (m *MockFullNode) StateComputeWithDiff(arg0 context.Context, arg1 abi.ChainEpoch, arg2 []*types.Message, arg3 types.TipSetKey) (*api.ComputeStateDiffOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateComputeWithDiff", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.ComputeStateDiffOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateComputeWithDiff indicates an expected call of StateComputeWithDiff.
+func (mr *MockFullNodeMockRecorder) StateComputeWithDiff(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateComputeWithDiff", reflect.TypeOf((*MockFullNode)(nil).StateComputeWithDiff), arg0, arg1, arg2, arg3)
+}
+
+// StateCronTrace mocks base method.
+func (m *MockFullNode) StateCronTrace(arg0 context.Context, arg1 types.TipSetKey) ([]*api.InvocResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateCronTrace", arg0, arg1)
+	ret0, _ := ret[0].([]*api.InvocResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateCronTrace indicates an expected call of StateCronTrace.
+func (mr *MockFullNodeMockRecorder) StateCronTrace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCronTrace", reflect.TypeOf((*MockFullNode)(nil).StateCronTrace), arg0, arg1)
+}
+
 // StateDealProviderCollateralBounds mocks base method.
 func (m *MockFullNode) StateDealProviderCollateralBounds(arg0 context.Context, arg1 abi.PaddedPieceSize, arg2 bool, arg3 types.TipSetKey) (api.DealCollateralBounds, error) {
 	m.ctrl.T.Helper()
@@ -3128,6 +3532,36 @@ func (mr *MockFullNodeMockRecorder) StateEncodeParams(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateEncodeParams", reflect.TypeOf((*MockFullNode)(nil).StateEncodeParams), arg0, arg1, arg2, arg3)
 }
 
+// StateGasProfile mocks base method.
+func (m *MockFullNode) StateGasProfile(arg0 context.Context, arg1 types.TipSetKey) (*api.GasProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateGasProfile", arg0, arg1)
+	ret0, _ := ret[0].(*api.GasProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateGasProfile indicates an expected call of StateGasProfile.
+func (mr *MockFullNodeMockRecorder) StateGasProfile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateGasProfile", reflect.TypeOf((*MockFullNode)(nil).StateGasProfile), arg0, arg1)
+}
+
+// StateGasStats mocks base method.
+func (m *MockFullNode) StateGasStats(arg0 context.Context, arg1 abi.ChainEpoch, arg2 types.TipSetKey) ([]*api.EpochGasStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateGasStats", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.EpochGasStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateGasStats indicates an expected call of StateGasStats.
+func (mr *MockFullNodeMockRecorder) StateGasStats(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateGasStats", reflect.TypeOf((*MockFullNode)(nil).StateGasStats), arg0, arg1, arg2)
+}
+
 // StateGetActor mocks base method.
 func (m *MockFullNode) StateGetActor(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*types.ActorV5, error) {
 	m.ctrl.T.Helper()
@@ -3278,6 +3712,21 @@ func (mr *MockFullNodeMockRecorder) StateGetRandomnessFromTickets(arg0, arg1, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateGetRandomnessFromTickets", reflect.TypeOf((*MockFullNode)(nil).StateGetRandomnessFromTickets), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StateImplicitTrace mocks base method.
+func (m *MockFullNode) StateImplicitTrace(arg0 context.Context, arg1 types.TipSetKey) ([]*api.InvocResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateImplicitTrace", arg0, arg1)
+	ret0, _ := ret[0].([]*api.InvocResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateImplicitTrace indicates an expected call of StateImplicitTrace.
+func (mr *MockFullNodeMockRecorder) StateImplicitTrace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateImplicitTrace", reflect.TypeOf((*MockFullNode)(nil).StateImplicitTrace), arg0, arg1)
+}
+
 // StateListActors mocks base method.
 func (m *MockFullNode) StateListActors(arg0 context.Context, arg1 types.TipSetKey) ([]address.Address, error) {
 	m.ctrl.T.Helper()
@@ -3668,6 +4117,20 @@ func (mr *MockFullNodeMockRecorder) StateNetworkVersion(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateNetworkVersion", reflect.TypeOf((*MockFullNode)(nil).StateNetworkVersion), arg0, arg1)
 }
 
+// StatePurgePersistentCache mocks base method.
+func (m *MockFullNode) StatePurgePersistentCache(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatePurgePersistentCache", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StatePurgePersistentCache indicates an expected call of StatePurgePersistentCache.
+func (mr *MockFullNodeMockRecorder) StatePurgePersistentCache(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatePurgePersistentCache", reflect.TypeOf((*MockFullNode)(nil).StatePurgePersistentCache), arg0)
+}
+
 // StateReadState mocks base method.
 func (m *MockFullNode) StateReadState(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*api.ActorState, error) {
 	m.ctrl.T.Helper()
@@ -3698,6 +4161,21 @@ func (mr *MockFullNodeMockRecorder) StateReplay(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplay", reflect.TypeOf((*MockFullNode)(nil).StateReplay), arg0, arg1, arg2)
 }
 
+// StateReplayEx mocks base method.
+func (m *MockFullNode) StateReplayEx(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid, arg3 api.ReplayOptions) (*api.ReplayResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateReplayEx", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.ReplayResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateReplayEx indicates an expected call of StateReplayEx.
+func (mr *MockFullNodeMockRecorder) StateReplayEx(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplayEx", reflect.TypeOf((*MockFullNode)(nil).StateReplayEx), arg0, arg1, arg2, arg3)
+}
+
 // StateSearchMsg mocks base method.
 func (m *MockFullNode) StateSearchMsg(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid, arg3 abi.ChainEpoch, arg4 bool) (*api.MsgLookup, error) {
 	m.ctrl.T.Helper()
@@ -3848,6 +4326,20 @@ func (mr *MockFullNodeMockRecorder) StateWaitMsg(arg0, arg1, arg2, arg3, arg4 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateWaitMsg", reflect.TypeOf((*MockFullNode)(nil).StateWaitMsg), arg0, arg1, arg2, arg3, arg4)
 }
 
+// SyncAddTrustedCheckpoints mocks base method.
+func (m *MockFullNode) SyncAddTrustedCheckpoints(arg0 context.Context, arg1 []api.TrustedCheckpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncAddTrustedCheckpoints", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncAddTrustedCheckpoints indicates an expected call of SyncAddTrustedCheckpoints.
+func (mr *MockFullNodeMockRecorder) SyncAddTrustedCheckpoints(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncAddTrustedCheckpoints", reflect.TypeOf((*MockFullNode)(nil).SyncAddTrustedCheckpoints), arg0, arg1)
+}
+
 // SyncCheckBad mocks base method.
 func (m *MockFullNode) SyncCheckBad(arg0 context.Context, arg1 cid.Cid) (string, error) {
 	m.ctrl.T.Helper()
@@ -3877,6 +4369,36 @@ func (mr *MockFullNodeMockRecorder) SyncCheckpoint(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncCheckpoint", reflect.TypeOf((*MockFullNode)(nil).SyncCheckpoint), arg0, arg1)
 }
 
+// SyncComputeProgress mocks base method.
+func (m *MockFullNode) SyncComputeProgress(arg0 context.Context) ([]api.ComputeProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncComputeProgress", arg0)
+	ret0, _ := ret[0].([]api.ComputeProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncComputeProgress indicates an expected call of SyncComputeProgress.
+func (mr *MockFullNodeMockRecorder) SyncComputeProgress(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncComputeProgress", reflect.TypeOf((*MockFullNode)(nil).SyncComputeProgress), arg0)
+}
+
+// SyncImportProgress mocks base method.
+func (m *MockFullNode) SyncImportProgress(arg0 context.Context) (*api.ImportProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncImportProgress", arg0)
+	ret0, _ := ret[0].(*api.ImportProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncImportProgress indicates an expected call of SyncImportProgress.
+func (mr *MockFullNodeMockRecorder) SyncImportProgress(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncImportProgress", reflect.TypeOf((*MockFullNode)(nil).SyncImportProgress), arg0)
+}
+
 // SyncIncomingBlocks mocks base method.
 func (m *MockFullNode) SyncIncomingBlocks(arg0 context.Context) (<-chan *types.BlockHeader, error) {
 	m.ctrl.T.Helper()
@@ -3906,6 +4428,20 @@ func (mr *MockFullNodeMockRecorder) SyncMarkBad(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncMarkBad", reflect.TypeOf((*MockFullNode)(nil).SyncMarkBad), arg0, arg1)
 }
 
+// SyncRemoveTrustedCheckpoint mocks base method.
+func (m *MockFullNode) SyncRemoveTrustedCheckpoint(arg0 context.Context, arg1 abi.ChainEpoch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncRemoveTrustedCheckpoint", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncRemoveTrustedCheckpoint indicates an expected call of SyncRemoveTrustedCheckpoint.
+func (mr *MockFullNodeMockRecorder) SyncRemoveTrustedCheckpoint(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncRemoveTrustedCheckpoint", reflect.TypeOf((*MockFullNode)(nil).SyncRemoveTrustedCheckpoint), arg0, arg1)
+}
+
 // SyncState mocks base method.
 func (m *MockFullNode) SyncState(arg0 context.Context) (*api.SyncState, error) {
 	m.ctrl.T.Helper()
@@ -3935,6 +4471,21 @@ func (mr *MockFullNodeMockRecorder) SyncSubmitBlock(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncSubmitBlock", reflect.TypeOf((*MockFullNode)(nil).SyncSubmitBlock), arg0, arg1)
 }
 
+// SyncTrustedCheckpoints mocks base method.
+func (m *MockFullNode) SyncTrustedCheckpoints(arg0 context.Context) ([]api.TrustedCheckpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncTrustedCheckpoints", arg0)
+	ret0, _ := ret[0].([]api.TrustedCheckpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncTrustedCheckpoints indicates an expected call of SyncTrustedCheckpoints.
+func (mr *MockFullNodeMockRecorder) SyncTrustedCheckpoints(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncTrustedCheckpoints", reflect.TypeOf((*MockFullNode)(nil).SyncTrustedCheckpoints), arg0)
+}
+
 // SyncUnmarkAllBad mocks base method.
 func (m *MockFullNode) SyncUnmarkAllBad(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -4037,6 +4588,20 @@ func (mr *MockFullNodeMockRecorder) WalletDelete(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletDelete", reflect.TypeOf((*MockFullNode)(nil).WalletDelete), arg0, arg1)
 }
 
+// WalletEncrypt mocks base method.
+func (m *MockFullNode) WalletEncrypt(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletEncrypt", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletEncrypt indicates an expected call of WalletEncrypt.
+func (mr *MockFullNodeMockRecorder) WalletEncrypt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletEncrypt", reflect.TypeOf((*MockFullNode)(nil).WalletEncrypt), arg0, arg1)
+}
+
 // WalletExport mocks base method.
 func (m *MockFullNode) WalletExport(arg0 context.Context, arg1 address.Address) (*types.KeyInfo, error) {
 	m.ctrl.T.Helper()
@@ -4052,6 +4617,51 @@ func (mr *MockFullNodeMockRecorder) WalletExport(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletExport", reflect.TypeOf((*MockFullNode)(nil).WalletExport), arg0, arg1)
 }
 
+// WalletHDDerive mocks base method.
+func (m *MockFullNode) WalletHDDerive(arg0 context.Context, arg1 int64) (*api.HDKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletHDDerive", arg0, arg1)
+	ret0, _ := ret[0].(*api.HDKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletHDDerive indicates an expected call of WalletHDDerive.
+func (mr *MockFullNodeMockRecorder) WalletHDDerive(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletHDDerive", reflect.TypeOf((*MockFullNode)(nil).WalletHDDerive), arg0, arg1)
+}
+
+// WalletHDNew mocks base method.
+func (m *MockFullNode) WalletHDNew(arg0 context.Context, arg1 int, arg2 string) (*api.HDMnemonic, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletHDNew", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.HDMnemonic)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletHDNew indicates an expected call of WalletHDNew.
+func (mr *MockFullNodeMockRecorder) WalletHDNew(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletHDNew", reflect.TypeOf((*MockFullNode)(nil).WalletHDNew), arg0, arg1, arg2)
+}
+
+// WalletHDRestore mocks base method.
+func (m *MockFullNode) WalletHDRestore(arg0 context.Context, arg1 string, arg2 string, arg3 int) ([]api.HDKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletHDRestore", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]api.HDKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletHDRestore indicates an expected call of WalletHDRestore.
+func (mr *MockFullNodeMockRecorder) WalletHDRestore(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletHDRestore", reflect.TypeOf((*MockFullNode)(nil).WalletHDRestore), arg0, arg1, arg2, arg3)
+}
+
 // WalletHas mocks base method.
 func (m *MockFullNode) WalletHas(arg0 context.Context, arg1 address.Address) (bool, error) {
 	m.ctrl.T.Helper()
@@ -4097,6 +4707,35 @@ func (mr *MockFullNodeMockRecorder) WalletList(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletList", reflect.TypeOf((*MockFullNode)(nil).WalletList), arg0)
 }
 
+// WalletLock mocks base method.
+func (m *MockFullNode) WalletLock(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletLock", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletLock indicates an expected call of WalletLock.
+func (mr *MockFullNodeMockRecorder) WalletLock(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletLock", reflect.TypeOf((*MockFullNode)(nil).WalletLock), arg0)
+}
+
+// WalletLockStatus mocks base method.
+func (m *MockFullNode) WalletLockStatus(arg0 context.Context) (*api.LockStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletLockStatus", arg0)
+	ret0, _ := ret[0].(*api.LockStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletLockStatus indicates an expected call of WalletLockStatus.
+func (mr *MockFullNodeMockRecorder) WalletLockStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletLockStatus", reflect.TypeOf((*MockFullNode)(nil).WalletLockStatus), arg0)
+}
+
 // WalletNew mocks base method.
 func (m *MockFullNode) WalletNew(arg0 context.Context, arg1 types.KeyType) (address.Address, error) {
 	m.ctrl.T.Helper()
@@ -4156,6 +4795,20 @@ func (mr *MockFullNodeMockRecorder) WalletSignMessage(arg0, arg1, arg2 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletSignMessage", reflect.TypeOf((*MockFullNode)(nil).WalletSignMessage), arg0, arg1, arg2)
 }
 
+// WalletUnlock mocks base method.
+func (m *MockFullNode) WalletUnlock(arg0 context.Context, arg1 string, arg2 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletUnlock", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletUnlock indicates an expected call of WalletUnlock.
+func (mr *MockFullNodeMockRecorder) WalletUnlock(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletUnlock", reflect.TypeOf((*MockFullNode)(nil).WalletUnlock), arg0, arg1, arg2)
+}
+
 // WalletValidateAddress mocks base method.
 func (m *MockFullNode) WalletValidateAddress(arg0 context.Context, arg1 string) (address.Address, error) {
 	m.ctrl.T.Helper()
@@ -40,6 +40,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/types/ethtypes"
 	"github.com/filecoin-project/lotus/journal/alerting"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/tracer"
 	"github.com/filecoin-project/lotus/node/repo/imports"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
@@ -142,6 +143,12 @@ type FullNodeMethods struct {
 
 	ChainExportRangeInternal func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 ChainExportConfig) error `perm:"admin"`
 
+	ChainGC func(p0 context.Context, p1 ChainGCOpts) (*ChainGCStatus, error) `perm:"admin"`
+
+	ChainGCCancel func(p0 context.Context) (*ChainGCStatus, error) `perm:"admin"`
+
+	ChainGCStatus func(p0 context.Context) (*ChainGCStatus, error) `perm:"admin"`
+
 	ChainGetBlock func(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) `perm:"read"`
 
 	ChainGetBlockMessages func(p0 context.Context, p1 cid.Cid) (*BlockMessages, error) `perm:"read"`
@@ -174,6 +181,10 @@ type FullNodeMethods struct {
 
 	ChainHotGC func(p0 context.Context, p1 HotGCOpts) error `perm:"admin"`
 
+	ChainImportOnline func(p0 context.Context, p1 string) (*ChainImportStatus, error) `perm:"admin"`
+
+	ChainImportStatus func(p0 context.Context) (*ChainImportStatus, error) `perm:"admin"`
+
 	ChainNotify func(p0 context.Context) (<-chan []*HeadChange, error) `perm:"read"`
 
 	ChainPrune func(p0 context.Context, p1 PruneOpts) error `perm:"admin"`
@@ -320,6 +331,8 @@ type FullNodeMethods struct {
 
 	FilecoinAddressToEthAddress func(p0 context.Context, p1 address.Address) (ethtypes.EthAddress, error) `perm:"read"`
 
+	GasBaseFeeForecast func(p0 context.Context, p1 int, p2 int) (*BaseFeeForecast, error) `perm:"read"`
+
 	GasEstimateFeeCap func(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
 	GasEstimateGasLimit func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (int64, error) `perm:"read"`
@@ -328,6 +341,10 @@ type FullNodeMethods struct {
 
 	GasEstimateMessageGas func(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec, p3 types.TipSetKey) (*types.Message, error) `perm:"read"`
 
+	GasFeeHistory func(p0 context.Context, p1 int, p2 []float64) (*FeeHistory, error) `perm:"read"`
+
+	GasPriceOracle func(p0 context.Context, p1 int) (*GasPriceOracle, error) `perm:"read"`
+
 	MarketAddBalance func(p0 context.Context, p1 address.Address, p2 address.Address, p3 types.BigInt) (cid.Cid, error) `perm:"sign"`
 
 	MarketGetReserved func(p0 context.Context, p1 address.Address) (types.BigInt, error) `perm:"sign"`
@@ -348,6 +365,8 @@ type FullNodeMethods struct {
 
 	MpoolBatchPushUntrusted func(p0 context.Context, p1 []*types.SignedMessage) ([]cid.Cid, error) `perm:"write"`
 
+	MpoolCancelScheduled func(p0 context.Context, p1 cid.Cid) error `perm:"write"`
+
 	MpoolCheckMessages func(p0 context.Context, p1 []*MessagePrototype) ([][]MessageCheckStatus, error) `perm:"read"`
 
 	MpoolCheckPendingMessages func(p0 context.Context, p1 address.Address) ([][]MessageCheckStatus, error) `perm:"read"`
@@ -356,24 +375,40 @@ type FullNodeMethods struct {
 
 	MpoolClear func(p0 context.Context, p1 bool) error `perm:"write"`
 
+	MpoolClearPersistedLocal func(p0 context.Context) (int, error) `perm:"admin"`
+
 	MpoolGetConfig func(p0 context.Context) (*types.MpoolConfig, error) `perm:"read"`
 
 	MpoolGetNonce func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"read"`
 
+	MpoolGetRBFPolicy func(p0 context.Context, p1 address.Address) (*RBFPolicy, error) `perm:"read"`
+
+	MpoolHistory func(p0 context.Context, p1 address.Address) ([]*LocalMessageRecord, error) `perm:"read"`
+
+	MpoolNonceGaps func(p0 context.Context, p1 address.Address) (*NonceGaps, error) `perm:"read"`
+
 	MpoolPending func(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) `perm:"read"`
 
+	MpoolPersistedLocal func(p0 context.Context) ([]*types.SignedMessage, error) `perm:"read"`
+
 	MpoolPush func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
 	MpoolPushMessage func(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec) (*types.SignedMessage, error) `perm:"sign"`
 
 	MpoolPushUntrusted func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
+	MpoolSchedule func(p0 context.Context, p1 *types.SignedMessage, p2 abi.ChainEpoch) (cid.Cid, error) `perm:"write"`
+
+	MpoolScheduled func(p0 context.Context) ([]*ScheduledMessage, error) `perm:"read"`
+
 	MpoolSelect func(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) `perm:"read"`
 
 	MpoolSetConfig func(p0 context.Context, p1 *types.MpoolConfig) error `perm:"admin"`
 
 	MpoolSub func(p0 context.Context) (<-chan MpoolUpdate, error) `perm:"read"`
 
+	MpoolSubFiltered func(p0 context.Context, p1 MpoolUpdateFilter) (<-chan MpoolUpdate, error) `perm:"read"`
+
 	MsigAddApprove func(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 address.Address, p6 bool) (*MessagePrototype, error) `perm:"sign"`
 
 	MsigAddCancel func(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 bool) (*MessagePrototype, error) `perm:"sign"`
@@ -410,6 +445,12 @@ type FullNodeMethods struct {
 
 	NetListening func(p0 context.Context) (bool, error) `perm:"read"`
 
+	NetPubsubTraceStart func(p0 context.Context, p1 tracer.DeliveryTraceConfig) (*tracer.DeliveryTraceStatus, error) `perm:"admin"`
+
+	NetPubsubTraceStatus func(p0 context.Context) (*tracer.DeliveryTraceStatus, error) `perm:"read"`
+
+	NetPubsubTraceStop func(p0 context.Context) (*tracer.DeliveryTraceStatus, error) `perm:"admin"`
+
 	NetVersion func(p0 context.Context) (string, error) `perm:"read"`
 
 	NodeStatus func(p0 context.Context, p1 bool) (NodeStatus, error) `perm:"read"`
@@ -460,22 +501,40 @@ type FullNodeMethods struct {
 
 	StateAllMinerFaults func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*Fault, error) `perm:"read"`
 
+	StateBackfillStatus func(p0 context.Context) (*StateBackfillStatus, error) `perm:"read"`
+
+	StateBeaconStatus func(p0 context.Context) ([]BeaconStatus, error) `perm:"read"`
+
 	StateCall func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (*InvocResult, error) `perm:"read"`
 
+	StateCallBatch func(p0 context.Context, p1 []*types.Message, p2 types.TipSetKey) ([]*InvocResult, error) `perm:"read"`
+
+	StateCallWithOverrides func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey, p3 map[address.Address]ActorOverride) (*InvocResult, error) `perm:"read"`
+
 	StateChangedActors func(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (map[string]types.Actor, error) `perm:"read"`
 
 	StateCirculatingSupply func(p0 context.Context, p1 types.TipSetKey) (abi.TokenAmount, error) `perm:"read"`
 
+	StateCirculatingSupplyRange func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]CirculatingSupplyAt, error) `perm:"read"`
+
 	StateCompute func(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*ComputeStateOutput, error) `perm:"read"`
 
 	StateComputeDataCID func(p0 context.Context, p1 address.Address, p2 abi.RegisteredSealProof, p3 []abi.DealID, p4 types.TipSetKey) (cid.Cid, error) `perm:"read"`
 
+	StateComputeWithDiff func(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*ComputeStateDiffOutput, error) `perm:"read"`
+
+	StateCronTrace func(p0 context.Context, p1 types.TipSetKey) ([]*InvocResult, error) `perm:"read"`
+
 	StateDealProviderCollateralBounds func(p0 context.Context, p1 abi.PaddedPieceSize, p2 bool, p3 types.TipSetKey) (DealCollateralBounds, error) `perm:"read"`
 
 	StateDecodeParams func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
 
 	StateEncodeParams func(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 json.RawMessage) ([]byte, error) `perm:"read"`
 
+	StateGasProfile func(p0 context.Context, p1 types.TipSetKey) (*GasProfile, error) `perm:"read"`
+
+	StateGasStats func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*EpochGasStats, error) `perm:"read"`
+
 	StateGetActor func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) `perm:"read"`
 
 	StateGetAllocation func(p0 context.Context, p1 address.Address, p2 verifregtypes.AllocationId, p3 types.TipSetKey) (*verifregtypes.Allocation, error) `perm:"read"`
@@ -496,6 +555,8 @@ type FullNodeMethods struct {
 
 	StateGetRandomnessFromTickets func(p0 context.Context, p1 crypto.DomainSeparationTag, p2 abi.ChainEpoch, p3 []byte, p4 types.TipSetKey) (abi.Randomness, error) `perm:"read"`
 
+	StateImplicitTrace func(p0 context.Context, p1 types.TipSetKey) ([]*InvocResult, error) `perm:"read"`
+
 	StateListActors func(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) `perm:"read"`
 
 	StateListMessages func(p0 context.Context, p1 *MessageMatch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]cid.Cid, error) `perm:"read"`
@@ -548,10 +609,14 @@ type FullNodeMethods struct {
 
 	StateNetworkVersion func(p0 context.Context, p1 types.TipSetKey) (apitypes.NetworkVersion, error) `perm:"read"`
 
+	StatePurgePersistentCache func(p0 context.Context) error `perm:"admin"`
+
 	StateReadState func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorState, error) `perm:"read"`
 
 	StateReplay func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*InvocResult, error) `perm:"read"`
 
+	StateReplayEx func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 ReplayOptions) (*ReplayResult, error) `perm:"read"`
+
 	StateSearchMsg func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) `perm:"read"`
 
 	StateSectorExpiration func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (*lminer.SectorExpiration, error) `perm:"read"`
@@ -572,18 +637,28 @@ type FullNodeMethods struct {
 
 	StateWaitMsg func(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) `perm:"read"`
 
+	SyncAddTrustedCheckpoints func(p0 context.Context, p1 []TrustedCheckpoint) error `perm:"admin"`
+
 	SyncCheckBad func(p0 context.Context, p1 cid.Cid) (string, error) `perm:"read"`
 
 	SyncCheckpoint func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+	SyncComputeProgress func(p0 context.Context) ([]ComputeProgress, error) `perm:"read"`
+
+	SyncImportProgress func(p0 context.Context) (*ImportProgress, error) `perm:"read"`
+
 	SyncIncomingBlocks func(p0 context.Context) (<-chan *types.BlockHeader, error) `perm:"read"`
 
 	SyncMarkBad func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
+	SyncRemoveTrustedCheckpoint func(p0 context.Context, p1 abi.ChainEpoch) error `perm:"admin"`
+
 	SyncState func(p0 context.Context) (*SyncState, error) `perm:"read"`
 
 	SyncSubmitBlock func(p0 context.Context, p1 *types.BlockMsg) error `perm:"write"`
 
+	SyncTrustedCheckpoints func(p0 context.Context) ([]TrustedCheckpoint, error) `perm:"read"`
+
 	SyncUnmarkAllBad func(p0 context.Context) error `perm:"admin"`
 
 	SyncUnmarkBad func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
@@ -596,14 +671,26 @@ type FullNodeMethods struct {
 
 	WalletDelete func(p0 context.Context, p1 address.Address) error `perm:"admin"`
 
+	WalletEncrypt func(p0 context.Context, p1 string) error `perm:"admin"`
+
 	WalletExport func(p0 context.Context, p1 address.Address) (*types.KeyInfo, error) `perm:"admin"`
 
+	WalletHDDerive func(p0 context.Context, p1 int64) (*HDKey, error) `perm:"admin"`
+
+	WalletHDNew func(p0 context.Context, p1 int, p2 string) (*HDMnemonic, error) `perm:"admin"`
+
+	WalletHDRestore func(p0 context.Context, p1 string, p2 string, p3 int) ([]HDKey, error) `perm:"admin"`
+
 	WalletHas func(p0 context.Context, p1 address.Address) (bool, error) `perm:"write"`
 
 	WalletImport func(p0 context.Context, p1 *types.KeyInfo) (address.Address, error) `perm:"admin"`
 
 	WalletList func(p0 context.Context) ([]address.Address, error) `perm:"write"`
 
+	WalletLock func(p0 context.Context) error `perm:"admin"`
+
+	WalletLockStatus func(p0 context.Context) (*LockStatus, error) `perm:"admin"`
+
 	WalletNew func(p0 context.Context, p1 types.KeyType) (address.Address, error) `perm:"write"`
 
 	WalletSetDefault func(p0 context.Context, p1 address.Address) error `perm:"write"`
@@ -612,6 +699,8 @@ type FullNodeMethods struct {
 
 	WalletSignMessage func(p0 context.Context, p1 address.Address, p2 *types.Message) (*types.SignedMessage, error) `perm:"sign"`
 
+	WalletUnlock func(p0 context.Context, p1 string, p2 time.Duration) error `perm:"admin"`
+
 	WalletValidateAddress func(p0 context.Context, p1 string) (address.Address, error) `perm:"read"`
 
 	WalletVerify func(p0 context.Context, p1 address.Address, p2 []byte, p3 *crypto.Signature) (bool, error) `perm:"read"`
@@ -1157,17 +1246,31 @@ type WalletStruct struct {
 type WalletMethods struct {
 	WalletDelete func(p0 context.Context, p1 address.Address) error `perm:"admin"`
 
+	WalletEncrypt func(p0 context.Context, p1 string) error `perm:"admin"`
+
 	WalletExport func(p0 context.Context, p1 address.Address) (*types.KeyInfo, error) `perm:"admin"`
 
+	WalletHDDerive func(p0 context.Context, p1 int64) (*HDKey, error) `perm:"admin"`
+
+	WalletHDNew func(p0 context.Context, p1 int, p2 string) (*HDMnemonic, error) `perm:"admin"`
+
+	WalletHDRestore func(p0 context.Context, p1 string, p2 string, p3 int) ([]HDKey, error) `perm:"admin"`
+
 	WalletHas func(p0 context.Context, p1 address.Address) (bool, error) `perm:"admin"`
 
 	WalletImport func(p0 context.Context, p1 *types.KeyInfo) (address.Address, error) `perm:"admin"`
 
 	WalletList func(p0 context.Context) ([]address.Address, error) `perm:"admin"`
 
+	WalletLock func(p0 context.Context) error `perm:"admin"`
+
+	WalletLockStatus func(p0 context.Context) (*LockStatus, error) `perm:"admin"`
+
 	WalletNew func(p0 context.Context, p1 types.KeyType) (address.Address, error) `perm:"admin"`
 
 	WalletSign func(p0 context.Context, p1 address.Address, p2 []byte, p3 MsgMeta) (*crypto.Signature, error) `perm:"admin"`
+
+	WalletUnlock func(p0 context.Context, p1 string, p2 time.Duration) error `perm:"admin"`
 }
 
 type WalletStub struct {
@@ -1476,6 +1579,39 @@ func (s *FullNodeStub) ChainExportRangeInternal(p0 context.Context, p1 types.Tip
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainGC(p0 context.Context, p1 ChainGCOpts) (*ChainGCStatus, error) {
+	if s.Internal.ChainGC == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainGC(p0, p1)
+}
+
+func (s *FullNodeStub) ChainGC(p0 context.Context, p1 ChainGCOpts) (*ChainGCStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainGCCancel(p0 context.Context) (*ChainGCStatus, error) {
+	if s.Internal.ChainGCCancel == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainGCCancel(p0)
+}
+
+func (s *FullNodeStub) ChainGCCancel(p0 context.Context) (*ChainGCStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainGCStatus(p0 context.Context) (*ChainGCStatus, error) {
+	if s.Internal.ChainGCStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainGCStatus(p0)
+}
+
+func (s *FullNodeStub) ChainGCStatus(p0 context.Context) (*ChainGCStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetBlock(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) {
 	if s.Internal.ChainGetBlock == nil {
 		return nil, ErrNotSupported
@@ -1652,6 +1788,28 @@ func (s *FullNodeStub) ChainHotGC(p0 context.Context, p1 HotGCOpts) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainImportOnline(p0 context.Context, p1 string) (*ChainImportStatus, error) {
+	if s.Internal.ChainImportOnline == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainImportOnline(p0, p1)
+}
+
+func (s *FullNodeStub) ChainImportOnline(p0 context.Context, p1 string) (*ChainImportStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainImportStatus(p0 context.Context) (*ChainImportStatus, error) {
+	if s.Internal.ChainImportStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainImportStatus(p0)
+}
+
+func (s *FullNodeStub) ChainImportStatus(p0 context.Context) (*ChainImportStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainNotify(p0 context.Context) (<-chan []*HeadChange, error) {
 	if s.Internal.ChainNotify == nil {
 		return nil, ErrNotSupported
@@ -2455,6 +2613,17 @@ func (s *FullNodeStub) FilecoinAddressToEthAddress(p0 context.Context, p1 addres
 	return *new(ethtypes.EthAddress), ErrNotSupported
 }
 
+func (s *FullNodeStruct) GasBaseFeeForecast(p0 context.Context, p1 int, p2 int) (*BaseFeeForecast, error) {
+	if s.Internal.GasBaseFeeForecast == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GasBaseFeeForecast(p0, p1, p2)
+}
+
+func (s *FullNodeStub) GasBaseFeeForecast(p0 context.Context, p1 int, p2 int) (*BaseFeeForecast, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) GasEstimateFeeCap(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) {
 	if s.Internal.GasEstimateFeeCap == nil {
 		return *new(types.BigInt), ErrNotSupported
@@ -2499,6 +2668,28 @@ func (s *FullNodeStub) GasEstimateMessageGas(p0 context.Context, p1 *types.Messa
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) GasFeeHistory(p0 context.Context, p1 int, p2 []float64) (*FeeHistory, error) {
+	if s.Internal.GasFeeHistory == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GasFeeHistory(p0, p1, p2)
+}
+
+func (s *FullNodeStub) GasFeeHistory(p0 context.Context, p1 int, p2 []float64) (*FeeHistory, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) GasPriceOracle(p0 context.Context, p1 int) (*GasPriceOracle, error) {
+	if s.Internal.GasPriceOracle == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GasPriceOracle(p0, p1)
+}
+
+func (s *FullNodeStub) GasPriceOracle(p0 context.Context, p1 int) (*GasPriceOracle, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MarketAddBalance(p0 context.Context, p1 address.Address, p2 address.Address, p3 types.BigInt) (cid.Cid, error) {
 	if s.Internal.MarketAddBalance == nil {
 		return *new(cid.Cid), ErrNotSupported
@@ -2609,6 +2800,17 @@ func (s *FullNodeStub) MpoolBatchPushUntrusted(p0 context.Context, p1 []*types.S
 	return *new([]cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolCancelScheduled(p0 context.Context, p1 cid.Cid) error {
+	if s.Internal.MpoolCancelScheduled == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MpoolCancelScheduled(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolCancelScheduled(p0 context.Context, p1 cid.Cid) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolCheckMessages(p0 context.Context, p1 []*MessagePrototype) ([][]MessageCheckStatus, error) {
 	if s.Internal.MpoolCheckMessages == nil {
 		return *new([][]MessageCheckStatus), ErrNotSupported
@@ -2653,6 +2855,17 @@ func (s *FullNodeStub) MpoolClear(p0 context.Context, p1 bool) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolClearPersistedLocal(p0 context.Context) (int, error) {
+	if s.Internal.MpoolClearPersistedLocal == nil {
+		return 0, ErrNotSupported
+	}
+	return s.Internal.MpoolClearPersistedLocal(p0)
+}
+
+func (s *FullNodeStub) MpoolClearPersistedLocal(p0 context.Context) (int, error) {
+	return 0, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolGetConfig(p0 context.Context) (*types.MpoolConfig, error) {
 	if s.Internal.MpoolGetConfig == nil {
 		return nil, ErrNotSupported
@@ -2675,6 +2888,39 @@ func (s *FullNodeStub) MpoolGetNonce(p0 context.Context, p1 address.Address) (ui
 	return 0, ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolGetRBFPolicy(p0 context.Context, p1 address.Address) (*RBFPolicy, error) {
+	if s.Internal.MpoolGetRBFPolicy == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolGetRBFPolicy(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolGetRBFPolicy(p0 context.Context, p1 address.Address) (*RBFPolicy, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolHistory(p0 context.Context, p1 address.Address) ([]*LocalMessageRecord, error) {
+	if s.Internal.MpoolHistory == nil {
+		return *new([]*LocalMessageRecord), ErrNotSupported
+	}
+	return s.Internal.MpoolHistory(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolHistory(p0 context.Context, p1 address.Address) ([]*LocalMessageRecord, error) {
+	return *new([]*LocalMessageRecord), ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolNonceGaps(p0 context.Context, p1 address.Address) (*NonceGaps, error) {
+	if s.Internal.MpoolNonceGaps == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolNonceGaps(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolNonceGaps(p0 context.Context, p1 address.Address) (*NonceGaps, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	if s.Internal.MpoolPending == nil {
 		return *new([]*types.SignedMessage), ErrNotSupported
@@ -2686,6 +2932,17 @@ func (s *FullNodeStub) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*
 	return *new([]*types.SignedMessage), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolPersistedLocal(p0 context.Context) ([]*types.SignedMessage, error) {
+	if s.Internal.MpoolPersistedLocal == nil {
+		return *new([]*types.SignedMessage), ErrNotSupported
+	}
+	return s.Internal.MpoolPersistedLocal(p0)
+}
+
+func (s *FullNodeStub) MpoolPersistedLocal(p0 context.Context) ([]*types.SignedMessage, error) {
+	return *new([]*types.SignedMessage), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPush(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) {
 	if s.Internal.MpoolPush == nil {
 		return *new(cid.Cid), ErrNotSupported
@@ -2719,6 +2976,28 @@ func (s *FullNodeStub) MpoolPushUntrusted(p0 context.Context, p1 *types.SignedMe
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolSchedule(p0 context.Context, p1 *types.SignedMessage, p2 abi.ChainEpoch) (cid.Cid, error) {
+	if s.Internal.MpoolSchedule == nil {
+		return *new(cid.Cid), ErrNotSupported
+	}
+	return s.Internal.MpoolSchedule(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolSchedule(p0 context.Context, p1 *types.SignedMessage, p2 abi.ChainEpoch) (cid.Cid, error) {
+	return *new(cid.Cid), ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolScheduled(p0 context.Context) ([]*ScheduledMessage, error) {
+	if s.Internal.MpoolScheduled == nil {
+		return *new([]*ScheduledMessage), ErrNotSupported
+	}
+	return s.Internal.MpoolScheduled(p0)
+}
+
+func (s *FullNodeStub) MpoolScheduled(p0 context.Context) ([]*ScheduledMessage, error) {
+	return *new([]*ScheduledMessage), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolSelect(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) {
 	if s.Internal.MpoolSelect == nil {
 		return *new([]*types.SignedMessage), ErrNotSupported
@@ -2752,6 +3031,17 @@ func (s *FullNodeStub) MpoolSub(p0 context.Context) (<-chan MpoolUpdate, error)
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolSubFiltered(p0 context.Context, p1 MpoolUpdateFilter) (<-chan MpoolUpdate, error) {
+	if s.Internal.MpoolSubFiltered == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolSubFiltered(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolSubFiltered(p0 context.Context, p1 MpoolUpdateFilter) (<-chan MpoolUpdate, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MsigAddApprove(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 address.Address, p6 bool) (*MessagePrototype, error) {
 	if s.Internal.MsigAddApprove == nil {
 		return nil, ErrNotSupported
@@ -2950,6 +3240,39 @@ func (s *FullNodeStub) NetListening(p0 context.Context) (bool, error) {
 	return false, ErrNotSupported
 }
 
+func (s *FullNodeStruct) NetPubsubTraceStart(p0 context.Context, p1 tracer.DeliveryTraceConfig) (*tracer.DeliveryTraceStatus, error) {
+	if s.Internal.NetPubsubTraceStart == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.NetPubsubTraceStart(p0, p1)
+}
+
+func (s *FullNodeStub) NetPubsubTraceStart(p0 context.Context, p1 tracer.DeliveryTraceConfig) (*tracer.DeliveryTraceStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) NetPubsubTraceStatus(p0 context.Context) (*tracer.DeliveryTraceStatus, error) {
+	if s.Internal.NetPubsubTraceStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.NetPubsubTraceStatus(p0)
+}
+
+func (s *FullNodeStub) NetPubsubTraceStatus(p0 context.Context) (*tracer.DeliveryTraceStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) NetPubsubTraceStop(p0 context.Context) (*tracer.DeliveryTraceStatus, error) {
+	if s.Internal.NetPubsubTraceStop == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.NetPubsubTraceStop(p0)
+}
+
+func (s *FullNodeStub) NetPubsubTraceStop(p0 context.Context) (*tracer.DeliveryTraceStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) NetVersion(p0 context.Context) (string, error) {
 	if s.Internal.NetVersion == nil {
 		return "", ErrNotSupported
@@ -3225,6 +3548,28 @@ func (s *FullNodeStub) StateAllMinerFaults(p0 context.Context, p1 abi.ChainEpoch
 	return *new([]*Fault), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateBackfillStatus(p0 context.Context) (*StateBackfillStatus, error) {
+	if s.Internal.StateBackfillStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateBackfillStatus(p0)
+}
+
+func (s *FullNodeStub) StateBackfillStatus(p0 context.Context) (*StateBackfillStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateBeaconStatus(p0 context.Context) ([]BeaconStatus, error) {
+	if s.Internal.StateBeaconStatus == nil {
+		return *new([]BeaconStatus), ErrNotSupported
+	}
+	return s.Internal.StateBeaconStatus(p0)
+}
+
+func (s *FullNodeStub) StateBeaconStatus(p0 context.Context) ([]BeaconStatus, error) {
+	return *new([]BeaconStatus), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateCall(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (*InvocResult, error) {
 	if s.Internal.StateCall == nil {
 		return nil, ErrNotSupported
@@ -3236,6 +3581,28 @@ func (s *FullNodeStub) StateCall(p0 context.Context, p1 *types.Message, p2 types
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateCallBatch(p0 context.Context, p1 []*types.Message, p2 types.TipSetKey) ([]*InvocResult, error) {
+	if s.Internal.StateCallBatch == nil {
+		return *new([]*InvocResult), ErrNotSupported
+	}
+	return s.Internal.StateCallBatch(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateCallBatch(p0 context.Context, p1 []*types.Message, p2 types.TipSetKey) ([]*InvocResult, error) {
+	return *new([]*InvocResult), ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateCallWithOverrides(p0 context.Context, p1 *types.Message, p2 types.TipSetKey, p3 map[address.Address]ActorOverride) (*InvocResult, error) {
+	if s.Internal.StateCallWithOverrides == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateCallWithOverrides(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateCallWithOverrides(p0 context.Context, p1 *types.Message, p2 types.TipSetKey, p3 map[address.Address]ActorOverride) (*InvocResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateChangedActors(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (map[string]types.Actor, error) {
 	if s.Internal.StateChangedActors == nil {
 		return *new(map[string]types.Actor), ErrNotSupported
@@ -3258,6 +3625,17 @@ func (s *FullNodeStub) StateCirculatingSupply(p0 context.Context, p1 types.TipSe
 	return *new(abi.TokenAmount), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateCirculatingSupplyRange(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]CirculatingSupplyAt, error) {
+	if s.Internal.StateCirculatingSupplyRange == nil {
+		return *new([]CirculatingSupplyAt), ErrNotSupported
+	}
+	return s.Internal.StateCirculatingSupplyRange(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateCirculatingSupplyRange(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]CirculatingSupplyAt, error) {
+	return *new([]CirculatingSupplyAt), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateCompute(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*ComputeStateOutput, error) {
 	if s.Internal.StateCompute == nil {
 		return nil, ErrNotSupported
@@ -3280,6 +3658,28 @@ func (s *FullNodeStub) StateComputeDataCID(p0 context.Context, p1 address.Addres
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateComputeWithDiff(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*ComputeStateDiffOutput, error) {
+	if s.Internal.StateComputeWithDiff == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateComputeWithDiff(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateComputeWithDiff(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*ComputeStateDiffOutput, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateCronTrace(p0 context.Context, p1 types.TipSetKey) ([]*InvocResult, error) {
+	if s.Internal.StateCronTrace == nil {
+		return *new([]*InvocResult), ErrNotSupported
+	}
+	return s.Internal.StateCronTrace(p0, p1)
+}
+
+func (s *FullNodeStub) StateCronTrace(p0 context.Context, p1 types.TipSetKey) ([]*InvocResult, error) {
+	return *new([]*InvocResult), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateDealProviderCollateralBounds(p0 context.Context, p1 abi.PaddedPieceSize, p2 bool, p3 types.TipSetKey) (DealCollateralBounds, error) {
 	if s.Internal.StateDealProviderCollateralBounds == nil {
 		return *new(DealCollateralBounds), ErrNotSupported
@@ -3313,6 +3713,28 @@ func (s *FullNodeStub) StateEncodeParams(p0 context.Context, p1 cid.Cid, p2 abi.
 	return *new([]byte), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateGasProfile(p0 context.Context, p1 types.TipSetKey) (*GasProfile, error) {
+	if s.Internal.StateGasProfile == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateGasProfile(p0, p1)
+}
+
+func (s *FullNodeStub) StateGasProfile(p0 context.Context, p1 types.TipSetKey) (*GasProfile, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateGasStats(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*EpochGasStats, error) {
+	if s.Internal.StateGasStats == nil {
+		return *new([]*EpochGasStats), ErrNotSupported
+	}
+	return s.Internal.StateGasStats(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateGasStats(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*EpochGasStats, error) {
+	return *new([]*EpochGasStats), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateGetActor(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) {
 	if s.Internal.StateGetActor == nil {
 		return nil, ErrNotSupported
@@ -3423,6 +3845,17 @@ func (s *FullNodeStub) StateGetRandomnessFromTickets(p0 context.Context, p1 cryp
 	return *new(abi.Randomness), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateImplicitTrace(p0 context.Context, p1 types.TipSetKey) ([]*InvocResult, error) {
+	if s.Internal.StateImplicitTrace == nil {
+		return *new([]*InvocResult), ErrNotSupported
+	}
+	return s.Internal.StateImplicitTrace(p0, p1)
+}
+
+func (s *FullNodeStub) StateImplicitTrace(p0 context.Context, p1 types.TipSetKey) ([]*InvocResult, error) {
+	return *new([]*InvocResult), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateListActors(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) {
 	if s.Internal.StateListActors == nil {
 		return *new([]address.Address), ErrNotSupported
@@ -3709,6 +4142,17 @@ func (s *FullNodeStub) StateNetworkVersion(p0 context.Context, p1 types.TipSetKe
 	return *new(apitypes.NetworkVersion), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StatePurgePersistentCache(p0 context.Context) error {
+	if s.Internal.StatePurgePersistentCache == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.StatePurgePersistentCache(p0)
+}
+
+func (s *FullNodeStub) StatePurgePersistentCache(p0 context.Context) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateReadState(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorState, error) {
 	if s.Internal.StateReadState == nil {
 		return nil, ErrNotSupported
@@ -3731,6 +4175,17 @@ func (s *FullNodeStub) StateReplay(p0 context.Context, p1 types.TipSetKey, p2 ci
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateReplayEx(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 ReplayOptions) (*ReplayResult, error) {
+	if s.Internal.StateReplayEx == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateReplayEx(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateReplayEx(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 ReplayOptions) (*ReplayResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateSearchMsg(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) {
 	if s.Internal.StateSearchMsg == nil {
 		return nil, ErrNotSupported
@@ -3841,6 +4296,17 @@ func (s *FullNodeStub) StateWaitMsg(p0 context.Context, p1 cid.Cid, p2 uint64, p
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) SyncAddTrustedCheckpoints(p0 context.Context, p1 []TrustedCheckpoint) error {
+	if s.Internal.SyncAddTrustedCheckpoints == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SyncAddTrustedCheckpoints(p0, p1)
+}
+
+func (s *FullNodeStub) SyncAddTrustedCheckpoints(p0 context.Context, p1 []TrustedCheckpoint) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) SyncCheckBad(p0 context.Context, p1 cid.Cid) (string, error) {
 	if s.Internal.SyncCheckBad == nil {
 		return "", ErrNotSupported
@@ -3863,6 +4329,28 @@ func (s *FullNodeStub) SyncCheckpoint(p0 context.Context, p1 types.TipSetKey) er
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) SyncComputeProgress(p0 context.Context) ([]ComputeProgress, error) {
+	if s.Internal.SyncComputeProgress == nil {
+		return *new([]ComputeProgress), ErrNotSupported
+	}
+	return s.Internal.SyncComputeProgress(p0)
+}
+
+func (s *FullNodeStub) SyncComputeProgress(p0 context.Context) ([]ComputeProgress, error) {
+	return *new([]ComputeProgress), ErrNotSupported
+}
+
+func (s *FullNodeStruct) SyncImportProgress(p0 context.Context) (*ImportProgress, error) {
+	if s.Internal.SyncImportProgress == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SyncImportProgress(p0)
+}
+
+func (s *FullNodeStub) SyncImportProgress(p0 context.Context) (*ImportProgress, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) SyncIncomingBlocks(p0 context.Context) (<-chan *types.BlockHeader, error) {
 	if s.Internal.SyncIncomingBlocks == nil {
 		return nil, ErrNotSupported
@@ -3885,6 +4373,17 @@ func (s *FullNodeStub) SyncMarkBad(p0 context.Context, p1 cid.Cid) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) SyncRemoveTrustedCheckpoint(p0 context.Context, p1 abi.ChainEpoch) error {
+	if s.Internal.SyncRemoveTrustedCheckpoint == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SyncRemoveTrustedCheckpoint(p0, p1)
+}
+
+func (s *FullNodeStub) SyncRemoveTrustedCheckpoint(p0 context.Context, p1 abi.ChainEpoch) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) SyncState(p0 context.Context) (*SyncState, error) {
 	if s.Internal.SyncState == nil {
 		return nil, ErrNotSupported
@@ -3907,6 +4406,17 @@ func (s *FullNodeStub) SyncSubmitBlock(p0 context.Context, p1 *types.BlockMsg) e
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) SyncTrustedCheckpoints(p0 context.Context) ([]TrustedCheckpoint, error) {
+	if s.Internal.SyncTrustedCheckpoints == nil {
+		return *new([]TrustedCheckpoint), ErrNotSupported
+	}
+	return s.Internal.SyncTrustedCheckpoints(p0)
+}
+
+func (s *FullNodeStub) SyncTrustedCheckpoints(p0 context.Context) ([]TrustedCheckpoint, error) {
+	return *new([]TrustedCheckpoint), ErrNotSupported
+}
+
 func (s *FullNodeStruct) SyncUnmarkAllBad(p0 context.Context) error {
 	if s.Internal.SyncUnmarkAllBad == nil {
 		return ErrNotSupported
@@ -3973,6 +4483,17 @@ func (s *FullNodeStub) WalletDelete(p0 context.Context, p1 address.Address) erro
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletEncrypt(p0 context.Context, p1 string) error {
+	if s.Internal.WalletEncrypt == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WalletEncrypt(p0, p1)
+}
+
+func (s *FullNodeStub) WalletEncrypt(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletExport(p0 context.Context, p1 address.Address) (*types.KeyInfo, error) {
 	if s.Internal.WalletExport == nil {
 		return nil, ErrNotSupported
@@ -3984,6 +4505,39 @@ func (s *FullNodeStub) WalletExport(p0 context.Context, p1 address.Address) (*ty
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletHDDerive(p0 context.Context, p1 int64) (*HDKey, error) {
+	if s.Internal.WalletHDDerive == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.WalletHDDerive(p0, p1)
+}
+
+func (s *FullNodeStub) WalletHDDerive(p0 context.Context, p1 int64) (*HDKey, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) WalletHDNew(p0 context.Context, p1 int, p2 string) (*HDMnemonic, error) {
+	if s.Internal.WalletHDNew == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.WalletHDNew(p0, p1, p2)
+}
+
+func (s *FullNodeStub) WalletHDNew(p0 context.Context, p1 int, p2 string) (*HDMnemonic, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) WalletHDRestore(p0 context.Context, p1 string, p2 string, p3 int) ([]HDKey, error) {
+	if s.Internal.WalletHDRestore == nil {
+		return *new([]HDKey), ErrNotSupported
+	}
+	return s.Internal.WalletHDRestore(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) WalletHDRestore(p0 context.Context, p1 string, p2 string, p3 int) ([]HDKey, error) {
+	return *new([]HDKey), ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletHas(p0 context.Context, p1 address.Address) (bool, error) {
 	if s.Internal.WalletHas == nil {
 		return false, ErrNotSupported
@@ -4017,6 +4571,28 @@ func (s *FullNodeStub) WalletList(p0 context.Context) ([]address.Address, error)
 	return *new([]address.Address), ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletLock(p0 context.Context) error {
+	if s.Internal.WalletLock == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WalletLock(p0)
+}
+
+func (s *FullNodeStub) WalletLock(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) WalletLockStatus(p0 context.Context) (*LockStatus, error) {
+	if s.Internal.WalletLockStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.WalletLockStatus(p0)
+}
+
+func (s *FullNodeStub) WalletLockStatus(p0 context.Context) (*LockStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletNew(p0 context.Context, p1 types.KeyType) (address.Address, error) {
 	if s.Internal.WalletNew == nil {
 		return *new(address.Address), ErrNotSupported
@@ -4061,6 +4637,17 @@ func (s *FullNodeStub) WalletSignMessage(p0 context.Context, p1 address.Address,
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletUnlock(p0 context.Context, p1 string, p2 time.Duration) error {
+	if s.Internal.WalletUnlock == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WalletUnlock(p0, p1, p2)
+}
+
+func (s *FullNodeStub) WalletUnlock(p0 context.Context, p1 string, p2 time.Duration) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletValidateAddress(p0 context.Context, p1 string) (address.Address, error) {
 	if s.Internal.WalletValidateAddress == nil {
 		return *new(address.Address), ErrNotSupported
@@ -6756,6 +7343,17 @@ func (s *WalletStub) WalletDelete(p0 context.Context, p1 address.Address) error
 	return ErrNotSupported
 }
 
+func (s *WalletStruct) WalletEncrypt(p0 context.Context, p1 string) error {
+	if s.Internal.WalletEncrypt == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WalletEncrypt(p0, p1)
+}
+
+func (s *WalletStub) WalletEncrypt(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *WalletStruct) WalletExport(p0 context.Context, p1 address.Address) (*types.KeyInfo, error) {
 	if s.Internal.WalletExport == nil {
 		return nil, ErrNotSupported
@@ -6767,6 +7365,39 @@ func (s *WalletStub) WalletExport(p0 context.Context, p1 address.Address) (*type
 	return nil, ErrNotSupported
 }
 
+func (s *WalletStruct) WalletHDDerive(p0 context.Context, p1 int64) (*HDKey, error) {
+	if s.Internal.WalletHDDerive == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.WalletHDDerive(p0, p1)
+}
+
+func (s *WalletStub) WalletHDDerive(p0 context.Context, p1 int64) (*HDKey, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *WalletStruct) WalletHDNew(p0 context.Context, p1 int, p2 string) (*HDMnemonic, error) {
+	if s.Internal.WalletHDNew == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.WalletHDNew(p0, p1, p2)
+}
+
+func (s *WalletStub) WalletHDNew(p0 context.Context, p1 int, p2 string) (*HDMnemonic, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *WalletStruct) WalletHDRestore(p0 context.Context, p1 string, p2 string, p3 int) ([]HDKey, error) {
+	if s.Internal.WalletHDRestore == nil {
+		return *new([]HDKey), ErrNotSupported
+	}
+	return s.Internal.WalletHDRestore(p0, p1, p2, p3)
+}
+
+func (s *WalletStub) WalletHDRestore(p0 context.Context, p1 string, p2 string, p3 int) ([]HDKey, error) {
+	return *new([]HDKey), ErrNotSupported
+}
+
 func (s *WalletStruct) WalletHas(p0 context.Context, p1 address.Address) (bool, error) {
 	if s.Internal.WalletHas == nil {
 		return false, ErrNotSupported
@@ -6800,6 +7431,28 @@ func (s *WalletStub) WalletList(p0 context.Context) ([]address.Address, error) {
 	return *new([]address.Address), ErrNotSupported
 }
 
+func (s *WalletStruct) WalletLock(p0 context.Context) error {
+	if s.Internal.WalletLock == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WalletLock(p0)
+}
+
+func (s *WalletStub) WalletLock(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *WalletStruct) WalletLockStatus(p0 context.Context) (*LockStatus, error) {
+	if s.Internal.WalletLockStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.WalletLockStatus(p0)
+}
+
+func (s *WalletStub) WalletLockStatus(p0 context.Context) (*LockStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *WalletStruct) WalletNew(p0 context.Context, p1 types.KeyType) (address.Address, error) {
 	if s.Internal.WalletNew == nil {
 		return *new(address.Address), ErrNotSupported
@@ -6822,6 +7475,17 @@ func (s *WalletStub) WalletSign(p0 context.Context, p1 address.Address, p2 []byt
 	return nil, ErrNotSupported
 }
 
+func (s *WalletStruct) WalletUnlock(p0 context.Context, p1 string, p2 time.Duration) error {
+	if s.Internal.WalletUnlock == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WalletUnlock(p0, p1, p2)
+}
+
+func (s *WalletStub) WalletUnlock(p0 context.Context, p1 string, p2 time.Duration) error {
+	return ErrNotSupported
+}
+
 func (s *WorkerStruct) AddPiece(p0 context.Context, p1 storiface.SectorRef, p2 []abi.UnpaddedPieceSize, p3 abi.UnpaddedPieceSize, p4 storiface.Data) (storiface.CallID, error) {
 	if s.Internal.AddPiece == nil {
 		return *new(storiface.CallID), ErrNotSupported
@@ -245,9 +245,13 @@ type FullNode interface {
 	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) //perm:sign
 
 	// MpoolBatchPush batch pushes a signed message to mempool.
+	// Either all the messages are added, or none: when a message is refused, the
+	// error identifies its index in the batch.
 	MpoolBatchPush(context.Context, []*types.SignedMessage) ([]cid.Cid, error) //perm:write
 
 	// MpoolBatchPushUntrusted batch pushes a signed message to mempool from untrusted sources.
+	// Either all the messages are added, or none: when a message is refused, the
+	// error identifies its index in the batch.
 	MpoolBatchPushUntrusted(context.Context, []*types.SignedMessage) ([]cid.Cid, error) //perm:write
 
 	// MpoolBatchPushMessage batch pushes a unsigned message to mempool.
@@ -468,6 +472,10 @@ type FullNode interface {
 	// A replacing message is a message with a different CID, any of Gas values, and
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
+	//
+	// MsgLookup.Replaced is set when a replacing message is returned. When the message
+	// with the same sender and nonce found on chain isn't a replacing message, an
+	// error naming it is returned.
 	StateSearchMsg(context.Context, cid.Cid) (*api.MsgLookup, error) //perm:read
 	// StateSearchMsgLimited looks back up to limit epochs in the chain for a message, and returns its receipt and the tipset where it was executed
 	//
@@ -484,6 +492,10 @@ type FullNode interface {
 	// A replacing message is a message with a different CID, any of Gas values, and
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
+	//
+	// MsgLookup.Replaced is set when a replacing message is returned. When the message
+	// with the same sender and nonce found on chain isn't a replacing message, an
+	// error naming it is returned.
 	StateSearchMsgLimited(ctx context.Context, msg cid.Cid, limit abi.ChainEpoch) (*api.MsgLookup, error) //perm:read
 	// StateWaitMsg looks back in the chain for a message. If not found, it blocks until the
 	// message arrives on chain, and gets to the indicated confidence depth.
@@ -501,6 +513,10 @@ type FullNode interface {
 	// A replacing message is a message with a different CID, any of Gas values, and
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
+	//
+	// MsgLookup.Replaced is set when a replacing message is returned. When the message
+	// with the same sender and nonce found on chain isn't a replacing message, an
+	// error naming it is returned.
 	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64) (*api.MsgLookup, error) //perm:read
 	// StateWaitMsgLimited looks back up to limit epochs in the chain for a message.
 	// If not found, it blocks until the message arrives on chain, and gets to the
@@ -519,6 +535,10 @@ type FullNode interface {
 	// A replacing message is a message with a different CID, any of Gas values, and
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
+	//
+	// MsgLookup.Replaced is set when a replacing message is returned. When the message
+	// with the same sender and nonce found on chain isn't a replacing message, an
+	// error naming it is returned.
 	StateWaitMsgLimited(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch) (*api.MsgLookup, error) //perm:read
 	// StateListMiners returns the addresses of every miner that has claimed power in the Power Actor
 	StateListMiners(context.Context, types.TipSetKey) ([]address.Address, error) //perm:read
@@ -444,7 +444,7 @@ func (b *Blockstore) deleteDB(path string) {
 	}
 }
 
-func (b *Blockstore) onlineGC(ctx context.Context, threshold float64, checkFreq time.Duration, check func() error) error {
+func (b *Blockstore) onlineGC(ctx context.Context, threshold float64, checkFreq time.Duration, check func() error, throttle time.Duration, progress func(blockstore.BlockstoreGCProgress)) error {
 	b.lockDB()
 	defer b.unlockDB()
 
@@ -461,6 +461,11 @@ func (b *Blockstore) onlineGC(ctx context.Context, threshold float64, checkFreq
 	if err != nil {
 		return err
 	}
+
+	var status blockstore.BlockstoreGCProgress
+	status.Compacted = true
+	progress(status)
+
 	checkTick := time.NewTimer(checkFreq)
 	defer checkTick.Stop()
 	for err == nil {
@@ -472,6 +477,20 @@ func (b *Blockstore) onlineGC(ctx context.Context, threshold float64, checkFreq
 			checkTick.Reset(checkFreq)
 		default:
 			err = b.db.RunValueLogGC(threshold)
+			if err != nil {
+				break
+			}
+
+			status.Rewrites++
+			progress(status)
+
+			if throttle > 0 {
+				select {
+				case <-ctx.Done():
+					err = ctx.Err()
+				case <-time.After(throttle):
+				}
+			}
 		}
 	}
 
@@ -516,7 +535,11 @@ func (b *Blockstore) CollectGarbage(ctx context.Context, opts ...blockstore.Bloc
 			return nil
 		}
 	}
-	return b.onlineGC(ctx, threshold, checkFreq, check)
+	progress := options.Progress
+	if progress == nil {
+		progress = func(blockstore.BlockstoreGCProgress) {}
+	}
+	return b.onlineGC(ctx, threshold, checkFreq, check, options.Throttle, progress)
 }
 
 // GCOnce runs garbage collection on the value log;
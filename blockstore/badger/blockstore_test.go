@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
@@ -270,3 +271,36 @@ func TestMoveWithPrefix(t *testing.T) {
 		return opts
 	})
 }
+
+func TestBadgerOnlineGCProgress(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := Open(DefaultOptions(t.TempDir()))
+	require.NoError(t, err)
+	defer db.Close() //nolint
+
+	for i := 0; i < 100; i++ {
+		blk := blocks.NewBlock([]byte(fmt.Sprintf("some data %d", i)))
+		require.NoError(t, db.Put(ctx, blk))
+		if i%2 == 0 {
+			require.NoError(t, db.DeleteBlock(ctx, blk.Cid()))
+		}
+	}
+
+	var reports []blockstore.BlockstoreGCProgress
+	err = db.CollectGarbage(ctx,
+		blockstore.WithThrottle(time.Millisecond),
+		blockstore.WithProgress(func(p blockstore.BlockstoreGCProgress) {
+			reports = append(reports, p)
+		}),
+	)
+	require.NoError(t, err)
+
+	// the store is compacted before any value log is rewritten
+	require.NotEmpty(t, reports)
+	require.True(t, reports[0].Compacted)
+	require.Equal(t, 0, reports[0].Rewrites)
+	for i, p := range reports {
+		require.Equal(t, i, p.Rewrites)
+	}
+}
@@ -62,6 +62,18 @@ type BlockstoreGCOptions struct {
 	CheckFreq time.Duration
 	// function to call periodically to pause or early terminate GC
 	Check func() error
+	// pause between value log rewrites in online GC, bounding the IO load it puts on the store
+	Throttle time.Duration
+	// function to call as online GC makes progress
+	Progress func(BlockstoreGCProgress)
+}
+
+// BlockstoreGCProgress reports the progress of an online GC
+type BlockstoreGCProgress struct {
+	// whether the store has been compacted ahead of the value log GC
+	Compacted bool
+	// number of value log files rewritten so far
+	Rewrites int
 }
 
 func WithFullGC(fullgc bool) BlockstoreGCOption {
@@ -92,6 +104,20 @@ func WithCheck(check func() error) BlockstoreGCOption {
 	}
 }
 
+func WithThrottle(d time.Duration) BlockstoreGCOption {
+	return func(opts *BlockstoreGCOptions) error {
+		opts.Throttle = d
+		return nil
+	}
+}
+
+func WithProgress(progress func(BlockstoreGCProgress)) BlockstoreGCOption {
+	return func(opts *BlockstoreGCOptions) error {
+		opts.Progress = progress
+		return nil
+	}
+}
+
 // BlockstoreSize is a trait for on-disk blockstores that can report their size
 type BlockstoreSize interface {
 	Size() (int64, error)
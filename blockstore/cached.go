@@ -0,0 +1,213 @@
+package blockstore
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// CachedBlockstore is a read-through blockstore caching the blocks read from the underlying
+// blockstore in memory, up to a budget in bytes, evicting the least recently used blocks first.
+// Blocks are immutable, so cached blocks never need to be invalidated except on deletion.
+//
+// The cache reports its metrics through CacheMeasures, tagged with its name, until Stop is
+// called.
+type CachedBlockstore struct {
+	Blockstore
+
+	name   string
+	budget int64
+
+	lk    sync.Mutex
+	cache *simplelru.LRU[string, []byte]
+	size  int64
+
+	hits, misses, adds, evictions, costAdded, costEvicted, rejected atomic.Int64
+
+	closeOnce sync.Once
+	closing   chan struct{}
+}
+
+var _ Blockstore = (*CachedBlockstore)(nil)
+
+// NewCachedBlockstore wraps bs with a read-through cache of at most budget bytes of block data.
+func NewCachedBlockstore(name string, bs Blockstore, budget int64) *CachedBlockstore {
+	c := &CachedBlockstore{
+		Blockstore: bs,
+		name:       name,
+		budget:     budget,
+		closing:    make(chan struct{}),
+	}
+
+	// entries are bounded by the byte budget, not by their count
+	c.cache, _ = simplelru.NewLRU[string, []byte](math.MaxInt32, c.onEvict)
+
+	go c.emitMetrics()
+	return c
+}
+
+// onEvict is called with lk held.
+func (c *CachedBlockstore) onEvict(_ string, data []byte) {
+	c.size -= int64(len(data))
+	c.evictions.Add(1)
+	c.costEvicted.Add(int64(len(data)))
+}
+
+func (c *CachedBlockstore) get(k cid.Cid) ([]byte, bool) {
+	c.lk.Lock()
+	data, ok := c.cache.Get(string(k.Hash()))
+	c.lk.Unlock()
+
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return data, ok
+}
+
+func (c *CachedBlockstore) add(k cid.Cid, data []byte) {
+	cost := int64(len(data))
+	if cost > c.budget {
+		c.rejected.Add(1)
+		return
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	key := string(k.Hash())
+	if c.cache.Contains(key) {
+		return
+	}
+	c.cache.Add(key, data)
+	c.size += cost
+	for c.size > c.budget {
+		c.cache.RemoveOldest()
+	}
+
+	c.adds.Add(1)
+	c.costAdded.Add(cost)
+}
+
+func (c *CachedBlockstore) remove(k cid.Cid) {
+	c.lk.Lock()
+	c.cache.Remove(string(k.Hash()))
+	c.lk.Unlock()
+}
+
+func (c *CachedBlockstore) Has(ctx context.Context, k cid.Cid) (bool, error) {
+	c.lk.Lock()
+	ok := c.cache.Contains(string(k.Hash()))
+	c.lk.Unlock()
+	if ok {
+		return true, nil
+	}
+	return c.Blockstore.Has(ctx, k)
+}
+
+func (c *CachedBlockstore) View(ctx context.Context, k cid.Cid, cb func([]byte) error) error {
+	if data, ok := c.get(k); ok {
+		return cb(data)
+	}
+
+	return c.Blockstore.View(ctx, k, func(data []byte) error {
+		// the underlying blockstore may reuse the buffer once the callback returns
+		cp := make([]byte, len(data))
+		copy(cp, data)
+		c.add(k, cp)
+		return cb(cp)
+	})
+}
+
+func (c *CachedBlockstore) Get(ctx context.Context, k cid.Cid) (blocks.Block, error) {
+	if data, ok := c.get(k); ok {
+		return blocks.NewBlockWithCid(data, k)
+	}
+
+	blk, err := c.Blockstore.Get(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	c.add(k, blk.RawData())
+	return blk, nil
+}
+
+func (c *CachedBlockstore) GetSize(ctx context.Context, k cid.Cid) (int, error) {
+	c.lk.Lock()
+	data, ok := c.cache.Peek(string(k.Hash()))
+	c.lk.Unlock()
+	if ok {
+		return len(data), nil
+	}
+	return c.Blockstore.GetSize(ctx, k)
+}
+
+func (c *CachedBlockstore) DeleteBlock(ctx context.Context, k cid.Cid) error {
+	c.remove(k)
+	return c.Blockstore.DeleteBlock(ctx, k)
+}
+
+func (c *CachedBlockstore) DeleteMany(ctx context.Context, ks []cid.Cid) error {
+	for _, k := range ks {
+		c.remove(k)
+	}
+	return c.Blockstore.DeleteMany(ctx, ks)
+}
+
+// Stop stops reporting metrics. It doesn't close the underlying blockstore.
+func (c *CachedBlockstore) Stop() {
+	c.closeOnce.Do(func() {
+		close(c.closing)
+	})
+}
+
+func (c *CachedBlockstore) emitMetrics() {
+	ctx, err := tag.New(context.Background(), tag.Upsert(CacheName, c.name))
+	if err != nil {
+		log.Errorf("failed to tag blockstore cache metrics: %s", err)
+		return
+	}
+
+	ticker := time.NewTicker(CacheMetricsEmitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.closing:
+			return
+		}
+
+		c.lk.Lock()
+		entries := int64(c.cache.Len())
+		c.lk.Unlock()
+
+		hits, misses := c.hits.Load(), c.misses.Load()
+		var ratio float64
+		if hits+misses > 0 {
+			ratio = float64(hits) / float64(hits+misses)
+		}
+
+		stats.Record(ctx,
+			CacheMeasures.HitRatio.M(ratio),
+			CacheMeasures.Hits.M(hits),
+			CacheMeasures.Misses.M(misses),
+			CacheMeasures.Entries.M(entries),
+			CacheMeasures.QueriesServed.M(hits+misses),
+			CacheMeasures.Adds.M(c.adds.Load()),
+			CacheMeasures.Evictions.M(c.evictions.Load()),
+			CacheMeasures.CostAdded.M(c.costAdded.Load()),
+			CacheMeasures.CostEvicted.M(c.costEvicted.Load()),
+			CacheMeasures.SetsRejected.M(c.rejected.Load()),
+		)
+	}
+}
@@ -0,0 +1,46 @@
+package blockstore
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedBlockstore(t *testing.T) {
+	ctx := context.Background()
+
+	inner := NewMemory()
+	var blks []blocks.Block
+	for _, s := range []string{"one", "two", "three"} {
+		blk := blocks.NewBlock([]byte(s))
+		require.NoError(t, inner.Put(ctx, blk))
+		blks = append(blks, blk)
+	}
+
+	// room for two of the blocks
+	cbs := NewCachedBlockstore("test", inner, 8)
+	defer cbs.Stop()
+
+	for _, blk := range blks {
+		got, err := cbs.Get(ctx, blk.Cid())
+		require.NoError(t, err)
+		require.Equal(t, blk.RawData(), got.RawData())
+	}
+	require.EqualValues(t, 3, cbs.misses.Load())
+	require.EqualValues(t, 1, cbs.evictions.Load())
+
+	// the most recently read blocks are served from the cache
+	require.NoError(t, cbs.View(ctx, blks[2].Cid(), func(b []byte) error {
+		require.Equal(t, blks[2].RawData(), b)
+		return nil
+	}))
+	require.EqualValues(t, 1, cbs.hits.Load())
+
+	// deletions are not served from the cache
+	require.NoError(t, cbs.DeleteBlock(ctx, blks[2].Cid()))
+	_, err := cbs.Get(ctx, blks[2].Cid())
+	require.True(t, ipld.IsNotFound(err))
+}
@@ -9,9 +9,9 @@ import (
 )
 
 //
-// Currently unused, but kept in repo in case we introduce one of the candidate
-// cache implementations (Freecache, Ristretto), both of which report these
-// metrics.
+// These metrics are reported by CachedBlockstore. Some of them (updates, dropped
+// sets and queries) are only meaningful for the candidate cache implementations
+// (Freecache, Ristretto) they were modelled after, and aren't reported.
 //
 
 // CacheMetricsEmitInterval is the interval at which metrics are emitted onto
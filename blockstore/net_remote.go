@@ -0,0 +1,132 @@
+package blockstore
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// RemoteStore is a read-only blockstore reading blocks from a blockstore served by another node
+// over a websocket, e.g. the /rest/v0/chain/blockstore endpoint of a lotus daemon. The
+// connection is established lazily, and re-established on the next request after it drops.
+type RemoteStore struct {
+	url    string
+	header http.Header
+
+	lk  sync.Mutex
+	cur *NetworkStore
+}
+
+var _ Blockstore = (*RemoteStore)(nil)
+
+// NewRemoteStore returns a blockstore reading from the websocket blockstore endpoint at url,
+// sending header (e.g. an Authorization header) when connecting.
+func NewRemoteStore(url string, header http.Header) *RemoteStore {
+	return &RemoteStore{url: url, header: header}
+}
+
+func (r *RemoteStore) store(ctx context.Context) (*NetworkStore, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if r.cur != nil {
+		return r.cur, nil
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, r.url, r.header)
+	if err != nil {
+		return nil, xerrors.Errorf("connecting to remote blockstore %s: %w", r.url, err)
+	}
+
+	ns := NewNetworkStoreWS(conn)
+	ns.OnClose(func() {
+		// the callback may run synchronously, while lk is held
+		go r.drop(ns)
+	})
+	r.cur = ns
+	return ns, nil
+}
+
+func (r *RemoteStore) drop(ns *NetworkStore) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if r.cur == ns {
+		log.Warnw("remote blockstore connection closed", "url", r.url)
+		r.cur = nil
+	}
+}
+
+func (r *RemoteStore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	ns, err := r.store(ctx)
+	if err != nil {
+		return false, err
+	}
+	return ns.Has(ctx, c)
+}
+
+func (r *RemoteStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	ns, err := r.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ns.Get(ctx, c)
+}
+
+func (r *RemoteStore) View(ctx context.Context, c cid.Cid, callback func([]byte) error) error {
+	ns, err := r.store(ctx)
+	if err != nil {
+		return err
+	}
+	return ns.View(ctx, c, callback)
+}
+
+func (r *RemoteStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	ns, err := r.store(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return ns.GetSize(ctx, c)
+}
+
+func (r *RemoteStore) Put(context.Context, blocks.Block) error {
+	return ErrReadOnly
+}
+
+func (r *RemoteStore) PutMany(context.Context, []blocks.Block) error {
+	return ErrReadOnly
+}
+
+func (r *RemoteStore) DeleteBlock(context.Context, cid.Cid) error {
+	return ErrReadOnly
+}
+
+func (r *RemoteStore) DeleteMany(context.Context, []cid.Cid) error {
+	return ErrReadOnly
+}
+
+func (r *RemoteStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return nil, xerrors.Errorf("not supported")
+}
+
+func (r *RemoteStore) HashOnRead(enabled bool) {}
+
+func (*RemoteStore) Flush(context.Context) error { return nil }
+
+// Close closes the current connection, if any.
+func (r *RemoteStore) Close() error {
+	r.lk.Lock()
+	ns := r.cur
+	r.cur = nil
+	r.lk.Unlock()
+
+	if ns == nil {
+		return nil
+	}
+	return ns.Stop(context.Background())
+}
@@ -0,0 +1,67 @@
+package blockstore
+
+import (
+	"context"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// ErrReadOnly is returned when writing to a read-only blockstore.
+var ErrReadOnly = xerrors.New("blockstore is read-only")
+
+var _ Blockstore = (*readonlystore)(nil)
+
+type readonlystore struct {
+	bs Blockstore
+}
+
+// NewReadOnlyStore returns a view of bs which rejects all writes and deletions with ErrReadOnly.
+func NewReadOnlyStore(bs Blockstore) Blockstore {
+	return &readonlystore{bs: bs}
+}
+
+func (b *readonlystore) Has(ctx context.Context, cid cid.Cid) (bool, error) {
+	return b.bs.Has(ctx, cid)
+}
+
+func (b *readonlystore) HashOnRead(hor bool) {
+	b.bs.HashOnRead(hor)
+}
+
+func (b *readonlystore) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
+	return b.bs.Get(ctx, cid)
+}
+
+func (b *readonlystore) GetSize(ctx context.Context, cid cid.Cid) (int, error) {
+	return b.bs.GetSize(ctx, cid)
+}
+
+func (b *readonlystore) View(ctx context.Context, cid cid.Cid, f func([]byte) error) error {
+	return b.bs.View(ctx, cid, f)
+}
+
+func (b *readonlystore) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (b *readonlystore) Put(ctx context.Context, blk blocks.Block) error {
+	return ErrReadOnly
+}
+
+func (b *readonlystore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	return ErrReadOnly
+}
+
+func (b *readonlystore) DeleteBlock(ctx context.Context, cid cid.Cid) error {
+	return ErrReadOnly
+}
+
+func (b *readonlystore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	return ErrReadOnly
+}
+
+func (b *readonlystore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return b.bs.AllKeysChan(ctx)
+}
@@ -0,0 +1,365 @@
+// Package s3bs implements a blockstore backed by an S3-compatible object store, for use as a
+// splitstore coldstore. Blocks are stored as one object each, keyed by the base32 encoding of
+// their multihash like the badger blockstore, so CIDs differing only in codec share an object.
+package s3bs
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	logger "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-base32"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/lib/sigv4"
+)
+
+var log = logger.Logger("s3bs")
+
+// Options configures an S3 blockstore.
+type Options struct {
+	// Endpoint is the base URL of the object store, e.g. https://s3.us-east-1.amazonaws.com.
+	Endpoint string
+	// Region is the region requests are signed for.
+	Region string
+	// Bucket is the bucket blocks are stored in; it must exist.
+	Bucket string
+	// Prefix is an optional prefix prepended to object keys.
+	Prefix string
+	// PathStyle addresses the bucket as a path of the endpoint rather than as a subdomain, as
+	// required by most self-hosted object stores.
+	PathStyle bool
+
+	AccessKey string
+	SecretKey string
+
+	// PutConcurrency bounds the number of concurrent uploads of PutMany. Default: 16.
+	PutConcurrency int
+	// Client is the HTTP client used for requests. Default: a client with a 1 minute timeout.
+	Client *http.Client
+}
+
+// Blockstore is a blockstore backed by an S3-compatible object store.
+type Blockstore struct {
+	opts   Options
+	base   *url.URL
+	client *http.Client
+}
+
+var _ blockstore.Blockstore = (*Blockstore)(nil)
+var _ blockstore.BlockstoreIterator = (*Blockstore)(nil)
+var _ blockstore.BlockstoreGC = (*Blockstore)(nil)
+
+// Open returns a blockstore for the given bucket. It checks that the bucket is reachable with
+// the given credentials.
+func Open(ctx context.Context, opts Options) (*Blockstore, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" || opts.Region == "" {
+		return nil, xerrors.Errorf("s3 blockstore requires an endpoint, a region and a bucket")
+	}
+	base, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing s3 endpoint: %w", err)
+	}
+	if opts.PathStyle {
+		base.Path = "/" + opts.Bucket + "/"
+	} else {
+		base.Host = opts.Bucket + "." + base.Host
+		base.Path = "/"
+	}
+	if opts.PutConcurrency <= 0 {
+		opts.PutConcurrency = 16
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+
+	b := &Blockstore{opts: opts, base: base, client: client}
+
+	// HEAD on the bucket checks both its existence and our credentials.
+	resp, err := b.do(ctx, http.MethodHead, "", nil, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("checking s3 bucket %s: %w", opts.Bucket, err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("checking s3 bucket %s: unexpected status %s", opts.Bucket, resp.Status)
+	}
+
+	return b, nil
+}
+
+func (b *Blockstore) objectKey(c cid.Cid) string {
+	return b.opts.Prefix + base32.RawStdEncoding.EncodeToString(c.Hash())
+}
+
+func (b *Blockstore) keyCid(key string) (cid.Cid, error) {
+	mh, err := base32.RawStdEncoding.DecodeString(strings.TrimPrefix(key, b.opts.Prefix))
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("decoding object key %q: %w", key, err)
+	}
+	return cid.NewCidV1(cid.Raw, mh), nil
+}
+
+// do sends a signed request for the given object key (the bucket itself if empty).
+func (b *Blockstore) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *b.base
+	u.Path += key
+	if query != nil {
+		u.RawQuery = sigv4.EncodeQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+
+	payloadHash := sigv4.EmptyPayloadHash
+	if len(body) > 0 {
+		payloadHash = sigv4.PayloadHash(body)
+	}
+	sigv4.Sign(req, b.opts.AccessKey, b.opts.SecretKey, b.opts.Region, "s3", payloadHash, time.Now())
+
+	return b.client.Do(req)
+}
+
+// errorResponse reads the body of a failed request into an error.
+func errorResponse(op string, c cid.Cid, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return xerrors.Errorf("s3 %s %s: unexpected status %s: %s", op, c, resp.Status, bytes.TrimSpace(msg))
+}
+
+func (b *Blockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	resp, err := b.do(ctx, http.MethodHead, b.objectKey(c), nil, nil)
+	if err != nil {
+		return false, xerrors.Errorf("s3 has %s: %w", c, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errorResponse("has", c, resp)
+	}
+}
+
+func (b *Blockstore) View(ctx context.Context, c cid.Cid, fn func([]byte) error) error {
+	resp, err := b.do(ctx, http.MethodGet, b.objectKey(c), nil, nil)
+	if err != nil {
+		return xerrors.Errorf("s3 get %s: %w", c, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return ipld.ErrNotFound{Cid: c}
+	default:
+		return errorResponse("get", c, resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return xerrors.Errorf("s3 get %s: reading body: %w", c, err)
+	}
+	return fn(data)
+}
+
+func (b *Blockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	var blk blocks.Block
+	err := b.View(ctx, c, func(data []byte) error {
+		var err error
+		blk, err = blocks.NewBlockWithCid(data, c)
+		return err
+	})
+	return blk, err
+}
+
+func (b *Blockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	resp, err := b.do(ctx, http.MethodHead, b.objectKey(c), nil, nil)
+	if err != nil {
+		return -1, xerrors.Errorf("s3 size %s: %w", c, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return strconv.Atoi(resp.Header.Get("Content-Length"))
+	case http.StatusNotFound:
+		return -1, ipld.ErrNotFound{Cid: c}
+	default:
+		return -1, errorResponse("size", c, resp)
+	}
+}
+
+func (b *Blockstore) Put(ctx context.Context, blk blocks.Block) error {
+	resp, err := b.do(ctx, http.MethodPut, b.objectKey(blk.Cid()), nil, blk.RawData())
+	if err != nil {
+		return xerrors.Errorf("s3 put %s: %w", blk.Cid(), err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return errorResponse("put", blk.Cid(), resp)
+	}
+	return nil
+}
+
+func (b *Blockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(b.opts.PutConcurrency)
+	for _, blk := range blks {
+		blk := blk
+		g.Go(func() error {
+			return b.Put(ctx, blk)
+		})
+	}
+	return g.Wait()
+}
+
+func (b *Blockstore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	resp, err := b.do(ctx, http.MethodDelete, b.objectKey(c), nil, nil)
+	if err != nil {
+		return xerrors.Errorf("s3 delete %s: %w", c, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return errorResponse("delete", c, resp)
+	}
+}
+
+func (b *Blockstore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(b.opts.PutConcurrency)
+	for _, c := range cids {
+		c := c
+		g.Go(func() error {
+			return b.DeleteBlock(ctx, c)
+		})
+	}
+	return g.Wait()
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// list calls fn with every object key under the prefix, a page at a time.
+func (b *Blockstore) list(ctx context.Context, fn func(key string) error) error {
+	var token string
+	for {
+		q := url.Values{"list-type": []string{"2"}}
+		if b.opts.Prefix != "" {
+			q.Set("prefix", b.opts.Prefix)
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+
+		resp, err := b.do(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return xerrors.Errorf("s3 list: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := errorResponse("list", cid.Undef, resp)
+			resp.Body.Close() //nolint:errcheck
+			return err
+		}
+
+		var res listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close() //nolint:errcheck
+		if err != nil {
+			return xerrors.Errorf("s3 list: decoding response: %w", err)
+		}
+
+		for _, o := range res.Contents {
+			if err := fn(o.Key); err != nil {
+				return err
+			}
+		}
+
+		if !res.IsTruncated {
+			return nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+// ForEachKey lists the bucket. CIDs are reconstructed from object keys with the raw codec.
+func (b *Blockstore) ForEachKey(f func(cid.Cid) error) error {
+	return b.list(context.Background(), func(key string) error {
+		c, err := b.keyCid(key)
+		if err != nil {
+			log.Warnf("skipping foreign object: %s", err)
+			return nil
+		}
+		return f(c)
+	})
+}
+
+func (b *Blockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	ch := make(chan cid.Cid)
+	go func() {
+		defer close(ch)
+		err := b.list(ctx, func(key string) error {
+			c, err := b.keyCid(key)
+			if err != nil {
+				log.Warnf("skipping foreign object: %s", err)
+				return nil
+			}
+			select {
+			case ch <- c:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("error listing s3 blockstore keys: %s", err)
+		}
+	}()
+	return ch, nil
+}
+
+// CollectGarbage is a no-op: deleted objects free their space immediately.
+func (b *Blockstore) CollectGarbage(context.Context, ...blockstore.BlockstoreGCOption) error {
+	return nil
+}
+
+func (b *Blockstore) HashOnRead(bool) {
+	log.Warnf("called HashOnRead on s3 blockstore; function not supported; ignoring")
+}
+
+func (b *Blockstore) Flush(context.Context) error {
+	return nil
+}
+
+func (b *Blockstore) String() string {
+	return fmt.Sprintf("s3bs(%s%s)", b.base.Host, b.base.Path)
+}
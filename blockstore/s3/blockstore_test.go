@@ -0,0 +1,139 @@
+package s3bs
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 serves a single path-style bucket from memory, listing at most two keys per page.
+type fakeS3 struct {
+	lk      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	if r.Header.Get("Authorization") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/"+f.bucket+"/")
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+	case key == "" && r.Method == http.MethodGet:
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) && k > r.URL.Query().Get("continuation-token") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		var res struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		for _, k := range keys {
+			if len(res.Contents) == 2 {
+				res.IsTruncated = true
+				res.NextContinuationToken = res.Contents[1].Key
+				break
+			}
+			res.Contents = append(res.Contents, struct{ Key string }{k})
+		}
+		_ = xml.NewEncoder(w).Encode(res)
+	case r.Method == http.MethodPut:
+		f.objects[key], _ = io.ReadAll(r.Body)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	}
+}
+
+func TestS3Blockstore(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(&fakeS3{bucket: "blocks", objects: make(map[string][]byte)})
+	defer srv.Close()
+
+	bs, err := Open(ctx, Options{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		Bucket:    "blocks",
+		Prefix:    "cold/",
+		PathStyle: true,
+		AccessKey: "key",
+		SecretKey: "secret",
+	})
+	require.NoError(t, err)
+
+	var blks []blocks.Block
+	for _, s := range []string{"foo", "bar", "baz", "qux", "quux"} {
+		blks = append(blks, blocks.NewBlock([]byte(s)))
+	}
+	require.NoError(t, bs.Put(ctx, blks[0]))
+	require.NoError(t, bs.PutMany(ctx, blks[1:]))
+
+	for _, blk := range blks {
+		has, err := bs.Has(ctx, blk.Cid())
+		require.NoError(t, err)
+		require.True(t, has)
+
+		got, err := bs.Get(ctx, blk.Cid())
+		require.NoError(t, err)
+		require.Equal(t, blk.RawData(), got.RawData())
+
+		size, err := bs.GetSize(ctx, blk.Cid())
+		require.NoError(t, err)
+		require.Equal(t, len(blk.RawData()), size)
+	}
+
+	var keys []cid.Cid
+	require.NoError(t, bs.ForEachKey(func(c cid.Cid) error {
+		keys = append(keys, c)
+		return nil
+	}))
+	require.Len(t, keys, len(blks))
+
+	require.NoError(t, bs.DeleteMany(ctx, []cid.Cid{blks[0].Cid(), blks[1].Cid()}))
+	_, err = bs.Get(ctx, blks[0].Cid())
+	require.True(t, ipld.IsNotFound(err))
+
+	ch, err := bs.AllKeysChan(ctx)
+	require.NoError(t, err)
+	var n int
+	for range ch {
+		n++
+	}
+	require.Equal(t, len(blks)-2, n)
+}
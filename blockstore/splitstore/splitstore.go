@@ -49,6 +49,9 @@ var (
 	// stores the base epoch of last prune in the metadata store
 	pruneEpochKey = dstore.NewKey("/splitstore/pruneEpoch")
 
+	// stores the time (unix seconds) at which the last prune completed
+	lastPruneTimeKey = dstore.NewKey("/splitstore/lastPruneTime")
+
 	log = logging.Logger("splitstore")
 
 	errClosing = errors.New("splitstore is closing")
@@ -132,6 +135,18 @@ type Config struct {
 	// Moving GC will not occur when total moving size exceeds
 	// HotstoreMaxSpaceTarget - HotstoreMaxSpaceSafetyBuffer
 	HotstoreMaxSpaceSafetyBuffer uint64
+
+	// ColdStoreGCInterval is the interval at which the coldstore is pruned automatically.
+	// A value of 0 disables scheduled pruning.
+	ColdStoreGCInterval time.Duration
+
+	// ColdStoreMaxSpaceTarget triggers an automatic prune with moving GC whenever the coldstore
+	// grows past it. A value of 0 disables it.
+	ColdStoreMaxSpaceTarget uint64
+
+	// ColdStoreGCRetainState is the state retention policy of automatic prunes, with the
+	// semantics of PruneRetainState.
+	ColdStoreGCRetainState int64
 }
 
 // ChainAccessor allows the Splitstore to access the chain. It will most likely
@@ -183,6 +198,7 @@ type SplitStore struct {
 	compactionIndex int64
 	pruneIndex      int64
 	onlineGCCnt     int64
+	lastPruneTime   time.Time // protected by compaction lock
 
 	ctx    context.Context
 	cancel func()
@@ -766,6 +782,18 @@ func (s *SplitStore) Start(chain ChainAccessor, us stmgr.UpgradeSchedule) error
 		return xerrors.Errorf("error loading warmup epoch: %w", err)
 	}
 
+	// load the time of the last prune, to schedule the next one
+	bs, err = s.ds.Get(s.ctx, lastPruneTimeKey)
+	switch err {
+	case nil:
+		s.lastPruneTime = time.Unix(bytesToInt64(bs), 0)
+	case dstore.ErrNotFound:
+		// count the interval from now, rather than pruning right away
+		s.lastPruneTime = time.Now()
+	default:
+		return xerrors.Errorf("error loading last prune time: %w", err)
+	}
+
 	// load markSetSize from metadata ds to provide a size hint for marksets
 	bs, err = s.ds.Get(s.ctx, markSetSizeKey)
 	switch err {
@@ -803,6 +831,11 @@ func (s *SplitStore) Start(chain ChainAccessor, us stmgr.UpgradeSchedule) error
 	// spawn the reifier
 	go s.reifyOrchestrator()
 
+	// schedule automatic coldstore gc
+	if s.cfg.ColdStoreGCInterval > 0 || s.cfg.ColdStoreMaxSpaceTarget > 0 {
+		go s.coldGCScheduler()
+	}
+
 	// watch the chain
 	chain.SubscribeHeadChanges(s.HeadChange)
 
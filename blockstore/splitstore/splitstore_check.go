@@ -160,5 +160,19 @@ func (s *SplitStore) Info() map[string]interface{} {
 		}
 	}
 
+	info["pruning"] = s.compacting == 1 && s.compactType == cold
+	if !s.lastPruneTime.IsZero() {
+		info["last prune"] = s.lastPruneTime.Format(time.RFC3339)
+	}
+	if s.cfg.ColdStoreGCInterval > 0 {
+		info["next scheduled prune"] = s.lastPruneTime.Add(s.cfg.ColdStoreGCInterval).Format(time.RFC3339)
+	}
+	if s.cfg.ColdStoreMaxSpaceTarget > 0 {
+		info["coldstore size target"] = s.cfg.ColdStoreMaxSpaceTarget
+	}
+	if size, ok := s.coldStoreSize(); ok {
+		info["coldstore size"] = size
+	}
+
 	return info
 }
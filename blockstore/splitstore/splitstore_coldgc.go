@@ -0,0 +1,69 @@
+package splitstore
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// coldGCCheckInterval is how often the coldstore gc scheduler checks whether a prune is due.
+var coldGCCheckInterval = 10 * time.Minute
+
+// coldGCScheduler prunes the coldstore every ColdStoreGCInterval, and with moving GC whenever the
+// coldstore grows past ColdStoreMaxSpaceTarget. Prunes that can't start because a compaction is
+// in progress are retried at the next check.
+func (s *SplitStore) coldGCScheduler() {
+	ticker := time.NewTicker(coldGCCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.maybeColdGC()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *SplitStore) maybeColdGC() {
+	if atomic.LoadInt32(&s.compacting) == 1 {
+		return
+	}
+
+	overTarget := false
+	if s.cfg.ColdStoreMaxSpaceTarget > 0 {
+		size, ok := s.coldStoreSize()
+		overTarget = ok && size > int64(s.cfg.ColdStoreMaxSpaceTarget)
+	}
+
+	// lastPruneTime is only written by prunes, which can't be running at this point.
+	due := s.cfg.ColdStoreGCInterval > 0 && time.Since(s.lastPruneTime) >= s.cfg.ColdStoreGCInterval
+	if !due && !overTarget {
+		return
+	}
+
+	log.Infow("starting scheduled coldstore gc", "interval due", due, "over space target", overTarget)
+	err := s.PruneChain(api.PruneOpts{
+		MovingGC:    overTarget,
+		RetainState: s.cfg.ColdStoreGCRetainState,
+	})
+	if err != nil {
+		log.Warnf("scheduled coldstore gc not started: %s", err)
+	}
+}
+
+func (s *SplitStore) coldStoreSize() (int64, bool) {
+	sizer, ok := s.cold.(bstore.BlockstoreSize)
+	if !ok {
+		return 0, false
+	}
+	size, err := sizer.Size()
+	if err != nil {
+		log.Warnf("error getting coldstore size: %s", err)
+		return 0, false
+	}
+	return size, true
+}
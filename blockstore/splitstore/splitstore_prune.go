@@ -2,6 +2,7 @@ package splitstore
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"runtime"
 	"sync"
@@ -64,6 +65,18 @@ func (s *SplitStore) GCHotStore(opts api.HotGCOpts) error {
 	return err
 }
 
+// CollectGarbage runs online GC on the hotstore, which holds the live chain; implements the
+// BlockstoreGC trait, so that the chainstore can be collected the same way with or without
+// the splitstore.
+func (s *SplitStore) CollectGarbage(ctx context.Context, opts ...bstore.BlockstoreGCOption) error {
+	gc, ok := s.hot.(bstore.BlockstoreGC)
+	if !ok {
+		return xerrors.Errorf("hotstore doesn't support garbage collection: %T", s.hot)
+	}
+
+	return gc.CollectGarbage(ctx, opts...)
+}
+
 // PruneChain instructs the SplitStore to prune chain state in the coldstore, according to the
 // options specified.
 func (s *SplitStore) PruneChain(opts api.PruneOpts) error {
@@ -351,6 +364,12 @@ func (s *SplitStore) doPrune(curTs *types.TipSet, retainStateP func(int64) bool,
 		return xerrors.Errorf("error saving prune index: %w", err)
 	}
 
+	s.lastPruneTime = time.Now()
+	err = s.ds.Put(s.ctx, lastPruneTimeKey, int64ToBytes(s.lastPruneTime.Unix()))
+	if err != nil {
+		return xerrors.Errorf("error saving last prune time: %w", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,60 @@
+package build
+
+import (
+	"errors"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+)
+
+// ErrDevnetParamsOnMainnet is returned when attempting to apply devnet parameters to a mainnet build.
+var ErrDevnetParamsOnMainnet = errors.New("devnet parameters cannot be applied to a mainnet build")
+
+// DevnetParams is a set of network parameter overrides for reproducible devnet and test setups.
+// Zero values leave the corresponding parameter unchanged.
+type DevnetParams struct {
+	BlockDelaySecs         uint64
+	PropagationDelaySecs   uint64
+	BootstrapPeerThreshold int
+	// UpgradeHeights maps a network version to the height of the upgrade introducing it.
+	UpgradeHeights map[network.Version]abi.ChainEpoch
+}
+
+// ApplyDevnetParams overrides the build parameters in one go, after checking that the resulting
+// set is consistent. Nothing is changed if the parameters are rejected.
+//
+// Note that packages which snapshot parameters at init time (e.g. the sync manager's bootstrap
+// threshold) won't observe the change.
+func ApplyDevnetParams(params DevnetParams) error {
+	if BuildType == BuildMainnet {
+		return ErrDevnetParamsOnMainnet
+	}
+	return applyDevnetParams(params)
+}
+
+// checkUpgradeHeights applies the same ordering rules as the upgrade schedule: once an upgrade is
+// enabled (non-negative height), every later upgrade must happen at a strictly greater height.
+func checkUpgradeHeights(heights map[network.Version]abi.ChainEpoch) error {
+	versions := make([]network.Version, 0, len(heights))
+	for nv := range heights {
+		versions = append(versions, nv)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+
+	for i := 1; i < len(versions); i++ {
+		prev, curr := heights[versions[i-1]], heights[versions[i]]
+		if prev < 0 {
+			// Previous upgrade was disabled.
+			continue
+		}
+		if !(prev < curr) {
+			return xerrors.Errorf("upgrade heights must be strictly increasing: version %d at height %d is followed by version %d at height %d", versions[i-1], prev, versions[i], curr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+//go:build debug || 2k
+// +build debug 2k
+
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+)
+
+func TestApplyDevnetParams(t *testing.T) {
+	oldBlockDelay, oldPropDelay, oldThreshold := BlockDelaySecs, PropagationDelaySecs, BootstrapPeerThreshold
+	oldHygge, oldLightning, oldThunder := UpgradeHyggeHeight, UpgradeLightningHeight, UpgradeThunderHeight
+	defer func() {
+		BlockDelaySecs, PropagationDelaySecs, BootstrapPeerThreshold = oldBlockDelay, oldPropDelay, oldThreshold
+		UpgradeHyggeHeight, UpgradeLightningHeight, UpgradeThunderHeight = oldHygge, oldLightning, oldThunder
+	}()
+
+	require.NoError(t, ApplyDevnetParams(DevnetParams{
+		BlockDelaySecs:         6,
+		PropagationDelaySecs:   2,
+		BootstrapPeerThreshold: 3,
+		UpgradeHeights: map[network.Version]abi.ChainEpoch{
+			network.Version18: 10,
+			network.Version19: 20,
+			network.Version20: 30,
+		},
+	}))
+
+	require.Equal(t, uint64(6), BlockDelaySecs)
+	require.Equal(t, uint64(2), PropagationDelaySecs)
+	require.Equal(t, 3, BootstrapPeerThreshold)
+	require.Equal(t, abi.ChainEpoch(10), UpgradeHyggeHeight)
+	require.Equal(t, abi.ChainEpoch(20), UpgradeLightningHeight)
+	require.Equal(t, abi.ChainEpoch(30), UpgradeThunderHeight)
+
+	// An inconsistent set is rejected as a whole.
+	err := ApplyDevnetParams(DevnetParams{
+		BlockDelaySecs: 1,
+		UpgradeHeights: map[network.Version]abi.ChainEpoch{
+			network.Version20: 5,
+		},
+	})
+	require.Error(t, err)
+	require.Equal(t, uint64(6), BlockDelaySecs)
+	require.Equal(t, abi.ChainEpoch(30), UpgradeThunderHeight)
+
+	err = ApplyDevnetParams(DevnetParams{PropagationDelaySecs: 6})
+	require.Error(t, err)
+	require.Equal(t, uint64(2), PropagationDelaySecs)
+}
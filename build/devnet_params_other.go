@@ -0,0 +1,10 @@
+//go:build !debug && !2k
+// +build !debug,!2k
+
+package build
+
+import "golang.org/x/xerrors"
+
+func applyDevnetParams(DevnetParams) error {
+	return xerrors.Errorf("devnet parameters can only be applied to 2k and debug builds (build type %d)", BuildType)
+}
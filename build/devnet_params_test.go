@@ -0,0 +1,42 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+)
+
+func TestApplyDevnetParamsRejectedOnMainnet(t *testing.T) {
+	defer func(bt int) { BuildType = bt }(BuildType)
+	BuildType = BuildMainnet
+
+	err := ApplyDevnetParams(DevnetParams{BlockDelaySecs: 2})
+	require.ErrorIs(t, err, ErrDevnetParamsOnMainnet)
+}
+
+func TestCheckUpgradeHeights(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		heights map[network.Version]abi.ChainEpoch
+		ok      bool
+	}{
+		{"increasing", map[network.Version]abi.ChainEpoch{network.Version18: 10, network.Version19: 20, network.Version20: 30}, true},
+		{"disabled before enabled", map[network.Version]abi.ChainEpoch{network.Version18: -2, network.Version19: -1, network.Version20: 30}, true},
+		{"equal heights", map[network.Version]abi.ChainEpoch{network.Version19: 20, network.Version20: 20}, false},
+		{"decreasing", map[network.Version]abi.ChainEpoch{network.Version19: 20, network.Version20: 10}, false},
+		{"disabled after enabled", map[network.Version]abi.ChainEpoch{network.Version19: 20, network.Version20: -1}, false},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkUpgradeHeights(tc.heights)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
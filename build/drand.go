@@ -3,6 +3,8 @@ package build
 import (
 	"sort"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
@@ -21,6 +23,44 @@ func DrandConfigSchedule() dtypes.DrandSchedule {
 	return out
 }
 
+// DrandTransition is a point in the DrandSchedule at which the given drand network takes over.
+type DrandTransition struct {
+	Height  abi.ChainEpoch
+	Network DrandEnum
+}
+
+// DrandNetworksInRange returns, in height order, the DrandSchedule transitions relevant to the
+// epochs [lo, hi]: the transition to the network active at lo, followed by every transition
+// happening in (lo, hi].
+func DrandNetworksInRange(lo, hi abi.ChainEpoch) []DrandTransition {
+	if hi < lo {
+		return nil
+	}
+
+	var out []DrandTransition
+	for _, point := range sortedDrandSchedule() {
+		switch {
+		case point.Height <= lo:
+			// Supersedes any earlier transition as the network active at lo.
+			out = []DrandTransition{point}
+		case point.Height <= hi:
+			out = append(out, point)
+		}
+	}
+	return out
+}
+
+func sortedDrandSchedule() []DrandTransition {
+	out := make([]DrandTransition, 0, len(DrandSchedule))
+	for start, network := range DrandSchedule {
+		out = append(out, DrandTransition{Height: start, Network: network})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Height < out[j].Height
+	})
+	return out
+}
+
 const (
 	DrandMainnet DrandEnum = iota + 1
 	DrandTestnet
@@ -0,0 +1,36 @@
+//go:build !debug && !2k && !testground && !calibnet && !butterflynet && !interopnet
+// +build !debug,!2k,!testground,!calibnet,!butterflynet,!interopnet
+
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+func TestDrandNetworksInRange(t *testing.T) {
+	incentinet := DrandTransition{Height: 0, Network: DrandIncentinet}
+	mainnet := DrandTransition{Height: UpgradeSmokeHeight, Network: DrandMainnet}
+
+	for _, tc := range []struct {
+		name   string
+		lo, hi abi.ChainEpoch
+		exp    []DrandTransition
+	}{
+		{"genesis", 0, 0, []DrandTransition{incentinet}},
+		{"within incentinet", 100, 200, []DrandTransition{incentinet}},
+		{"ends just before smoke", 100, UpgradeSmokeHeight - 1, []DrandTransition{incentinet}},
+		{"ends at smoke", 100, UpgradeSmokeHeight, []DrandTransition{incentinet, mainnet}},
+		{"spans smoke", 0, UpgradeSmokeHeight + 100, []DrandTransition{incentinet, mainnet}},
+		{"starts at smoke", UpgradeSmokeHeight, UpgradeSmokeHeight + 100, []DrandTransition{mainnet}},
+		{"within mainnet", 2_000_000, 3_000_000, []DrandTransition{mainnet}},
+		{"empty range", 200, 100, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, DrandNetworksInRange(tc.lo, tc.hi))
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	actorstypes "github.com/filecoin-project/go-state-types/actors"
@@ -125,9 +126,9 @@ func init() {
 
 }
 
-const BlockDelaySecs = uint64(4)
+var BlockDelaySecs = uint64(4)
 
-const PropagationDelaySecs = uint64(1)
+var PropagationDelaySecs = uint64(1)
 
 // SlashablePowerDelay is the number of epochs after ElectionPeriodStart, after
 // which the miner is slashed
@@ -138,10 +139,78 @@ const SlashablePowerDelay = 20
 // Epochs
 const InteractivePoRepConfidence = 6
 
-const BootstrapPeerThreshold = 1
+var BootstrapPeerThreshold = 1
 
 // ChainId defines the chain ID used in the Ethereum JSON-RPC endpoint.
 // As per https://github.com/ethereum-lists/chains
 const Eip155ChainId = 31415926
 
 var WhitelistedBlock = cid.Undef
+
+// upgradeHeights maps each network version to the variable holding the height of the upgrade
+// which introduced it.
+func upgradeHeights() map[network.Version]*abi.ChainEpoch {
+	return map[network.Version]*abi.ChainEpoch{
+		network.Version1:  &UpgradeBreezeHeight,
+		network.Version2:  &UpgradeSmokeHeight,
+		network.Version3:  &UpgradeIgnitionHeight,
+		network.Version4:  &UpgradeAssemblyHeight,
+		network.Version5:  &UpgradeTapeHeight,
+		network.Version6:  &UpgradeKumquatHeight,
+		network.Version7:  &UpgradeCalicoHeight,
+		network.Version8:  &UpgradePersianHeight,
+		network.Version9:  &UpgradeOrangeHeight,
+		network.Version10: &UpgradeTrustHeight,
+		network.Version11: &UpgradeNorwegianHeight,
+		network.Version12: &UpgradeTurboHeight,
+		network.Version13: &UpgradeHyperdriveHeight,
+		network.Version14: &UpgradeChocolateHeight,
+		network.Version15: &UpgradeOhSnapHeight,
+		network.Version16: &UpgradeSkyrHeight,
+		network.Version17: &UpgradeSharkHeight,
+		network.Version18: &UpgradeHyggeHeight,
+		network.Version19: &UpgradeLightningHeight,
+		network.Version20: &UpgradeThunderHeight,
+	}
+}
+
+func applyDevnetParams(params DevnetParams) error {
+	heights := upgradeHeights()
+
+	// Merge the requested heights over the current ones, so that consistency is checked
+	// against the schedule we'd actually end up with.
+	merged := make(map[network.Version]abi.ChainEpoch, len(heights))
+	for nv, h := range heights {
+		merged[nv] = *h
+	}
+	for nv, h := range params.UpgradeHeights {
+		if _, ok := heights[nv]; !ok {
+			return xerrors.Errorf("no upgrade height for network version %d", nv)
+		}
+		merged[nv] = h
+	}
+	if err := checkUpgradeHeights(merged); err != nil {
+		return err
+	}
+
+	blockDelay, propDelay := BlockDelaySecs, PropagationDelaySecs
+	if params.BlockDelaySecs != 0 {
+		blockDelay = params.BlockDelaySecs
+	}
+	if params.PropagationDelaySecs != 0 {
+		propDelay = params.PropagationDelaySecs
+	}
+	if propDelay >= blockDelay {
+		return xerrors.Errorf("propagation delay (%ds) must be shorter than the block delay (%ds)", propDelay, blockDelay)
+	}
+
+	BlockDelaySecs, PropagationDelaySecs = blockDelay, propDelay
+	if params.BootstrapPeerThreshold != 0 {
+		BootstrapPeerThreshold = params.BootstrapPeerThreshold
+	}
+	for nv, h := range merged {
+		*heights[nv] = h
+	}
+
+	return nil
+}
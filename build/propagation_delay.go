@@ -0,0 +1,54 @@
+package build
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	propagationJitterLk sync.Mutex
+	propagationJitter   time.Duration
+	propagationRand     *rand.Rand
+)
+
+// SetPropagationDelayJitter makes PropagationDelay report values drawn uniformly from
+// PropagationDelaySecs ± jitter, using an RNG seeded with seed so that runs are reproducible. A
+// jitter of 0 disables it. Jitter is only available on non-mainnet builds, for simulations.
+func SetPropagationDelayJitter(jitter time.Duration, seed int64) error {
+	if BuildType == BuildMainnet {
+		return ErrDevnetParamsOnMainnet
+	}
+
+	propagationJitterLk.Lock()
+	defer propagationJitterLk.Unlock()
+
+	propagationJitter = jitter
+	propagationRand = nil
+	if jitter > 0 {
+		propagationRand = rand.New(rand.NewSource(seed))
+	}
+	return nil
+}
+
+// PropagationDelay returns the block propagation delay, with any jitter configured through
+// SetPropagationDelayJitter applied. Mainnet builds always return exactly PropagationDelaySecs.
+func PropagationDelay() time.Duration {
+	base := time.Duration(PropagationDelaySecs) * time.Second
+	if BuildType == BuildMainnet {
+		return base
+	}
+
+	propagationJitterLk.Lock()
+	defer propagationJitterLk.Unlock()
+
+	if propagationRand == nil {
+		return base
+	}
+
+	d := base - propagationJitter + time.Duration(propagationRand.Int63n(int64(2*propagationJitter)+1))
+	if d < 0 {
+		return 0
+	}
+	return d
+}
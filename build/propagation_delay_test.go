@@ -0,0 +1,46 @@
+package build
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropagationDelay(t *testing.T) {
+	defer func(bt int) { BuildType = bt }(BuildType)
+	base := time.Duration(PropagationDelaySecs) * time.Second
+
+	BuildType = BuildMainnet
+	require.ErrorIs(t, SetPropagationDelayJitter(time.Second, 1), ErrDevnetParamsOnMainnet)
+	require.Equal(t, base, PropagationDelay())
+
+	BuildType = Build2k
+	defer func() { require.NoError(t, SetPropagationDelayJitter(0, 0)) }()
+
+	const jitter = 500 * time.Millisecond
+	sample := func() []time.Duration {
+		require.NoError(t, SetPropagationDelayJitter(jitter, 42))
+		var out []time.Duration
+		for i := 0; i < 20; i++ {
+			out = append(out, PropagationDelay())
+		}
+		return out
+	}
+
+	first := sample()
+	require.Equal(t, first, sample())
+
+	jittered := false
+	for _, d := range first {
+		require.GreaterOrEqual(t, d, base-jitter)
+		require.LessOrEqual(t, d, base+jitter)
+		jittered = jittered || d != base
+	}
+	require.True(t, jittered)
+
+	// Mainnet ignores any configured jitter.
+	BuildType = BuildMainnet
+	require.Equal(t, base, PropagationDelay())
+	BuildType = Build2k
+}
@@ -3,27 +3,30 @@ package drand
 import (
 	"bytes"
 	"context"
+	"sync"
 	"time"
 
 	dchain "github.com/drand/drand/chain"
 	dclient "github.com/drand/drand/client"
-	hclient "github.com/drand/drand/client/http"
 	"github.com/drand/drand/common/scheme"
 	dlog "github.com/drand/drand/log"
-	gclient "github.com/drand/drand/lp2p/client"
 	"github.com/drand/kyber"
 	lru "github.com/hashicorp/golang-lru/v2"
 	logging "github.com/ipfs/go-log/v2"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"go.opencensus.io/stats"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/network"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
@@ -50,7 +53,14 @@ func (dp *drandPeer) IsTLS() bool {
 //
 // The root trust for the Drand chain is configured from build.DrandChain.
 type DrandBeacon struct {
-	client dclient.Client
+	// groups of endpoints serving randomness; requests go to the active group, and fail over to
+	// the next ones in order
+	groups []*drandGroup
+
+	activeLk sync.Mutex
+	active   int
+	// when the first group is retried after failing over
+	retryFirstAt time.Time
 
 	pubkey kyber.Point
 
@@ -62,6 +72,10 @@ type DrandBeacon struct {
 	filRoundTime uint64
 
 	localCache *lru.Cache[uint64, *types.BeaconEntry]
+
+	chainHash []byte
+	// persisted validated entries, when enabled
+	store *entryStore
 }
 
 // DrandHTTPClient interface overrides the user agent used by drand
@@ -91,32 +105,19 @@ func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes
 		return nil, xerrors.Errorf("unable to unmarshal drand chain info: %w", err)
 	}
 
-	var clients []dclient.Client
-	for _, url := range config.Servers {
-		hc, err := hclient.NewWithInfo(url, drandChain, nil)
-		if err != nil {
-			return nil, xerrors.Errorf("could not create http drand client: %w", err)
+	var groups []*drandGroup
+	for i, servers := range append([][]string{config.Servers}, config.FallbackServers...) {
+		var gps *pubsub.PubSub
+		if i == 0 {
+			// drand gossip relays only serve the main group
+			gps = ps
 		}
-		hc.(DrandHTTPClient).SetUserAgent("drand-client-lotus/" + build.BuildVersion)
-		clients = append(clients, hc)
-
-	}
-
-	opts := []dclient.Option{
-		dclient.WithChainInfo(drandChain),
-		dclient.WithCacheSize(1024),
-		dclient.WithLogger(&logger{&log.SugaredLogger}),
-	}
 
-	if ps != nil {
-		opts = append(opts, gclient.WithPubsub(ps))
-	} else {
-		log.Info("drand beacon without pubsub")
-	}
-
-	client, err := dclient.Wrap(clients, opts...)
-	if err != nil {
-		return nil, xerrors.Errorf("creating drand client: %w", err)
+		client, err := newGroupClient(servers, drandChain, gps)
+		if err != nil {
+			return nil, xerrors.Errorf("creating drand client for endpoint group %d: %w", i, err)
+		}
+		groups = append(groups, &drandGroup{servers: servers, client: client})
 	}
 
 	lc, err := lru.New[uint64, *types.BeaconEntry](1024)
@@ -125,10 +126,11 @@ func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes
 	}
 
 	db := &DrandBeacon{
-		client:     client,
+		groups:     groups,
 		localCache: lc,
 	}
 
+	db.chainHash = drandChain.Hash()
 	db.pubkey = drandChain.PublicKey
 	db.interval = drandChain.Period
 	db.drandGenTime = uint64(drandChain.GenesisTime)
@@ -147,12 +149,19 @@ func (db *DrandBeacon) Entry(ctx context.Context, round uint64) <-chan beacon.Re
 			close(out)
 			return out
 		}
+
+		if be := db.getStoredValue(ctx, round); be != nil {
+			db.cacheValue(*be)
+			out <- beacon.Response{Entry: *be}
+			close(out)
+			return out
+		}
 	}
 
 	go func() {
 		start := build.Clock.Now()
 		log.Debugw("start fetching randomness", "round", round)
-		resp, err := db.client.Get(ctx, round)
+		resp, err := db.get(ctx, round)
 
 		var br beacon.Response
 		if err != nil {
@@ -168,6 +177,81 @@ func (db *DrandBeacon) Entry(ctx context.Context, round uint64) <-chan beacon.Re
 
 	return out
 }
+
+// get fetches a round from the active endpoint group, failing over to the next groups when it
+// fails.
+func (db *DrandBeacon) get(ctx context.Context, round uint64) (dclient.Result, error) {
+	first := db.firstGroup()
+
+	var errs error
+	for n := 0; n < len(db.groups); n++ {
+		i := (first + n) % len(db.groups)
+		g := db.groups[i]
+
+		gctx, cancel := ctx, context.CancelFunc(func() {})
+		if n < len(db.groups)-1 {
+			// leave time for the next groups
+			gctx, cancel = context.WithTimeout(ctx, drandGroupTimeout)
+		}
+
+		resp, err := g.client.Get(gctx, round)
+		cancel()
+		if err == nil {
+			db.setActive(i)
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		g.fail(err)
+		errs = multierr.Append(errs, xerrors.Errorf("endpoint group %d: %w", i, err))
+	}
+
+	return nil, errs
+}
+
+func (db *DrandBeacon) firstGroup() int {
+	db.activeLk.Lock()
+	defer db.activeLk.Unlock()
+
+	if db.active != 0 && build.Clock.Now().After(db.retryFirstAt) {
+		db.retryFirstAt = build.Clock.Now().Add(drandRetryFirstGroup)
+		return 0
+	}
+	return db.active
+}
+
+func (db *DrandBeacon) setActive(i int) {
+	db.activeLk.Lock()
+	defer db.activeLk.Unlock()
+
+	if i == db.active {
+		return
+	}
+	if db.active == 0 {
+		db.retryFirstAt = build.Clock.Now().Add(drandRetryFirstGroup)
+	}
+
+	log.Warnw("drand endpoint group switched", "from", db.groups[db.active].servers, "to", db.groups[i].servers)
+	db.active = i
+	stats.Record(context.Background(), metrics.DrandActiveGroup.M(int64(i)))
+}
+
+// Status reports the endpoint groups of the beacon, and which one serves randomness.
+func (db *DrandBeacon) Status() []api.BeaconGroupStatus {
+	db.activeLk.Lock()
+	active := db.active
+	db.activeLk.Unlock()
+
+	out := make([]api.BeaconGroupStatus, len(db.groups))
+	for i, g := range db.groups {
+		out[i] = g.status()
+		out[i].Active = i == active
+	}
+	return out
+}
+
 func (db *DrandBeacon) cacheValue(e types.BeaconEntry) {
 	db.localCache.Add(e.Round, &e)
 }
@@ -177,6 +261,27 @@ func (db *DrandBeacon) getCachedValue(round uint64) *types.BeaconEntry {
 	return v
 }
 
+func (db *DrandBeacon) getStoredValue(ctx context.Context, round uint64) *types.BeaconEntry {
+	if db.store == nil {
+		return nil
+	}
+	be, err := db.store.get(ctx, round)
+	if err != nil {
+		log.Warnw("reading persisted beacon entry", "round", round, "error", err)
+		return nil
+	}
+	return be
+}
+
+func (db *DrandBeacon) storeValue(e types.BeaconEntry) {
+	if db.store == nil {
+		return
+	}
+	if err := db.store.put(context.TODO(), e); err != nil {
+		log.Warnw("persisting beacon entry", "round", e.Round, "error", err)
+	}
+}
+
 func (db *DrandBeacon) VerifyEntry(curr types.BeaconEntry, prev types.BeaconEntry) error {
 	if prev.Round == 0 {
 		// TODO handle genesis better
@@ -194,6 +299,11 @@ func (db *DrandBeacon) VerifyEntry(curr types.BeaconEntry, prev types.BeaconEntr
 		// return no error if the value is in the cache already
 		return nil
 	}
+	if be := db.getStoredValue(context.TODO(), curr.Round); be != nil && bytes.Equal(curr.Data, be.Data) {
+		// validated before being persisted
+		db.cacheValue(curr)
+		return nil
+	}
 	b := &dchain.Beacon{
 		PreviousSig: prev.Data,
 		Round:       curr.Round,
@@ -202,6 +312,7 @@ func (db *DrandBeacon) VerifyEntry(curr types.BeaconEntry, prev types.BeaconEntr
 	err := dchain.NewVerifier(scheme.GetSchemeFromEnv()).VerifyBeacon(*b, db.pubkey)
 	if err == nil {
 		db.cacheValue(curr)
+		db.storeValue(curr)
 	}
 	return err
 }
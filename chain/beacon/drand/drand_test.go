@@ -6,14 +6,21 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	dchain "github.com/drand/drand/chain"
+	dclient "github.com/drand/drand/client"
 	hclient "github.com/drand/drand/client/http"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/network"
 
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
 )
 
 func TestPrintGroupInfo(t *testing.T) {
@@ -37,3 +44,72 @@ func TestMaxBeaconRoundForEpoch(t *testing.T) {
 	mbr16 := db.MaxBeaconRoundForEpoch(network.Version16, 100)
 	assert.Equal(t, mbr15+1, mbr16)
 }
+
+type failingClient struct {
+	dclient.Client
+	fail  bool
+	calls int
+}
+
+func (c *failingClient) Get(ctx context.Context, round uint64) (dclient.Result, error) {
+	c.calls++
+	if c.fail {
+		return nil, xerrors.New("endpoint down")
+	}
+	return nil, nil
+}
+
+func TestDrandGroupFailover(t *testing.T) {
+	primary, fallback := &failingClient{fail: true}, &failingClient{}
+	db := &DrandBeacon{groups: []*drandGroup{
+		{servers: []string{"primary"}, client: primary},
+		{servers: []string{"fallback"}, client: fallback},
+	}}
+
+	_, err := db.get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, primary.calls)
+	require.Equal(t, 1, fallback.calls)
+
+	st := db.Status()
+	require.False(t, st[0].Active)
+	require.Equal(t, uint64(1), st[0].Failures)
+	require.Equal(t, "endpoint down", st[0].LastError)
+	require.True(t, st[1].Active)
+
+	// the fallback group keeps serving until the primary is retried
+	_, err = db.get(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, 1, primary.calls)
+	require.Equal(t, 2, fallback.calls)
+
+	primary.fail = false
+	db.retryFirstAt = time.Time{}
+	_, err = db.get(context.Background(), 3)
+	require.NoError(t, err)
+	require.Equal(t, 2, primary.calls)
+	require.True(t, db.Status()[0].Active)
+
+	// all groups failing
+	primary.fail, fallback.fail = true, true
+	_, err = db.get(context.Background(), 4)
+	require.Error(t, err)
+}
+
+func TestEntryStorePruning(t *testing.T) {
+	ctx := context.Background()
+	s := &entryStore{ds: dssync.MutexWrap(datastore.NewMapDatastore()), horizon: 150}
+
+	for round := uint64(1); round <= 250; round++ {
+		require.NoError(t, s.put(ctx, types.BeaconEntry{Round: round, Data: []byte{byte(round)}}))
+	}
+
+	// pruned when storing round 200, keeping the rounds of the last horizon
+	e, err := s.get(ctx, 49)
+	require.NoError(t, err)
+	require.Nil(t, e)
+
+	e, err = s.get(ctx, 50)
+	require.NoError(t, err)
+	require.Equal(t, &types.BeaconEntry{Round: 50, Data: []byte{50}}, e)
+}
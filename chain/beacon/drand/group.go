@@ -0,0 +1,110 @@
+package drand
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	dchain "github.com/drand/drand/chain"
+	dclient "github.com/drand/drand/client"
+	hclient "github.com/drand/drand/client/http"
+	gclient "github.com/drand/drand/lp2p/client"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var (
+	// drandGroupTimeout bounds the time an endpoint group is given to serve a round before the
+	// next group is tried.
+	drandGroupTimeout = 10 * time.Second
+	// drandRetryFirstGroup is how often the first endpoint group is retried after failing over.
+	drandRetryFirstGroup = 5 * time.Minute
+)
+
+type drandGroup struct {
+	servers []string
+	client  dclient.Client
+
+	lk          sync.Mutex
+	failures    uint64
+	lastErr     string
+	lastErrorAt time.Time
+}
+
+func (g *drandGroup) fail(err error) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+
+	g.failures++
+	g.lastErr = err.Error()
+	g.lastErrorAt = build.Clock.Now()
+}
+
+func (g *drandGroup) status() api.BeaconGroupStatus {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+
+	return api.BeaconGroupStatus{
+		Servers:     g.servers,
+		Failures:    g.failures,
+		LastError:   g.lastErr,
+		LastErrorAt: g.lastErrorAt,
+	}
+}
+
+func newGroupClient(servers []string, drandChain *dchain.Info, ps *pubsub.PubSub) (dclient.Client, error) {
+	var clients []dclient.Client
+	for _, url := range servers {
+		hc, err := hclient.NewWithInfo(url, drandChain, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("could not create http drand client: %w", err)
+		}
+		hc.(DrandHTTPClient).SetUserAgent("drand-client-lotus/" + build.BuildVersion)
+		clients = append(clients, &measuredClient{Client: hc, endpoint: url})
+	}
+
+	opts := []dclient.Option{
+		dclient.WithChainInfo(drandChain),
+		dclient.WithCacheSize(1024),
+		dclient.WithLogger(&logger{&log.SugaredLogger}),
+	}
+
+	if ps != nil {
+		opts = append(opts, gclient.WithPubsub(ps))
+	} else {
+		log.Info("drand beacon without pubsub")
+	}
+
+	client, err := dclient.Wrap(clients, opts...)
+	if err != nil {
+		return nil, xerrors.Errorf("creating drand client: %w", err)
+	}
+	return client, nil
+}
+
+// measuredClient records the latency and the failures of the requests to a drand endpoint.
+type measuredClient struct {
+	dclient.Client
+	endpoint string
+}
+
+func (c *measuredClient) Get(ctx context.Context, round uint64) (dclient.Result, error) {
+	start := time.Now()
+	res, err := c.Client.Get(ctx, round)
+
+	mctx, _ := tag.New(context.Background(), tag.Upsert(metrics.Endpoint, c.endpoint))
+	switch {
+	case err == nil:
+		stats.Record(mctx, metrics.DrandRequestDuration.M(metrics.SinceInMilliseconds(start)))
+	case ctx.Err() == nil:
+		// requests canceled as another endpoint answered first are not failures
+		stats.Record(mctx, metrics.DrandRequestFailure.M(1))
+	}
+	return res, err
+}
@@ -0,0 +1,118 @@
+package drand
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// entryStorePruneRounds is the number of rounds stored between two prunings of the entry store.
+var entryStorePruneRounds uint64 = 100
+
+// entryStore persists the validated entries of a drand network, so that they aren't fetched
+// again after a restart.
+type entryStore struct {
+	ds datastore.Batching
+	// number of rounds kept behind the latest stored round
+	horizon uint64
+
+	pruneLk   sync.Mutex
+	lastPrune uint64
+}
+
+func roundKey(round uint64) datastore.Key {
+	// zero padded so that keys sort by round
+	return datastore.NewKey(fmt.Sprintf("%020d", round))
+}
+
+// PersistEntries keeps the validated beacon entries of the rounds of the last horizon in the
+// datastore, and serves lookups from it.
+func (db *DrandBeacon) PersistEntries(ds datastore.Batching, horizon time.Duration) {
+	rounds := uint64(horizon / db.interval)
+	if rounds == 0 {
+		return
+	}
+
+	db.store = &entryStore{
+		ds:      namespace.Wrap(ds, datastore.NewKey("/beacon/drand/"+hex.EncodeToString(db.chainHash))),
+		horizon: rounds,
+	}
+}
+
+func (s *entryStore) get(ctx context.Context, round uint64) (*types.BeaconEntry, error) {
+	data, err := s.ds.Get(ctx, roundKey(round))
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &types.BeaconEntry{Round: round, Data: data}, nil
+}
+
+func (s *entryStore) put(ctx context.Context, e types.BeaconEntry) error {
+	if err := s.ds.Put(ctx, roundKey(e.Round), e.Data); err != nil {
+		return err
+	}
+
+	s.pruneLk.Lock()
+	defer s.pruneLk.Unlock()
+
+	if e.Round < s.lastPrune+entryStorePruneRounds {
+		return nil
+	}
+	s.lastPrune = e.Round
+	if e.Round <= s.horizon {
+		return nil
+	}
+	if err := s.prune(ctx, e.Round-s.horizon); err != nil {
+		return xerrors.Errorf("pruning beacon entries: %w", err)
+	}
+	return nil
+}
+
+// prune removes the entries of the rounds before the given one.
+func (s *entryStore) prune(ctx context.Context, before uint64) error {
+	res, err := s.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	defer res.Close() //nolint:errcheck
+
+	b, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pruned int
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+
+		round, err := strconv.ParseUint(datastore.RawKey(r.Key).BaseNamespace(), 10, 64)
+		if err != nil || round >= before {
+			continue
+		}
+		if err := b.Delete(ctx, datastore.RawKey(r.Key)); err != nil {
+			return err
+		}
+		pruned++
+	}
+
+	if err := b.Commit(ctx); err != nil {
+		return err
+	}
+	log.Debugw("pruned beacon entries", "before", before, "count", pruned)
+	return nil
+}
@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"context"
+	"errors"
 	"sync/atomic"
 	"time"
 
@@ -85,6 +86,21 @@ func (t *TipSetExecutor) ApplyBlocks(ctx context.Context,
 	vmTracing bool,
 	baseFee abi.TokenAmount,
 	ts *types.TipSet) (cid.Cid, cid.Cid, error) {
+	return t.applyBlocks(ctx, sm, parentEpoch, pstate, bms, epoch, r, em, vmTracing, baseFee, ts, stmgr.ExecutorOpts{})
+}
+
+func (t *TipSetExecutor) applyBlocks(ctx context.Context,
+	sm *stmgr.StateManager,
+	parentEpoch abi.ChainEpoch,
+	pstate cid.Cid,
+	bms []FilecoinBlockMessages,
+	epoch abi.ChainEpoch,
+	r vm.Rand,
+	em stmgr.ExecMonitor,
+	vmTracing bool,
+	baseFee abi.TokenAmount,
+	ts *types.TipSet,
+	opts stmgr.ExecutorOpts) (cid.Cid, cid.Cid, error) {
 	done := metrics.Timer(ctx, metrics.VMApplyBlocksTotal)
 	defer done()
 
@@ -94,26 +110,36 @@ func (t *TipSetExecutor) ApplyBlocks(ctx context.Context,
 	}()
 
 	ctx = blockstore.WithHotView(ctx)
-	makeVm := func(base cid.Cid, e abi.ChainEpoch, timestamp uint64) (vm.Interface, error) {
-		vmopt := &vm.VMOpts{
+	stateBs := sm.ChainStore().StateBlockstore()
+	if opts.StateBlockstore != nil {
+		stateBs = opts.StateBlockstore
+	}
+	newVMOpts := func(base cid.Cid, e abi.ChainEpoch, timestamp uint64, tracing bool) *vm.VMOpts {
+		nv := sm.GetNetworkVersion(ctx, e)
+		if opts.NetworkVersionOverride != nil {
+			nv = *opts.NetworkVersionOverride
+		}
+
+		return &vm.VMOpts{
 			StateBase:      base,
 			Epoch:          e,
 			Timestamp:      timestamp,
 			Rand:           r,
-			Bstore:         sm.ChainStore().StateBlockstore(),
+			Bstore:         stateBs,
 			Actors:         NewActorRegistry(),
 			Syscalls:       sm.Syscalls,
 			CircSupplyCalc: sm.GetVMCirculatingSupply,
-			NetworkVersion: sm.GetNetworkVersion(ctx, e),
+			NetworkVersion: nv,
 			BaseFee:        baseFee,
 			LookbackState:  stmgr.LookbackStateGetterForTipset(sm, ts),
 			TipSetGetter:   stmgr.TipSetGetterForTipset(sm.ChainStore(), ts),
-			Tracing:        vmTracing,
+			Tracing:        tracing,
 			ReturnEvents:   sm.ChainStore().IsStoringEvents(),
 			ExecutionLane:  vm.ExecutionLanePriority,
 		}
-
-		return sm.VMConstructor()(ctx, vmopt)
+	}
+	makeVm := func(base cid.Cid, e abi.ChainEpoch, timestamp uint64, tracing bool) (vm.Interface, error) {
+		return sm.VMConstructor()(ctx, newVMOpts(base, e, timestamp, tracing))
 	}
 
 	var cronGas int64
@@ -164,7 +190,7 @@ func (t *TipSetExecutor) ApplyBlocks(ctx context.Context,
 			}
 
 			ts := genesis.Timestamp + build.BlockDelaySecs*(uint64(i))
-			vmCron, err := makeVm(pstate, i, ts)
+			vmCron, err := makeVm(pstate, i, ts, vmTracing)
 			if err != nil {
 				return cid.Undef, cid.Undef, xerrors.Errorf("making cron vm: %w", err)
 			}
@@ -193,62 +219,90 @@ func (t *TipSetExecutor) ApplyBlocks(ctx context.Context,
 	cronGas = 0
 	partDone = metrics.Timer(ctx, metrics.VMApplyMessages)
 
-	vmi, err := makeVm(pstate, epoch, ts.MinTimestamp())
-	if err != nil {
-		return cid.Undef, cid.Undef, xerrors.Errorf("making vm: %w", err)
-	}
-
 	var (
+		vmi           vm.Interface
 		receipts      []*types.MessageReceipt
 		storingEvents = sm.ChainStore().IsStoringEvents()
 		events        [][]types.Event
-		processedMsgs = make(map[cid.Cid]struct{})
+		msgGas        int64
 	)
 
-	var msgGas int64
+	if workers := sm.ParallelExecutionWorkers(); opts.Parallel && workers > 1 && opts.MessageLimit == 0 && opts.MessageOverride == nil {
+		res, err := t.applyMessagesParallel(ctx, sm, stateBs, pstate, bms, epoch, em, ts, storingEvents, workers, newVMOpts)
+		switch {
+		case errors.Is(err, errParallelConflict):
+			atomic.AddUint64(&StatParallelFallbacks, 1)
+			log.Debugw("parallel execution conflicted, executing serially", "epoch", epoch, "error", err)
+		case err != nil:
+			return cid.Undef, cid.Undef, err
+		case res != nil:
+			atomic.AddUint64(&StatParallelTipSets, 1)
+			vmi, receipts, events, msgGas = res.vmi, res.receipts, res.events, res.msgGas
+		}
+	}
+
+	if vmi == nil {
+		var err error
+		vmi, err = makeVm(pstate, epoch, ts.MinTimestamp(), vmTracing)
+		if err != nil {
+			return cid.Undef, cid.Undef, xerrors.Errorf("making vm: %w", err)
+		}
+
+		stopPrefetch := prefetchMessageState(ctx, stateBs, pstate, bms)
+		defer stopPrefetch()
 
-	for _, b := range bms {
-		penalty := types.NewInt(0)
-		gasReward := big.Zero()
+		processedMsgs := make(map[cid.Cid]struct{})
+		for _, b := range bms {
+			penalty := types.NewInt(0)
+			gasReward := big.Zero()
 
-		for _, cm := range append(b.BlsMessages, b.SecpkMessages...) {
-			m := cm.VMMessage()
-			if _, found := processedMsgs[m.Cid()]; found {
-				continue
-			}
-			r, err := vmi.ApplyMessage(ctx, cm)
-			if err != nil {
-				return cid.Undef, cid.Undef, err
-			}
+			for _, cm := range append(b.BlsMessages, b.SecpkMessages...) {
+				if opts.MessageLimit > 0 && len(receipts) >= opts.MessageLimit {
+					break
+				}
+				m := cm.VMMessage()
+				mcid := m.Cid()
+				if _, found := processedMsgs[mcid]; found {
+					continue
+				}
+				if opts.MessageOverride != nil {
+					cm = opts.MessageOverride(cm)
+					m = cm.VMMessage()
+				}
+				r, err := vmi.ApplyMessage(ctx, cm)
+				if err != nil {
+					return cid.Undef, cid.Undef, err
+				}
 
-			msgGas += r.GasUsed
+				msgGas += r.GasUsed
 
-			receipts = append(receipts, &r.MessageReceipt)
-			gasReward = big.Add(gasReward, r.GasCosts.MinerTip)
-			penalty = big.Add(penalty, r.GasCosts.MinerPenalty)
+				receipts = append(receipts, &r.MessageReceipt)
+				gasReward = big.Add(gasReward, r.GasCosts.MinerTip)
+				penalty = big.Add(penalty, r.GasCosts.MinerPenalty)
 
-			if storingEvents {
-				// Appends nil when no events are returned to preserve positional alignment.
-				events = append(events, r.Events)
-			}
+				if storingEvents {
+					// Appends nil when no events are returned to preserve positional alignment.
+					events = append(events, r.Events)
+				}
 
-			if em != nil {
-				if err := em.MessageApplied(ctx, ts, cm.Cid(), m, r, false); err != nil {
-					return cid.Undef, cid.Undef, err
+				if em != nil {
+					if err := em.MessageApplied(ctx, ts, cm.Cid(), m, r, false); err != nil {
+						return cid.Undef, cid.Undef, err
+					}
 				}
+				processedMsgs[mcid] = struct{}{}
 			}
-			processedMsgs[m.Cid()] = struct{}{}
-		}
 
-		params := &reward.AwardBlockRewardParams{
-			Miner:     b.Miner,
-			Penalty:   penalty,
-			GasReward: gasReward,
-			WinCount:  b.WinCount,
-		}
-		rErr := t.reward(ctx, vmi, em, epoch, ts, params)
-		if rErr != nil {
-			return cid.Undef, cid.Undef, xerrors.Errorf("error applying reward: %w", rErr)
+			params := &reward.AwardBlockRewardParams{
+				Miner:     b.Miner,
+				Penalty:   penalty,
+				GasReward: gasReward,
+				WinCount:  b.WinCount,
+			}
+			rErr := t.reward(ctx, vmi, em, epoch, ts, params)
+			if rErr != nil {
+				return cid.Undef, cid.Undef, xerrors.Errorf("error applying reward: %w", rErr)
+			}
 		}
 	}
 
@@ -311,6 +365,19 @@ func (t *TipSetExecutor) ExecuteTipSet(ctx context.Context,
 	ts *types.TipSet,
 	em stmgr.ExecMonitor,
 	vmTracing bool) (stateroot cid.Cid, rectsroot cid.Cid, err error) {
+	return t.ExecuteTipSetWithOpts(ctx, sm, ts, em, vmTracing, stmgr.ExecutorOpts{})
+}
+
+func (t *TipSetExecutor) ExecuteTipSetWithOpts(ctx context.Context,
+	sm *stmgr.StateManager,
+	ts *types.TipSet,
+	em stmgr.ExecMonitor,
+	vmTracing bool,
+	opts stmgr.ExecutorOpts) (stateroot cid.Cid, rectsroot cid.Cid, err error) {
+	if err := opts.Validate(); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
 	ctx, span := trace.StartSpan(ctx, "computeTipSetState")
 	defer span.End()
 
@@ -336,6 +403,9 @@ func (t *TipSetExecutor) ExecuteTipSet(ctx context.Context,
 
 	var parentEpoch abi.ChainEpoch
 	pstate := blks[0].ParentStateRoot
+	if opts.ParentStateOverride.Defined() {
+		pstate = opts.ParentStateOverride
+	}
 	if blks[0].Height > 0 {
 		parent, err := sm.ChainStore().GetBlock(ctx, blks[0].Parents[0])
 		if err != nil {
@@ -358,7 +428,7 @@ func (t *TipSetExecutor) ExecuteTipSet(ctx context.Context,
 	}
 	baseFee := blks[0].ParentBaseFee
 
-	return t.ApplyBlocks(ctx, sm, parentEpoch, pstate, fbmsgs, blks[0].Height, r, em, vmTracing, baseFee, ts)
+	return t.applyBlocks(ctx, sm, parentEpoch, pstate, fbmsgs, blks[0].Height, r, em, vmTracing, baseFee, ts, opts)
 }
 
 func (t *TipSetExecutor) StoreEventsAMT(ctx context.Context, cs *store.ChainStore, events []types.Event) (cid.Cid, error) {
@@ -370,4 +440,4 @@ func (t *TipSetExecutor) StoreEventsAMT(ctx context.Context, cs *store.ChainStor
 	return amt4.FromArray(ctx, cst, objs, amt4.UseTreeBitWidth(types.EventAMTBitwidth))
 }
 
-var _ stmgr.Executor = &TipSetExecutor{}
+var _ stmgr.ExecutorWithOpts = &TipSetExecutor{}
@@ -294,6 +294,35 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 	return consensus.RunAsyncChecks(ctx, await)
 }
 
+// PrevalidateBlock performs the checks of ValidateBlock which only need the parent tipset header:
+// sanity checks, height and timestamp, and the syntax and key-address signatures of the messages.
+func (filec *FilecoinEC) PrevalidateBlock(ctx context.Context, b *types.FullBlock) error {
+	if err := blockSanityChecks(b.Header); err != nil {
+		return xerrors.Errorf("incoming header failed basic sanity checks: %w", err)
+	}
+
+	h := b.Header
+
+	baseTs, err := filec.store.LoadTipSet(ctx, types.NewTipSetKey(h.Parents...))
+	if err != nil {
+		return xerrors.Errorf("load parent tipset failed (%s): %w", h.Parents, err)
+	}
+
+	if h.Height <= baseTs.Height() {
+		return xerrors.Errorf("block height not greater than parent height: %d != %d", h.Height, baseTs.Height())
+	}
+
+	nulls := h.Height - (baseTs.Height() + 1)
+	if tgtTs := baseTs.MinTimestamp() + build.BlockDelaySecs*uint64(nulls+1); h.Timestamp != tgtTs {
+		return xerrors.Errorf("block has wrong timestamp: %d != %d", h.Timestamp, tgtTs)
+	}
+
+	if err := consensus.PrevalidateMessages(ctx, filec.sm, b); err != nil {
+		return xerrors.Errorf("block messages failed prevalidation: %w", err)
+	}
+	return nil
+}
+
 func blockSanityChecks(h *types.BlockHeader) error {
 	if h.ElectionProof == nil {
 		return xerrors.Errorf("block cannot have nil election proof")
@@ -544,3 +573,4 @@ func signBlock(ctx context.Context, w api.Wallet,
 }
 
 var _ consensus.Consensus = &FilecoinEC{}
+var _ consensus.BlockPrevalidator = &FilecoinEC{}
@@ -61,8 +61,39 @@ var fvmLiftoffBanner string
 var (
 	MigrationMaxWorkerCount    int
 	EnvMigrationMaxWorkerCount = "LOTUS_MIGRATION_MAX_WORKER_COUNT"
+
+	// MigrationJobQueueSize and MigrationResultQueueSize bound the number of actor migration jobs
+	// and results buffered in memory during a migration. Lower them on memory constrained nodes.
+	MigrationJobQueueSize    uint = 1000
+	MigrationResultQueueSize uint = 100
 )
 
+// MigrationConfig holds the tunables applied to every state migration.
+type MigrationConfig struct {
+	// MaxWorkers is the number of workers used by migrations; pre-migrations use half of them.
+	// Zero keeps the current value.
+	MaxWorkers int
+	// JobQueueSize and ResultQueueSize override MigrationJobQueueSize and
+	// MigrationResultQueueSize when non-zero.
+	JobQueueSize    uint
+	ResultQueueSize uint
+}
+
+// SetMigrationConfig updates the migration tunables. It takes effect for migrations started
+// after the call.
+func SetMigrationConfig(cfg MigrationConfig) {
+	if cfg.MaxWorkers > 0 {
+		MigrationMaxWorkerCount = cfg.MaxWorkers
+	}
+	if cfg.JobQueueSize > 0 {
+		MigrationJobQueueSize = cfg.JobQueueSize
+	}
+	if cfg.ResultQueueSize > 0 {
+		MigrationResultQueueSize = cfg.ResultQueueSize
+	}
+	log.Infof("migration config: %d workers, job queue %d, result queue %d", MigrationMaxWorkerCount, MigrationJobQueueSize, MigrationResultQueueSize)
+}
+
 func init() {
 	// the default calculation used for migration worker count
 	MigrationMaxWorkerCount = runtime.NumCPU()
@@ -949,8 +980,8 @@ func UpgradeActorsV3(ctx context.Context, sm *stmgr.StateManager, cache stmgr.Mi
 
 	config := nv10.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 	newRoot, err := upgradeActorsV3Common(ctx, sm, cache, root, epoch, ts, config)
@@ -1051,8 +1082,8 @@ func UpgradeActorsV4(ctx context.Context, sm *stmgr.StateManager, cache stmgr.Mi
 
 	config := nv12.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 
@@ -1137,8 +1168,8 @@ func UpgradeActorsV5(ctx context.Context, sm *stmgr.StateManager, cache stmgr.Mi
 
 	config := nv13.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 
@@ -1223,8 +1254,8 @@ func UpgradeActorsV6(ctx context.Context, sm *stmgr.StateManager, cache stmgr.Mi
 
 	config := nv14.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 
@@ -1309,8 +1340,8 @@ func UpgradeActorsV7(ctx context.Context, sm *stmgr.StateManager, cache stmgr.Mi
 
 	config := nv15.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 
@@ -1402,8 +1433,8 @@ func UpgradeActorsV8(ctx context.Context, sm *stmgr.StateManager, cache stmgr.Mi
 
 	config := nv16.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 
@@ -1509,8 +1540,8 @@ func UpgradeActorsV9(ctx context.Context, sm *stmgr.StateManager, cache stmgr.Mi
 
 	config := nv17.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 
@@ -1637,8 +1668,8 @@ func UpgradeActorsV10(ctx context.Context, sm *stmgr.StateManager, cache stmgr.M
 
 	config := migration.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 
@@ -1744,8 +1775,8 @@ func UpgradeActorsV11(ctx context.Context, sm *stmgr.StateManager, cache stmgr.M
 	}
 	config := migration.Config{
 		MaxWorkers:        uint(workerCount),
-		JobQueueSize:      1000,
-		ResultQueueSize:   100,
+		JobQueueSize:      MigrationJobQueueSize,
+		ResultQueueSize:   MigrationResultQueueSize,
 		ProgressLogPeriod: 10 * time.Second,
 	}
 	newRoot, err := upgradeActorsV11Common(ctx, sm, cache, root, epoch, ts, config)
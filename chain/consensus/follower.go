@@ -0,0 +1,111 @@
+package consensus
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// followerTrustedHeads is the number of recent heads announced by the upstream a Follower accepts
+// blocks from, so that it can follow the upstream through short reorgs.
+const followerTrustedHeads = 16
+
+// Follower is a Consensus for nodes following the chain of a trusted upstream node, such as read
+// replicas of a node which already validated it. It only accepts the blocks of the chains of the
+// heads announced by the upstream, and instead of the full consensus validation it only executes
+// their parents to check the state and receipts they are built on. Header checks and block
+// creation are delegated to the wrapped consensus.
+type Follower struct {
+	Consensus
+
+	sm *stmgr.StateManager
+
+	lk    sync.Mutex
+	heads []*types.TipSet // newest last
+}
+
+var _ Consensus = (*Follower)(nil)
+
+func NewFollower(inner Consensus, sm *stmgr.StateManager) *Follower {
+	return &Follower{
+		Consensus: inner,
+		sm:        sm,
+	}
+}
+
+// TrustHead records a head announced by the upstream; the blocks of its chain are then accepted.
+func (f *Follower) TrustHead(ts *types.TipSet) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	f.heads = append(f.heads, ts)
+	if len(f.heads) > followerTrustedHeads {
+		f.heads = f.heads[len(f.heads)-followerTrustedHeads:]
+	}
+}
+
+// ValidateBlock accepts blocks in the chain of a trusted head whose parent state and receipts
+// match the execution of their parent tipset. Other blocks are refused with ErrTemporal, as the
+// upstream may announce them later.
+func (f *Follower) ValidateBlock(ctx context.Context, b *types.FullBlock) error {
+	trusted, err := f.isTrusted(ctx, b.Header)
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		return xerrors.Errorf("block %s at height %d isn't in the chain of the trusted upstream: %w", b.Cid(), b.Header.Height, ErrTemporal)
+	}
+
+	h := b.Header
+	baseTs, err := f.sm.ChainStore().LoadTipSet(ctx, types.NewTipSetKey(h.Parents...))
+	if err != nil {
+		return xerrors.Errorf("load parent tipset failed (%s): %w", h.Parents, err)
+	}
+
+	stateroot, precp, err := f.sm.TipSetState(ctx, baseTs)
+	if err != nil {
+		return xerrors.Errorf("get tipsetstate(%d, %s) failed: %w", h.Height, h.Parents, err)
+	}
+	if stateroot != h.ParentStateRoot {
+		return xerrors.Errorf("parent state root did not match computed state (%s != %s)", h.ParentStateRoot, stateroot)
+	}
+	if precp != h.ParentMessageReceipts {
+		return xerrors.Errorf("parent receipts root did not match computed value (%s != %s)", precp, h.ParentMessageReceipts)
+	}
+	return nil
+}
+
+// isTrusted checks if the block is in the chain of one of the trusted heads.
+func (f *Follower) isTrusted(ctx context.Context, b *types.BlockHeader) (bool, error) {
+	f.lk.Lock()
+	heads := append([]*types.TipSet(nil), f.heads...)
+	f.lk.Unlock()
+
+	cs := f.sm.ChainStore()
+	for i := len(heads) - 1; i >= 0; i-- {
+		head := heads[i]
+		if head.Height() < b.Height {
+			continue
+		}
+
+		ts, err := cs.GetTipsetByHeight(ctx, b.Height, head, true)
+		if err != nil {
+			// the chain of the head may not be fully fetched yet
+			log.Debugw("looking up block in the chain of a trusted head", "head", head.Key(), "error", err)
+			continue
+		}
+		if ts.Height() != b.Height {
+			continue
+		}
+		for _, c := range ts.Cids() {
+			if c == b.Cid() {
+				return true, nil
+			}
+		}
+	}
+	return false, ctx.Err()
+}
@@ -45,6 +45,16 @@ type Consensus interface {
 	CreateBlock(ctx context.Context, w api.Wallet, bt *api.BlockTemplate) (*types.FullBlock, error)
 }
 
+// BlockPrevalidator is implemented by consensus implementations able to check the parts of a block
+// which don't depend on the state it is built on, such as its syntax and message signatures.
+//
+// The syncer prevalidates upcoming tipsets in parallel while earlier ones are being executed, so
+// that invalid blocks are rejected early. ValidateBlock must still perform all the checks, but can
+// rely on the verified signature cache warmed by prevalidation.
+type BlockPrevalidator interface {
+	PrevalidateBlock(ctx context.Context, b *types.FullBlock) error
+}
+
 // RewardFunc parametrizes the logic for rewards when a message is executed.
 //
 // Each consensus implementation can set their own reward function.
@@ -0,0 +1,450 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	_init "github.com/filecoin-project/lotus/chain/actors/builtin/init"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// StatParallelTipSets counts the tipsets whose messages were executed in parallel, and
+// StatParallelFallbacks those whose parallel execution conflicted and was redone serially.
+var (
+	StatParallelTipSets   uint64
+	StatParallelFallbacks uint64
+)
+
+var errParallelConflict = errors.New("message groups conflict")
+
+// parallelMsg is a message of the tipset, in execution order, with the index of the first block
+// including it and the result of applying it.
+type parallelMsg struct {
+	block   int
+	cm      types.ChainMsg
+	ret     *vm.ApplyRet
+	touched map[address.Address]struct{}
+}
+
+type parallelResult struct {
+	vmi      vm.Interface
+	receipts []*types.MessageReceipt
+	events   [][]types.Event
+	msgGas   int64
+}
+
+// monitorRecorder buffers ExecMonitor callbacks, so that they can be delivered once the parallel
+// execution is known to be equivalent to the serial one.
+type monitorRecorder struct {
+	calls []recordedCall
+}
+
+type recordedCall struct {
+	mcid     cid.Cid
+	msg      *types.Message
+	ret      *vm.ApplyRet
+	implicit bool
+}
+
+func (r *monitorRecorder) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	r.calls = append(r.calls, recordedCall{mcid: mcid, msg: msg, ret: ret, implicit: implicit})
+	return nil
+}
+
+// applyMessagesParallel applies the messages of bms, and the block rewards, on top of pstate.
+// Messages are split into groups connected by their senders and recipients, and the groups are
+// spread over up to workers VMs executing concurrently from pstate, each in chain order and
+// writing to its own scratch blockstore. The resulting state changes are then merged into
+// stateBs, and the rewards applied on top.
+//
+// This only matches the serial execution if the groups are independent, which is checked after
+// the fact using the execution traces: no actor written by one VM may have been touched by
+// another, the only exceptions being the balances of the burnt funds and reward actors, which
+// only ever accumulate gas payments. Actors whose behaviour can't be derived from the trace (EVM
+// contracts, the init actor allocating IDs, actors created, deleted or changing code, which other
+// actors can look up without sending them a message) and rewards touching actors used by messages
+// of later blocks also conflict. On conflict, errParallelConflict is returned and nothing is
+// written to the ExecMonitor; the caller must then execute the messages serially. A nil result
+// with a nil error means the tipset can't, or isn't worth, executing in parallel.
+//
+// Up to network version 14, the VM recomputes the circulating supply from the state tree it is
+// mutating whenever an actor asks for it, so that messages of different groups observe each other
+// through the burnt funds and reward actor balances: those tipsets are always executed serially.
+// Later network versions compute it once, when the VM is created; it is pinned to the value of
+// the parent state for every VM used here, as it is for the single VM of the serial execution.
+func (t *TipSetExecutor) applyMessagesParallel(ctx context.Context,
+	sm *stmgr.StateManager,
+	stateBs blockstore.Blockstore,
+	pstate cid.Cid,
+	bms []FilecoinBlockMessages,
+	epoch abi.ChainEpoch,
+	em stmgr.ExecMonitor,
+	ts *types.TipSet,
+	storingEvents bool,
+	workers int,
+	newVMOpts func(base cid.Cid, e abi.ChainEpoch, timestamp uint64, tracing bool) *vm.VMOpts) (*parallelResult, error) {
+	if newVMOpts(pstate, epoch, ts.MinTimestamp(), true).NetworkVersion <= network.Version14 {
+		return nil, nil
+	}
+
+	var msgs []*parallelMsg
+	processedMsgs := make(map[cid.Cid]struct{})
+	for bi, b := range bms {
+		for _, cm := range append(b.BlsMessages, b.SecpkMessages...) {
+			mcid := cm.VMMessage().Cid()
+			if _, found := processedMsgs[mcid]; found {
+				continue
+			}
+			processedMsgs[mcid] = struct{}{}
+			msgs = append(msgs, &parallelMsg{block: bi, cm: cm})
+		}
+	}
+
+	cst := cbor.NewCborStore(stateBs)
+	base, err := state.LoadStateTree(cst, pstate)
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent state: %w", err)
+	}
+	resolve := func(addr address.Address) address.Address {
+		if id, err := base.LookupID(addr); err == nil {
+			return id
+		}
+		return addr
+	}
+
+	bins := groupMessages(msgs, resolve, workers)
+	if len(bins) < 2 {
+		return nil, nil
+	}
+
+	circSupply, err := sm.GetVMCirculatingSupply(ctx, epoch, base)
+	if err != nil {
+		return nil, xerrors.Errorf("computing circulating supply: %w", err)
+	}
+	makeVm := func(root cid.Cid, bs blockstore.Blockstore) (vm.Interface, error) {
+		vmopt := newVMOpts(root, epoch, ts.MinTimestamp(), true)
+		vmopt.Bstore = bs
+		vmopt.CircSupplyCalc = func(context.Context, abi.ChainEpoch, *state.StateTree) (abi.TokenAmount, error) {
+			return circSupply, nil
+		}
+		return sm.VMConstructor()(ctx, vmopt)
+	}
+
+	// The state roots of the groups are thrown away once merged; only the actor states they
+	// changed are copied to stateBs.
+	binBs := make([]blockstore.Blockstore, len(bins))
+	roots := make([]cid.Cid, len(bins))
+	eg, egctx := errgroup.WithContext(ctx)
+	for i, bin := range bins {
+		i, bin := i, bin
+		binBs[i] = blockstore.NewTieredBstore(stateBs, blockstore.NewMemorySync())
+		eg.Go(func() error {
+			vmi, err := makeVm(pstate, binBs[i])
+			if err != nil {
+				return xerrors.Errorf("making vm: %w", err)
+			}
+			for _, mi := range bin {
+				ret, err := vmi.ApplyMessage(egctx, msgs[mi].cm)
+				if err != nil {
+					// Let the serial execution decide whether the error is real.
+					return xerrors.Errorf("%w: applying message: %s", errParallelConflict, err)
+				}
+				msgs[mi].ret = ret
+			}
+			roots[i], err = vmi.Flush(egctx)
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range msgs {
+		vmsg := m.cm.VMMessage()
+		m.touched = map[address.Address]struct{}{
+			resolve(vmsg.From): {},
+			resolve(vmsg.To):   {},
+		}
+		addTraceActors(m.touched, &m.ret.ExecutionTrace, resolve)
+	}
+
+	// touched holds, for each actor, the bins whose messages called it.
+	touched := make(map[address.Address]map[int]struct{})
+	for bi, bin := range bins {
+		for _, mi := range bin {
+			for addr := range msgs[mi].touched {
+				if touched[addr] == nil {
+					touched[addr] = make(map[int]struct{})
+				}
+				touched[addr][bi] = struct{}{}
+			}
+		}
+	}
+	written := make(map[address.Address]struct{})
+	var (
+		changes    []state.ActorChange
+		changeBins []int
+	)
+	for bi, root := range roots {
+		post, err := state.LoadStateTree(cbor.NewCborStore(binBs[bi]), root)
+		if err != nil {
+			return nil, xerrors.Errorf("loading group state: %w", err)
+		}
+		binChanges, err := state.DiffActors(ctx, base, post)
+		if err != nil {
+			return nil, xerrors.Errorf("diffing group state: %w", err)
+		}
+		for _, ch := range binChanges {
+			switch {
+			case ch.Address == builtin.BurntFundsActorAddr || ch.Address == reward.Address:
+				if ch.Old == nil || ch.New == nil || !ch.Old.Code.Equals(ch.New.Code) ||
+					!ch.Old.Head.Equals(ch.New.Head) || ch.Old.Nonce != ch.New.Nonce {
+					return nil, xerrors.Errorf("%w: %s state modified", errParallelConflict, ch.Address)
+				}
+			case ch.Address == _init.Address:
+				return nil, xerrors.Errorf("%w: init actor modified", errParallelConflict)
+			case ch.Old == nil || ch.New == nil || !ch.Old.Code.Equals(ch.New.Code):
+				return nil, xerrors.Errorf("%w: %s created, deleted or changed code", errParallelConflict, ch.Address)
+			default:
+				if _, ok := written[ch.Address]; ok {
+					return nil, xerrors.Errorf("%w: %s modified by several groups", errParallelConflict, ch.Address)
+				}
+				for other := range touched[ch.Address] {
+					if other != bi {
+						return nil, xerrors.Errorf("%w: %s modified and used by different groups", errParallelConflict, ch.Address)
+					}
+				}
+				written[ch.Address] = struct{}{}
+			}
+			changes = append(changes, ch)
+			changeBins = append(changeBins, bi)
+		}
+	}
+
+	for addr := range touched {
+		act, err := base.GetActor(addr)
+		if err != nil {
+			continue
+		}
+		if builtin.IsEvmActor(act.Code) {
+			return nil, xerrors.Errorf("%w: EVM actor %s called", errParallelConflict, addr)
+		}
+	}
+
+	// The block rewards are applied after all messages, instead of after each block. The reward
+	// actor clamps the payout to its balance, which must then not depend on the gas paid by the
+	// messages of later blocks.
+	rewardAct, err := base.GetActor(reward.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("loading reward actor: %w", err)
+	}
+	rst, err := reward.Load(adt.WrapStore(ctx, cst), rewardAct)
+	if err != nil {
+		return nil, xerrors.Errorf("loading reward actor state: %w", err)
+	}
+	epochReward, err := rst.ThisEpochReward()
+	if err != nil {
+		return nil, xerrors.Errorf("loading epoch reward: %w", err)
+	}
+
+	params := make([]*reward.AwardBlockRewardParams, len(bms))
+	for bi, b := range bms {
+		params[bi] = &reward.AwardBlockRewardParams{
+			Miner:     b.Miner,
+			Penalty:   types.NewInt(0),
+			GasReward: big.Zero(),
+			WinCount:  b.WinCount,
+		}
+	}
+	for _, m := range msgs {
+		p := params[m.block]
+		p.GasReward = big.Add(p.GasReward, m.ret.GasCosts.MinerTip)
+		p.Penalty = big.Add(p.Penalty, m.ret.GasCosts.MinerPenalty)
+	}
+	maxPayout := big.Zero()
+	for _, p := range params {
+		maxPayout = big.Sum(maxPayout, big.Mul(epochReward, big.NewInt(p.WinCount)), p.GasReward)
+	}
+	if rewardAct.Balance.LessThan(maxPayout) {
+		return nil, xerrors.Errorf("%w: reward actor balance may clamp block rewards", errParallelConflict)
+	}
+
+	merged, err := state.LoadStateTree(cst, pstate)
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent state: %w", err)
+	}
+	for i, ch := range changes {
+		switch {
+		case ch.Address == builtin.BurntFundsActorAddr || ch.Address == reward.Address:
+			act, err := merged.GetActor(ch.Address)
+			if err != nil {
+				return nil, xerrors.Errorf("loading %s: %w", ch.Address, err)
+			}
+			act.Balance = big.Add(act.Balance, big.Sub(ch.New.Balance, ch.Old.Balance))
+			if err := merged.SetActor(ch.Address, act); err != nil {
+				return nil, xerrors.Errorf("setting %s: %w", ch.Address, err)
+			}
+		default:
+			if !ch.New.Head.Equals(ch.Old.Head) {
+				if err := vm.Copy(ctx, binBs[changeBins[i]], stateBs, ch.New.Head); err != nil {
+					return nil, xerrors.Errorf("copying state of %s: %w", ch.Address, err)
+				}
+			}
+			if err := merged.SetActor(ch.Address, ch.New); err != nil {
+				return nil, xerrors.Errorf("setting %s: %w", ch.Address, err)
+			}
+		}
+	}
+	mergedRoot, err := merged.Flush(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("flushing merged state: %w", err)
+	}
+
+	vmi, err := makeVm(mergedRoot, stateBs)
+	if err != nil {
+		return nil, xerrors.Errorf("making vm: %w", err)
+	}
+	rewardCalls := make([]recordedCall, len(bms))
+	for bi := range bms {
+		rec := &monitorRecorder{}
+		if err := t.reward(ctx, vmi, rec, epoch, ts, params[bi]); err != nil {
+			return nil, xerrors.Errorf("error applying reward: %w", err)
+		}
+		if len(rec.calls) != 1 {
+			return nil, xerrors.Errorf("expected one reward message, got %d", len(rec.calls))
+		}
+		rewardCalls[bi] = rec.calls[0]
+
+		rewarded := make(map[address.Address]struct{})
+		addTraceActors(rewarded, &rec.calls[0].ret.ExecutionTrace, resolve)
+		delete(rewarded, builtin.BurntFundsActorAddr)
+		for _, m := range msgs {
+			if m.block <= bi {
+				continue
+			}
+			for addr := range rewarded {
+				if _, ok := m.touched[addr]; ok {
+					return nil, xerrors.Errorf("%w: block reward uses %s, used by a later block", errParallelConflict, addr)
+				}
+			}
+		}
+	}
+
+	res := &parallelResult{vmi: vmi}
+	next := 0
+	for bi := range bms {
+		for ; next < len(msgs) && msgs[next].block == bi; next++ {
+			m := msgs[next]
+			res.msgGas += m.ret.GasUsed
+			res.receipts = append(res.receipts, &m.ret.MessageReceipt)
+			if storingEvents {
+				// Appends nil when no events are returned to preserve positional alignment.
+				res.events = append(res.events, m.ret.Events)
+			}
+			if em != nil {
+				if err := em.MessageApplied(ctx, ts, m.cm.Cid(), m.cm.VMMessage(), m.ret, false); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if em != nil {
+			rc := rewardCalls[bi]
+			if err := em.MessageApplied(ctx, ts, rc.mcid, rc.msg, rc.ret, rc.implicit); err != nil {
+				return nil, xerrors.Errorf("callback failed on reward message: %w", err)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// groupMessages splits msgs into groups of messages connected by their senders and recipients,
+// and spreads the groups over at most n bins, largest first. Each bin lists message indexes in
+// chain order.
+func groupMessages(msgs []*parallelMsg, resolve func(address.Address) address.Address, n int) [][]int {
+	parent := make(map[address.Address]address.Address)
+	var find func(a address.Address) address.Address
+	find = func(a address.Address) address.Address {
+		p, ok := parent[a]
+		if !ok || p == a {
+			parent[a] = a
+			return a
+		}
+		root := find(p)
+		parent[a] = root
+		return root
+	}
+
+	for _, m := range msgs {
+		vmsg := m.cm.VMMessage()
+		from, to := find(resolve(vmsg.From)), find(resolve(vmsg.To))
+		if from != to {
+			parent[to] = from
+		}
+	}
+
+	groupIdx := make(map[address.Address]int)
+	var groups [][]int
+	for i, m := range msgs {
+		root := find(resolve(m.cm.VMMessage().From))
+		gi, ok := groupIdx[root]
+		if !ok {
+			gi = len(groups)
+			groupIdx[root] = gi
+			groups = append(groups, nil)
+		}
+		groups[gi] = append(groups[gi], i)
+	}
+
+	if n > len(groups) {
+		n = len(groups)
+	}
+	if n < 2 {
+		return nil
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i]) > len(groups[j])
+	})
+	bins := make([][]int, n)
+	for _, g := range groups {
+		smallest := 0
+		for bi := range bins {
+			if len(bins[bi]) < len(bins[smallest]) {
+				smallest = bi
+			}
+		}
+		bins[smallest] = append(bins[smallest], g...)
+	}
+	for _, bin := range bins {
+		sort.Ints(bin)
+	}
+	return bins
+}
+
+// addTraceActors adds to out every actor sending or receiving a message in the trace.
+func addTraceActors(out map[address.Address]struct{}, et *types.ExecutionTrace, resolve func(address.Address) address.Address) {
+	out[resolve(et.Msg.From)] = struct{}{}
+	out[resolve(et.Msg.To)] = struct{}{}
+	for i := range et.Subcalls {
+		addTraceActors(out, &et.Subcalls[i], resolve)
+	}
+}
@@ -0,0 +1,229 @@
+// stm: #unit
+package consensus_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/network"
+	miner2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+func init() {
+	policy.SetSupportedProofTypes(abi.RegisteredSealProof_StackedDrg2KiBV1)
+	policy.SetConsensusMinerMinPower(abi.NewStoragePower(2048))
+	policy.SetMinVerifiedDealSize(abi.NewStoragePower(256))
+}
+
+// legacyUpgrades upgrades the chain to network version 15, the last one executed by the legacy VM.
+var legacyUpgrades = stmgr.UpgradeSchedule{{
+	Network:   network.Version9,
+	Height:    1,
+	Migration: filcns.UpgradeActorsV2,
+}, {
+	Network:   network.Version10,
+	Height:    2,
+	Migration: filcns.UpgradeActorsV3,
+}, {
+	Network:   network.Version12,
+	Height:    3,
+	Migration: filcns.UpgradeActorsV4,
+}, {
+	Network:   network.Version13,
+	Height:    4,
+	Migration: filcns.UpgradeActorsV5,
+}, {
+	Network:   network.Version14,
+	Height:    5,
+	Migration: filcns.UpgradeActorsV6,
+}, {
+	Network:   network.Version15,
+	Height:    6,
+	Migration: filcns.UpgradeActorsV7,
+}}
+
+type appliedMsg struct {
+	mcid     cid.Cid
+	receipt  types.MessageReceipt
+	implicit bool
+}
+
+type appliedRecorder struct {
+	applied []appliedMsg
+}
+
+func (r *appliedRecorder) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	r.applied = append(r.applied, appliedMsg{mcid: mcid, receipt: ret.MessageReceipt, implicit: implicit})
+	return nil
+}
+
+func TestParallelExecution(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		upgrades stmgr.UpgradeSchedule
+		parallel bool
+	}{
+		// up to network version 14, tipsets are always executed serially
+		{name: "nv13", upgrades: legacyUpgrades[:4], parallel: false},
+		{name: "nv15", upgrades: legacyUpgrades, parallel: true},
+		{name: "latest", upgrades: filcns.DefaultUpgradeSchedule(), parallel: true},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			testParallelExecution(t, tc.upgrades, tc.parallel)
+		})
+	}
+}
+
+func testParallelExecution(t *testing.T, upgrades stmgr.UpgradeSchedule, parallel bool) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGeneratorWithUpgradeSchedule(upgrades)
+	require.NoError(t, err)
+	// get past the upgrades
+	for i := 0; i < 8; i++ {
+		_, err := cg.NextTipSet()
+		require.NoError(t, err)
+	}
+	sm := cg.StateManager()
+	sm.SetParallelExecutionWorkers(4)
+
+	nonces := make(map[address.Address]uint64)
+	call := func(from, to address.Address, value abi.TokenAmount, method abi.MethodNum, params []byte) *types.SignedMessage {
+		msg := types.Message{
+			From:       from,
+			To:         to,
+			Nonce:      nonces[from],
+			Value:      value,
+			Method:     method,
+			Params:     params,
+			GasLimit:   1_000_000_000,
+			GasFeeCap:  abi.NewTokenAmount(1_000_000_000),
+			GasPremium: abi.NewTokenAmount(1_000),
+		}
+		nonces[from]++
+
+		sig, err := cg.Wallet().WalletSign(ctx, from, msg.Cid().Bytes(), api.MsgMeta{Type: api.MTUnknown})
+		require.NoError(t, err)
+		return &types.SignedMessage{Message: msg, Signature: *sig}
+	}
+	send := func(from, to address.Address, value abi.TokenAmount) *types.SignedMessage {
+		return call(from, to, value, builtin.MethodSend, nil)
+	}
+
+	// Fund accounts, so that the next tipsets can move funds between disjoint pairs of them.
+	banker, err := sm.LoadActor(ctx, cg.Banker(), cg.CurTipset.TipSet())
+	require.NoError(t, err)
+	nonces[cg.Banker()] = banker.Nonce
+
+	var accts []address.Address
+	var funding []*types.SignedMessage
+	for i := 0; i < 8; i++ {
+		a, err := cg.Wallet().WalletNew(ctx, types.KTSecp256k1)
+		require.NoError(t, err)
+		accts = append(accts, a)
+		funding = append(funding, send(cg.Banker(), a, types.FromFil(100)))
+	}
+	ts, err := cg.NextTipSetFromMinersWithMessagesAndNulls(cg.CurTipset.TipSet(), cg.Miners[:1], [][]*types.SignedMessage{funding}, 0)
+	require.NoError(t, err)
+
+	exec := consensus.NewTipSetExecutor(filcns.RewardFunc)
+	execute := func(ts *types.TipSet, parallel bool) (cid.Cid, cid.Cid, []appliedMsg) {
+		var rec appliedRecorder
+		buf := blockstore.NewTieredBstore(sm.ChainStore().StateBlockstore(), blockstore.NewMemorySync())
+		st, rectroot, err := exec.ExecuteTipSetWithOpts(ctx, sm, ts, &rec, false, stmgr.ExecutorOpts{
+			StateBlockstore: buf,
+			Parallel:        parallel,
+		})
+		require.NoError(t, err)
+		// the whole resulting state is available from the execution's blockstore
+		require.NoError(t, vm.Copy(ctx, buf, blockstore.NewMemory(), st))
+		return st, rectroot, rec.applied
+	}
+	check := func(ts *types.TipSet) {
+		serialSt, serialRec, serialApplied := execute(ts, false)
+		parallelSt, parallelRec, parallelApplied := execute(ts, true)
+		require.Equal(t, serialSt, parallelSt)
+		require.Equal(t, serialRec, parallelRec)
+		require.Equal(t, serialApplied, parallelApplied)
+	}
+	expectParallel := func(tipsets, fallbacks uint64, f func()) {
+		prevTipSets := atomic.LoadUint64(&consensus.StatParallelTipSets)
+		prevFallbacks := atomic.LoadUint64(&consensus.StatParallelFallbacks)
+		f()
+		if !parallel {
+			tipsets, fallbacks = 0, 0
+		}
+		require.Equal(t, prevTipSets+tipsets, atomic.LoadUint64(&consensus.StatParallelTipSets))
+		require.Equal(t, prevFallbacks+fallbacks, atomic.LoadUint64(&consensus.StatParallelFallbacks))
+	}
+
+	// Independent transfers, spread over two blocks, one message being included in both.
+	pair := func(i int) *types.SignedMessage { return send(accts[2*i], accts[2*i+1], types.FromFil(1)) }
+	first, second, third, fourth := pair(0), pair(1), pair(2), pair(3)
+	msgs := [][]*types.SignedMessage{{first, second}, {third, fourth, first}}
+	ts, err = cg.NextTipSetFromMinersWithMessagesAndNulls(ts.TipSet(), cg.Miners, msgs, 0)
+	require.NoError(t, err)
+	transfers := ts.TipSet()
+	expectParallel(1, 0, func() { check(transfers) })
+
+	// A market deposit and a miner call, next to transfers. Depending on the order of the blocks,
+	// the reward of a block may touch the miner before a later block calls it, in which case the
+	// tipset is executed serially; the results must match either way.
+	st, _, err := sm.TipSetState(ctx, ts.TipSet())
+	require.NoError(t, err)
+	worker, err := stmgr.GetMinerWorkerRaw(ctx, sm, st, cg.Miners[0])
+	require.NoError(t, err)
+	workerAct, err := sm.LoadActor(ctx, worker, ts.TipSet())
+	require.NoError(t, err)
+	nonces[worker] = workerAct.Nonce
+
+	escrowParams, err := actors.SerializeParams(&accts[0])
+	require.NoError(t, err)
+	peerParams, err := actors.SerializeParams(&miner2.ChangePeerIDParams{NewID: abi.PeerID("parallel")})
+	require.NoError(t, err)
+	msgs = [][]*types.SignedMessage{
+		{call(accts[0], market.Address, types.FromFil(1), builtin.MethodsMarket.AddBalance, escrowParams), pair(1)},
+		{call(worker, cg.Miners[0], types.NewInt(0), builtin.MethodsMiner.ChangePeerID, peerParams), pair(2)},
+	}
+	ts, err = cg.NextTipSetFromMinersWithMessagesAndNulls(ts.TipSet(), cg.Miners, msgs, 0)
+	require.NoError(t, err)
+	check(ts.TipSet())
+
+	// Transfers creating accounts allocate IDs in the init actor, and must be executed serially.
+	a, err := cg.Wallet().WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	b, err := cg.Wallet().WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	msgs = [][]*types.SignedMessage{{send(accts[0], a, types.FromFil(1)), send(accts[2], b, types.FromFil(1))}}
+	ts, err = cg.NextTipSetFromMinersWithMessagesAndNulls(ts.TipSet(), cg.Miners[:1], msgs, 0)
+	require.NoError(t, err)
+	expectParallel(0, 1, func() { check(ts.TipSet()) })
+
+	// Executions not asking for it, such as block validation, are always serial.
+	expectParallel(0, 0, func() {
+		_, _, err := exec.ExecuteTipSet(ctx, sm, transfers, nil, false)
+		require.NoError(t, err)
+	})
+}
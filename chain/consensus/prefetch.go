@@ -0,0 +1,100 @@
+package consensus
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+)
+
+// Messages can't be applied concurrently: every message touches the gas accounting actors, and
+// the VM isn't safe for concurrent use. What can be parallelised is loading the state each
+// message is about to touch, which dominates execution time on cold archival nodes. When enabled,
+// the actors of disjoint groups of senders and recipients are loaded by a pool of workers ahead of
+// the (serial) message application. This only warms the blockstore, and never changes the result
+// of an execution; see ExecutorOpts.Parallel for executing independent messages concurrently.
+var (
+	ExecPrefetchWorkers    int
+	EnvExecPrefetchWorkers = "LOTUS_EXEC_PREFETCH_WORKERS"
+)
+
+func init() {
+	if pws := os.Getenv(EnvExecPrefetchWorkers); pws != "" {
+		pw, err := strconv.ParseInt(pws, 10, 32)
+		if err != nil {
+			log.Warnf("invalid value for %s (%s), prefetching disabled: %s", EnvExecPrefetchWorkers, pws, err)
+			return
+		}
+		ExecPrefetchWorkers = int(pw)
+	}
+}
+
+// prefetchMessageState warms the blockstore with the actors (and their state heads) touched by
+// the given messages, in the background. Failures are ignored; the serial execution will surface
+// them if they matter. The returned function stops prefetching and waits for the workers to exit.
+func prefetchMessageState(ctx context.Context, bs blockstore.Blockstore, pstate cid.Cid, bms []FilecoinBlockMessages) func() {
+	if ExecPrefetchWorkers <= 0 {
+		return func() {}
+	}
+
+	// Group addresses by worker so that no two workers load the same actor.
+	seen := make(map[address.Address]struct{})
+	groups := make([][]address.Address, ExecPrefetchWorkers)
+	var n int
+	add := func(addr address.Address) {
+		if _, ok := seen[addr]; ok {
+			return
+		}
+		seen[addr] = struct{}{}
+		groups[n%len(groups)] = append(groups[n%len(groups)], addr)
+		n++
+	}
+	for _, b := range bms {
+		for _, cm := range append(b.BlsMessages, b.SecpkMessages...) {
+			m := cm.VMMessage()
+			add(m.From)
+			add(m.To)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(group []address.Address) {
+			defer wg.Done()
+
+			// Each worker needs its own state tree, as they aren't safe for concurrent use.
+			st, err := state.LoadStateTree(cbor.NewCborStore(bs), pstate)
+			if err != nil {
+				return
+			}
+			for _, addr := range group {
+				if ctx.Err() != nil {
+					return
+				}
+				act, err := st.GetActor(addr)
+				if err != nil {
+					continue
+				}
+				_, _ = bs.Get(ctx, act.Head)
+			}
+		}(group)
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,126 @@
+package consensus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// verifiedSigsCacheSize bounds the verified signature cache; 64k * ~100 bytes = 6MB, enough for
+// the messages of a few sync batches.
+const verifiedSigsCacheSize = 64 << 10
+
+// verifiedSigs remembers recently verified message signatures and BLS aggregates, keyed by a hash
+// of the signature, the signers and the signed data, so that the signatures checked when
+// prevalidating a block aren't verified again when validating it.
+var verifiedSigs, _ = lru.New[[sha256.Size]byte, struct{}](verifiedSigsCacheSize)
+
+func signatureKey(sig *crypto.Signature, signer address.Address, digest []byte) [sha256.Size]byte {
+	h := sha256.New()
+	_, _ = h.Write([]byte{byte(sig.Type)})
+	writeLenPrefixed(h, sig.Data)
+	writeLenPrefixed(h, signer.Bytes())
+	writeLenPrefixed(h, digest)
+
+	var k [sha256.Size]byte
+	h.Sum(k[:0])
+	return k
+}
+
+func blsAggregateKey(sig *crypto.Signature, msgs []cid.Cid, pubks [][]byte) [sha256.Size]byte {
+	h := sha256.New()
+	writeLenPrefixed(h, sig.Data)
+	for i := range msgs {
+		writeLenPrefixed(h, msgs[i].Bytes())
+		writeLenPrefixed(h, pubks[i])
+	}
+
+	var k [sha256.Size]byte
+	h.Sum(k[:0])
+	return k
+}
+
+func writeLenPrefixed(h hash.Hash, b []byte) {
+	_, _ = h.Write(binary.AppendUvarint(nil, uint64(len(b))))
+	_, _ = h.Write(b)
+}
+
+// PrevalidateMessages performs the checks of the messages of a block which don't depend on the
+// state it is built on: syntax, the block gas limit, the signature types of secpk messages, and
+// the signatures of the messages sent from key addresses, including the BLS aggregate when all
+// the BLS messages are. Signatures of messages sent from ID addresses are left to ValidateBlock,
+// which needs the state to resolve them.
+func PrevalidateMessages(ctx context.Context, sm *stmgr.StateManager, b *types.FullBlock) error {
+	nv := sm.GetNetworkVersion(ctx, b.Header.Height)
+	pl := vm.PricelistByEpoch(b.Header.Height)
+
+	var sumGasLimit int64
+	checkMsg := func(msg types.ChainMsg) error {
+		m := msg.VMMessage()
+
+		minGas := pl.OnChainMessage(msg.ChainLength())
+		if err := m.ValidForBlockInclusion(minGas.Total(), nv); err != nil {
+			return xerrors.Errorf("msg %s invalid for block inclusion: %w", m.Cid(), err)
+		}
+
+		// overflow safe, as ValidForBlockInclusion bounds the gas limit of each message
+		sumGasLimit += m.GasLimit
+		if sumGasLimit > build.BlockGasLimit {
+			return xerrors.Errorf("block gas limit exceeded")
+		}
+		return nil
+	}
+
+	sigCids := make([]cid.Cid, 0, len(b.BlsMessages))
+	pubks := make([][]byte, 0, len(b.BlsMessages))
+	for i, m := range b.BlsMessages {
+		if err := checkMsg(m); err != nil {
+			return xerrors.Errorf("block had invalid bls message at index %d: %w", i, err)
+		}
+
+		if pubks != nil && m.From.Protocol() == address.BLS {
+			sigCids = append(sigCids, m.Cid())
+			pubks = append(pubks, m.From.Payload())
+		} else {
+			pubks = nil
+		}
+	}
+	if pubks != nil {
+		if err := VerifyBlsAggregate(ctx, b.Header.BLSAggregate, sigCids, pubks); err != nil {
+			return xerrors.Errorf("bls aggregate signature was invalid: %w", err)
+		}
+	}
+
+	for i, m := range b.SecpkMessages {
+		if nv >= network.Version14 && !IsValidSecpkSigType(nv, m.Signature.Type) {
+			return xerrors.Errorf("block had invalid signed message at index %d", i)
+		}
+
+		if err := checkMsg(m); err != nil {
+			return xerrors.Errorf("block had invalid secpk message at index %d: %w", i, err)
+		}
+
+		switch m.Message.From.Protocol() {
+		case address.SECP256K1, address.Delegated:
+			if err := AuthenticateMessage(m, m.Message.From); err != nil {
+				return xerrors.Errorf("failed to validate signature: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
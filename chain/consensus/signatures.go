@@ -44,9 +44,15 @@ func AuthenticateMessage(msg *types.SignedMessage, signer address.Address) error
 		digest = msg.Message.Cid().Bytes()
 	}
 
+	key := signatureKey(&msg.Signature, signer, digest)
+	if verifiedSigs.Contains(key) {
+		return nil
+	}
+
 	if err := sigs.Verify(&msg.Signature, signer, digest); err != nil {
 		return xerrors.Errorf("message %s has invalid signature (type %d): %w", msg.Cid(), typ, err)
 	}
+	verifiedSigs.Add(key, struct{}{})
 	return nil
 }
 
@@ -37,10 +37,16 @@ func VerifyBlsAggregate(ctx context.Context, sig *crypto.Signature, msgs []cid.C
 		return nil
 	}
 
+	key := blsAggregateKey(sig, msgs, pubks)
+	if verifiedSigs.Contains(key) {
+		return nil
+	}
+
 	valid := ffi.HashVerify(sigS, msgsS, pubksS)
 	if !valid {
 		return xerrors.New("bls aggregate signature failed to verify")
 	}
+	verifiedSigs.Add(key, struct{}{})
 	return nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"time"
 
@@ -101,9 +102,6 @@ func (c *client) doRequest(
 	// return on the first successful response.
 	// FIXME: Doing this serially isn't great, but fetching in parallel
 	//  may not be a good idea either. Think about this more.
-	globalTime := build.Clock.Now()
-	// Global time used to track what is the expected time we will need to get
-	// a response if a client fails us.
 	for _, peer := range peers {
 		select {
 		case <-ctx.Done():
@@ -129,7 +127,6 @@ func (c *client) doRequest(
 			continue
 		}
 
-		c.peerTracker.logGlobalSuccess(build.Clock.Since(globalTime))
 		c.host.ConnManager().TagPeer(peer, "bsync", SuccessPeerTagValue)
 		return validRes, nil
 	}
@@ -407,6 +404,7 @@ func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, req *Reque
 	}
 
 	connectionStart := build.Clock.Now()
+	defer c.peerTracker.startRequest(peer)()
 
 	// Open stream to peer.
 	stream, err := c.host.NewStream(
@@ -424,7 +422,7 @@ func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, req *Reque
 	_ = stream.SetWriteDeadline(time.Now().Add(WriteReqDeadline))
 	if err := cborutil.WriteCborRPC(stream, req); err != nil {
 		_ = stream.SetWriteDeadline(time.Time{})
-		c.peerTracker.logFailure(peer, build.Clock.Since(connectionStart), req.Length)
+		c.peerTracker.logFailure(peer, build.Clock.Since(connectionStart), 0)
 		// FIXME: Should we also remove peer here?
 		return nil, err
 	}
@@ -436,11 +434,10 @@ func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, req *Reque
 
 	// Read response.
 	var res Response
-	err = cborutil.ReadCborRPC(
-		bufio.NewReader(incrt.New(stream, ReadResMinSpeed, ReadResDeadline)),
-		&res)
+	cr := &countingReader{Reader: incrt.New(stream, ReadResMinSpeed, ReadResDeadline)}
+	err = cborutil.ReadCborRPC(bufio.NewReader(cr), &res)
 	if err != nil {
-		c.peerTracker.logFailure(peer, build.Clock.Since(connectionStart), req.Length)
+		c.peerTracker.logFailure(peer, build.Clock.Since(connectionStart), cr.n)
 		return nil, xerrors.Errorf("failed to read chainxchg response: %w", err)
 	}
 
@@ -454,7 +451,7 @@ func (c *client) sendRequestToPeer(ctx context.Context, peer peer.ID, req *Reque
 		)
 	}
 
-	c.peerTracker.logSuccess(peer, build.Clock.Since(connectionStart), uint64(len(res.Chain)))
+	c.peerTracker.logSuccess(peer, build.Clock.Since(connectionStart), cr.n)
 	// FIXME: We should really log a success only after we validate the response.
 	//  It might be a bit hard to do.
 	return &res, nil
@@ -494,3 +491,15 @@ func shufflePrefix(peers []peer.ID) {
 
 	copy(peers, buf)
 }
+
+// countingReader counts the bytes of responses, to measure the throughput of peers.
+type countingReader struct {
+	io.Reader
+	n uint64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += uint64(n)
+	return n, err
+}
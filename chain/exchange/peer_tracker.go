@@ -17,17 +17,18 @@ import (
 )
 
 type peerStats struct {
-	successes   int
-	failures    int
-	firstSeen   time.Time
-	averageTime time.Duration
+	successes  int
+	failures   int
+	firstSeen  time.Time
+	throughput float64 // bytes per second, moving average
+	inFlight   int
 }
 
 type bsPeerTracker struct {
 	lk sync.Mutex
 
-	peers         map[peer.ID]*peerStats
-	avgGlobalTime time.Duration
+	peers            map[peer.ID]*peerStats
+	globalThroughput float64
 
 	pmgr *peermgr.PeerMgr
 }
@@ -82,42 +83,28 @@ const (
 	newPeerMul = 0.9
 )
 
+// prefSortedPeers returns the peers sorted by the throughput a new request can expect from them:
+// their measured throughput, reduced by their failure rate and shared with the requests already in
+// flight to them, so that concurrent requests are spread over the fastest peers.
 func (bpt *bsPeerTracker) prefSortedPeers() []peer.ID {
 	// TODO: this could probably be cached, but as long as its not too many peers, fine for now
 	bpt.lk.Lock()
 	defer bpt.lk.Unlock()
 	out := make([]peer.ID, 0, len(bpt.peers))
-	for p := range bpt.peers {
+	score := make(map[peer.ID]float64, len(bpt.peers))
+	for p, pi := range bpt.peers {
 		out = append(out, p)
-	}
-
-	// sort by 'expected cost' of requesting data from that peer
-	// additionally handle edge cases where not enough data is available
-	sort.Slice(out, func(i, j int) bool {
-		pi := bpt.peers[out[i]]
-		pj := bpt.peers[out[j]]
-
-		var costI, costJ float64
-
-		getPeerInitLat := func(p peer.ID) float64 {
-			return float64(bpt.avgGlobalTime) * newPeerMul
-		}
 
+		tp := bpt.globalThroughput / newPeerMul
 		if pi.successes+pi.failures > 0 {
-			failRateI := float64(pi.failures) / float64(pi.failures+pi.successes)
-			costI = float64(pi.averageTime) + failRateI*float64(bpt.avgGlobalTime)
-		} else {
-			costI = getPeerInitLat(out[i])
-		}
-
-		if pj.successes+pj.failures > 0 {
-			failRateJ := float64(pj.failures) / float64(pj.failures+pj.successes)
-			costJ = float64(pj.averageTime) + failRateJ*float64(bpt.avgGlobalTime)
-		} else {
-			costJ = getPeerInitLat(out[j])
+			failRate := float64(pi.failures) / float64(pi.failures+pi.successes)
+			tp = pi.throughput * (1 - failRate)
 		}
+		score[p] = tp / float64(1+pi.inFlight)
+	}
 
-		return costI < costJ
+	sort.Slice(out, func(i, j int) bool {
+		return score[out[i]] > score[out[j]]
 	})
 
 	return out
@@ -130,29 +117,37 @@ const (
 	globalInvAlpha = 25 // 86% of the value is the last 49
 )
 
-func (bpt *bsPeerTracker) logGlobalSuccess(dur time.Duration) {
-	bpt.lk.Lock()
-	defer bpt.lk.Unlock()
-
-	if bpt.avgGlobalTime == 0 {
-		bpt.avgGlobalTime = dur
+func logThroughput(avg *float64, invAlpha float64, dur time.Duration, size uint64) {
+	if dur <= 0 {
 		return
 	}
-	delta := (dur - bpt.avgGlobalTime) / globalInvAlpha
-	bpt.avgGlobalTime += delta
-}
-
-func logTime(pi *peerStats, dur time.Duration) {
-	if pi.averageTime == 0 {
-		pi.averageTime = dur
+	tp := float64(size) / dur.Seconds()
+	if *avg == 0 {
+		*avg = tp
 		return
 	}
-	delta := (dur - pi.averageTime) / localInvAlpha
-	pi.averageTime += delta
+	*avg += (tp - *avg) / invAlpha
+}
 
+// startRequest records a request in flight to a peer; it returns a function to call when it ends.
+func (bpt *bsPeerTracker) startRequest(p peer.ID) func() {
+	bpt.lk.Lock()
+	defer bpt.lk.Unlock()
+
+	pi, ok := bpt.peers[p]
+	if !ok {
+		return func() {}
+	}
+	pi.inFlight++
+	return func() {
+		bpt.lk.Lock()
+		defer bpt.lk.Unlock()
+		pi.inFlight--
+	}
 }
 
-func (bpt *bsPeerTracker) logSuccess(p peer.ID, dur time.Duration, reqSize uint64) {
+// logSuccess records a response of size bytes received from a peer in dur.
+func (bpt *bsPeerTracker) logSuccess(p peer.ID, dur time.Duration, size uint64) {
 	bpt.lk.Lock()
 	defer bpt.lk.Unlock()
 
@@ -164,13 +159,12 @@ func (bpt *bsPeerTracker) logSuccess(p peer.ID, dur time.Duration, reqSize uint6
 	}
 
 	pi.successes++
-	if reqSize == 0 {
-		reqSize = 1
-	}
-	logTime(pi, dur/time.Duration(reqSize))
+	logThroughput(&pi.throughput, localInvAlpha, dur, size)
+	logThroughput(&bpt.globalThroughput, globalInvAlpha, dur, size)
 }
 
-func (bpt *bsPeerTracker) logFailure(p peer.ID, dur time.Duration, reqSize uint64) {
+// logFailure records a failed request to a peer, which sent size bytes in dur before failing.
+func (bpt *bsPeerTracker) logFailure(p peer.ID, dur time.Duration, size uint64) {
 	bpt.lk.Lock()
 	defer bpt.lk.Unlock()
 
@@ -182,10 +176,7 @@ func (bpt *bsPeerTracker) logFailure(p peer.ID, dur time.Duration, reqSize uint6
 	}
 
 	pi.failures++
-	if reqSize == 0 {
-		reqSize = 1
-	}
-	logTime(pi, dur/time.Duration(reqSize))
+	logThroughput(&pi.throughput, localInvAlpha, dur, size)
 }
 
 func (bpt *bsPeerTracker) removePeer(p peer.ID) {
@@ -0,0 +1,38 @@
+// stm: #unit
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerTrackerThroughputScoring(t *testing.T) {
+	bpt := &bsPeerTracker{peers: make(map[peer.ID]*peerStats)}
+
+	fast, slow, failing, fresh := peer.ID("fast"), peer.ID("slow"), peer.ID("failing"), peer.ID("fresh")
+	for _, p := range []peer.ID{fast, slow, failing, fresh} {
+		bpt.addPeer(p)
+	}
+
+	bpt.logSuccess(fast, time.Second, 4<<20)
+	bpt.logSuccess(slow, time.Second, 1<<20)
+	bpt.logSuccess(failing, time.Second, 4<<20)
+	bpt.logFailure(failing, 10*time.Second, 0)
+
+	// new peers are assumed slightly better than the average
+	require.Equal(t, []peer.ID{fresh, fast, failing, slow}, bpt.prefSortedPeers())
+
+	// requests in flight share the throughput of a peer
+	doneFresh := bpt.startRequest(fresh)
+	doneFast := bpt.startRequest(fast)
+	doneFast2 := bpt.startRequest(fast)
+	require.Equal(t, []peer.ID{fresh, failing, fast, slow}, bpt.prefSortedPeers())
+
+	doneFresh()
+	doneFast()
+	doneFast2()
+	require.Equal(t, []peer.ID{fresh, fast, failing, slow}, bpt.prefSortedPeers())
+}
@@ -27,24 +27,59 @@ func New(dstore ds.Batching) *SlashFilter {
 	}
 }
 
+// Consensus faults detected by the filter.
+const (
+	FaultDoubleForkMining = "double-fork mining faults"
+	FaultTimeOffsetMining = "time-offset mining faults"
+	FaultParentGrinding   = "parent-grinding fault"
+)
+
 func (f *SlashFilter) MinedBlock(ctx context.Context, bh *types.BlockHeader, parentEpoch abi.ChainEpoch) error {
-	if build.IsNearUpgrade(bh.Height, build.UpgradeOrangeHeight) {
+	fault, other, err := f.CheckBlock(ctx, bh, parentEpoch)
+	if err != nil {
+		return err
+	}
+
+	switch fault {
+	case "":
 		return nil
+	case FaultParentGrinding:
+		return xerrors.Errorf("produced block would trigger '%s' consensus fault; miner: %s; bh: %s, expected parent: %s", fault, bh.Miner, bh.Cid(), other)
+	default:
+		return xerrors.Errorf("produced block would trigger '%s' consensus fault; miner: %s; bh: %s, other: %s", fault, bh.Miner, bh.Cid(), other)
+	}
+}
+
+// CheckBlock checks whether a block commits a consensus fault with a block of the same miner
+// checked before, and returns the fault along with the other block; for parent-grinding faults,
+// the other block is the one the block should have been mined on. Blocks committing no fault
+// are recorded.
+func (f *SlashFilter) CheckBlock(ctx context.Context, bh *types.BlockHeader, parentEpoch abi.ChainEpoch) (string, cid.Cid, error) {
+	if build.IsNearUpgrade(bh.Height, build.UpgradeOrangeHeight) {
+		return "", cid.Undef, nil
 	}
 
 	epochKey := ds.NewKey(fmt.Sprintf("/%s/%d", bh.Miner, bh.Height))
 	{
 		// double-fork mining (2 blocks at one epoch)
-		if err := checkFault(ctx, f.byEpoch, epochKey, bh, "double-fork mining faults"); err != nil {
-			return err
+		other, err := checkFault(ctx, f.byEpoch, epochKey, bh)
+		if err != nil {
+			return "", cid.Undef, err
+		}
+		if other != cid.Undef {
+			return FaultDoubleForkMining, other, nil
 		}
 	}
 
 	parentsKey := ds.NewKey(fmt.Sprintf("/%s/%x", bh.Miner, types.NewTipSetKey(bh.Parents...).Bytes()))
 	{
 		// time-offset mining faults (2 blocks with the same parents)
-		if err := checkFault(ctx, f.byParents, parentsKey, bh, "time-offset mining faults"); err != nil {
-			return err
+		other, err := checkFault(ctx, f.byParents, parentsKey, bh)
+		if err != nil {
+			return "", cid.Undef, err
+		}
+		if other != cid.Undef {
+			return FaultTimeOffsetMining, other, nil
 		}
 	}
 
@@ -55,19 +90,19 @@ func (f *SlashFilter) MinedBlock(ctx context.Context, bh *types.BlockHeader, par
 		parentEpochKey := ds.NewKey(fmt.Sprintf("/%s/%d", bh.Miner, parentEpoch))
 		have, err := f.byEpoch.Has(ctx, parentEpochKey)
 		if err != nil {
-			return err
+			return "", cid.Undef, err
 		}
 
 		if have {
 			// If we had, make sure it's in our parent tipset
 			cidb, err := f.byEpoch.Get(ctx, parentEpochKey)
 			if err != nil {
-				return xerrors.Errorf("getting other block cid: %w", err)
+				return "", cid.Undef, xerrors.Errorf("getting other block cid: %w", err)
 			}
 
 			_, parent, err := cid.CidFromBytes(cidb)
 			if err != nil {
-				return err
+				return "", cid.Undef, err
 			}
 
 			var found bool
@@ -78,45 +113,46 @@ func (f *SlashFilter) MinedBlock(ctx context.Context, bh *types.BlockHeader, par
 			}
 
 			if !found {
-				return xerrors.Errorf("produced block would trigger 'parent-grinding fault' consensus fault; miner: %s; bh: %s, expected parent: %s", bh.Miner, bh.Cid(), parent)
+				return FaultParentGrinding, parent, nil
 			}
 		}
 	}
 
 	if err := f.byParents.Put(ctx, parentsKey, bh.Cid().Bytes()); err != nil {
-		return xerrors.Errorf("putting byEpoch entry: %w", err)
+		return "", cid.Undef, xerrors.Errorf("putting byEpoch entry: %w", err)
 	}
 
 	if err := f.byEpoch.Put(ctx, epochKey, bh.Cid().Bytes()); err != nil {
-		return xerrors.Errorf("putting byEpoch entry: %w", err)
+		return "", cid.Undef, xerrors.Errorf("putting byEpoch entry: %w", err)
 	}
 
-	return nil
+	return "", cid.Undef, nil
 }
 
-func checkFault(ctx context.Context, t ds.Datastore, key ds.Key, bh *types.BlockHeader, faultType string) error {
+// checkFault returns the other block recorded at key, if it isn't bh.
+func checkFault(ctx context.Context, t ds.Datastore, key ds.Key, bh *types.BlockHeader) (cid.Cid, error) {
 	fault, err := t.Has(ctx, key)
 	if err != nil {
-		return err
+		return cid.Undef, err
 	}
 
 	if fault {
 		cidb, err := t.Get(ctx, key)
 		if err != nil {
-			return xerrors.Errorf("getting other block cid: %w", err)
+			return cid.Undef, xerrors.Errorf("getting other block cid: %w", err)
 		}
 
 		_, other, err := cid.CidFromBytes(cidb)
 		if err != nil {
-			return err
+			return cid.Undef, err
 		}
 
 		if other == bh.Cid() {
-			return nil
+			return cid.Undef, nil
 		}
 
-		return xerrors.Errorf("produced block would trigger '%s' consensus fault; miner: %s; bh: %s, other: %s", faultType, bh.Miner, bh.Cid(), other)
+		return other, nil
 	}
 
-	return nil
+	return cid.Undef, nil
 }
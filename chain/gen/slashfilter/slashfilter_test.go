@@ -0,0 +1,66 @@
+package slashfilter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestCheckBlock(t *testing.T) {
+	ctx := context.Background()
+	sf := New(dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	// far from any upgrade
+	const base = abi.ChainEpoch(100_000_000)
+	mkBlock := func(parents *types.TipSet, height abi.ChainEpoch, nonce uint64) *types.BlockHeader {
+		b := mock.MkBlock(parents, 1, nonce)
+		b.Height = height
+		return b
+	}
+
+	genesis := mock.TipSet(mkBlock(nil, base, 0))
+	a := mkBlock(genesis, base+1, 1)
+
+	fault, other, err := sf.CheckBlock(ctx, a, base)
+	require.NoError(t, err)
+	require.Empty(t, fault)
+	require.Equal(t, cid.Undef, other)
+
+	// the same block again is fine
+	fault, _, err = sf.CheckBlock(ctx, a, base)
+	require.NoError(t, err)
+	require.Empty(t, fault)
+
+	// another block at the same epoch
+	fault, other, err = sf.CheckBlock(ctx, mkBlock(genesis, base+1, 2), base)
+	require.NoError(t, err)
+	require.Equal(t, FaultDoubleForkMining, fault)
+	require.Equal(t, a.Cid(), other)
+
+	// another block on the same parents
+	fault, other, err = sf.CheckBlock(ctx, mkBlock(genesis, base+2, 3), base)
+	require.NoError(t, err)
+	require.Equal(t, FaultTimeOffsetMining, fault)
+	require.Equal(t, a.Cid(), other)
+
+	// a block not mined on top of a
+	sibling := mock.TipSet(mkBlock(genesis, base+1, 4))
+	fault, other, err = sf.CheckBlock(ctx, mkBlock(sibling, base+2, 5), base+1)
+	require.NoError(t, err)
+	require.Equal(t, FaultParentGrinding, fault)
+	require.Equal(t, a.Cid(), other)
+
+	// a block mined on top of a
+	fault, _, err = sf.CheckBlock(ctx, mkBlock(mock.TipSet(a), base+2, 6), base+1)
+	require.NoError(t, err)
+	require.Empty(t, fault)
+}
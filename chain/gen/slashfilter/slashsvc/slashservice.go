@@ -0,0 +1,211 @@
+package slashsvc
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	cborutil "github.com/filecoin-project/go-cbor-util"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("slashsvc")
+
+// ConsensusSlasherApi is the node API the consensus fault reporter runs against.
+type ConsensusSlasherApi interface {
+	ChainGetBlock(context.Context, cid.Cid) (*types.BlockHeader, error)
+	ChainGetTipSet(context.Context, types.TipSetKey) (*types.TipSet, error)
+	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateReplay(context.Context, types.TipSetKey, cid.Cid) (*api.InvocResult, error)
+	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	SyncIncomingBlocks(context.Context) (<-chan *types.BlockHeader, error)
+	WalletDefaultAddress(context.Context) (address.Address, error)
+}
+
+// Options of the consensus fault reporter.
+type Options struct {
+	// From is the address the reports are sent from; the default wallet address when undefined.
+	From address.Address
+	// DryRun only logs the faults found, without reporting them.
+	DryRun bool
+	// TrackRewards waits for the reports to land on chain and logs the rewards they earned.
+	TrackRewards bool
+}
+
+// SlashConsensus watches the blocks received by the node for consensus faults, and reports
+// them to the miner actor of the faulty miner. The blocks are recorded in ds.
+func SlashConsensus(ctx context.Context, a ConsensusSlasherApi, ds datastore.Batching, opts Options) error {
+	from := opts.From
+	if from == address.Undef {
+		var err error
+		from, err = a.WalletDefaultAddress(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting default wallet address: %w", err)
+		}
+		if from == address.Undef && !opts.DryRun {
+			return xerrors.Errorf("no address to report consensus faults from: set one, or a default wallet address")
+		}
+	}
+
+	blocks, err := a.SyncIncomingBlocks(ctx)
+	if err != nil {
+		return xerrors.Errorf("subscribing to incoming blocks: %w", err)
+	}
+
+	sf := slashfilter.New(ds)
+	log.Infow("consensus fault reporter started", "from", from, "dryRun", opts.DryRun)
+
+	go func() {
+		for bh := range blocks {
+			params, fault, err := checkBlock(ctx, a, sf, bh)
+			if err != nil {
+				log.Errorw("checking block for consensus faults", "block", bh.Cid(), "error", err)
+				continue
+			}
+			if params == nil {
+				continue
+			}
+
+			log.Warnw("consensus fault found", "fault", fault, "miner", bh.Miner, "epoch", bh.Height, "block", bh.Cid())
+			if opts.DryRun {
+				continue
+			}
+
+			enc, err := actors.SerializeParams(params)
+			if err != nil {
+				log.Errorw("serializing consensus fault report", "error", err)
+				continue
+			}
+
+			smsg, err := a.MpoolPushMessage(ctx, &types.Message{
+				To:     bh.Miner,
+				From:   from,
+				Value:  big.Zero(),
+				Method: builtin.MethodsMiner.ReportConsensusFault,
+				Params: enc,
+			}, nil)
+			if err != nil {
+				log.Errorw("pushing consensus fault report", "miner", bh.Miner, "error", err)
+				continue
+			}
+			log.Infow("consensus fault reported", "fault", fault, "miner", bh.Miner, "message", smsg.Cid())
+
+			if opts.TrackRewards {
+				go trackReward(ctx, a, from, smsg.Cid())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// checkBlock runs a block through the slash filter, and returns the parameters of the report of
+// the consensus fault it commits, if any.
+func checkBlock(ctx context.Context, a ConsensusSlasherApi, sf *slashfilter.SlashFilter, bh *types.BlockHeader) (*miner.ReportConsensusFaultParams, string, error) {
+	parent, err := a.ChainGetTipSet(ctx, types.NewTipSetKey(bh.Parents...))
+	if err != nil {
+		return nil, "", xerrors.Errorf("getting parent tipset: %w", err)
+	}
+
+	fault, otherCid, err := sf.CheckBlock(ctx, bh, parent.Height())
+	if err != nil || fault == "" {
+		return nil, "", err
+	}
+
+	other, err := a.ChainGetBlock(ctx, otherCid)
+	if err != nil {
+		return nil, "", xerrors.Errorf("getting other block: %w", err)
+	}
+
+	var params miner.ReportConsensusFaultParams
+	if fault == slashfilter.FaultParentGrinding {
+		// the block the miner should have mined on comes first, and the extra block is a block
+		// of the parents of bh mined on the same parents and at the same epoch as it
+		var extra *types.BlockHeader
+		for _, pb := range parent.Blocks() {
+			if pb.Height == other.Height && types.CidArrsEqual(pb.Parents, other.Parents) {
+				extra = pb
+				break
+			}
+		}
+		if extra == nil {
+			return nil, "", xerrors.Errorf("no parent block of %s to prove the parent-grinding fault with", bh.Cid())
+		}
+
+		if params.BlockHeader1, err = cborutil.Dump(other); err != nil {
+			return nil, "", err
+		}
+		if params.BlockHeader2, err = cborutil.Dump(bh); err != nil {
+			return nil, "", err
+		}
+		if params.BlockHeaderExtra, err = cborutil.Dump(extra); err != nil {
+			return nil, "", err
+		}
+		return &params, fault, nil
+	}
+
+	if params.BlockHeader1, err = cborutil.Dump(bh); err != nil {
+		return nil, "", err
+	}
+	if params.BlockHeader2, err = cborutil.Dump(other); err != nil {
+		return nil, "", err
+	}
+	return &params, fault, nil
+}
+
+// trackReward waits for a report to land on chain and logs the reward it earned.
+func trackReward(ctx context.Context, a ConsensusSlasherApi, from address.Address, mc cid.Cid) {
+	lookup, err := a.StateWaitMsg(ctx, mc, build.MessageConfidence, api.LookbackNoLimit, true)
+	if err != nil {
+		log.Errorw("waiting for consensus fault report", "message", mc, "error", err)
+		return
+	}
+	if lookup.Receipt.ExitCode.IsError() {
+		log.Warnw("consensus fault report failed", "message", mc, "exitCode", lookup.Receipt.ExitCode)
+		return
+	}
+
+	res, err := a.StateReplay(ctx, lookup.TipSet, lookup.Message)
+	if err != nil {
+		log.Errorw("replaying consensus fault report", "message", mc, "error", err)
+		return
+	}
+
+	// the miner actor pays the reward to the ID address of the reporter
+	fromID, err := a.StateLookupID(ctx, from, lookup.TipSet)
+	if err != nil {
+		log.Errorw("looking up reporter ID address", "address", from, "error", err)
+		return
+	}
+
+	reward := big.Zero()
+	var sum func(types.ExecutionTrace)
+	sum = func(et types.ExecutionTrace) {
+		if et.Msg.To == fromID || et.Msg.To == from {
+			reward = big.Add(reward, et.Msg.Value)
+		}
+		for _, sc := range et.Subcalls {
+			sum(sc)
+		}
+	}
+	for _, sc := range res.ExecutionTrace.Subcalls {
+		sum(sc)
+	}
+
+	log.Infow("consensus fault report landed", "message", mc, "epoch", lookup.Height,
+		"reward", types.FIL(reward), "gasCost", types.FIL(res.GasCost.TotalCost))
+}
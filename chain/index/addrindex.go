@@ -0,0 +1,558 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var addrDbName = "addrindex.db"
+var addrDbDefs = []string{
+	`CREATE TABLE IF NOT EXISTS address_messages (
+     address VARCHAR(128) NOT NULL,
+     direction INTEGER NOT NULL,
+     cid VARCHAR(80) NOT NULL,
+     tipset_cid VARCHAR(80) NOT NULL,
+     epoch INTEGER NOT NULL,
+     position INTEGER NOT NULL,
+     PRIMARY KEY (address, direction, cid) ON CONFLICT REPLACE
+   )`,
+	`CREATE INDEX IF NOT EXISTS address_epochs ON address_messages (address, direction, epoch)
+  `,
+	`CREATE INDEX IF NOT EXISTS address_tipset_cids ON address_messages (tipset_cid)
+  `,
+	`CREATE TABLE IF NOT EXISTS tipsets (
+     tipset_cid VARCHAR(80) PRIMARY KEY ON CONFLICT REPLACE,
+     tipset_key BLOB NOT NULL,
+     epoch INTEGER NOT NULL
+   )`,
+	`CREATE INDEX IF NOT EXISTS tipset_epochs ON tipsets (epoch)
+  `,
+}
+
+const (
+	addrqInsertMessage        = "INSERT INTO address_messages (address, direction, cid, tipset_cid, epoch, position) VALUES (?, ?, ?, ?, ?, ?)"
+	addrqInsertTipset         = "INSERT INTO tipsets (tipset_cid, tipset_key, epoch) VALUES (?, ?, ?)"
+	addrqCountTipset          = "SELECT COUNT(*) FROM tipsets WHERE tipset_cid = ?"
+	addrqDeleteTipsetMessages = "DELETE FROM address_messages WHERE tipset_cid = ?"
+	addrqDeleteTipset         = "DELETE FROM tipsets WHERE tipset_cid = ?"
+	addrqListMessages         = "SELECT cid FROM address_messages WHERE address = ? AND direction = ? AND epoch <= ? AND epoch >= ? ORDER BY epoch DESC, position ASC"
+	// coverage
+	addrqMinEpoch     = "SELECT MIN(epoch) FROM tipsets"
+	addrqBottomTipset = "SELECT tipset_key, epoch FROM tipsets ORDER BY epoch ASC LIMIT 1"
+	// reconciliation
+	addrqDeleteMessagesByEpoch = "DELETE FROM address_messages WHERE epoch > ?"
+	addrqDeleteTipsetsByEpoch  = "DELETE FROM tipsets WHERE epoch > ?"
+)
+
+// backfill configuration; exposed to make tests snappy
+var (
+	// AddrIndexBackfillBatch is the number of tipsets indexed per backfill transaction.
+	AddrIndexBackfillBatch = 100
+	// AddrIndexBackfillIdle is how long the backfill waits for the first tipset to be indexed.
+	AddrIndexBackfillIdle = 30 * time.Second
+)
+
+// addrIndex indexes the messages of the chain by sender and recipient. The indexed tipsets are
+// always a contiguous range of the current chain: new tipsets are indexed on head changes,
+// along with any of their ancestors missed while the node was down, and a backfill walks the
+// chain down from the lowest indexed tipset to genesis, or to the first tipset whose messages
+// are unavailable.
+type addrIndex struct {
+	cs ChainStore
+
+	db *sql.DB
+
+	// lk serializes the updates of head changes and of the backfill.
+	lk sync.Mutex
+	// low is the epoch of the lowest indexed tipset, or -1 if nothing is indexed.
+	low atomic.Int64
+
+	sema chan struct{}
+	mx   sync.Mutex
+	pend []headChange
+
+	cancel  func()
+	workers sync.WaitGroup
+	closeLk sync.RWMutex
+	closed  bool
+}
+
+var _ AddrIndex = (*addrIndex)(nil)
+
+func NewAddrIndex(lctx context.Context, basePath string, cs ChainStore) (AddrIndex, error) {
+	err := os.MkdirAll(basePath, 0755)
+	if err != nil {
+		return nil, xerrors.Errorf("error creating addrindex base directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", path.Join(basePath, addrDbName))
+	if err != nil {
+		return nil, xerrors.Errorf("error opening addrindex database: %w", err)
+	}
+
+	for _, stmt := range addrDbDefs {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("error executing sql statement '%s': %w", stmt, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(lctx)
+
+	x := &addrIndex{
+		cs:     cs,
+		db:     db,
+		sema:   make(chan struct{}, 1),
+		cancel: cancel,
+	}
+
+	if err := x.reconcile(ctx); err != nil {
+		cancel()
+		_ = db.Close()
+		return nil, xerrors.Errorf("error reconciling addrindex database: %w", err)
+	}
+	if err := x.refreshLow(); err != nil {
+		cancel()
+		_ = db.Close()
+		return nil, err
+	}
+
+	rnf := store.WrapHeadChangeCoalescer(
+		x.onHeadChange,
+		CoalesceMinDelay,
+		CoalesceMaxDelay,
+		CoalesceMergeInterval,
+	)
+	cs.SubscribeHeadChanges(rnf)
+
+	x.workers.Add(2)
+	go x.background(ctx)
+	go x.backfill(ctx)
+
+	return x, nil
+}
+
+// reconcile removes the tipsets which are no longer in the current chain, leaving the indexed
+// tipsets up to the most recent one still in it.
+func (x *addrIndex) reconcile(ctx context.Context) error {
+	var low sql.NullInt64
+	if err := x.db.QueryRow(addrqMinEpoch).Scan(&low); err != nil {
+		return xerrors.Errorf("error finding lowest indexed epoch: %w", err)
+	}
+	if !low.Valid {
+		return nil
+	}
+
+	// everything above the boundary goes; if no indexed tipset is in the chain anymore, that's
+	// everything
+	boundary := abi.ChainEpoch(low.Int64) - 1
+
+	curTs := x.cs.GetHeaviestTipSet()
+	for curTs != nil && curTs.Height() >= abi.ChainEpoch(low.Int64) {
+		indexed, err := x.isIndexed(x.db, curTs)
+		if err != nil {
+			return err
+		}
+		if indexed {
+			boundary = curTs.Height()
+			break
+		}
+		if curTs.Height() == 0 {
+			break
+		}
+
+		curTs, err = x.cs.GetTipSetFromKey(ctx, curTs.Parents())
+		if err != nil {
+			return xerrors.Errorf("error walking chain: %w", err)
+		}
+	}
+
+	if _, err := x.db.Exec(addrqDeleteMessagesByEpoch, int64(boundary)); err != nil {
+		return xerrors.Errorf("error deleting reorged out messages: %w", err)
+	}
+	if _, err := x.db.Exec(addrqDeleteTipsetsByEpoch, int64(boundary)); err != nil {
+		return xerrors.Errorf("error deleting reorged out tipsets: %w", err)
+	}
+	return nil
+}
+
+func (x *addrIndex) refreshLow() error {
+	var low sql.NullInt64
+	if err := x.db.QueryRow(addrqMinEpoch).Scan(&low); err != nil {
+		return xerrors.Errorf("error finding lowest indexed epoch: %w", err)
+	}
+	if !low.Valid {
+		x.low.Store(-1)
+		return nil
+	}
+	x.low.Store(low.Int64)
+	return nil
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func (x *addrIndex) isIndexed(db queryRower, ts *types.TipSet) (bool, error) {
+	tscid, err := ts.Key().Cid()
+	if err != nil {
+		return false, xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	var count int64
+	if err := db.QueryRow(addrqCountTipset, tscid.String()).Scan(&count); err != nil {
+		return false, xerrors.Errorf("error looking up tipset: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (x *addrIndex) indexTipSet(tx *sql.Tx, ts *types.TipSet, msgs []types.ChainMsg) error {
+	tscid, err := ts.Key().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	tskey := tscid.String()
+	epoch := int64(ts.Height())
+
+	for i, msg := range msgs {
+		key := msg.Cid().String()
+		vmsg := msg.VMMessage()
+		if _, err := tx.Exec(addrqInsertMessage, vmsg.From.String(), AddrMsgFrom, key, tskey, epoch, i); err != nil {
+			return xerrors.Errorf("error inserting message: %w", err)
+		}
+		if _, err := tx.Exec(addrqInsertMessage, vmsg.To.String(), AddrMsgTo, key, tskey, epoch, i); err != nil {
+			return xerrors.Errorf("error inserting message: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(addrqInsertTipset, tskey, ts.Key().Bytes(), epoch); err != nil {
+		return xerrors.Errorf("error inserting tipset: %w", err)
+	}
+	return nil
+}
+
+// head change notifee
+func (x *addrIndex) onHeadChange(rev, app []*types.TipSet) error {
+	x.closeLk.RLock()
+	defer x.closeLk.RUnlock()
+
+	if x.closed {
+		return nil
+	}
+
+	// do it in the background to avoid blocking head change processing
+	x.mx.Lock()
+	x.pend = append(x.pend, headChange{rev: rev, app: app})
+	pendLen := len(x.pend)
+	x.mx.Unlock()
+
+	if pendLen > 10 {
+		log.Warnf("address index head change processing is building backlog: %d pending head changes", pendLen)
+	}
+
+	select {
+	case x.sema <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (x *addrIndex) background(ctx context.Context) {
+	defer x.workers.Done()
+
+	for {
+		select {
+		case <-x.sema:
+			if err := x.processHeadChanges(ctx); err != nil {
+				// we can't rely on an inconsistent index, so shut it down.
+				log.Errorf("error processing head change notifications: %s; shutting down address index", err)
+				go func() {
+					if err := x.Close(); err != nil {
+						log.Errorf("error shutting down address index: %s", err)
+					}
+				}()
+				return
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (x *addrIndex) processHeadChanges(ctx context.Context) error {
+	x.mx.Lock()
+	pend := x.pend
+	x.pend = nil
+	x.mx.Unlock()
+
+	x.lk.Lock()
+	defer x.lk.Unlock()
+
+	tx, err := x.db.Begin()
+	if err != nil {
+		return xerrors.Errorf("error creating transaction: %w", err)
+	}
+
+	for _, hc := range pend {
+		for _, ts := range hc.rev {
+			if err := x.doRevert(tx, ts); err != nil {
+				if err2 := tx.Rollback(); err2 != nil {
+					log.Errorf("error rolling back transaction: %s", err2)
+				}
+				return xerrors.Errorf("error reverting %s: %w", ts, err)
+			}
+		}
+
+		for _, ts := range hc.app {
+			if err := x.doApply(ctx, tx, ts); err != nil {
+				if err2 := tx.Rollback(); err2 != nil {
+					log.Errorf("error rolling back transaction: %s", err2)
+				}
+				return xerrors.Errorf("error applying %s: %w", ts, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return x.refreshLow()
+}
+
+func (x *addrIndex) doRevert(tx *sql.Tx, ts *types.TipSet) error {
+	tscid, err := ts.Key().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	key := tscid.String()
+	if _, err := tx.Exec(addrqDeleteTipsetMessages, key); err != nil {
+		return err
+	}
+	_, err = tx.Exec(addrqDeleteTipset, key)
+	return err
+}
+
+// doApply indexes ts, along with the ancestors it needs to be linked to the indexed range.
+func (x *addrIndex) doApply(ctx context.Context, tx *sql.Tx, ts *types.TipSet) error {
+	var missing []*types.TipSet
+
+	var low sql.NullInt64
+	if err := tx.QueryRow(addrqMinEpoch).Scan(&low); err != nil {
+		return xerrors.Errorf("error finding lowest indexed epoch: %w", err)
+	}
+
+	cur := ts
+	for {
+		indexed, err := x.isIndexed(tx, cur)
+		if err != nil {
+			return err
+		}
+		if indexed {
+			break
+		}
+		missing = append(missing, cur)
+
+		if !low.Valid || cur.Height() <= abi.ChainEpoch(low.Int64) {
+			// nothing indexed to link to; this starts the indexed range
+			break
+		}
+
+		cur, err = x.cs.GetTipSetFromKey(ctx, cur.Parents())
+		if err != nil {
+			return xerrors.Errorf("error walking chain: %w", err)
+		}
+		if cur.Height() < abi.ChainEpoch(low.Int64) {
+			return xerrors.Errorf("tipset %s doesn't link to the indexed chain", ts)
+		}
+	}
+
+	if len(missing) > 1 {
+		log.Infof("address index catching up on %d tipsets", len(missing)-1)
+	}
+
+	for _, ts := range missing {
+		msgs, err := x.cs.MessagesForTipset(ctx, ts)
+		if err != nil {
+			return xerrors.Errorf("error retrieving messages for tipset %s: %w", ts, err)
+		}
+		if err := x.indexTipSet(tx, ts, msgs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfill indexes the chain below the lowest indexed tipset, a batch at a time.
+func (x *addrIndex) backfill(ctx context.Context) {
+	defer x.workers.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		done, err := x.backfillBatch(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				log.Errorf("address index backfill failed: %s", err)
+			}
+			return
+		case done:
+			log.Infof("address index backfill complete; messages are indexed from epoch %d", x.low.Load())
+			return
+		}
+	}
+}
+
+func (x *addrIndex) backfillBatch(ctx context.Context) (done bool, err error) {
+	x.lk.Lock()
+	defer x.lk.Unlock()
+
+	var (
+		tskey []byte
+		epoch int64
+	)
+	err = x.db.QueryRow(addrqBottomTipset).Scan(&tskey, &epoch)
+	switch {
+	case err == sql.ErrNoRows:
+		// wait for the first head change to start the indexed range
+		x.lk.Unlock()
+		select {
+		case <-time.After(AddrIndexBackfillIdle):
+		case <-ctx.Done():
+		}
+		x.lk.Lock()
+		return false, nil
+	case err != nil:
+		return false, xerrors.Errorf("error finding lowest indexed tipset: %w", err)
+	case epoch == 0:
+		return true, nil
+	}
+
+	tsk, err := types.TipSetKeyFromBytes(tskey)
+	if err != nil {
+		return false, xerrors.Errorf("error decoding tipset key: %w", err)
+	}
+	cur, err := x.cs.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return false, xerrors.Errorf("error loading lowest indexed tipset: %w", err)
+	}
+
+	tx, err := x.db.Begin()
+	if err != nil {
+		return false, xerrors.Errorf("error creating transaction: %w", err)
+	}
+
+	for i := 0; i < AddrIndexBackfillBatch && cur.Height() > 0; i++ {
+		parent, err := x.cs.GetTipSetFromKey(ctx, cur.Parents())
+		if err != nil {
+			log.Infof("address index backfill stopping at epoch %d: %s", cur.Height(), err)
+			done = true
+			break
+		}
+		msgs, err := x.cs.MessagesForTipset(ctx, parent)
+		if err != nil {
+			log.Infof("address index backfill stopping at epoch %d: %s", cur.Height(), err)
+			done = true
+			break
+		}
+		if err := x.indexTipSet(tx, parent, msgs); err != nil {
+			if err2 := tx.Rollback(); err2 != nil {
+				log.Errorf("error rolling back transaction: %s", err2)
+			}
+			return false, err
+		}
+		cur = parent
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, xerrors.Errorf("error committing transaction: %w", err)
+	}
+	if err := x.refreshLow(); err != nil {
+		return false, err
+	}
+	return done || cur.Height() == 0, nil
+}
+
+// interface
+func (x *addrIndex) ListMessages(ctx context.Context, addr address.Address, dir AddrMsgDirection, ts *types.TipSet, toHeight abi.ChainEpoch) ([]cid.Cid, error) {
+	x.closeLk.RLock()
+	defer x.closeLk.RUnlock()
+
+	if x.closed {
+		return nil, ErrClosed
+	}
+
+	if low := x.low.Load(); low < 0 || int64(toHeight) < low {
+		return nil, ErrNotIndexed
+	}
+	indexed, err := x.isIndexed(x.db, ts)
+	if err != nil {
+		return nil, err
+	}
+	if !indexed {
+		return nil, ErrNotIndexed
+	}
+
+	rows, err := x.db.QueryContext(ctx, addrqListMessages, addr.String(), dir, int64(ts.Height()), int64(toHeight))
+	if err != nil {
+		return nil, xerrors.Errorf("error querying addrindex database: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var out []cid.Cid
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, xerrors.Errorf("error querying addrindex database: %w", err)
+		}
+		c, err := cid.Decode(key)
+		if err != nil {
+			return nil, xerrors.Errorf("error decoding message cid: %w", err)
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("error querying addrindex database: %w", err)
+	}
+
+	return out, nil
+}
+
+func (x *addrIndex) Close() error {
+	x.closeLk.Lock()
+	defer x.closeLk.Unlock()
+
+	if x.closed {
+		return nil
+	}
+
+	x.closed = true
+
+	x.cancel()
+	x.workers.Wait()
+
+	return x.db.Close()
+}
@@ -0,0 +1,52 @@
+package index
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddrIndex(t *testing.T) {
+	// 1. Create a chain with some history
+	// 2. Create an index and advance the chain; the new tipset is indexed on the head change,
+	//    and the history is backfilled
+	// 3. Verify that the messages are listed most recent first
+	AddrIndexBackfillIdle = 100 * time.Millisecond
+
+	cs := newMockChainStore()
+	cs.genesis()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, cs.advance())
+	}
+
+	addrIndex, err := NewAddrIndex(context.Background(), t.TempDir(), cs)
+	require.NoError(t, err)
+	defer addrIndex.Close() //nolint
+
+	require.NoError(t, cs.advance())
+	head := cs.GetHeaviestTipSet()
+
+	waitForCoalescerAfterLastEvent()
+
+	var expected []cid.Cid
+	for ts := head; ts.Height() > 0; ts = cs.tipsets[ts.Parents()] {
+		for _, m := range cs.msgs[ts.Key()] {
+			expected = append(expected, m.Cid())
+		}
+	}
+
+	msgs, err := addrIndex.ListMessages(context.Background(), systemAddr, AddrMsgFrom, head, 0)
+	require.NoError(t, err)
+	require.Equal(t, expected, msgs)
+
+	msgs, err = addrIndex.ListMessages(context.Background(), systemAddr, AddrMsgTo, head, head.Height())
+	require.NoError(t, err)
+	require.Equal(t, expected[:2], msgs)
+
+	// tipsets which aren't indexed can't be listed from
+	_, err = addrIndex.ListMessages(context.Background(), systemAddr, AddrMsgFrom, cs.makeBlk(), 0)
+	require.ErrorIs(t, err, ErrNotIndexed)
+}
@@ -6,7 +6,10 @@ import (
 
 	"github.com/ipfs/go-cid"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
 )
 
 var ErrNotFound = errors.New("message not found")
@@ -20,6 +23,14 @@ type MsgInfo struct {
 	TipSet cid.Cid
 	// the epoch where this message was included
 	Epoch abi.ChainEpoch
+	// the position of the message in the inclusion tipset, which is also the index of its
+	// receipt in the execution tipset; -1 if it wasn't recorded when the message was indexed
+	ReceiptIndex int64
+	// the first block of the inclusion tipset including the message; cid.Undef if it wasn't
+	// recorded when the message was indexed
+	Block cid.Cid
+	// the tipset executing the message; types.EmptyTSK until it is applied
+	ExecutionTipSet types.TipSetKey
 }
 
 // MsgIndex is the interface to the message index
@@ -43,3 +54,37 @@ func (dummyMsgIndex) Close() error {
 }
 
 var DummyMsgIndex MsgIndex = dummyMsgIndex{}
+
+var ErrNotIndexed = errors.New("range not covered by the index")
+
+// AddrMsgDirection is the direction of a message relative to an address in the address index.
+type AddrMsgDirection int
+
+const (
+	// AddrMsgFrom selects the messages sent by an address.
+	AddrMsgFrom AddrMsgDirection = iota
+	// AddrMsgTo selects the messages sent to an address.
+	AddrMsgTo
+)
+
+// AddrIndex is the interface to the index of messages by sender and recipient address
+type AddrIndex interface {
+	// ListMessages returns the messages sent from or to addr, as given in the messages, in the
+	// chain of ts down to epoch toHeight, most recent first. It returns ErrNotIndexed if that
+	// range isn't covered by the index.
+	ListMessages(ctx context.Context, addr address.Address, dir AddrMsgDirection, ts *types.TipSet, toHeight abi.ChainEpoch) ([]cid.Cid, error)
+	// Close closes the index
+	Close() error
+}
+
+type dummyAddrIndex struct{}
+
+func (dummyAddrIndex) ListMessages(context.Context, address.Address, AddrMsgDirection, *types.TipSet, abi.ChainEpoch) ([]cid.Cid, error) {
+	return nil, ErrNotIndexed
+}
+
+func (dummyAddrIndex) Close() error {
+	return nil
+}
+
+var DummyAddrIndex AddrIndex = dummyAddrIndex{}
@@ -37,18 +37,44 @@ var dbDefs = []string{
 	)`,
 	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
 }
+
+// dbUpgrades are applied in order to databases whose version is below the version they upgrade to.
+var dbUpgrades = []struct {
+	version int64
+	stmts   []string
+}{{
+	// version 2 records the position of each message in its tipset, which is also the index of
+	// its receipt in the execution tipset. Messages indexed before the upgrade have -1.
+	version: 2,
+	stmts: []string{
+		`ALTER TABLE messages ADD COLUMN receipt_index INTEGER NOT NULL DEFAULT -1`,
+	},
+}, {
+	// version 3 records the block which included each message and the tipset which executed it.
+	// Messages indexed before the upgrade have no block, and their execution tipset is only
+	// recorded once the tipset executing them is applied.
+	version: 3,
+	stmts: []string{
+		`ALTER TABLE messages ADD COLUMN block_cid VARCHAR(80) NOT NULL DEFAULT ''`,
+		`ALTER TABLE messages ADD COLUMN exec_tipset BLOB`,
+	},
+}}
 var dbPragmas = []string{}
 
 const (
 	// prepared stmts
-	dbqGetMessageInfo       = "SELECT tipset_cid, epoch FROM messages WHERE cid = ?"
-	dbqInsertMessage        = "INSERT INTO messages VALUES (?, ?, ?)"
+	dbqGetMessageInfo       = "SELECT tipset_cid, epoch, receipt_index, block_cid, exec_tipset FROM messages WHERE cid = ?"
+	dbqInsertMessage        = "INSERT INTO messages (cid, tipset_cid, epoch, receipt_index, block_cid, exec_tipset) VALUES (?, ?, ?, ?, ?, ?)"
 	dbqDeleteTipsetMessages = "DELETE FROM messages WHERE tipset_cid = ?"
+	dbqSetExecTipset        = "UPDATE messages SET exec_tipset = ? WHERE tipset_cid = ?"
 	// reconciliation
 	dbqCountMessages         = "SELECT COUNT(*) FROM messages"
 	dbqMinEpoch              = "SELECT MIN(epoch) FROM messages"
 	dbqCountTipsetMessages   = "SELECT COUNT(*) FROM messages WHERE tipset_cid = ?"
 	dbqDeleteMessagesByEpoch = "DELETE FROM messages WHERE epoch >= ?"
+	// upgrades
+	dbqVersion       = "SELECT MAX(version) FROM _meta"
+	dbqInsertVersion = "INSERT OR IGNORE INTO _meta (version) VALUES (?)"
 )
 
 // coalescer configuration (TODO: use observer instead)
@@ -64,6 +90,7 @@ var (
 type ChainStore interface {
 	SubscribeHeadChanges(f store.ReorgNotifee)
 	MessagesForTipset(ctx context.Context, ts *types.TipSet) ([]types.ChainMsg, error)
+	BlockMsgsForTipset(ctx context.Context, ts *types.TipSet) ([]store.BlockMessages, error)
 	GetHeaviestTipSet() *types.TipSet
 	GetTipSetFromKey(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error)
 }
@@ -77,6 +104,7 @@ type msgIndex struct {
 	selectMsgStmt    *sql.Stmt
 	insertMsgStmt    *sql.Stmt
 	deleteTipSetStmt *sql.Stmt
+	setExecStmt      *sql.Stmt
 
 	sema chan struct{}
 	mx   sync.Mutex
@@ -217,6 +245,8 @@ func PopulateAfterSnapshot(lctx context.Context, basePath string, cs ChainStore)
 
 	curTs := cs.GetHeaviestTipSet()
 	startHeight := curTs.Height()
+	// the tipset executing the messages of curTs; the head's messages aren't executed yet
+	var execTs []byte
 	for curTs != nil {
 		tscid, err := curTs.Key().Cid()
 		if err != nil {
@@ -227,20 +257,20 @@ func PopulateAfterSnapshot(lctx context.Context, basePath string, cs ChainStore)
 		tskey := tscid.String()
 		epoch := int64(curTs.Height())
 
-		msgs, err := cs.MessagesForTipset(lctx, curTs)
+		msgs, err := tipsetIndexedMessages(lctx, cs, curTs)
 		if err != nil {
 			log.Infof("stopping import after %d tipsets", startHeight-curTs.Height())
 			break
 		}
 
-		for _, msg := range msgs {
-			key := msg.Cid().String()
-			if _, err := insertStmt.Exec(key, tskey, epoch); err != nil {
+		for i, msg := range msgs {
+			if _, err := insertStmt.Exec(msg.cid.String(), tskey, epoch, i, msg.block.String(), execTs); err != nil {
 				rollback()
 				return xerrors.Errorf("error inserting message: %w", err)
 			}
 		}
 
+		execTs = curTs.Key().Bytes()
 		curTs, err = cs.GetTipSetFromKey(lctx, curTs.Parents())
 		if err != nil {
 			rollback()
@@ -256,6 +286,32 @@ func PopulateAfterSnapshot(lctx context.Context, basePath string, cs ChainStore)
 	return nil
 }
 
+type indexedMessage struct {
+	cid   cid.Cid
+	block cid.Cid
+}
+
+// tipsetIndexedMessages returns the messages of ts in execution order, along with the first
+// block including each of them.
+func tipsetIndexedMessages(ctx context.Context, cs ChainStore, ts *types.TipSet) ([]indexedMessage, error) {
+	bmsgs, err := cs.BlockMsgsForTipset(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []indexedMessage
+	for i, bm := range bmsgs {
+		blk := ts.Blocks()[i].Cid()
+		for _, m := range bm.BlsMessages {
+			out = append(out, indexedMessage{cid: m.Cid(), block: blk})
+		}
+		for _, m := range bm.SecpkMessages {
+			out = append(out, indexedMessage{cid: m.Cid(), block: blk})
+		}
+	}
+	return out, nil
+}
+
 // init utilities
 func prepareDB(db *sql.DB) error {
 	for _, stmt := range dbDefs {
@@ -270,6 +326,25 @@ func prepareDB(db *sql.DB) error {
 		}
 	}
 
+	var version int64
+	if err := db.QueryRow(dbqVersion).Scan(&version); err != nil {
+		return xerrors.Errorf("error reading msgindex version: %w", err)
+	}
+	for _, up := range dbUpgrades {
+		if version >= up.version {
+			continue
+		}
+		for _, stmt := range up.stmts {
+			if _, err := db.Exec(stmt); err != nil {
+				return xerrors.Errorf("error upgrading msgindex to version %d: executing sql statement '%s': %w", up.version, stmt, err)
+			}
+		}
+		if _, err := db.Exec(dbqInsertVersion, up.version); err != nil {
+			return xerrors.Errorf("error recording msgindex version %d: %w", up.version, err)
+		}
+		version = up.version
+	}
+
 	return nil
 }
 
@@ -362,6 +437,12 @@ func (x *msgIndex) prepareStatements() error {
 	}
 	x.deleteTipSetStmt = stmt
 
+	stmt, err = x.db.Prepare(dbqSetExecTipset)
+	if err != nil {
+		return xerrors.Errorf("prepare setExecStmt: %w", err)
+	}
+	x.setExecStmt = stmt
+
 	return nil
 }
 
@@ -455,7 +536,16 @@ func (x *msgIndex) doRevert(ctx context.Context, tx *sql.Tx, ts *types.TipSet) e
 	}
 
 	key := tskey.String()
-	_, err = tx.Stmt(x.deleteTipSetStmt).Exec(key)
+	if _, err := tx.Stmt(x.deleteTipSetStmt).Exec(key); err != nil {
+		return err
+	}
+
+	// the messages of the parent are no longer executed
+	parent, err := ts.Parents().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+	_, err = tx.Stmt(x.setExecStmt).Exec(nil, parent.String())
 	return err
 }
 
@@ -468,19 +558,27 @@ func (x *msgIndex) doApply(ctx context.Context, tx *sql.Tx, ts *types.TipSet) er
 	tskey := tscid.String()
 	epoch := int64(ts.Height())
 
-	msgs, err := x.cs.MessagesForTipset(ctx, ts)
+	msgs, err := tipsetIndexedMessages(ctx, x.cs, ts)
 	if err != nil {
 		return xerrors.Errorf("error retrieving messages for tipset %s: %w", ts, err)
 	}
 
 	insertStmt := tx.Stmt(x.insertMsgStmt)
-	for _, msg := range msgs {
-		key := msg.Cid().String()
-		if _, err := insertStmt.Exec(key, tskey, epoch); err != nil {
+	for i, msg := range msgs {
+		if _, err := insertStmt.Exec(msg.cid.String(), tskey, epoch, i, msg.block.String(), nil); err != nil {
 			return xerrors.Errorf("error inserting message: %w", err)
 		}
 	}
 
+	// ts executes the messages of its parent
+	parent, err := ts.Parents().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+	if _, err := tx.Stmt(x.setExecStmt).Exec(ts.Key().Bytes(), parent.String()); err != nil {
+		return xerrors.Errorf("error recording execution tipset: %w", err)
+	}
+
 	return nil
 }
 
@@ -494,13 +592,16 @@ func (x *msgIndex) GetMsgInfo(ctx context.Context, m cid.Cid) (MsgInfo, error) {
 	}
 
 	var (
-		tipset string
-		epoch  int64
+		tipset       string
+		epoch        int64
+		receiptIndex int64
+		block        string
+		execTipset   []byte
 	)
 
 	key := m.String()
 	row := x.selectMsgStmt.QueryRow(key)
-	err := row.Scan(&tipset, &epoch)
+	err := row.Scan(&tipset, &epoch, &receiptIndex, &block, &execTipset)
 	switch {
 	case err == sql.ErrNoRows:
 		return MsgInfo{}, ErrNotFound
@@ -514,10 +615,29 @@ func (x *msgIndex) GetMsgInfo(ctx context.Context, m cid.Cid) (MsgInfo, error) {
 		return MsgInfo{}, xerrors.Errorf("error decoding tipset cid: %w", err)
 	}
 
+	blockCid := cid.Undef
+	if block != "" {
+		blockCid, err = cid.Decode(block)
+		if err != nil {
+			return MsgInfo{}, xerrors.Errorf("error decoding block cid: %w", err)
+		}
+	}
+
+	execTsk := types.EmptyTSK
+	if len(execTipset) > 0 {
+		execTsk, err = types.TipSetKeyFromBytes(execTipset)
+		if err != nil {
+			return MsgInfo{}, xerrors.Errorf("error decoding execution tipset key: %w", err)
+		}
+	}
+
 	return MsgInfo{
-		Message: m,
-		TipSet:  tipsetCid,
-		Epoch:   abi.ChainEpoch(epoch),
+		Message:         m,
+		TipSet:          tipsetCid,
+		Epoch:           abi.ChainEpoch(epoch),
+		ReceiptIndex:    receiptIndex,
+		Block:           blockCid,
+		ExecutionTipSet: execTsk,
 	}, nil
 }
 
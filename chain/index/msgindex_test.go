@@ -2,9 +2,11 @@ package index
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"math/rand"
 	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -90,6 +92,41 @@ func TestReorgMsgIndex(t *testing.T) {
 	verifyMissing(t, cs, msgIndex, reorgme)
 }
 
+func TestUpgradeMsgIndex(t *testing.T) {
+	// a database created before receipt indexes were recorded is upgraded in place
+	cs := newMockChainStore()
+	cs.genesis()
+	require.NoError(t, cs.advance())
+
+	tmp := t.TempDir()
+
+	db, err := sql.Open("sqlite3", path.Join(tmp, dbName))
+	require.NoError(t, err)
+	for _, stmt := range dbDefs {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	msgs, err := cs.MessagesForTipset(context.Background(), cs.curTs)
+	require.NoError(t, err)
+	tsCid, err := cs.curTs.Key().Cid()
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO messages VALUES (?, ?, ?)", msgs[0].Cid().String(), tsCid.String(), int64(cs.curTs.Height()))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	msgIndex, err := NewMsgIndex(context.Background(), tmp, cs)
+	require.NoError(t, err)
+
+	defer msgIndex.Close() //nolint
+
+	minfo, err := msgIndex.GetMsgInfo(context.Background(), msgs[0].Cid())
+	require.NoError(t, err)
+	require.Equal(t, tsCid, minfo.TipSet)
+	require.Equal(t, int64(-1), minfo.ReceiptIndex)
+	require.Equal(t, cid.Undef, minfo.Block)
+}
+
 func TestReconcileMsgIndex(t *testing.T) {
 	// test that exercises the reconciliation code paths
 	// 1. Create  and populate a basic msgindex, similar to TestBasicMsgIndex.
@@ -145,6 +182,7 @@ func TestReconcileMsgIndex(t *testing.T) {
 }
 
 func verifyIndex(t *testing.T, cs *mockChainStore, msgIndex MsgIndex) {
+	execTsk := types.EmptyTSK
 	for ts := cs.curTs; ts.Height() > 0; {
 		t.Logf("verify at height %d", ts.Height())
 		blks := ts.Blocks()
@@ -157,13 +195,17 @@ func verifyIndex(t *testing.T, cs *mockChainStore, msgIndex MsgIndex) {
 
 		msgs, err := cs.MessagesForTipset(context.Background(), ts)
 		require.NoError(t, err)
-		for _, m := range msgs {
+		for i, m := range msgs {
 			minfo, err := msgIndex.GetMsgInfo(context.Background(), m.Cid())
 			require.NoError(t, err)
 			require.Equal(t, tsCid, minfo.TipSet)
 			require.Equal(t, ts.Height(), minfo.Epoch)
+			require.Equal(t, int64(i), minfo.ReceiptIndex)
+			require.Equal(t, blks[0].Cid(), minfo.Block)
+			require.Equal(t, execTsk, minfo.ExecutionTipSet)
 		}
 
+		execTsk = ts.Key()
 		parents := ts.Parents()
 		ts, err = cs.GetTipSetFromKey(context.Background(), parents)
 		require.NoError(t, err)
@@ -286,6 +328,15 @@ func (cs *mockChainStore) MessagesForTipset(ctx context.Context, ts *types.TipSe
 	return msgs, nil
 }
 
+func (cs *mockChainStore) BlockMsgsForTipset(ctx context.Context, ts *types.TipSet) ([]store.BlockMessages, error) {
+	msgs, err := cs.MessagesForTipset(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []store.BlockMessages{{BlsMessages: msgs}}, nil
+}
+
 func (cs *mockChainStore) GetHeaviestTipSet() *types.TipSet {
 	return cs.curTs
 }
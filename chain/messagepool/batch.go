@@ -0,0 +1,182 @@
+package messagepool
+
+import (
+	"context"
+	"fmt"
+	stdbig "math/big"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// BatchError is the error of a batch of messages refused because of one of its messages.
+type BatchError struct {
+	// Index is the index of the refused message in the batch.
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("message %d of the batch: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// PushBatch is like Push and PushUntrusted for a batch of messages, but adds either all of them
+// or none: the messages are all validated, in order, against the pending messages and the ones
+// before them in the batch before any is added. When a message is refused, the error is a
+// *BatchError with its index.
+func (mp *MessagePool) PushBatch(ctx context.Context, msgs []*types.SignedMessage, untrusted, publish bool) ([]cid.Cid, error) {
+	for i, m := range msgs {
+		if err := mp.checkMessage(ctx, m); err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+	}
+
+	// serialize push access to reduce lock contention
+	mp.addSema <- struct{}{}
+	defer func() {
+		<-mp.addSema
+	}()
+
+	mp.curTsLk.Lock()
+	toPublish, err := mp.addBatch(ctx, msgs, mp.curTs, untrusted)
+	mp.curTsLk.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	cids := make([]cid.Cid, 0, len(msgs))
+	for i, m := range msgs {
+		cids = append(cids, m.Cid())
+
+		if !publish || !toPublish[i] {
+			continue
+		}
+
+		msgb, err := m.Serialize()
+		if err != nil {
+			return nil, xerrors.Errorf("error serializing message %d: %w", i, err)
+		}
+
+		if err := mp.api.PubSubPublish(build.MessagesTopic(mp.netName), msgb); err != nil {
+			return nil, xerrors.Errorf("error publishing message %d: %w", i, err)
+		}
+	}
+
+	return cids, nil
+}
+
+// addBatch adds a batch of local messages, or none of them, and returns which ones should be
+// published.
+func (mp *MessagePool) addBatch(ctx context.Context, msgs []*types.SignedMessage, curTs *types.TipSet, untrusted bool) ([]bool, error) {
+	for i, m := range msgs {
+		if err := mp.checkSender(ctx, m, curTs); err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+	}
+
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	// validate the batch against copies of the pending messages of its senders
+	publish := make([]bool, len(msgs))
+	scratch := make(map[address.Address]*msgSet)
+	for i, m := range msgs {
+		pub, err := mp.verifyMsgBeforeAdd(ctx, m, curTs, true)
+		if err != nil {
+			return nil, &BatchError{Index: i, Err: xerrors.Errorf("verify msg failed: %w", err)}
+		}
+		publish[i] = pub
+
+		from, err := mp.resolveToKey(ctx, m.Message.From)
+		if err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+
+		mset, ok := scratch[from]
+		if !ok {
+			pending, ok, err := mp.getPendingMset(ctx, from)
+			if err != nil {
+				return nil, &BatchError{Index: i, Err: err}
+			}
+			if ok {
+				mset = pending.clone()
+			} else {
+				nonce, err := mp.getStateNonce(ctx, from, curTs)
+				if err != nil {
+					return nil, &BatchError{Index: i, Err: xerrors.Errorf("failed to get initial actor nonce: %w", err)}
+				}
+				mset = newMsgSet(nonce)
+			}
+			scratch[from] = mset
+		}
+
+		if err := mp.checkBalanceWith(ctx, m, curTs, mset); err != nil {
+			return nil, &BatchError{Index: i, Err: xerrors.Errorf("failed to check balance: %w", err)}
+		}
+
+		if _, err := mset.add(m, mp, false, untrusted, mp.isPrioritySender(ctx, from)); err != nil {
+			return nil, &BatchError{Index: i, Err: xerrors.Errorf("failed to add locked: %w", err)}
+		}
+	}
+
+	// the batch is valid, add it; the adds can only fail on storage errors, in which case the
+	// messages already added are removed, restoring the messages they replaced
+	var added, replaced []*types.SignedMessage
+	rollback := func() {
+		for j := len(added) - 1; j >= 0; j-- {
+			m := added[j]
+			mp.remove(ctx, m.Message.From, m.Message.Nonce, false)
+			if err := mp.localMsgs.Delete(ctx, datastore.NewKey(string(m.Cid().Bytes()))); err != nil {
+				log.Warnf("error deleting local message of a failed batch: %s", err)
+			}
+			if replaced[j] != nil {
+				if err := mp.addLocked(ctx, replaced[j], false, false); err != nil {
+					log.Errorf("error restoring message replaced by a failed batch: %s", err)
+				}
+			}
+		}
+	}
+
+	for i, m := range msgs {
+		var exms *types.SignedMessage
+		if mset, ok, err := mp.getPendingMset(ctx, m.Message.From); err == nil && ok {
+			exms = mset.msgs[m.Message.Nonce]
+		}
+
+		if err := mp.addLocked(ctx, m, false, untrusted); err != nil {
+			rollback()
+			return nil, &BatchError{Index: i, Err: xerrors.Errorf("failed to add locked: %w", err)}
+		}
+		added = append(added, m)
+		replaced = append(replaced, exms)
+
+		if err := mp.addLocal(ctx, m); err != nil {
+			rollback()
+			return nil, &BatchError{Index: i, Err: xerrors.Errorf("error persisting local message: %w", err)}
+		}
+	}
+
+	return publish, nil
+}
+
+func (ms *msgSet) clone() *msgSet {
+	c := &msgSet{
+		msgs:          make(map[uint64]*types.SignedMessage, len(ms.msgs)),
+		nextNonce:     ms.nextNonce,
+		requiredFunds: new(stdbig.Int).Set(ms.requiredFunds),
+	}
+	for n, m := range ms.msgs {
+		c.msgs[n] = m
+	}
+	return c
+}
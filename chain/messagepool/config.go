@@ -40,7 +40,8 @@ func loadConfig(ctx context.Context, ds dtypes.MetadataDS) (*types.MpoolConfig,
 	if err != nil {
 		return nil, err
 	}
-	cfg := new(types.MpoolConfig)
+	// fields missing from configs saved by older versions keep their default
+	cfg := DefaultConfig()
 	err = json.Unmarshal(cfgBytes, cfg)
 	return cfg, err
 }
@@ -98,5 +99,6 @@ func DefaultConfig() *types.MpoolConfig {
 		ReplaceByFeeRatio:      ReplaceByFeePercentageDefault,
 		PruneCooldown:          PruneCooldownDefault,
 		GasLimitOverestimation: GasLimitOverestimation,
+		PersistLocalMessages:   true,
 	}
 }
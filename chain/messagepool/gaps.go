@@ -0,0 +1,56 @@
+package messagepool
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// NonceGaps returns the state nonce of a sender in the current tipset of the message pool, and
+// the gaps between it and the pending messages of the sender.
+func (mp *MessagePool) NonceGaps(ctx context.Context, addr address.Address) (uint64, []api.NonceGap, error) {
+	mp.curTsLk.RLock()
+	defer mp.curTsLk.RUnlock()
+
+	snonce, err := mp.getStateNonce(ctx, addr, mp.curTs)
+	if err != nil {
+		return 0, nil, xerrors.Errorf("getting state nonce: %w", err)
+	}
+
+	mp.lk.RLock()
+	defer mp.lk.RUnlock()
+
+	mset, ok, err := mp.getPendingMset(ctx, addr)
+	if err != nil {
+		return 0, nil, xerrors.Errorf("getting pending messages: %w", err)
+	}
+	if !ok {
+		return snonce, nil, nil
+	}
+
+	nonces := make([]uint64, 0, len(mset.msgs))
+	for n := range mset.msgs {
+		if n >= snonce {
+			nonces = append(nonces, n)
+		}
+	}
+	sort.Slice(nonces, func(i, j int) bool {
+		return nonces[i] < nonces[j]
+	})
+
+	var gaps []api.NonceGap
+	next := snonce
+	for _, n := range nonces {
+		if n > next {
+			gaps = append(gaps, api.NonceGap{Start: next, End: n})
+		}
+		next = n + 1
+	}
+
+	return snonce, gaps, nil
+}
@@ -0,0 +1,172 @@
+package messagepool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const localHistoryDs = "/mpool/history"
+
+// SetLocalHistory enables or disables the recording of the messages pushed by the node.
+func (mp *MessagePool) SetLocalHistory(enabled bool) {
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+
+	mp.localHistory = enabled
+}
+
+func (mp *MessagePool) localHistoryEnabled() bool {
+	mp.cfgLk.RLock()
+	defer mp.cfgLk.RUnlock()
+
+	return mp.localHistory
+}
+
+func localHistoryKey(from address.Address, nonce uint64) datastore.Key {
+	// zero padded so that the records of a sender are listed in nonce order
+	return datastore.NewKey(fmt.Sprintf("%s/%020d", from, nonce))
+}
+
+func (mp *MessagePool) getLocalRecord(ctx context.Context, from address.Address, nonce uint64) (*api.LocalMessageRecord, error) {
+	b, err := mp.localHistoryDs.Get(ctx, localHistoryKey(from, nonce))
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r api.LocalMessageRecord
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, xerrors.Errorf("decoding local message record: %w", err)
+	}
+	return &r, nil
+}
+
+func (mp *MessagePool) putLocalRecord(ctx context.Context, r *api.LocalMessageRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return xerrors.Errorf("encoding local message record: %w", err)
+	}
+	return mp.localHistoryDs.Put(ctx, localHistoryKey(r.From, r.Nonce), b)
+}
+
+// recordLocal adds a message pushed by the node to the history of its nonce.
+func (mp *MessagePool) recordLocal(ctx context.Context, m *types.SignedMessage) error {
+	if !mp.localHistoryEnabled() {
+		return nil
+	}
+
+	from, err := mp.resolveToKey(ctx, m.Message.From)
+	if err != nil {
+		return err
+	}
+
+	mp.localHistoryLk.Lock()
+	defer mp.localHistoryLk.Unlock()
+
+	r, err := mp.getLocalRecord(ctx, from, m.Message.Nonce)
+	if err != nil {
+		return xerrors.Errorf("getting local message record: %w", err)
+	}
+	if r == nil || r.Outcome != nil {
+		// a new nonce, or a nonce used again after a reorg or a state reset; start over
+		r = &api.LocalMessageRecord{From: from, Nonce: m.Message.Nonce}
+	}
+
+	c := m.Cid()
+	for _, a := range r.Attempts {
+		if a.Cid == c {
+			return nil // pushed again
+		}
+	}
+	r.Attempts = append(r.Attempts, api.LocalMessageAttempt{
+		Cid:     c,
+		Message: m,
+		Pushed:  build.Clock.Now(),
+	})
+
+	return mp.putLocalRecord(ctx, r)
+}
+
+// LocalHistory returns the history of the messages pushed by the node from a sender, in nonce
+// order.
+func (mp *MessagePool) LocalHistory(ctx context.Context, addr address.Address) ([]*api.LocalMessageRecord, error) {
+	from, err := mp.resolveToKey(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return mp.queryLocalHistory(ctx, query.Query{Prefix: "/" + from.String()}, nil)
+}
+
+// UnresolvedLocalHistory returns the records of the local message history whose outcome isn't
+// known yet.
+func (mp *MessagePool) UnresolvedLocalHistory(ctx context.Context) ([]*api.LocalMessageRecord, error) {
+	return mp.queryLocalHistory(ctx, query.Query{}, func(r *api.LocalMessageRecord) bool {
+		return r.Outcome == nil
+	})
+}
+
+func (mp *MessagePool) queryLocalHistory(ctx context.Context, q query.Query, filter func(*api.LocalMessageRecord) bool) ([]*api.LocalMessageRecord, error) {
+	mp.localHistoryLk.Lock()
+	defer mp.localHistoryLk.Unlock()
+
+	res, err := mp.localHistoryDs.Query(ctx, q)
+	if err != nil {
+		return nil, xerrors.Errorf("querying local message history: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []*api.LocalMessageRecord
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("querying local message history: %w", r.Error)
+		}
+
+		var rec api.LocalMessageRecord
+		if err := json.Unmarshal(r.Value, &rec); err != nil {
+			return nil, xerrors.Errorf("decoding local message record %s: %w", r.Key, err)
+		}
+		if filter == nil || filter(&rec) {
+			out = append(out, &rec)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From.String() < out[j].From.String()
+		}
+		return out[i].Nonce < out[j].Nonce
+	})
+	return out, nil
+}
+
+// SetLocalOutcome records the outcome on chain of a nonce of a local sender.
+func (mp *MessagePool) SetLocalOutcome(ctx context.Context, from address.Address, nonce uint64, o api.LocalMessageOutcome) error {
+	mp.localHistoryLk.Lock()
+	defer mp.localHistoryLk.Unlock()
+
+	r, err := mp.getLocalRecord(ctx, from, nonce)
+	if err != nil {
+		return xerrors.Errorf("getting local message record: %w", err)
+	}
+	if r == nil {
+		return xerrors.Errorf("no local message record for %s nonce %d", from, nonce)
+	}
+
+	r.Outcome = &o
+	return mp.putLocalRecord(ctx, r)
+}
@@ -0,0 +1,77 @@
+package messagepool
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// Bounds of the pending message limits.
+var (
+	MaxActorPendingMessagesLimit = 100_000
+	MaxNonceGapLimit             = uint64(100)
+)
+
+// PendingLimits are the limits on the pending messages of a sender.
+type PendingLimits struct {
+	// MaxActorPending is the maximum number of pending messages of a sender.
+	MaxActorPending int
+	// MaxUntrustedActorPending is the maximum number of pending messages of a sender received
+	// from the network.
+	MaxUntrustedActorPending int
+	// MaxNonceGap is the maximum gap between the nonce of a message and the next nonce of its
+	// sender; messages received from the network can't have any gap.
+	MaxNonceGap uint64
+}
+
+// DefaultPendingLimits returns the default limits on the pending messages of a sender.
+func DefaultPendingLimits() PendingLimits {
+	return PendingLimits{
+		MaxActorPending:          MaxActorPendingMessages,
+		MaxUntrustedActorPending: MaxUntrustedActorPendingMessages,
+		MaxNonceGap:              MaxNonceGap,
+	}
+}
+
+func (l PendingLimits) validate() error {
+	if l.MaxActorPending < 1 || l.MaxActorPending > MaxActorPendingMessagesLimit {
+		return xerrors.Errorf("maximum pending messages of an actor must be between 1 and %d, got %d", MaxActorPendingMessagesLimit, l.MaxActorPending)
+	}
+	if l.MaxUntrustedActorPending < 1 || l.MaxUntrustedActorPending > l.MaxActorPending {
+		return xerrors.Errorf("maximum untrusted pending messages of an actor must be between 1 and %d, got %d", l.MaxActorPending, l.MaxUntrustedActorPending)
+	}
+	if l.MaxNonceGap > MaxNonceGapLimit {
+		return xerrors.Errorf("maximum nonce gap must be at most %d, got %d", MaxNonceGapLimit, l.MaxNonceGap)
+	}
+	return nil
+}
+
+// SetPendingLimits sets the limits on the pending messages of a sender.
+func (mp *MessagePool) SetPendingLimits(l PendingLimits) error {
+	if err := l.validate(); err != nil {
+		return err
+	}
+
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+
+	mp.pendingLimits = l
+	return nil
+}
+
+// PendingLimits returns the limits on the pending messages of a sender.
+func (mp *MessagePool) PendingLimits() PendingLimits {
+	mp.cfgLk.RLock()
+	defer mp.cfgLk.RUnlock()
+
+	return mp.pendingLimits
+}
+
+func recordLimitRejected(ctx context.Context, limit string) {
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.FailureType, limit))
+	stats.Record(ctx, metrics.MpoolLimitRejected.M(1))
+}
@@ -0,0 +1,218 @@
+package messagepool
+
+import (
+	"container/heap"
+	"context"
+
+	"go.opencensus.io/stats"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// msgMemoryOverhead approximates the memory held for a pending message on top of its serialized
+// size: its cid, its signature and the map entries indexing it.
+const msgMemoryOverhead = 512
+
+// memoryLowWatermark is the fraction of the memory budget evictions bring the pending messages
+// down to, so that a full message pool doesn't evict on every new message.
+const memoryLowWatermark = 0.9
+
+func msgMemory(m *types.SignedMessage) int64 {
+	return int64(m.ChainLength()) + msgMemoryOverhead
+}
+
+// SetMemoryBudget sets the estimated memory the pending messages may hold, in bytes; 0 disables
+// the budget. When a message would exceed it, pending messages are evicted in this order until
+// they are back under 90% of the budget:
+//
+//   - only the last pending message of a sender can be evicted, so that evictions never open
+//     nonce gaps;
+//   - among those, the message with the lowest gas premium is evicted first;
+//   - between messages with the same premium, the one furthest ahead of the state nonce of its
+//     sender is evicted first.
+//
+// The messages of local and priority senders are never evicted, and the pending messages of a
+// sender aren't evicted to make room for its own messages. A message which would itself be
+// evicted first is refused with ErrMemoryBudgetExceeded instead. Lowering the budget takes effect
+// on the next message added.
+func (mp *MessagePool) SetMemoryBudget(budget int64) error {
+	if budget < 0 {
+		return xerrors.Errorf("memory budget must not be negative, got %d", budget)
+	}
+
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+
+	mp.memoryBudget = budget
+	return nil
+}
+
+// MemoryBudget returns the memory budget of the pending messages, in bytes, or 0 when disabled.
+func (mp *MessagePool) MemoryBudget() int64 {
+	mp.cfgLk.RLock()
+	defer mp.cfgLk.RUnlock()
+
+	return mp.memoryBudget
+}
+
+// MemoryUsage returns the estimated memory held by the pending messages, in bytes.
+func (mp *MessagePool) MemoryUsage() int64 {
+	mp.lk.RLock()
+	defer mp.lk.RUnlock()
+
+	return mp.memory
+}
+
+type evictionCandidate struct {
+	from address.Address
+	msg  *types.SignedMessage
+	// how far ahead of the state nonce of its sender the message is
+	ahead uint64
+}
+
+// evictsBefore returns whether a is evicted before b.
+func (a *evictionCandidate) evictsBefore(b *evictionCandidate) bool {
+	if c := types.BigCmp(a.msg.Message.GasPremium, b.msg.Message.GasPremium); c != 0 {
+		return c < 0
+	}
+	return a.ahead > b.ahead
+}
+
+type evictionQueue []*evictionCandidate
+
+func (q evictionQueue) Len() int           { return len(q) }
+func (q evictionQueue) Less(i, j int) bool { return q[i].evictsBefore(q[j]) }
+func (q evictionQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *evictionQueue) Push(x interface{}) {
+	*q = append(*q, x.(*evictionCandidate))
+}
+
+func (q *evictionQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// lastPending returns the pending message of a sender with the highest nonce.
+func lastPending(ms *msgSet) *types.SignedMessage {
+	var last *types.SignedMessage
+	for _, m := range ms.msgs {
+		if last == nil || m.Message.Nonce > last.Message.Nonce {
+			last = m
+		}
+	}
+	return last
+}
+
+func (mp *MessagePool) evictionCandidate(ctx context.Context, from address.Address, m *types.SignedMessage) *evictionCandidate {
+	c := &evictionCandidate{from: from, msg: m}
+	if snonce, err := mp.getStateNonce(ctx, from, mp.curTs); err == nil && m.Message.Nonce > snonce {
+		c.ahead = m.Message.Nonce - snonce
+	}
+	return c
+}
+
+// makeRoomLocked evicts pending messages until m, about to be added to the pending messages
+// mset of its sender, fits in the memory budget, following the order documented on
+// SetMemoryBudget. mp.lk must be held.
+func (mp *MessagePool) makeRoomLocked(ctx context.Context, m *types.SignedMessage, mset *msgSet) error {
+	budget := mp.MemoryBudget()
+	if budget == 0 {
+		return nil
+	}
+
+	need := msgMemory(m)
+	if exms, ok := mset.msgs[m.Message.Nonce]; ok {
+		need -= msgMemory(exms)
+	}
+	if mp.memory+need <= budget {
+		return nil
+	}
+
+	from, err := mp.resolveToKey(ctx, m.Message.From)
+	if err != nil {
+		return err
+	}
+
+	protected := make(map[address.Address]struct{})
+	for _, actor := range mp.priorityAddrs() {
+		pk, err := mp.resolveToKey(ctx, actor)
+		if err != nil {
+			log.Debugf("makeRoomLocked failed to resolve priority address: %s", err)
+			continue
+		}
+		protected[pk] = struct{}{}
+	}
+	mp.forEachLocal(ctx, func(ctx context.Context, actor address.Address) {
+		protected[actor] = struct{}{}
+	})
+
+	_, isProtected := protected[from]
+
+	// the incoming message competes with the other candidates when it would be the last pending
+	// message of its sender
+	var incoming *evictionCandidate
+	if last := lastPending(mset); !isProtected && (last == nil || m.Message.Nonce >= last.Message.Nonce) {
+		incoming = mp.evictionCandidate(ctx, from, m)
+	}
+
+	var q evictionQueue
+	mp.forEachPending(func(a address.Address, ms *msgSet) {
+		if _, ok := protected[a]; ok || a == from {
+			return
+		}
+		if last := lastPending(ms); last != nil {
+			q = append(q, mp.evictionCandidate(ctx, a, last))
+		}
+	})
+	heap.Init(&q)
+
+	target := int64(float64(budget) * memoryLowWatermark)
+	for mp.memory+need > target && q.Len() > 0 {
+		if incoming != nil && !q[0].evictsBefore(incoming) {
+			break
+		}
+
+		c := heap.Pop(&q).(*evictionCandidate)
+		mp.remove(ctx, c.from, c.msg.Message.Nonce, false)
+		recordEviction(ctx, msgMemory(c.msg))
+
+		if ms, ok, err := mp.getPendingMset(ctx, c.from); err == nil && ok {
+			if last := lastPending(ms); last != nil {
+				heap.Push(&q, mp.evictionCandidate(ctx, c.from, last))
+			}
+		}
+	}
+
+	if mp.memory+need > budget && !isProtected {
+		recordLimitRejected(ctx, "memory_budget")
+		return ErrMemoryBudgetExceeded
+	}
+	return nil
+}
+
+// recountMemoryLocked recomputes the memory held by the pending messages after they were changed
+// wholesale. mp.lk must be held.
+func (mp *MessagePool) recountMemoryLocked(ctx context.Context) {
+	mp.memory = 0
+	mp.forEachPending(func(_ address.Address, ms *msgSet) {
+		for _, m := range ms.msgs {
+			mp.memory += msgMemory(m)
+		}
+	})
+	recordMemory(ctx, mp.memory)
+}
+
+func recordEviction(ctx context.Context, size int64) {
+	stats.Record(ctx, metrics.MpoolEvicted.M(1), metrics.MpoolEvictedBytes.M(size))
+}
+
+func recordMemory(ctx context.Context, memory int64) {
+	stats.Record(ctx, metrics.MpoolMemory.M(memory))
+}
@@ -81,6 +81,8 @@ var (
 	ErrTooManyPendingMessages = errors.New("too many pending messages for actor")
 	ErrNonceGap               = errors.New("unfulfilled nonce gap")
 	ErrExistingNonce          = errors.New("message with nonce already exists")
+	ErrMemoryBudgetExceeded   = errors.New("message pool memory budget exceeded")
+	ErrScheduledNotFound      = errors.New("message not scheduled")
 )
 
 const (
@@ -145,6 +147,21 @@ type MessagePool struct {
 	cfgLk sync.RWMutex
 	cfg   *types.MpoolConfig
 
+	// replace-by-fee policy, and its overrides by sender; guarded by cfgLk
+	rbfPolicy    RBFPolicy
+	rbfOverrides map[address.Address]RBFPolicy
+
+	// senders exempt from the pending message caps, and selected first; guarded by cfgLk
+	prioritySenders []address.Address
+	// guarded by cfgLk
+	pendingLimits PendingLimits
+	// strategy selecting the messages of the blocks, DefaultSelector when nil; guarded by cfgLk
+	selector Selector
+	// whether the messages pushed by the node are recorded in the local history; guarded by cfgLk
+	localHistory bool
+	// estimated memory the pending messages may hold, disabled when 0; guarded by cfgLk
+	memoryBudget int64
+
 	api Provider
 
 	minGasPrice types.BigInt
@@ -152,6 +169,8 @@ type MessagePool struct {
 	getNtwkVersion func(abi.ChainEpoch) (network.Version, error)
 
 	currentSize int
+	// estimated memory held by the pending messages; guarded by lk
+	memory int64
 
 	// pruneTrigger is a channel used to trigger a mempool pruning
 	pruneTrigger chan struct{}
@@ -165,6 +184,14 @@ type MessagePool struct {
 
 	localMsgs datastore.Datastore
 
+	localHistoryLk sync.Mutex
+	localHistoryDs datastore.Datastore
+
+	scheduledLk   sync.Mutex
+	scheduledMsgs datastore.Datastore
+	// scheduleTrigger is a channel used to trigger pushing the scheduled messages
+	scheduleTrigger chan struct{}
+
 	netName dtypes.NetworkName
 
 	sigValCache *lru.TwoQueueCache[string, struct{}]
@@ -231,15 +258,20 @@ func CapGasFee(mff dtypes.DefaultMaxFeeFunc, msg *types.Message, sendSpec *api.M
 	msg.GasPremium = big.Min(msg.GasFeeCap, msg.GasPremium) // cap premium at FeeCap
 }
 
-func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted bool) (bool, error) {
+func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted, priority bool) (bool, error) {
 	nextNonce := ms.nextNonce
 	nonceGap := false
 
-	maxNonceGap := MaxNonceGap
-	maxActorPendingMessages := MaxActorPendingMessages
+	limits := mp.PendingLimits()
+	maxNonceGap := limits.MaxNonceGap
+	maxActorPendingMessages := limits.MaxActorPending
 	if untrusted {
 		maxNonceGap = 0
-		maxActorPendingMessages = MaxUntrustedActorPendingMessages
+		maxActorPendingMessages = limits.MaxUntrustedActorPending
+	}
+	if priority {
+		// priority senders aren't capped
+		maxActorPendingMessages = math.MaxInt
 	}
 
 	switch {
@@ -266,7 +298,7 @@ func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted
 
 		if m.Cid() != exms.Cid() {
 			// check if RBF passes
-			minPrice := ComputeMinRBF(exms.Message.GasPremium)
+			minPrice := mp.RBFPolicy(m.Message.From).MinPremium(exms.Message.GasPremium)
 			if types.BigCmp(m.Message.GasPremium, minPrice) >= 0 {
 				log.Debugw("add with RBF", "oldpremium", exms.Message.GasPremium,
 					"newpremium", m.Message.GasPremium, "addr", m.Message.From, "nonce", m.Message.Nonce)
@@ -401,9 +433,14 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 		stateNonceCache: stateNonceCache,
 		changes:         lps.New(50),
 		localMsgs:       namespace.Wrap(ds, datastore.NewKey(localMsgsDs)),
+		localHistoryDs:  namespace.Wrap(ds, datastore.NewKey(localHistoryDs)),
+		scheduledMsgs:   namespace.Wrap(ds, datastore.NewKey(scheduledMsgsDs)),
+		scheduleTrigger: make(chan struct{}, 1),
 		api:             api,
 		netName:         netName,
 		cfg:             cfg,
+		rbfPolicy:       DefaultRBFPolicy(),
+		pendingLimits:   DefaultPendingLimits(),
 		evtTypes: [...]journal.EventType{
 			evtTypeMpoolAdd:    j.RegisterEventType("mpool", "add"),
 			evtTypeMpoolRemove: j.RegisterEventType("mpool", "remove"),
@@ -442,6 +479,12 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 
 		log.Info("mpool ready")
 
+		// push the messages scheduled at epochs reached while the node was down
+		select {
+		case mp.scheduleTrigger <- struct{}{}:
+		default:
+		}
+
 		mp.runLoop(ctx)
 	}()
 
@@ -608,6 +651,9 @@ func (mp *MessagePool) runLoop(ctx context.Context) {
 				log.Errorf("failed to prune excess messages from mempool: %s", err)
 			}
 
+		case <-mp.scheduleTrigger:
+			mp.pushScheduled(ctx)
+
 		case <-mp.closer:
 			mp.repubTk.Stop()
 			return
@@ -620,6 +666,14 @@ func (mp *MessagePool) addLocal(ctx context.Context, m *types.SignedMessage) err
 		return err
 	}
 
+	if err := mp.recordLocal(ctx, m); err != nil {
+		return xerrors.Errorf("recording local message history: %w", err)
+	}
+
+	if !mp.getConfig().PersistLocalMessages {
+		return nil
+	}
+
 	msgb, err := m.Serialize()
 	if err != nil {
 		return xerrors.Errorf("error serializing message: %w", err)
@@ -836,6 +890,18 @@ func (mp *MessagePool) VerifyMsgSig(m *types.SignedMessage) error {
 }
 
 func (mp *MessagePool) checkBalance(ctx context.Context, m *types.SignedMessage, curTs *types.TipSet) error {
+	mset, _, err := mp.getPendingMset(ctx, m.Message.From)
+	if err != nil {
+		log.Debugf("mpoolcheckbalance failed to get pending mset: %s", err)
+		return err
+	}
+
+	return mp.checkBalanceWith(ctx, m, curTs, mset)
+}
+
+// checkBalanceWith is like checkBalance, with the pending messages of the sender in mset, which
+// is nil if it has none.
+func (mp *MessagePool) checkBalanceWith(ctx context.Context, m *types.SignedMessage, curTs *types.TipSet, mset *msgSet) error {
 	balance, err := mp.getStateBalance(ctx, m.Message.From, curTs)
 	if err != nil {
 		return xerrors.Errorf("failed to check sender balance: %s: %w", err, ErrSoftValidationFailure)
@@ -849,13 +915,7 @@ func (mp *MessagePool) checkBalance(ctx context.Context, m *types.SignedMessage,
 	// add Value for soft failure check
 	// requiredFunds = types.BigAdd(requiredFunds, m.Message.Value)
 
-	mset, ok, err := mp.getPendingMset(ctx, m.Message.From)
-	if err != nil {
-		log.Debugf("mpoolcheckbalance failed to get pending mset: %s", err)
-		return err
-	}
-
-	if ok {
+	if mset != nil {
 		requiredFunds = types.BigAdd(requiredFunds, mset.getRequiredFunds(m.Message.Nonce))
 	}
 
@@ -872,27 +932,8 @@ func (mp *MessagePool) addTs(ctx context.Context, m *types.SignedMessage, curTs
 	done := metrics.Timer(ctx, metrics.MpoolAddTsDuration)
 	defer done()
 
-	snonce, err := mp.getStateNonce(ctx, m.Message.From, curTs)
-	if err != nil {
-		return false, xerrors.Errorf("failed to look up actor state nonce: %s: %w", err, ErrSoftValidationFailure)
-	}
-
-	if snonce > m.Message.Nonce {
-		return false, xerrors.Errorf("minimum expected nonce is %d: %w", snonce, ErrNonceTooLow)
-	}
-
-	senderAct, err := mp.api.GetActorAfter(m.Message.From, curTs)
-	if err != nil {
-		return false, xerrors.Errorf("failed to get sender actor: %w", err)
-	}
-
-	// This message can only be included in the _next_ epoch and beyond, hence the +1.
-	epoch := curTs.Height() + 1
-	nv := mp.api.StateNetworkVersion(ctx, epoch)
-
-	// TODO: I'm not thrilled about depending on filcns here, but I prefer this to duplicating logic
-	if !consensus.IsValidForSending(nv, senderAct) {
-		return false, xerrors.Errorf("sender actor %s is not a valid top-level sender", m.Message.From)
+	if err := mp.checkSender(ctx, m, curTs); err != nil {
+		return false, err
 	}
 
 	mp.lk.Lock()
@@ -922,6 +963,35 @@ func (mp *MessagePool) addTs(ctx context.Context, m *types.SignedMessage, curTs
 	return publish, nil
 }
 
+// checkSender checks the nonce of a message against the state nonce of its sender, and that the
+// sender can send messages.
+func (mp *MessagePool) checkSender(ctx context.Context, m *types.SignedMessage, curTs *types.TipSet) error {
+	snonce, err := mp.getStateNonce(ctx, m.Message.From, curTs)
+	if err != nil {
+		return xerrors.Errorf("failed to look up actor state nonce: %s: %w", err, ErrSoftValidationFailure)
+	}
+
+	if snonce > m.Message.Nonce {
+		return xerrors.Errorf("minimum expected nonce is %d: %w", snonce, ErrNonceTooLow)
+	}
+
+	senderAct, err := mp.api.GetActorAfter(m.Message.From, curTs)
+	if err != nil {
+		return xerrors.Errorf("failed to get sender actor: %w", err)
+	}
+
+	// This message can only be included in the _next_ epoch and beyond, hence the +1.
+	epoch := curTs.Height() + 1
+	nv := mp.api.StateNetworkVersion(ctx, epoch)
+
+	// TODO: I'm not thrilled about depending on filcns here, but I prefer this to duplicating logic
+	if !consensus.IsValidForSending(nv, senderAct) {
+		return xerrors.Errorf("sender actor %s is not a valid top-level sender", m.Message.From)
+	}
+
+	return nil
+}
+
 func (mp *MessagePool) addLoaded(ctx context.Context, m *types.SignedMessage) error {
 	err := mp.checkMessage(ctx, m)
 	if err != nil {
@@ -997,12 +1067,29 @@ func (mp *MessagePool) addLocked(ctx context.Context, m *types.SignedMessage, st
 		}
 	}
 
-	incr, err := mset.add(m, mp, strict, untrusted)
+	if err := mp.makeRoomLocked(ctx, m, mset); err != nil {
+		return err
+	}
+
+	exms := mset.msgs[m.Message.Nonce]
+	incr, err := mset.add(m, mp, strict, untrusted, mp.isPrioritySender(ctx, m.Message.From))
 	if err != nil {
 		log.Debug(err)
+		switch {
+		case xerrors.Is(err, ErrTooManyPendingMessages):
+			recordLimitRejected(ctx, "too_many_pending")
+		case xerrors.Is(err, ErrNonceGap):
+			recordLimitRejected(ctx, "nonce_gap")
+		}
 		return err
 	}
 
+	mp.memory += msgMemory(m)
+	if exms != nil {
+		mp.memory -= msgMemory(exms)
+	}
+	recordMemory(ctx, mp.memory)
+
 	if incr {
 		mp.currentSize++
 		if mp.currentSize > mp.getConfig().SizeLimitHigh {
@@ -1206,6 +1293,8 @@ func (mp *MessagePool) remove(ctx context.Context, from address.Address, nonce u
 		})
 
 		mp.currentSize--
+		mp.memory -= msgMemory(m)
+		recordMemory(ctx, mp.memory)
 	}
 
 	// NB: This deletes any message with the given nonce. This makes sense
@@ -1358,6 +1447,13 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 		}
 	}
 
+	if len(apply) > 0 {
+		select {
+		case mp.scheduleTrigger <- struct{}{}:
+		default:
+		}
+	}
+
 	for _, s := range rmsgs {
 		for _, msg := range s {
 			if err := mp.addSkipChecks(ctx, msg); err != nil {
@@ -1542,6 +1638,12 @@ func (mp *MessagePool) RecoverSig(msg *types.Message) *types.SignedMessage {
 }
 
 func (mp *MessagePool) Updates(ctx context.Context) (<-chan api.MpoolUpdate, error) {
+	return mp.updates(ctx, nil), nil
+}
+
+// updates sends the message pool updates accepted by match, or all of them if it's nil, until
+// the context is done.
+func (mp *MessagePool) updates(ctx context.Context, match func(api.MpoolUpdate) bool) <-chan api.MpoolUpdate {
 	out := make(chan api.MpoolUpdate, 20)
 	sub := mp.changes.Sub(localUpdates)
 
@@ -1552,8 +1654,12 @@ func (mp *MessagePool) Updates(ctx context.Context) (<-chan api.MpoolUpdate, err
 		for {
 			select {
 			case u := <-sub:
+				mu := u.(api.MpoolUpdate)
+				if match != nil && !match(mu) {
+					continue
+				}
 				select {
-				case out <- u.(api.MpoolUpdate):
+				case out <- mu:
 				case <-ctx.Done():
 					return
 				case <-mp.closer:
@@ -1567,15 +1673,20 @@ func (mp *MessagePool) Updates(ctx context.Context) (<-chan api.MpoolUpdate, err
 		}
 	}()
 
-	return out, nil
+	return out
 }
 
 func (mp *MessagePool) loadLocal(ctx context.Context) error {
+	if !mp.getConfig().PersistLocalMessages {
+		return nil
+	}
+
 	res, err := mp.localMsgs.Query(ctx, query.Query{})
 	if err != nil {
 		return xerrors.Errorf("query local messages: %w", err)
 	}
 
+	var loaded, dropped int
 	for r := range res.Next() {
 		if r.Error != nil {
 			return xerrors.Errorf("r.Error: %w", r.Error)
@@ -1588,10 +1699,17 @@ func (mp *MessagePool) loadLocal(ctx context.Context) error {
 
 		if err := mp.addLoaded(ctx, &sm); err != nil {
 			if xerrors.Is(err, ErrNonceTooLow) {
-				continue // todo: drop the message from local cache (if above certain confidence threshold)
+				// the nonce was used on chain since the message was persisted
+				if err := mp.localMsgs.Delete(ctx, datastore.RawKey(r.Key)); err != nil {
+					log.Warnf("error deleting stale local message: %s", err)
+				}
+				dropped++
+				continue
 			}
 
 			log.Errorf("adding local message: %+v", err)
+		} else {
+			loaded++
 		}
 
 		if err = mp.setLocal(ctx, sm.Message.From); err != nil {
@@ -1600,9 +1718,68 @@ func (mp *MessagePool) loadLocal(ctx context.Context) error {
 		}
 	}
 
+	if loaded > 0 {
+		log.Infow("loaded persisted local messages", "loaded", loaded, "dropped", dropped)
+
+		// republish the loaded messages right away, the network may have forgotten them
+		select {
+		case mp.repubTrigger <- struct{}{}:
+		default:
+		}
+	}
+
 	return nil
 }
 
+// PersistedLocal returns the local messages persisted in the datastore, which are loaded back
+// in the pool on startup.
+func (mp *MessagePool) PersistedLocal(ctx context.Context) ([]*types.SignedMessage, error) {
+	res, err := mp.localMsgs.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("query local messages: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []*types.SignedMessage
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("r.Error: %w", r.Error)
+		}
+
+		var sm types.SignedMessage
+		if err := sm.UnmarshalCBOR(bytes.NewReader(r.Value)); err != nil {
+			return nil, xerrors.Errorf("unmarshaling local message: %w", err)
+		}
+		out = append(out, &sm)
+	}
+
+	return out, nil
+}
+
+// ClearPersistedLocal removes the local messages persisted in the datastore, leaving the pending
+// messages of the pool alone. It returns the number of messages removed.
+func (mp *MessagePool) ClearPersistedLocal(ctx context.Context) (int, error) {
+	res, err := mp.localMsgs.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return 0, xerrors.Errorf("query local messages: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var removed int
+	for r := range res.Next() {
+		if r.Error != nil {
+			return removed, xerrors.Errorf("r.Error: %w", r.Error)
+		}
+
+		if err := mp.localMsgs.Delete(ctx, datastore.RawKey(r.Key)); err != nil {
+			return removed, xerrors.Errorf("deleting local message: %w", err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
 func (mp *MessagePool) Clear(ctx context.Context, local bool) {
 	mp.lk.Lock()
 	defer mp.lk.Unlock()
@@ -1629,6 +1806,7 @@ func (mp *MessagePool) Clear(ctx context.Context, local bool) {
 
 		mp.clearPending()
 		mp.republished = nil
+		mp.recountMemoryLocked(ctx)
 
 		return
 	}
@@ -1649,6 +1827,7 @@ func (mp *MessagePool) Clear(ctx context.Context, local bool) {
 			return
 		}
 	})
+	mp.recountMemoryLocked(ctx)
 }
 
 func getBaseFeeLowerBound(baseFee, factor types.BigInt) types.BigInt {
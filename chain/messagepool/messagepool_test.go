@@ -6,14 +6,18 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/network"
 	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
@@ -598,6 +602,87 @@ func TestLoadLocal(t *testing.T) {
 	}
 }
 
+func TestPersistedLocal(t *testing.T) {
+	tma := newTestMpoolAPI()
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w2, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w2.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	for i := 0; i < 3; i++ {
+		m := makeTestMessage(w1, a1, a2, uint64(i), gasLimit, uint64(i+1))
+		if _, err := mp.Push(context.TODO(), m, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	persisted, err := mp.PersistedLocal(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persisted) != 3 {
+		t.Fatalf("expected 3 persisted messages, but got %d", len(persisted))
+	}
+
+	// messages pushed with persistence disabled aren't persisted
+	cfg := mp.GetConfig()
+	cfg.PersistLocalMessages = false
+	if err := mp.SetConfig(context.TODO(), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	m := makeTestMessage(w1, a1, a2, 3, gasLimit, 4)
+	if _, err := mp.Push(context.TODO(), m, true); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := mp.ClearPersistedLocal(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 3 {
+		t.Fatalf("expected to remove 3 persisted messages, but removed %d", removed)
+	}
+
+	persisted, err = mp.PersistedLocal(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persisted) != 0 {
+		t.Fatalf("expected no persisted messages, but got %d", len(persisted))
+	}
+
+	// clearing the persisted messages leaves the pending ones alone
+	pending, _ := mp.Pending(context.TODO())
+	if len(pending) != 4 {
+		t.Fatalf("expected 4 pending messages, but got %d", len(pending))
+	}
+}
+
 func TestClearAll(t *testing.T) {
 	tma := newTestMpoolAPI()
 	ds := datastore.NewMapDatastore()
@@ -1134,3 +1219,465 @@ func TestCapGasFee(t *testing.T) {
 		assert.Equal(t, msg.GasPremium.Int.Int64(), int64(100_000))
 	})
 }
+
+func TestRBFPolicy(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+	tma.setBalance(a2, 1) // in FIL
+
+	// the policy of the network can't be relaxed
+	if err := mp.SetRBFPolicy(RBFPolicy{MinPercent: 105, MinIncrease: big.Zero()}, nil); err == nil {
+		t.Fatal("expected a replace-by-fee policy below the network minimum to be refused")
+	}
+
+	err = mp.SetRBFPolicy(DefaultRBFPolicy(), map[address.Address]RBFPolicy{
+		a2: {MinPercent: 200, MinIncrease: big.NewInt(500)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	for _, a := range []address.Address{a1, a2} {
+		if _, err := mp.Push(context.TODO(), makeTestMessage(w1, a, a1, 0, gasLimit, 100), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a1 replaces at the network minimum
+	if _, err := mp.Push(context.TODO(), makeTestMessage(w1, a1, a1, 0, gasLimit, 111), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// a2 must double its premium, and add at least 500
+	_, err = mp.Push(context.TODO(), makeTestMessage(w1, a2, a1, 0, gasLimit, 201), true)
+	if !xerrors.Is(err, ErrRBFTooLowPremium) {
+		t.Fatalf("expected the replacement to be refused, got %v", err)
+	}
+	if _, err := mp.Push(context.TODO(), makeTestMessage(w1, a2, a1, 0, gasLimit, 600), true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrioritySenders(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+	tma.setBalance(a2, 1) // in FIL
+
+	limits := DefaultPendingLimits()
+	limits.MaxActorPending = 3
+	if err := mp.SetPendingLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+
+	mp.SetPrioritySenders([]address.Address{a2})
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	for i := 0; i < 5; i++ {
+		err := mp.Add(context.TODO(), makeTestMessage(w1, a1, a2, uint64(i), gasLimit, 100))
+		if i < limits.MaxActorPending && err != nil {
+			t.Fatal(err)
+		}
+		if i >= limits.MaxActorPending && !xerrors.Is(err, ErrTooManyPendingMessages) {
+			t.Fatalf("expected the pending messages of %s to be capped, got %v", a1, err)
+		}
+
+		// the priority sender isn't capped
+		mustAdd(t, mp, makeTestMessage(w1, a2, a1, uint64(i), gasLimit, 100))
+	}
+
+	assert.Contains(t, mp.priorityAddrs(), a2)
+}
+
+func TestPendingLimitsBounds(t *testing.T) {
+	mp, _ := makeTestMpool()
+
+	limits := DefaultPendingLimits()
+	limits.MaxActorPending = 0
+	assert.Error(t, mp.SetPendingLimits(limits))
+
+	limits = DefaultPendingLimits()
+	limits.MaxUntrustedActorPending = limits.MaxActorPending + 1
+	assert.Error(t, mp.SetPendingLimits(limits))
+
+	limits = DefaultPendingLimits()
+	limits.MaxNonceGap = MaxNonceGapLimit + 1
+	assert.Error(t, mp.SetPendingLimits(limits))
+
+	limits = PendingLimits{MaxActorPending: 5000, MaxUntrustedActorPending: 50, MaxNonceGap: 20}
+	assert.NoError(t, mp.SetPendingLimits(limits))
+	assert.Equal(t, limits, mp.PendingLimits())
+}
+
+func TestFilteredUpdates(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+	tma.setBalance(a2, 1) // in FIL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub, err := mp.FilteredUpdates(ctx, UpdateFilter{From: []address.Address{a2}, Methods: []abi.MethodNum{2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	mustAdd(t, mp, makeTestMessage(w1, a1, a2, 0, gasLimit, 100))
+	m := makeTestMessage(w1, a2, a1, 0, gasLimit, 100)
+	mustAdd(t, mp, m)
+
+	select {
+	case u := <-sub:
+		assert.Equal(t, api.MpoolAdd, u.Type)
+		assert.Equal(t, m.Cid(), u.Message.Cid())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the update")
+	}
+
+	select {
+	case u := <-sub:
+		t.Fatalf("unexpected update of %s", u.Message.Cid())
+	default:
+	}
+
+	f := UpdateFilter{To: []address.Address{a1}, Methods: []abi.MethodNum{3}}
+	assert.False(t, f.Matches(&m.Message))
+	f.Methods = append(f.Methods, 2)
+	assert.True(t, f.Matches(&m.Message))
+	assert.True(t, (&UpdateFilter{}).Matches(&m.Message))
+}
+
+func TestPushBatch(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	batch := []*types.SignedMessage{
+		makeTestMessage(w1, a1, a2, 0, gasLimit, 100),
+		makeTestMessage(w1, a1, a2, 1, gasLimit, 100),
+		// doesn't replace the previous message of the batch
+		makeTestMessage(w1, a1, a2, 1, gasLimit, 101),
+	}
+
+	_, err = mp.PushBatch(context.TODO(), batch, false, true)
+	var berr *BatchError
+	if !xerrors.As(err, &berr) {
+		t.Fatalf("expected a batch error, got %v", err)
+	}
+	assert.Equal(t, 2, berr.Index)
+	assert.True(t, xerrors.Is(err, ErrRBFTooLowPremium))
+
+	pending, _ := mp.Pending(context.TODO())
+	assert.Empty(t, pending)
+
+	cids, err := mp.PushBatch(context.TODO(), batch[:2], false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []cid.Cid{batch[0].Cid(), batch[1].Cid()}, cids)
+
+	pending, _ = mp.Pending(context.TODO())
+	assert.Len(t, pending, 2)
+}
+
+func TestNonceGaps(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+
+	snonce, gaps, err := mp.NonceGaps(context.TODO(), a1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(0), snonce)
+	assert.Empty(t, gaps)
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	for _, nonce := range []uint64{0, 2, 3, 6} {
+		if _, err := mp.Push(context.TODO(), makeTestMessage(w1, a1, a2, nonce, gasLimit, 100), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, gaps, err = mp.NonceGaps(context.TODO(), a1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []api.NonceGap{{Start: 1, End: 2}, {Start: 4, End: 6}}, gaps)
+}
+
+func TestLocalHistory(t *testing.T) {
+	mp, tma := makeTestMpool()
+	mp.SetLocalHistory(true)
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	m0 := makeTestMessage(w1, a1, a2, 0, gasLimit, 100)
+	m0r := makeTestMessage(w1, a1, a2, 0, gasLimit, 300) // replaces m0
+	m1 := makeTestMessage(w1, a1, a2, 1, gasLimit, 100)
+	for _, m := range []*types.SignedMessage{m0, m0r, m1} {
+		if _, err := mp.Push(context.TODO(), m, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := mp.LocalHistory(context.TODO(), a1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.Len(t, records, 2)
+	require.Len(t, records[0].Attempts, 2)
+	assert.Equal(t, m0.Cid(), records[0].Attempts[0].Cid)
+	assert.Equal(t, m0r.Cid(), records[0].Attempts[1].Cid)
+	require.Len(t, records[1].Attempts, 1)
+	assert.Equal(t, uint64(1), records[1].Nonce)
+	assert.Nil(t, records[1].Landed())
+
+	err = mp.SetLocalOutcome(context.TODO(), a1, 0, api.LocalMessageOutcome{Cid: m0r.Cid(), Epoch: 10, GasUsed: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unresolved, err := mp.UnresolvedLocalHistory(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.Len(t, unresolved, 1)
+	assert.Equal(t, uint64(1), unresolved[0].Nonce)
+
+	records, err = mp.LocalHistory(context.TODO(), a1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	landed := records[0].Landed()
+	require.NotNil(t, landed)
+	assert.Equal(t, m0r.Cid(), landed.Cid)
+	assert.Equal(t, abi.ChainEpoch(10), records[0].Outcome.Epoch)
+
+	// nothing is recorded for the other senders
+	records, err = mp.LocalHistory(context.TODO(), a2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, records)
+}
+
+func TestMemoryBudget(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	var msgs []*types.SignedMessage
+	for _, premium := range []uint64{100, 200, 300, 400, 50} {
+		from, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tma.setBalance(from, 1) // in FIL
+
+		msgs = append(msgs, makeTestMessage(w, from, to, 0, gasLimit, premium))
+	}
+
+	// room for the first three messages and a half
+	budget := msgMemory(msgs[0]) + msgMemory(msgs[1]) + msgMemory(msgs[2]) + msgMemory(msgs[3])/2
+	if err := mp.SetMemoryBudget(budget); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range msgs[:3] {
+		if err := mp.Add(context.TODO(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, msgMemory(msgs[0])+msgMemory(msgs[1])+msgMemory(msgs[2]), mp.MemoryUsage())
+
+	// evicts the message with the lowest premium
+	if err := mp.Add(context.TODO(), msgs[3]); err != nil {
+		t.Fatal(err)
+	}
+	pending, _ := mp.Pending(context.TODO())
+	assert.ElementsMatch(t, []cid.Cid{msgs[1].Cid(), msgs[2].Cid(), msgs[3].Cid()}, cidsOf(pending))
+	assert.Equal(t, msgMemory(msgs[1])+msgMemory(msgs[2])+msgMemory(msgs[3]), mp.MemoryUsage())
+
+	// would be evicted first
+	err = mp.Add(context.TODO(), msgs[4])
+	assert.True(t, xerrors.Is(err, ErrMemoryBudgetExceeded), "unexpected error: %v", err)
+	pending, _ = mp.Pending(context.TODO())
+	assert.Len(t, pending, 3)
+}
+
+func TestScheduledMessages(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	m := makeTestMessage(w1, a1, a2, 0, gasLimit, 100)
+
+	c, err := mp.Schedule(context.TODO(), m, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, m.Cid(), c)
+
+	scheduled, err := mp.Scheduled(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.Len(t, scheduled, 1)
+	assert.Equal(t, abi.ChainEpoch(10), scheduled[0].Epoch)
+
+	// not pushed before its epoch
+	mp.pushScheduled(context.TODO())
+	pending, _ := mp.Pending(context.TODO())
+	assert.Empty(t, pending)
+
+	if err := mp.CancelScheduled(context.TODO(), c); err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorIs(t, mp.CancelScheduled(context.TODO(), c), ErrScheduledNotFound)
+
+	if _, err := mp.Schedule(context.TODO(), m, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	tma.applyBlock(t, tma.nextBlockWithHeight(10))
+	mp.pushScheduled(context.TODO())
+
+	pending, _ = mp.Pending(context.TODO())
+	assert.Equal(t, []cid.Cid{c}, cidsOf(pending))
+
+	scheduled, err = mp.Scheduled(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, scheduled)
+}
+
+func cidsOf(msgs []*types.SignedMessage) []cid.Cid {
+	out := make([]cid.Cid, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, m.Cid())
+	}
+	return out
+}
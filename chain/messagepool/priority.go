@@ -0,0 +1,60 @@
+package messagepool
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+)
+
+// SetPrioritySenders sets the senders whose messages aren't subject to the caps on the pending
+// messages of an actor, and are selected and protected from pruning like the messages of the
+// priority addresses of the config.
+func (mp *MessagePool) SetPrioritySenders(addrs []address.Address) {
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+
+	mp.prioritySenders = addrs
+}
+
+// priorityAddrs returns the priority addresses of the config along with the priority senders.
+func (mp *MessagePool) priorityAddrs() []address.Address {
+	mp.cfgLk.RLock()
+	defer mp.cfgLk.RUnlock()
+
+	if len(mp.prioritySenders) == 0 {
+		return mp.cfg.PriorityAddrs
+	}
+
+	out := make([]address.Address, 0, len(mp.cfg.PriorityAddrs)+len(mp.prioritySenders))
+	out = append(out, mp.cfg.PriorityAddrs...)
+	return append(out, mp.prioritySenders...)
+}
+
+// isPrioritySender returns whether a sender is one of the priority senders.
+func (mp *MessagePool) isPrioritySender(ctx context.Context, from address.Address) bool {
+	mp.cfgLk.RLock()
+	senders := mp.prioritySenders
+	mp.cfgLk.RUnlock()
+
+	if len(senders) == 0 {
+		return false
+	}
+
+	fk, err := mp.resolveToKey(ctx, from)
+	if err != nil {
+		log.Debugf("failed to resolve sender: %s", err)
+		return false
+	}
+
+	for _, s := range senders {
+		sk, err := mp.resolveToKey(ctx, s)
+		if err != nil {
+			log.Debugf("failed to resolve priority sender: %s", err)
+			continue
+		}
+		if sk == fk {
+			return true
+		}
+	}
+	return false
+}
@@ -58,7 +58,7 @@ func (mp *MessagePool) pruneMessages(ctx context.Context, ts *types.TipSet) erro
 
 	mpCfg := mp.getConfig()
 	// we never prune priority addresses
-	for _, actor := range mpCfg.PriorityAddrs {
+	for _, actor := range mp.priorityAddrs() {
 		pk, err := mp.resolveToKey(ctx, actor)
 		if err != nil {
 			log.Debugf("pruneMessages failed to resolve priority address: %s", err)
@@ -0,0 +1,81 @@
+package messagepool
+
+import (
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// RBFPolicy is the policy a message must satisfy to replace a pending message with the same
+// nonce (replace-by-fee).
+type RBFPolicy struct {
+	// MinPercent is the minimum gas premium of the replacement, in percent of the gas premium of
+	// the replaced message.
+	MinPercent types.Percent
+	// MinIncrease is the minimum increase of the gas premium, in attoFIL per gas unit.
+	MinIncrease abi.TokenAmount
+}
+
+// DefaultRBFPolicy is the replace-by-fee policy of the network.
+func DefaultRBFPolicy() RBFPolicy {
+	return RBFPolicy{
+		MinPercent:  ReplaceByFeePercentageMinimum,
+		MinIncrease: big.Zero(),
+	}
+}
+
+// MinPremium returns the minimum gas premium of a message replacing a message with the given
+// gas premium.
+func (p RBFPolicy) MinPremium(curPrem abi.TokenAmount) abi.TokenAmount {
+	minPrice := ComputeRBF(curPrem, p.MinPercent)
+	if floor := big.Add(curPrem, p.MinIncrease); floor.GreaterThan(minPrice) {
+		return floor
+	}
+	return minPrice
+}
+
+func (p RBFPolicy) validate() error {
+	// replacements under the minimum of the network wouldn't propagate
+	if p.MinPercent < ReplaceByFeePercentageMinimum {
+		return xerrors.Errorf("minimum replace-by-fee premium of %d%% is less than the required %d%%", p.MinPercent, ReplaceByFeePercentageMinimum)
+	}
+	if p.MinIncrease.Int == nil || p.MinIncrease.LessThan(big.Zero()) {
+		return xerrors.Errorf("minimum replace-by-fee premium increase must be positive")
+	}
+	return nil
+}
+
+// SetRBFPolicy sets the replace-by-fee policy of the pool, along with the policies overriding it
+// for the messages of some senders.
+func (mp *MessagePool) SetRBFPolicy(def RBFPolicy, overrides map[address.Address]RBFPolicy) error {
+	if err := def.validate(); err != nil {
+		return err
+	}
+	for addr, p := range overrides {
+		if err := p.validate(); err != nil {
+			return xerrors.Errorf("replace-by-fee policy of %s: %w", addr, err)
+		}
+	}
+
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+
+	mp.rbfPolicy = def
+	mp.rbfOverrides = overrides
+	return nil
+}
+
+// RBFPolicy returns the replace-by-fee policy the messages of a sender must satisfy.
+func (mp *MessagePool) RBFPolicy(from address.Address) RBFPolicy {
+	mp.cfgLk.RLock()
+	defer mp.cfgLk.RUnlock()
+
+	if p, ok := mp.rbfOverrides[from]; ok {
+		return p
+	}
+	return mp.rbfPolicy
+}
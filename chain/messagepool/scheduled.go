@@ -0,0 +1,153 @@
+package messagepool
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const scheduledMsgsDs = "/mpool/scheduled"
+
+// ScheduledMessageExpiry is the number of epochs after its target epoch a scheduled message
+// keeps being retried when pushing it fails, before it is dropped.
+var ScheduledMessageExpiry = build.Finality
+
+// Schedule persists a signed message, to be pushed like Push once the chain reaches an epoch;
+// messages scheduled at past epochs are pushed at the next head change. The message is only
+// validated syntactically and for its signature: balance and nonce are checked when it's pushed.
+func (mp *MessagePool) Schedule(ctx context.Context, m *types.SignedMessage, epoch abi.ChainEpoch) (cid.Cid, error) {
+	if err := mp.checkMessage(ctx, m); err != nil {
+		return cid.Undef, err
+	}
+
+	sm := &api.ScheduledMessage{
+		Cid:       m.Cid(),
+		Message:   m,
+		Epoch:     epoch,
+		Scheduled: build.Clock.Now(),
+	}
+
+	mp.scheduledLk.Lock()
+	defer mp.scheduledLk.Unlock()
+
+	if err := mp.putScheduled(ctx, sm); err != nil {
+		return cid.Undef, err
+	}
+	return sm.Cid, nil
+}
+
+// Scheduled returns the scheduled messages, by target epoch.
+func (mp *MessagePool) Scheduled(ctx context.Context) ([]*api.ScheduledMessage, error) {
+	mp.scheduledLk.Lock()
+	defer mp.scheduledLk.Unlock()
+
+	return mp.listScheduled(ctx)
+}
+
+// CancelScheduled cancels a scheduled message.
+func (mp *MessagePool) CancelScheduled(ctx context.Context, c cid.Cid) error {
+	mp.scheduledLk.Lock()
+	defer mp.scheduledLk.Unlock()
+
+	k := datastore.NewKey(c.String())
+	has, err := mp.scheduledMsgs.Has(ctx, k)
+	if err != nil {
+		return xerrors.Errorf("looking up scheduled message: %w", err)
+	}
+	if !has {
+		return ErrScheduledNotFound
+	}
+	return mp.scheduledMsgs.Delete(ctx, k)
+}
+
+func (mp *MessagePool) putScheduled(ctx context.Context, sm *api.ScheduledMessage) error {
+	b, err := json.Marshal(sm)
+	if err != nil {
+		return xerrors.Errorf("encoding scheduled message: %w", err)
+	}
+	if err := mp.scheduledMsgs.Put(ctx, datastore.NewKey(sm.Cid.String()), b); err != nil {
+		return xerrors.Errorf("persisting scheduled message: %w", err)
+	}
+	return nil
+}
+
+func (mp *MessagePool) listScheduled(ctx context.Context) ([]*api.ScheduledMessage, error) {
+	res, err := mp.scheduledMsgs.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying scheduled messages: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []*api.ScheduledMessage
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("querying scheduled messages: %w", r.Error)
+		}
+
+		var sm api.ScheduledMessage
+		if err := json.Unmarshal(r.Value, &sm); err != nil {
+			return nil, xerrors.Errorf("decoding scheduled message %s: %w", r.Key, err)
+		}
+		out = append(out, &sm)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Epoch != out[j].Epoch {
+			return out[i].Epoch < out[j].Epoch
+		}
+		return out[i].Scheduled.Before(out[j].Scheduled)
+	})
+	return out, nil
+}
+
+// pushScheduled pushes the scheduled messages whose epoch the current tipset reached.
+func (mp *MessagePool) pushScheduled(ctx context.Context) {
+	mp.curTsLk.RLock()
+	height := mp.curTs.Height()
+	mp.curTsLk.RUnlock()
+
+	mp.scheduledLk.Lock()
+	defer mp.scheduledLk.Unlock()
+
+	scheduled, err := mp.listScheduled(ctx)
+	if err != nil {
+		log.Errorf("error listing scheduled messages: %s", err)
+		return
+	}
+
+	for _, sm := range scheduled {
+		if sm.Epoch > height {
+			break
+		}
+
+		_, err := mp.Push(ctx, sm.Message, true)
+		switch {
+		case err == nil, xerrors.Is(err, ErrExistingNonce):
+			log.Infow("pushed scheduled message", "cid", sm.Cid, "epoch", sm.Epoch, "height", height)
+		case xerrors.Is(err, ErrNonceTooLow), height > sm.Epoch+ScheduledMessageExpiry:
+			log.Errorw("dropping scheduled message failing to push", "cid", sm.Cid, "epoch", sm.Epoch, "error", err)
+		default:
+			log.Warnw("error pushing scheduled message, retrying at the next epoch", "cid", sm.Cid, "epoch", sm.Epoch, "error", err)
+			sm.LastError = err.Error()
+			if err := mp.putScheduled(ctx, sm); err != nil {
+				log.Errorf("error updating scheduled message: %s", err)
+			}
+			continue
+		}
+
+		if err := mp.scheduledMsgs.Delete(ctx, datastore.NewKey(sm.Cid.String())); err != nil {
+			log.Errorf("error deleting pushed scheduled message: %s", err)
+		}
+	}
+}
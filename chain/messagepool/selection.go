@@ -56,32 +56,23 @@ func (mp *MessagePool) SelectMessages(ctx context.Context, ts *types.TipSet, tq
 		}
 	}
 
-	// if the ticket quality is high enough that the first block has higher probability
-	// than any other block, then we don't bother with optimal selection because the
-	// first block will always have higher effective performance
-	var sm *selectedMessages
-	var err error
-	if tq > 0.84 {
-		sm, err = mp.selectMessagesGreedy(ctx, mp.curTs, ts)
-	} else {
-		sm, err = mp.selectMessagesOptimal(ctx, mp.curTs, ts, tq)
-	}
-
+	msgs, err := mp.getSelector().Select(ctx, &SelectionContext{
+		mp:            mp,
+		CurTs:         mp.curTs,
+		Ts:            ts,
+		TicketQuality: tq,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if sm == nil {
-		return nil, nil
-	}
-
 	// one last sanity check
-	if len(sm.msgs) > build.BlockMessageLimit {
-		log.Errorf("message selection chose too many messages %d > %d", len(sm.msgs), build.BlockMessageLimit)
-		sm.msgs = sm.msgs[:build.BlockMessageLimit]
+	if len(msgs) > build.BlockMessageLimit {
+		log.Errorf("message selection chose too many messages %d > %d", len(msgs), build.BlockMessageLimit)
+		msgs = msgs[:build.BlockMessageLimit]
 	}
 
-	return sm.msgs, nil
+	return msgs, nil
 }
 
 type selectedMessages struct {
@@ -608,7 +599,7 @@ func (mp *MessagePool) selectPriorityMessages(ctx context.Context, pending map[a
 
 	// 1. Get priority actor chains
 	var chains []*msgChain
-	priority := mpCfg.PriorityAddrs
+	priority := mp.priorityAddrs()
 	for _, actor := range priority {
 		pk, err := mp.resolveToKey(ctx, actor)
 		if err != nil {
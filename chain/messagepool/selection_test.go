@@ -1690,3 +1690,82 @@ readLoop:
 	}
 
 }
+
+func TestFairSelector(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := tma.nextBlock()
+	ts := mock.TipSet(block)
+	tma.applyBlock(t, block)
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+
+	tma.setBalance(a1, 1) // in FIL
+	tma.setBalance(a2, 1) // in FIL
+
+	for i := 0; i < 10; i++ {
+		mustAdd(t, mp, makeTestMessage(w1, a1, a2, uint64(i), gasLimit, uint64(2*i+1)))
+		mustAdd(t, mp, makeTestMessage(w1, a2, a1, uint64(i), gasLimit, uint64(i+1)))
+	}
+
+	_, err = NewFairSelector(0)
+	if err == nil {
+		t.Fatal("expected an error for a cap below 1")
+	}
+
+	s, err := NewFairSelector(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp.SetSelector(s)
+
+	msgs, err := mp.SelectMessages(context.Background(), ts, 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonces := map[address.Address]uint64{}
+	for _, m := range msgs {
+		if m.Message.Nonce != nonces[m.Message.From] {
+			t.Fatalf("expected nonce %d from %s, got %d", nonces[m.Message.From], m.Message.From, m.Message.Nonce)
+		}
+		nonces[m.Message.From]++
+	}
+	if nonces[a1] != 3 || nonces[a2] != 3 {
+		t.Fatalf("expected 3 messages from each sender, got %d and %d", nonces[a1], nonces[a2])
+	}
+
+	// priority senders aren't capped
+	mp.SetPrioritySenders([]address.Address{a1})
+	msgs, err = mp.SelectMessages(context.Background(), ts, 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 13 {
+		t.Fatalf("expected 13 messages, got %d", len(msgs))
+	}
+
+	mp.SetSelector(GreedySelector)
+	msgs, err = mp.SelectMessages(context.Background(), ts, 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 20 {
+		t.Fatalf("expected 20 messages, got %d", len(msgs))
+	}
+}
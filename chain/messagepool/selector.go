@@ -0,0 +1,183 @@
+package messagepool
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Selector is a strategy selecting the messages of a block from the pending messages.
+type Selector interface {
+	// Select returns the messages of a block, in the order they are to be included and within
+	// the limits of a block. The message pool is locked while it runs, so it must not call the
+	// methods of the message pool.
+	Select(ctx context.Context, sc *SelectionContext) ([]*types.SignedMessage, error)
+}
+
+// SelectionContext gives selectors access to the pending messages and to the built-in selection
+// algorithms, so that they can refine them.
+type SelectionContext struct {
+	mp *MessagePool
+
+	// CurTs is the current tipset of the message pool.
+	CurTs *types.TipSet
+	// Ts is the tipset the block is mined on.
+	Ts *types.TipSet
+	// TicketQuality is the quality of the ticket of the block.
+	TicketQuality float64
+}
+
+// Pending returns the messages pending at Ts, by sender and nonce. They must not be modified.
+func (sc *SelectionContext) Pending(ctx context.Context) (map[address.Address]map[uint64]*types.SignedMessage, error) {
+	return sc.mp.getPendingMessages(ctx, sc.CurTs, sc.Ts)
+}
+
+// Greedy selects messages by gas performance alone, ignoring the other blocks of the tipset.
+func (sc *SelectionContext) Greedy(ctx context.Context) ([]*types.SignedMessage, error) {
+	sm, err := sc.mp.selectMessagesGreedy(ctx, sc.CurTs, sc.Ts)
+	if err != nil || sm == nil {
+		return nil, err
+	}
+	return sm.msgs, nil
+}
+
+// Optimal selects messages by gas performance, accounting for the probability of the messages
+// to be included by the other blocks of the tipset given the ticket quality.
+func (sc *SelectionContext) Optimal(ctx context.Context) ([]*types.SignedMessage, error) {
+	sm, err := sc.mp.selectMessagesOptimal(ctx, sc.CurTs, sc.Ts, sc.TicketQuality)
+	if err != nil || sm == nil {
+		return nil, err
+	}
+	return sm.msgs, nil
+}
+
+type selectorFunc func(ctx context.Context, sc *SelectionContext) ([]*types.SignedMessage, error)
+
+func (f selectorFunc) Select(ctx context.Context, sc *SelectionContext) ([]*types.SignedMessage, error) {
+	return f(ctx, sc)
+}
+
+var (
+	// DefaultSelector selects messages greedily when the ticket quality is high enough for the
+	// block to be the first of the tipset, and optimally otherwise.
+	DefaultSelector Selector = selectorFunc(func(ctx context.Context, sc *SelectionContext) ([]*types.SignedMessage, error) {
+		// if the ticket quality is high enough that the first block has higher probability
+		// than any other block, then we don't bother with optimal selection because the
+		// first block will always have higher effective performance
+		if sc.TicketQuality > 0.84 {
+			return sc.Greedy(ctx)
+		}
+		return sc.Optimal(ctx)
+	})
+
+	// GreedySelector always selects messages greedily.
+	GreedySelector Selector = selectorFunc(func(ctx context.Context, sc *SelectionContext) ([]*types.SignedMessage, error) {
+		return sc.Greedy(ctx)
+	})
+
+	// OptimalSelector always selects messages optimally.
+	OptimalSelector Selector = selectorFunc(func(ctx context.Context, sc *SelectionContext) ([]*types.SignedMessage, error) {
+		return sc.Optimal(ctx)
+	})
+)
+
+// NewFairSelector returns a selector selecting messages like DefaultSelector, but at most
+// maxPerSender messages from each sender, so that a few senders can't fill the blocks. The
+// messages of the priority addresses aren't capped.
+func NewFairSelector(maxPerSender int) (Selector, error) {
+	if maxPerSender < 1 {
+		return nil, xerrors.Errorf("maximum number of messages per sender %d is below 1", maxPerSender)
+	}
+
+	return selectorFunc(func(ctx context.Context, sc *SelectionContext) ([]*types.SignedMessage, error) {
+		msgs, err := DefaultSelector.Select(ctx, sc)
+		if err != nil {
+			return nil, err
+		}
+
+		priority := make(map[address.Address]struct{})
+		for _, a := range sc.mp.priorityAddrs() {
+			if ka, err := sc.mp.resolveToKey(ctx, a); err == nil {
+				priority[ka] = struct{}{}
+			}
+		}
+
+		// the messages of a sender are selected in nonce order, so keeping the first ones
+		// doesn't leave gaps
+		counts := make(map[address.Address]int)
+		out := msgs[:0]
+		for _, m := range msgs {
+			from := m.Message.From
+			if _, ok := priority[from]; !ok && counts[from] >= maxPerSender {
+				continue
+			}
+			counts[from]++
+			out = append(out, m)
+		}
+		return out, nil
+	}), nil
+}
+
+var (
+	selectorsLk sync.Mutex
+	selectors   = map[string]Selector{
+		"default": DefaultSelector,
+		"greedy":  GreedySelector,
+		"optimal": OptimalSelector,
+	}
+)
+
+// RegisterSelector registers a selector under a name, so that it can be configured by name. It is
+// meant to be called from the init functions of the packages of custom selectors.
+func RegisterSelector(name string, s Selector) {
+	selectorsLk.Lock()
+	defer selectorsLk.Unlock()
+
+	selectors[name] = s
+}
+
+// SelectorByName returns the selector registered under a name.
+func SelectorByName(name string) (Selector, bool) {
+	selectorsLk.Lock()
+	defer selectorsLk.Unlock()
+
+	s, ok := selectors[name]
+	return s, ok
+}
+
+// Selectors returns the names of the registered selectors.
+func Selectors() []string {
+	selectorsLk.Lock()
+	defer selectorsLk.Unlock()
+
+	names := make([]string, 0, len(selectors))
+	for n := range selectors {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetSelector sets the strategy selecting the messages of the blocks.
+func (mp *MessagePool) SetSelector(s Selector) {
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+
+	mp.selector = s
+}
+
+func (mp *MessagePool) getSelector() Selector {
+	mp.cfgLk.RLock()
+	defer mp.cfgLk.RUnlock()
+
+	if mp.selector == nil {
+		return DefaultSelector
+	}
+	return mp.selector
+}
@@ -0,0 +1,74 @@
+package messagepool
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// UpdateFilter selects the message pool updates of the messages matching all its non-empty
+// criteria; an empty filter matches every message.
+type UpdateFilter struct {
+	// From matches the messages sent by any of these addresses.
+	From []address.Address
+	// To matches the messages sent to any of these addresses, as written in the messages.
+	To []address.Address
+	// Methods matches the messages calling any of these methods.
+	Methods []abi.MethodNum
+}
+
+// Matches returns whether a message matches the filter.
+func (f *UpdateFilter) Matches(m *types.Message) bool {
+	return matchAddr(f.From, m.From) && matchAddr(f.To, m.To) && matchMethod(f.Methods, m.Method)
+}
+
+func matchAddr(addrs []address.Address, a address.Address) bool {
+	if len(addrs) == 0 {
+		return true
+	}
+	for _, fa := range addrs {
+		if fa == a {
+			return true
+		}
+	}
+	return false
+}
+
+func matchMethod(methods []abi.MethodNum, m abi.MethodNum) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, fm := range methods {
+		if fm == m {
+			return true
+		}
+	}
+	return false
+}
+
+// FilteredUpdates is like Updates, but only sends the updates of the messages matching the
+// filter. The senders of the filter are also matched by their key address, which the pending
+// messages are sent from, so that they can be given as ID addresses.
+func (mp *MessagePool) FilteredUpdates(ctx context.Context, f UpdateFilter) (<-chan api.MpoolUpdate, error) {
+	from := make([]address.Address, 0, len(f.From))
+	for _, a := range f.From {
+		from = append(from, a)
+		ka, err := mp.resolveToKey(ctx, a)
+		if err != nil {
+			log.Debugf("failed to resolve filtered sender: %s", err)
+			continue
+		}
+		if ka != a {
+			from = append(from, ka)
+		}
+	}
+	f.From = from
+
+	return mp.updates(ctx, func(u api.MpoolUpdate) bool {
+		return f.Matches(&u.Message.Message)
+	}), nil
+}
@@ -662,3 +662,94 @@ func Diff(ctx context.Context, oldTree, newTree *StateTree) (map[string]types.Ac
 	}
 	return out, nil
 }
+
+// ActorChange is an entry of the actors HAMT which differs between two state trees. Old is nil
+// for actors created in the new tree, and New is nil for actors deleted from it. OldRaw and NewRaw
+// hold the raw HAMT values.
+type ActorChange struct {
+	Address address.Address
+	Old     *types.Actor
+	New     *types.Actor
+	OldRaw  []byte
+	NewRaw  []byte
+}
+
+// DiffActors returns every actor created, modified or deleted between oldTree and newTree. Unlike
+// Diff, it reports deletions and the previous value of each actor.
+func DiffActors(ctx context.Context, oldTree, newTree *StateTree) ([]ActorChange, error) {
+	d := &actorsDiff{ctx: ctx, oldVersion: oldTree.version, newVersion: newTree.version}
+	if err := adt.DiffAdtMap(oldTree.root, newTree.root, d); err != nil {
+		return nil, err
+	}
+	return d.changes, nil
+}
+
+type actorsDiff struct {
+	ctx        context.Context
+	oldVersion types.StateTreeVersion
+	newVersion types.StateTreeVersion
+	changes    []ActorChange
+}
+
+func (d *actorsDiff) AsKey(key string) (abi.Keyer, error) {
+	addr, err := address.NewFromBytes([]byte(key))
+	if err != nil {
+		return nil, xerrors.Errorf("address in state tree was not valid: %w", err)
+	}
+	return abi.AddrKey(addr), nil
+}
+
+func (d *actorsDiff) Add(key string, val *cbg.Deferred) error {
+	return d.record(key, nil, val)
+}
+
+func (d *actorsDiff) Modify(key string, from, to *cbg.Deferred) error {
+	return d.record(key, from, to)
+}
+
+func (d *actorsDiff) Remove(key string, val *cbg.Deferred) error {
+	return d.record(key, val, nil)
+}
+
+func (d *actorsDiff) record(key string, from, to *cbg.Deferred) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+
+	addr, err := address.NewFromBytes([]byte(key))
+	if err != nil {
+		return xerrors.Errorf("address in state tree was not valid: %w", err)
+	}
+
+	change := ActorChange{Address: addr}
+	if from != nil {
+		change.OldRaw = append([]byte(nil), from.Raw...)
+		if change.Old, err = decodeActor(d.oldVersion, from.Raw); err != nil {
+			return xerrors.Errorf("decoding old actor %s: %w", addr, err)
+		}
+	}
+	if to != nil {
+		change.NewRaw = append([]byte(nil), to.Raw...)
+		if change.New, err = decodeActor(d.newVersion, to.Raw); err != nil {
+			return xerrors.Errorf("decoding new actor %s: %w", addr, err)
+		}
+	}
+	d.changes = append(d.changes, change)
+	return nil
+}
+
+func decodeActor(version types.StateTreeVersion, raw []byte) (*types.Actor, error) {
+	if version <= types.StateTreeVersion4 {
+		var act types.ActorV4
+		if err := act.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+			return nil, err
+		}
+		return types.AsActorV5(&act), nil
+	}
+
+	var act types.Actor
+	if err := act.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+	return &act, nil
+}
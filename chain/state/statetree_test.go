@@ -421,3 +421,90 @@ func TestStateTreeConsistency(t *testing.T) {
 		t.Fatal("MISMATCH!")
 	}
 }
+
+func TestDiffActors(t *testing.T) {
+	ctx := context.Background()
+	cst := cbor.NewMemCborStore()
+	sv, err := VersionForNetwork(build.TestNetworkVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := NewStateTree(cst, sv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var addrs []address.Address
+	for i := 0; i < 3; i++ {
+		a, err := address.NewIDAddress(uint64(100 + i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, a)
+	}
+	mkActor := func(nonce uint64) *types.Actor {
+		return &types.Actor{
+			Balance: types.NewInt(0),
+			Code:    builtin2.AccountActorCodeID,
+			Head:    builtin2.AccountActorCodeID,
+			Nonce:   nonce,
+		}
+	}
+
+	for _, a := range addrs[:2] {
+		if err := st.SetActor(a, mkActor(0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldRoot, err := st.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modify the first actor, delete the second and create the third.
+	if err := st.SetActor(addrs[0], mkActor(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.DeleteActor(addrs[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetActor(addrs[2], mkActor(0)); err != nil {
+		t.Fatal(err)
+	}
+	newRoot, err := st.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldTree, err := LoadStateTree(cst, oldRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTree, err := LoadStateTree(cst, newRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := DiffActors(ctx, oldTree, newTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(changes))
+	}
+
+	byAddr := make(map[address.Address]ActorChange)
+	for _, c := range changes {
+		byAddr[c.Address] = c
+	}
+	if c := byAddr[addrs[0]]; c.Old == nil || c.New == nil || c.Old.Nonce != 0 || c.New.Nonce != 1 {
+		t.Errorf("unexpected change for modified actor: %+v", c)
+	}
+	if c := byAddr[addrs[1]]; c.Old == nil || c.New != nil || c.NewRaw != nil {
+		t.Errorf("unexpected change for deleted actor: %+v", c)
+	}
+	if c := byAddr[addrs[2]]; c.Old != nil || c.New == nil || c.OldRaw != nil {
+		t.Errorf("unexpected change for created actor: %+v", c)
+	}
+}
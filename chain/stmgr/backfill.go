@@ -0,0 +1,230 @@
+package stmgr
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// BlockFetcher fetches blocks from the network; it fails if any of them can't be fetched.
+type BlockFetcher func(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error)
+
+// StateBackfillStatus returns the progress of the archival state backfill.
+func (sm *StateManager) StateBackfillStatus() api.StateBackfillStatus {
+	sm.backfillLk.Lock()
+	defer sm.backfillLk.Unlock()
+
+	st := sm.backfill
+	st.EarliestState = sm.cs.StatePrunedBefore()
+	return st
+}
+
+// RunStateBackfill makes the node archival by filling in the state below the epoch from which it
+// is available, as after importing a lite snapshot, one tipset at a time going backwards, until
+// the genesis is reached or ctx is cancelled. For each tipset, its parent state, receipts and
+// messages are fetched and the tipset is executed on top of them to verify that they produce the
+// parent state of its child, before the state boundary is lowered to it; the state above the
+// boundary keeps being served meanwhile.
+func (sm *StateManager) RunStateBackfill(ctx context.Context, fetch BlockFetcher) error {
+	sm.backfillLk.Lock()
+	if sm.backfill.Running {
+		sm.backfillLk.Unlock()
+		return xerrors.Errorf("state backfill already running")
+	}
+	sm.backfill = api.StateBackfillStatus{Running: true, Started: build.Clock.Now()}
+	sm.backfillLk.Unlock()
+
+	err := sm.runStateBackfill(ctx, fetch)
+
+	sm.backfillLk.Lock()
+	sm.backfill.Running = false
+	if err != nil {
+		sm.backfill.Error = err.Error()
+	}
+	sm.backfillLk.Unlock()
+	return err
+}
+
+func (sm *StateManager) runStateBackfill(ctx context.Context, fetch BlockFetcher) error {
+	lastLog := time.Now()
+	for {
+		boundary := sm.cs.StatePrunedBefore()
+		if boundary == 0 {
+			log.Infow("state backfill done, all the state is available")
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		lowered, err := sm.backfillBelow(ctx, boundary, fetch)
+		if err != nil {
+			return xerrors.Errorf("backfilling the state below epoch %d: %w", boundary, err)
+		}
+
+		sm.backfillLk.Lock()
+		sm.backfill.Epochs += int64(boundary - lowered)
+		sm.backfillLk.Unlock()
+
+		if time.Since(lastLog) > time.Minute {
+			log.Infow("backfilling state", "earliest", lowered)
+			lastLog = time.Now()
+		}
+	}
+}
+
+// backfillBelow fills in the parent state of the last tipset below boundary, verifies it and
+// lowers the boundary to that tipset, returning its height.
+func (sm *StateManager) backfillBelow(ctx context.Context, boundary abi.ChainEpoch, fetch BlockFetcher) (abi.ChainEpoch, error) {
+	// the lowest tipset with its parent state, and its parent which doesn't have it
+	child, err := sm.cs.GetTipsetByHeight(ctx, boundary, sm.cs.GetHeaviestTipSet(), false)
+	if err != nil {
+		return 0, xerrors.Errorf("loading tipset at the state boundary: %w", err)
+	}
+	ts, err := sm.cs.LoadTipSet(ctx, child.Parents())
+	if err != nil {
+		return 0, xerrors.Errorf("loading parent tipset: %w", err)
+	}
+
+	// the genesis state is always available
+	if ts.Height() > 0 {
+		sbs, cbs := sm.cs.StateBlockstore(), sm.cs.ChainBlockstore()
+		if err := fillDag(ctx, sbs, ts.ParentState(), fetch); err != nil {
+			return 0, xerrors.Errorf("fetching parent state: %w", err)
+		}
+		if err := fillDag(ctx, sbs, ts.Blocks()[0].ParentMessageReceipts, fetch); err != nil {
+			return 0, xerrors.Errorf("fetching parent receipts: %w", err)
+		}
+		for _, b := range ts.Blocks() {
+			if err := fillDag(ctx, cbs, b.Messages, fetch); err != nil {
+				return 0, xerrors.Errorf("fetching messages of block %s: %w", b.Cid(), err)
+			}
+		}
+
+		// the state written by the execution is already in the child's parent state
+		buf := blockstore.NewTieredBstore(sbs, blockstore.NewMemorySync())
+		st, rec, err := sm.ExecuteTipSetWithOpts(ctx, ts, nil, false, ExecutorOpts{StateBlockstore: buf, Parallel: true})
+		if err != nil {
+			return 0, xerrors.Errorf("executing tipset %s at height %d: %w", ts.Key(), ts.Height(), err)
+		}
+		if st != child.ParentState() || rec != child.Blocks()[0].ParentMessageReceipts {
+			return 0, xerrors.Errorf("executing tipset %s at height %d gave state %s and receipts %s, the chain has %s and %s",
+				ts.Key(), ts.Height(), st, rec, child.ParentState(), child.Blocks()[0].ParentMessageReceipts)
+		}
+	}
+
+	if err := sm.cs.SetStatePrunedBefore(ctx, ts.Height()); err != nil {
+		return 0, err
+	}
+	return ts.Height(), nil
+}
+
+// fillDag fetches the blocks of the DAG under root missing from bs and writes them, each one
+// after all of its children, so that a block in bs always has its whole DAG in bs, and subtrees
+// already present are never walked.
+func fillDag(ctx context.Context, bs blockstore.Blockstore, root cid.Cid, fetch BlockFetcher) error {
+	type frame struct {
+		blk      blocks.Block
+		children []blocks.Block
+	}
+
+	// newFrame returns the frame of blk, with its children missing from bs fetched
+	newFrame := func(blk blocks.Block) (*frame, error) {
+		f := &frame{blk: blk}
+		if blk.Cid().Prefix().Codec != cid.DagCBOR {
+			return f, nil
+		}
+
+		var missing []cid.Cid
+		var hasErr error
+		seen := cid.NewSet()
+		if err := cbg.ScanForLinks(bytes.NewReader(blk.RawData()), func(l cid.Cid) {
+			if hasErr != nil || l.Prefix().MhType == multihash.IDENTITY || !seen.Visit(l) {
+				return
+			}
+			has, err := bs.Has(ctx, l)
+			if err != nil {
+				hasErr = err
+				return
+			}
+			if !has {
+				missing = append(missing, l)
+			}
+		}); err != nil {
+			return nil, xerrors.Errorf("scanning block %s for links: %w", blk.Cid(), err)
+		}
+		if hasErr != nil {
+			return nil, hasErr
+		}
+
+		if len(missing) > 0 {
+			children, err := fetch(ctx, missing)
+			if err != nil {
+				return nil, err
+			}
+			f.children = children
+		}
+		return f, nil
+	}
+
+	if has, err := bs.Has(ctx, root); err != nil || has {
+		return err
+	}
+	rblks, err := fetch(ctx, []cid.Cid{root})
+	if err != nil {
+		return err
+	}
+	if len(rblks) != 1 {
+		return xerrors.Errorf("fetching %s returned %d blocks", root, len(rblks))
+	}
+	rf, err := newFrame(rblks[0])
+	if err != nil {
+		return err
+	}
+
+	stack := []*frame{rf}
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		top := stack[len(stack)-1]
+		if n := len(top.children); n > 0 {
+			child := top.children[n-1]
+			top.children = top.children[:n-1]
+
+			// shared subtrees may have been written meanwhile
+			if has, err := bs.Has(ctx, child.Cid()); err != nil {
+				return err
+			} else if has {
+				continue
+			}
+
+			f, err := newFrame(child)
+			if err != nil {
+				return err
+			}
+			stack = append(stack, f)
+			continue
+		}
+
+		if err := bs.Put(ctx, top.blk); err != nil {
+			return xerrors.Errorf("writing block %s: %w", top.blk.Cid(), err)
+		}
+		stack = stack[:len(stack)-1]
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/gen"
+)
+
+func TestRunStateBackfill(t *testing.T) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		_, err := cg.NextTipSet()
+		require.NoError(t, err)
+	}
+
+	cs := cg.ChainStore()
+	sm := cg.StateManager()
+	bs := cs.StateBlockstore()
+
+	// a peer with all the state
+	remote := blockstore.NewMemorySync()
+	keys, err := bs.AllKeysChan(ctx)
+	require.NoError(t, err)
+	for c := range keys {
+		blk, err := bs.Get(ctx, c)
+		require.NoError(t, err)
+		require.NoError(t, remote.Put(ctx, blk))
+	}
+	fetch := func(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+		var out []blocks.Block
+		for _, c := range cids {
+			blk, err := remote.Get(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, blk)
+		}
+		return out, nil
+	}
+
+	_, err = cs.PruneState(ctx, bs, 5)
+	require.NoError(t, err)
+	boundary := cs.StatePrunedBefore()
+	require.Greater(t, boundary, abi.ChainEpoch(0))
+
+	// recording the boundary finds the same epoch
+	require.NoError(t, cs.SetStatePrunedBefore(ctx, 0))
+	earliest, err := cs.RecordStateBoundary(ctx, cs.GetHeaviestTipSet())
+	require.NoError(t, err)
+	require.Equal(t, boundary, earliest)
+
+	require.NoError(t, sm.RunStateBackfill(ctx, fetch))
+
+	st := sm.StateBackfillStatus()
+	require.False(t, st.Running)
+	require.Zero(t, st.EarliestState)
+	require.Equal(t, int64(boundary), st.Epochs)
+	require.Empty(t, st.Error)
+
+	for ts := cs.GetHeaviestTipSet(); ts.Height() > 0; {
+		has, err := bs.Has(ctx, ts.ParentState())
+		require.NoError(t, err)
+		require.True(t, has)
+
+		ts, err = cs.LoadTipSet(ctx, ts.Parents())
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,108 @@
+package stmgr
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+// ErrExecutionBreakerOpen is returned by TipSetState when a tipset has failed to execute too many
+// times in a row, until the breaker's cooldown has passed.
+var ErrExecutionBreakerOpen = errors.New("tipset execution circuit breaker open")
+
+var (
+	execBreakerWindow   = 5 * time.Minute
+	execBreakerCooldown = time.Minute
+	// execBreakerMaxTracked bounds the number of tipsets whose failures are tracked, the least
+	// recently failed ones being forgotten first.
+	execBreakerMaxTracked = 256
+)
+
+// breakerState tracks the failures of a tipset. Once open, the breaker refuses executions until
+// openUntil; the next execution is then let through (half-open). Its success closes the breaker,
+// while its failure opens it again straight away.
+type breakerState struct {
+	failures  int
+	first     time.Time
+	lastErr   error
+	openUntil time.Time
+	halfOpen  bool
+}
+
+// execBreaker tracks consecutive execution failures per state cache key.
+type execBreaker struct {
+	lk        sync.Mutex
+	threshold int // zero disables the breaker
+	states    *lru.Cache[string, *breakerState]
+}
+
+func newExecBreaker(threshold int) *execBreaker {
+	states, err := lru.New[string, *breakerState](execBreakerMaxTracked)
+	if err != nil {
+		panic(err) // only fails for non-positive sizes
+	}
+	return &execBreaker{threshold: threshold, states: states}
+}
+
+// SetExecBreakerThreshold sets the number of consecutive failures after which execution of a
+// tipset is refused for a while. Zero, the default, disables the breaker.
+func (sm *StateManager) SetExecBreakerThreshold(threshold int) {
+	sm.execBreaker.lk.Lock()
+	defer sm.execBreaker.lk.Unlock()
+	sm.execBreaker.threshold = threshold
+}
+
+// check returns an error wrapping ErrExecutionBreakerOpen if execution of the given key should
+// be refused.
+func (b *execBreaker) check(ck string) error {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	s, ok := b.states.Peek(ck)
+	if !ok || s.openUntil.IsZero() {
+		return nil
+	}
+	if !build.Clock.Now().Before(s.openUntil) {
+		s.openUntil = time.Time{}
+		s.halfOpen = true
+		return nil
+	}
+	return xerrors.Errorf("%w (last error: %s)", ErrExecutionBreakerOpen, s.lastErr)
+}
+
+// record updates the breaker with the outcome of an execution of the given key.
+func (b *execBreaker) record(ck string, err error) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	if b.threshold <= 0 {
+		return
+	}
+
+	if err == nil {
+		b.states.Remove(ck)
+		return
+	}
+
+	now := build.Clock.Now()
+	s, ok := b.states.Get(ck)
+	if !ok || (!s.halfOpen && now.Sub(s.first) > execBreakerWindow) {
+		s = &breakerState{first: now}
+		b.states.Add(ck, s)
+	}
+	s.failures++
+	s.lastErr = err
+	if s.halfOpen || s.failures >= b.threshold {
+		s.openUntil = now.Add(execBreakerCooldown)
+		s.halfOpen = false
+	}
+}
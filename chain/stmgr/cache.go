@@ -0,0 +1,145 @@
+package stmgr
+
+import (
+	"container/list"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// stateCacheEntry is a computed (state, receipt) pair for a single tipset.
+type stateCacheEntry struct {
+	key types.TipSetKey
+	st  cid.Cid
+	rec cid.Cid
+}
+
+// stateCache holds the results of TipSetState, keyed by cidsToKey. When bound is positive, the
+// least recently used entries are evicted once the cache grows past it. Pinned keys are never
+// evicted, whether or not they are cached yet, and don't count towards the bound. The cache is not thread-safe; callers must hold
+// the StateManager's stlk.
+type stateCache struct {
+	bound   int
+	entries map[string]*list.Element
+	order   *list.List
+	pinned  map[string]struct{}
+}
+
+func newStateCache(bound int) *stateCache {
+	return &stateCache{
+		bound:   bound,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		pinned:  make(map[string]struct{}),
+	}
+}
+
+func (c *stateCache) pin(ck string) {
+	c.pinned[ck] = struct{}{}
+}
+
+func (c *stateCache) unpin(ck string) {
+	delete(c.pinned, ck)
+}
+
+// get returns the entry for the given key, marking it as recently used.
+func (c *stateCache) get(ck string) (stateCacheEntry, bool) {
+	elem, ok := c.entries[ck]
+	if !ok {
+		return stateCacheEntry{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(stateCacheEntry), true
+}
+
+// peek returns the entry for the given key without affecting its recency.
+func (c *stateCache) peek(ck string) (stateCacheEntry, bool) {
+	elem, ok := c.entries[ck]
+	if !ok {
+		return stateCacheEntry{}, false
+	}
+	return elem.Value.(stateCacheEntry), true
+}
+
+// put adds or replaces the entry for the given key, returning any entries evicted to make room.
+func (c *stateCache) put(ck string, e stateCacheEntry) []stateCacheEntry {
+	if elem, ok := c.entries[ck]; ok {
+		elem.Value = e
+		c.order.MoveToFront(elem)
+		return nil
+	}
+	c.entries[ck] = c.order.PushFront(e)
+	return c.shrink()
+}
+
+// shrink evicts the least recently used entries past the bound, returning them.
+func (c *stateCache) shrink() []stateCacheEntry {
+	if c.bound <= 0 {
+		return nil
+	}
+
+	// Pinned entries don't count towards the bound.
+	excess := c.order.Len() - c.bound
+	for pk := range c.pinned {
+		if _, ok := c.entries[pk]; ok {
+			excess--
+		}
+	}
+
+	var evicted []stateCacheEntry
+	for elem := c.order.Back(); elem != nil && excess > 0; {
+		prev := elem.Prev()
+		old := elem.Value.(stateCacheEntry)
+		oldKey := cidsToKey(old.key.Cids())
+		if _, pinned := c.pinned[oldKey]; !pinned {
+			c.order.Remove(elem)
+			delete(c.entries, oldKey)
+			evicted = append(evicted, old)
+			excess--
+		}
+		elem = prev
+	}
+	return evicted
+}
+
+func (c *stateCache) len() int {
+	return c.order.Len()
+}
+
+// PinTipSetState exempts the state of the given tipset from eviction from the tipset state cache.
+// If the state isn't cached yet, it is retained once computed.
+func (sm *StateManager) PinTipSetState(key types.TipSetKey) {
+	sm.stlk.Lock()
+	defer sm.stlk.Unlock()
+	sm.stCache.pin(cidsToKey(key.Cids()))
+}
+
+// UnpinTipSetState makes the state of the given tipset subject to eviction again.
+func (sm *StateManager) UnpinTipSetState(key types.TipSetKey) {
+	sm.stlk.Lock()
+	defer sm.stlk.Unlock()
+	sm.stCache.unpin(cidsToKey(key.Cids()))
+}
+
+// notifyEvicted invokes the OnEvict hook for each evicted entry. It must not be called with stlk
+// held, so that the hook is free to call back into the StateManager.
+func (sm *StateManager) notifyEvicted(evicted []stateCacheEntry) {
+	if sm.OnEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		sm.OnEvict(e.key, e.st, e.rec)
+	}
+}
+
+// SetStateCacheSize bounds the number of entries of the tipset state cache, evicting the least
+// recently used entries past it. Zero, the default, leaves the cache unbounded.
+func (sm *StateManager) SetStateCacheSize(size int) {
+	sm.stlk.Lock()
+	sm.stCache.bound = size
+	evicted := sm.stCache.shrink()
+	sm.stlk.Unlock()
+
+	sm.notifyEvicted(evicted)
+}
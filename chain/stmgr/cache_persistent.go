@@ -0,0 +1,155 @@
+package stmgr
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var persistentStateCachePrefix = dstore.NewKey("/stmgr/state-results")
+
+type persistentStateCacheEntry struct {
+	State    cid.Cid
+	Receipts cid.Cid
+}
+
+// persistentStateCache keeps TipSetState results in the metadata datastore, so that they survive
+// restarts. It holds at most bound entries; recency is tracked in memory, so after a restart the
+// entries are evicted in arbitrary order until they are used again.
+type persistentStateCache struct {
+	ds dstore.Batching
+
+	lk    sync.Mutex
+	index *lru.Cache[string, struct{}]
+}
+
+func newPersistentStateCache(ctx context.Context, ds dstore.Batching, bound int) (*persistentStateCache, error) {
+	c := &persistentStateCache{ds: ds}
+
+	var err error
+	c.index, err = lru.NewWithEvict[string, struct{}](bound, func(k string, _ struct{}) {
+		// Called with lk held.
+		if err := ds.Delete(context.Background(), persistentStateCachePrefix.ChildString(k)); err != nil {
+			log.Errorw("failed to delete evicted persistent state cache entry", "key", k, "error", err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ds.Query(ctx, query.Query{Prefix: persistentStateCachePrefix.String(), KeysOnly: true})
+	if err != nil {
+		return nil, xerrors.Errorf("querying persistent state cache: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("iterating persistent state cache: %w", r.Error)
+		}
+		c.index.Add(dstore.RawKey(r.Key).BaseNamespace(), struct{}{})
+	}
+
+	return c, nil
+}
+
+func persistentStateCacheKey(tsk types.TipSetKey) string {
+	return hex.EncodeToString(tsk.Bytes())
+}
+
+func (c *persistentStateCache) get(ctx context.Context, tsk types.TipSetKey) (cid.Cid, cid.Cid, bool, error) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	k := persistentStateCacheKey(tsk)
+	if _, ok := c.index.Get(k); !ok {
+		return cid.Undef, cid.Undef, false, nil
+	}
+
+	b, err := c.ds.Get(ctx, persistentStateCachePrefix.ChildString(k))
+	if err != nil {
+		return cid.Undef, cid.Undef, false, xerrors.Errorf("reading persistent state cache entry: %w", err)
+	}
+	var e persistentStateCacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return cid.Undef, cid.Undef, false, xerrors.Errorf("unmarshaling persistent state cache entry: %w", err)
+	}
+	return e.State, e.Receipts, true, nil
+}
+
+func (c *persistentStateCache) put(ctx context.Context, tsk types.TipSetKey, st, rec cid.Cid) error {
+	b, err := json.Marshal(persistentStateCacheEntry{State: st, Receipts: rec})
+	if err != nil {
+		return err
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	k := persistentStateCacheKey(tsk)
+	if err := c.ds.Put(ctx, persistentStateCachePrefix.ChildString(k), b); err != nil {
+		return xerrors.Errorf("writing persistent state cache entry: %w", err)
+	}
+	c.index.Add(k, struct{}{})
+	return nil
+}
+
+func (c *persistentStateCache) purge() {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.index.Purge()
+}
+
+// persistentStateLookup returns the result of a previous execution of ts from the persistent
+// state cache, if it's there and its state and receipts are still in the blockstore.
+func (sm *StateManager) persistentStateLookup(ctx context.Context, ts *types.TipSet) (cid.Cid, cid.Cid, bool) {
+	if sm.pstCache == nil {
+		return cid.Undef, cid.Undef, false
+	}
+
+	st, rec, ok, err := sm.pstCache.get(ctx, ts.Key())
+	if err != nil {
+		log.Errorw("failed to read persistent state cache", "tipset", ts.Key(), "error", err)
+		return cid.Undef, cid.Undef, false
+	}
+	if !ok || !sm.hasStateCacheEntry(ctx, st, rec) {
+		return cid.Undef, cid.Undef, false
+	}
+	return st, rec, true
+}
+
+// EnablePersistentStateCache keeps up to size TipSetState results in the metadata datastore, so
+// that they survive restarts, loading those kept by a previous run. It must be called before the
+// StateManager is used.
+func (sm *StateManager) EnablePersistentStateCache(ctx context.Context, size int) error {
+	if size <= 0 {
+		return nil
+	}
+	if sm.metadataDs == nil {
+		return xerrors.New("the persistent state cache requires a metadata datastore")
+	}
+
+	c, err := newPersistentStateCache(ctx, sm.metadataDs, size)
+	if err != nil {
+		return xerrors.Errorf("loading persistent state cache: %w", err)
+	}
+	sm.pstCache = c
+	return nil
+}
+
+// PurgePersistentStateCache drops every entry from the persistent tipset state cache. It is a
+// no-op if the persistent cache is disabled.
+func (sm *StateManager) PurgePersistentStateCache() {
+	if sm.pstCache != nil {
+		sm.pstCache.purge()
+	}
+}
@@ -0,0 +1,120 @@
+package stmgr
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var stateCacheSnapshotKey = dstore.NewKey("/stmgr/state-cache-snapshot")
+
+type stateCacheSnapshotEntry struct {
+	Key      types.TipSetKey
+	State    cid.Cid
+	Receipts cid.Cid
+}
+
+// FlushStateCache writes a snapshot of the tipset state cache to the metadata datastore, so that
+// LoadStateCacheSnapshot can warm the cache after a restart. The snapshot holds the whole cache,
+// most recently used first, so its size follows the one set with SetStateCacheSize. Entries whose
+// state or receipts are no longer in the blockstore are skipped. If ctx has a deadline, entries
+// are verified until three quarters of the remaining time have passed, and those verified so far
+// are written; nothing is written once ctx is done. It returns the number of entries written.
+func (sm *StateManager) FlushStateCache(ctx context.Context) (int, error) {
+	if sm.metadataDs == nil {
+		return 0, xerrors.Errorf("no metadata datastore to flush the state cache to")
+	}
+
+	sm.stlk.Lock()
+	entries := make([]stateCacheEntry, 0, sm.stCache.order.Len())
+	for elem := sm.stCache.order.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, elem.Value.(stateCacheEntry))
+	}
+	sm.stlk.Unlock()
+
+	// Leave time to write the snapshot within ctx.
+	verifyCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		verifyCtx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Until(deadline)/4))
+		defer cancel()
+	}
+
+	snapshot := make([]stateCacheSnapshotEntry, 0, len(entries))
+	for _, e := range entries {
+		if verifyCtx.Err() != nil {
+			log.Warnw("state cache flush deadline reached, writing partial snapshot", "verified", len(snapshot), "total", len(entries))
+			break
+		}
+		if !sm.hasStateCacheEntry(verifyCtx, e.st, e.rec) {
+			continue
+		}
+		snapshot = append(snapshot, stateCacheSnapshotEntry{Key: e.key, State: e.st, Receipts: e.rec})
+	}
+
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return 0, xerrors.Errorf("marshaling state cache snapshot: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, xerrors.Errorf("writing state cache snapshot: %w", err)
+	}
+	if err := sm.metadataDs.Put(ctx, stateCacheSnapshotKey, b); err != nil {
+		return 0, xerrors.Errorf("writing state cache snapshot: %w", err)
+	}
+	return len(snapshot), nil
+}
+
+// LoadStateCacheSnapshot warms the tipset state cache from a snapshot written by FlushStateCache.
+// Entries whose state or receipts are no longer in the blockstore are skipped. It returns the
+// number of entries loaded.
+func (sm *StateManager) LoadStateCacheSnapshot(ctx context.Context) (int, error) {
+	if sm.metadataDs == nil {
+		return 0, nil
+	}
+
+	b, err := sm.metadataDs.Get(ctx, stateCacheSnapshotKey)
+	if xerrors.Is(err, dstore.ErrNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, xerrors.Errorf("reading state cache snapshot: %w", err)
+	}
+
+	var snapshot []stateCacheSnapshotEntry
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return 0, xerrors.Errorf("unmarshaling state cache snapshot: %w", err)
+	}
+
+	var loaded int
+	var evicted []stateCacheEntry
+	// The snapshot is ordered most recently used first, so insert in reverse.
+	for i := len(snapshot) - 1; i >= 0; i-- {
+		e := snapshot[i]
+		if !sm.hasStateCacheEntry(ctx, e.State, e.Receipts) {
+			continue
+		}
+		sm.stlk.Lock()
+		evicted = append(evicted, sm.stCache.put(cidsToKey(e.Key.Cids()), stateCacheEntry{key: e.Key, st: e.State, rec: e.Receipts})...)
+		sm.stlk.Unlock()
+		loaded++
+	}
+	sm.notifyEvicted(evicted)
+
+	return loaded, nil
+}
+
+func (sm *StateManager) hasStateCacheEntry(ctx context.Context, st, rec cid.Cid) bool {
+	if has, err := sm.cs.StateBlockstore().Has(ctx, st); err != nil || !has {
+		return false
+	}
+	if has, err := sm.cs.ChainBlockstore().Has(ctx, rec); err != nil || !has {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,436 @@
+// stm: #unit
+package stmgr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// testExecutor is an Executor which doesn't run any messages. Unless execFn is set, it returns
+// the first block CID of the tipset as both the state and the receipt root.
+type testExecutor struct {
+	lk     sync.Mutex
+	calls  int
+	execFn func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error)
+}
+
+var _ ExecutorWithOpts = (*testExecutor)(nil)
+
+func (e *testExecutor) NewActorRegistry() *vm.ActorRegistry {
+	return vm.NewActorRegistry()
+}
+
+func (e *testExecutor) ExecuteTipSet(ctx context.Context, sm *StateManager, ts *types.TipSet, em ExecMonitor, vmTracing bool) (cid.Cid, cid.Cid, error) {
+	return e.ExecuteTipSetWithOpts(ctx, sm, ts, em, vmTracing, ExecutorOpts{})
+}
+
+func (e *testExecutor) ExecuteTipSetWithOpts(ctx context.Context, sm *StateManager, ts *types.TipSet, em ExecMonitor, vmTracing bool, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+	e.lk.Lock()
+	e.calls++
+	e.lk.Unlock()
+
+	if e.execFn != nil {
+		return e.execFn(ctx, ts, em, opts)
+	}
+	return ts.Cids()[0], ts.Cids()[0], nil
+}
+
+func (e *testExecutor) callCount() int {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+	return e.calls
+}
+
+var testCid, testRctCid = mkTestCid("stmgr test"), mkTestCid("stmgr test receipts")
+
+func mkTestCid(data string) cid.Cid {
+	c, err := abi.CidBuilder.Sum([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// putTestBlock stores data under the CID returned by mkTestCid.
+func putTestBlock(t *testing.T, bs blockstore.Blockstore, data string) {
+	blk, err := blocks.NewBlockWithCid([]byte(data), mkTestCid(data))
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(context.Background(), blk))
+}
+
+func mkTestBlock(parent *types.TipSet, nonce uint64) *types.BlockHeader {
+	miner, err := address.NewIDAddress(1000 + nonce)
+	if err != nil {
+		panic(err)
+	}
+
+	var parents []cid.Cid
+	var height abi.ChainEpoch
+	var timestamp uint64
+	if parent != nil {
+		parents = parent.Cids()
+		height = parent.Height() + 1
+		timestamp = parent.MinTimestamp() + build.BlockDelaySecs
+	}
+
+	return &types.BlockHeader{
+		Miner:                 miner,
+		Ticket:                &types.Ticket{VRFProof: []byte(fmt.Sprintf("ticket-%d-%d", height, nonce))},
+		ElectionProof:         &types.ElectionProof{WinCount: 1, VRFProof: []byte(fmt.Sprintf("proof-%d", nonce))},
+		Parents:               parents,
+		ParentWeight:          types.NewInt(uint64(height)),
+		Height:                height,
+		ParentStateRoot:       testCid,
+		ParentMessageReceipts: testRctCid,
+		Messages:              testCid,
+		BLSAggregate:          &crypto.Signature{Type: crypto.SigTypeBLS},
+		BlockSig:              &crypto.Signature{Type: crypto.SigTypeBLS},
+		Timestamp:             timestamp,
+		ParentBaseFee:         types.NewInt(100),
+	}
+}
+
+// newTestChain persists a linear chain of n single-block tipsets (including genesis) and sets
+// the last one as the head.
+func newTestChain(t *testing.T, n int) (*store.ChainStore, []*types.TipSet) {
+	ctx := context.Background()
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, datastore.NewMapDatastore(), nil, nil)
+	t.Cleanup(func() { _ = cs.Close() })
+
+	genesis := mkTestBlock(nil, 0)
+	require.NoError(t, cs.SetGenesis(ctx, genesis))
+
+	tss := []*types.TipSet{mkTestTipSet(t, genesis)}
+	for i := 1; i < n; i++ {
+		ts := mkTestTipSet(t, mkTestBlock(tss[i-1], 0))
+		require.NoError(t, cs.PutTipSet(ctx, ts))
+		tss = append(tss, ts)
+	}
+	require.NoError(t, cs.ForceHeadSilent(ctx, tss[len(tss)-1]))
+
+	return cs, tss
+}
+
+func mkTestTipSet(t *testing.T, blks ...*types.BlockHeader) *types.TipSet {
+	ts, err := types.NewTipSet(blks)
+	require.NoError(t, err)
+	return ts
+}
+
+func newTestStateManager(t *testing.T, cs *store.ChainStore, exec Executor) *StateManager {
+	sm, err := NewStateManager(cs, exec, nil, nil, nil, datastore.NewMapDatastore(), index.DummyMsgIndex)
+	require.NoError(t, err)
+	return sm
+}
+
+func TestStateCacheOnEvict(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 6)
+	exec := &testExecutor{}
+	sm := newTestStateManager(t, cs, exec)
+	sm.stCache = newStateCache(2)
+
+	evicted := make(map[types.TipSetKey]int)
+	sm.OnEvict = func(key types.TipSetKey, st, rec cid.Cid) {
+		// the hook must be invoked without holding stlk
+		sm.stlk.Lock()
+		sm.stlk.Unlock() //nolint:staticcheck
+
+		require.Equal(t, key.Cids()[0], st)
+		evicted[key]++
+	}
+
+	for _, ts := range tss[1:] {
+		_, _, err := sm.TipSetState(ctx, ts)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, sm.stCache.len())
+	require.Len(t, evicted, 3)
+	for _, ts := range tss[1:4] {
+		require.Equal(t, 1, evicted[ts.Key()])
+	}
+	for _, ts := range tss[4:] {
+		require.NotContains(t, evicted, ts.Key())
+	}
+}
+
+func TestSetStateCacheSize(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 6)
+	exec := &testExecutor{}
+	sm := newTestStateManager(t, cs, exec)
+
+	var evicted []types.TipSetKey
+	sm.OnEvict = func(key types.TipSetKey, st, rec cid.Cid) {
+		evicted = append(evicted, key)
+	}
+
+	for _, ts := range tss[1:] {
+		_, _, err := sm.TipSetState(ctx, ts)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 5, sm.stCache.len())
+	require.Empty(t, evicted)
+
+	// Lowering the bound evicts the least recently used entries right away.
+	sm.SetStateCacheSize(2)
+	require.Equal(t, 2, sm.stCache.len())
+	require.Equal(t, []types.TipSetKey{tss[1].Key(), tss[2].Key(), tss[3].Key()}, evicted)
+}
+
+func TestPinTipSetState(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 7)
+	exec := &testExecutor{}
+	sm := newTestStateManager(t, cs, exec)
+	sm.stCache = newStateCache(2)
+
+	var evicted []types.TipSetKey
+	sm.OnEvict = func(key types.TipSetKey, st, rec cid.Cid) {
+		evicted = append(evicted, key)
+	}
+
+	// tss[1] is pinned once cached, tss[2] before it is ever computed.
+	_, _, err := sm.TipSetState(ctx, tss[1])
+	require.NoError(t, err)
+	sm.PinTipSetState(tss[1].Key())
+	sm.PinTipSetState(tss[2].Key())
+
+	for _, ts := range tss[2:] {
+		_, _, err := sm.TipSetState(ctx, ts)
+		require.NoError(t, err)
+	}
+
+	// Only unpinned entries were evicted, and the pins don't count towards the bound.
+	require.Equal(t, []types.TipSetKey{tss[3].Key(), tss[4].Key()}, evicted)
+	require.Equal(t, 4, sm.stCache.len())
+	for _, ts := range []*types.TipSet{tss[1], tss[2], tss[5], tss[6]} {
+		_, ok := sm.stCache.peek(cidsToKey(ts.Cids()))
+		require.True(t, ok, "height %d", ts.Height())
+	}
+
+	// Once unpinned, the entry is evicted like any other.
+	sm.UnpinTipSetState(tss[1].Key())
+	_, _, err = sm.TipSetState(ctx, tss[0])
+	require.NoError(t, err)
+	require.Equal(t, []types.TipSetKey{tss[3].Key(), tss[4].Key(), tss[1].Key(), tss[5].Key()}, evicted)
+	_, ok := sm.stCache.peek(cidsToKey(tss[2].Cids()))
+	require.True(t, ok)
+}
+
+func TestTipSetStateStatus(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 4)
+	exec := &testExecutor{}
+	sm := newTestStateManager(t, cs, exec)
+
+	status, err := sm.TipSetStateStatus(ctx, tss[1])
+	require.NoError(t, err)
+	require.Equal(t, StatusMustExecute, status)
+
+	// Once the state and receipts referenced by the child tipset are available, the fast path
+	// applies.
+	putTestBlock(t, cs.StateBlockstore(), "stmgr test")
+	putTestBlock(t, cs.ChainBlockstore(), "stmgr test receipts")
+
+	status, err = sm.TipSetStateStatus(ctx, tss[1])
+	require.NoError(t, err)
+	require.Equal(t, StatusLookupable, status)
+
+	// The head has no child to look the state up from.
+	head := tss[len(tss)-1]
+	status, err = sm.TipSetStateStatus(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, StatusMustExecute, status)
+
+	_, _, err = sm.TipSetState(ctx, head)
+	require.NoError(t, err)
+
+	status, err = sm.TipSetStateStatus(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, StatusCached, status)
+
+	// Only the explicit TipSetState call executed anything.
+	require.Equal(t, 1, exec.callCount())
+}
+
+func TestTipSetStateStatusLookupOrder(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 5)
+	exec := &testExecutor{}
+	ds := datastore.NewMapDatastore()
+	sm, err := NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	require.NoError(t, sm.EnablePersistentStateCache(ctx, 2))
+	head := tss[len(tss)-1]
+
+	_, _, err = sm.TipSetState(ctx, head)
+	require.NoError(t, err)
+
+	// After a restart, the state is only in the persistent cache.
+	restarted, err := NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	require.NoError(t, restarted.EnablePersistentStateCache(ctx, 2))
+	status, err := restarted.TipSetStateStatus(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, StatusCached, status)
+	_, _, err = restarted.TipSetState(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.callCount())
+
+	// Replay checkpoints are only used by calls asking for them.
+	ds = datastore.NewMapDatastore()
+	sm, err = NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	opts := TipSetStateOpts{DiscardState: true, CheckpointInterval: 2}
+	_, _, err = sm.TipSetStateWithOpts(ctx, head, opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, exec.callCount())
+
+	restarted, err = NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	status, err = restarted.TipSetStateStatusWithOpts(ctx, head, opts)
+	require.NoError(t, err)
+	require.Equal(t, StatusCached, status)
+	status, err = restarted.TipSetStateStatus(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, StatusMustExecute, status)
+
+	// Below the state retention boundary, the tipset can't be executed at all.
+	require.NoError(t, cs.SetStatePrunedBefore(ctx, 3))
+	status, err = restarted.TipSetStateStatus(ctx, tss[1])
+	require.NoError(t, err)
+	require.Equal(t, StatusPruned, status)
+	_, _, err = restarted.TipSetState(ctx, tss[1])
+	var pruned *api.ErrStatePruned
+	require.ErrorAs(t, err, &pruned)
+	require.Equal(t, 2, exec.callCount())
+}
+
+func TestCacheKeyRoundTrip(t *testing.T) {
+	_, tss := newTestChain(t, 2)
+	cids := []cid.Cid{tss[1].Cids()[0], tss[0].Cids()[0], testCid}
+
+	decoded, err := keyToCids(cidsToKey(cids))
+	require.NoError(t, err)
+	require.Equal(t, cids, decoded)
+
+	_, err = keyToCids("not a cid")
+	require.Error(t, err)
+}
+
+func TestStateCacheSnapshot(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 5)
+	exec := &testExecutor{}
+	ds := datastore.NewMapDatastore()
+	sm, err := NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	require.NoError(t, sm.EnablePersistentStateCache(ctx, 2))
+
+	for _, ts := range tss[1:4] {
+		_, _, err := sm.TipSetState(ctx, ts)
+		require.NoError(t, err)
+	}
+	// An entry whose state has since been dropped from the blockstore.
+	sm.stCache.put(cidsToKey(tss[4].Cids()), stateCacheEntry{key: tss[4].Key(), st: testCid, rec: testRctCid})
+
+	n, err := sm.FlushStateCache(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	restarted, err := NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	n, err = restarted.LoadStateCacheSnapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	for _, ts := range tss[1:4] {
+		status, err := restarted.TipSetStateStatus(ctx, ts)
+		require.NoError(t, err)
+		require.Equal(t, StatusCached, status)
+	}
+	require.Equal(t, 3, exec.callCount())
+
+	// The snapshot holds the whole cache, bounded by its size.
+	sm.SetStateCacheSize(2)
+	for _, ts := range tss[2:4] {
+		_, _, err := sm.TipSetState(ctx, ts)
+		require.NoError(t, err)
+	}
+	n, err = sm.FlushStateCache(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// A flush past its deadline doesn't hold up the shutdown, and leaves the previous snapshot.
+	expired, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = sm.FlushStateCache(expired)
+	require.ErrorIs(t, err, context.Canceled)
+
+	restarted, err = NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	n, err = restarted.LoadStateCacheSnapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestPersistentStateCache(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 5)
+	exec := &testExecutor{}
+	ds := datastore.NewMapDatastore()
+	sm, err := NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	require.NoError(t, sm.EnablePersistentStateCache(ctx, 2))
+
+	for _, ts := range tss[1:4] {
+		_, _, err := sm.TipSetState(ctx, ts)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, exec.callCount())
+
+	// Only the two most recent results survive a restart.
+	restarted, err := NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	require.NoError(t, restarted.EnablePersistentStateCache(ctx, 2))
+	for _, ts := range tss[2:4] {
+		st, _, err := restarted.TipSetState(ctx, ts)
+		require.NoError(t, err)
+		require.Equal(t, ts.Cids()[0], st)
+	}
+	require.Equal(t, 3, exec.callCount())
+
+	_, _, err = restarted.TipSetState(ctx, tss[1])
+	require.NoError(t, err)
+	require.Equal(t, 4, exec.callCount())
+
+	restarted.PurgePersistentStateCache()
+	restarted, err = NewStateManager(cs, exec, nil, nil, nil, ds, index.DummyMsgIndex)
+	require.NoError(t, err)
+	require.NoError(t, restarted.EnablePersistentStateCache(ctx, 2))
+	_, _, err = restarted.TipSetState(ctx, tss[3])
+	require.NoError(t, err)
+	require.Equal(t, 5, exec.callCount())
+}
@@ -12,13 +12,18 @@ import (
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/manifest"
 	"github.com/filecoin-project/go-state-types/network"
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/account"
 	"github.com/filecoin-project/lotus/chain/rand"
 	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -31,7 +36,19 @@ var ErrExpensiveFork = errors.New("refusing explicit call due to state fork at e
 // tipset's parent. In the presence of null blocks, the height at which the message is invoked may
 // be less than the specified tipset.
 func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.TipSet) (*api.InvocResult, error) {
-	// Copy the message as we modify it below.
+	return sm.CallWithStateOverrides(ctx, msg, ts, nil)
+}
+
+// CallWithStateOverrides is like Call, but applies the given actor overrides to a temporary copy
+// of the state before executing the message. Overridden state heads must be in the blockstore.
+func (sm *StateManager) CallWithStateOverrides(ctx context.Context, msg *types.Message, ts *types.TipSet, overrides map[address.Address]api.ActorOverride) (*api.InvocResult, error) {
+	msg = withCallDefaults(msg)
+	return firstResult(sm.callInternal(ctx, []*types.Message{msg}, nil, ts, cid.Undef, sm.GetNetworkVersion, false, false, overrides))
+}
+
+// withCallDefaults returns a copy of msg with unset gas parameters and value defaulted as Call
+// expects them.
+func withCallDefaults(msg *types.Message) *types.Message {
 	msgCopy := *msg
 	msg = &msgCopy
 
@@ -47,13 +64,12 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 	if msg.Value == types.EmptyInt {
 		msg.Value = types.NewInt(0)
 	}
-
-	return sm.callInternal(ctx, msg, nil, ts, cid.Undef, sm.GetNetworkVersion, false, false)
+	return msg
 }
 
 // CallWithGas calculates the state for a given tipset, and then applies the given message on top of that state.
 func (sm *StateManager) CallWithGas(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet, applyTsMessages bool) (*api.InvocResult, error) {
-	return sm.callInternal(ctx, msg, priorMsgs, ts, cid.Undef, sm.GetNetworkVersion, true, applyTsMessages)
+	return firstResult(sm.callInternal(ctx, []*types.Message{msg}, priorMsgs, ts, cid.Undef, sm.GetNetworkVersion, true, applyTsMessages, nil))
 }
 
 // CallAtStateAndVersion allows you to specify a message to execute on the given stateCid and network version.
@@ -65,19 +81,46 @@ func (sm *StateManager) CallAtStateAndVersion(ctx context.Context, msg *types.Me
 		return v
 	}
 
-	return sm.callInternal(ctx, msg, nil, nil, stateCid, nvGetter, true, false)
+	return firstResult(sm.callInternal(ctx, []*types.Message{msg}, nil, nil, stateCid, nvGetter, true, false, nil))
+}
+
+// CallBatch applies the given messages in order, like Call, to a single buffered copy of the
+// tipset's parent state, so that each message observes the effects of the previous ones. If a
+// message can't be applied, the results of the messages applied so far are returned with the error.
+func (sm *StateManager) CallBatch(ctx context.Context, msgs []*types.Message, ts *types.TipSet) ([]*api.InvocResult, error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	defaulted := make([]*types.Message, len(msgs))
+	for i, msg := range msgs {
+		defaulted[i] = withCallDefaults(msg)
+	}
+	return sm.callInternal(ctx, defaulted, nil, ts, cid.Undef, sm.GetNetworkVersion, false, false, nil)
+}
+
+func firstResult(res []*api.InvocResult, err error) (*api.InvocResult, error) {
+	if len(res) == 0 {
+		return nil, err
+	}
+	return res[0], err
 }
 
 //   - If no tipset is specified, the first tipset without an expensive migration or one in its parent is used.
 //   - If executing a message at a given tipset or its parent would trigger an expensive migration, the call will
 //     fail with ErrExpensiveFork.
-func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet, stateCid cid.Cid, nvGetter rand.NetworkVersionGetter, checkGas, applyTsMessages bool) (*api.InvocResult, error) {
+func (sm *StateManager) callInternal(ctx context.Context, msgs []*types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet, stateCid cid.Cid, nvGetter rand.NetworkVersionGetter, checkGas, applyTsMessages bool, stateOverrides map[address.Address]api.ActorOverride) ([]*api.InvocResult, error) {
 	ctx, span := trace.StartSpan(ctx, "statemanager.callInternal")
 	defer span.End()
 
-	// Copy the message as we'll be modifying the nonce.
-	msgCopy := *msg
-	msg = &msgCopy
+	// Copy the messages as we'll be modifying their nonces.
+	msgs = append([]*types.Message(nil), msgs...)
+	senders := make(map[address.Address]struct{}, len(msgs))
+	for i := range msgs {
+		msgCopy := *msgs[i]
+		msgs[i] = &msgCopy
+		senders[msgCopy.From] = struct{}{}
+	}
 
 	var err error
 	var pts *types.TipSet
@@ -128,7 +171,7 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 		var filteredTsMsgs []types.ChainMsg
 		for _, tsMsg := range tsMsgs {
 			//TODO we should technically be normalizing the filecoin address of from when we compare here
-			if tsMsg.VMMessage().From == msg.VMMessage().From {
+			if _, ok := senders[tsMsg.VMMessage().From]; ok {
 				filteredTsMsgs = append(filteredTsMsgs, tsMsg)
 			}
 		}
@@ -141,11 +184,11 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 		return nil, fmt.Errorf("failed to handle fork: %w", err)
 	}
 
-	if span.IsRecordingEvents() {
+	if span.IsRecordingEvents() && len(msgs) == 1 {
 		span.AddAttributes(
-			trace.Int64Attribute("gas_limit", msg.GasLimit),
-			trace.StringAttribute("gas_feecap", msg.GasFeeCap.String()),
-			trace.StringAttribute("value", msg.Value.String()),
+			trace.Int64Attribute("gas_limit", msgs[0].GasLimit),
+			trace.StringAttribute("gas_feecap", msgs[0].GasFeeCap.String()),
+			trace.StringAttribute("value", msgs[0].Value.String()),
 		)
 	}
 
@@ -176,94 +219,188 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 		}
 	}
 
-	// We flush to get the VM's view of the state tree after applying the above messages
-	// This is needed to get the correct nonce from the actor state to match the VM
-	stateCid, err = vmi.Flush(ctx)
-	if err != nil {
-		return nil, xerrors.Errorf("flushing vm: %w", err)
-	}
-
-	stTree, err := state.LoadStateTree(cbor.NewCborStore(buffStore), stateCid)
-	if err != nil {
-		return nil, xerrors.Errorf("loading state tree: %w", err)
-	}
-
-	fromActor, err := stTree.GetActor(msg.From)
-	if err != nil {
-		return nil, xerrors.Errorf("call raw get actor: %s", err)
-	}
-
-	msg.Nonce = fromActor.Nonce
-
-	// If the fee cap is set to zero, make gas free.
-	if msg.GasFeeCap.NilOrZero() {
-		// Now estimate with a new VM with no base fee.
-		vmopt.BaseFee = big.Zero()
+	if len(stateOverrides) > 0 {
+		// We flush to get the VM's view of the state tree after applying the above messages.
+		stateCid, err = vmi.Flush(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("flushing vm: %w", err)
+		}
+		stTree, err := state.LoadStateTree(cbor.NewCborStore(buffStore), stateCid)
+		if err != nil {
+			return nil, xerrors.Errorf("loading state tree: %w", err)
+		}
+		if err := applyActorOverrides(ctx, stTree, vmopt.NetworkVersion, stateOverrides); err != nil {
+			return nil, xerrors.Errorf("applying state overrides: %w", err)
+		}
+		stateCid, err = stTree.Flush(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("flushing state overrides: %w", err)
+		}
 		vmopt.StateBase = stateCid
-
 		vmi, err = sm.newVM(ctx, vmopt)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to set up estimation vm: %w", err)
+			return nil, xerrors.Errorf("failed to set up vm with state overrides: %w", err)
 		}
 	}
 
-	var ret *vm.ApplyRet
-	var gasInfo api.MsgGasCost
-	if checkGas {
-		fromKey, err := sm.ResolveToDeterministicAddress(ctx, msg.From, ts)
+	out := make([]*api.InvocResult, 0, len(msgs))
+	for _, msg := range msgs {
+		// We flush to get the VM's view of the state tree after applying the above messages
+		// This is needed to get the correct nonce from the actor state to match the VM
+		stateCid, err = vmi.Flush(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("flushing vm: %w", err)
+		}
+
+		stTree, err := state.LoadStateTree(cbor.NewCborStore(buffStore), stateCid)
 		if err != nil {
-			return nil, xerrors.Errorf("could not resolve key: %w", err)
+			return nil, xerrors.Errorf("loading state tree: %w", err)
 		}
 
-		var msgApply types.ChainMsg
-
-		switch fromKey.Protocol() {
-		case address.BLS:
-			msgApply = msg
-		case address.SECP256K1:
-			msgApply = &types.SignedMessage{
-				Message: *msg,
-				Signature: crypto.Signature{
-					Type: crypto.SigTypeSecp256k1,
-					Data: make([]byte, 65),
-				},
+		fromActor, err := stTree.GetActor(msg.From)
+		if err != nil {
+			return nil, xerrors.Errorf("call raw get actor: %s", err)
+		}
+
+		msg.Nonce = fromActor.Nonce
+
+		// If the fee cap is set to zero, make gas free.
+		if msg.GasFeeCap.NilOrZero() {
+			// Now estimate with a new VM with no base fee.
+			vmopt.BaseFee = big.Zero()
+			vmopt.StateBase = stateCid
+
+			vmi, err = sm.newVM(ctx, vmopt)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to set up estimation vm: %w", err)
+			}
+		}
+
+		var ret *vm.ApplyRet
+		var gasInfo api.MsgGasCost
+		if checkGas {
+			fromKey, err := sm.ResolveToDeterministicAddress(ctx, msg.From, ts)
+			if err != nil {
+				return nil, xerrors.Errorf("could not resolve key: %w", err)
 			}
-		case address.Delegated:
-			msgApply = &types.SignedMessage{
-				Message: *msg,
-				Signature: crypto.Signature{
-					Type: crypto.SigTypeDelegated,
-					Data: make([]byte, 65),
-				},
+
+			var msgApply types.ChainMsg
+
+			switch fromKey.Protocol() {
+			case address.BLS:
+				msgApply = msg
+			case address.SECP256K1:
+				msgApply = &types.SignedMessage{
+					Message: *msg,
+					Signature: crypto.Signature{
+						Type: crypto.SigTypeSecp256k1,
+						Data: make([]byte, 65),
+					},
+				}
+			case address.Delegated:
+				msgApply = &types.SignedMessage{
+					Message: *msg,
+					Signature: crypto.Signature{
+						Type: crypto.SigTypeDelegated,
+						Data: make([]byte, 65),
+					},
+				}
 			}
+
+			ret, err = vmi.ApplyMessage(ctx, msgApply)
+			if err != nil {
+				return nil, xerrors.Errorf("gas estimation failed: %w", err)
+			}
+			gasInfo = MakeMsgGasCost(msg, ret)
+		} else {
+			ret, err = vmi.ApplyImplicitMessage(ctx, msg)
+			if err != nil && ret == nil {
+				return nil, xerrors.Errorf("apply message failed: %w", err)
+			}
+		}
+
+		var errs string
+		if ret.ActorErr != nil {
+			errs = ret.ActorErr.Error()
 		}
 
-		ret, err = vmi.ApplyMessage(ctx, msgApply)
+		out = append(out, &api.InvocResult{
+			MsgCid:         msg.Cid(),
+			Msg:            msg,
+			MsgRct:         &ret.MessageReceipt,
+			GasCost:        gasInfo,
+			ExecutionTrace: ret.ExecutionTrace,
+			Error:          errs,
+			Duration:       ret.Duration,
+		})
 		if err != nil {
-			return nil, xerrors.Errorf("gas estimation failed: %w", err)
+			return out, err
 		}
-		gasInfo = MakeMsgGasCost(msg, ret)
-	} else {
-		ret, err = vmi.ApplyImplicitMessage(ctx, msg)
-		if err != nil && ret == nil {
-			return nil, xerrors.Errorf("apply message failed: %w", err)
+	}
+
+	return out, nil
+}
+
+func applyActorOverrides(ctx context.Context, st *state.StateTree, nv network.Version, overrides map[address.Address]api.ActorOverride) error {
+	for addr, o := range overrides {
+		act, err := st.GetActor(addr)
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			act, err = newOverrideAccountActor(ctx, st, nv, addr)
+		}
+		if err != nil {
+			return xerrors.Errorf("loading actor %s: %w", addr, err)
+		}
+
+		if o.Balance != nil {
+			act.Balance = *o.Balance
 		}
+		if o.Nonce != nil {
+			act.Nonce = *o.Nonce
+		}
+		if o.Head != nil {
+			act.Head = *o.Head
+		}
+		if err := st.SetActor(addr, act); err != nil {
+			return xerrors.Errorf("setting actor %s: %w", addr, err)
+		}
+	}
+	return nil
+}
+
+// newOverrideAccountActor registers addr with the init actor and returns a new account actor for
+// it, with a zero balance.
+func newOverrideAccountActor(ctx context.Context, st *state.StateTree, nv network.Version, addr address.Address) (*types.Actor, error) {
+	if addr.Protocol() != address.BLS && addr.Protocol() != address.SECP256K1 {
+		return nil, xerrors.Errorf("no actor for %s, and only BLS and secp256k1 account actors can be created", addr)
 	}
 
-	var errs string
-	if ret.ActorErr != nil {
-		errs = ret.ActorErr.Error()
+	av, err := actorstypes.VersionForNetwork(nv)
+	if err != nil {
+		return nil, err
+	}
+	code, ok := actors.GetActorCodeID(av, manifest.AccountKey)
+	if !ok {
+		return nil, xerrors.Errorf("failed to get account actor code ID for actors version %d", av)
+	}
+	ast, err := account.MakeState(adt.WrapStore(ctx, st.Store), av, addr)
+	if err != nil {
+		return nil, err
+	}
+	head, err := st.Store.Put(ctx, ast.GetState())
+	if err != nil {
+		return nil, err
 	}
 
-	return &api.InvocResult{
-		MsgCid:         msg.Cid(),
-		Msg:            msg,
-		MsgRct:         &ret.MessageReceipt,
-		GasCost:        gasInfo,
-		ExecutionTrace: ret.ExecutionTrace,
-		Error:          errs,
-		Duration:       ret.Duration,
-	}, err
+	if _, err := st.RegisterNewAddress(addr); err != nil {
+		return nil, xerrors.Errorf("registering address: %w", err)
+	}
+
+	return &types.Actor{
+		Code:    code,
+		Head:    head,
+		Balance: big.Zero(),
+		Address: &addr,
+	}, nil
 }
 
 var errHaltExecution = fmt.Errorf("halt")
@@ -284,3 +421,69 @@ func (sm *StateManager) Replay(ctx context.Context, ts *types.TipSet, mcid cid.C
 
 	return finder.outm, finder.outr, nil
 }
+
+// applyMessageOverrides returns a copy of msg, modified by o.
+func applyMessageOverrides(o *api.MessageOverrides, msg *types.Message) *types.Message {
+	out := *msg
+	if o.GasLimit != nil {
+		out.GasLimit = *o.GasLimit
+	}
+	if o.GasFeeCap != nil {
+		out.GasFeeCap = *o.GasFeeCap
+	}
+	if o.Value != nil {
+		out.Value = *o.Value
+	}
+	if o.Params != nil {
+		out.Params = o.Params
+	}
+	return &out
+}
+
+// ReplayWithOverrides re-executes ts, like Replay, but applies the message mcid with the given
+// overrides instead of the message included in the chain. The messages preceding it are applied
+// as they were. The resulting state is discarded.
+func (sm *StateManager) ReplayWithOverrides(ctx context.Context, ts *types.TipSet, mcid cid.Cid, overrides api.MessageOverrides) (*api.InvocResult, error) {
+	var finder messageFinder
+	opts := ExecutorOpts{
+		StateBlockstore: blockstore.NewTieredBstore(sm.cs.StateBlockstore(), blockstore.NewMemorySync()),
+		MessageOverride: func(cm types.ChainMsg) types.ChainMsg {
+			if cm.Cid() != mcid && cm.VMMessage().Cid() != mcid {
+				return cm
+			}
+			// Signatures aren't checked by the VM, and secp messages are charged for the
+			// signature's size, so the original signature is kept.
+			msg := applyMessageOverrides(&overrides, cm.VMMessage())
+			var out types.ChainMsg = msg
+			if smsg, ok := cm.(*types.SignedMessage); ok {
+				out = &types.SignedMessage{Message: *msg, Signature: smsg.Signature}
+			}
+			finder.mcid = out.Cid()
+			return out
+		},
+	}
+
+	_, _, err := sm.ExecuteTipSetWithOpts(ctx, ts, &finder, true, opts)
+	if err != nil && !xerrors.Is(err, errHaltExecution) {
+		return nil, xerrors.Errorf("unexpected error during execution: %w", err)
+	}
+
+	if finder.outr == nil {
+		return nil, xerrors.Errorf("given message not found in tipset")
+	}
+
+	ir := &api.InvocResult{
+		MsgCid:         finder.mcid,
+		Msg:            finder.outm,
+		MsgRct:         &finder.outr.MessageReceipt,
+		ExecutionTrace: finder.outr.ExecutionTrace,
+		Duration:       finder.outr.Duration,
+	}
+	if finder.outr.ActorErr != nil {
+		ir.Error = finder.outr.ActorErr.Error()
+	}
+	if finder.outr.GasCosts != nil {
+		ir.GasCost = MakeMsgGasCost(finder.outm, finder.outr)
+	}
+	return ir, nil
+}
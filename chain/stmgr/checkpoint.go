@@ -0,0 +1,88 @@
+package stmgr
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var replayCheckpointKey = dstore.NewKey("/stmgr/replay-checkpoint")
+
+// ReplayCheckpoint is the last tipset state persisted by TipSetStateWithOpts because of a
+// CheckpointInterval. A replay interrupted after it was written can resume from Height.
+type ReplayCheckpoint struct {
+	TipSet   types.TipSetKey
+	Height   abi.ChainEpoch
+	State    cid.Cid
+	Receipts cid.Cid
+}
+
+// LatestReplayCheckpoint returns the last checkpoint written, or nil if there is none.
+func (sm *StateManager) LatestReplayCheckpoint(ctx context.Context) (*ReplayCheckpoint, error) {
+	if sm.metadataDs == nil {
+		return nil, nil
+	}
+
+	b, err := sm.metadataDs.Get(ctx, replayCheckpointKey)
+	if err == dstore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("reading replay checkpoint: %w", err)
+	}
+
+	var cp ReplayCheckpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, xerrors.Errorf("unmarshaling replay checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+func (sm *StateManager) writeReplayCheckpoint(ctx context.Context, ts *types.TipSet, st, rec cid.Cid) error {
+	b, err := json.Marshal(ReplayCheckpoint{
+		TipSet:   ts.Key(),
+		Height:   ts.Height(),
+		State:    st,
+		Receipts: rec,
+	})
+	if err != nil {
+		return err
+	}
+	return sm.metadataDs.Put(ctx, replayCheckpointKey, b)
+}
+
+// isReplayCheckpoint returns true if executing ts crosses a multiple of interval, counting from
+// the parent tipset, so that null rounds don't cause checkpoints to be skipped.
+func (sm *StateManager) isReplayCheckpoint(ctx context.Context, ts *types.TipSet, interval abi.ChainEpoch) bool {
+	if interval <= 0 || sm.metadataDs == nil {
+		return false
+	}
+
+	parent, err := sm.cs.GetTipSetFromKey(ctx, ts.Parents())
+	if err != nil {
+		log.Warnw("failed to load parent tipset for replay checkpoint", "tipset", ts.Key(), "error", err)
+		return false
+	}
+	return ts.Height()/interval != parent.Height()/interval
+}
+
+// replayCheckpointLookup returns the state of ts if it is the latest replay checkpoint and its
+// state is still in the blockstore.
+func (sm *StateManager) replayCheckpointLookup(ctx context.Context, ts *types.TipSet) (cid.Cid, cid.Cid, bool) {
+	cp, err := sm.LatestReplayCheckpoint(ctx)
+	if err != nil {
+		log.Errorw("failed to read replay checkpoint", "error", err)
+		return cid.Undef, cid.Undef, false
+	}
+	if cp == nil || cp.TipSet != ts.Key() || !sm.hasStateCacheEntry(ctx, cp.State, cp.Receipts) {
+		return cid.Undef, cid.Undef, false
+	}
+	return cp.State, cp.Receipts, true
+}
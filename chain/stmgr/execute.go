@@ -1,18 +1,54 @@
 package stmgr
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"runtime/debug"
+	"sort"
 
 	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
 	"go.opencensus.io/trace"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
+// TipSetStateOpts controls how TipSetStateWithOpts computes the state of a tipset.
+type TipSetStateOpts struct {
+	// DiscardState executes the tipset against a temporary buffer instead of the state
+	// blockstore, so the resulting state tree is not persisted. Results computed this way are
+	// never written to the state cache. Cached and chain-derived results are still returned.
+	DiscardState bool
+	// NoCacheWrite skips writing the result to the state cache. The cache and the chain are still
+	// consulted, and concurrent calls for the same tipset still wait on each other.
+	NoCacheWrite bool
+	// Progress, if set, is called after every message applied while executing the tipset. It is
+	// not called if the result is cached or derived from the chain.
+	Progress func(ExecutionProgress)
+	// CheckpointInterval, if non-zero, persists the state of every tipset whose execution crosses
+	// a multiple of CheckpointInterval epochs, even if DiscardState is set, and records it as the
+	// latest replay checkpoint. Range replays use it to resume after being interrupted.
+	CheckpointInterval abi.ChainEpoch
+}
+
 func (sm *StateManager) TipSetState(ctx context.Context, ts *types.TipSet) (st cid.Cid, rec cid.Cid, err error) {
+	return sm.TipSetStateWithOpts(ctx, ts, TipSetStateOpts{})
+}
+
+func (sm *StateManager) TipSetStateWithOpts(ctx context.Context, ts *types.TipSet, opts TipSetStateOpts) (st cid.Cid, rec cid.Cid, err error) {
 	ctx, span := trace.StartSpan(ctx, "tipSetState")
 	defer span.End()
 	if span.IsRecordingEvents() {
@@ -32,23 +68,28 @@ func (sm *StateManager) TipSetState(ctx context.Context, ts *types.TipSet) (st c
 			return cid.Undef, cid.Undef, ctx.Err()
 		}
 	}
-	cached, ok := sm.stCache[ck]
+	cached, ok := sm.stCache.get(ck)
 	if ok {
 		sm.stlk.Unlock()
 		span.AddAttributes(trace.BoolAttribute("cache", true))
-		return cached[0], cached[1], nil
+		return cached.st, cached.rec, nil
 	}
 	ch := make(chan struct{})
 	sm.compWait[ck] = ch
 
+	// Only results backed by a persisted state tree may be cached.
+	writeCache := !opts.NoCacheWrite
+
 	defer func() {
+		var evicted []stateCacheEntry
 		sm.stlk.Lock()
 		delete(sm.compWait, ck)
-		if st != cid.Undef {
-			sm.stCache[ck] = []cid.Cid{st, rec}
+		if st != cid.Undef && writeCache {
+			evicted = sm.stCache.put(ck, stateCacheEntry{key: ts.Key(), st: st, rec: rec})
 		}
 		sm.stlk.Unlock()
 		close(ch)
+		sm.notifyEvicted(evicted)
 	}()
 
 	sm.stlk.Unlock()
@@ -69,37 +110,214 @@ func (sm *StateManager) TipSetState(ctx context.Context, ts *types.TipSet) (st c
 		return st, rec, nil
 	}
 
-	st, rec, err = sm.tsExec.ExecuteTipSet(ctx, sm, ts, sm.tsExecMonitor, false)
+	if st, rec, found := sm.persistentStateLookup(ctx, ts); found {
+		return st, rec, nil
+	}
+
+	if opts.CheckpointInterval > 0 {
+		if st, rec, found := sm.replayCheckpointLookup(ctx, ts); found {
+			return st, rec, nil
+		}
+	}
+
+	// executing the tipset needs its parent state
+	if err := sm.cs.CheckStatePruned(ts.Height()); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	if err := sm.execBreaker.check(ck); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	checkpoint := sm.isReplayCheckpoint(ctx, ts, opts.CheckpointInterval)
+	discard := opts.DiscardState && !checkpoint
+	if discard {
+		writeCache = false
+	}
+	em, done := sm.trackProgress(ctx, ts, sm.execMonitor(), opts.Progress)
+	defer done()
+	st, rec, err = recoverExecution(ts, func() (cid.Cid, cid.Cid, error) {
+		if discard {
+			buf := blockstore.NewTieredBstore(sm.cs.StateBlockstore(), blockstore.NewMemorySync())
+			return sm.ExecuteTipSetWithOpts(ctx, ts, em, false, ExecutorOpts{StateBlockstore: buf})
+		}
+		return sm.tsExec.ExecuteTipSet(ctx, sm, ts, em, false)
+	})
+	// Failures caused by the caller giving up say nothing about the tipset.
+	if err == nil || ctx.Err() == nil {
+		sm.execBreaker.record(ck, err)
+	}
 	if err != nil {
 		return cid.Undef, cid.Undef, err
 	}
 
+	if writeCache && sm.pstCache != nil {
+		if err := sm.pstCache.put(ctx, ts.Key(), st, rec); err != nil {
+			log.Errorw("failed to write persistent state cache", "tipset", ts.Key(), "error", err)
+		}
+	}
+
+	if checkpoint {
+		if err := sm.writeReplayCheckpoint(ctx, ts, st, rec); err != nil {
+			log.Errorw("failed to write replay checkpoint", "tipset", ts.Key(), "error", err)
+		}
+	}
+
 	return st, rec, nil
 }
 
+// ErrTipSetExecutionPanic is returned by TipSetState when executing a tipset panicked and the
+// panic was recovered.
+var ErrTipSetExecutionPanic = errors.New("tipset execution panicked")
+
+// recoverExecutionPanics enables recovering from panics during tipset execution on mainnet
+// builds. Other builds always recover.
+var recoverExecutionPanics = false
+
+// recoverExecution runs exec, converting a panic into an error wrapping ErrTipSetExecutionPanic
+// when recovery is enabled.
+func recoverExecution(ts *types.TipSet, exec func() (cid.Cid, cid.Cid, error)) (st cid.Cid, rec cid.Cid, err error) {
+	if build.BuildType == build.BuildMainnet && !recoverExecutionPanics {
+		return exec()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorw("recovered from panic executing tipset", "tipset", ts.Key(), "height", ts.Height(), "panic", r)
+			st, rec = cid.Undef, cid.Undef
+			err = xerrors.Errorf("%w: tipset %s at height %d: %v\n%s", ErrTipSetExecutionPanic, ts.Key(), ts.Height(), r, debug.Stack())
+		}
+	}()
+	return exec()
+}
+
+// StateStatus describes what it would take to compute the state of a tipset.
+type StateStatus int
+
+const (
+	// StatusMustExecute means the tipset would have to be executed.
+	StatusMustExecute StateStatus = iota
+	// StatusCached means the state is in one of the state caches: the in-memory tipset state
+	// cache, the persistent state cache, or, for calls using them, the replay checkpoints.
+	StatusCached
+	// StatusLookupable means the state can be read off the chain without executing the tipset.
+	StatusLookupable
+	// StatusPruned means the state isn't available and the tipset can't be executed, as its parent
+	// state is below the state retention boundary. TipSetState would fail with an
+	// *api.ErrStatePruned.
+	StatusPruned
+	// StatusBreakerOpen means the tipset would have to be executed, but the execution breaker
+	// refuses to until its cooldown has passed. TipSetState would fail with
+	// ErrExecutionBreakerOpen.
+	StatusBreakerOpen
+)
+
+func (s StateStatus) String() string {
+	switch s {
+	case StatusMustExecute:
+		return "must-execute"
+	case StatusCached:
+		return "cached"
+	case StatusLookupable:
+		return "lookupable"
+	case StatusPruned:
+		return "pruned"
+	case StatusBreakerOpen:
+		return "breaker-open"
+	default:
+		return fmt.Sprintf("StateStatus(%d)", int(s))
+	}
+}
+
+// TipSetStateStatus reports whether TipSetState would be served from a cache, from the chain,
+// or would require executing the tipset. It never executes anything itself. Tipsets TipSetState
+// would refuse to execute are reported as StatusPruned or StatusBreakerOpen.
+func (sm *StateManager) TipSetStateStatus(ctx context.Context, ts *types.TipSet) (StateStatus, error) {
+	return sm.TipSetStateStatusWithOpts(ctx, ts, TipSetStateOpts{})
+}
+
+// TipSetStateStatusWithOpts is like TipSetStateStatus, for a TipSetStateWithOpts call with the
+// given options. It looks the state up in the same places, in the same order.
+func (sm *StateManager) TipSetStateStatusWithOpts(ctx context.Context, ts *types.TipSet, opts TipSetStateOpts) (StateStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return StatusMustExecute, err
+	}
+
+	ck := cidsToKey(ts.Cids())
+	sm.stlk.Lock()
+	_, ok := sm.stCache.peek(ck)
+	sm.stlk.Unlock()
+	if ok {
+		return StatusCached, nil
+	}
+
+	// The genesis state is read directly from the genesis block.
+	if ts.Height() == 0 {
+		return StatusLookupable, nil
+	}
+
+	if _, _, found := tryLookupTipsetState(ctx, sm.cs, ts); found {
+		return StatusLookupable, nil
+	}
+
+	if _, _, found := sm.persistentStateLookup(ctx, ts); found {
+		return StatusCached, nil
+	}
+
+	if opts.CheckpointInterval > 0 {
+		if _, _, found := sm.replayCheckpointLookup(ctx, ts); found {
+			return StatusCached, nil
+		}
+	}
+
+	if sm.cs.CheckStatePruned(ts.Height()) != nil {
+		return StatusPruned, nil
+	}
+
+	if sm.execBreaker.check(ck) != nil {
+		return StatusBreakerOpen, nil
+	}
+
+	return StatusMustExecute, nil
+}
+
+// Reasons logged when tryLookupTipsetState can't read the state of a tipset off the chain.
+const (
+	lookupMissNoChild  = "no child at height+1"
+	lookupMissFork     = "fork mismatch"
+	lookupMissState    = "state missing"
+	lookupMissReceipts = "receipts missing"
+)
+
+// tipsetStateLookupWindow is the number of epochs above a tipset that tryLookupTipsetState
+// searches for validated children among the blocks recently seen by the chain store, when the
+// tipset isn't on the canonical chain.
+var tipsetStateLookupWindow abi.ChainEpoch = 5
+
 // Try to lookup a state & receipt CID for a given tipset by walking the chain instead of executing
 // it. This will only successfully return the state/receipt CIDs if they're found in the state
 // store.
 //
+// If the tipset isn't on the canonical chain, the validated blocks recently seen by the chain
+// store are searched for a child, so that the state of re-orged away tipsets isn't recomputed.
+//
 // NOTE: This _won't_ recursively walk the receipt/state trees. It assumes that having the root
 // implies having the rest of the tree. However, lotus generally makes that assumption anyways.
 func tryLookupTipsetState(ctx context.Context, cs *store.ChainStore, ts *types.TipSet) (cid.Cid, cid.Cid, bool) {
-	nextTs, err := cs.GetTipsetByHeight(ctx, ts.Height()+1, nil, false)
-	if err != nil {
-		// Nothing to see here. The requested height may be beyond the current head.
-		return cid.Undef, cid.Undef, false
+	logMiss := func(reason string) {
+		log.Debugw("tipset state lookup missed", "reason", reason, "tipset", ts.Key(), "height", ts.Height())
 	}
 
-	// Make sure we're on the correct fork.
-	if nextTs.Parents() != ts.Key() {
-		// Also nothing to see here. This just means that the requested tipset is on a
-		// different fork.
-		return cid.Undef, cid.Undef, false
+	stateCid, receiptCid, miss := canonicalChildState(ctx, cs, ts)
+	if miss != "" {
+		var found bool
+		stateCid, receiptCid, found = trackedChildState(ctx, cs, ts)
+		if !found {
+			logMiss(miss)
+			return cid.Undef, cid.Undef, false
+		}
 	}
 
-	stateCid := nextTs.ParentState()
-	receiptCid := nextTs.ParentMessageReceipts()
-
 	// Make sure we have the parent state.
 	if hasState, err := cs.StateBlockstore().Has(ctx, stateCid); err != nil {
 		log.Errorw("failed to lookup state-root in blockstore", "cid", stateCid, "error", err)
@@ -107,6 +325,7 @@ func tryLookupTipsetState(ctx context.Context, cs *store.ChainStore, ts *types.T
 	} else if !hasState {
 		// We have the chain but don't have the state. It looks like we need to try
 		// executing?
+		logMiss(lookupMissState)
 		return cid.Undef, cid.Undef, false
 	}
 
@@ -116,18 +335,327 @@ func tryLookupTipsetState(ctx context.Context, cs *store.ChainStore, ts *types.T
 		return cid.Undef, cid.Undef, false
 	} else if !hasReceipts {
 		// If we don't have the receipts, re-execute and try again.
+		logMiss(lookupMissReceipts)
 		return cid.Undef, cid.Undef, false
 	}
 
 	return stateCid, receiptCid, true
 }
 
+// canonicalChildState returns the parent state of the child of ts on the canonical chain, or the
+// reason it couldn't be found.
+func canonicalChildState(ctx context.Context, cs *store.ChainStore, ts *types.TipSet) (cid.Cid, cid.Cid, string) {
+	nextTs, err := cs.GetTipsetByHeight(ctx, ts.Height()+1, nil, false)
+	if err != nil {
+		// Nothing to see here. The requested height may be beyond the current head.
+		return cid.Undef, cid.Undef, lookupMissNoChild
+	}
+
+	// Make sure we're on the correct fork.
+	if nextTs.Parents() != ts.Key() {
+		// Also nothing to see here. This just means that the requested tipset is on a
+		// different fork.
+		return cid.Undef, cid.Undef, lookupMissFork
+	}
+
+	return nextTs.ParentState(), nextTs.ParentMessageReceipts(), ""
+}
+
+// trackedChildState searches the blocks recently seen by the chain store, within
+// tipsetStateLookupWindow epochs above ts, for a validated block whose parent is ts. Validation
+// checked its parent state against our own execution, so it can be trusted.
+func trackedChildState(ctx context.Context, cs *store.ChainStore, ts *types.TipSet) (cid.Cid, cid.Cid, bool) {
+	for h := ts.Height() + 1; h <= ts.Height()+tipsetStateLookupWindow; h++ {
+		for _, c := range cs.TrackedBlocks(h) {
+			if validated, err := cs.IsBlockValidated(ctx, c); err != nil || !validated {
+				continue
+			}
+			blk, err := cs.GetBlock(ctx, c)
+			if err != nil {
+				continue
+			}
+			if types.NewTipSetKey(blk.Parents...) == ts.Key() {
+				return blk.ParentStateRoot, blk.ParentMessageReceipts, true
+			}
+		}
+	}
+	return cid.Undef, cid.Undef, false
+}
+
+// ExecuteTipSetWithOpts executes the given tipset with the supplied executor overrides. The result
+// is never written to the state cache, so an overridden execution can't leak into TipSetState.
+func (sm *StateManager) ExecuteTipSetWithOpts(ctx context.Context, ts *types.TipSet, em ExecMonitor, vmTracing bool, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+	if err := opts.Validate(); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	exec, ok := sm.tsExec.(ExecutorWithOpts)
+	if !ok {
+		return cid.Undef, cid.Undef, xerrors.Errorf("executor %T does not support execution overrides", sm.tsExec)
+	}
+
+	return exec.ExecuteTipSetWithOpts(ctx, sm, ts, em, vmTracing, opts)
+}
+
+// StateForwardFrom computes the state of the canonical tipset at targetHeight (or the last
+// tipset before it, if targetHeight is a null round) by executing forward from baseTs, whose
+// state is trusted to be baseState. Tipsets whose state is already in the state cache are not
+// re-executed. Results are not written to the state cache.
+func (sm *StateManager) StateForwardFrom(ctx context.Context, baseTs *types.TipSet, baseState cid.Cid, targetHeight abi.ChainEpoch) (cid.Cid, error) {
+	if targetHeight < baseTs.Height() {
+		return cid.Undef, xerrors.Errorf("target height %d is below base tipset height %d", targetHeight, baseTs.Height())
+	}
+
+	head := sm.cs.GetHeaviestTipSet()
+	if targetHeight > head.Height() {
+		return cid.Undef, xerrors.Errorf("target height %d is above the current head height %d", targetHeight, head.Height())
+	}
+
+	canonBase, err := sm.cs.GetTipsetByHeight(ctx, baseTs.Height(), head, false)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("loading canonical tipset at base height %d: %w", baseTs.Height(), err)
+	}
+	if canonBase.Key() != baseTs.Key() {
+		return cid.Undef, xerrors.Errorf("base tipset %s is not on the canonical chain", baseTs.Key())
+	}
+
+	target, err := sm.cs.GetTipsetByHeight(ctx, targetHeight, head, true)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("loading canonical tipset at target height %d: %w", targetHeight, err)
+	}
+
+	// Walk back from the target to the base, then execute in chain order.
+	var path []*types.TipSet
+	for ts := target; ts.Height() > baseTs.Height(); {
+		path = append(path, ts)
+		if ts, err = sm.cs.LoadTipSet(ctx, ts.Parents()); err != nil {
+			return cid.Undef, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	st := baseState
+	for i := len(path) - 1; i >= 0; i-- {
+		ts := path[i]
+
+		sm.stlk.Lock()
+		cached, ok := sm.stCache.get(cidsToKey(ts.Cids()))
+		sm.stlk.Unlock()
+		if ok {
+			st = cached.st
+			continue
+		}
+
+		st, _, err = sm.ExecuteTipSetWithOpts(ctx, ts, nil, false, ExecutorOpts{ParentStateOverride: st, Parallel: true})
+		if err != nil {
+			return cid.Undef, xerrors.Errorf("executing tipset %s at height %d: %w", ts.Key(), ts.Height(), err)
+		}
+	}
+
+	return st, nil
+}
+
+// TipSetStatePrefix computes the state and receipt roots resulting from applying only the first
+// count (deduplicated) messages of the tipset, for bisecting message effects. Block rewards and
+// cron are still applied, so with count equal to the number of messages the result matches
+// TipSetState. A count of 0 returns the parent state and an empty receipt root. The resulting
+// state is not persisted, and never cached.
+func (sm *StateManager) TipSetStatePrefix(ctx context.Context, ts *types.TipSet, count int) (cid.Cid, cid.Cid, error) {
+	msgs, err := sm.cs.MessagesForTipset(ctx, ts)
+	if err != nil {
+		return cid.Undef, cid.Undef, xerrors.Errorf("loading tipset messages: %w", err)
+	}
+	if count < 0 || count > len(msgs) {
+		return cid.Undef, cid.Undef, xerrors.Errorf("message count %d out of range [0, %d]", count, len(msgs))
+	}
+
+	if count == 0 {
+		rec, err := blockadt.MakeEmptyArray(sm.cs.ActorStore(ctx)).Root()
+		if err != nil {
+			return cid.Undef, cid.Undef, xerrors.Errorf("building empty receipts amt: %w", err)
+		}
+		return ts.ParentState(), rec, nil
+	}
+
+	buf := blockstore.NewTieredBstore(sm.cs.StateBlockstore(), blockstore.NewMemorySync())
+	return sm.ExecuteTipSetWithOpts(ctx, ts, nil, false, ExecutorOpts{StateBlockstore: buf, MessageLimit: count})
+}
+
+// verifyReceiptsParallelism bounds the number of tipsets VerifyReceiptsRange executes at once.
+var verifyReceiptsParallelism = 4
+
+// VerifyReceiptsRange executes every tipset on the chain from `from` to `to` (inclusive) and
+// compares the resulting receipt root against the one recorded by its child. It returns the
+// heights of all tipsets whose receipt roots don't match. Execution results are not persisted.
+func (sm *StateManager) VerifyReceiptsRange(ctx context.Context, from, to *types.TipSet) ([]abi.ChainEpoch, error) {
+	if to.Height() < from.Height() {
+		return nil, xerrors.Errorf("range end %d is below range start %d", to.Height(), from.Height())
+	}
+
+	child, err := sm.cs.GetTipsetByHeight(ctx, to.Height()+1, nil, false)
+	if err != nil {
+		return nil, xerrors.Errorf("loading child of range end: %w", err)
+	}
+	if child.Parents() != to.Key() {
+		return nil, xerrors.Errorf("range end %s is not on the canonical chain", to.Key())
+	}
+
+	// Pair each tipset with the receipt root its child recorded for it.
+	type pending struct {
+		ts  *types.TipSet
+		rec cid.Cid
+	}
+	var work []pending
+	for ts := to; ; {
+		work = append(work, pending{ts: ts, rec: child.ParentMessageReceipts()})
+		if ts.Height() <= from.Height() {
+			if ts.Key() != from.Key() {
+				return nil, xerrors.Errorf("range start %s is not an ancestor of range end %s", from.Key(), to.Key())
+			}
+			break
+		}
+		child = ts
+		if ts, err = sm.cs.LoadTipSet(ctx, ts.Parents()); err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	mismatched := make([]bool, len(work))
+	eg, ectx := errgroup.WithContext(ctx)
+	eg.SetLimit(verifyReceiptsParallelism)
+	for i, w := range work {
+		i, w := i, w
+		eg.Go(func() error {
+			buf := blockstore.NewTieredBstore(sm.cs.StateBlockstore(), blockstore.NewMemorySync())
+			_, rec, err := sm.ExecuteTipSetWithOpts(ectx, w.ts, nil, false, ExecutorOpts{StateBlockstore: buf, Parallel: true})
+			if err != nil {
+				return xerrors.Errorf("executing tipset %s at height %d: %w", w.ts.Key(), w.ts.Height(), err)
+			}
+			mismatched[i] = rec != w.rec
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	// work is ordered from the range end backwards.
+	var mismatches []abi.ChainEpoch
+	for i := len(work) - 1; i >= 0; i-- {
+		if mismatched[i] {
+			mismatches = append(mismatches, work[i].ts.Height())
+		}
+	}
+	return mismatches, nil
+}
+
+// TouchedActors executes the given tipset and returns the ID addresses of the senders and
+// receivers of its explicit messages whose state differs between the parent state and the
+// resulting state, so that read-only calls and failed messages to unchanged receivers are left
+// out. The tipset is executed without tracing. Actors only modified by subcalls, implicit messages
+// (cron, block rewards) or gas accounting are not included, while participants also modified by
+// those, or by a network upgrade at this tipset, are. The resulting state is not persisted.
+func (sm *StateManager) TouchedActors(ctx context.Context, ts *types.TipSet) ([]address.Address, error) {
+	tracer := &participantsTracer{participants: make(map[address.Address]struct{})}
+	buf := blockstore.NewTieredBstore(sm.cs.StateBlockstore(), blockstore.NewMemorySync())
+	st, _, err := sm.ExecuteTipSetWithOpts(ctx, ts, tracer, false, ExecutorOpts{StateBlockstore: buf})
+	if err != nil {
+		return nil, xerrors.Errorf("executing tipset: %w", err)
+	}
+
+	cst := cbor.NewCborStore(buf)
+	pre, err := state.LoadStateTree(cst, ts.ParentState())
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent state tree: %w", err)
+	}
+	post, err := state.LoadStateTree(cst, st)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	ids := make(map[address.Address]struct{}, len(tracer.participants))
+	for addr := range tracer.participants {
+		id, err := post.LookupID(addr)
+		if errors.Is(err, types.ErrActorNotFound) {
+			// a message to an address which was never created
+			continue
+		} else if err != nil {
+			return nil, xerrors.Errorf("resolving %s: %w", addr, err)
+		}
+		if _, ok := ids[id]; ok {
+			continue
+		}
+
+		after, err := post.GetActor(id)
+		if err != nil {
+			return nil, xerrors.Errorf("loading %s: %w", id, err)
+		}
+		before, err := pre.GetActor(id)
+		switch {
+		case errors.Is(err, types.ErrActorNotFound):
+			// created by the tipset
+		case err != nil:
+			return nil, xerrors.Errorf("loading parent state of %s: %w", id, err)
+		case before.Head.Equals(after.Head) && before.Nonce == after.Nonce && before.Code.Equals(after.Code) &&
+			before.Balance.Equals(after.Balance) && equalAddrs(before.Address, after.Address):
+			continue
+		}
+		ids[id] = struct{}{}
+	}
+
+	out := make([]address.Address, 0, len(ids))
+	for id := range ids {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].Bytes(), out[j].Bytes()) < 0
+	})
+	return out, nil
+}
+
+func equalAddrs(a, b *address.Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func (sm *StateManager) ExecutionTraceWithMonitor(ctx context.Context, ts *types.TipSet, em ExecMonitor) (cid.Cid, error) {
 	st, _, err := sm.tsExec.ExecuteTipSet(ctx, sm, ts, em, true)
 	return st, err
 }
 
 func (sm *StateManager) ExecutionTrace(ctx context.Context, ts *types.TipSet) (cid.Cid, []*api.InvocResult, error) {
+	st, invocTrace, err := sm.ExecutionTracePartial(ctx, ts)
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	return st, invocTrace, nil
+}
+
+// SetExecutionTraceMaxNodes bounds the number of execution trace nodes retained when tracing a
+// tipset; the results whose traces were cut short are marked as truncated. Zero, the default,
+// means unlimited. It must be called before the StateManager is used.
+func (sm *StateManager) SetExecutionTraceMaxNodes(maxNodes int) {
+	sm.traceMaxNodes = maxNodes
+}
+
+// ExecutionTraceFull is like ExecutionTrace, but also returns the receipt root of the tipset.
+func (sm *StateManager) ExecutionTraceFull(ctx context.Context, ts *types.TipSet) (cid.Cid, cid.Cid, []*api.InvocResult, error) {
+	st, rec, invocTrace, err := sm.executionTrace(ctx, ts)
+	if err != nil {
+		return cid.Undef, cid.Undef, nil, err
+	}
+	return st, rec, invocTrace, nil
+}
+
+// ExecutionTracePartial is like ExecutionTrace, except that when execution fails part way
+// through, the invocation results collected up to the failure are returned alongside the error.
+func (sm *StateManager) ExecutionTracePartial(ctx context.Context, ts *types.TipSet) (cid.Cid, []*api.InvocResult, error) {
+	st, _, invocTrace, err := sm.executionTrace(ctx, ts)
+	return st, invocTrace, err
+}
+
+func (sm *StateManager) executionTrace(ctx context.Context, ts *types.TipSet) (cid.Cid, cid.Cid, []*api.InvocResult, error) {
 	tsKey := ts.Key()
 
 	// check if we have the trace for this tipset in the cache
@@ -138,26 +666,67 @@ func (sm *StateManager) ExecutionTrace(ctx context.Context, ts *types.TipSet) (c
 			// and we don't want that to change what we store in cache
 			invocTraceCopy := makeDeepCopy(entry.invocTrace)
 			sm.execTraceCacheLock.Unlock()
-			return entry.postStateRoot, invocTraceCopy, nil
+			return entry.postStateRoot, entry.receiptRoot, invocTraceCopy, nil
 		}
 		sm.execTraceCacheLock.Unlock()
 	}
 
 	var invocTrace []*api.InvocResult
-	st, err := sm.ExecutionTraceWithMonitor(ctx, ts, &InvocationTracer{trace: &invocTrace})
+	st, rec, err := sm.tsExec.ExecuteTipSet(ctx, sm, ts, NewInvocationTracer(&invocTrace, sm.traceMaxNodes), true)
 	if err != nil {
-		return cid.Undef, nil, err
+		return cid.Undef, cid.Undef, invocTrace, err
 	}
 
-	if execTraceCacheSize > 0 {
+	if size := approxTraceSize(invocTrace); execTraceCacheSize > 0 && (execTraceCacheBytes <= 0 || size <= execTraceCacheBytes) {
 		invocTraceCopy := makeDeepCopy(invocTrace)
 
 		sm.execTraceCacheLock.Lock()
-		sm.execTraceCache.Add(tsKey, tipSetCacheEntry{st, invocTraceCopy})
+		if old, ok := sm.execTraceCache.Peek(tsKey); ok {
+			sm.execTraceCacheUsed -= old.size
+		}
+		sm.execTraceCache.Add(tsKey, tipSetCacheEntry{st, rec, invocTraceCopy, size})
+		sm.execTraceCacheUsed += size
+		for execTraceCacheBytes > 0 && sm.execTraceCacheUsed > execTraceCacheBytes {
+			sm.execTraceCache.RemoveOldest()
+		}
 		sm.execTraceCacheLock.Unlock()
 	}
 
-	return st, invocTrace, nil
+	if msgTraceCacheSize > 0 {
+		for _, ir := range makeDeepCopy(invocTrace) {
+			if ir == nil {
+				continue
+			}
+			sm.msgTraceCache.Add(msgTraceKey{tsk: tsKey, mcid: ir.MsgCid}, ir)
+		}
+	}
+
+	return st, rec, invocTrace, nil
+}
+
+// MessageTrace returns the invocation result for the given message as executed in the given
+// tipset. It is served from the message trace cache when possible, and otherwise traces the whole
+// tipset (which populates the cache).
+func (sm *StateManager) MessageTrace(ctx context.Context, ts *types.TipSet, mcid cid.Cid) (*api.InvocResult, error) {
+	if msgTraceCacheSize > 0 {
+		if ir, ok := sm.msgTraceCache.Get(msgTraceKey{tsk: ts.Key(), mcid: mcid}); ok {
+			// as for the tipset cache, the caller may modify the result
+			return makeDeepCopy([]*api.InvocResult{ir})[0], nil
+		}
+	}
+
+	_, invocTrace, err := sm.ExecutionTrace(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ir := range invocTrace {
+		if ir != nil && ir.MsgCid == mcid {
+			return ir, nil
+		}
+	}
+
+	return nil, xerrors.Errorf("message %s not found in tipset %s", mcid, ts.Key())
 }
 
 func makeDeepCopy(invocTrace []*api.InvocResult) []*api.InvocResult {
@@ -167,8 +736,73 @@ func makeDeepCopy(invocTrace []*api.InvocResult) []*api.InvocResult {
 			continue
 		}
 		tmp := *ir
+		if ir.Msg != nil {
+			msg := *ir.Msg
+			tmp.Msg = &msg
+		}
+		if ir.MsgRct != nil {
+			rct := *ir.MsgRct
+			tmp.MsgRct = &rct
+		}
+		tmp.ExecutionTrace = copyExecutionTrace(ir.ExecutionTrace)
 		c[i] = &tmp
 	}
 
 	return c
 }
+
+func copyExecutionTrace(et types.ExecutionTrace) types.ExecutionTrace {
+	out := et
+	if et.GasCharges != nil {
+		out.GasCharges = make([]*types.GasTrace, len(et.GasCharges))
+		for i, gc := range et.GasCharges {
+			if gc != nil {
+				gcCopy := *gc
+				out.GasCharges[i] = &gcCopy
+			}
+		}
+	}
+	if et.Subcalls != nil {
+		out.Subcalls = make([]types.ExecutionTrace, len(et.Subcalls))
+		for i, sub := range et.Subcalls {
+			out.Subcalls[i] = copyExecutionTrace(sub)
+		}
+	}
+	return out
+}
+
+// approxTraceSize estimates the memory held by a trace. It only needs to be good enough to bound
+// the execution trace cache.
+func approxTraceSize(invocTrace []*api.InvocResult) int64 {
+	const invocResultOverhead, traceNodeOverhead, gasTraceOverhead = 512, 256, 64
+
+	var traceSize func(et types.ExecutionTrace) int64
+	traceSize = func(et types.ExecutionTrace) int64 {
+		n := int64(traceNodeOverhead + len(et.Msg.Params) + len(et.MsgRct.Return))
+		for _, gc := range et.GasCharges {
+			n += gasTraceOverhead
+			if gc != nil {
+				n += int64(len(gc.Name))
+			}
+		}
+		for _, sub := range et.Subcalls {
+			n += traceSize(sub)
+		}
+		return n
+	}
+
+	var size int64
+	for _, ir := range invocTrace {
+		if ir == nil {
+			continue
+		}
+		size += invocResultOverhead + int64(len(ir.Error)) + traceSize(ir.ExecutionTrace)
+		if ir.Msg != nil {
+			size += int64(len(ir.Msg.Params))
+		}
+		if ir.MsgRct != nil {
+			size += int64(len(ir.MsgRct.Return))
+		}
+	}
+	return size
+}
@@ -0,0 +1,721 @@
+// stm: #unit
+package stmgr
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+func TestExecutionTracePartial(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	execErr := xerrors.New("boom")
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			for i := uint64(0); i < 2; i++ {
+				msg := &types.Message{Nonce: i}
+				if err := em.MessageApplied(ctx, ts, msg.Cid(), msg, &vm.ApplyRet{}, false); err != nil {
+					return cid.Undef, cid.Undef, err
+				}
+			}
+			return cid.Undef, cid.Undef, execErr
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+
+	st, trace, err := sm.ExecutionTracePartial(ctx, tss[1])
+	require.ErrorIs(t, err, execErr)
+	require.Equal(t, cid.Undef, st)
+	require.Len(t, trace, 2)
+	for i, ir := range trace {
+		require.Equal(t, uint64(i), ir.Msg.Nonce)
+	}
+
+	// The plain variant still drops the partial trace.
+	_, trace, err = sm.ExecutionTrace(ctx, tss[1])
+	require.ErrorIs(t, err, execErr)
+	require.Nil(t, trace)
+}
+
+func TestMessageTraceCache(t *testing.T) {
+	defer func(etcs, mtcs int) {
+		execTraceCacheSize, msgTraceCacheSize = etcs, mtcs
+	}(execTraceCacheSize, msgTraceCacheSize)
+	// Disable the whole-tipset trace cache so that only the message cache can avoid execution.
+	execTraceCacheSize, msgTraceCacheSize = 0, 8
+
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	var msgs []*types.Message
+	for i := uint64(0); i < 3; i++ {
+		msgs = append(msgs, &types.Message{Nonce: i})
+	}
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			for _, msg := range msgs {
+				ret := &vm.ApplyRet{
+					MessageReceipt: types.MessageReceipt{GasUsed: int64(msg.Nonce) + 1},
+					ExecutionTrace: types.ExecutionTrace{
+						Subcalls:   []types.ExecutionTrace{{Msg: types.MessageTrace{Method: 2}}},
+						GasCharges: []*types.GasTrace{{Name: "OnMethodInvocation", TotalGas: 10}},
+					},
+				}
+				if err := em.MessageApplied(ctx, ts, msg.Cid(), msg, ret, false); err != nil {
+					return cid.Undef, cid.Undef, err
+				}
+			}
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+
+	_, trace, err := sm.ExecutionTrace(ctx, tss[1])
+	require.NoError(t, err)
+	require.Len(t, trace, len(msgs))
+	require.Equal(t, 1, exec.callCount())
+
+	ir, err := sm.MessageTrace(ctx, tss[1], msgs[1].Cid())
+	require.NoError(t, err)
+	require.Equal(t, msgs[1].Cid(), ir.MsgCid)
+	require.Equal(t, int64(2), ir.MsgRct.GasUsed)
+	require.Equal(t, 1, exec.callCount())
+
+	// Mutating a served result doesn't affect the cached one.
+	ir.Msg.Nonce = 7
+	ir.MsgRct.GasUsed = 7
+	ir.ExecutionTrace.Subcalls[0].Msg.Method = 7
+	ir.ExecutionTrace.GasCharges[0].TotalGas = 7
+	again, err := sm.MessageTrace(ctx, tss[1], msgs[1].Cid())
+	require.NoError(t, err)
+	require.Equal(t, trace[1], again)
+	require.Equal(t, 1, exec.callCount())
+
+	// The same message in a different tipset isn't served from the cache.
+	_, err = sm.MessageTrace(ctx, tss[2], msgs[1].Cid())
+	require.NoError(t, err)
+	require.Equal(t, 2, exec.callCount())
+}
+
+func TestCronTrace(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			applied := []struct {
+				msg      *types.Message
+				implicit bool
+			}{
+				{&types.Message{From: builtin.SystemActorAddr, To: reward.Address}, true},
+				{&types.Message{From: builtin.SystemActorAddr, To: builtin.CronActorAddr, Nonce: 1}, true},
+				{&types.Message{From: builtin.BurntFundsActorAddr, To: builtin.CronActorAddr}, false},
+				{&types.Message{From: builtin.SystemActorAddr, To: builtin.CronActorAddr, Nonce: 2}, true},
+			}
+			for _, a := range applied {
+				ret := &vm.ApplyRet{MessageReceipt: types.MessageReceipt{GasUsed: int64(a.msg.Nonce)}}
+				if err := em.MessageApplied(ctx, ts, a.msg.Cid(), a.msg, ret, a.implicit); err != nil {
+					return cid.Undef, cid.Undef, err
+				}
+			}
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+	head := tss[len(tss)-1]
+
+	implicit, err := sm.ImplicitTrace(ctx, head)
+	require.NoError(t, err)
+	require.Len(t, implicit, 3)
+
+	cron, err := sm.CronTrace(ctx, head)
+	require.NoError(t, err)
+	require.Len(t, cron, 2)
+	require.Equal(t, int64(1), cron[0].MsgRct.GasUsed)
+	require.Equal(t, int64(2), cron[1].MsgRct.GasUsed)
+}
+
+func TestTipSetStateDiscardStateNotCached(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	// Each execution "writes" a state tree whose root is the returned state CID.
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			bs := opts.StateBlockstore
+			if bs == nil {
+				bs = cs.StateBlockstore()
+			}
+			blk := blocks.NewBlock([]byte(ts.Key().String()))
+			if err := bs.Put(ctx, blk); err != nil {
+				return cid.Undef, cid.Undef, err
+			}
+			return blk.Cid(), blk.Cid(), nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+	head := tss[len(tss)-1]
+
+	st, _, err := sm.TipSetStateWithOpts(ctx, head, TipSetStateOpts{DiscardState: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.callCount())
+	require.Equal(t, 0, sm.stCache.len())
+
+	has, err := cs.StateBlockstore().Has(ctx, st)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// A normal call must not be served the discarded result.
+	st2, _, err := sm.TipSetState(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, st, st2)
+	require.Equal(t, 2, exec.callCount())
+	require.Equal(t, 1, sm.stCache.len())
+
+	has, err = cs.StateBlockstore().Has(ctx, st2)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// Durable results are served to discard-state callers from the cache.
+	_, _, err = sm.TipSetStateWithOpts(ctx, head, TipSetStateOpts{DiscardState: true})
+	require.NoError(t, err)
+	require.Equal(t, 2, exec.callCount())
+}
+
+func TestTipSetStateCheckpointInterval(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 5)
+
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			bs := opts.StateBlockstore
+			if bs == nil {
+				bs = cs.StateBlockstore()
+			}
+			blk := blocks.NewBlock([]byte(ts.Key().String()))
+			if err := bs.Put(ctx, blk); err != nil {
+				return cid.Undef, cid.Undef, err
+			}
+			return blk.Cid(), blk.Cid(), nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+	head := tss[len(tss)-1]
+
+	cp, err := sm.LatestReplayCheckpoint(ctx)
+	require.NoError(t, err)
+	require.Nil(t, cp)
+
+	// Going from height 3 to 4 crosses a multiple of 2, so the state is kept despite DiscardState.
+	st, rec, err := sm.TipSetStateWithOpts(ctx, head, TipSetStateOpts{DiscardState: true, CheckpointInterval: 2})
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.callCount())
+
+	has, err := cs.StateBlockstore().Has(ctx, st)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	cp, err = sm.LatestReplayCheckpoint(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &ReplayCheckpoint{TipSet: head.Key(), Height: head.Height(), State: st, Receipts: rec}, cp)
+
+	// Resuming is served from the checkpoint.
+	sm.stCache = newStateCache(10)
+	st2, _, err := sm.TipSetStateWithOpts(ctx, head, TipSetStateOpts{DiscardState: true, CheckpointInterval: 2})
+	require.NoError(t, err)
+	require.Equal(t, st, st2)
+	require.Equal(t, 1, exec.callCount())
+
+	// Without a crossing, the state is discarded as usual.
+	cs, tss = newTestChain(t, 4)
+	sm = newTestStateManager(t, cs, exec)
+	st, _, err = sm.TipSetStateWithOpts(ctx, tss[len(tss)-1], TipSetStateOpts{DiscardState: true, CheckpointInterval: 2})
+	require.NoError(t, err)
+
+	has, err = cs.StateBlockstore().Has(ctx, st)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	cp, err = sm.LatestReplayCheckpoint(ctx)
+	require.NoError(t, err)
+	require.Nil(t, cp)
+}
+
+func TestExecutionBreaker(t *testing.T) {
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	build.Clock = mc
+
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	fail := true
+	errMissingState := xerrors.New("missing state")
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			if fail {
+				return cid.Undef, cid.Undef, errMissingState
+			}
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	const threshold = 3
+	sm := newTestStateManager(t, cs, exec)
+	sm.SetExecBreakerThreshold(threshold)
+	head := tss[len(tss)-1]
+
+	for i := 0; i < threshold; i++ {
+		_, _, err := sm.TipSetState(ctx, head)
+		require.ErrorIs(t, err, errMissingState)
+	}
+	require.Equal(t, threshold, exec.callCount())
+
+	// The breaker is now open: the executor isn't invoked.
+	status, err := sm.TipSetStateStatus(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, StatusBreakerOpen, status)
+	_, _, err = sm.TipSetState(ctx, head)
+	require.ErrorIs(t, err, ErrExecutionBreakerOpen)
+	require.Contains(t, err.Error(), errMissingState.Error())
+	require.Equal(t, threshold, exec.callCount())
+
+	// Other tipsets are unaffected.
+	_, _, err = sm.TipSetState(ctx, tss[1])
+	require.ErrorIs(t, err, errMissingState)
+
+	// After the cooldown a single execution is let through, and its failure opens the breaker
+	// again.
+	mc.Add(execBreakerCooldown)
+	_, _, err = sm.TipSetState(ctx, head)
+	require.ErrorIs(t, err, errMissingState)
+	require.Equal(t, threshold+2, exec.callCount())
+	_, _, err = sm.TipSetState(ctx, head)
+	require.ErrorIs(t, err, ErrExecutionBreakerOpen)
+	require.Equal(t, threshold+2, exec.callCount())
+
+	// After the next cooldown, a success closes the breaker.
+	fail = false
+	mc.Add(execBreakerCooldown)
+	_, _, err = sm.TipSetState(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, threshold+3, exec.callCount())
+	require.False(t, sm.execBreaker.states.Contains(cidsToKey(head.Cids())))
+}
+
+func TestExecutionBreakerDisabledByDefault(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	errMissingState := xerrors.New("missing state")
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			return cid.Undef, cid.Undef, errMissingState
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+	head := tss[len(tss)-1]
+
+	for i := 0; i < 5; i++ {
+		_, _, err := sm.TipSetState(ctx, head)
+		require.ErrorIs(t, err, errMissingState)
+	}
+	require.Equal(t, 5, exec.callCount())
+	require.Zero(t, sm.execBreaker.states.Len())
+}
+
+func TestExecutionBreakerBounded(t *testing.T) {
+	defer func(max int) { execBreakerMaxTracked = max }(execBreakerMaxTracked)
+	execBreakerMaxTracked = 2
+
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 4)
+
+	errMissingState := xerrors.New("missing state")
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			return cid.Undef, cid.Undef, errMissingState
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+	sm.SetExecBreakerThreshold(3)
+
+	// Tipsets failing fewer times than the threshold don't accumulate.
+	for _, ts := range tss[1:] {
+		_, _, err := sm.TipSetState(ctx, ts)
+		require.ErrorIs(t, err, errMissingState)
+	}
+	require.Equal(t, 2, sm.execBreaker.states.Len())
+	require.False(t, sm.execBreaker.states.Contains(cidsToKey(tss[1].Cids())))
+}
+
+func TestTryLookupTipsetStateMissReasons(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	require.NoError(t, logging.SetLogLevel("statemgr", "debug"))
+	t.Cleanup(func() { _ = logging.SetLogLevel("statemgr", "error") })
+
+	pr := logging.NewPipeReader(logging.PipeFormat(logging.JSONOutput))
+	t.Cleanup(func() { _ = pr.Close() })
+
+	reasons := make(chan string, 16)
+	go func() {
+		dec := json.NewDecoder(pr)
+		for {
+			var entry struct {
+				Logger string
+				Msg    string
+				Reason string
+			}
+			if err := dec.Decode(&entry); err != nil {
+				return
+			}
+			if entry.Logger == "statemgr" && entry.Msg == "tipset state lookup missed" {
+				reasons <- entry.Reason
+			}
+		}
+	}()
+
+	requireMiss := func(ts *types.TipSet, reason string) {
+		_, _, found := tryLookupTipsetState(ctx, cs, ts)
+		require.False(t, found)
+		select {
+		case logged := <-reasons:
+			require.Equal(t, reason, logged)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no miss logged for %q", reason)
+		}
+	}
+
+	requireMiss(tss[2], lookupMissNoChild)
+	requireMiss(mkTestTipSet(t, mkTestBlock(tss[0], 1)), lookupMissFork)
+	requireMiss(tss[1], lookupMissState)
+
+	putTestBlock(t, cs.StateBlockstore(), "stmgr test")
+	requireMiss(tss[1], lookupMissReceipts)
+}
+
+func TestTryLookupTipsetStateFork(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+	putTestBlock(t, cs.StateBlockstore(), "stmgr test")
+	putTestBlock(t, cs.ChainBlockstore(), "stmgr test receipts")
+
+	// A re-orged away tipset, whose child was seen after a null round.
+	fork := mkTestTipSet(t, mkTestBlock(tss[0], 1))
+	child := mkTestBlock(fork, 1)
+	child.Height++
+	require.NoError(t, cs.PersistTipsets(ctx, []*types.TipSet{fork, mkTestTipSet(t, child)}))
+	require.NoError(t, cs.AddToTipSetTracker(ctx, child))
+
+	// Children that weren't validated aren't trusted.
+	_, _, found := tryLookupTipsetState(ctx, cs, fork)
+	require.False(t, found)
+
+	require.NoError(t, cs.MarkBlockAsValidated(ctx, child.Cid()))
+	st, rec, found := tryLookupTipsetState(ctx, cs, fork)
+	require.True(t, found)
+	require.Equal(t, testCid, st)
+	require.Equal(t, testRctCid, rec)
+
+	oldWindow := tipsetStateLookupWindow
+	t.Cleanup(func() { tipsetStateLookupWindow = oldWindow })
+	tipsetStateLookupWindow = 1
+	_, _, found = tryLookupTipsetState(ctx, cs, fork)
+	require.False(t, found)
+}
+
+func TestVerifyReceiptsRange(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 6)
+
+	// Every child records testRctCid; execution of tss[2] produces something else.
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			if ts.Key() == tss[2].Key() {
+				return testCid, testCid, nil
+			}
+			return testCid, testRctCid, nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+
+	mismatches, err := sm.VerifyReceiptsRange(ctx, tss[1], tss[4])
+	require.NoError(t, err)
+	require.Equal(t, []abi.ChainEpoch{tss[2].Height()}, mismatches)
+	require.Equal(t, 4, exec.callCount())
+	require.Equal(t, 0, sm.stCache.len())
+
+	// The head has no child recording its receipts.
+	_, err = sm.VerifyReceiptsRange(ctx, tss[1], tss[5])
+	require.Error(t, err)
+
+	_, err = sm.VerifyReceiptsRange(ctx, tss[3], tss[1])
+	require.Error(t, err)
+}
+
+func TestExecutionTraceCache(t *testing.T) {
+	defer func(etcs int, etcb int64) {
+		execTraceCacheSize, execTraceCacheBytes = etcs, etcb
+	}(execTraceCacheSize, execTraceCacheBytes)
+	execTraceCacheSize, execTraceCacheBytes = 4, 0
+
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			msg := &types.Message{Nonce: 1}
+			ret := &vm.ApplyRet{ExecutionTrace: types.ExecutionTrace{
+				Subcalls: []types.ExecutionTrace{{Msg: types.MessageTrace{Method: 2}}},
+			}}
+			if err := em.MessageApplied(ctx, ts, msg.Cid(), msg, ret, false); err != nil {
+				return cid.Undef, cid.Undef, err
+			}
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+
+	_, first, err := sm.ExecutionTrace(ctx, tss[1])
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.callCount())
+
+	_, second, err := sm.ExecutionTrace(ctx, tss[1])
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.callCount())
+	require.Equal(t, first, second)
+
+	// Mutating a served trace doesn't affect the cached one.
+	second[0].Msg.Nonce = 7
+	second[0].ExecutionTrace.Subcalls[0].Msg.Method = 7
+	_, third, err := sm.ExecutionTrace(ctx, tss[1])
+	require.NoError(t, err)
+	require.Equal(t, first, third)
+
+	// Traces larger than the byte budget aren't cached.
+	execTraceCacheBytes = 1
+	sm = newTestStateManager(t, cs, exec)
+	for i := 0; i < 2; i++ {
+		_, _, err = sm.ExecutionTrace(ctx, tss[1])
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, exec.callCount())
+}
+
+func TestTipSetStateNoCacheWrite(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 4)
+	exec := &testExecutor{}
+	sm := newTestStateManager(t, cs, exec)
+	head := tss[len(tss)-1]
+
+	st, _, err := sm.TipSetStateWithOpts(ctx, head, TipSetStateOpts{NoCacheWrite: true})
+	require.NoError(t, err)
+	require.Equal(t, head.Cids()[0], st)
+	require.Equal(t, 1, exec.callCount())
+	require.Equal(t, 0, sm.stCache.len())
+
+	// Reads are still served from the cache.
+	_, _, err = sm.TipSetState(ctx, tss[2])
+	require.NoError(t, err)
+	require.Equal(t, 2, exec.callCount())
+
+	st, _, err = sm.TipSetStateWithOpts(ctx, tss[2], TipSetStateOpts{NoCacheWrite: true})
+	require.NoError(t, err)
+	require.Equal(t, tss[2].Cids()[0], st)
+	require.Equal(t, 2, exec.callCount())
+	require.Equal(t, 1, sm.stCache.len())
+}
+
+func TestTipSetStateRecoversPanics(t *testing.T) {
+	defer func(bt int, rep bool) {
+		build.BuildType, recoverExecutionPanics = bt, rep
+	}(build.BuildType, recoverExecutionPanics)
+
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+	head := tss[len(tss)-1]
+
+	panics := true
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			if panics {
+				panic("vm bug")
+			}
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+
+	requireFreed := func() {
+		sm.stlk.Lock()
+		defer sm.stlk.Unlock()
+		require.Empty(t, sm.compWait)
+	}
+
+	// Mainnet doesn't recover by default.
+	build.BuildType, recoverExecutionPanics = build.BuildMainnet, false
+	require.PanicsWithValue(t, "vm bug", func() { _, _, _ = sm.TipSetState(ctx, head) })
+	requireFreed()
+
+	recoverExecutionPanics = true
+	_, _, err := sm.TipSetState(ctx, head)
+	require.ErrorIs(t, err, ErrTipSetExecutionPanic)
+	require.Contains(t, err.Error(), head.Key().String())
+	requireFreed()
+
+	build.BuildType, recoverExecutionPanics = build.Build2k, false
+	_, _, err = sm.TipSetState(ctx, head)
+	require.ErrorIs(t, err, ErrTipSetExecutionPanic)
+	requireFreed()
+
+	// The tipset can still be computed once the executor behaves.
+	panics = false
+	st, _, err := sm.TipSetState(ctx, head)
+	require.NoError(t, err)
+	require.Equal(t, head.Cids()[0], st)
+}
+
+func TestTipSetStateProgress(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+
+	var sm *StateManager
+	var inFlight []ExecutionProgress
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			for i := uint64(0); i < 2; i++ {
+				msg := &types.Message{Nonce: i}
+				if err := em.MessageApplied(ctx, ts, msg.Cid(), msg, &vm.ApplyRet{MessageReceipt: types.MessageReceipt{GasUsed: 10}}, false); err != nil {
+					return cid.Undef, cid.Undef, err
+				}
+			}
+			cron := &types.Message{Method: 2}
+			if err := em.MessageApplied(ctx, ts, cron.Cid(), cron, &vm.ApplyRet{MessageReceipt: types.MessageReceipt{GasUsed: 5}}, true); err != nil {
+				return cid.Undef, cid.Undef, err
+			}
+			inFlight = sm.ExecutionProgress()
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	sm = newTestStateManager(t, cs, exec)
+
+	var reported []ExecutionProgress
+	_, _, err := sm.TipSetStateWithOpts(ctx, tss[1], TipSetStateOpts{
+		Progress: func(p ExecutionProgress) { reported = append(reported, p) },
+	})
+	require.NoError(t, err)
+
+	require.Len(t, reported, 3)
+	require.Equal(t, 1, reported[0].MessagesApplied)
+	require.Equal(t, int64(10), reported[0].GasUsed)
+	require.Equal(t, 2, reported[2].MessagesApplied)
+	require.Equal(t, int64(25), reported[2].GasUsed)
+	require.Equal(t, tss[1].Key(), reported[2].TipSet)
+
+	require.Len(t, inFlight, 1)
+	require.Equal(t, reported[2].GasUsed, inFlight[0].GasUsed)
+	require.Empty(t, sm.ExecutionProgress())
+}
+
+func TestProgressCountsMessagesLazily(t *testing.T) {
+	ctx := context.Background()
+
+	var counted int
+	p := &progressMonitor{countTotal: func() int {
+		counted++
+		return 2
+	}}
+	msg := &types.Message{}
+	ret := &vm.ApplyRet{MessageReceipt: types.MessageReceipt{GasUsed: 10}}
+
+	// Without a reporter, applying messages doesn't load the tipset's messages.
+	require.NoError(t, p.MessageApplied(ctx, nil, msg.Cid(), msg, ret, false))
+	require.Zero(t, counted)
+
+	// They are loaded, once, when the progress is first read.
+	require.Equal(t, 2, p.get().MessagesTotal)
+	require.Equal(t, 1, p.get().MessagesApplied)
+	require.Equal(t, 1, counted)
+}
+
+func TestExecutionTraceMaxNodes(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 2)
+
+	// Each message has a root call with three subcalls.
+	et := types.ExecutionTrace{Subcalls: make([]types.ExecutionTrace, 3)}
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			for i := uint64(0); i < 2; i++ {
+				msg := &types.Message{Nonce: i}
+				if err := em.MessageApplied(ctx, ts, msg.Cid(), msg, &vm.ApplyRet{ExecutionTrace: et}, false); err != nil {
+					return cid.Undef, cid.Undef, err
+				}
+			}
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+	sm.SetExecutionTraceMaxNodes(6)
+
+	_, trace, err := sm.ExecutionTrace(ctx, tss[1])
+	require.NoError(t, err)
+	require.Len(t, trace, 2)
+	require.False(t, trace[0].Truncated)
+	require.Len(t, trace[0].ExecutionTrace.Subcalls, 3)
+	require.True(t, trace[1].Truncated)
+	require.Equal(t, 2, trace[1].OmittedNodes)
+	require.Len(t, trace[1].ExecutionTrace.Subcalls, 1)
+}
+
+func TestSpeculativeExecute(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 3)
+	release := make(chan struct{})
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			<-release
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	sm := newTestStateManager(t, cs, exec)
+
+	// Disabled by default.
+	sm.SpeculativeExecute(tss[1])
+	require.Equal(t, 0, exec.callCount())
+
+	sm.SetSpeculativeExecution(true)
+	sm.SpeculativeExecute(tss[1])
+	require.Eventually(t, func() bool { return exec.callCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// Only one speculative execution runs at a time.
+	sm.SpeculativeExecute(tss[2])
+	close(release)
+
+	require.Eventually(t, func() bool {
+		status, err := sm.TipSetStateStatus(ctx, tss[1])
+		return err == nil && status == StatusCached
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, exec.callCount())
+}
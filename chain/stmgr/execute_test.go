@@ -0,0 +1,304 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+func TestStateForwardFrom(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	const n = 5
+	var tss []*types.TipSet
+	for i := 0; i < n; i++ {
+		mts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		tss = append(tss, mts.TipSet.TipSet())
+	}
+
+	// A fresh state manager has nothing cached, so every step is executed.
+	sm, err := stmgr.NewStateManager(cg.ChainStore(), consensus.NewTipSetExecutor(filcns.RewardFunc), cg.StateManager().VMSys(),
+		filcns.DefaultUpgradeSchedule(), cg.BeaconSchedule(), datastore.NewMapDatastore(), index.DummyMsgIndex)
+	require.NoError(t, err)
+
+	genTs, err := cg.ChainStore().GetGenesis(ctx)
+	require.NoError(t, err)
+	base, err := types.NewTipSet([]*types.BlockHeader{genTs})
+	require.NoError(t, err)
+
+	st, err := sm.StateForwardFrom(ctx, base, base.ParentState(), 0)
+	require.NoError(t, err)
+	require.Equal(t, base.ParentState(), st)
+
+	// The state of each tipset is recorded as the parent state of its child.
+	for i := 0; i < n-1; i++ {
+		st, err := sm.StateForwardFrom(ctx, base, base.ParentState(), tss[i].Height())
+		require.NoError(t, err)
+		require.Equal(t, tss[i+1].ParentState(), st, "height %d", tss[i].Height())
+	}
+
+	_, err = sm.StateForwardFrom(ctx, tss[1], tss[2].ParentState(), tss[0].Height())
+	require.Error(t, err)
+
+	_, err = sm.StateForwardFrom(ctx, base, base.ParentState(), tss[n-1].Height()+1)
+	require.Error(t, err)
+
+	// A lighter fork off genesis is not on the canonical chain.
+	fork, err := cg.NextTipSetFromMiners(base, cg.Miners[1:], 0)
+	require.NoError(t, err)
+	forkTs := fork.TipSet.TipSet()
+	_, err = sm.StateForwardFrom(ctx, forkTs, forkTs.ParentState(), tss[n-1].Height())
+	require.Error(t, err)
+}
+
+func TestTouchedActors(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	to, err := cg.Wallet().WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	var nonce uint64
+	var value types.BigInt
+	cg.GetMessages = func(cg *gen.ChainGen) ([]*types.SignedMessage, error) {
+		msg := types.Message{
+			From:       cg.Banker(),
+			To:         to,
+			Nonce:      nonce,
+			Value:      value,
+			GasLimit:   100_000_000,
+			GasFeeCap:  types.NewInt(0),
+			GasPremium: types.NewInt(0),
+		}
+		nonce++
+		sig, err := cg.Wallet().WalletSign(ctx, cg.Banker(), msg.Cid().Bytes(), api.MsgMeta{Type: api.MTUnknown})
+		if err != nil {
+			return nil, err
+		}
+		return []*types.SignedMessage{{Message: msg, Signature: *sig}}, nil
+	}
+	sm := cg.StateManager()
+
+	touched := func(ts *types.TipSet) (fromID, toID address.Address, touched []address.Address) {
+		touched, err := sm.TouchedActors(ctx, ts)
+		require.NoError(t, err)
+
+		st, rec, err := sm.TipSetState(ctx, ts)
+		require.NoError(t, err)
+		rcpts, err := sm.ChainStore().ReadReceipts(ctx, rec)
+		require.NoError(t, err)
+		require.Len(t, rcpts, 1)
+		require.True(t, rcpts[0].ExitCode.IsSuccess())
+
+		stree, err := sm.StateTree(st)
+		require.NoError(t, err)
+		fromID, err = stree.LookupID(cg.Banker())
+		require.NoError(t, err)
+		toID, err = stree.LookupID(to)
+		require.NoError(t, err)
+		return fromID, toID, touched
+	}
+
+	// A transfer modifies both participants.
+	value = types.NewInt(1000)
+	mts, err := cg.NextTipSet()
+	require.NoError(t, err)
+	fromID, toID, actors := touched(mts.TipSet.TipSet())
+	require.ElementsMatch(t, []address.Address{fromID, toID}, actors)
+
+	// A successful call leaving the receiver unchanged only modifies the sender.
+	value = types.NewInt(0)
+	mts, err = cg.NextTipSet()
+	require.NoError(t, err)
+	fromID, _, actors = touched(mts.TipSet.TipSet())
+	require.Equal(t, []address.Address{fromID}, actors)
+}
+
+func TestExecutionTraceFull(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	mts, err := cg.NextTipSet()
+	require.NoError(t, err)
+	ts := mts.TipSet.TipSet()
+	sm := cg.StateManager()
+
+	st, rec, invocTrace, err := sm.ExecutionTraceFull(ctx, ts)
+	require.NoError(t, err)
+	require.NotEmpty(t, invocTrace)
+
+	expSt, expRec, err := sm.TipSetState(ctx, ts)
+	require.NoError(t, err)
+	require.Equal(t, expSt, st)
+	require.Equal(t, expRec, rec)
+}
+
+func TestExecuteTipSetWithNetworkVersionOverride(t *testing.T) {
+	defer func(bt int) { build.BuildType = bt }(build.BuildType)
+	build.BuildType = build.Build2k
+
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	mts, err := cg.NextTipSet()
+	require.NoError(t, err)
+	ts := mts.TipSet.TipSet()
+	sm := cg.StateManager()
+
+	expSt, expRec, err := sm.TipSetState(ctx, ts)
+	require.NoError(t, err)
+
+	// The generated chain runs v0 actors, which are what the VM routes to at network version 0.
+	nv0 := network.Version0
+	st, rec, err := sm.ExecuteTipSetWithOpts(ctx, ts, nil, false, stmgr.ExecutorOpts{NetworkVersionOverride: &nv0})
+	require.NoError(t, err)
+	require.Equal(t, expSt, st)
+	require.Equal(t, expRec, rec)
+
+	// At network version 4 the VM only accepts v2 actors, so invoking the chain's v0 actors fails.
+	nv4 := network.Version4
+	_, _, err = sm.ExecuteTipSetWithOpts(ctx, ts, nil, false, stmgr.ExecutorOpts{NetworkVersionOverride: &nv4})
+	require.ErrorContains(t, err, "version 0 actor")
+
+	build.BuildType = build.BuildMainnet
+	_, _, err = sm.ExecuteTipSetWithOpts(ctx, ts, nil, false, stmgr.ExecutorOpts{NetworkVersionOverride: &nv0})
+	require.ErrorIs(t, err, stmgr.ErrExecutorOptsOnMainnet)
+}
+
+func TestTipSetStatePrefix(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	mts, err := cg.NextTipSet()
+	require.NoError(t, err)
+	ts := mts.TipSet.TipSet()
+	sm := cg.StateManager()
+
+	msgs, err := sm.ChainStore().MessagesForTipset(ctx, ts)
+	require.NoError(t, err)
+	require.NotEmpty(t, msgs)
+
+	st, _, err := sm.TipSetStatePrefix(ctx, ts, 0)
+	require.NoError(t, err)
+	require.Equal(t, ts.ParentState(), st)
+
+	seen := map[cid.Cid]struct{}{st: {}}
+	var rec cid.Cid
+	for count := 1; count <= len(msgs); count++ {
+		st, rec, err = sm.TipSetStatePrefix(ctx, ts, count)
+		require.NoError(t, err)
+		// every message moves funds, so each prefix yields a distinct state
+		require.NotContains(t, seen, st)
+		seen[st] = struct{}{}
+	}
+
+	expSt, expRec, err := sm.TipSetState(ctx, ts)
+	require.NoError(t, err)
+	require.Equal(t, expSt, st)
+	require.Equal(t, expRec, rec)
+
+	_, _, err = sm.TipSetStatePrefix(ctx, ts, len(msgs)+1)
+	require.Error(t, err)
+	_, _, err = sm.TipSetStatePrefix(ctx, ts, -1)
+	require.Error(t, err)
+}
+
+func TestExecutionTraceFrames(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	mts, err := cg.NextTipSet()
+	require.NoError(t, err)
+	ts := mts.TipSet.TipSet()
+	sm := cg.StateManager()
+
+	st, invocTrace, err := sm.ExecutionTrace(ctx, ts)
+	require.NoError(t, err)
+
+	frames, err := sm.TraceFrames(ctx, st, invocTrace)
+	require.NoError(t, err)
+
+	var calls int
+	var count func(types.ExecutionTrace)
+	count = func(et types.ExecutionTrace) {
+		calls++
+		for _, sub := range et.Subcalls {
+			count(sub)
+		}
+	}
+	for _, ir := range invocTrace {
+		count(ir.ExecutionTrace)
+	}
+	require.Len(t, frames, calls)
+
+	// Every message starts at depth 0, and depth only grows one call at a time.
+	require.Equal(t, 0, frames[0].Depth)
+	for i := 1; i < len(frames); i++ {
+		if frames[i].MsgCid != frames[i-1].MsgCid {
+			require.Equal(t, 0, frames[i].Depth)
+		} else {
+			require.LessOrEqual(t, frames[i].Depth, frames[i-1].Depth+1)
+		}
+		require.True(t, frames[i].ToCode.Defined())
+	}
+}
+
+func TestGasProfile(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	mts, err := cg.NextTipSet()
+	require.NoError(t, err)
+	ts := mts.TipSet.TipSet()
+	sm := cg.StateManager()
+
+	profile, err := sm.GasProfile(ctx, ts)
+	require.NoError(t, err)
+	require.NotEmpty(t, profile.Entries)
+
+	_, invocTrace, err := sm.ExecutionTrace(ctx, ts)
+	require.NoError(t, err)
+	var total int64
+	var sum func(types.ExecutionTrace)
+	sum = func(et types.ExecutionTrace) {
+		total += et.SumGas().TotalGas
+		for _, sub := range et.Subcalls {
+			sum(sub)
+		}
+	}
+	for _, ir := range invocTrace {
+		sum(ir.ExecutionTrace)
+	}
+	require.Equal(t, total, profile.TotalGasCharged)
+
+	for i := 1; i < len(profile.Entries); i++ {
+		require.GreaterOrEqual(t, profile.Entries[i-1].GasCharged, profile.Entries[i].GasCharged)
+	}
+}
@@ -159,6 +159,33 @@ func (us UpgradeSchedule) Validate() error {
 	return nil
 }
 
+// WithPreMigrationLookahead returns a copy of the schedule where the first pre-migration of every
+// upgrade starts within the given number of epochs of the upgrade, instead of its default. The
+// later pre-migrations are left as they are.
+func (us UpgradeSchedule) WithPreMigrationLookahead(epochs abi.ChainEpoch) (UpgradeSchedule, error) {
+	out := make(UpgradeSchedule, len(us))
+	for i, u := range us {
+		if len(u.PreMigrations) > 0 {
+			pms := append([]PreMigration(nil), u.PreMigrations...)
+			first := &pms[0]
+			minEpochs := first.StopWithin
+			if first.DontStartWithin > minEpochs {
+				minEpochs = first.DontStartWithin
+			}
+			if len(pms) > 1 && pms[1].StartWithin > minEpochs {
+				minEpochs = pms[1].StartWithin
+			}
+			if epochs <= minEpochs {
+				return nil, xerrors.Errorf("pre-migration lookahead %d for network version %d must be larger than %d", epochs, u.Network, minEpochs)
+			}
+			first.StartWithin = epochs
+			u.PreMigrations = pms
+		}
+		out[i] = u
+	}
+	return out, nil
+}
+
 func (us UpgradeSchedule) GetNtwkVersion(e abi.ChainEpoch) (network.Version, error) {
 	// Traverse from newest to oldest returning upgrade active during epoch e
 	for i := len(us) - 1; i >= 0; i-- {
@@ -709,3 +709,36 @@ func TestMigrtionCache(t *testing.T) {
 		require.Equal(t, 1, len(counter))
 	}
 }
+
+func TestPreMigrationLookahead(t *testing.T) {
+	noop := func(context.Context, *StateManager, MigrationCache, cid.Cid, abi.ChainEpoch, *types.TipSet) error {
+		return nil
+	}
+	us := UpgradeSchedule{{
+		Network: network.Version9,
+		Height:  100,
+		PreMigrations: []PreMigration{{
+			PreMigration:    noop,
+			StartWithin:     120,
+			DontStartWithin: 60,
+			StopWithin:      35,
+		}, {
+			PreMigration:    noop,
+			StartWithin:     30,
+			DontStartWithin: 15,
+			StopWithin:      5,
+		}},
+	}}
+
+	longer, err := us.WithPreMigrationLookahead(600)
+	require.NoError(t, err)
+	require.NoError(t, longer.Validate())
+	require.Equal(t, abi.ChainEpoch(600), longer[0].PreMigrations[0].StartWithin)
+	require.Equal(t, abi.ChainEpoch(30), longer[0].PreMigrations[1].StartWithin)
+
+	// The original schedule is left untouched.
+	require.Equal(t, abi.ChainEpoch(120), us[0].PreMigrations[0].StartWithin)
+
+	_, err = us.WithPreMigrationLookahead(60)
+	require.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package stmgr
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// GasProfile executes ts with tracing enabled (or uses a cached trace), and aggregates the gas
+// charged within each call, excluding its subcalls, by receiver code and method.
+func (sm *StateManager) GasProfile(ctx context.Context, ts *types.TipSet) (*api.GasProfile, error) {
+	frames, err := sm.ExecutionTraceFrames(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		code   cid.Cid
+		method abi.MethodNum
+	}
+	entries := make(map[key]*api.GasProfileEntry)
+	out := &api.GasProfile{TipSet: ts.Key()}
+	for _, f := range frames {
+		k := key{code: f.ToCode, method: f.Method}
+		e, ok := entries[k]
+		if !ok {
+			e = &api.GasProfileEntry{Code: f.ToCode, Method: f.Method}
+			entries[k] = e
+		}
+		e.Calls++
+		e.GasCharged += f.GasCharged
+		out.TotalGasCharged += f.GasCharged
+	}
+
+	out.Entries = make([]api.GasProfileEntry, 0, len(entries))
+	for _, e := range entries {
+		out.Entries = append(out.Entries, *e)
+	}
+	sort.Slice(out.Entries, func(i, j int) bool {
+		a, b := out.Entries[i], out.Entries[j]
+		if a.GasCharged != b.GasCharged {
+			return a.GasCharged > b.GasCharged
+		}
+		if a.Code != b.Code {
+			return a.Code.KeyString() < b.Code.KeyString()
+		}
+		return a.Method < b.Method
+	})
+	return out, nil
+}
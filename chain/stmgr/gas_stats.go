@@ -0,0 +1,102 @@
+package stmgr
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// UnknownActorName is used in api.EpochGasStats.MessagesByActor for receivers which didn't exist
+// before the tipset was executed.
+const UnknownActorName = "<unknown>"
+
+// EpochGasStats returns the gas statistics of every tipset on the chain from `from` to `to`
+// (inclusive), ordered by height. Tipsets whose state isn't known are executed.
+func (sm *StateManager) EpochGasStats(ctx context.Context, from, to *types.TipSet) ([]*api.EpochGasStats, error) {
+	if to.Height() < from.Height() {
+		return nil, xerrors.Errorf("range end %d is below range start %d", to.Height(), from.Height())
+	}
+
+	var out []*api.EpochGasStats
+	for ts := to; ; {
+		st, err := sm.epochGasStats(ctx, ts)
+		if err != nil {
+			return nil, xerrors.Errorf("computing gas stats at height %d: %w", ts.Height(), err)
+		}
+		out = append(out, st)
+
+		if ts.Height() <= from.Height() {
+			if ts.Key() != from.Key() {
+				return nil, xerrors.Errorf("range start %s is not an ancestor of range end %s", from.Key(), to.Key())
+			}
+			break
+		}
+		if ts, err = sm.cs.LoadTipSet(ctx, ts.Parents()); err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+func (sm *StateManager) epochGasStats(ctx context.Context, ts *types.TipSet) (*api.EpochGasStats, error) {
+	msgs, err := sm.cs.MessagesForTipset(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading messages: %w", err)
+	}
+
+	_, rec, err := sm.TipSetState(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("computing tipset state: %w", err)
+	}
+	receipts, err := sm.cs.ReadReceipts(ctx, rec)
+	if err != nil {
+		return nil, xerrors.Errorf("loading receipts: %w", err)
+	}
+	if len(receipts) != len(msgs) {
+		return nil, xerrors.Errorf("have %d receipts for %d messages", len(receipts), len(msgs))
+	}
+
+	stree, err := sm.ParentState(ts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent state: %w", err)
+	}
+
+	out := &api.EpochGasStats{
+		TipSet:          ts.Key(),
+		Height:          ts.Height(),
+		Blocks:          len(ts.Blocks()),
+		BaseFee:         ts.Blocks()[0].ParentBaseFee,
+		Messages:        len(msgs),
+		MessagesByActor: make(map[string]int),
+	}
+	for i, m := range msgs {
+		msg := m.VMMessage()
+		out.GasLimit += msg.GasLimit
+		out.GasUsed += receipts[i].GasUsed
+
+		name := UnknownActorName
+		if act, err := stree.GetActor(msg.To); err == nil {
+			name = builtin.ActorNameByCode(act.Code)
+		} else if !xerrors.Is(err, types.ErrActorNotFound) {
+			return nil, xerrors.Errorf("loading receiver %s: %w", msg.To, err)
+		}
+		out.MessagesByActor[name]++
+	}
+
+	out.Utilization = float64(out.GasLimit) / float64(build.BlockGasLimit*int64(out.Blocks))
+	if out.GasUsed > 0 {
+		out.OverEstimation = float64(out.GasLimit) / float64(out.GasUsed)
+	}
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package stmgr
+
+import (
+	"context"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ImplicitTrace returns the invocation results of the implicit messages (block rewards and cron
+// ticks) applied while executing ts, in execution order. Implicit messages are the only ones
+// sent by the system actor.
+func (sm *StateManager) ImplicitTrace(ctx context.Context, ts *types.TipSet) ([]*api.InvocResult, error) {
+	_, trace, err := sm.ExecutionTrace(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*api.InvocResult
+	for _, ir := range trace {
+		if ir.Msg != nil && ir.Msg.From == builtin.SystemActorAddr {
+			out = append(out, ir)
+		}
+	}
+	return out, nil
+}
+
+// CronTrace returns the invocation results of the cron ticks run while executing ts. There is
+// one per epoch since the parent tipset, including null rounds, the last one being the tick for
+// the epoch of ts itself.
+func (sm *StateManager) CronTrace(ctx context.Context, ts *types.TipSet) ([]*api.InvocResult, error) {
+	implicit, err := sm.ImplicitTrace(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*api.InvocResult
+	for _, ir := range implicit {
+		if ir.Msg.To == builtin.CronActorAddr {
+			out = append(out, ir)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,196 @@
+package stmgr
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// execMonitorSocket, if set, is the path of a unix socket to which the results of every message
+// applied while executing tipsets are streamed. Set with LOTUS_EXEC_MONITOR_SOCKET.
+var execMonitorSocket = ""
+
+var _ ExecMonitor = (*execMonitors)(nil)
+
+// execMonitors fans applied messages out to the monitor set at construction time and to the
+// monitors attached at runtime.
+type execMonitors struct {
+	lk       sync.RWMutex
+	next     uint64
+	attached map[uint64]ExecMonitor
+}
+
+func (m *execMonitors) attach(em ExecMonitor) func() {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	if m.attached == nil {
+		m.attached = make(map[uint64]ExecMonitor)
+	}
+	id := m.next
+	m.next++
+	m.attached[id] = em
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.lk.Lock()
+			delete(m.attached, id)
+			m.lk.Unlock()
+		})
+	}
+}
+
+func (m *execMonitors) empty() bool {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+	return len(m.attached) == 0
+}
+
+// MessageApplied forwards the message to every attached monitor. All monitors are called even if
+// some of them fail; the errors are combined.
+func (m *execMonitors) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+
+	var merr error
+	for _, em := range m.attached {
+		if err := em.MessageApplied(ctx, ts, mcid, msg, ret, implicit); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+	return merr
+}
+
+// AttachExecMonitor attaches em to every subsequent tipset execution performed by TipSetState,
+// in addition to the monitor the StateManager was constructed with. Like any ExecMonitor, em halts
+// the execution by returning an error. The returned function detaches it.
+func (sm *StateManager) AttachExecMonitor(em ExecMonitor) (detach func()) {
+	return sm.execMonitors.attach(em)
+}
+
+// execMonitor returns the monitor to pass to the executor: the construction-time monitor followed
+// by the attached ones, or nil if there are none.
+func (sm *StateManager) execMonitor() ExecMonitor {
+	if sm.execMonitors.empty() {
+		return sm.tsExecMonitor
+	}
+	if sm.tsExecMonitor == nil {
+		return &sm.execMonitors
+	}
+	return chainedMonitor{sm.tsExecMonitor, &sm.execMonitors}
+}
+
+// chainedMonitor calls its monitors in order, stopping at the first error.
+type chainedMonitor []ExecMonitor
+
+func (c chainedMonitor) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	for _, em := range c {
+		if err := em.MessageApplied(ctx, ts, mcid, msg, ret, implicit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// socketMonitorBuffer is the number of results SocketExecMonitor queues before dropping them.
+const socketMonitorBuffer = 1024
+
+// SocketExecMonitorEvent is the JSON object, one per line, streamed by SocketExecMonitor.
+// Execution traces are only populated for executions run with VM tracing enabled.
+type SocketExecMonitorEvent struct {
+	TipSet   types.TipSetKey
+	Height   abi.ChainEpoch
+	Implicit bool
+	Result   *api.InvocResult
+}
+
+var _ ExecMonitor = (*SocketExecMonitor)(nil)
+
+// SocketExecMonitor streams the result of every applied message to a unix socket, as newline
+// delimited JSON. It never slows down or halts execution: results are queued and dropped when the
+// queue is full or the socket can't be reached, in which case it redials on the next result.
+type SocketExecMonitor struct {
+	path  string
+	queue chan *SocketExecMonitorEvent
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	done      chan struct{}
+}
+
+// NewSocketExecMonitor returns a monitor streaming to the unix socket at path. It must be closed
+// with Close once detached.
+func NewSocketExecMonitor(path string) *SocketExecMonitor {
+	m := &SocketExecMonitor{
+		path:    path,
+		queue:   make(chan *SocketExecMonitorEvent, socketMonitorBuffer),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+func (m *SocketExecMonitor) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	var trace []*api.InvocResult
+	if err := (&InvocationTracer{trace: &trace}).MessageApplied(ctx, ts, mcid, msg, ret, implicit); err != nil {
+		return err
+	}
+
+	select {
+	case m.queue <- &SocketExecMonitorEvent{TipSet: ts.Key(), Height: ts.Height(), Implicit: implicit, Result: trace[0]}:
+	default:
+		log.Debugw("exec monitor socket queue full, dropping result", "path", m.path, "msg", mcid)
+	}
+	return nil
+}
+
+func (m *SocketExecMonitor) run() {
+	defer close(m.done)
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case ev := <-m.queue:
+			if conn == nil {
+				var err error
+				if conn, err = net.Dial("unix", m.path); err != nil {
+					log.Debugw("failed to dial exec monitor socket, dropping result", "path", m.path, "error", err)
+					conn = nil
+					continue
+				}
+			}
+			if err := json.NewEncoder(conn).Encode(ev); err != nil {
+				log.Warnw("failed to write to exec monitor socket", "path", m.path, "error", err)
+				_ = conn.Close()
+				conn = nil
+			}
+		case <-m.closing:
+			return
+		}
+	}
+}
+
+// Close stops streaming, discarding any queued results.
+func (m *SocketExecMonitor) Close() error {
+	m.closeOnce.Do(func() { close(m.closing) })
+	<-m.done
+	return nil
+}
@@ -0,0 +1,88 @@
+package stmgr
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+type countingMonitor struct {
+	applied int
+}
+
+func (c *countingMonitor) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	c.applied++
+	return nil
+}
+
+func TestAttachExecMonitor(t *testing.T) {
+	ctx := context.Background()
+	cs, tss := newTestChain(t, 4)
+
+	exec := &testExecutor{
+		execFn: func(ctx context.Context, ts *types.TipSet, em ExecMonitor, opts ExecutorOpts) (cid.Cid, cid.Cid, error) {
+			msg := &types.Message{Nonce: uint64(ts.Height())}
+			if err := em.MessageApplied(ctx, ts, msg.Cid(), msg, &vm.ApplyRet{MessageReceipt: types.MessageReceipt{GasUsed: 7}}, false); err != nil {
+				return cid.Undef, cid.Undef, err
+			}
+			return ts.Cids()[0], ts.Cids()[0], nil
+		},
+	}
+	base := &countingMonitor{}
+	sm, err := NewStateManagerWithUpgradeScheduleAndMonitor(cs, exec, nil, nil, nil, base, datastore.NewMapDatastore(), index.DummyMsgIndex)
+	require.NoError(t, err)
+
+	sock := filepath.Join(t.TempDir(), "monitor.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	defer l.Close() //nolint:errcheck
+
+	events := make(chan SocketExecMonitorEvent)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			var ev SocketExecMonitorEvent
+			if json.Unmarshal(sc.Bytes(), &ev) == nil {
+				events <- ev
+			}
+		}
+	}()
+
+	attached := &countingMonitor{}
+	detach := sm.AttachExecMonitor(attached)
+	sockMon := NewSocketExecMonitor(sock)
+	defer sockMon.Close() //nolint:errcheck
+	detachSock := sm.AttachExecMonitor(sockMon)
+
+	_, _, err = sm.TipSetState(ctx, tss[1])
+	require.NoError(t, err)
+	require.Equal(t, 1, base.applied)
+	require.Equal(t, 1, attached.applied)
+
+	ev := <-events
+	require.Equal(t, tss[1].Key(), ev.TipSet)
+	require.Equal(t, int64(7), ev.Result.MsgRct.GasUsed)
+
+	detach()
+	detachSock()
+	_, _, err = sm.TipSetState(ctx, tss[2])
+	require.NoError(t, err)
+	require.Equal(t, 2, base.applied)
+	require.Equal(t, 1, attached.applied)
+}
@@ -0,0 +1,129 @@
+package stmgr
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// ExecutionProgress describes how far the execution of a tipset has progressed. Implicit messages
+// (rewards and cron) count towards GasUsed, but not towards MessagesApplied.
+type ExecutionProgress struct {
+	TipSet          types.TipSetKey
+	Height          abi.ChainEpoch
+	Started         time.Time
+	MessagesApplied int
+	MessagesTotal   int
+	GasUsed         int64
+}
+
+var _ ExecMonitor = (*progressMonitor)(nil)
+
+// progressMonitor tracks the progress of a tipset execution, and forwards every applied message
+// to the next monitor. The messages of the tipset are only counted once the progress is first
+// needed, either by report or by a call to ExecutionProgress, so that executions nobody follows
+// don't pay for loading them.
+type progressMonitor struct {
+	next       ExecMonitor
+	report     func(ExecutionProgress)
+	countTotal func() int
+
+	totalOnce sync.Once
+
+	lk       sync.Mutex
+	progress ExecutionProgress
+}
+
+func (p *progressMonitor) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	if p.report != nil {
+		p.loadTotal()
+	}
+
+	p.lk.Lock()
+	if !implicit {
+		p.progress.MessagesApplied++
+	}
+	p.progress.GasUsed += ret.GasUsed
+	progress := p.progress
+	p.lk.Unlock()
+
+	if p.report != nil {
+		p.report(progress)
+	}
+	if p.next != nil {
+		return p.next.MessageApplied(ctx, ts, mcid, msg, ret, implicit)
+	}
+	return nil
+}
+
+func (p *progressMonitor) loadTotal() {
+	p.totalOnce.Do(func() {
+		total := p.countTotal()
+		p.lk.Lock()
+		p.progress.MessagesTotal = total
+		p.lk.Unlock()
+	})
+}
+
+func (p *progressMonitor) get() ExecutionProgress {
+	p.loadTotal()
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	return p.progress
+}
+
+// trackProgress registers a progress monitor for the execution of ts, wrapping next. The returned
+// function unregisters it.
+func (sm *StateManager) trackProgress(ctx context.Context, ts *types.TipSet, next ExecMonitor, report func(ExecutionProgress)) (ExecMonitor, func()) {
+	p := &progressMonitor{
+		next:   next,
+		report: report,
+		countTotal: func() int {
+			msgs, err := sm.cs.MessagesForTipset(ctx, ts)
+			if err != nil {
+				log.Warnw("failed to count tipset messages for progress reporting", "tipset", ts.Key(), "error", err)
+				return 0
+			}
+			return len(msgs)
+		},
+		progress: ExecutionProgress{
+			TipSet:  ts.Key(),
+			Height:  ts.Height(),
+			Started: time.Now(),
+		},
+	}
+
+	sm.progressLk.Lock()
+	sm.execProgress[p] = struct{}{}
+	sm.progressLk.Unlock()
+
+	return p, func() {
+		sm.progressLk.Lock()
+		delete(sm.execProgress, p)
+		sm.progressLk.Unlock()
+	}
+}
+
+// ExecutionProgress returns the progress of every tipset execution currently in flight.
+func (sm *StateManager) ExecutionProgress() []ExecutionProgress {
+	sm.progressLk.Lock()
+	monitors := make([]*progressMonitor, 0, len(sm.execProgress))
+	for p := range sm.execProgress {
+		monitors = append(monitors, p)
+	}
+	sm.progressLk.Unlock()
+
+	out := make([]ExecutionProgress, 0, len(monitors))
+	for _, p := range monitors {
+		out = append(out, p.get())
+	}
+	return out
+}
@@ -22,6 +22,12 @@ func (sm *StateManager) ParentStateTsk(ctx context.Context, tsk types.TipSetKey)
 }
 
 func (sm *StateManager) ParentState(ts *types.TipSet) (*state.StateTree, error) {
+	if ts != nil {
+		if err := sm.cs.CheckStatePruned(ts.Height()); err != nil {
+			return nil, err
+		}
+	}
+
 	cst := cbor.NewCborStore(sm.cs.StateBlockstore())
 	state, err := state.LoadStateTree(cst, sm.parentState(ts))
 	if err != nil {
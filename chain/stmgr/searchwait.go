@@ -8,6 +8,7 @@ import (
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/chain/index"
@@ -55,6 +56,7 @@ func (sm *StateManager) WaitForMessage(ctx context.Context, mcid cid.Cid, confid
 	var backTs *types.TipSet
 	var backRcp *types.MessageReceipt
 	var backFm cid.Cid
+	var backErr error
 	backSearchWait := make(chan struct{})
 	go func() {
 		fts, r, foundMsg, err := sm.searchForIndexedMsg(ctx, mcid, msg)
@@ -64,6 +66,14 @@ func (sm *StateManager) WaitForMessage(ctx context.Context, mcid cid.Cid, confid
 			fts, r, foundMsg, err = sm.searchBackForMsg(ctx, head[0].Val, msg, lookbackLimit, allowReplaced)
 			if err != nil {
 				log.Warnf("failed to look back through chain for message: %v", err)
+
+				// the message was replaced before we started waiting, report it rather than
+				// waiting forever
+				var rerr *ReplacedError
+				if errors.As(err, &rerr) {
+					backErr = rerr
+					close(backSearchWait)
+				}
 				return
 			}
 		}
@@ -117,6 +127,10 @@ func (sm *StateManager) WaitForMessage(ctx context.Context, mcid cid.Cid, confid
 				}
 			}
 		case <-backSearchWait:
+			if backErr != nil {
+				return nil, nil, cid.Undef, backErr
+			}
+
 			// check if we found the message in the chain and that is hasn't been reverted since we started searching
 			if backTs != nil && !reverts[backTs.Key()] {
 				// if head is at or past confidence interval, return immediately
@@ -202,11 +216,28 @@ func (sm *StateManager) searchForIndexedMsg(ctx context.Context, mcid cid.Cid, m
 		return nil, nil, cid.Undef, xerrors.Errorf("indexed message does not appear before the current tipset; index epoch: %d, current epoch: %d", minfo.Epoch, curTs.Height())
 	}
 
-	// now get the execution tipset
-	// TODO optimization: the index should have it implicitly so we can return it in the msginfo.
-	xts, err := sm.cs.GetTipsetByHeight(ctx, minfo.Epoch+1, curTs, false)
-	if err != nil {
-		return nil, nil, cid.Undef, xerrors.Errorf("error looking up execution tipset: %w", err)
+	// now get the execution tipset; the index records it once the inclusion tipset has a child,
+	// otherwise fall back to the first tipset after the inclusion epoch
+	var xts *types.TipSet
+	if minfo.ExecutionTipSet != types.EmptyTSK {
+		xts, err = sm.cs.LoadTipSet(ctx, minfo.ExecutionTipSet)
+		if err != nil {
+			return nil, nil, cid.Undef, xerrors.Errorf("error loading indexed execution tipset: %w", err)
+		}
+
+		// the indexed execution tipset must be on the current chain
+		cts, err := sm.cs.GetTipsetByHeight(ctx, xts.Height(), curTs, false)
+		if err != nil {
+			return nil, nil, cid.Undef, xerrors.Errorf("error looking up execution tipset: %w", err)
+		}
+		if cts.Key() != xts.Key() {
+			return nil, nil, cid.Undef, xerrors.Errorf("indexed execution tipset %s is not in the current chain", xts.Key())
+		}
+	} else {
+		xts, err = sm.cs.GetTipsetByHeight(ctx, minfo.Epoch+1, curTs, false)
+		if err != nil {
+			return nil, nil, cid.Undef, xerrors.Errorf("error looking up execution tipset: %w", err)
+		}
 	}
 
 	// check that the parent of the execution index is indeed the inclusion tipset
@@ -220,6 +251,15 @@ func (sm *StateManager) searchForIndexedMsg(ctx context.Context, mcid cid.Cid, m
 		return nil, nil, cid.Undef, xerrors.Errorf("inclusion tipset mismatch: have %s, expected %s", parentCid, minfo.TipSet)
 	}
 
+	// the index knows where the receipt is, so there is no need to scan the tipset messages
+	if minfo.ReceiptIndex >= 0 {
+		r, err := sm.cs.GetParentReceipt(ctx, xts.Blocks()[0], int(minfo.ReceiptIndex))
+		if err != nil {
+			return nil, nil, cid.Undef, xerrors.Errorf("error loading indexed receipt: %w", err)
+		}
+		return xts, r, mcid, nil
+	}
+
 	r, foundMsg, err := sm.tipsetExecutedMessage(ctx, xts, mcid, m.VMMessage(), false)
 	if err != nil {
 		return nil, nil, cid.Undef, xerrors.Errorf("error in tipstExecutedMessage: %w", err)
@@ -298,6 +338,29 @@ func (sm *StateManager) searchBackForMsg(ctx context.Context, from *types.TipSet
 	}
 }
 
+// ReplacedError is returned when the message found on chain with the sender and nonce of the
+// requested message is a different message, which can't be returned in its place: either it
+// isn't a replacing message, calling something else, or replacing messages aren't allowed.
+type ReplacedError struct {
+	Requested cid.Cid
+	Landed    cid.Cid
+	// SameCall is set when the landed message is a replacing message, only differing in gas
+	// values and signature.
+	SameCall bool
+	// TipSet is the tipset the landed message was executed in.
+	TipSet  *types.TipSet
+	Receipt *types.MessageReceipt
+}
+
+func (e *ReplacedError) Error() string {
+	kind := "a message with a different call"
+	if e.SameCall {
+		kind = "a replacing message"
+	}
+	return fmt.Sprintf("message %s was replaced by %s %s, executed at height %d with exit code %d",
+		e.Requested, kind, e.Landed, e.TipSet.Height(), e.Receipt.ExitCode)
+}
+
 func (sm *StateManager) tipsetExecutedMessage(ctx context.Context, ts *types.TipSet, msg cid.Cid, vmm *types.Message, allowReplaced bool) (*types.MessageReceipt, cid.Cid, error) {
 	// The genesis block did not execute any messages
 	if ts.Height() == 0 {
@@ -314,33 +377,43 @@ func (sm *StateManager) tipsetExecutedMessage(ctx context.Context, ts *types.Tip
 		return nil, cid.Undef, err
 	}
 
+	// the messages of the sender, and their replacements, may refer to it by its ID address or
+	// by its robust address
+	senders := map[address.Address]struct{}{vmm.From: {}}
+	if id, err := sm.LookupID(ctx, vmm.From, pts); err == nil {
+		senders[id] = struct{}{}
+	}
+	if ra, err := sm.ResolveToDeterministicAddress(ctx, vmm.From, pts); err == nil {
+		senders[ra] = struct{}{}
+	}
+
 	for ii := range cm {
 		// iterate in reverse because we going backwards through the chain
 		i := len(cm) - ii - 1
 		m := cm[i]
 
-		if m.VMMessage().From == vmm.From { // cheaper to just check origin first
+		if _, ok := senders[m.VMMessage().From]; ok { // cheaper to just check origin first
 			if m.VMMessage().Nonce == vmm.Nonce {
-				if !m.VMMessage().EqualCall(vmm) {
-					// this is an entirely different message, fail
-					return nil, cid.Undef, xerrors.Errorf("found message with equal nonce as the one we are looking for that is NOT a valid replacement message (F:%s n %d, TS: %s n%d)",
-						msg, vmm.Nonce, m.Cid(), m.VMMessage().Nonce)
+				pr, err := sm.cs.GetParentReceipt(ctx, ts.Blocks()[0], i)
+				if err != nil {
+					return nil, cid.Undef, err
 				}
 
 				if m.Cid() != msg {
-					if !allowReplaced {
-						log.Warnw("found message with equal nonce and call params but different CID",
-							"wanted", msg, "found", m.Cid(), "nonce", vmm.Nonce, "from", vmm.From)
-						return nil, cid.Undef, xerrors.Errorf("found message with equal nonce as the one we are looking for (F:%s n %d, TS: %s n%d)",
-							msg, vmm.Nonce, m.Cid(), m.VMMessage().Nonce)
+					sameCall := m.VMMessage().EqualCall(vmm)
+					if !sameCall || !allowReplaced {
+						log.Warnw("found message with equal nonce but different CID",
+							"wanted", msg, "found", m.Cid(), "nonce", vmm.Nonce, "from", vmm.From, "sameCall", sameCall)
+						return nil, cid.Undef, &ReplacedError{
+							Requested: msg,
+							Landed:    m.Cid(),
+							SameCall:  sameCall,
+							TipSet:    ts,
+							Receipt:   pr,
+						}
 					}
 				}
 
-				pr, err := sm.cs.GetParentReceipt(ctx, ts.Blocks()[0], i)
-				if err != nil {
-					return nil, cid.Undef, err
-				}
-
 				return pr, m.Cid(), nil
 			}
 			if m.VMMessage().Nonce < vmm.Nonce {
@@ -3,11 +3,13 @@ package stmgr_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/filecoin-project/go-state-types/big"
 
 	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/stmgr"
 	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
 	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
 )
@@ -107,8 +109,12 @@ func TestSearchForMessageReplacements(t *testing.T) {
 
 	// Step 4: Searching for the replacement msg with replacements disallowed fails
 	_, _, _, err = cg.StateManager().SearchForMessage(ctx, mts2.TipSet.TipSet(), rm.Cid(), 100, false)
-	if err == nil {
-		t.Fatal("expected search to fail")
+	var rerr *stmgr.ReplacedError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("expected search to fail with a replaced error, got %v", err)
+	}
+	if rerr.Landed != m.Cid() || !rerr.SameCall || !rerr.TipSet.Equals(mts2.TipSet.TipSet()) {
+		t.Fatalf("unexpected replaced error: %v", rerr)
 	}
 
 	// nrm is NOT a valid replacement message for m
@@ -127,8 +133,11 @@ func TestSearchForMessageReplacements(t *testing.T) {
 
 	// Step 5: Searching for the not-replacement msg with replacements allowed fails
 	_, _, _, err = cg.StateManager().SearchForMessage(ctx, mts2.TipSet.TipSet(), nrm.Cid(), 100, true)
-	if err == nil {
-		t.Fatal("expected search to fail")
+	if !errors.As(err, &rerr) {
+		t.Fatalf("expected search to fail with a replaced error, got %v", err)
+	}
+	if rerr.Landed != m.Cid() || rerr.SameCall || rerr.Receipt.ExitCode != 0 {
+		t.Fatalf("unexpected replaced error: %v", rerr)
 	}
 
 	// Step 6: Searching for the not-replacement msg with replacements disallowed also fails
@@ -137,4 +146,30 @@ func TestSearchForMessageReplacements(t *testing.T) {
 		t.Fatal("expected search to fail")
 	}
 
+	// idm refers to the sender of m by its ID address
+	fromID, err := cg.StateManager().LookupID(ctx, m.Message.From, mts2.TipSet.TipSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idm := m.Message
+	idm.From = fromID
+
+	idmb, err := idm.ToStorageBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cg.Blockstore().Put(ctx, idmb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Step 7: Searching for a msg from the ID address of the sender finds m in its place
+	_, _, _, err = cg.StateManager().SearchForMessage(ctx, mts2.TipSet.TipSet(), idm.Cid(), 100, true)
+	if !errors.As(err, &rerr) {
+		t.Fatalf("expected search to fail with a replaced error, got %v", err)
+	}
+	if rerr.Landed != m.Cid() {
+		t.Fatalf("unexpected replaced error: %v", rerr)
+	}
 }
@@ -0,0 +1,57 @@
+package stmgr
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// speculativeExecutionTimeout bounds a single speculative execution; a result arriving later
+// than this would be of little use.
+var speculativeExecutionTimeout = 2 * time.Duration(build.BlockDelaySecs) * time.Second
+
+// SetSpeculativeExecution enables executing incoming tipsets before they are validated, so that
+// their state is already cached by the time they become the head. It must be called before the
+// StateManager is used.
+func (sm *StateManager) SetSpeculativeExecution(enable bool) {
+	sm.speculative = enable
+}
+
+// SpeculativeExecute starts executing ts in the background if speculative execution is enabled,
+// ts isn't cached yet and no other speculative execution is running. The tipset needs not be
+// validated: the result is cached under its key only, so an invalid tipset merely wastes the work.
+// The messages of ts and its parent state must already be available locally.
+func (sm *StateManager) SpeculativeExecute(ts *types.TipSet) {
+	if !sm.speculative || ts.Height() == 0 {
+		return
+	}
+
+	sm.stlk.Lock()
+	_, cached := sm.stCache.get(cidsToKey(ts.Cids()))
+	sm.stlk.Unlock()
+	if cached {
+		return
+	}
+
+	if !atomic.CompareAndSwapInt32(&sm.speculating, 0, 1) {
+		log.Debugw("skipping speculative execution, another one is running", "tipset", ts.Key())
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&sm.speculating, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), speculativeExecutionTimeout)
+		defer cancel()
+
+		start := build.Clock.Now()
+		if _, _, err := sm.TipSetState(ctx, ts); err != nil {
+			log.Debugw("speculative execution failed", "tipset", ts.Key(), "height", ts.Height(), "error", err)
+			return
+		}
+		log.Debugw("speculatively executed tipset", "tipset", ts.Key(), "height", ts.Height(), "took", build.Clock.Since(start))
+	}()
+}
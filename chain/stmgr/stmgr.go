@@ -2,6 +2,7 @@ package stmgr
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -22,6 +23,7 @@ import (
 	"github.com/filecoin-project/specs-actors/v8/actors/migration/nv16"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/adt"
 	_init "github.com/filecoin-project/lotus/chain/actors/builtin/init"
@@ -43,6 +45,11 @@ const LookbackNoLimit = api.LookbackNoLimit
 const ReceiptAmtBitwidth = 3
 
 var execTraceCacheSize = 16
+
+// execTraceCacheBytes, if positive, additionally bounds the approximate size in bytes of the
+// traces held in the execution trace cache.
+var execTraceCacheBytes int64 = 0
+var msgTraceCacheSize = 0
 var log = logging.Logger("statemgr")
 
 type StateManagerAPI interface {
@@ -84,6 +91,41 @@ func init() {
 			execTraceCacheSize = letc
 		}
 	}
+	if s := os.Getenv("LOTUS_EXEC_TRACE_CACHE_BYTES"); s != "" {
+		letcb, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			log.Errorf("failed to parse 'LOTUS_EXEC_TRACE_CACHE_BYTES' env var: %s", err)
+		} else {
+			execTraceCacheBytes = letcb
+		}
+	}
+	if s := os.Getenv("LOTUS_MSG_TRACE_CACHE_SIZE"); s != "" {
+		lmtc, err := strconv.Atoi(s)
+		if err != nil {
+			log.Errorf("failed to parse 'LOTUS_MSG_TRACE_CACHE_SIZE' env var: %s", err)
+		} else {
+			msgTraceCacheSize = lmtc
+		}
+	}
+	if s := os.Getenv("LOTUS_RECOVER_EXECUTION_PANICS"); s != "" {
+		lrep, err := strconv.ParseBool(s)
+		if err != nil {
+			log.Errorf("failed to parse 'LOTUS_RECOVER_EXECUTION_PANICS' env var: %s", err)
+		} else {
+			recoverExecutionPanics = lrep
+		}
+	}
+	if s := os.Getenv("LOTUS_EXEC_MONITOR_SOCKET"); s != "" {
+		execMonitorSocket = s
+	}
+	if s := os.Getenv("LOTUS_TIPSET_STATE_LOOKUP_WINDOW"); s != "" {
+		ltslw, err := strconv.Atoi(s)
+		if err != nil {
+			log.Errorf("failed to parse 'LOTUS_TIPSET_STATE_LOOKUP_WINDOW' env var: %s", err)
+		} else {
+			tipsetStateLookupWindow = abi.ChainEpoch(ltslw)
+		}
+	}
 }
 
 func (m *migrationResultCache) Get(ctx context.Context, root cid.Cid) (cid.Cid, bool, error) {
@@ -118,6 +160,51 @@ type Executor interface {
 	ExecuteTipSet(ctx context.Context, sm *StateManager, ts *types.TipSet, em ExecMonitor, vmTracing bool) (stateroot cid.Cid, rectsroot cid.Cid, err error)
 }
 
+// ErrExecutorOptsOnMainnet is returned when execution overrides are requested on a mainnet build.
+var ErrExecutorOptsOnMainnet = errors.New("execution overrides are not permitted on mainnet builds")
+
+// ExecutorOpts carries per-execution overrides used for simulations and analytics. None of these
+// may be set on the consensus path; Validate rejects those altering the rules of execution on
+// mainnet builds.
+type ExecutorOpts struct {
+	// StateBlockstore, if set, is used by the VM instead of the chain's state blockstore. This
+	// allows executing a tipset without persisting the resulting state tree.
+	StateBlockstore blockstore.Blockstore
+	// ParentStateOverride, if defined, is used as the pre-state of the tipset instead of the
+	// parent state root recorded in its blocks.
+	ParentStateOverride cid.Cid
+	// NetworkVersionOverride, if set, forces the network version the VM executes the tipset's
+	// messages at, regardless of the tipset's height.
+	NetworkVersionOverride *network.Version
+	// MessageLimit, if positive, stops applying the tipset's (deduplicated) messages once that
+	// many have been applied. Block rewards and cron are still applied.
+	MessageLimit int
+	// MessageOverride, if set, is called with every (deduplicated) message of the tipset before it
+	// is applied, and the message it returns is applied instead. The resulting state is
+	// meaningless to the chain, so it should only be used with a StateBlockstore.
+	MessageOverride func(types.ChainMsg) types.ChainMsg
+	// Parallel allows executing the tipset's messages on several VMs concurrently, if enabled with
+	// SetParallelExecutionWorkers, falling back to serial execution when they aren't independent.
+	// Independence is established from the execution traces, so only replays of past tipsets,
+	// whose results are checked against or kept apart from the chain, may set it; tipset
+	// validation never does.
+	Parallel bool
+}
+
+// Validate checks that the requested overrides are permitted by the current build.
+func (o *ExecutorOpts) Validate() error {
+	if o.NetworkVersionOverride != nil && build.BuildType == build.BuildMainnet {
+		return xerrors.Errorf("network version override: %w", ErrExecutorOptsOnMainnet)
+	}
+	return nil
+}
+
+// ExecutorWithOpts is implemented by executors which support ExecutorOpts overrides.
+type ExecutorWithOpts interface {
+	Executor
+	ExecuteTipSetWithOpts(ctx context.Context, sm *StateManager, ts *types.TipSet, em ExecMonitor, vmTracing bool, opts ExecutorOpts) (stateroot cid.Cid, rectsroot cid.Cid, err error)
+}
+
 type StateManager struct {
 	cs *store.ChainStore
 
@@ -135,10 +222,17 @@ type StateManager struct {
 	// ErrExpensiveFork.
 	expensiveUpgrades map[abi.ChainEpoch]struct{}
 
-	stCache             map[string][]cid.Cid
+	stCache             *stateCache
+	pstCache            *persistentStateCache
+	execBreaker         *execBreaker
 	tCache              treeCache
 	compWait            map[string]chan struct{}
 	stlk                sync.Mutex
+	progressLk          sync.Mutex
+	execProgress        map[*progressMonitor]struct{}
+	speculative         bool  // see SetSpeculativeExecution
+	speculating         int32 // set while a speculative execution is running
+	parallelWorkers     int   // see SetParallelExecutionWorkers
 	genesisMsigLk       sync.Mutex
 	newVM               func(context.Context, *vm.VMOpts) (vm.Interface, error)
 	Syscalls            vm.SyscallBuilder
@@ -150,16 +244,38 @@ type StateManager struct {
 
 	tsExec        Executor
 	tsExecMonitor ExecMonitor
+	execMonitors  execMonitors
 	beacon        beacon.Schedule
 
 	msgIndex index.MsgIndex
 
+	// Holds migration results and the state cache snapshot.
+	metadataDs dstore.Batching
+
 	// We keep a small cache for calls to ExecutionTrace which helps improve
 	// performance for node operators like exchanges and block explorers
-	execTraceCache *lru.ARCCache[types.TipSetKey, tipSetCacheEntry]
+	execTraceCache *lru.Cache[types.TipSetKey, tipSetCacheEntry]
 	// We need a lock while making the copy as to prevent other callers
 	// overwrite the cache while making the copy
 	execTraceCacheLock sync.Mutex
+	// Approximate size of the traces in execTraceCache, guarded by execTraceCacheLock.
+	execTraceCacheUsed int64
+	// Bounds the number of execution trace nodes retained per traced tipset, see
+	// SetExecutionTraceMaxNodes.
+	traceMaxNodes int
+
+	// Optional per-message cache of invocation results, populated by ExecutionTrace. It lets
+	// MessageTrace answer single-message queries without re-tracing the whole tipset.
+	msgTraceCache *lru.Cache[msgTraceKey, *api.InvocResult]
+
+	// Progress of the archival state backfill, see RunStateBackfill.
+	backfillLk sync.Mutex
+	backfill   api.StateBackfillStatus
+
+	// OnEvict, if set, is called for every entry dropped from the tipset state cache. It is
+	// invoked without holding any StateManager locks, and must be set before the StateManager
+	// is used.
+	OnEvict func(key types.TipSetKey, st, rec cid.Cid)
 }
 
 // Caches a single state tree
@@ -170,7 +286,16 @@ type treeCache struct {
 
 type tipSetCacheEntry struct {
 	postStateRoot cid.Cid
+	receiptRoot   cid.Cid
 	invocTrace    []*api.InvocResult
+	size          int64
+}
+
+// Messages may be included in multiple tipsets (e.g. on different forks), so results are keyed by
+// the executing tipset as well as the message.
+type msgTraceKey struct {
+	tsk  types.TipSetKey
+	mcid cid.Cid
 }
 
 func NewStateManager(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder, us UpgradeSchedule, beacon beacon.Schedule, metadataDs dstore.Batching, msgIndex index.MsgIndex) (*StateManager, error) {
@@ -212,17 +337,17 @@ func NewStateManager(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder,
 		}
 	}
 
-	log.Debugf("execTraceCache size: %d", execTraceCacheSize)
-	var execTraceCache *lru.ARCCache[types.TipSetKey, tipSetCacheEntry]
+	log.Debugf("execTraceCache size: %d (%d bytes), msgTraceCache size: %d", execTraceCacheSize, execTraceCacheBytes, msgTraceCacheSize)
 	var err error
-	if execTraceCacheSize > 0 {
-		execTraceCache, err = lru.NewARC[types.TipSetKey, tipSetCacheEntry](execTraceCacheSize)
+	var msgTraceCache *lru.Cache[msgTraceKey, *api.InvocResult]
+	if msgTraceCacheSize > 0 {
+		msgTraceCache, err = lru.New[msgTraceKey, *api.InvocResult](msgTraceCacheSize)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return &StateManager{
+	sm := &StateManager{
 		networkVersions:   networkVersions,
 		latestVersion:     lastVersion,
 		stateMigrations:   stateMigrations,
@@ -231,16 +356,35 @@ func NewStateManager(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder,
 		Syscalls:          sys,
 		cs:                cs,
 		tsExec:            exec,
-		stCache:           make(map[string][]cid.Cid),
+		stCache:           newStateCache(0),
+		execBreaker:       newExecBreaker(0),
 		beacon:            beacon,
 		tCache: treeCache{
 			root: cid.Undef,
 			tree: nil,
 		},
-		compWait:       make(map[string]chan struct{}),
-		msgIndex:       msgIndex,
-		execTraceCache: execTraceCache,
-	}, nil
+		compWait:      make(map[string]chan struct{}),
+		execProgress:  make(map[*progressMonitor]struct{}),
+		msgIndex:      msgIndex,
+		metadataDs:    metadataDs,
+		msgTraceCache: msgTraceCache,
+	}
+
+	if execTraceCacheSize > 0 {
+		sm.execTraceCache, err = lru.NewWithEvict[types.TipSetKey, tipSetCacheEntry](execTraceCacheSize, func(_ types.TipSetKey, e tipSetCacheEntry) {
+			// Called with execTraceCacheLock held.
+			sm.execTraceCacheUsed -= e.size
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if execMonitorSocket != "" {
+		sm.AttachExecMonitor(NewSocketExecMonitor(execMonitorSocket))
+	}
+
+	return sm, nil
 }
 
 func NewStateManagerWithUpgradeScheduleAndMonitor(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder, us UpgradeSchedule, b beacon.Schedule, em ExecMonitor, metadataDs dstore.Batching, msgIndex index.MsgIndex) (*StateManager, error) {
@@ -252,6 +396,8 @@ func NewStateManagerWithUpgradeScheduleAndMonitor(cs *store.ChainStore, exec Exe
 	return sm, nil
 }
 
+// cidsToKey encodes tipset CIDs into the key used by the state cache and compWait. The key is a
+// concatenation of the binary CIDs, the same encoding used by types.TipSetKey.
 func cidsToKey(cids []cid.Cid) string {
 	var out string
 	for _, c := range cids {
@@ -260,6 +406,15 @@ func cidsToKey(cids []cid.Cid) string {
 	return out
 }
 
+// keyToCids decodes a key produced by cidsToKey back into the original tipset CIDs, in order.
+func keyToCids(key string) ([]cid.Cid, error) {
+	tsk, err := types.TipSetKeyFromBytes([]byte(key))
+	if err != nil {
+		return nil, xerrors.Errorf("decoding state cache key: %w", err)
+	}
+	return tsk.Cids(), nil
+}
+
 // Start starts the state manager's optional background processes. At the moment, this schedules
 // pre-migration functions to run ahead of network upgrades.
 //
@@ -480,6 +635,18 @@ func (sm *StateManager) SetVMConstructor(nvm func(context.Context, *vm.VMOpts) (
 	sm.newVM = nvm
 }
 
+// SetParallelExecutionWorkers sets the number of VMs executions with ExecutorOpts.Parallel may
+// spread the messages of a tipset over. 0, the default, disables parallel execution. It must be
+// called before the StateManager is used.
+func (sm *StateManager) SetParallelExecutionWorkers(workers int) {
+	sm.parallelWorkers = workers
+}
+
+// ParallelExecutionWorkers returns the number of VMs set with SetParallelExecutionWorkers.
+func (sm *StateManager) ParallelExecutionWorkers() int {
+	return sm.parallelWorkers
+}
+
 func (sm *StateManager) VMConstructor() func(context.Context, *vm.VMOpts) (vm.Interface, error) {
 	return func(ctx context.Context, opts *vm.VMOpts) (vm.Interface, error) {
 		return sm.newVM(ctx, opts)
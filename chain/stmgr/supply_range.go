@@ -0,0 +1,92 @@
+package stmgr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var circSupplyCachePrefix = dstore.NewKey("/stmgr/circ-supply")
+
+// CirculatingSupplyRange returns the detailed circulating supply of the tipsets of the chain
+// ending at `to`, from height `from` up to `to`, sampled every `interval` epochs. When a sampled
+// height is a null round, the tipset before it is used. Results are cached in the metadata
+// datastore by height and state root, so repeated queries over the same range are cheap.
+func (sm *StateManager) CirculatingSupplyRange(ctx context.Context, from abi.ChainEpoch, to *types.TipSet, interval abi.ChainEpoch) ([]api.CirculatingSupplyAt, error) {
+	if interval <= 0 {
+		return nil, xerrors.Errorf("interval must be positive, got %d", interval)
+	}
+	if to.Height() < from {
+		return nil, xerrors.Errorf("range end %d is below range start %d", to.Height(), from)
+	}
+
+	var out []api.CirculatingSupplyAt
+	for h := from; h <= to.Height(); h += interval {
+		ts, err := sm.cs.GetTipsetByHeight(ctx, h, to, true)
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset at height %d: %w", h, err)
+		}
+		// Consecutive null rounds may resolve to the same tipset.
+		if len(out) > 0 && out[len(out)-1].TipSet == ts.Key() {
+			continue
+		}
+
+		cs, err := sm.cachedCirculatingSupply(ctx, ts)
+		if err != nil {
+			return nil, xerrors.Errorf("computing circulating supply at height %d: %w", ts.Height(), err)
+		}
+		out = append(out, api.CirculatingSupplyAt{TipSet: ts.Key(), Height: ts.Height(), CirculatingSupply: cs})
+	}
+	return out, nil
+}
+
+func circSupplyCacheKey(height abi.ChainEpoch, root cid.Cid) dstore.Key {
+	return circSupplyCachePrefix.ChildString(fmt.Sprintf("%d", height)).ChildString(root.String())
+}
+
+func (sm *StateManager) cachedCirculatingSupply(ctx context.Context, ts *types.TipSet) (api.CirculatingSupply, error) {
+	k := circSupplyCacheKey(ts.Height(), ts.ParentState())
+	if sm.metadataDs != nil {
+		b, err := sm.metadataDs.Get(ctx, k)
+		switch {
+		case err == nil:
+			var cs api.CirculatingSupply
+			uerr := json.Unmarshal(b, &cs)
+			if uerr == nil {
+				return cs, nil
+			}
+			log.Warnw("discarding corrupt circulating supply cache entry", "key", k, "error", uerr)
+		case err != dstore.ErrNotFound:
+			return api.CirculatingSupply{}, xerrors.Errorf("reading circulating supply cache: %w", err)
+		}
+	}
+
+	st, err := sm.ParentState(ts)
+	if err != nil {
+		return api.CirculatingSupply{}, err
+	}
+	cs, err := sm.GetVMCirculatingSupplyDetailed(ctx, ts.Height(), st)
+	if err != nil {
+		return api.CirculatingSupply{}, err
+	}
+
+	if sm.metadataDs != nil {
+		b, err := json.Marshal(cs)
+		if err != nil {
+			return api.CirculatingSupply{}, err
+		}
+		if err := sm.metadataDs.Put(ctx, k, b); err != nil {
+			log.Errorw("failed to write circulating supply cache", "key", k, "error", err)
+		}
+	}
+	return cs, nil
+}
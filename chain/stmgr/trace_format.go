@@ -0,0 +1,124 @@
+package stmgr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/ipfs/go-cid"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// ExecutionTraceFrames returns the execution trace of ts as frames.
+func (sm *StateManager) ExecutionTraceFrames(ctx context.Context, ts *types.TipSet) ([]api.TraceFrame, error) {
+	st, trace, err := sm.ExecutionTrace(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+	return sm.TraceFrames(ctx, st, trace)
+}
+
+// TraceFrames flattens the execution traces of trace, computed on a tipset whose resulting state
+// is st, into frames. The receivers' code is looked up in st to decode parameters and returns.
+func (sm *StateManager) TraceFrames(ctx context.Context, st cid.Cid, trace []*api.InvocResult) ([]api.TraceFrame, error) {
+	tree, err := sm.StateTree(st)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	f := traceFlattener{tree: tree, ar: sm.tsExec.NewActorRegistry(), codes: make(map[address.Address]cid.Cid)}
+	for _, ir := range trace {
+		f.add(ir.MsgCid, ir.ExecutionTrace, 0)
+	}
+	return f.frames, nil
+}
+
+type traceFlattener struct {
+	tree   *state.StateTree
+	ar     *vm.ActorRegistry
+	codes  map[address.Address]cid.Cid
+	frames []api.TraceFrame
+}
+
+func (f *traceFlattener) add(mcid cid.Cid, et types.ExecutionTrace, depth int) {
+	code := f.code(et.Msg.To)
+	f.frames = append(f.frames, api.TraceFrame{
+		MsgCid:        mcid,
+		Depth:         depth,
+		From:          et.Msg.From,
+		To:            et.Msg.To,
+		Value:         et.Msg.Value,
+		Method:        et.Msg.Method,
+		ToCode:        code,
+		Params:        et.Msg.Params,
+		ParamsCodec:   et.Msg.ParamsCodec,
+		ParamsDecoded: f.decode(code, et.Msg.Method, et.Msg.Params, false),
+		ExitCode:      et.MsgRct.ExitCode,
+		Return:        et.MsgRct.Return,
+		ReturnCodec:   et.MsgRct.ReturnCodec,
+		ReturnDecoded: f.decode(code, et.Msg.Method, et.MsgRct.Return, true),
+		GasCharged:    et.SumGas().TotalGas,
+	})
+	for _, sub := range et.Subcalls {
+		f.add(mcid, sub, depth+1)
+	}
+}
+
+func (f *traceFlattener) code(addr address.Address) cid.Cid {
+	if c, ok := f.codes[addr]; ok {
+		return c
+	}
+	c := cid.Undef
+	if act, err := f.tree.GetActor(addr); err == nil {
+		c = act.Code
+	}
+	f.codes[addr] = c
+	return c
+}
+
+// decode returns the JSON encoding of the parameters (or return value, if ret is set) of the
+// given method, or nil if they can't be decoded.
+func (f *traceFlattener) decode(code cid.Cid, method abi.MethodNum, data []byte, ret bool) json.RawMessage {
+	if !code.Defined() || len(data) == 0 {
+		return nil
+	}
+	m, found := f.ar.Methods[code][method]
+	if !found {
+		return nil
+	}
+
+	typ := m.Params
+	if ret {
+		typ = m.Ret
+	}
+	v, ok := newCborUnmarshaler(typ)
+	if !ok {
+		return nil
+	}
+	if err := v.UnmarshalCBOR(bytes.NewReader(data)); err != nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func newCborUnmarshaler(typ reflect.Type) (cbg.CBORUnmarshaler, bool) {
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return nil, false
+	}
+	v, ok := reflect.New(typ.Elem()).Interface().(cbg.CBORUnmarshaler)
+	return v, ok
+}
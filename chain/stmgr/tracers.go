@@ -5,6 +5,8 @@ import (
 
 	"github.com/ipfs/go-cid"
 
+	"github.com/filecoin-project/go-address"
+
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
@@ -19,16 +21,44 @@ var _ ExecMonitor = (*InvocationTracer)(nil)
 
 type InvocationTracer struct {
 	trace *[]*api.InvocResult
+
+	// maxNodes bounds the total number of execution trace nodes retained across all messages.
+	// Zero means unlimited.
+	maxNodes int
+	nodes    int
+	omitted  int
+}
+
+// NewInvocationTracer returns a tracer appending to trace which retains at most maxNodes execution
+// trace nodes across all applied messages. Nodes are retained in depth-first order; once the
+// limit is reached, further subcalls are omitted. A maxNodes of 0 means unlimited.
+func NewInvocationTracer(trace *[]*api.InvocResult, maxNodes int) *InvocationTracer {
+	return &InvocationTracer{trace: trace, maxNodes: maxNodes}
+}
+
+// Truncated reports whether any execution trace nodes were omitted.
+func (i *InvocationTracer) Truncated() bool {
+	return i.omitted > 0
+}
+
+// OmittedNodes returns the number of execution trace nodes omitted so far.
+func (i *InvocationTracer) OmittedNodes() int {
+	return i.omitted
 }
 
 func (i *InvocationTracer) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	omitted := i.omitted
 	ir := &api.InvocResult{
 		MsgCid:         mcid,
 		Msg:            msg,
 		MsgRct:         &ret.MessageReceipt,
-		ExecutionTrace: ret.ExecutionTrace,
+		ExecutionTrace: i.limitTrace(ret.ExecutionTrace),
 		Duration:       ret.Duration,
 	}
+	if omitted != i.omitted {
+		ir.Truncated = true
+		ir.OmittedNodes = i.omitted - omitted
+	}
 	if ret.ActorErr != nil {
 		ir.Error = ret.ActorErr.Error()
 	}
@@ -39,6 +69,54 @@ func (i *InvocationTracer) MessageApplied(ctx context.Context, ts *types.TipSet,
 	return nil
 }
 
+// limitTrace returns et with any nodes beyond the tracer's remaining budget omitted.
+func (i *InvocationTracer) limitTrace(et types.ExecutionTrace) types.ExecutionTrace {
+	if i.maxNodes <= 0 {
+		return et
+	}
+	if i.nodes >= i.maxNodes {
+		i.omitted += countTraceNodes(et)
+		return types.ExecutionTrace{}
+	}
+
+	i.nodes++
+	out := et
+	out.Subcalls = nil
+	for _, sub := range et.Subcalls {
+		if i.nodes >= i.maxNodes {
+			i.omitted += countTraceNodes(sub)
+			continue
+		}
+		out.Subcalls = append(out.Subcalls, i.limitTrace(sub))
+	}
+	return out
+}
+
+func countTraceNodes(et types.ExecutionTrace) int {
+	n := 1
+	for _, sub := range et.Subcalls {
+		n += countTraceNodes(sub)
+	}
+	return n
+}
+
+var _ ExecMonitor = (*participantsTracer)(nil)
+
+// participantsTracer records the senders and receivers of explicit messages. It needs no
+// execution traces.
+type participantsTracer struct {
+	participants map[address.Address]struct{}
+}
+
+func (t *participantsTracer) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	if implicit {
+		return nil
+	}
+	t.participants[msg.From] = struct{}{}
+	t.participants[msg.To] = struct{}{}
+	return nil
+}
+
 var _ ExecMonitor = (*messageFinder)(nil)
 
 type messageFinder struct {
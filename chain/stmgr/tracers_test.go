@@ -0,0 +1,67 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// mkWideTrace builds a trace in which every node above the given depth has fanout subcalls.
+func mkWideTrace(depth, fanout int) types.ExecutionTrace {
+	var et types.ExecutionTrace
+	if depth == 0 {
+		return et
+	}
+	for i := 0; i < fanout; i++ {
+		et.Subcalls = append(et.Subcalls, mkWideTrace(depth-1, fanout))
+	}
+	return et
+}
+
+func TestInvocationTracerMaxNodes(t *testing.T) {
+	ctx := context.Background()
+	msg := &types.Message{}
+	mcid, err := abi.CidBuilder.Sum([]byte("msg"))
+	require.NoError(t, err)
+	ret := &vm.ApplyRet{ExecutionTrace: mkWideTrace(3, 3)} // 1 + 3 + 9 = 13 nodes
+
+	var unlimited []*api.InvocResult
+	tracer := stmgr.NewInvocationTracer(&unlimited, 0)
+	require.NoError(t, tracer.MessageApplied(ctx, nil, mcid, msg, ret, false))
+	require.False(t, tracer.Truncated())
+	require.Equal(t, ret.ExecutionTrace, unlimited[0].ExecutionTrace)
+	require.False(t, unlimited[0].Truncated)
+
+	var limited []*api.InvocResult
+	tracer = stmgr.NewInvocationTracer(&limited, 5)
+	require.NoError(t, tracer.MessageApplied(ctx, nil, mcid, msg, ret, false))
+
+	// Depth-first: the root, its first subcall and that subcall's three children are kept.
+	root := limited[0].ExecutionTrace
+	require.Len(t, root.Subcalls, 1)
+	require.Len(t, root.Subcalls[0].Subcalls, 3)
+	require.True(t, tracer.Truncated())
+	require.Equal(t, 8, tracer.OmittedNodes())
+	require.True(t, limited[0].Truncated)
+	require.Equal(t, 8, limited[0].OmittedNodes)
+
+	// The budget spans messages, so the next trace is omitted entirely.
+	require.NoError(t, tracer.MessageApplied(ctx, nil, mcid, msg, ret, false))
+	require.Len(t, limited, 2)
+	require.Empty(t, limited[1].ExecutionTrace.Subcalls)
+	require.Equal(t, 21, tracer.OmittedNodes())
+	require.True(t, limited[1].Truncated)
+	require.Equal(t, 13, limited[1].OmittedNodes)
+
+	// The original trace is left untouched.
+	require.Len(t, ret.ExecutionTrace.Subcalls, 3)
+}
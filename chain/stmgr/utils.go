@@ -66,20 +66,50 @@ func GetNetworkName(ctx context.Context, sm *StateManager, st cid.Cid) (dtypes.N
 }
 
 func ComputeState(ctx context.Context, sm *StateManager, height abi.ChainEpoch, msgs []*types.Message, ts *types.TipSet) (cid.Cid, []*api.InvocResult, error) {
+	_, root, trace, err := computeState(ctx, sm, height, msgs, ts)
+	return root, trace, err
+}
+
+// ComputeStateWithDiff is like ComputeState, but also returns the actors created, modified or
+// deleted by the given messages, relative to the state they were applied on (after any state
+// migrations up to height).
+func ComputeStateWithDiff(ctx context.Context, sm *StateManager, height abi.ChainEpoch, msgs []*types.Message, ts *types.TipSet) (cid.Cid, []*api.InvocResult, []state.ActorChange, error) {
+	base, root, trace, err := computeState(ctx, sm, height, msgs, ts)
+	if err != nil {
+		return cid.Undef, nil, nil, err
+	}
+
+	baseTree, err := sm.StateTree(base)
+	if err != nil {
+		return cid.Undef, nil, nil, xerrors.Errorf("loading base state tree: %w", err)
+	}
+	rootTree, err := sm.StateTree(root)
+	if err != nil {
+		return cid.Undef, nil, nil, xerrors.Errorf("loading computed state tree: %w", err)
+	}
+	changes, err := state.DiffActors(ctx, baseTree, rootTree)
+	if err != nil {
+		return cid.Undef, nil, nil, xerrors.Errorf("diffing state trees: %w", err)
+	}
+
+	return root, trace, changes, nil
+}
+
+func computeState(ctx context.Context, sm *StateManager, height abi.ChainEpoch, msgs []*types.Message, ts *types.TipSet) (base cid.Cid, root cid.Cid, trace []*api.InvocResult, err error) {
 	if ts == nil {
 		ts = sm.cs.GetHeaviestTipSet()
 	}
 
-	base, trace, err := sm.ExecutionTrace(ctx, ts)
+	base, trace, err = sm.ExecutionTrace(ctx, ts)
 	if err != nil {
-		return cid.Undef, nil, err
+		return cid.Undef, cid.Undef, nil, err
 	}
 
 	for i := ts.Height(); i < height; i++ {
 		// Technically, the tipset we're passing in here should be ts+1, but that may not exist.
-		base, err = sm.HandleStateForks(ctx, base, i, &InvocationTracer{trace: &trace}, ts)
+		base, err = sm.HandleStateForks(ctx, base, i, NewInvocationTracer(&trace, sm.traceMaxNodes), ts)
 		if err != nil {
-			return cid.Undef, nil, xerrors.Errorf("error handling state forks: %w", err)
+			return cid.Undef, cid.Undef, nil, xerrors.Errorf("error handling state forks: %w", err)
 		}
 
 		// We intentionally don't run cron here, as we may be trying to look into the
@@ -104,26 +134,26 @@ func ComputeState(ctx context.Context, sm *StateManager, height abi.ChainEpoch,
 	}
 	vmi, err := sm.newVM(ctx, vmopt)
 	if err != nil {
-		return cid.Undef, nil, err
+		return cid.Undef, cid.Undef, nil, err
 	}
 
 	for i, msg := range msgs {
 		// TODO: Use the signed message length for secp messages
 		ret, err := vmi.ApplyMessage(ctx, msg)
 		if err != nil {
-			return cid.Undef, nil, xerrors.Errorf("applying message %s: %w", msg.Cid(), err)
+			return cid.Undef, cid.Undef, nil, xerrors.Errorf("applying message %s: %w", msg.Cid(), err)
 		}
 		if ret.ExitCode != 0 {
 			log.Infof("compute state apply message %d failed (exit: %d): %s", i, ret.ExitCode, ret.ActorErr)
 		}
 	}
 
-	root, err := vmi.Flush(ctx)
+	root, err = vmi.Flush(ctx)
 	if err != nil {
-		return cid.Undef, nil, err
+		return cid.Undef, cid.Undef, nil, err
 	}
 
-	return root, trace, nil
+	return base, root, trace, nil
 }
 
 func LookbackStateGetterForTipset(sm *StateManager, ts *types.TipSet) vm.LookbackStateGetter {
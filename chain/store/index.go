@@ -2,10 +2,12 @@ package store
 
 import (
 	"context"
+	"encoding/binary"
 	"os"
 	"strconv"
 	"sync"
 
+	dstore "github.com/ipfs/go-datastore"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
@@ -32,6 +34,10 @@ type ChainIndex struct {
 
 	loadTipSet loadTipSetFunc
 
+	// ds persists the skip entries, if set, so that they survive restarts. An entry only depends
+	// on the ancestry of its tipset, so persisted entries never go stale.
+	ds dstore.Datastore
+
 	skipLength abi.ChainEpoch
 }
 type loadTipSetFunc func(context.Context, types.TipSetKey) (*types.TipSet, error)
@@ -64,6 +70,9 @@ func (ci *ChainIndex) GetTipsetByHeight(ctx context.Context, from *types.TipSet,
 	cur := rounded.Key()
 	for {
 		lbe, ok := ci.indexCache[cur]
+		if !ok {
+			lbe, ok = ci.loadEntry(ctx, cur)
+		}
 		if !ok {
 			fc, err := ci.fillCache(ctx, cur)
 			if err != nil {
@@ -138,10 +147,120 @@ func (ci *ChainIndex) fillCache(ctx context.Context, tsk types.TipSetKey) (*lbEn
 		target:       skipTarget.Key(),
 	}
 	ci.indexCache[tsk] = lbe
+	ci.persistEntry(ctx, tsk, lbe)
 
 	return lbe, nil
 }
 
+func (ci *ChainIndex) entryKey(tsk types.TipSetKey) (dstore.Key, error) {
+	c, err := tsk.Cid()
+	if err != nil {
+		return dstore.Key{}, err
+	}
+	return dstore.NewKey(c.String()), nil
+}
+
+// Caller must hold indexCacheLk
+func (ci *ChainIndex) loadEntry(ctx context.Context, tsk types.TipSetKey) (*lbEntry, bool) {
+	if ci.ds == nil {
+		return nil, false
+	}
+
+	k, err := ci.entryKey(tsk)
+	if err != nil {
+		return nil, false
+	}
+	b, err := ci.ds.Get(ctx, k)
+	if err != nil {
+		if err != dstore.ErrNotFound {
+			log.Warnf("failed to load chain index entry: %s", err)
+		}
+		return nil, false
+	}
+
+	height, n := binary.Varint(b)
+	if n <= 0 {
+		log.Warnf("corrupt chain index entry for %s", tsk)
+		return nil, false
+	}
+	target, err := types.TipSetKeyFromBytes(b[n:])
+	if err != nil {
+		log.Warnf("corrupt chain index entry for %s: %s", tsk, err)
+		return nil, false
+	}
+
+	lbe := &lbEntry{
+		targetHeight: abi.ChainEpoch(height),
+		target:       target,
+	}
+	ci.indexCache[tsk] = lbe
+	return lbe, true
+}
+
+func (ci *ChainIndex) persistEntry(ctx context.Context, tsk types.TipSetKey, lbe *lbEntry) {
+	if ci.ds == nil {
+		return
+	}
+
+	k, err := ci.entryKey(tsk)
+	if err != nil {
+		log.Warnf("failed to persist chain index entry: %s", err)
+		return
+	}
+	b := binary.AppendVarint(nil, int64(lbe.targetHeight))
+	b = append(b, lbe.target.Bytes()...)
+	if err := ci.ds.Put(ctx, k, b); err != nil {
+		log.Warnf("failed to persist chain index entry: %s", err)
+	}
+}
+
+// onHeadChange keeps the index warm, adding the entries of the applied tipsets which start a
+// skip interval, and drops the entries of the reverted ones.
+func (ci *ChainIndex) onHeadChange(ctx context.Context, rev, app []*types.TipSet) error {
+	ci.indexCacheLk.Lock()
+	defer ci.indexCacheLk.Unlock()
+
+	for _, ts := range rev {
+		if _, ok := ci.indexCache[ts.Key()]; !ok {
+			continue
+		}
+		delete(ci.indexCache, ts.Key())
+		if ci.ds != nil {
+			if k, err := ci.entryKey(ts.Key()); err == nil {
+				if err := ci.ds.Delete(ctx, k); err != nil {
+					log.Warnf("failed to delete chain index entry: %s", err)
+				}
+			}
+		}
+	}
+
+	for _, ts := range app {
+		if ts.Height() == 0 {
+			continue
+		}
+		if _, ok := ci.indexCache[ts.Key()]; ok {
+			continue
+		}
+
+		parent, err := ci.loadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("failed to load tipset: %w", err)
+		}
+		if parent.Height() >= ci.roundHeight(ts.Height()) {
+			// not the first tipset of its skip interval; there is no entry for it
+			continue
+		}
+		if _, ok := ci.loadEntry(ctx, ts.Key()); ok {
+			continue
+		}
+		if _, err := ci.fillCache(ctx, ts.Key()); err != nil {
+			return xerrors.Errorf("failed to fill cache: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // floors to nearest skipLength multiple
 func (ci *ChainIndex) roundHeight(h abi.ChainEpoch) abi.ChainEpoch {
 	return (h / ci.skipLength) * ci.skipLength
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	syncds "github.com/ipfs/go-datastore/sync"
 	"github.com/stretchr/testify/assert"
 
@@ -86,3 +87,60 @@ func TestIndexSeeks(t *testing.T) {
 		assert.Equal(t, abi.ChainEpoch(i), ts3.Height())
 	}
 }
+
+func TestIndexPersisted(t *testing.T) {
+	cg, err := gen.NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gencar, err := cg.GenesisCar()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.TODO()
+
+	nbs := blockstore.NewMemorySync()
+	ds := syncds.MutexWrap(datastore.NewMapDatastore())
+	cs := store.NewChainStore(nbs, nbs, ds, filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	_, err = cs.Import(ctx, bytes.NewReader(gencar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cur := mock.TipSet(cg.Genesis())
+	for i := 0; i < 100; i++ {
+		cur = mock.TipSet(mock.MkBlock(cur, 1, 1))
+		if err := cs.PutTipSet(ctx, cur); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ts, err := cs.GetTipsetByHeight(ctx, 10, cur, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ds.Query(ctx, query.Query{Prefix: "/chain/index", KeysOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, entries)
+
+	// a restarted chainstore finds the same tipset through the persisted entries
+	restarted := store.NewChainStore(nbs, nbs, ds, filcns.Weight, nil)
+	defer restarted.Close() //nolint:errcheck
+
+	ts2, err := restarted.GetTipsetByHeight(ctx, 10, cur, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ts.Key(), ts2.Key())
+}
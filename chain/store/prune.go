@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// statePrunedKey records the epoch below which tipsets no longer have their parent state.
+var statePrunedKey = dstore.NewKey("/chain/statePruned")
+
+// pruneBatchSize is the number of blocks deleted at once when sweeping the blockstore.
+const pruneBatchSize = 1000
+
+type pruneProtectors struct {
+	lk         sync.Mutex
+	protectors []func(func(cid.Cid) error) error
+}
+
+// AddProtector adds a function enumerating objects which are not reachable from the chain but
+// must survive a state prune, such as pending messages; implements the GCReferenceProtector
+// interface.
+func (cs *ChainStore) AddProtector(protector func(func(cid.Cid) error) error) {
+	cs.pruneProtect.lk.Lock()
+	defer cs.pruneProtect.lk.Unlock()
+
+	cs.pruneProtect.protectors = append(cs.pruneProtect.protectors, protector)
+}
+
+func (cs *ChainStore) loadStatePruned(ctx context.Context) error {
+	b, err := cs.metadataDs.Get(ctx, statePrunedKey)
+	if err == dstore.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("loading state prune epoch: %w", err)
+	}
+
+	h, n := binary.Varint(b)
+	if n <= 0 {
+		return xerrors.Errorf("malformed state prune epoch")
+	}
+	cs.statePruned.Store(h)
+	return nil
+}
+
+// StatePrunedBefore returns the epoch below which the state of the chain has been pruned by
+// PruneState; tipsets below it no longer have their parent state, except for the genesis.
+func (cs *ChainStore) StatePrunedBefore() abi.ChainEpoch {
+	return abi.ChainEpoch(cs.statePruned.Load())
+}
+
+// SetStatePrunedBefore records the epoch below which tipsets don't have their parent state.
+func (cs *ChainStore) SetStatePrunedBefore(ctx context.Context, boundary abi.ChainEpoch) error {
+	buf := binary.AppendVarint(nil, int64(boundary))
+	if err := cs.metadataDs.Put(ctx, statePrunedKey, buf); err != nil {
+		return xerrors.Errorf("persisting state prune epoch: %w", err)
+	}
+	cs.statePruned.Store(int64(boundary))
+	return nil
+}
+
+// RecordStateBoundary walks the chain of ts down while the parent state roots of the tipsets are
+// in the state blockstore, and records the height of the lowest one as the epoch below which the
+// state is missing, as after importing a lite snapshot. It returns that epoch.
+func (cs *ChainStore) RecordStateBoundary(ctx context.Context, ts *types.TipSet) (abi.ChainEpoch, error) {
+	for ts.Height() > 0 {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		pts, err := cs.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return 0, xerrors.Errorf("loading parent tipset of %s: %w", ts.Key(), err)
+		}
+		has, err := cs.stateBlockstore.Has(ctx, pts.ParentState())
+		if err != nil {
+			return 0, xerrors.Errorf("checking for state root %s: %w", pts.ParentState(), err)
+		}
+		if !has && pts.Height() > 0 {
+			break
+		}
+		ts = pts
+	}
+
+	if err := cs.SetStatePrunedBefore(ctx, ts.Height()); err != nil {
+		return 0, err
+	}
+	return ts.Height(), nil
+}
+
+// CheckStatePruned returns an *api.ErrStatePruned if the parent state of the tipsets at height h
+// has been pruned.
+func (cs *ChainStore) CheckStatePruned(h abi.ChainEpoch) error {
+	boundary := cs.StatePrunedBefore()
+	if h == 0 || h >= boundary {
+		return nil
+	}
+
+	// the genesis state is always retained
+	nearest := boundary
+	if h < boundary-h {
+		nearest = 0
+	}
+	return &api.ErrStatePruned{Height: h, NearestRetained: nearest}
+}
+
+// PruneState deletes from bs the state trees and receipts of the tipsets more than retention
+// epochs below the current head, keeping all the block headers and messages of the chain, the
+// genesis state and the actor bundles. bs must be the blockstore underlying the chain and state
+// blockstores, and its AllKeysChan must iterate over a snapshot of the keys taken when it is
+// called, as the badger blockstore does; the objects written while pruning are then never
+// deleted. It returns the number of deleted blocks.
+func (cs *ChainStore) PruneState(ctx context.Context, bs bstore.Blockstore, retention abi.ChainEpoch) (int, error) {
+	if retention <= 0 {
+		return 0, xerrors.Errorf("state retention must be positive, got %d", retention)
+	}
+
+	head := cs.GetHeaviestTipSet()
+	boundary := head.Height() - retention + 1
+	if boundary <= cs.StatePrunedBefore() {
+		return 0, nil
+	}
+
+	start := time.Now()
+	log.Infow("pruning state", "head", head.Height(), "boundary", boundary)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// everything written after this point is outside the sweep
+	keys, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("listing blockstore keys: %w", err)
+	}
+
+	// blocks are keyed by multihash in the blockstore
+	marked := make(map[string]struct{})
+	mark := func(c cid.Cid) error {
+		marked[string(c.Hash())] = struct{}{}
+		return nil
+	}
+
+	win := snapshotWindows{messages: -1, receipts: retention, states: retention}
+	if err := cs.walkSnapshot(ctx, head, win, mark); err != nil {
+		return 0, xerrors.Errorf("marking retained chain: %w", err)
+	}
+
+	walked := cid.NewSet()
+	for _, v := range actors.Versions {
+		mf, ok := actors.GetManifest(actorstypes.Version(v))
+		if !ok || !walked.Visit(mf) {
+			continue
+		}
+		cids, err := recurseLinks(ctx, cs.stateBlockstore, walked, mf, []cid.Cid{mf})
+		if err != nil {
+			return 0, xerrors.Errorf("marking actors manifest %s: %w", mf, err)
+		}
+		for _, c := range cids {
+			_ = mark(c)
+		}
+	}
+
+	cs.pruneProtect.lk.Lock()
+	protectors := cs.pruneProtect.protectors
+	cs.pruneProtect.lk.Unlock()
+	for _, protect := range protectors {
+		if err := protect(mark); err != nil {
+			return 0, xerrors.Errorf("marking protected objects: %w", err)
+		}
+	}
+
+	// record the boundary before deleting anything, so that lookups below it fail clearly
+	if err := cs.SetStatePrunedBefore(ctx, boundary); err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	batch := make([]cid.Cid, 0, pruneBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := bs.DeleteMany(ctx, batch); err != nil {
+			return xerrors.Errorf("deleting pruned blocks: %w", err)
+		}
+		deleted += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for c := range keys {
+		if _, ok := marked[string(c.Hash())]; ok {
+			continue
+		}
+		batch = append(batch, c)
+		if len(batch) == pruneBatchSize {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return deleted, err
+	}
+	if err := flush(); err != nil {
+		return deleted, err
+	}
+
+	log.Infow("pruning state done", "boundary", boundary, "deleted", deleted, "marked", len(marked), "took", time.Since(start))
+	return deleted, nil
+}
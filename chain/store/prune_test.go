@@ -0,0 +1,77 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/gen"
+)
+
+func TestPruneState(t *testing.T) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	var tipsets []*gen.MinedTipSet
+	for i := 0; i < 20; i++ {
+		ts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		tipsets = append(tipsets, ts)
+	}
+	last := tipsets[len(tipsets)-1].TipSet.TipSet()
+
+	cs := cg.ChainStore()
+	bs := cs.StateBlockstore()
+
+	deleted, err := cs.PruneState(ctx, bs, 5)
+	require.NoError(t, err)
+	require.Greater(t, deleted, 0)
+
+	boundary := last.Height() - 4
+	require.Equal(t, boundary, cs.StatePrunedBefore())
+
+	// headers and messages are kept, as is the retained state
+	for ts := last; ts.Height() > 0; {
+		for _, b := range ts.Blocks() {
+			_, _, err := cs.MessagesForBlock(ctx, b)
+			require.NoError(t, err)
+		}
+		if ts.Height() >= boundary {
+			has, err := bs.Has(ctx, ts.ParentState())
+			require.NoError(t, err)
+			require.True(t, has)
+		}
+
+		ts, err = cs.LoadTipSet(ctx, ts.Parents())
+		require.NoError(t, err)
+	}
+
+	genesis, err := cs.GetGenesis(ctx)
+	require.NoError(t, err)
+	has, err := bs.Has(ctx, genesis.ParentStateRoot)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// state queries below the boundary report the nearest retained epoch
+	require.NoError(t, cs.CheckStatePruned(boundary))
+	require.NoError(t, cs.CheckStatePruned(0))
+
+	err = cs.CheckStatePruned(boundary - 1)
+	var perr *api.ErrStatePruned
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, boundary, perr.NearestRetained)
+
+	err = cs.CheckStatePruned(1)
+	require.ErrorAs(t, err, &perr)
+	require.Zero(t, perr.NearestRetained)
+
+	// pruning again without the chain advancing is a no-op
+	deleted, err = cs.PruneState(ctx, bs, 5)
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ScrubOptions configures a blockstore scrub, see Scrub.
+type ScrubOptions struct {
+	// StateEpochs is the number of epochs below the scrubbed tipset whose state trees and
+	// receipts are verified; a negative value verifies all of them. The genesis state is always
+	// verified.
+	StateEpochs abi.ChainEpoch
+	// Fetch, if set, is used to fetch missing and corrupted blocks, which are then written to
+	// the blockstore and walked.
+	Fetch func(context.Context, cid.Cid) (blocks.Block, error)
+	// OnBad, if set, is called for each missing or corrupted block, after trying to repair it.
+	OnBad func(c cid.Cid, corrupted, repaired bool)
+}
+
+// ScrubResult counts the blocks verified by a scrub.
+type ScrubResult struct {
+	Checked   int
+	Missing   int
+	Corrupted int
+	Repaired  int
+}
+
+// Scrub walks the chain from ts down to the genesis, reading every block header and message,
+// and the state trees and receipts within the configured window, directly from bs, and verifies
+// that the data of each block matches its CID. Missing and corrupted blocks are reported and,
+// with a Fetch function, repaired; the links of blocks which can't be read are not followed.
+// bs should be the underlying blockstore of the node, so that no fallback hides missing blocks.
+func (cs *ChainStore) Scrub(ctx context.Context, bs bstore.Blockstore, ts *types.TipSet, opts ScrubOptions) (ScrubResult, error) {
+	var res ScrubResult
+
+	// load returns the verified data of a block, or nil if it is missing or corrupted and can't
+	// be repaired
+	load := func(c cid.Cid) ([]byte, error) {
+		if c.Prefix().MhType == multihash.IDENTITY {
+			dmh, err := multihash.Decode(c.Hash())
+			if err != nil {
+				return nil, xerrors.Errorf("decoding identity cid %s: %w", c, err)
+			}
+			return dmh.Digest, nil
+		}
+
+		res.Checked++
+
+		var data []byte
+		blk, err := bs.Get(ctx, c)
+		switch {
+		case ipld.IsNotFound(err):
+		case err != nil:
+			return nil, xerrors.Errorf("reading block %s: %w", c, err)
+		default:
+			data = blk.RawData()
+		}
+
+		corrupted := data != nil && !blockMatches(c, data)
+		if data != nil && !corrupted {
+			return data, nil
+		}
+
+		if corrupted {
+			res.Corrupted++
+		} else {
+			res.Missing++
+		}
+
+		data = nil
+		if opts.Fetch != nil {
+			blk, err := opts.Fetch(ctx, c)
+			if err != nil {
+				log.Warnw("failed to fetch block", "cid", c, "error", err)
+			} else if !blockMatches(c, blk.RawData()) {
+				log.Warnw("fetched block doesn't match its cid", "cid", c)
+			} else {
+				// the blockstore skips writing blocks it already has
+				if corrupted {
+					if err := bs.DeleteBlock(ctx, c); err != nil {
+						return nil, xerrors.Errorf("deleting corrupted block %s: %w", c, err)
+					}
+				}
+				if err := bs.Put(ctx, blk); err != nil {
+					return nil, xerrors.Errorf("writing repaired block %s: %w", c, err)
+				}
+				res.Repaired++
+				data = blk.RawData()
+			}
+		}
+
+		if opts.OnBad != nil {
+			opts.OnBad(c, corrupted, data != nil)
+		}
+		return data, nil
+	}
+
+	walked := cid.NewSet()
+
+	// walkDag verifies all the blocks of the DAG under root, depth first
+	walkDag := func(root cid.Cid) error {
+		if !walked.Visit(root) {
+			return nil
+		}
+
+		stack := []cid.Cid{root}
+		for len(stack) > 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			data, err := load(c)
+			if err != nil {
+				return err
+			}
+			if data == nil || c.Prefix().Codec != cid.DagCBOR {
+				continue
+			}
+
+			if err := cbg.ScanForLinks(bytes.NewReader(data), func(l cid.Cid) {
+				if walked.Visit(l) {
+					stack = append(stack, l)
+				}
+			}); err != nil {
+				log.Warnw("failed to scan block for links", "cid", c, "error", err)
+			}
+		}
+		return nil
+	}
+
+	inWindow := func(h abi.ChainEpoch) bool {
+		return h == 0 || opts.StateEpochs < 0 || h > ts.Height()-opts.StateEpochs
+	}
+
+	lastLog := time.Now()
+	seen := cid.NewSet()
+	headers := append([]cid.Cid(nil), ts.Cids()...)
+	for len(headers) > 0 {
+		c := headers[len(headers)-1]
+		headers = headers[:len(headers)-1]
+		if !seen.Visit(c) {
+			continue
+		}
+
+		data, err := load(c)
+		if err != nil {
+			return res, err
+		}
+		if data == nil {
+			continue
+		}
+
+		var b types.BlockHeader
+		if err := b.UnmarshalCBOR(bytes.NewReader(data)); err != nil {
+			return res, xerrors.Errorf("unmarshaling block header %s: %w", c, err)
+		}
+
+		if time.Since(lastLog) > time.Minute {
+			log.Infow("scrubbing blockstore", "height", b.Height, "checked", res.Checked, "missing", res.Missing, "corrupted", res.Corrupted)
+			lastLog = time.Now()
+		}
+
+		if err := walkDag(b.Messages); err != nil {
+			return res, err
+		}
+		if inWindow(b.Height) {
+			if err := walkDag(b.ParentStateRoot); err != nil {
+				return res, err
+			}
+			if err := walkDag(b.ParentMessageReceipts); err != nil {
+				return res, err
+			}
+		}
+
+		if b.Height > 0 {
+			headers = append(headers, b.Parents...)
+		}
+	}
+
+	return res, nil
+}
+
+// blockMatches checks that data hashes to the multihash of c.
+func blockMatches(c cid.Cid, data []byte) bool {
+	sum, err := c.Prefix().Sum(data)
+	if err != nil {
+		return false
+	}
+	return sum.Equals(c)
+}
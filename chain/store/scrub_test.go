@@ -0,0 +1,80 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+func TestScrub(t *testing.T) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	var last *gen.MinedTipSet
+	for i := 0; i < 10; i++ {
+		last, err = cg.NextTipSet()
+		require.NoError(t, err)
+	}
+	head := last.TipSet.TipSet()
+
+	cs := cg.ChainStore()
+	bs := cg.Blockstore()
+
+	backup := blockstore.NewMemory()
+	keys, err := bs.AllKeysChan(ctx)
+	require.NoError(t, err)
+	for c := range keys {
+		blk, err := bs.Get(ctx, c)
+		require.NoError(t, err)
+		require.NoError(t, backup.Put(ctx, blk))
+	}
+
+	opts := store.ScrubOptions{StateEpochs: -1}
+	res, err := cs.Scrub(ctx, bs, head, opts)
+	require.NoError(t, err)
+	require.Greater(t, res.Checked, 0)
+	require.Zero(t, res.Missing)
+	require.Zero(t, res.Corrupted)
+
+	// lose the messages of the head and corrupt its parent state root
+	hb := head.Blocks()[0]
+	require.NoError(t, bs.DeleteBlock(ctx, hb.Messages))
+	require.NoError(t, bs.DeleteBlock(ctx, hb.ParentStateRoot))
+	garbage, err := blocks.NewBlockWithCid([]byte("garbage"), hb.ParentStateRoot)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(ctx, garbage))
+
+	bad := make(map[cid.Cid]bool)
+	opts.OnBad = func(c cid.Cid, corrupted, repaired bool) {
+		require.False(t, repaired)
+		bad[c] = corrupted
+	}
+	res, err = cs.Scrub(ctx, bs, head, opts)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Missing)
+	require.Equal(t, 1, res.Corrupted)
+	require.Equal(t, map[cid.Cid]bool{hb.Messages: false, hb.ParentStateRoot: true}, bad)
+
+	// repair from the backup
+	opts.OnBad = nil
+	opts.Fetch = backup.Get
+	res, err = cs.Scrub(ctx, bs, head, opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, res.Repaired)
+
+	opts.Fetch = nil
+	res, err = cs.Scrub(ctx, bs, head, opts)
+	require.NoError(t, err)
+	require.Zero(t, res.Missing)
+	require.Zero(t, res.Corrupted)
+}
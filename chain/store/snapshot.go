@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"runtime"
 	"sync"
 	"time"
 
@@ -36,6 +37,24 @@ func (cs *ChainStore) UnionStore() bstore.Blockstore {
 }
 
 func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, w io.Writer) error {
+	return cs.ExportWithReceipts(ctx, ts, inclRecentRoots, skipOldMsgs, false, w)
+}
+
+// ExportWithReceipts is Export, additionally including the message receipts of the tipsets whose
+// state roots are exported when inclReceipts is set. Nodes importing such a snapshot can then
+// look up the receipts of recent messages without re-executing their tipsets.
+func (cs *ChainStore) ExportWithReceipts(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, inclReceipts bool, w io.Writer) error {
+	win := snapshotWindows{messages: -1, states: inclRecentRoots}
+	if skipOldMsgs {
+		win.messages = inclRecentRoots
+	}
+	if inclReceipts {
+		win.receipts = inclRecentRoots
+	}
+	return cs.exportWindows(ctx, ts, win, w)
+}
+
+func (cs *ChainStore) exportWindows(ctx context.Context, ts *types.TipSet, win snapshotWindows, w io.Writer) error {
 	h := &car.CarHeader{
 		Roots:   ts.Cids(),
 		Version: 1,
@@ -46,7 +65,7 @@ func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRo
 	}
 
 	unionBs := cs.UnionStore()
-	return cs.WalkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, true, func(c cid.Cid) error {
+	return cs.walkSnapshot(ctx, ts, win, func(c cid.Cid) error {
 		blk, err := unionBs.Get(ctx, c)
 		if err != nil {
 			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
@@ -60,62 +79,224 @@ func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRo
 	})
 }
 
+// DefaultImportWorkers is the default number of goroutines verifying and writing the blocks of
+// an imported CAR.
+var DefaultImportWorkers = runtime.NumCPU()
+
+// ImportOptions tune a chain import.
+type ImportOptions struct {
+	// Workers is the number of goroutines verifying and writing blocks; DefaultImportWorkers if 0.
+	Workers int
+	// Size is the size in bytes of the CAR, if known, used to estimate the remaining time.
+	Size int64
+	// Root, if set, is the tipset the CAR must be rooted at; other CARs are refused before any
+	// block is imported.
+	Root types.TipSetKey
+}
+
+// ImportProgress reports the progress of a chain import.
+type ImportProgress struct {
+	Running bool
+	Started time.Time
+	// Blocks is the number of blocks verified and written
+	Blocks int64
+	// Bytes is the number of bytes of the CAR read
+	Bytes int64
+	// Size is the size of the CAR, when known
+	Size int64 `json:",omitempty"`
+}
+
+// Rate returns the import throughput in bytes and blocks per second.
+func (p ImportProgress) Rate() (bytesPerSec, blocksPerSec float64) {
+	elapsed := time.Since(p.Started).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	return float64(p.Bytes) / elapsed, float64(p.Blocks) / elapsed
+}
+
+// ETA returns the estimated remaining time of the import, if the size of the CAR is known.
+func (p ImportProgress) ETA() (time.Duration, bool) {
+	bps, _ := p.Rate()
+	if p.Size <= 0 || bps <= 0 || p.Bytes > p.Size {
+		return 0, false
+	}
+	return time.Duration(float64(p.Size-p.Bytes) / bps * float64(time.Second)), true
+}
+
+type importTracker struct {
+	started time.Time
+	size    int64
+	blocks  atomic.Int64
+	bytes   atomic.Int64
+	done    atomic.Bool
+}
+
+func (t *importTracker) progress() ImportProgress {
+	return ImportProgress{
+		Running: !t.done.Load(),
+		Started: t.started,
+		Blocks:  t.blocks.Load(),
+		Bytes:   t.bytes.Load(),
+		Size:    t.size,
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (cr countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n.Add(int64(n))
+	return n, err
+}
+
+// ImportProgress returns the progress of the running chain import, or of the last one if none is
+// running; the second return value is false if nothing was imported since the node started.
+func (cs *ChainStore) ImportProgress() (ImportProgress, bool) {
+	cs.importLk.Lock()
+	t := cs.lastImport
+	cs.importLk.Unlock()
+
+	if t == nil {
+		return ImportProgress{}, false
+	}
+	return t.progress(), true
+}
+
 func (cs *ChainStore) Import(ctx context.Context, r io.Reader) (*types.TipSet, error) {
+	return cs.ImportWithOptions(ctx, r, ImportOptions{})
+}
+
+// ImportWithOptions imports a CAR into the blockstore, verifying the blocks against their CIDs in
+// parallel, and returns its root tipset. The progress of the import is logged periodically and
+// reported by ImportProgress.
+func (cs *ChainStore) ImportWithOptions(ctx context.Context, r io.Reader, opts ImportOptions) (*types.TipSet, error) {
 	// TODO: writing only to the state blockstore is incorrect.
 	//  At this time, both the state and chain blockstores are backed by the
 	//  universal store. When we physically segregate the stores, we will need
 	//  to route state objects to the state blockstore, and chain objects to
 	//  the chain blockstore.
 
-	br, err := carv2.NewBlockReader(r)
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultImportWorkers
+	}
+
+	tracker := &importTracker{started: build.Clock.Now(), size: opts.Size}
+	defer tracker.done.Store(true)
+
+	cs.importLk.Lock()
+	cs.lastImport = tracker
+	cs.importLk.Unlock()
+
+	// the blocks are verified by the workers
+	br, err := carv2.NewBlockReader(countingReader{r: r, n: &tracker.bytes}, carv2.WithTrustedCAR(true))
 	if err != nil {
 		return nil, xerrors.Errorf("loadcar failed: %w", err)
 	}
 
+	if !opts.Root.IsEmpty() {
+		if root := types.NewTipSetKey(br.Roots...); root != opts.Root {
+			return nil, xerrors.Errorf("snapshot is rooted at %s, expected %s", root, opts.Root)
+		}
+	}
+
 	s := cs.StateBlockstore()
 
-	parallelPuts := 5
-	putThrottle := make(chan error, parallelPuts)
-	for i := 0; i < parallelPuts; i++ {
-		putThrottle <- nil
-	}
+	logDone := make(chan struct{})
+	defer close(logDone)
+	go func() {
+		tick := build.Clock.Ticker(30 * time.Second)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				p := tracker.progress()
+				bps, blkps := p.Rate()
+				if eta, ok := p.ETA(); ok {
+					log.Infow("importing chain", "blocks", p.Blocks, "MiB", p.Bytes>>20, "MiB/s", bps/(1<<20), "blocks/s", blkps, "eta", eta.Truncate(time.Second))
+				} else {
+					log.Infow("importing chain", "blocks", p.Blocks, "MiB", p.Bytes>>20, "MiB/s", bps/(1<<20), "blocks/s", blkps)
+				}
+			case <-logDone:
+				return
+			}
+		}
+	}()
 
-	var buf []blocks.Block
-	for {
-		blk, err := br.Next()
-		if err != nil {
-			if err == io.EOF {
-				if len(buf) > 0 {
-					if err := s.PutMany(ctx, buf); err != nil {
-						return nil, err
+	batches := make(chan []blocks.Block, workers)
+	eg, egctx := errgroup.WithContext(ctx)
+	for i := 0; i < workers; i++ {
+		eg.Go(func() error {
+			for batch := range batches {
+				for _, blk := range batch {
+					hashed, err := blk.Cid().Prefix().Sum(blk.RawData())
+					if err != nil {
+						return xerrors.Errorf("hashing block %s: %w", blk.Cid(), err)
 					}
+					if !hashed.Equals(blk.Cid()) {
+						return xerrors.Errorf("mismatch in content integrity, block %s hashes to %s", blk.Cid(), hashed)
+					}
+				}
+				if err := s.PutMany(egctx, batch); err != nil {
+					return err
 				}
+				tracker.blocks.Add(int64(len(batch)))
+			}
+			return nil
+		})
+	}
+
+	readErr := func() error {
+		defer close(batches)
 
+		var buf []blocks.Block
+		for {
+			blk, err := br.Next()
+			if err == io.EOF {
 				break
 			}
-			return nil, err
-		}
-
-		buf = append(buf, blk)
+			if err != nil {
+				return err
+			}
 
-		if len(buf) > 1000 {
-			if lastErr := <-putThrottle; lastErr != nil { // consume one error to have the right to add one
-				return nil, lastErr
+			buf = append(buf, blk)
+			if len(buf) < 1000 {
+				continue
 			}
 
-			go func(buf []blocks.Block) {
-				putThrottle <- s.PutMany(ctx, buf)
-			}(buf)
+			select {
+			case batches <- buf:
+			case <-egctx.Done():
+				return nil
+			}
 			buf = nil
 		}
-	}
 
-	// check errors
-	for i := 0; i < parallelPuts; i++ {
-		if lastErr := <-putThrottle; lastErr != nil {
-			return nil, lastErr
+		if len(buf) > 0 {
+			select {
+			case batches <- buf:
+			case <-egctx.Done():
+			}
 		}
+		return nil
+	}()
+	if err := eg.Wait(); err != nil {
+		return nil, err
 	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p := tracker.progress()
+	bps, blkps := p.Rate()
+	log.Infow("imported chain blocks", "blocks", p.Blocks, "MiB", p.Bytes>>20, "took", time.Since(p.Started).Truncate(time.Second), "MiB/s", bps/(1<<20), "blocks/s", blkps)
 
 	root, err := cs.LoadTipSet(ctx, types.NewTipSetKey(br.Roots...))
 	if err != nil {
@@ -141,6 +322,169 @@ func (cs *ChainStore) Import(ctx context.Context, r io.Reader) (*types.TipSet, e
 	return root, nil
 }
 
+// ExportDiff writes a CAR with the blocks of the snapshot of ts which aren't part of the
+// snapshot of base taken with the same parameters, so that operators can distribute the
+// difference between two snapshots instead of a new full one. ts must descend from base.
+//
+// The set of blocks of the base snapshot is held in memory while exporting.
+func (cs *ChainStore) ExportDiff(ctx context.Context, ts, base *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, w io.Writer) error {
+	if base.Height() >= ts.Height() {
+		return xerrors.Errorf("base tipset height %d must be below the exported tipset height %d", base.Height(), ts.Height())
+	}
+	anc, err := cs.GetTipsetByHeight(ctx, base.Height(), ts, true)
+	if err != nil {
+		return xerrors.Errorf("looking up the ancestor of %s at height %d: %w", ts.Key(), base.Height(), err)
+	}
+	if anc.Key() != base.Key() {
+		return xerrors.Errorf("tipset %s does not descend from base tipset %s", ts.Key(), base.Key())
+	}
+
+	log.Infow("walking base snapshot", "base", base.Key())
+	have := cid.NewSet()
+	if err := cs.WalkSnapshot(ctx, base, inclRecentRoots, skipOldMsgs, true, func(c cid.Cid) error {
+		have.Add(c)
+		return nil
+	}); err != nil {
+		return xerrors.Errorf("walking base snapshot: %w", err)
+	}
+
+	h := &car.CarHeader{
+		Roots:   ts.Cids(),
+		Version: 1,
+	}
+
+	if err := car.WriteHeader(h, w); err != nil {
+		return xerrors.Errorf("failed to write car header: %s", err)
+	}
+
+	unionBs := cs.UnionStore()
+	var written int
+	err = cs.WalkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, true, func(c cid.Cid) error {
+		if have.Has(c) {
+			return nil
+		}
+
+		blk, err := unionBs.Get(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
+		}
+
+		if err := carutil.LdWrite(w, c.Bytes(), blk.RawData()); err != nil {
+			return xerrors.Errorf("failed to write block to car output: %w", err)
+		}
+
+		written++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infow("differential export done", "base", base.Key(), "baseBlocks", have.Len(), "written", written)
+	return nil
+}
+
+// ExportLite writes a minimal snapshot of the chain at ts for bootstrapping non-archival nodes.
+// Besides the block headers down to genesis and the genesis state, which a node needs to load
+// the chain, it only includes the messages and receipts of the last recentTipsets epochs and
+// the state trees of the last stateRoots epochs.
+//
+// A stateRoots of 1 only includes the parent state of ts. That is enough to serve state queries
+// at the head, but validating new blocks needs the state at the winning PoSt lookback, so nodes
+// which have to sync from the snapshot need stateRoots of at least that lookback.
+func (cs *ChainStore) ExportLite(ctx context.Context, ts *types.TipSet, recentTipsets, stateRoots abi.ChainEpoch, w io.Writer) error {
+	if recentTipsets < 1 || stateRoots < 1 {
+		return xerrors.Errorf("lite snapshots need at least one recent tipset and state root, got %d and %d", recentTipsets, stateRoots)
+	}
+
+	return cs.exportWindows(ctx, ts, snapshotWindows{messages: recentTipsets, receipts: recentTipsets, states: stateRoots}, w)
+}
+
+// ImportDiff imports a CAR written by ExportDiff on top of the snapshot of base, which must
+// already have been imported. It returns the root tipset of the CAR.
+func (cs *ChainStore) ImportDiff(ctx context.Context, r io.Reader, base types.TipSetKey) (*types.TipSet, error) {
+	baseTs, err := cs.LoadTipSet(ctx, base)
+	if err != nil {
+		return nil, xerrors.Errorf("loading base tipset %s (was the base snapshot imported?): %w", base, err)
+	}
+	if has, err := cs.StateBlockstore().Has(ctx, baseTs.ParentState()); err != nil {
+		return nil, xerrors.Errorf("checking for the base state: %w", err)
+	} else if !has {
+		return nil, xerrors.Errorf("state of base tipset %s is missing (was the base snapshot imported?)", base)
+	}
+
+	root, err := cs.Import(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	anc, err := cs.GetTipsetByHeight(ctx, baseTs.Height(), root, true)
+	if err != nil {
+		return nil, xerrors.Errorf("looking up the base of the imported chain: %w", err)
+	}
+	if anc.Key() != base {
+		return nil, xerrors.Errorf("imported tipset %s does not descend from base tipset %s", root.Key(), base)
+	}
+
+	return root, nil
+}
+
+// ImportOnline imports a snapshot into the blockstore of a running node and switches the head to
+// its root tipset once validated. The snapshot must be a chain of this node's network, and its
+// root must be heavier than the current head. validate is called with the root tipset before the
+// head is switched, and can be used to check that its state is usable.
+func (cs *ChainStore) ImportOnline(ctx context.Context, r io.Reader, validate func(context.Context, *types.TipSet) error) (*types.TipSet, error) {
+	genesis, err := cs.GetGenesis(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("loading genesis: %w", err)
+	}
+
+	root, err := cs.Import(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	gts, err := cs.GetTipsetByHeight(ctx, 0, root, true)
+	if err != nil {
+		return nil, xerrors.Errorf("looking up the genesis of the imported chain: %w", err)
+	}
+	if gts.Blocks()[0].Cid() != genesis.Cid() {
+		return nil, xerrors.Errorf("imported chain has genesis %s, expected %s", gts.Blocks()[0].Cid(), genesis.Cid())
+	}
+	if has, err := cs.StateBlockstore().Has(ctx, root.ParentState()); err != nil {
+		return nil, xerrors.Errorf("checking for the imported state: %w", err)
+	} else if !has {
+		return nil, xerrors.Errorf("state %s of imported tipset %s is missing", root.ParentState(), root.Key())
+	}
+
+	if validate != nil {
+		if err := validate(ctx, root); err != nil {
+			return nil, xerrors.Errorf("validating imported tipset %s: %w", root.Key(), err)
+		}
+	}
+
+	rootWeight, err := cs.weight(ctx, cs.StateBlockstore(), root)
+	if err != nil {
+		return nil, xerrors.Errorf("computing weight of imported tipset: %w", err)
+	}
+
+	cs.heaviestLk.Lock()
+	defer cs.heaviestLk.Unlock()
+
+	headWeight, err := cs.weight(ctx, cs.StateBlockstore(), cs.heaviest)
+	if err != nil {
+		return nil, xerrors.Errorf("computing weight of the current head: %w", err)
+	}
+	if !rootWeight.GreaterThan(headWeight) {
+		return nil, xerrors.Errorf("imported tipset %s (height %d) is not heavier than the current head %s (height %d)", root.Key(), root.Height(), cs.heaviest.Key(), cs.heaviest.Height())
+	}
+
+	if err := cs.takeHeaviestTipSet(ctx, root); err != nil {
+		return nil, xerrors.Errorf("switching head: %w", err)
+	}
+	return root, nil
+}
+
 type walkSchedTaskType int
 
 const (
@@ -605,10 +949,32 @@ func (cs *ChainStore) ExportRange(
 }
 
 func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, cb func(cid.Cid) error) error {
+	win := snapshotWindows{messages: -1, receipts: inclRecentRoots, states: inclRecentRoots}
+	if skipOldMsgs {
+		win.messages = inclRecentRoots
+	}
+	if skipMsgReceipts {
+		win.receipts = 0
+	}
+	return cs.walkSnapshot(ctx, ts, win, cb)
+}
+
+// snapshotWindows are the number of epochs below the snapshot tipset for which a snapshot walk
+// includes messages, receipts and state trees. A negative window covers the whole chain. The
+// genesis state is always included.
+type snapshotWindows struct {
+	messages, receipts, states abi.ChainEpoch
+}
+
+func (cs *ChainStore) walkSnapshot(ctx context.Context, ts *types.TipSet, win snapshotWindows, cb func(cid.Cid) error) error {
 	if ts == nil {
 		ts = cs.GetHeaviestTipSet()
 	}
 
+	inWindow := func(h, window abi.ChainEpoch) bool {
+		return window < 0 || h > ts.Height()-window
+	}
+
 	seen := cid.NewSet()
 	walked := cid.NewSet()
 
@@ -642,7 +1008,7 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 		}
 
 		var cids []cid.Cid
-		if !skipOldMsgs || b.Height > ts.Height()-inclRecentRoots {
+		if inWindow(b.Height, win.messages) {
 			if walked.Visit(b.Messages) {
 				mcids, err := recurseLinks(ctx, cs.chainBlockstore, walked, b.Messages, []cid.Cid{b.Messages})
 				if err != nil {
@@ -663,7 +1029,7 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 
 		out := cids
 
-		if b.Height == 0 || b.Height > ts.Height()-inclRecentRoots {
+		if b.Height == 0 || inWindow(b.Height, win.states) {
 			if walked.Visit(b.ParentStateRoot) {
 				cids, err := recurseLinks(ctx, cs.stateBlockstore, walked, b.ParentStateRoot, []cid.Cid{b.ParentStateRoot})
 				if err != nil {
@@ -672,10 +1038,15 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 
 				out = append(out, cids...)
 			}
+		}
 
-			if !skipMsgReceipts && walked.Visit(b.ParentMessageReceipts) {
-				out = append(out, b.ParentMessageReceipts)
+		if win.receipts != 0 && (b.Height == 0 || inWindow(b.Height, win.receipts)) && walked.Visit(b.ParentMessageReceipts) {
+			cids, err := recurseLinks(ctx, cs.stateBlockstore, walked, b.ParentMessageReceipts, []cid.Cid{b.ParentMessageReceipts})
+			if err != nil {
+				return xerrors.Errorf("recursing receipts failed: %w", err)
 			}
+
+			out = append(out, cids...)
 		}
 
 		for _, c := range out {
@@ -9,12 +9,14 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 	block "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
 	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
 	"github.com/ipfs/go-datastore/query"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	logging "github.com/ipfs/go-log/v2"
@@ -43,6 +45,7 @@ var (
 	chainHeadKey                  = dstore.NewKey("head")
 	checkpointKey                 = dstore.NewKey("/chain/checks")
 	blockValidationCacheKeyPrefix = dstore.NewKey("blockValidation")
+	chainIndexPrefix              = dstore.NewKey("/chain/index")
 )
 
 var DefaultTipSetCacheSize = 8192
@@ -128,6 +131,15 @@ type ChainStore struct {
 
 	storeEvents bool
 
+	// epoch below which the state has been pruned, see PruneState
+	statePruned  atomic.Int64
+	pruneProtect pruneProtectors
+
+	trusted trustedCheckpoints
+
+	importLk   sync.Mutex
+	lastImport *importTracker
+
 	cancelFn context.CancelFunc
 	wg       sync.WaitGroup
 }
@@ -162,6 +174,7 @@ func NewChainStore(chainBs bstore.Blockstore, stateBs bstore.Blockstore, ds dsto
 	}
 
 	ci := NewChainIndex(cs.LoadTipSet)
+	ci.ds = namespace.Wrap(ds, chainIndexPrefix)
 
 	cs.cindex = ci
 
@@ -195,8 +208,15 @@ func NewChainStore(chainBs bstore.Blockstore, stateBs bstore.Blockstore, ds dsto
 		return nil
 	}
 
+	hcindex := func(rev, app []*types.TipSet) error {
+		if err := ci.onHeadChange(ctx, rev, app); err != nil {
+			log.Warnf("failed to update chain index: %s", err)
+		}
+		return nil
+	}
+
 	cs.reorgNotifeeCh = make(chan ReorgNotifee)
-	cs.reorgCh = cs.reorgWorker(ctx, []ReorgNotifee{hcnf, hcmetric})
+	cs.reorgCh = cs.reorgWorker(ctx, []ReorgNotifee{hcnf, hcmetric, hcindex})
 
 	return cs
 }
@@ -214,6 +234,12 @@ func (cs *ChainStore) Load(ctx context.Context) error {
 	if err := cs.loadCheckpoint(ctx); err != nil {
 		return err
 	}
+	if err := cs.loadStatePruned(ctx); err != nil {
+		return err
+	}
+	if err := cs.loadTrustedCheckpoints(ctx); err != nil {
+		return err
+	}
 	return nil
 }
 func (cs *ChainStore) loadHead(ctx context.Context) error {
@@ -453,6 +479,10 @@ func (cs *ChainStore) MaybeTakeHeavierTipSet(ctx context.Context, ts *types.TipS
 			return nil
 		}
 
+		if err := cs.checkTrustedCheckpointsOf(ctx, ts); err != nil {
+			return err
+		}
+
 		return cs.takeHeaviestTipSet(ctx, ts)
 	}
 
@@ -970,6 +1000,15 @@ func (cs *ChainStore) AddToTipSetTracker(ctx context.Context, b *types.BlockHead
 	return nil
 }
 
+// TrackedBlocks returns the blocks at height h recently added to the tipset tracker. These are
+// only kept for about a finality.
+func (cs *ChainStore) TrackedBlocks(h abi.ChainEpoch) []cid.Cid {
+	cs.tstLk.Lock()
+	defer cs.tstLk.Unlock()
+
+	return append([]cid.Cid(nil), cs.tipsets[h]...)
+}
+
 func (cs *ChainStore) PersistTipsets(ctx context.Context, tipsets []*types.TipSet) error {
 	toPersist := make([]*types.BlockHeader, 0, len(tipsets)*int(build.BlocksPerEpoch))
 	tsBlks := make([]block.Block, 0, len(tipsets))
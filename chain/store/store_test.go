@@ -117,7 +117,8 @@ func TestChainExportImport(t *testing.T) {
 	cs := store.NewChainStore(nbs, nbs, datastore.NewMapDatastore(), filcns.Weight, nil)
 	defer cs.Close() //nolint:errcheck
 
-	root, err := cs.Import(context.TODO(), buf)
+	size := int64(buf.Len())
+	root, err := cs.ImportWithOptions(context.TODO(), buf, store.ImportOptions{Workers: 4, Size: size})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -125,6 +126,70 @@ func TestChainExportImport(t *testing.T) {
 	if !root.Equals(last) {
 		t.Fatal("imported chain differed from exported chain")
 	}
+
+	p, ok := cs.ImportProgress()
+	require.True(t, ok)
+	require.False(t, p.Running)
+	require.Equal(t, size, p.Bytes)
+	require.Greater(t, p.Blocks, int64(0))
+}
+
+func TestChainImportCorrupted(t *testing.T) {
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	var last *types.TipSet
+	for i := 0; i < 10; i++ {
+		ts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		last = ts.TipSet.TipSet()
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cg.ChainStore().Export(context.TODO(), last, 0, false, buf))
+
+	// flip the last byte of the data of the last block
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xff
+
+	nbs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(nbs, nbs, datastore.NewMapDatastore(), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	_, err = cs.Import(context.TODO(), bytes.NewReader(data))
+	require.ErrorContains(t, err, "content integrity")
+}
+
+func TestChainImportPinnedRoot(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	var tipsets []*types.TipSet
+	for i := 0; i < 10; i++ {
+		ts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		tipsets = append(tipsets, ts.TipSet.TipSet())
+	}
+	last := tipsets[len(tipsets)-1]
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cg.ChainStore().Export(ctx, last, 0, false, buf))
+
+	nbs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(nbs, nbs, datastore.NewMapDatastore(), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	// a snapshot rooted elsewhere is refused before any block is imported
+	_, err = cs.ImportWithOptions(ctx, bytes.NewReader(buf.Bytes()), store.ImportOptions{Root: tipsets[5].Key()})
+	require.ErrorContains(t, err, "snapshot is rooted at")
+	has, err := nbs.Has(ctx, last.Cids()[0])
+	require.NoError(t, err)
+	require.False(t, has)
+
+	ts, err := cs.ImportWithOptions(ctx, bytes.NewReader(buf.Bytes()), store.ImportOptions{Root: last.Key()})
+	require.NoError(t, err)
+	require.Equal(t, last.Key(), ts.Key())
 }
 
 // Test to check if tipset key cids are being stored on snapshot
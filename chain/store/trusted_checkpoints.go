@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// trustedCheckpointsPrefix is the metadata datastore prefix of the trusted checkpoints, keyed by
+// epoch.
+var trustedCheckpointsPrefix = dstore.NewKey("/chain/trustedCheckpoints")
+
+// ErrTrustedCheckpoint is returned for chains which don't go through the trusted checkpoints.
+var ErrTrustedCheckpoint = errors.New("chain conflicts with a trusted checkpoint")
+
+type trustedCheckpoints struct {
+	lk     sync.RWMutex
+	sorted []api.TrustedCheckpoint
+}
+
+// ReadCheckpointsFile reads a checkpoints file, a JSON list of trusted checkpoints as written by
+// WriteCheckpoints.
+func ReadCheckpointsFile(path string) ([]api.TrustedCheckpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var cps []api.TrustedCheckpoint
+	if err := json.NewDecoder(f).Decode(&cps); err != nil {
+		return nil, xerrors.Errorf("decoding checkpoints file %s: %w", path, err)
+	}
+	return cps, nil
+}
+
+// WriteCheckpoints writes trusted checkpoints in the format of a checkpoints file.
+func WriteCheckpoints(w io.Writer, cps []api.TrustedCheckpoint) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cps)
+}
+
+func (cs *ChainStore) loadTrustedCheckpoints(ctx context.Context) error {
+	res, err := cs.metadataDs.Query(ctx, query.Query{Prefix: trustedCheckpointsPrefix.String()})
+	if err != nil {
+		return xerrors.Errorf("querying trusted checkpoints: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var cps []api.TrustedCheckpoint
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("reading trusted checkpoints: %w", r.Error)
+		}
+
+		var cp api.TrustedCheckpoint
+		if err := json.Unmarshal(r.Value, &cp); err != nil {
+			return xerrors.Errorf("decoding trusted checkpoint %s: %w", r.Key, err)
+		}
+		cps = append(cps, cp)
+	}
+
+	sort.Slice(cps, func(i, j int) bool { return cps[i].Epoch < cps[j].Epoch })
+
+	cs.trusted.lk.Lock()
+	cs.trusted.sorted = cps
+	cs.trusted.lk.Unlock()
+	return nil
+}
+
+// TrustedCheckpoints returns the trusted checkpoints, by increasing epoch.
+func (cs *ChainStore) TrustedCheckpoints() []api.TrustedCheckpoint {
+	cs.trusted.lk.RLock()
+	defer cs.trusted.lk.RUnlock()
+
+	return append([]api.TrustedCheckpoint(nil), cs.trusted.sorted...)
+}
+
+// AddTrustedCheckpoint persists a trusted checkpoint. It fails if another tipset is already
+// trusted at the same epoch, or if the current chain goes through another tipset at that epoch.
+func (cs *ChainStore) AddTrustedCheckpoint(ctx context.Context, cp api.TrustedCheckpoint) error {
+	if cp.TipSet.IsEmpty() {
+		return xerrors.Errorf("empty tipset key for checkpoint at epoch %d", cp.Epoch)
+	}
+
+	// checked before taking the lock, which MaybeTakeHeavierTipSet takes while holding heaviestLk
+	if head := cs.GetHeaviestTipSet(); head != nil && cp.Epoch <= head.Height() {
+		if err := cs.checkTrustedCheckpoint(ctx, head, cp); err != nil {
+			return xerrors.Errorf("checkpoint at epoch %d doesn't match the current chain: %w", cp.Epoch, err)
+		}
+	}
+
+	cs.trusted.lk.Lock()
+	defer cs.trusted.lk.Unlock()
+
+	i := sort.Search(len(cs.trusted.sorted), func(i int) bool { return cs.trusted.sorted[i].Epoch >= cp.Epoch })
+	if i < len(cs.trusted.sorted) && cs.trusted.sorted[i].Epoch == cp.Epoch {
+		if cs.trusted.sorted[i].TipSet == cp.TipSet {
+			return nil
+		}
+		return xerrors.Errorf("tipset %s is already trusted at epoch %d", cs.trusted.sorted[i].TipSet, cp.Epoch)
+	}
+
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	if err := cs.metadataDs.Put(ctx, trustedCheckpointKey(cp.Epoch), b); err != nil {
+		return xerrors.Errorf("persisting trusted checkpoint: %w", err)
+	}
+
+	cs.trusted.sorted = append(cs.trusted.sorted, api.TrustedCheckpoint{})
+	copy(cs.trusted.sorted[i+1:], cs.trusted.sorted[i:])
+	cs.trusted.sorted[i] = cp
+	return nil
+}
+
+// RemoveTrustedCheckpoint removes the trusted checkpoint at an epoch.
+func (cs *ChainStore) RemoveTrustedCheckpoint(ctx context.Context, epoch abi.ChainEpoch) error {
+	cs.trusted.lk.Lock()
+	defer cs.trusted.lk.Unlock()
+
+	i := sort.Search(len(cs.trusted.sorted), func(i int) bool { return cs.trusted.sorted[i].Epoch >= epoch })
+	if i == len(cs.trusted.sorted) || cs.trusted.sorted[i].Epoch != epoch {
+		return xerrors.Errorf("no trusted checkpoint at epoch %d", epoch)
+	}
+
+	if err := cs.metadataDs.Delete(ctx, trustedCheckpointKey(epoch)); err != nil {
+		return xerrors.Errorf("deleting trusted checkpoint: %w", err)
+	}
+
+	cs.trusted.sorted = append(cs.trusted.sorted[:i], cs.trusted.sorted[i+1:]...)
+	return nil
+}
+
+// CheckTrustedCheckpoints checks that a chain segment, ordered from the highest tipset down and
+// linked by parents, goes through the trusted checkpoints at the epochs it spans.
+func (cs *ChainStore) CheckTrustedCheckpoints(tipsets []*types.TipSet) error {
+	if len(tipsets) == 0 {
+		return nil
+	}
+
+	cs.trusted.lk.RLock()
+	defer cs.trusted.lk.RUnlock()
+
+	cps := cs.trusted.sorted
+	low, high := tipsets[len(tipsets)-1].Height(), tipsets[0].Height()
+	i := sort.Search(len(cps), func(i int) bool { return cps[i].Epoch >= low })
+
+	for j := len(tipsets) - 1; j >= 0 && i < len(cps) && cps[i].Epoch <= high; j-- {
+		ts := tipsets[j]
+		for i < len(cps) && cps[i].Epoch <= ts.Height() {
+			if cps[i].Epoch < ts.Height() || cps[i].TipSet != ts.Key() {
+				// the checkpoint is either skipped by a null round, or a different tipset
+				return xerrors.Errorf("tipset %s at epoch %d: %w", ts.Key(), ts.Height(), ErrTrustedCheckpoint)
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// checkTrustedCheckpointsOf checks that the chain of ts goes through the highest trusted
+// checkpoint at or below its height, and thereby through all the others.
+func (cs *ChainStore) checkTrustedCheckpointsOf(ctx context.Context, ts *types.TipSet) error {
+	cs.trusted.lk.RLock()
+	cps := cs.trusted.sorted
+	i := sort.Search(len(cps), func(i int) bool { return cps[i].Epoch > ts.Height() })
+	var cp api.TrustedCheckpoint
+	if i > 0 {
+		cp = cps[i-1]
+	}
+	cs.trusted.lk.RUnlock()
+
+	if i == 0 {
+		return nil
+	}
+	return cs.checkTrustedCheckpoint(ctx, ts, cp)
+}
+
+func (cs *ChainStore) checkTrustedCheckpoint(ctx context.Context, ts *types.TipSet, cp api.TrustedCheckpoint) error {
+	cts, err := cs.GetTipsetByHeight(ctx, cp.Epoch, ts, true)
+	if err != nil {
+		return xerrors.Errorf("looking up the tipset at checkpoint epoch %d: %w", cp.Epoch, err)
+	}
+	if cts.Height() != cp.Epoch || cts.Key() != cp.TipSet {
+		return xerrors.Errorf("chain of %s has %s at epoch %d, trusted checkpoint is %s: %w", ts.Key(), cts.Key(), cts.Height(), cp.TipSet, ErrTrustedCheckpoint)
+	}
+	return nil
+}
+
+func trustedCheckpointKey(epoch abi.ChainEpoch) dstore.Key {
+	return trustedCheckpointsPrefix.ChildString(strconv.FormatInt(int64(epoch), 10))
+}
@@ -0,0 +1,75 @@
+// stm: #unit
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestTrustedCheckpoints(t *testing.T) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	var chain []*types.TipSet
+	for i := 0; i < 10; i++ {
+		mts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		chain = append(chain, mts.TipSet.TipSet())
+	}
+	cs := cg.ChainStore()
+
+	at := func(h int) *types.TipSet {
+		for _, ts := range chain {
+			if int(ts.Height()) == h {
+				return ts
+			}
+		}
+		t.Fatalf("no tipset at height %d", h)
+		return nil
+	}
+
+	// checkpoints on the current chain and ahead of the head are accepted
+	cp5 := api.TrustedCheckpoint{Epoch: 5, TipSet: at(5).Key()}
+	require.NoError(t, cs.AddTrustedCheckpoint(ctx, cp5))
+	ahead := api.TrustedCheckpoint{Epoch: 100, TipSet: at(3).Key()}
+	require.NoError(t, cs.AddTrustedCheckpoint(ctx, ahead))
+	require.Equal(t, []api.TrustedCheckpoint{cp5, ahead}, cs.TrustedCheckpoints())
+
+	// adding the same checkpoint again is a no-op, conflicting ones are refused
+	require.NoError(t, cs.AddTrustedCheckpoint(ctx, cp5))
+	require.Error(t, cs.AddTrustedCheckpoint(ctx, api.TrustedCheckpoint{Epoch: 5, TipSet: at(4).Key()}))
+	require.Error(t, cs.AddTrustedCheckpoint(ctx, api.TrustedCheckpoint{Epoch: 6, TipSet: at(4).Key()}))
+
+	// the canonical chain goes through the checkpoint
+	segment := []*types.TipSet{at(7), at(6), at(5), at(4)}
+	require.NoError(t, cs.CheckTrustedCheckpoints(segment))
+
+	// a fork skipping the checkpoint with a null round is refused
+	mts, err := cg.NextTipSetFromMiners(at(4), cg.Miners, 1)
+	require.NoError(t, err)
+	fork := mts.TipSet.TipSet()
+	require.Equal(t, at(6).Height(), fork.Height())
+	require.ErrorIs(t, cs.CheckTrustedCheckpoints([]*types.TipSet{fork, at(4)}), store.ErrTrustedCheckpoint)
+
+	// segments not spanning any checkpoint are accepted
+	require.NoError(t, cs.CheckTrustedCheckpoints([]*types.TipSet{at(9), at(8)}))
+
+	// checkpoints round trip through checkpoints files
+	var buf bytes.Buffer
+	require.NoError(t, store.WriteCheckpoints(&buf, cs.TrustedCheckpoints()))
+	require.Contains(t, buf.String(), at(5).Cids()[0].String())
+
+	require.NoError(t, cs.RemoveTrustedCheckpoint(ctx, 100))
+	require.Error(t, cs.RemoveTrustedCheckpoint(ctx, 100))
+	require.Equal(t, []api.TrustedCheckpoint{cp5}, cs.TrustedCheckpoints())
+}
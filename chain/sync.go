@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-	"sync"
 	"time"
 
 	"github.com/Gurpartap/async"
@@ -55,6 +54,7 @@ var (
 	concurrentSyncRequests = exchange.ShufflePeersPrefix
 	syncRequestBatchSize   = 8
 	syncRequestRetries     = 5
+	syncStripeHedgeFactor  = 2
 )
 
 // Syncer is in charge of running the chain synchronization logic. As such, it
@@ -247,6 +247,9 @@ func (syncer *Syncer) InformNewHead(from peer.ID, fts *store.FullTipSet) bool {
 		return false
 	}
 
+	// Start executing the tipset while it's being synced, so that its state is ready by the time
+	// it becomes the head.
+	syncer.sm.SpeculativeExecute(fts.TipSet())
 	syncer.syncmgr.SetPeerHead(ctx, from, fts.TipSet())
 	return true
 }
@@ -943,22 +946,41 @@ func (syncer *Syncer) syncMessagesAndCheckState(ctx context.Context, headers []*
 	ss := extractSyncState(ctx)
 	ss.SetHeight(headers[len(headers)-1].Height())
 
-	return syncer.iterFullTipsets(ctx, headers, func(ctx context.Context, fts *store.FullTipSet) error {
+	pv := syncer.startPrevalidation(ctx)
+	defer pv.close()
+
+	return syncer.iterFullTipsets(ctx, headers, pv.submit, func(ctx context.Context, fts *store.FullTipSet) error {
+		if err := pv.wait(ctx, fts.TipSet().Key()); err != nil {
+			log.Errorf("failed to prevalidate tipset: %+v", err)
+			return xerrors.Errorf("message processing failed: %w", err)
+		}
+
+		// compute the parent state first, so that execution is accounted apart from validation;
+		// failures are left for the validation to report
+		start := build.Clock.Now()
+		if pts, err := syncer.store.LoadTipSet(ctx, fts.TipSet().Parents()); err == nil {
+			_, _, _ = syncer.sm.TipSetState(ctx, pts)
+		}
+		execution := build.Clock.Since(start)
+
 		log.Debugw("validating tipset", "height", fts.TipSet().Height(), "size", len(fts.TipSet().Cids()))
+		start = build.Clock.Now()
 		if err := syncer.ValidateTipSet(ctx, fts, true); err != nil {
 			log.Errorf("failed to validate tipset: %+v", err)
 			return xerrors.Errorf("message processing failed: %w", err)
 		}
 
 		stats.Record(ctx, metrics.ChainNodeWorkerHeight.M(int64(fts.TipSet().Height())))
-		ss.SetHeight(fts.TipSet().Height())
+		ss.TipSetValidated(fts.TipSet().Height(), build.Clock.Since(start), execution)
 
 		return nil
 	})
 }
 
-// fills out each of the given tipsets with messages and calls the callback with it
-func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipSet, cb func(context.Context, *store.FullTipSet) error) error {
+// fills out each of the given tipsets with messages and calls the callback with it; ahead is called
+// with all the tipsets of a batch of fetched messages, in order, before the callback is called with
+// the first of them
+func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipSet, ahead func(*store.FullTipSet), cb func(context.Context, *store.FullTipSet) error) error {
 	ss := extractSyncState(ctx)
 	ctx, span := trace.StartSpan(ctx, "iterFullTipsets")
 	defer span.End()
@@ -992,6 +1014,8 @@ func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipS
 			return xerrors.Errorf("failed to fetch messages: %w", batchErr)
 		}
 
+		fullTipsets := make([]*store.FullTipSet, len(bstout))
+		msgstores := make([]bstore.Blockstore, len(bstout))
 		for bsi := 0; bsi < len(bstout); bsi++ {
 			// temp storage so we don't persist data we dont want to
 			bs := bstore.NewMemory()
@@ -1007,7 +1031,15 @@ func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipS
 				return xerrors.Errorf("message processing failed: %w", err)
 			}
 
-			if err := cb(ctx, fts); err != nil {
+			fullTipsets[bsi], msgstores[bsi] = fts, bs
+			ahead(fts)
+		}
+
+		for bsi := 0; bsi < len(bstout); bsi++ {
+			bs := msgstores[bsi]
+			bstip := bstout[len(bstout)-(bsi+1)]
+
+			if err := cb(ctx, fullTipsets[bsi]); err != nil {
 				return err
 			}
 
@@ -1055,77 +1087,87 @@ func checkMsgMeta(ts *types.TipSet, allbmsgs []*types.Message, allsmsgs []*types
 	return nil
 }
 
+// fetchMessages fetches the messages of the headers in stripes of syncRequestBatchSize tipsets,
+// requested concurrently; the exchange client spreads concurrent requests over the peers by their
+// throughput. So that a slow peer doesn't stall the batch, the stripes still pending
+// syncStripeHedgeFactor times longer than the completed ones took are requested again, and the
+// first complete response for each stripe is used.
 func (syncer *Syncer) fetchMessages(ctx context.Context, headers []*types.TipSet, startOffset int) ([]*exchange.CompactedMessages, error) {
 	batchSize := len(headers)
 	batch := make([]*exchange.CompactedMessages, batchSize)
 
-	var wg sync.WaitGroup
-	var mx sync.Mutex
-	var batchErr error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type stripeResult struct {
+		j    int
+		msgs []*exchange.CompactedMessages
+		err  error
+	}
+
+	stripes := (batchSize + syncRequestBatchSize - 1) / syncRequestBatchSize
+	// each stripe is requested at most twice, so the fetchers never block on sending their result
+	results := make(chan stripeResult, 2*stripes)
+	fetch := func(j int) {
+		nreq := syncRequestBatchSize
+		if j+nreq > batchSize {
+			nreq = batchSize - j
+		}
+
+		go func() {
+			msgs, err := syncer.fetchStripe(ctx, headers[j:j+nreq], startOffset+j)
+			results <- stripeResult{j: j, msgs: msgs, err: err}
+		}()
+	}
 
 	start := build.Clock.Now()
 
+	pending := make(map[int]int, stripes)
 	for j := 0; j < batchSize; j += syncRequestBatchSize {
-		wg.Add(1)
-		go func(j int) {
-			defer wg.Done()
-
-			nreq := syncRequestBatchSize
-			if j+nreq > batchSize {
-				nreq = batchSize - j
-			}
+		pending[j]++
+		fetch(j)
+	}
 
-			failed := false
-			for offset := 0; !failed && offset < nreq; {
-				nextI := j + offset
-				lastI := j + nreq
-
-				var requestErr error
-				var requestResult []*exchange.CompactedMessages
-				for retry := 0; requestResult == nil && retry < syncRequestRetries; retry++ {
-					if retry > 0 {
-						log.Infof("fetching messages at %d (retry %d)", startOffset+nextI, retry)
-					} else {
-						log.Infof("fetching messages at %d", startOffset+nextI)
-					}
+	hedgeTicker := build.Clock.Ticker(time.Second)
+	defer hedgeTicker.Stop()
 
-					result, err := syncer.Exchange.GetChainMessages(ctx, headers[nextI:lastI])
-					if err != nil {
-						requestErr = multierror.Append(requestErr, err)
-					} else {
-						isGood := true
-						for index, ts := range headers[nextI:lastI] {
-							cm := result[index]
-							if err := checkMsgMeta(ts, cm.Bls, cm.Secpk, cm.BlsIncludes, cm.SecpkIncludes); err != nil {
-								log.Errorf("fetched messages not as expected: %s", err)
-								isGood = false
-								break
-							}
-						}
-
-						if isGood {
-							requestResult = result
-						}
-					}
+	var slowest time.Duration
+	hedged := make(map[int]bool)
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			if _, ok := pending[r.j]; !ok {
+				continue // the other request for the stripe already completed
+			}
+			if r.err != nil {
+				if pending[r.j]--; pending[r.j] > 0 {
+					continue // the hedged request may still succeed
 				}
+				log.Errorf("error fetching messages at %d: %s", startOffset+r.j, r.err)
+				return nil, r.err
+			}
 
-				mx.Lock()
-				if requestResult != nil {
-					copy(batch[j+offset:], requestResult)
-					offset += len(requestResult)
-				} else {
-					log.Errorf("error fetching messages at %d: %s", nextI, requestErr)
-					batchErr = multierror.Append(batchErr, requestErr)
-					failed = true
+			copy(batch[r.j:], r.msgs)
+			delete(pending, r.j)
+			if took := build.Clock.Since(start); took > slowest {
+				slowest = took
+			}
+		case <-hedgeTicker.C:
+			if slowest == 0 || build.Clock.Since(start) < time.Duration(syncStripeHedgeFactor)*slowest {
+				continue
+			}
+			for j := range pending {
+				if hedged[j] {
+					continue
 				}
-				mx.Unlock()
+				log.Infof("fetching messages at %d is slow, requesting them again", startOffset+j)
+				hedged[j] = true
+				pending[j]++
+				fetch(j)
 			}
-		}(j)
-	}
-	wg.Wait()
-
-	if batchErr != nil {
-		return nil, batchErr
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
 
 	log.Infof("fetching messages for %d tipsets at %d done; took %s", batchSize, startOffset, build.Clock.Since(start))
@@ -1133,6 +1175,50 @@ func (syncer *Syncer) fetchMessages(ctx context.Context, headers []*types.TipSet
 	return batch, nil
 }
 
+// fetchStripe fetches the messages of a stripe of headers, retrying failed requests.
+func (syncer *Syncer) fetchStripe(ctx context.Context, headers []*types.TipSet, offset int) ([]*exchange.CompactedMessages, error) {
+	out := make([]*exchange.CompactedMessages, 0, len(headers))
+	for len(out) < len(headers) {
+		nextI := len(out)
+
+		var requestErr error
+		var requestResult []*exchange.CompactedMessages
+		for retry := 0; requestResult == nil && retry < syncRequestRetries; retry++ {
+			if retry > 0 {
+				log.Infof("fetching messages at %d (retry %d)", offset+nextI, retry)
+			} else {
+				log.Infof("fetching messages at %d", offset+nextI)
+			}
+
+			result, err := syncer.Exchange.GetChainMessages(ctx, headers[nextI:])
+			if err != nil {
+				requestErr = multierror.Append(requestErr, err)
+				continue
+			}
+
+			isGood := true
+			for index, ts := range headers[nextI : nextI+len(result)] {
+				cm := result[index]
+				if err := checkMsgMeta(ts, cm.Bls, cm.Secpk, cm.BlsIncludes, cm.SecpkIncludes); err != nil {
+					log.Errorf("fetched messages not as expected: %s", err)
+					isGood = false
+					break
+				}
+			}
+
+			if isGood {
+				requestResult = result
+			}
+		}
+
+		if requestResult == nil {
+			return nil, requestErr
+		}
+		out = append(out, requestResult...)
+	}
+	return out, nil
+}
+
 func persistMessages(ctx context.Context, bs bstore.Blockstore, bst *exchange.CompactedMessages) error {
 	_, span := trace.StartSpan(ctx, "persistMessages")
 	defer span.End()
@@ -1196,6 +1282,12 @@ func (syncer *Syncer) collectChain(ctx context.Context, ts *types.TipSet, hts *t
 		return xerrors.Errorf("collectChain synced %s, wanted to sync %s", headers[0].Cids(), ts.Cids())
 	}
 
+	if err := syncer.store.CheckTrustedCheckpoints(headers); err != nil {
+		err = xerrors.Errorf("collectChain: %w", err)
+		ss.Error(err)
+		return err
+	}
+
 	ss.SetStage(api.StagePersistHeaders)
 
 	// Write tipsets from oldest to newest.
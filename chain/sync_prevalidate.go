@@ -0,0 +1,161 @@
+package chain
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Tipsets have to be executed in order, but most of the checks of their blocks (syntax, message
+// signatures) don't depend on the parent state. During catch-up sync, the tipsets of a batch of
+// fetched messages are prevalidated by a pool of workers while earlier tipsets are executed, which
+// rejects invalid blocks before executing their ancestors and warms the verified signature cache
+// used by the serial validation.
+var (
+	PrevalidationWorkers    = runtime.NumCPU()
+	EnvPrevalidationWorkers = "LOTUS_SYNC_PREVALIDATION_WORKERS"
+)
+
+func init() {
+	if pws := os.Getenv(EnvPrevalidationWorkers); pws != "" {
+		pw, err := strconv.ParseInt(pws, 10, 32)
+		if err != nil {
+			log.Warnf("invalid value for %s (%s), using %d workers: %s", EnvPrevalidationWorkers, pws, PrevalidationWorkers, err)
+			return
+		}
+		PrevalidationWorkers = int(pw)
+	}
+}
+
+type prevalidator struct {
+	syncer *Syncer
+	pv     consensus.BlockPrevalidator
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	work   chan *store.FullTipSet
+	wg     sync.WaitGroup
+
+	lk      sync.Mutex
+	results map[types.TipSetKey]chan error
+}
+
+// startPrevalidation starts the prevalidation workers. It returns nil, on which all the methods of
+// prevalidator are no-ops, if prevalidation is disabled or not supported by the consensus.
+func (syncer *Syncer) startPrevalidation(ctx context.Context) *prevalidator {
+	pv, ok := syncer.consensus.(consensus.BlockPrevalidator)
+	if !ok || PrevalidationWorkers <= 0 {
+		return nil
+	}
+
+	p := &prevalidator{
+		syncer:  syncer,
+		pv:      pv,
+		work:    make(chan *store.FullTipSet, concurrentSyncRequests*syncRequestBatchSize),
+		results: make(map[types.TipSetKey]chan error),
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	for i := 0; i < PrevalidationWorkers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for fts := range p.work {
+				p.resultOf(fts.TipSet().Key()) <- p.prevalidate(fts)
+			}
+		}()
+	}
+	return p
+}
+
+// submit queues a tipset for prevalidation, in the order of execution.
+func (p *prevalidator) submit(fts *store.FullTipSet) {
+	if p == nil {
+		return
+	}
+
+	p.resultOf(fts.TipSet().Key())
+	p.work <- fts
+}
+
+// wait returns the result of the prevalidation of a tipset, or nil if it wasn't submitted.
+func (p *prevalidator) wait(ctx context.Context, tsk types.TipSetKey) error {
+	if p == nil {
+		return nil
+	}
+
+	p.lk.Lock()
+	res, ok := p.results[tsk]
+	delete(p.results, tsk)
+	p.lk.Unlock()
+	if !ok {
+		return nil
+	}
+
+	select {
+	case err := <-res:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops the workers and waits for them to exit.
+func (p *prevalidator) close() {
+	if p == nil {
+		return
+	}
+
+	p.cancel()
+	close(p.work)
+	p.wg.Wait()
+}
+
+func (p *prevalidator) resultOf(tsk types.TipSetKey) chan error {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	res, ok := p.results[tsk]
+	if !ok {
+		res = make(chan error, 1)
+		p.results[tsk] = res
+	}
+	return res
+}
+
+func (p *prevalidator) prevalidate(fts *store.FullTipSet) error {
+	if fts.TipSet().Equals(p.syncer.Genesis) {
+		return nil
+	}
+
+	for _, b := range fts.Blocks {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+
+		validated, err := p.syncer.store.IsBlockValidated(p.ctx, b.Cid())
+		if err != nil {
+			return xerrors.Errorf("check block validation cache %s: %w", b.Cid(), err)
+		}
+		if validated {
+			continue
+		}
+
+		if err := p.pv.PrevalidateBlock(p.ctx, b); err != nil {
+			if p.ctx.Err() == nil && isPermanent(err) {
+				p.syncer.bad.Add(b.Cid(), NewBadBlockReason([]cid.Cid{b.Cid()}, err.Error()))
+			}
+			return xerrors.Errorf("prevalidating block %s: %w", b.Cid(), err)
+		}
+	}
+	return nil
+}
@@ -11,6 +11,10 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
+// syncRateWindow is the window over which the sync rate, and the ETA derived from it, are
+// computed.
+const syncRateWindow = 2 * time.Minute
+
 type SyncerStateSnapshot struct {
 	WorkerID uint64
 	Target   *types.TipSet
@@ -20,11 +24,26 @@ type SyncerStateSnapshot struct {
 	Message  string
 	Start    time.Time
 	End      time.Time
+
+	// EpochsPerSec is the rate at which tipsets were executed over the last syncRateWindow, and
+	// ETA the time to reach the target at that rate.
+	EpochsPerSec float64
+	ETA          time.Duration
+	// ValidationTime and ExecutionTime are the total time spent validating the executed tipsets,
+	// and computing their parent states.
+	ValidationTime time.Duration
+	ExecutionTime  time.Duration
+}
+
+type heightSample struct {
+	at     time.Time
+	height abi.ChainEpoch
 }
 
 type SyncerState struct {
-	lk   sync.Mutex
-	data SyncerStateSnapshot
+	lk      sync.Mutex
+	data    SyncerStateSnapshot
+	samples []heightSample
 }
 
 func (ss *SyncerState) SetStage(v api.SyncStateStage) {
@@ -54,6 +73,9 @@ func (ss *SyncerState) Init(base, target *types.TipSet) {
 	ss.data.Message = ""
 	ss.data.Start = build.Clock.Now()
 	ss.data.End = time.Time{}
+	ss.data.ValidationTime = 0
+	ss.data.ExecutionTime = 0
+	ss.samples = nil
 }
 
 func (ss *SyncerState) SetHeight(h abi.ChainEpoch) {
@@ -66,6 +88,30 @@ func (ss *SyncerState) SetHeight(h abi.ChainEpoch) {
 	ss.data.Height = h
 }
 
+// TipSetValidated records the validation of the tipset at height h, which took validation, after
+// its parent state was computed in execution.
+func (ss *SyncerState) TipSetValidated(h abi.ChainEpoch, validation, execution time.Duration) {
+	if ss == nil {
+		return
+	}
+
+	ss.lk.Lock()
+	defer ss.lk.Unlock()
+	ss.data.Height = h
+	ss.data.ValidationTime += validation
+	ss.data.ExecutionTime += execution
+
+	now := build.Clock.Now()
+	ss.samples = append(ss.samples, heightSample{at: now, height: h})
+
+	// keep the last sample before the window, so that the rate spans the whole window
+	var drop int
+	for drop < len(ss.samples)-2 && now.Sub(ss.samples[drop+1].at) > syncRateWindow {
+		drop++
+	}
+	ss.samples = ss.samples[drop:]
+}
+
 func (ss *SyncerState) Error(err error) {
 	if ss == nil {
 		return
@@ -81,5 +127,16 @@ func (ss *SyncerState) Error(err error) {
 func (ss *SyncerState) Snapshot() SyncerStateSnapshot {
 	ss.lk.Lock()
 	defer ss.lk.Unlock()
-	return ss.data
+
+	out := ss.data
+	if len(ss.samples) > 1 && out.End.IsZero() {
+		first, last := ss.samples[0], ss.samples[len(ss.samples)-1]
+		if dt := last.at.Sub(first.at); dt > 0 && last.height > first.height {
+			out.EpochsPerSec = float64(last.height-first.height) / dt.Seconds()
+			if out.Target != nil && out.Target.Height() > last.height {
+				out.ETA = time.Duration(float64(out.Target.Height()-last.height) / out.EpochsPerSec * float64(time.Second))
+			}
+		}
+	}
+	return out
 }
@@ -13,6 +13,7 @@ type MpoolConfig struct {
 	ReplaceByFeeRatio      Percent
 	PruneCooldown          time.Duration
 	GasLimitOverestimation float64
+	PersistLocalMessages   bool
 }
 
 func (mc *MpoolConfig) Clone() *MpoolConfig {
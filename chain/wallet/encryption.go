@@ -0,0 +1,495 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raulk/clock"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+)
+
+const (
+	// KEncryption is the keystore name of the encryption params of the wallet.
+	KEncryption = "keystore-encryption"
+	// KTEncryption is the key type the encryption params are stored with.
+	KTEncryption types.KeyType = "keystore-encryption"
+	// KTEncrypted is the key type of the encrypted keys, whose PrivateKey is the json of the
+	// encrypted key info.
+	KTEncrypted types.KeyType = "encrypted"
+
+	// kEncryptingPrefix prefixes the encrypted copies of the keys being encrypted.
+	kEncryptingPrefix = "encrypting-"
+)
+
+var (
+	ErrWalletLocked       = xerrors.New("wallet is locked")
+	ErrWalletNotEncrypted = xerrors.New("wallet keystore isn't encrypted")
+	ErrWrongPassphrase    = xerrors.New("wrong passphrase")
+)
+
+// scryptN is the scrypt cost of new encryption params, with r = 8 and p = 1 it takes 256MiB.
+var scryptN = 1 << 18
+
+// encryptionCheck is encrypted in the params, to tell wrong passphrases.
+var encryptionCheck = []byte("lotus wallet encryption")
+
+type encryptionParams struct {
+	Salt    []byte
+	N, R, P int
+	// Check is encryptionCheck, encrypted.
+	Check []byte
+}
+
+type encryptedKey struct {
+	// Address is the address of the key, in clear so that it's known while the wallet is
+	// locked; empty for keys which aren't signing keys.
+	Address string `json:",omitempty"`
+	Nonce   []byte
+	Data    []byte
+}
+
+// encryptedKeyStore encrypts the keys put into a keystore once it has encryption params, and
+// decrypts the encrypted keys while unlocked.
+type encryptedKeyStore struct {
+	types.KeyStore
+
+	lk     sync.Mutex
+	aead   cipher.AEAD
+	timer  *clock.Timer
+	lockAt time.Time
+}
+
+func (ks *encryptedKeyStore) params() (*encryptionParams, error) {
+	ki, err := ks.KeyStore.Get(KEncryption)
+	if xerrors.Is(err, types.ErrKeyInfoNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting encryption params: %w", err)
+	}
+
+	var p encryptionParams
+	if err := json.Unmarshal(ki.PrivateKey, &p); err != nil {
+		return nil, xerrors.Errorf("decoding encryption params: %w", err)
+	}
+	return &p, nil
+}
+
+func newAEAD(passphrase string, p *encryptionParams) (cipher.AEAD, error) {
+	k, err := scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, 32)
+	if err != nil {
+		return nil, xerrors.Errorf("deriving key: %w", err)
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext under the keystore name it's stored with, so that it can't be opened
+// once moved to another name.
+func seal(aead cipher.AEAD, name string, plaintext []byte) ([]byte, []byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, []byte(name)), nil
+}
+
+func (ks *encryptedKeyStore) encrypt(aead cipher.AEAD, name string, ki types.KeyInfo) (types.KeyInfo, error) {
+	var ek encryptedKey
+	if k, err := key.NewKey(ki); err == nil {
+		ek.Address = k.Address.String()
+	}
+
+	plaintext, err := json.Marshal(ki)
+	if err != nil {
+		return types.KeyInfo{}, err
+	}
+	if ek.Nonce, ek.Data, err = seal(aead, name, plaintext); err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("encrypting key: %w", err)
+	}
+
+	b, err := json.Marshal(&ek)
+	if err != nil {
+		return types.KeyInfo{}, err
+	}
+	return types.KeyInfo{Type: KTEncrypted, PrivateKey: b}, nil
+}
+
+func (ks *encryptedKeyStore) Get(name string) (types.KeyInfo, error) {
+	ki, err := ks.KeyStore.Get(name)
+	if err != nil || ki.Type != KTEncrypted {
+		return ki, err
+	}
+
+	var ek encryptedKey
+	if err := json.Unmarshal(ki.PrivateKey, &ek); err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("decoding encrypted key '%s': %w", name, err)
+	}
+
+	ks.lk.Lock()
+	aead := ks.aead
+	ks.lk.Unlock()
+	if aead == nil {
+		return types.KeyInfo{}, xerrors.Errorf("getting key '%s': %w", name, ErrWalletLocked)
+	}
+
+	plaintext, err := aead.Open(nil, ek.Nonce, ek.Data, []byte(name))
+	if err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("decrypting key '%s': %w", name, err)
+	}
+
+	var out types.KeyInfo
+	if err := json.Unmarshal(plaintext, &out); err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("decoding decrypted key '%s': %w", name, err)
+	}
+	return out, nil
+}
+
+func (ks *encryptedKeyStore) Put(name string, ki types.KeyInfo) error {
+	p, err := ks.params()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ks.KeyStore.Put(name, ki)
+	}
+
+	ks.lk.Lock()
+	aead := ks.aead
+	ks.lk.Unlock()
+	if aead == nil {
+		return xerrors.Errorf("putting key '%s': %w", name, ErrWalletLocked)
+	}
+
+	eki, err := ks.encrypt(aead, name, ki)
+	if err != nil {
+		return err
+	}
+	return ks.KeyStore.Put(name, eki)
+}
+
+// address returns the address of a key without decrypting it.
+func (ks *encryptedKeyStore) address(name string) (address.Address, error) {
+	ki, err := ks.KeyStore.Get(name)
+	if err != nil {
+		return address.Undef, err
+	}
+	if ki.Type != KTEncrypted {
+		k, err := key.NewKey(ki)
+		if err != nil {
+			return address.Undef, err
+		}
+		return k.Address, nil
+	}
+
+	var ek encryptedKey
+	if err := json.Unmarshal(ki.PrivateKey, &ek); err != nil {
+		return address.Undef, xerrors.Errorf("decoding encrypted key '%s': %w", name, err)
+	}
+	return address.NewFromString(ek.Address)
+}
+
+// unlock sets the cipher keys are decrypted with, until lock is called or the timeout passes
+// when positive.
+func (ks *encryptedKeyStore) unlock(aead cipher.AEAD, timeout time.Duration, lock func()) {
+	ks.lk.Lock()
+	defer ks.lk.Unlock()
+
+	ks.aead = aead
+	if ks.timer != nil {
+		ks.timer.Stop()
+		ks.timer = nil
+	}
+	ks.lockAt = time.Time{}
+	if timeout > 0 {
+		ks.timer = build.Clock.AfterFunc(timeout, lock)
+		ks.lockAt = build.Clock.Now().Add(timeout)
+	}
+}
+
+func (ks *encryptedKeyStore) lock() {
+	ks.lk.Lock()
+	defer ks.lk.Unlock()
+
+	ks.aead = nil
+	if ks.timer != nil {
+		ks.timer.Stop()
+		ks.timer = nil
+	}
+	ks.lockAt = time.Time{}
+}
+
+// isWalletKey tells the keystore names of the wallet, which are encrypted, from the ones of the
+// node sharing the keystore.
+func isWalletKey(name string) bool {
+	return strings.HasPrefix(name, KNamePrefix) || strings.HasPrefix(name, KTrashPrefix) || name == KDefault || name == KHDSeed
+}
+
+func (w *LocalWallet) encryptedKeyStore() (*encryptedKeyStore, error) {
+	if w.enc == nil {
+		return nil, xerrors.Errorf("the wallet has no keystore")
+	}
+	return w.enc, nil
+}
+
+func (w *LocalWallet) WalletEncrypt(ctx context.Context, passphrase string) error {
+	if passphrase == "" {
+		return xerrors.Errorf("empty passphrase")
+	}
+
+	ks, err := w.encryptedKeyStore()
+	if err != nil {
+		return err
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	p, err := ks.params()
+	if err != nil {
+		return err
+	}
+
+	var aead cipher.AEAD
+	if p != nil {
+		// resume an interrupted encryption
+		if aead, err = checkPassphrase(passphrase, p); err != nil {
+			return err
+		}
+	} else {
+		p = &encryptionParams{Salt: make([]byte, 32), N: scryptN, R: 8, P: 1}
+		if _, err := rand.Read(p.Salt); err != nil {
+			return err
+		}
+		if aead, err = newAEAD(passphrase, p); err != nil {
+			return err
+		}
+		nonce, check, err := seal(aead, KEncryption, encryptionCheck)
+		if err != nil {
+			return err
+		}
+		p.Check = append(nonce, check...)
+
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		if err := ks.KeyStore.Put(KEncryption, types.KeyInfo{Type: KTEncryption, PrivateKey: b}); err != nil {
+			return xerrors.Errorf("saving encryption params: %w", err)
+		}
+	}
+
+	names, err := ks.KeyStore.List()
+	if err != nil {
+		return xerrors.Errorf("listing keystore: %w", err)
+	}
+
+	// finish replacing the keys which were encrypted when interrupted
+	for _, name := range names {
+		if !strings.HasPrefix(name, kEncryptingPrefix) {
+			continue
+		}
+		orig := strings.TrimPrefix(name, kEncryptingPrefix)
+		if err := w.replaceKey(ks, orig, name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		if !isWalletKey(name) {
+			continue
+		}
+
+		ki, err := ks.KeyStore.Get(name)
+		if err != nil {
+			return xerrors.Errorf("getting key '%s': %w", name, err)
+		}
+		if ki.Type == KTEncrypted {
+			continue
+		}
+
+		// the copy is encrypted under the name of the key it replaces
+		eki, err := ks.encrypt(aead, name, ki)
+		if err != nil {
+			return err
+		}
+		// the encrypted copy is put first, so that an interruption doesn't lose the key
+		if err := ks.KeyStore.Put(kEncryptingPrefix+name, eki); err != nil {
+			return xerrors.Errorf("saving encrypted key '%s': %w", name, err)
+		}
+		if err := w.replaceKey(ks, name, kEncryptingPrefix+name); err != nil {
+			return err
+		}
+	}
+
+	ks.unlock(aead, 0, nil)
+	return nil
+}
+
+// replaceKey replaces a key with its encrypted copy, and deletes the copy.
+func (w *LocalWallet) replaceKey(ks *encryptedKeyStore, name, encrypted string) error {
+	eki, err := ks.KeyStore.Get(encrypted)
+	if err != nil {
+		return xerrors.Errorf("getting encrypted key '%s': %w", name, err)
+	}
+
+	if err := ks.KeyStore.Delete(name); err != nil && !xerrors.Is(err, types.ErrKeyInfoNotFound) {
+		return xerrors.Errorf("deleting unencrypted key '%s': %w", name, err)
+	}
+	if err := ks.KeyStore.Put(name, eki); err != nil {
+		return xerrors.Errorf("saving encrypted key '%s': %w", name, err)
+	}
+	if err := ks.KeyStore.Delete(encrypted); err != nil {
+		return xerrors.Errorf("deleting encrypted copy of key '%s': %w", name, err)
+	}
+	return nil
+}
+
+func checkPassphrase(passphrase string, p *encryptionParams) (cipher.AEAD, error) {
+	aead, err := newAEAD(passphrase, p)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := aead.NonceSize()
+	if len(p.Check) < ns {
+		return nil, xerrors.Errorf("invalid encryption params")
+	}
+	check, err := aead.Open(nil, p.Check[:ns], p.Check[ns:], []byte(KEncryption))
+	if err != nil || !bytes.Equal(check, encryptionCheck) {
+		return nil, ErrWrongPassphrase
+	}
+	return aead, nil
+}
+
+func (w *LocalWallet) WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error {
+	ks, err := w.encryptedKeyStore()
+	if err != nil {
+		return err
+	}
+
+	p, err := ks.params()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ErrWalletNotEncrypted
+	}
+
+	aead, err := checkPassphrase(passphrase, p)
+	if err != nil {
+		return err
+	}
+
+	ks.unlock(aead, timeout, func() {
+		log.Info("locking wallet after the unlock timeout")
+		if err := w.WalletLock(context.Background()); err != nil {
+			log.Errorf("locking wallet: %s", err)
+		}
+	})
+	return nil
+}
+
+func (w *LocalWallet) WalletLock(ctx context.Context) error {
+	ks, err := w.encryptedKeyStore()
+	if err != nil {
+		return err
+	}
+
+	p, err := ks.params()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ErrWalletNotEncrypted
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	ks.lock()
+	// forget the keys decrypted while unlocked
+	w.keys = make(map[address.Address]*key.Key)
+	return nil
+}
+
+func (w *LocalWallet) WalletLockStatus(ctx context.Context) (*api.LockStatus, error) {
+	ks, err := w.encryptedKeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := ks.params()
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return &api.LockStatus{}, nil
+	}
+
+	ks.lk.Lock()
+	defer ks.lk.Unlock()
+	return &api.LockStatus{
+		Encrypted: true,
+		Locked:    ks.aead == nil,
+		LockAt:    ks.lockAt,
+	}, nil
+}
+
+func (m MultiWallet) WalletEncrypt(ctx context.Context, passphrase string) error {
+	l, err := m.lockable()
+	if err != nil {
+		return err
+	}
+	return l.WalletEncrypt(ctx, passphrase)
+}
+
+func (m MultiWallet) WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error {
+	l, err := m.lockable()
+	if err != nil {
+		return err
+	}
+	return l.WalletUnlock(ctx, passphrase, timeout)
+}
+
+func (m MultiWallet) WalletLock(ctx context.Context) error {
+	l, err := m.lockable()
+	if err != nil {
+		return err
+	}
+	return l.WalletLock(ctx)
+}
+
+func (m MultiWallet) WalletLockStatus(ctx context.Context) (*api.LockStatus, error) {
+	l, err := m.lockable()
+	if err != nil {
+		return nil, err
+	}
+	return l.WalletLockStatus(ctx)
+}
+
+func (m MultiWallet) lockable() (api.Wallet, error) {
+	w := firstNonNil(m.Remote, m.Local)
+	if w == nil {
+		return nil, xerrors.Errorf("no wallet backends supporting keystore encryption")
+	}
+	return w, nil
+}
@@ -0,0 +1,133 @@
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	crypto "github.com/filecoin-project/go-crypto"
+)
+
+// HardenedOffset is added to the index of hardened children.
+const HardenedOffset uint32 = 0x80000000
+
+// FilecoinCoinType is the SLIP-44 coin type of Filecoin.
+const FilecoinCoinType = 461
+
+// secp256k1N is the order of the secp256k1 curve.
+var secp256k1N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+
+// FilecoinPath returns the BIP44 derivation path of an address of an account:
+// m/44'/461'/account'/0/index.
+func FilecoinPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/0/%d", FilecoinCoinType, account, index)
+}
+
+// ParsePath parses a derivation path like m/44'/461'/0'/0/0 into child indexes.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, xerrors.Errorf("derivation path %q doesn't start with m", path)
+	}
+
+	out := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		var hardened uint32
+		if strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h") {
+			hardened = HardenedOffset
+			p = p[:len(p)-1]
+		}
+
+		i, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || uint32(i) >= HardenedOffset {
+			return nil, xerrors.Errorf("invalid index %q in derivation path %q", p, path)
+		}
+		out = append(out, uint32(i)+hardened)
+	}
+	return out, nil
+}
+
+// ExtendedKey is a BIP32 extended private key.
+type ExtendedKey struct {
+	Key       []byte
+	ChainCode []byte
+}
+
+// NewMasterKey returns the master key of a seed.
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, xerrors.Errorf("seed length %d isn't between 16 and 64 bytes", len(seed))
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	_, _ = mac.Write(seed)
+	I := mac.Sum(nil)
+
+	if k := new(big.Int).SetBytes(I[:32]); k.Sign() == 0 || k.Cmp(secp256k1N) >= 0 {
+		return nil, xerrors.Errorf("seed yields an invalid master key")
+	}
+	return &ExtendedKey{Key: I[:32], ChainCode: I[32:]}, nil
+}
+
+// Child derives the child key at an index, hardened when the index is at least HardenedOffset.
+func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
+	var data []byte
+	if i >= HardenedOffset {
+		data = append([]byte{0}, k.Key...)
+	} else {
+		data = compressPublicKey(crypto.PublicKey(k.Key))
+	}
+	data = binary.BigEndian.AppendUint32(data, i)
+
+	mac := hmac.New(sha512.New, k.ChainCode)
+	_, _ = mac.Write(data)
+	I := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(I[:32])
+	if il.Cmp(secp256k1N) >= 0 {
+		// the next index must be used instead, as BIP32 specifies; this has a probability
+		// lower than 1 in 2^127
+		return nil, xerrors.Errorf("index %d yields an invalid child key", i)
+	}
+
+	child := il.Add(il, new(big.Int).SetBytes(k.Key))
+	child.Mod(child, secp256k1N)
+	if child.Sign() == 0 {
+		return nil, xerrors.Errorf("index %d yields an invalid child key", i)
+	}
+
+	return &ExtendedKey{Key: child.FillBytes(make([]byte, 32)), ChainCode: I[32:]}, nil
+}
+
+// DeriveKey derives the secp256k1 private key at a derivation path from a seed.
+func DeriveKey(seed []byte, path string) ([]byte, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range indexes {
+		if k, err = k.Child(i); err != nil {
+			return nil, xerrors.Errorf("deriving %s: %w", path, err)
+		}
+	}
+	return k.Key, nil
+}
+
+// compressPublicKey converts an uncompressed secp256k1 public key to its compressed form.
+func compressPublicKey(pub []byte) []byte {
+	out := make([]byte, 33)
+	out[0] = 2 + pub[64]&1
+	copy(out[1:], pub[1:33])
+	return out
+}
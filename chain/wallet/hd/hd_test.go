@@ -0,0 +1,94 @@
+package hd
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMnemonic(t *testing.T) {
+	require.Len(t, wordlist, 2048)
+
+	// BIP39 test vectors
+	for _, v := range []struct {
+		entropy, mnemonic, seed string
+	}{
+		{
+			"00000000000000000000000000000000",
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+	} {
+		entropy, err := hex.DecodeString(v.entropy)
+		require.NoError(t, err)
+
+		m, err := EntropyToMnemonic(entropy)
+		require.NoError(t, err)
+		require.Equal(t, v.mnemonic, m)
+
+		back, err := MnemonicToEntropy(m)
+		require.NoError(t, err)
+		require.Equal(t, entropy, back)
+
+		seed, err := MnemonicToSeed(m, "TREZOR")
+		require.NoError(t, err)
+		require.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+
+	entropy, err := hex.DecodeString(strings.Repeat("ff", 32))
+	require.NoError(t, err)
+	m, err := EntropyToMnemonic(entropy)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("zoo ", 23)+"vote", m)
+
+	for _, words := range []int{12, 15, 18, 21, 24} {
+		m, err := NewMnemonic(words)
+		require.NoError(t, err)
+		require.Len(t, strings.Fields(m), words)
+		require.NoError(t, ValidateMnemonic(m))
+	}
+	_, err = NewMnemonic(13)
+	require.Error(t, err)
+
+	require.ErrorIs(t, ValidateMnemonic(strings.Repeat("abandon ", 12)), ErrInvalidMnemonic)
+	require.ErrorIs(t, ValidateMnemonic("abandon about"), ErrInvalidMnemonic)
+	require.ErrorIs(t, ValidateMnemonic(strings.Repeat("abandon ", 11)+"notaword"), ErrInvalidMnemonic)
+}
+
+func TestDeriveKey(t *testing.T) {
+	// BIP32 test vector 1
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+
+	k, err := DeriveKey(seed, "m")
+	require.NoError(t, err)
+	require.Equal(t, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", hex.EncodeToString(k))
+
+	k, err = DeriveKey(seed, "m/0'/1/2'/2/1000000000")
+	require.NoError(t, err)
+	require.Equal(t, "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8", hex.EncodeToString(k))
+
+	seed, err = MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	require.NoError(t, err)
+
+	require.Equal(t, "m/44'/461'/0'/0/1", FilecoinPath(0, 1))
+	k, err = DeriveKey(seed, FilecoinPath(0, 1))
+	require.NoError(t, err)
+	require.Equal(t, "ff91cfecbd459ca53112e15c6dd9b26cf4422bb5935c5616d5a6cad95ab0253b", hex.EncodeToString(k))
+
+	indexes, err := ParsePath("m/44h/461'/0'/0/7")
+	require.NoError(t, err)
+	require.Equal(t, []uint32{44 + HardenedOffset, 461 + HardenedOffset, HardenedOffset, 0, 7}, indexes)
+
+	for _, p := range []string{"", "44'/461'", "m/x", "m/2147483648", "m//0"} {
+		_, err := ParsePath(p)
+		require.Error(t, err, p)
+	}
+}
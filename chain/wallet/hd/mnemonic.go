@@ -0,0 +1,124 @@
+// Package hd implements BIP39 mnemonics and BIP32 hierarchical deterministic derivation of
+// secp256k1 keys, so that the addresses of a wallet can be restored from a seed phrase.
+package hd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/xerrors"
+)
+
+// english.txt is the BIP39 English wordlist.
+//
+//go:embed english.txt
+var englishTxt string
+
+var (
+	wordlist  = strings.Fields(englishTxt)
+	wordIndex = func() map[string]int64 {
+		m := make(map[string]int64, len(wordlist))
+		for i, w := range wordlist {
+			m[w] = int64(i)
+		}
+		return m
+	}()
+)
+
+// ErrInvalidMnemonic is returned for mnemonics with an unknown word, a wrong number of words or a
+// wrong checksum.
+var ErrInvalidMnemonic = xerrors.New("invalid mnemonic")
+
+// NewMnemonic generates a mnemonic of 12, 15, 18, 21 or 24 words.
+func NewMnemonic(words int) (string, error) {
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", xerrors.Errorf("number of words %d isn't 12, 15, 18, 21 or 24", words)
+	}
+
+	entropy := make([]byte, words*4/3)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", xerrors.Errorf("generating entropy: %w", err)
+	}
+
+	return EntropyToMnemonic(entropy)
+}
+
+// EntropyToMnemonic encodes 16 to 32 bytes of entropy, in steps of 4 bytes, as a mnemonic.
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", xerrors.Errorf("entropy length %d isn't between 16 and 32 bytes in steps of 4", len(entropy))
+	}
+
+	// the entropy is followed by the first bits of its hash, one per 32 bits of entropy
+	csBits := uint(len(entropy) / 4)
+	h := sha256.Sum256(entropy)
+
+	b := new(big.Int).SetBytes(entropy)
+	b.Lsh(b, csBits)
+	b.Or(b, big.NewInt(int64(h[0]>>(8-csBits))))
+
+	words := make([]string, (uint(len(entropy))*8+csBits)/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = wordlist[new(big.Int).And(b, mask).Int64()]
+		b.Rsh(b, 11)
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a mnemonic back to its entropy, verifying its checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, xerrors.Errorf("%w: %d words", ErrInvalidMnemonic, len(words))
+	}
+
+	b := new(big.Int)
+	for _, w := range words {
+		i, ok := wordIndex[w]
+		if !ok {
+			return nil, xerrors.Errorf("%w: unknown word %q", ErrInvalidMnemonic, w)
+		}
+		b.Lsh(b, 11)
+		b.Or(b, big.NewInt(i))
+	}
+
+	csBits := uint(len(words) / 3)
+	cs := new(big.Int).And(b, big.NewInt(1<<csBits-1)).Int64()
+	b.Rsh(b, csBits)
+
+	entropy := make([]byte, len(words)*4/3)
+	b.FillBytes(entropy)
+
+	h := sha256.Sum256(entropy)
+	if int64(h[0]>>(8-csBits)) != cs {
+		return nil, xerrors.Errorf("%w: wrong checksum", ErrInvalidMnemonic)
+	}
+
+	return entropy, nil
+}
+
+// ValidateMnemonic checks the words and the checksum of a mnemonic.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed validates a mnemonic and derives the 64 bytes seed of its keys, protected by an
+// optional passphrase.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	m := strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(m), []byte(salt), 2048, 64, sha512.New), nil
+}
@@ -0,0 +1,198 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/hd"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+)
+
+const (
+	// KHDSeed is the keystore name of the HD seed of the wallet.
+	KHDSeed = "hd-seed"
+	// KTHDSeed is the key type the HD seed is stored with; it isn't a signing key.
+	KTHDSeed types.KeyType = "hd-seed"
+)
+
+var (
+	ErrHDSeedExists = xerrors.New("wallet already has a different HD seed")
+	ErrNoHDSeed     = xerrors.New("wallet has no HD seed")
+)
+
+func (w *LocalWallet) WalletHDNew(ctx context.Context, words int, passphrase string) (*api.HDMnemonic, error) {
+	mnemonic, err := hd.NewMnemonic(words)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := hd.MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	if err := w.putHDSeed(seed); err != nil {
+		return nil, err
+	}
+
+	k, err := w.deriveHDKey(seed, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &api.HDMnemonic{Mnemonic: mnemonic, Key: k}, nil
+}
+
+func (w *LocalWallet) WalletHDDerive(ctx context.Context, index int64) (*api.HDKey, error) {
+	if index >= int64(hd.HardenedOffset) {
+		return nil, xerrors.Errorf("index %d isn't below %d", index, hd.HardenedOffset)
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	ki, err := w.keystore.Get(KHDSeed)
+	if xerrors.Is(err, types.ErrKeyInfoNotFound) {
+		return nil, ErrNoHDSeed
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting HD seed: %w", err)
+	}
+
+	if index >= 0 {
+		return w.deriveHDKey(ki.PrivateKey, uint32(index))
+	}
+
+	for i := uint32(0); i < hd.HardenedOffset; i++ {
+		k, err := w.hdKey(ki.PrivateKey, i)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.tryFind(k.Address); xerrors.Is(err, types.ErrKeyInfoNotFound) {
+			return w.deriveHDKey(ki.PrivateKey, i)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return nil, xerrors.Errorf("all indexes are in the wallet")
+}
+
+func (w *LocalWallet) WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]api.HDKey, error) {
+	if count < 1 || count > 1000 {
+		return nil, xerrors.Errorf("number of addresses %d isn't between 1 and 1000", count)
+	}
+
+	seed, err := hd.MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	if err := w.putHDSeed(seed); err != nil {
+		return nil, err
+	}
+
+	out := make([]api.HDKey, 0, count)
+	for i := 0; i < count; i++ {
+		k, err := w.deriveHDKey(seed, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *k)
+	}
+	return out, nil
+}
+
+// putHDSeed stores the HD seed of the wallet, unless it has a different one. w.lk must be held.
+func (w *LocalWallet) putHDSeed(seed []byte) error {
+	ki, err := w.keystore.Get(KHDSeed)
+	switch {
+	case err == nil:
+		if !bytes.Equal(ki.PrivateKey, seed) {
+			return ErrHDSeedExists
+		}
+		return nil
+	case !xerrors.Is(err, types.ErrKeyInfoNotFound):
+		return xerrors.Errorf("getting HD seed: %w", err)
+	}
+
+	if err := w.keystore.Put(KHDSeed, types.KeyInfo{Type: KTHDSeed, PrivateKey: seed}); err != nil {
+		return xerrors.Errorf("saving HD seed to keystore: %w", err)
+	}
+	return nil
+}
+
+func (w *LocalWallet) hdKey(seed []byte, index uint32) (*key.Key, error) {
+	pk, err := hd.DeriveKey(seed, hd.FilecoinPath(0, index))
+	if err != nil {
+		return nil, err
+	}
+	return key.NewKey(types.KeyInfo{Type: types.KTSecp256k1, PrivateKey: pk})
+}
+
+// deriveHDKey adds the key at an index to the wallet, and makes it the default key when there
+// is none. w.lk must be held.
+func (w *LocalWallet) deriveHDKey(seed []byte, index uint32) (*api.HDKey, error) {
+	k, err := w.hdKey(seed, index)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.keystore.Put(KNamePrefix+k.Address.String(), k.KeyInfo); err != nil && !xerrors.Is(err, types.ErrKeyExists) {
+		return nil, xerrors.Errorf("saving to keystore: %w", err)
+	}
+	w.keys[k.Address] = k
+
+	_, err = w.keystore.Get(KDefault)
+	if err != nil {
+		if !xerrors.Is(err, types.ErrKeyInfoNotFound) {
+			return nil, err
+		}
+
+		if err := w.keystore.Put(KDefault, k.KeyInfo); err != nil {
+			return nil, xerrors.Errorf("failed to set new key as default: %w", err)
+		}
+	}
+
+	return &api.HDKey{Address: k.Address, Path: hd.FilecoinPath(0, index)}, nil
+}
+
+func (m MultiWallet) hdWallet() (api.Wallet, error) {
+	w := firstNonNil(m.Remote, m.Local)
+	if w == nil {
+		return nil, xerrors.Errorf("no wallet backends supporting HD keys")
+	}
+	return w, nil
+}
+
+func (m MultiWallet) WalletHDNew(ctx context.Context, words int, passphrase string) (*api.HDMnemonic, error) {
+	w, err := m.hdWallet()
+	if err != nil {
+		return nil, err
+	}
+	return w.WalletHDNew(ctx, words, passphrase)
+}
+
+func (m MultiWallet) WalletHDDerive(ctx context.Context, index int64) (*api.HDKey, error) {
+	w, err := m.hdWallet()
+	if err != nil {
+		return nil, err
+	}
+	return w.WalletHDDerive(ctx, index)
+}
+
+func (m MultiWallet) WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]api.HDKey, error) {
+	w, err := m.hdWallet()
+	if err != nil {
+		return nil, err
+	}
+	return w.WalletHDRestore(ctx, mnemonic, passphrase, count)
+}
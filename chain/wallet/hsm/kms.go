@@ -0,0 +1,103 @@
+package hsmwallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/sigv4"
+)
+
+// KMSConfig configures the AWS KMS backend.
+type KMSConfig struct {
+	// Region is the region of the keys.
+	Region string
+	// Endpoint overrides the endpoint of KMS in the region, e.g. for VPC endpoints.
+	Endpoint string
+	// AccessKey, SecretKey and SessionToken are the credentials requests are signed with.
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// KMS signs with asymmetric ECC_SECG_P256K1 keys of AWS KMS, identified by their key id, ARN
+// or alias. The credentials need the kms:Sign permission on the keys.
+type KMS struct {
+	cfg    KMSConfig
+	client *http.Client
+}
+
+// NewKMS returns the AWS KMS backend of a config.
+func NewKMS(cfg KMSConfig) (*KMS, error) {
+	if cfg.Region == "" {
+		return nil, xerrors.Errorf("no KMS region")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, xerrors.Errorf("no AWS credentials")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://kms." + cfg.Region + ".amazonaws.com/"
+	}
+
+	return &KMS{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (k *KMS) SignDigest(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"KeyId":            keyID,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Sign")
+	if k.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", k.cfg.SessionToken)
+	}
+	sigv4.Sign(req, k.cfg.AccessKey, k.cfg.SecretKey, k.cfg.Region, "kms", sigv4.PayloadHash(body), time.Now())
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("KMS sign: %w", err)
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode != http.StatusOK {
+		var kerr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		em, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		if err := json.Unmarshal(em, &kerr); err == nil && kerr.Type != "" {
+			return nil, xerrors.Errorf("KMS sign: http %d: %s: %s", resp.StatusCode, kerr.Type, kerr.Message)
+		}
+		return nil, xerrors.Errorf("KMS sign: http %d: %s", resp.StatusCode, strings.TrimSpace(string(em)))
+	}
+
+	var out struct {
+		Signature []byte
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, xerrors.Errorf("decoding KMS response: %w", err)
+	}
+	return out.Signature, nil
+}
+
+var _ Backend = &KMS{}
@@ -0,0 +1,91 @@
+package hsmwallet
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// PKCS11Config configures the PKCS#11 backend.
+type PKCS11Config struct {
+	// Tool is the path of the OpenSC pkcs11-tool binary, looked up in PATH by default.
+	Tool string
+	// Module is the path of the PKCS#11 library of the HSM.
+	Module string
+	// TokenLabel is the label of the token holding the keys, the first token when empty.
+	TokenLabel string
+	// PINFile is the path of the file holding the user PIN of the token.
+	PINFile string
+}
+
+// PKCS11 signs with the secp256k1 keys of any HSM with a PKCS#11 library, identified by their
+// hex encoded CKA_ID. It runs the OpenSC pkcs11-tool (0.21 or later) for every signature, so
+// that lotus doesn't link the library.
+type PKCS11 struct {
+	cfg PKCS11Config
+}
+
+// NewPKCS11 returns the PKCS#11 backend of a config.
+func NewPKCS11(cfg PKCS11Config) (*PKCS11, error) {
+	if cfg.Module == "" {
+		return nil, xerrors.Errorf("no PKCS#11 module")
+	}
+	if cfg.PINFile == "" {
+		return nil, xerrors.Errorf("no PKCS#11 PIN file")
+	}
+	if cfg.Tool == "" {
+		cfg.Tool = "pkcs11-tool"
+	}
+	if _, err := exec.LookPath(cfg.Tool); err != nil {
+		return nil, xerrors.Errorf("finding pkcs11-tool: %w", err)
+	}
+
+	return &PKCS11{cfg: cfg}, nil
+}
+
+func (p *PKCS11) SignDigest(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	pin, err := os.ReadFile(p.cfg.PINFile)
+	if err != nil {
+		return nil, xerrors.Errorf("reading PKCS#11 PIN: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "lotus-pkcs11-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir) // nolint
+
+	in, out := filepath.Join(dir, "digest"), filepath.Join(dir, "signature")
+	if err := os.WriteFile(in, digest, 0600); err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"--module", p.cfg.Module,
+		"--login", "--pin", "env:LOTUS_PKCS11_PIN",
+		"--sign", "--mechanism", "ECDSA", "--signature-format", "openssl",
+		"--id", keyID,
+		"--input-file", in, "--output-file", out,
+	}
+	if p.cfg.TokenLabel != "" {
+		args = append(args, "--token-label", p.cfg.TokenLabel)
+	}
+
+	cmd := exec.CommandContext(ctx, p.cfg.Tool, args...)
+	// the PIN is passed in the environment of the tool, to keep it out of its command line
+	cmd.Env = append(os.Environ(), "LOTUS_PKCS11_PIN="+strings.TrimSpace(string(pin)))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, xerrors.Errorf("pkcs11-tool: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return os.ReadFile(out)
+}
+
+var _ Backend = &PKCS11{}
@@ -0,0 +1,197 @@
+// Package hsmwallet implements a wallet signing with secp256k1 keys held in a hardware security
+// module, so that the private keys never leave it. The HSM signs the blake2b-256 digest of the
+// signed bytes with ECDSA, and the wallet turns the DER encoded signature into the recoverable
+// signature of Filecoin, checking that it was made by the key of the address.
+package hsmwallet
+
+import (
+	"context"
+	"encoding/asn1"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/minio/blake2b-simd"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	gocrypto "github.com/filecoin-project/go-crypto"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("wallet-hsm")
+
+// Backend signs digests with the secp256k1 keys of an HSM.
+type Backend interface {
+	// SignDigest signs a 32 bytes digest with ECDSA, with the key of the given id, and returns
+	// the DER encoded signature.
+	SignDigest(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+}
+
+// Wallet is a wallet signing for a fixed set of secp256k1 addresses with the keys of an HSM.
+type Wallet struct {
+	backend Backend
+	keys    map[address.Address]string
+}
+
+// NewWallet returns a wallet signing for the addresses of keys with the key ids they map to.
+func NewWallet(backend Backend, keys map[address.Address]string) *Wallet {
+	return &Wallet{backend: backend, keys: keys}
+}
+
+// ParseKeys parses '<address>=<key id>' entries.
+func ParseKeys(entries []string) (map[address.Address]string, error) {
+	keys := make(map[address.Address]string, len(entries))
+	for _, e := range entries {
+		as, id, ok := strings.Cut(e, "=")
+		if !ok || id == "" {
+			return nil, xerrors.Errorf("HSM key %q isn't '<address>=<key id>'", e)
+		}
+
+		a, err := address.NewFromString(strings.TrimSpace(as))
+		if err != nil {
+			return nil, xerrors.Errorf("parsing address of HSM key %q: %w", e, err)
+		}
+		if a.Protocol() != address.SECP256K1 {
+			return nil, xerrors.Errorf("HSM key %q: only secp256k1 addresses are supported", e)
+		}
+		keys[a] = strings.TrimSpace(id)
+	}
+	return keys, nil
+}
+
+func (w *Wallet) WalletSign(ctx context.Context, addr address.Address, msg []byte, meta api.MsgMeta) (*crypto.Signature, error) {
+	id, ok := w.keys[addr]
+	if !ok {
+		return nil, xerrors.Errorf("signing using key '%s': %w", addr, types.ErrKeyInfoNotFound)
+	}
+
+	digest := blake2b.Sum256(msg)
+	der, err := w.backend.SignDigest(ctx, id, digest[:])
+	if err != nil {
+		return nil, xerrors.Errorf("signing with HSM key %s: %w", id, err)
+	}
+
+	sig, err := recoverableSignature(addr, digest[:], der)
+	if err != nil {
+		return nil, xerrors.Errorf("HSM key %s: %w", id, err)
+	}
+
+	log.Infow("signed with HSM", "address", addr, "key", id, "type", meta.Type)
+	return &crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: sig}, nil
+}
+
+// secp256k1N is the order of the secp256k1 curve.
+var secp256k1N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+
+// recoverableSignature turns a DER encoded ECDSA signature of a digest into the 65 bytes
+// [R || S || V] signature Filecoin uses, with the low S value, checking that the key which made
+// it is the one of the address.
+func recoverableSignature(addr address.Address, digest, der []byte) ([]byte, error) {
+	var rs struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(der, &rs)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding DER signature: %w", err)
+	}
+	if len(rest) > 0 || rs.R.Sign() <= 0 || rs.S.Sign() <= 0 || rs.R.Cmp(secp256k1N) >= 0 || rs.S.Cmp(secp256k1N) >= 0 {
+		return nil, xerrors.Errorf("invalid signature")
+	}
+
+	// signatures with a high S are malleable and refused by the chain
+	if rs.S.Cmp(new(big.Int).Rsh(secp256k1N, 1)) > 0 {
+		rs.S.Sub(secp256k1N, rs.S)
+	}
+
+	sig := make([]byte, 65)
+	rs.R.FillBytes(sig[:32])
+	rs.S.FillBytes(sig[32:64])
+
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		pub, err := gocrypto.EcRecover(digest, sig)
+		if err != nil {
+			continue
+		}
+		if a, err := address.NewSecp256k1Address(pub); err == nil && a == addr {
+			return sig, nil
+		}
+	}
+	return nil, xerrors.Errorf("the signature isn't from the key of %s", addr)
+}
+
+func (w *Wallet) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
+	_, ok := w.keys[addr]
+	return ok, nil
+}
+
+func (w *Wallet) WalletList(ctx context.Context) ([]address.Address, error) {
+	out := make([]address.Address, 0, len(w.keys))
+	for a := range w.keys {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].String() < out[j].String()
+	})
+	return out, nil
+}
+
+func (w *Wallet) WalletNew(ctx context.Context, typ types.KeyType) (address.Address, error) {
+	return address.Undef, xerrors.Errorf("HSM keys are created in the HSM, and configured in Wallet.HSM.Keys")
+}
+
+func (w *Wallet) WalletExport(ctx context.Context, addr address.Address) (*types.KeyInfo, error) {
+	return nil, xerrors.Errorf("HSM keys can't be exported")
+}
+
+func (w *Wallet) WalletImport(ctx context.Context, ki *types.KeyInfo) (address.Address, error) {
+	return address.Undef, xerrors.Errorf("keys can't be imported into an HSM")
+}
+
+func (w *Wallet) WalletDelete(ctx context.Context, addr address.Address) error {
+	return xerrors.Errorf("HSM keys are removed from Wallet.HSM.Keys")
+}
+
+func (w *Wallet) WalletHDNew(ctx context.Context, words int, passphrase string) (*api.HDMnemonic, error) {
+	return nil, xerrors.Errorf("HD keys can't be derived into an HSM")
+}
+
+func (w *Wallet) WalletHDDerive(ctx context.Context, index int64) (*api.HDKey, error) {
+	return nil, xerrors.Errorf("HD keys can't be derived into an HSM")
+}
+
+func (w *Wallet) WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]api.HDKey, error) {
+	return nil, xerrors.Errorf("HD keys can't be derived into an HSM")
+}
+
+func (w *Wallet) WalletEncrypt(ctx context.Context, passphrase string) error {
+	return xerrors.Errorf("HSM keys aren't stored in an encrypted keystore")
+}
+
+func (w *Wallet) WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error {
+	return xerrors.Errorf("HSM keys aren't stored in an encrypted keystore")
+}
+
+func (w *Wallet) WalletLock(ctx context.Context) error {
+	return xerrors.Errorf("HSM keys aren't stored in an encrypted keystore")
+}
+
+func (w *Wallet) WalletLockStatus(ctx context.Context) (*api.LockStatus, error) {
+	return nil, xerrors.Errorf("HSM keys aren't stored in an encrypted keystore")
+}
+
+func (w *Wallet) Get() api.Wallet {
+	if w == nil {
+		return nil
+	}
+
+	return w
+}
+
+var _ api.Wallet = &Wallet{}
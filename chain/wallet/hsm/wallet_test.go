@@ -0,0 +1,105 @@
+package hsmwallet
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	gocrypto "github.com/filecoin-project/go-crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+// derSign signs a digest like an HSM, returning a DER signature with the high S value.
+func derSign(t *testing.T, priv, digest []byte) []byte {
+	sig, err := gocrypto.Sign(priv, digest)
+	require.NoError(t, err)
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if s.Cmp(new(big.Int).Rsh(secp256k1N, 1)) <= 0 {
+		s.Sub(secp256k1N, s)
+	}
+
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	require.NoError(t, err)
+	return der
+}
+
+func TestKMSWallet(t *testing.T) {
+	ctx := context.Background()
+
+	k, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+	other, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	const arn = "arn:aws:kms:us-east-1:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	signers := map[string][]byte{arn: k.PrivateKey, "other": other.PrivateKey}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "TrentService.Sign", r.Header.Get("X-Amz-Target"))
+		require.Contains(t, r.Header.Get("Authorization"), "/us-east-1/kms/aws4_request")
+
+		var req struct {
+			KeyId            string
+			Message          []byte
+			MessageType      string
+			SigningAlgorithm string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "DIGEST", req.MessageType)
+		require.Equal(t, "ECDSA_SHA_256", req.SigningAlgorithm)
+
+		priv, ok := signers[req.KeyId]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"NotFoundException","message":"key not found"}`))
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string][]byte{"Signature": derSign(t, priv, req.Message)}))
+	}))
+	defer srv.Close()
+
+	kms, err := NewKMS(KMSConfig{Region: "us-east-1", Endpoint: srv.URL, AccessKey: "AKID", SecretKey: "secret"})
+	require.NoError(t, err)
+
+	keys, err := ParseKeys([]string{k.Address.String() + "=" + arn, other.Address.String() + "=missing"})
+	require.NoError(t, err)
+	require.Equal(t, arn, keys[k.Address])
+
+	w := NewWallet(kms, keys)
+
+	has, err := w.WalletHas(ctx, k.Address)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	msg := []byte("message")
+	sig, err := w.WalletSign(ctx, k.Address, msg, api.MsgMeta{Type: api.MTUnknown})
+	require.NoError(t, err)
+	require.NoError(t, sigs.Verify(sig, k.Address, msg))
+
+	_, err = w.WalletSign(ctx, other.Address, msg, api.MsgMeta{Type: api.MTUnknown})
+	require.ErrorContains(t, err, "NotFoundException")
+
+	// a key configured for the wrong address is caught
+	w = NewWallet(kms, map[address.Address]string{other.Address: arn})
+	_, err = w.WalletSign(ctx, other.Address, msg, api.MsgMeta{Type: api.MTUnknown})
+	require.ErrorContains(t, err, "isn't from the key of")
+
+	_, err = ParseKeys([]string{"t01000=" + arn})
+	require.Error(t, err)
+	_, err = ParseKeys([]string{k.Address.String()})
+	require.Error(t, err)
+}
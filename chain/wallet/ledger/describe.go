@@ -0,0 +1,112 @@
+package ledgerwallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// ActorCodeFunc returns the code of the actor at an address, so that the methods and params of
+// the messages sent to it can be decoded.
+type ActorCodeFunc func(ctx context.Context, addr address.Address) (cid.Cid, error)
+
+// MessageDescription is the decoded method and params of a message. It is logged before the
+// message is sent to the ledger device, for the signer to check against what the device shows.
+type MessageDescription struct {
+	To     address.Address
+	Value  types.FIL
+	Method string
+	// Params are the params as json, or as hex prefixed with raw: when they can't be decoded.
+	Params string
+	// Proposal is the message proposed by a multisig proposal.
+	Proposal *MessageDescription `json:",omitempty"`
+}
+
+// describeMessage decodes the method and params of a message sent to an address, falling back to
+// the method number and the raw params when the code of the recipient is unknown.
+func describeMessage(ctx context.Context, actorCode ActorCodeFunc, to address.Address, value abi.TokenAmount, method abi.MethodNum, params []byte) *MessageDescription {
+	d := &MessageDescription{
+		To:     to,
+		Value:  types.FIL(value),
+		Method: fmt.Sprint(method),
+		Params: "raw:" + hex.EncodeToString(params),
+	}
+	if method == builtin.MethodSend {
+		d.Method = "Send"
+	}
+	if actorCode == nil {
+		return d
+	}
+
+	code, err := actorCode(ctx, to)
+	if err != nil {
+		log.Warnw("looking up recipient actor to decode the message", "to", to, "error", err)
+		return d
+	}
+
+	ar := consensus.NewActorRegistry()
+	if m, ok := ar.Methods[code][method]; ok {
+		d.Method = m.Name
+	}
+
+	if len(params) > 0 {
+		if p, err := decodeParams(ar, code, method, params); err == nil {
+			d.Params = p
+		} else {
+			log.Warnw("decoding message params", "to", to, "method", method, "error", err)
+		}
+	}
+
+	if builtin.IsMultisigActor(code) && method == multisig.Methods.Propose {
+		var mp multisig.ProposeParams
+		if err := mp.UnmarshalCBOR(bytes.NewReader(params)); err == nil {
+			d.Proposal = describeMessage(ctx, actorCode, mp.To, mp.Value, mp.Method, mp.Params)
+		}
+	}
+
+	return d
+}
+
+func decodeParams(ar *vm.ActorRegistry, code cid.Cid, method abi.MethodNum, params []byte) (string, error) {
+	p, err := stmgr.GetParamType(ar, code, method)
+	if err != nil {
+		return "", err
+	}
+	if err := p.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
+		return "", xerrors.Errorf("unmarshalling params: %w", err)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// signError explains the errors the Filecoin ledger app returns when it doesn't sign a message.
+func signError(err error) error {
+	switch msg := err.Error(); {
+	case strings.Contains(msg, "Transaction rejected"):
+		return xerrors.Errorf("the message was rejected on the ledger device: %w", err)
+	case strings.Contains(msg, "Data is invalid"), strings.Contains(msg, "Unexpected"):
+		return xerrors.Errorf("the Filecoin ledger app couldn't decode the message; messages with params need an up to date app, and some need its expert mode enabled: %w", err)
+	default:
+		return xerrors.Errorf("signing with ledger: %w", err)
+	}
+}
@@ -0,0 +1,67 @@
+package ledgerwallet
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/manifest"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestDescribeMessage(t *testing.T) {
+	ctx := context.Background()
+
+	av, err := actorstypes.VersionForNetwork(build.TestNetworkVersion)
+	require.NoError(t, err)
+	msigCode, ok := actors.GetActorCodeID(av, manifest.MultisigKey)
+	require.True(t, ok)
+	accountCode, ok := actors.GetActorCodeID(av, manifest.AccountKey)
+	require.True(t, ok)
+
+	msig, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	target, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+
+	codes := map[address.Address]cid.Cid{msig: msigCode, target: accountCode}
+	actorCode := func(ctx context.Context, addr address.Address) (cid.Cid, error) {
+		c, ok := codes[addr]
+		if !ok {
+			return cid.Undef, xerrors.Errorf("actor %s not found", addr)
+		}
+		return c, nil
+	}
+
+	params, err := actors.SerializeParams(&multisig.ProposeParams{
+		To:     target,
+		Value:  types.FromFil(3),
+		Method: builtin.MethodSend,
+	})
+	require.NoError(t, err)
+
+	d := describeMessage(ctx, actorCode, msig, types.NewInt(0), multisig.Methods.Propose, params)
+	require.Equal(t, "Propose", d.Method)
+	require.False(t, strings.HasPrefix(d.Params, "raw:"), d.Params)
+	require.NotNil(t, d.Proposal)
+	require.Equal(t, target, d.Proposal.To)
+	require.Equal(t, "Send", d.Proposal.Method)
+	require.Equal(t, types.FIL(types.FromFil(3)), d.Proposal.Value)
+
+	// without actor codes, only the method number and the raw params are known
+	d = describeMessage(ctx, nil, msig, types.NewInt(0), multisig.Methods.Propose, params)
+	require.Equal(t, "2", d.Method)
+	require.True(t, strings.HasPrefix(d.Params, "raw:"))
+	require.Nil(t, d.Proposal)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
@@ -25,10 +26,19 @@ var log = logging.Logger("wallet-ledger")
 
 type LedgerWallet struct {
 	ds datastore.Datastore
+
+	actorCode ActorCodeFunc
 }
 
 func NewWallet(ds dtypes.MetadataDS) *LedgerWallet {
-	return &LedgerWallet{ds}
+	return &LedgerWallet{ds: ds}
+}
+
+// NewWalletWithActorCodes returns a ledger wallet which decodes the method and params of the
+// messages it signs from the codes of their recipients, and logs them for the signer to check
+// against what the device shows.
+func NewWalletWithActorCodes(ds dtypes.MetadataDS, actorCode ActorCodeFunc) *LedgerWallet {
+	return &LedgerWallet{ds: ds, actorCode: actorCode}
 }
 
 type LedgerKeyInfo struct {
@@ -44,35 +54,42 @@ func (lw LedgerWallet) WalletSign(ctx context.Context, signer address.Address, t
 		return nil, err
 	}
 
-	fl, err := ledgerfil.FindLedgerFilecoinApp()
-	if err != nil {
-		return nil, err
-	}
-	defer fl.Close() // nolint:errcheck
 	if meta.Type != api.MTChainMsg {
-		return nil, fmt.Errorf("ledger can only sign chain messages")
+		return nil, fmt.Errorf("ledger can only sign chain messages, not %s", meta.Type)
 	}
 
-	{
-		var cmsg types.Message
-		if err := cmsg.UnmarshalCBOR(bytes.NewReader(meta.Extra)); err != nil {
-			return nil, xerrors.Errorf("unmarshalling message: %w", err)
-		}
+	var cmsg types.Message
+	if err := cmsg.UnmarshalCBOR(bytes.NewReader(meta.Extra)); err != nil {
+		return nil, xerrors.Errorf("unmarshalling message: %w", err)
+	}
 
-		_, bc, err := cid.CidFromBytes(toSign)
-		if err != nil {
-			return nil, xerrors.Errorf("getting cid from signing bytes: %w", err)
-		}
+	_, bc, err := cid.CidFromBytes(toSign)
+	if err != nil {
+		return nil, xerrors.Errorf("getting cid from signing bytes: %w", err)
+	}
 
-		if !cmsg.Cid().Equals(bc) {
-			return nil, xerrors.Errorf("cid(meta.Extra).bytes() != toSign")
-		}
+	if !cmsg.Cid().Equals(bc) {
+		return nil, xerrors.Errorf("cid(meta.Extra).bytes() != toSign")
 	}
 
-	sig, err := fl.SignSECP256K1(ki.Path, meta.Extra)
+	// the device signs the whole message, whatever its method, and displays what the Filecoin
+	// app decodes of it; log the decoded message for the signer to check it against
+	desc, err := json.MarshalIndent(describeMessage(ctx, lw.actorCode, cmsg.To, cmsg.Value, cmsg.Method, cmsg.Params), "", "  ")
+	if err != nil {
+		return nil, xerrors.Errorf("marshaling message description: %w", err)
+	}
+	log.Warnf("signing message %s from %s nonce %d, review and accept it on the ledger device:\n%s", bc, signer, cmsg.Nonce, desc)
+
+	fl, err := ledgerfil.FindLedgerFilecoinApp()
 	if err != nil {
 		return nil, err
 	}
+	defer fl.Close() // nolint:errcheck
+
+	sig, err := fl.SignSECP256K1(ki.Path, meta.Extra)
+	if err != nil {
+		return nil, signError(err)
+	}
 
 	return &crypto.Signature{
 		Type: crypto.SigTypeSecp256k1,
@@ -227,6 +244,34 @@ func (lw LedgerWallet) WalletNew(ctx context.Context, t types.KeyType) (address.
 	return lw.importKey(ctx, lki)
 }
 
+func (lw LedgerWallet) WalletHDNew(ctx context.Context, words int, passphrase string) (*api.HDMnemonic, error) {
+	return nil, fmt.Errorf("cannot derive HD keys into ledger wallets")
+}
+
+func (lw LedgerWallet) WalletHDDerive(ctx context.Context, index int64) (*api.HDKey, error) {
+	return nil, fmt.Errorf("cannot derive HD keys into ledger wallets")
+}
+
+func (lw LedgerWallet) WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]api.HDKey, error) {
+	return nil, fmt.Errorf("cannot derive HD keys into ledger wallets")
+}
+
+func (lw LedgerWallet) WalletEncrypt(ctx context.Context, passphrase string) error {
+	return fmt.Errorf("cannot encrypt the keys of ledger wallets")
+}
+
+func (lw LedgerWallet) WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error {
+	return fmt.Errorf("cannot encrypt the keys of ledger wallets")
+}
+
+func (lw LedgerWallet) WalletLock(ctx context.Context) error {
+	return fmt.Errorf("cannot encrypt the keys of ledger wallets")
+}
+
+func (lw LedgerWallet) WalletLockStatus(ctx context.Context) (*api.LockStatus, error) {
+	return nil, fmt.Errorf("cannot encrypt the keys of ledger wallets")
+}
+
 func (lw *LedgerWallet) Get() api.Wallet {
 	if lw == nil {
 		return nil
@@ -12,6 +12,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
+	hsmwallet "github.com/filecoin-project/lotus/chain/wallet/hsm"
 	ledgerwallet "github.com/filecoin-project/lotus/chain/wallet/ledger"
 	"github.com/filecoin-project/lotus/chain/wallet/remotewallet"
 )
@@ -22,6 +23,7 @@ type MultiWallet struct {
 	Local  *LocalWallet               `optional:"true"`
 	Remote *remotewallet.RemoteWallet `optional:"true"`
 	Ledger *ledgerwallet.LedgerWallet `optional:"true"`
+	HSM    *hsmwallet.Wallet          `optional:"true"`
 }
 
 type getif interface {
@@ -86,7 +88,7 @@ func (m MultiWallet) WalletNew(ctx context.Context, keyType types.KeyType) (addr
 }
 
 func (m MultiWallet) WalletHas(ctx context.Context, address address.Address) (bool, error) {
-	w, err := m.find(ctx, address, m.Remote, m.Ledger, m.Local)
+	w, err := m.find(ctx, address, m.HSM, m.Remote, m.Ledger, m.Local)
 	return w != nil, err
 }
 
@@ -94,7 +96,7 @@ func (m MultiWallet) WalletList(ctx context.Context) ([]address.Address, error)
 	out := make([]address.Address, 0)
 	seen := map[address.Address]struct{}{}
 
-	ws := nonNil(m.Remote, m.Ledger, m.Local)
+	ws := nonNil(m.HSM, m.Remote, m.Ledger, m.Local)
 	for _, w := range ws {
 		l, err := w.WalletList(ctx)
 		if err != nil {
@@ -115,7 +117,7 @@ func (m MultiWallet) WalletList(ctx context.Context) ([]address.Address, error)
 }
 
 func (m MultiWallet) WalletSign(ctx context.Context, signer address.Address, toSign []byte, meta api.MsgMeta) (*crypto.Signature, error) {
-	w, err := m.find(ctx, signer, m.Remote, m.Ledger, m.Local)
+	w, err := m.find(ctx, signer, m.HSM, m.Remote, m.Ledger, m.Local)
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +129,7 @@ func (m MultiWallet) WalletSign(ctx context.Context, signer address.Address, toS
 }
 
 func (m MultiWallet) WalletExport(ctx context.Context, addr address.Address) (*types.KeyInfo, error) {
-	w, err := m.find(ctx, addr, m.Remote, m.Local)
+	w, err := m.find(ctx, addr, m.HSM, m.Remote, m.Local)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +156,7 @@ func (m MultiWallet) WalletImport(ctx context.Context, info *types.KeyInfo) (add
 
 func (m MultiWallet) WalletDelete(ctx context.Context, address address.Address) error {
 	for {
-		w, err := m.find(ctx, address, m.Remote, m.Ledger, m.Local)
+		w, err := m.find(ctx, address, m.HSM, m.Remote, m.Ledger, m.Local)
 		if err != nil {
 			return err
 		}
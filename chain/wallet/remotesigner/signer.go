@@ -0,0 +1,346 @@
+// Package remotesigner implements a wallet holding no keys, which forwards signing requests to a
+// remote signing service over HTTPS with mutual TLS.
+//
+// The signing service exposes two endpoints:
+//
+//   - POST /v0/sign, with a json body {"Address", "Data", "Meta"} where Data are the bytes to
+//     sign and Meta is the api.MsgMeta of the request, returning the crypto.Signature as json;
+//   - GET /v0/addresses, returning the json list of the addresses it signs for.
+//
+// On top of the TLS client certificate, requests are authenticated with the headers
+// X-Signer-Timestamp, the unix time of the request, and X-Signer-Signature, the hex encoded
+// HMAC-SHA256, keyed with a shared secret, of the method, the path, the timestamp and the hex
+// encoded SHA256 of the body, separated by newlines. The service should refuse timestamps more
+// than a few seconds away from its clock, so that requests can't be replayed.
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls" // enable bls signatures
+	_ "github.com/filecoin-project/lotus/lib/sigs/delegated"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp" // enable secp signatures
+)
+
+var log = logging.Logger("wallet-remotesigner")
+
+const (
+	timestampHeader = "X-Signer-Timestamp"
+	signatureHeader = "X-Signer-Signature"
+)
+
+// Config configures a remote signer.
+type Config struct {
+	// Endpoints are the base URLs of the replicas of the signing service, in order of preference.
+	Endpoints []string
+	// CertFile and KeyFile are the client certificate and its key, in PEM.
+	CertFile string
+	KeyFile  string
+	// CAFile is the PEM bundle of the authorities the certificate of the service is verified
+	// with, the system roots when empty.
+	CAFile string
+	// Secret is the key the requests are signed with.
+	Secret []byte
+	// Timeout bounds each request to an endpoint.
+	Timeout time.Duration
+	// Rounds is the number of times all the endpoints are tried before giving up, waiting
+	// between rounds from one second, doubling every round.
+	Rounds int
+}
+
+// Signer is a wallet signing with a remote signing service.
+type Signer struct {
+	endpoints []string
+	client    *http.Client
+	secret    []byte
+	rounds    int
+	backoff   time.Duration
+
+	lk sync.Mutex
+	// preferred is the endpoint which answered last, tried first
+	preferred int
+}
+
+// New returns a signer for the signing service of a config.
+func New(cfg Config) (*Signer, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, xerrors.Errorf("no signer endpoint")
+	}
+	if len(cfg.Secret) == 0 {
+		return nil, xerrors.Errorf("no request signing secret")
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, xerrors.Errorf("loading client certificate: %w", err)
+	}
+
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, xerrors.Errorf("reading CA bundle: %w", err)
+		}
+		tlsCfg.RootCAs = x509.NewCertPool()
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, xerrors.Errorf("no certificate in CA bundle %s", cfg.CAFile)
+		}
+	}
+
+	for _, e := range cfg.Endpoints {
+		u, err := url.Parse(e)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing signer endpoint %q: %w", e, err)
+		}
+		if u.Scheme != "https" {
+			return nil, xerrors.Errorf("signer endpoint %q isn't https", e)
+		}
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	rounds := cfg.Rounds
+	if rounds < 1 {
+		rounds = 1
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+	}
+	return newSigner(cfg.Endpoints, client, cfg.Secret, rounds), nil
+}
+
+func newSigner(endpoints []string, client *http.Client, secret []byte, rounds int) *Signer {
+	return &Signer{
+		endpoints: endpoints,
+		client:    client,
+		secret:    secret,
+		rounds:    rounds,
+		backoff:   time.Second,
+	}
+}
+
+// signRequest returns the signature of a request.
+func signRequest(secret []byte, method, path, timestamp string, body []byte) string {
+	bh := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%s\n%x", method, path, timestamp, bh)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryableError is a failure of an endpoint, after which the next one is tried.
+type retryableError struct {
+	error
+}
+
+func (s *Signer) do(ctx context.Context, method, p string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	s.lk.Lock()
+	first := s.preferred
+	s.lk.Unlock()
+
+	backoff := s.backoff
+	var lastErr error
+	for round := 0; round < s.rounds; round++ {
+		if round > 0 {
+			select {
+			case <-build.Clock.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		for i := range s.endpoints {
+			e := (first + i) % len(s.endpoints)
+
+			err := s.doEndpoint(ctx, s.endpoints[e], method, p, body, out)
+			if err == nil {
+				s.lk.Lock()
+				s.preferred = e
+				s.lk.Unlock()
+				return nil
+			}
+
+			var rerr retryableError
+			if !xerrors.As(err, &rerr) {
+				return err
+			}
+			log.Warnw("remote signer endpoint failed, trying the next one", "endpoint", s.endpoints[e], "error", err)
+			lastErr = err
+		}
+	}
+
+	return xerrors.Errorf("all remote signer endpoints failed: %w", lastErr)
+}
+
+func (s *Signer) doEndpoint(ctx context.Context, endpoint, method, p string, body []byte, out interface{}) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, p)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	ts := fmt.Sprint(build.Clock.Now().Unix())
+	req.Header.Set(timestampHeader, ts)
+	req.Header.Set(signatureHeader, signRequest(s.secret, method, u.Path, ts, body))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return retryableError{xerrors.Errorf("%s %s: %w", method, u, err)}
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode != http.StatusOK {
+		em, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		err := xerrors.Errorf("%s %s: http %d: %s", method, u, resp.StatusCode, strings.TrimSpace(string(em)))
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return retryableError{err}
+		}
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return retryableError{xerrors.Errorf("%s %s: decoding response: %w", method, u, err)}
+	}
+	return nil
+}
+
+func (s *Signer) WalletSign(ctx context.Context, addr address.Address, msg []byte, meta api.MsgMeta) (*crypto.Signature, error) {
+	var sig crypto.Signature
+	err := s.do(ctx, http.MethodPost, "/v0/sign", struct {
+		Address address.Address
+		Data    []byte
+		Meta    api.MsgMeta
+	}{addr, msg, meta}, &sig)
+	if err != nil {
+		return nil, xerrors.Errorf("remote signing with %s: %w", addr, err)
+	}
+
+	// don't pass on signatures which wouldn't land, nor let the service sign with another key
+	if err := sigs.Verify(&sig, addr, msg); err != nil {
+		return nil, xerrors.Errorf("remote signer returned an invalid signature for %s: %w", addr, err)
+	}
+	return &sig, nil
+}
+
+func (s *Signer) WalletList(ctx context.Context) ([]address.Address, error) {
+	var out []address.Address
+	if err := s.do(ctx, http.MethodGet, "/v0/addresses", nil, &out); err != nil {
+		return nil, xerrors.Errorf("listing remote signer addresses: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Signer) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
+	addrs, err := s.WalletList(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range addrs {
+		if a == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Signer) WalletNew(ctx context.Context, typ types.KeyType) (address.Address, error) {
+	return address.Undef, xerrors.Errorf("keys can't be created through a remote signer")
+}
+
+func (s *Signer) WalletExport(ctx context.Context, addr address.Address) (*types.KeyInfo, error) {
+	return nil, xerrors.Errorf("keys can't be exported from a remote signer")
+}
+
+func (s *Signer) WalletImport(ctx context.Context, ki *types.KeyInfo) (address.Address, error) {
+	return address.Undef, xerrors.Errorf("keys can't be imported into a remote signer")
+}
+
+func (s *Signer) WalletDelete(ctx context.Context, addr address.Address) error {
+	return xerrors.Errorf("keys can't be deleted from a remote signer")
+}
+
+func (s *Signer) WalletHDNew(ctx context.Context, words int, passphrase string) (*api.HDMnemonic, error) {
+	return nil, xerrors.Errorf("HD keys can't be derived through a remote signer")
+}
+
+func (s *Signer) WalletHDDerive(ctx context.Context, index int64) (*api.HDKey, error) {
+	return nil, xerrors.Errorf("HD keys can't be derived through a remote signer")
+}
+
+func (s *Signer) WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]api.HDKey, error) {
+	return nil, xerrors.Errorf("HD keys can't be derived through a remote signer")
+}
+
+func (s *Signer) WalletEncrypt(ctx context.Context, passphrase string) error {
+	return xerrors.Errorf("keys of a remote signer aren't stored in an encrypted keystore")
+}
+
+func (s *Signer) WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error {
+	return xerrors.Errorf("keys of a remote signer aren't stored in an encrypted keystore")
+}
+
+func (s *Signer) WalletLock(ctx context.Context) error {
+	return xerrors.Errorf("keys of a remote signer aren't stored in an encrypted keystore")
+}
+
+func (s *Signer) WalletLockStatus(ctx context.Context) (*api.LockStatus, error) {
+	return nil, xerrors.Errorf("keys of a remote signer aren't stored in an encrypted keystore")
+}
+
+var _ api.Wallet = &Signer{}
@@ -0,0 +1,100 @@
+package remotesigner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+)
+
+func TestSignerFailover(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("secret")
+
+	k, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+	other, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	down := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	var signWith *key.Key
+	var calls int
+	up := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if r.Header.Get(signatureHeader) != signRequest(secret, r.Method, r.URL.Path, r.Header.Get(timestampHeader), body) {
+			http.Error(w, "bad request signature", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v0/addresses":
+			require.NoError(t, json.NewEncoder(w).Encode([]address.Address{k.Address}))
+		case "/v0/sign":
+			var req struct {
+				Address address.Address
+				Data    []byte
+			}
+			require.NoError(t, json.Unmarshal(body, &req))
+			sig, err := sigs.Sign(key.ActSigType(signWith.Type), signWith.PrivateKey, req.Data)
+			require.NoError(t, err)
+			require.NoError(t, json.NewEncoder(w).Encode(sig))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer up.Close()
+
+	// the httptest servers share their certificate
+	s := newSigner([]string{down.URL, up.URL}, up.Client(), secret, 2)
+	s.backoff = time.Millisecond
+
+	msg := []byte("message")
+	signWith = k
+	sig, err := s.WalletSign(ctx, k.Address, msg, api.MsgMeta{Type: api.MTUnknown})
+	require.NoError(t, err)
+	require.NoError(t, sigs.Verify(sig, k.Address, msg))
+	require.Equal(t, 1, s.preferred)
+
+	has, err := s.WalletHas(ctx, k.Address)
+	require.NoError(t, err)
+	require.True(t, has)
+	has, err = s.WalletHas(ctx, other.Address)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// signatures with another key are refused
+	signWith = other
+	_, err = s.WalletSign(ctx, k.Address, msg, api.MsgMeta{Type: api.MTUnknown})
+	require.Error(t, err)
+
+	// client errors aren't retried
+	calls = 0
+	s.secret = []byte("wrong")
+	_, err = s.WalletList(ctx)
+	require.ErrorContains(t, err, "http 401")
+	require.Equal(t, 1, calls)
+
+	// every endpoint is tried in every round
+	s = newSigner([]string{down.URL, down.URL}, up.Client(), secret, 2)
+	s.backoff = time.Millisecond
+	_, err = s.WalletList(ctx)
+	require.ErrorContains(t, err, "all remote signer endpoints failed")
+}
@@ -0,0 +1,394 @@
+// Package vault implements a keystore backed by HashiCorp Vault, so that the keys of a wallet
+// aren't stored on the disk of the node and every access to them is in the Vault audit log.
+//
+// The keys are stored in a KV version 2 secrets engine, one secret per key. When a transit key
+// is configured, the keys are encrypted with it before being stored, so that reading them needs
+// both the KV secrets and the transit decryption policy. Filecoin keys are secp256k1 and BLS keys,
+// which transit can't sign with, so the keys are still decrypted in the memory of the node.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("wallet-vault")
+
+// Config configures a Vault keystore.
+type Config struct {
+	// Address is the URL of the Vault server.
+	Address string
+	// Namespace is the Vault Enterprise namespace of the engines, if any.
+	Namespace string
+	// CACert is the PEM bundle the certificate of the server is verified with, the system roots
+	// when empty.
+	CACert string
+
+	// KVMount is the mount path of the KV version 2 engine, and KVPath the path of the keys in it.
+	KVMount string
+	KVPath  string
+	// TransitMount is the mount path of the transit engine, and TransitKey the key the keys are
+	// encrypted with; the keys are stored as they are when TransitKey is empty.
+	TransitMount string
+	TransitKey   string
+
+	// Token authenticates to Vault, unless AppRoleID is set to log in with AppRole, with the
+	// secret id read from AppRoleSecretIDFile.
+	Token               string
+	AppRoleMount        string
+	AppRoleID           string
+	AppRoleSecretIDFile string
+}
+
+// KeyStore is a types.KeyStore storing the keys in Vault.
+type KeyStore struct {
+	cfg    Config
+	client *http.Client
+
+	lk sync.Mutex
+	// token is the token of the AppRole login
+	token string
+}
+
+// NewKeyStore returns the keystore of a config, checking that it can list the keys.
+func NewKeyStore(cfg Config) (*KeyStore, error) {
+	if cfg.Address == "" {
+		return nil, xerrors.Errorf("no vault address")
+	}
+	if cfg.KVMount == "" || cfg.KVPath == "" {
+		return nil, xerrors.Errorf("no vault KV mount or path")
+	}
+	if cfg.Token == "" && cfg.AppRoleID == "" {
+		return nil, xerrors.Errorf("no vault token nor AppRole")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CACert != "" {
+		pem, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, xerrors.Errorf("reading vault CA certificate: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, xerrors.Errorf("no certificate in vault CA bundle %s", cfg.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	ks := &KeyStore{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}
+	if _, err := ks.List(); err != nil {
+		return nil, xerrors.Errorf("checking vault access: %w", err)
+	}
+	return ks, nil
+}
+
+// vaultError is an error response of Vault.
+type vaultError struct {
+	status int
+	errors []string
+}
+
+func (e *vaultError) Error() string {
+	return fmt.Sprintf("vault: http %d: %s", e.status, strings.Join(e.errors, "; "))
+}
+
+func (ks *KeyStore) authToken(ctx context.Context, renew bool) (string, error) {
+	if ks.cfg.AppRoleID == "" {
+		return ks.cfg.Token, nil
+	}
+
+	ks.lk.Lock()
+	defer ks.lk.Unlock()
+
+	if ks.token != "" && !renew {
+		return ks.token, nil
+	}
+
+	secretID, err := os.ReadFile(ks.cfg.AppRoleSecretIDFile)
+	if err != nil {
+		return "", xerrors.Errorf("reading AppRole secret id: %w", err)
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	err = ks.do(ctx, "", http.MethodPost, path.Join("auth", ks.cfg.AppRoleMount, "login"), map[string]string{
+		"role_id":   ks.cfg.AppRoleID,
+		"secret_id": strings.TrimSpace(string(secretID)),
+	}, &resp)
+	if err != nil {
+		return "", xerrors.Errorf("vault AppRole login: %w", err)
+	}
+
+	ks.token = resp.Auth.ClientToken
+	return ks.token, nil
+}
+
+// request sends an authenticated request to Vault, logging in again with AppRole when the token
+// is refused, as it expired.
+func (ks *KeyStore) request(method, p string, in, out interface{}) error {
+	ctx := context.TODO()
+
+	token, err := ks.authToken(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	err = ks.do(ctx, token, method, p, in, out)
+	var verr *vaultError
+	if ks.cfg.AppRoleID != "" && xerrors.As(err, &verr) && verr.status == http.StatusForbidden {
+		log.Infow("vault refused the token, logging in again", "error", err)
+		if token, err = ks.authToken(ctx, true); err != nil {
+			return err
+		}
+		err = ks.do(ctx, token, method, p, in, out)
+	}
+	return err
+}
+
+func (ks *KeyStore) do(ctx context.Context, token, method, p string, in, out interface{}) error {
+	u, err := url.Parse(ks.cfg.Address)
+	if err != nil {
+		return xerrors.Errorf("parsing vault address: %w", err)
+	}
+	u.Path = path.Join(u.Path, "v1", p)
+	if method == "LIST" {
+		method = http.MethodGet
+		u.RawQuery = "list=true"
+	}
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if ks.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", ks.cfg.Namespace)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("vault %s %s: %w", method, p, err)
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		verr := &vaultError{status: resp.StatusCode}
+		var eresp struct {
+			Errors []string `json:"errors"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&eresp); err == nil {
+			verr.errors = eresp.Errors
+		}
+		return verr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return xerrors.Errorf("decoding vault response: %w", err)
+	}
+	return nil
+}
+
+func (ks *KeyStore) kvPath(kind, name string) (string, error) {
+	if name == "" || strings.Contains(name, "/") || name == ".." {
+		return "", xerrors.Errorf("invalid key name '%s'", name)
+	}
+	return path.Join(ks.cfg.KVMount, kind, ks.cfg.KVPath, name), nil
+}
+
+func isNotFound(err error) bool {
+	var verr *vaultError
+	return xerrors.As(err, &verr) && verr.status == http.StatusNotFound
+}
+
+// secret is the data of the KV secret of a key.
+type secret struct {
+	Type       types.KeyType `json:",omitempty"`
+	PrivateKey []byte        `json:",omitempty"`
+	// Ciphertext is the json of the key info, encrypted with the transit key.
+	Ciphertext string `json:",omitempty"`
+}
+
+// List lists all the keys stored in the KeyStore
+func (ks *KeyStore) List() ([]string, error) {
+	var resp struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	err := ks.request("LIST", path.Join(ks.cfg.KVMount, "metadata", ks.cfg.KVPath), nil, &resp)
+	if isNotFound(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("listing vault keys: %w", err)
+	}
+
+	out := make([]string, 0, len(resp.Data.Keys))
+	for _, k := range resp.Data.Keys {
+		if strings.HasSuffix(k, "/") {
+			continue // not a key but a folder
+		}
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+// Get gets a key out of keystore and returns types.KeyInfo corresponding to named key
+func (ks *KeyStore) Get(name string) (types.KeyInfo, error) {
+	var resp struct {
+		Data struct {
+			Data *secret `json:"data"`
+		} `json:"data"`
+	}
+	p, err := ks.kvPath("data", name)
+	if err != nil {
+		return types.KeyInfo{}, err
+	}
+	err = ks.request(http.MethodGet, p, nil, &resp)
+	if isNotFound(err) || (err == nil && resp.Data.Data == nil) {
+		return types.KeyInfo{}, xerrors.Errorf("getting key '%s': %w", name, types.ErrKeyInfoNotFound)
+	}
+	if err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("getting key '%s': %w", name, err)
+	}
+
+	s := resp.Data.Data
+	if s.Ciphertext == "" {
+		return types.KeyInfo{Type: s.Type, PrivateKey: s.PrivateKey}, nil
+	}
+
+	if ks.cfg.TransitKey == "" {
+		return types.KeyInfo{}, xerrors.Errorf("key '%s' is encrypted, but no transit key is configured", name)
+	}
+
+	var dresp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	err = ks.request(http.MethodPost, path.Join(ks.cfg.TransitMount, "decrypt", ks.cfg.TransitKey), map[string]string{
+		"ciphertext": s.Ciphertext,
+	}, &dresp)
+	if err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("decrypting key '%s': %w", name, err)
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(dresp.Data.Plaintext)
+	if err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("decoding decrypted key '%s': %w", name, err)
+	}
+	var ki types.KeyInfo
+	if err := json.Unmarshal(plaintext, &ki); err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("decoding decrypted key '%s': %w", name, err)
+	}
+	return ki, nil
+}
+
+// Put saves key info under given name
+func (ks *KeyStore) Put(name string, ki types.KeyInfo) error {
+	p, err := ks.kvPath("data", name)
+	if err != nil {
+		return err
+	}
+
+	s := &secret{Type: ki.Type, PrivateKey: ki.PrivateKey}
+
+	if ks.cfg.TransitKey != "" {
+		plaintext, err := json.Marshal(ki)
+		if err != nil {
+			return xerrors.Errorf("encoding key '%s': %w", name, err)
+		}
+
+		var eresp struct {
+			Data struct {
+				Ciphertext string `json:"ciphertext"`
+			} `json:"data"`
+		}
+		err = ks.request(http.MethodPost, path.Join(ks.cfg.TransitMount, "encrypt", ks.cfg.TransitKey), map[string]string{
+			"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+		}, &eresp)
+		if err != nil {
+			return xerrors.Errorf("encrypting key '%s': %w", name, err)
+		}
+		s = &secret{Ciphertext: eresp.Data.Ciphertext}
+	}
+
+	// check-and-set 0 only writes the secret when it doesn't exist
+	err = ks.request(http.MethodPost, p, map[string]interface{}{
+		"options": map[string]int{"cas": 0},
+		"data":    s,
+	}, nil)
+	var verr *vaultError
+	if xerrors.As(err, &verr) && verr.status == http.StatusBadRequest && strings.Contains(strings.Join(verr.errors, " "), "check-and-set") {
+		return xerrors.Errorf("checking key before put '%s': %w", name, types.ErrKeyExists)
+	}
+	if err != nil {
+		return xerrors.Errorf("writing key '%s': %w", name, err)
+	}
+	return nil
+}
+
+// Delete removes a key from keystore
+func (ks *KeyStore) Delete(name string) error {
+	p, err := ks.kvPath("metadata", name)
+	if err != nil {
+		return err
+	}
+
+	err = ks.request(http.MethodGet, p, nil, nil)
+	if isNotFound(err) {
+		return xerrors.Errorf("checking key before delete '%s': %w", name, types.ErrKeyInfoNotFound)
+	}
+	if err != nil {
+		return xerrors.Errorf("checking key before delete '%s': %w", name, err)
+	}
+
+	// deleting the metadata deletes all the versions of the key, so that it can be put again
+	if err := ks.request(http.MethodDelete, p, nil, nil); err != nil {
+		return xerrors.Errorf("deleting key '%s': %w", name, err)
+	}
+	return nil
+}
+
+var _ types.KeyStore = &KeyStore{}
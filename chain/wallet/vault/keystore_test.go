@@ -0,0 +1,175 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// fakeVault serves the subset of the Vault API used by the keystore: AppRole login, KV version 2
+// and transit, with a transit encryption which is only an encoding.
+type fakeVault struct {
+	lk      sync.Mutex
+	token   string
+	logins  int
+	secrets map[string]json.RawMessage
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.lk.Lock()
+	defer v.lk.Unlock()
+
+	reply := func(status int, out interface{}) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(out)
+	}
+	fail := func(status int, msg string) {
+		reply(status, map[string][]string{"errors": {msg}})
+	}
+
+	p := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if p == "auth/approle/login" {
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["role_id"] != "role" || req["secret_id"] != "secret" {
+			fail(http.StatusBadRequest, "invalid role or secret id")
+			return
+		}
+		v.logins++
+		v.token = fmt.Sprint("token-", v.logins)
+		reply(http.StatusOK, map[string]interface{}{"auth": map[string]string{"client_token": v.token}})
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != v.token {
+		fail(http.StatusForbidden, "permission denied")
+		return
+	}
+
+	switch {
+	case p == "transit/encrypt/key":
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		reply(http.StatusOK, map[string]interface{}{"data": map[string]string{"ciphertext": "vault:v1:" + req["plaintext"]}})
+
+	case p == "transit/decrypt/key":
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		reply(http.StatusOK, map[string]interface{}{"data": map[string]string{"plaintext": strings.TrimPrefix(req["ciphertext"], "vault:v1:")}})
+
+	case p == "secret/metadata/lotus/wallet" && r.URL.Query().Get("list") == "true":
+		var keys []string
+		for k := range v.secrets {
+			keys = append(keys, k)
+		}
+		if len(keys) == 0 {
+			fail(http.StatusNotFound, "")
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{"data": map[string][]string{"keys": keys}})
+
+	case strings.HasPrefix(p, "secret/data/lotus/wallet/"):
+		name := strings.TrimPrefix(p, "secret/data/lotus/wallet/")
+		s, ok := v.secrets[name]
+		switch r.Method {
+		case http.MethodGet:
+			if !ok {
+				fail(http.StatusNotFound, "")
+				return
+			}
+			reply(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"data": s}})
+		case http.MethodPost:
+			var req struct {
+				Options struct{ Cas *int } `json:"options"`
+				Data    json.RawMessage    `json:"data"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if ok && req.Options.Cas != nil && *req.Options.Cas == 0 {
+				fail(http.StatusBadRequest, "check-and-set parameter did not match the current version")
+				return
+			}
+			v.secrets[name] = req.Data
+			reply(http.StatusOK, map[string]interface{}{})
+		}
+
+	case strings.HasPrefix(p, "secret/metadata/lotus/wallet/"):
+		name := strings.TrimPrefix(p, "secret/metadata/lotus/wallet/")
+		if _, ok := v.secrets[name]; !ok {
+			fail(http.StatusNotFound, "")
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(v.secrets, name)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{})
+
+	default:
+		fail(http.StatusNotFound, "no handler for "+p)
+	}
+}
+
+func TestVaultKeyStore(t *testing.T) {
+	fv := &fakeVault{secrets: map[string]json.RawMessage{}}
+	srv := httptest.NewServer(fv)
+	defer srv.Close()
+
+	secretFile := filepath.Join(t.TempDir(), "secret-id")
+	require.NoError(t, os.WriteFile(secretFile, []byte("secret\n"), 0600))
+
+	ks, err := NewKeyStore(Config{
+		Address:             srv.URL,
+		KVMount:             "secret",
+		KVPath:              "lotus/wallet",
+		TransitMount:        "transit",
+		TransitKey:          "key",
+		AppRoleMount:        "approle",
+		AppRoleID:           "role",
+		AppRoleSecretIDFile: secretFile,
+	})
+	require.NoError(t, err)
+
+	names, err := ks.List()
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	ki := types.KeyInfo{Type: types.KTSecp256k1, PrivateKey: []byte("private key")}
+	require.NoError(t, ks.Put("wallet-a", ki))
+	require.ErrorIs(t, ks.Put("wallet-a", ki), types.ErrKeyExists)
+
+	// only the ciphertext is stored
+	require.NotContains(t, string(fv.secrets["wallet-a"]), "secp256k1")
+	require.Contains(t, string(fv.secrets["wallet-a"]), "vault:v1:")
+
+	got, err := ks.Get("wallet-a")
+	require.NoError(t, err)
+	require.Equal(t, ki, got)
+
+	_, err = ks.Get("wallet-b")
+	require.ErrorIs(t, err, types.ErrKeyInfoNotFound)
+
+	// an expired token is renewed with a new login
+	fv.lk.Lock()
+	fv.token = "expired"
+	fv.lk.Unlock()
+
+	names, err = ks.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"wallet-a"}, names)
+	require.Equal(t, 2, fv.logins)
+
+	require.NoError(t, ks.Delete("wallet-a"))
+	require.ErrorIs(t, ks.Delete("wallet-a"), types.ErrKeyInfoNotFound)
+	require.NoError(t, ks.Put("wallet-a", ki))
+}
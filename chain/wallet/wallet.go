@@ -32,6 +32,8 @@ const (
 type LocalWallet struct {
 	keys     map[address.Address]*key.Key
 	keystore types.KeyStore
+	// enc is keystore, which encrypts the keys once the wallet is encrypted
+	enc *encryptedKeyStore
 
 	lk sync.Mutex
 }
@@ -43,8 +45,11 @@ type Default interface {
 
 func NewWallet(keystore types.KeyStore) (*LocalWallet, error) {
 	w := &LocalWallet{
-		keys:     make(map[address.Address]*key.Key),
-		keystore: keystore,
+		keys: make(map[address.Address]*key.Key),
+	}
+	if keystore != nil {
+		w.enc = &encryptedKeyStore{KeyStore: keystore}
+		w.keystore = w.enc
 	}
 
 	return w, nil
@@ -201,6 +206,9 @@ func (w *LocalWallet) GetDefault() (address.Address, error) {
 	defer w.lk.Unlock()
 
 	ki, err := w.keystore.Get(KDefault)
+	if xerrors.Is(err, ErrWalletLocked) {
+		return w.enc.address(KDefault)
+	}
 	if err != nil {
 		return address.Undef, xerrors.Errorf("failed to get default key: %w", err)
 	}
@@ -265,6 +273,10 @@ func (w *LocalWallet) WalletNew(ctx context.Context, typ types.KeyType) (address
 
 func (w *LocalWallet) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
 	k, err := w.findKey(addr)
+	if xerrors.Is(err, ErrWalletLocked) {
+		// the key is in the keystore, encrypted
+		return true, nil
+	}
 	if err != nil {
 		return false, err
 	}
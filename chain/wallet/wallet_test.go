@@ -4,9 +4,14 @@ package wallet
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
@@ -104,3 +109,154 @@ func TestWallet(t *testing.T) {
 	}
 
 }
+
+func TestHDWallet(t *testing.T) {
+	ctx := context.Background()
+
+	w1, err := NewWallet(NewMemKeyStore())
+	require.NoError(t, err)
+
+	_, err = w1.WalletHDDerive(ctx, -1)
+	require.ErrorIs(t, err, ErrNoHDSeed)
+
+	hm, err := w1.WalletHDNew(ctx, 24, "")
+	require.NoError(t, err)
+	mnemonic, k0 := hm.Mnemonic, hm.Key
+	require.Equal(t, "m/44'/461'/0'/0/0", k0.Path)
+
+	def, err := w1.GetDefault()
+	require.NoError(t, err)
+	require.Equal(t, k0.Address, def)
+
+	k1, err := w1.WalletHDDerive(ctx, -1)
+	require.NoError(t, err)
+	require.Equal(t, "m/44'/461'/0'/0/1", k1.Path)
+
+	_, err = w1.WalletHDNew(ctx, 12, "")
+	require.ErrorIs(t, err, ErrHDSeedExists)
+
+	// a wallet restored from the mnemonic has the same addresses
+	w2, err := NewWallet(NewMemKeyStore())
+	require.NoError(t, err)
+
+	restored, err := w2.WalletHDRestore(ctx, mnemonic, "", 2)
+	require.NoError(t, err)
+	require.Equal(t, []api.HDKey{*k0, *k1}, restored)
+
+	has, err := w2.WalletHas(ctx, k1.Address)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// known derivation of the test mnemonic
+	w3, err := NewWallet(NewMemKeyStore())
+	require.NoError(t, err)
+
+	restored, err = w3.WalletHDRestore(ctx, "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "", 2)
+	require.NoError(t, err)
+	expected, err := address.NewFromString("f12nzdrhfh6caurft7gwy6d3uazvgy3lhl7rfzvpq")
+	require.NoError(t, err)
+	require.Equal(t, expected, restored[1].Address)
+}
+
+func TestWalletEncryption(t *testing.T) {
+	ctx := context.Background()
+	scryptN = 1 << 10
+
+	ks := NewMemKeyStore()
+	w, err := NewWallet(ks)
+	require.NoError(t, err)
+
+	a1, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	_, err = w.WalletHDNew(ctx, 12, "")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, w.WalletLock(ctx), ErrWalletNotEncrypted)
+	require.NoError(t, w.WalletEncrypt(ctx, "passphrase"))
+
+	// no wallet key is left in clear
+	names, err := ks.List()
+	require.NoError(t, err)
+	for _, name := range names {
+		ki, err := ks.Get(name)
+		require.NoError(t, err)
+		if name == KEncryption {
+			continue
+		}
+		require.Equal(t, KTEncrypted, ki.Type, name)
+	}
+
+	status, err := w.WalletLockStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, api.LockStatus{Encrypted: true}, *status)
+
+	// a wallet opened on the encrypted keystore starts locked
+	w2, err := NewWallet(ks)
+	require.NoError(t, err)
+
+	_, err = w2.WalletSign(ctx, a1, []byte("msg"), api.MsgMeta{})
+	require.ErrorIs(t, err, ErrWalletLocked)
+	_, err = w2.WalletNew(ctx, types.KTSecp256k1)
+	require.ErrorIs(t, err, ErrWalletLocked)
+
+	// the addresses are known while locked
+	has, err := w2.WalletHas(ctx, a1)
+	require.NoError(t, err)
+	require.True(t, has)
+	def, err := w2.GetDefault()
+	require.NoError(t, err)
+	require.Equal(t, a1, def)
+
+	require.ErrorIs(t, w2.WalletUnlock(ctx, "wrong", 0), ErrWrongPassphrase)
+	require.NoError(t, w2.WalletUnlock(ctx, "passphrase", time.Hour))
+
+	status, err = w2.WalletLockStatus(ctx)
+	require.NoError(t, err)
+	require.False(t, status.Locked)
+	require.False(t, status.LockAt.IsZero())
+
+	_, err = w2.WalletSign(ctx, a1, []byte("msg"), api.MsgMeta{})
+	require.NoError(t, err)
+	_, err = w2.WalletHDDerive(ctx, -1)
+	require.NoError(t, err)
+
+	require.NoError(t, w2.WalletLock(ctx))
+	_, err = w2.WalletSign(ctx, a1, []byte("msg"), api.MsgMeta{})
+	require.ErrorIs(t, err, ErrWalletLocked)
+}
+
+func TestWalletEncryptionBindsNames(t *testing.T) {
+	ctx := context.Background()
+	scryptN = 1 << 10
+
+	ks := NewMemKeyStore()
+	w, err := NewWallet(ks)
+	require.NoError(t, err)
+
+	a1, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	a2, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	require.NoError(t, w.WalletEncrypt(ctx, "passphrase"))
+
+	// swap the encrypted keys of the two addresses in the keystore
+	n1, n2 := KNamePrefix+a1.String(), KNamePrefix+a2.String()
+	k1, err := ks.Get(n1)
+	require.NoError(t, err)
+	k2, err := ks.Get(n2)
+	require.NoError(t, err)
+	require.NoError(t, ks.Delete(n1))
+	require.NoError(t, ks.Delete(n2))
+	require.NoError(t, ks.Put(n1, k2))
+	require.NoError(t, ks.Put(n2, k1))
+
+	w2, err := NewWallet(ks)
+	require.NoError(t, err)
+	require.NoError(t, w2.WalletUnlock(ctx, "passphrase", 0))
+
+	// the swapped keys don't decrypt under their new names
+	_, err = w2.WalletSign(ctx, a1, []byte("msg"), api.MsgMeta{})
+	require.Error(t, err)
+	_, err = w2.WalletSign(ctx, a2, []byte("msg"), api.MsgMeta{})
+	require.Error(t, err)
+}
@@ -65,6 +65,10 @@ var ChainCmd = &cli.Command{
 		ChainEncodeCmd,
 		ChainDisputeSetCmd,
 		ChainPruneCmd,
+		ChainGCCmd,
+		ChainBackfillCmd,
+		ChainBeaconStatusCmd,
+		ChainImportOnlineCmd,
 	},
 }
 
@@ -1162,6 +1166,14 @@ var ChainExportRangeCmd = &cli.Command{
 			Usage: "specify tipset to end the export at (lower epoch)",
 			Value: "@tail",
 		},
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "export the range starting at this epoch (the first tipset at or after it), instead of --tail",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "export the range ending at this epoch (the last tipset at or before it), instead of --head",
+		},
 		&cli.BoolFlag{
 			Name:  "messages",
 			Usage: "specify if messages should be include",
@@ -1202,9 +1214,18 @@ var ChainExportRangeCmd = &cli.Command{
 		defer closer()
 		ctx := ReqContext(cctx)
 
+		if (cctx.IsSet("from") && cctx.IsSet("tail")) || (cctx.IsSet("to") && cctx.IsSet("head")) {
+			return errors.New("--from and --to replace --tail and --head, and cannot be combined with them")
+		}
+
 		var head, tail *types.TipSet
 		headstr := cctx.String("head")
-		if headstr == "@head" {
+		if cctx.IsSet("to") {
+			head, err = api.ChainGetTipSetByHeight(ctx, abi.ChainEpoch(cctx.Int64("to")), types.EmptyTSK)
+			if err != nil {
+				return fmt.Errorf("getting tipset at --to epoch: %w", err)
+			}
+		} else if headstr == "@head" {
 			head, err = api.ChainHead(ctx)
 			if err != nil {
 				return err
@@ -1216,7 +1237,12 @@ var ChainExportRangeCmd = &cli.Command{
 			}
 		}
 		tailstr := cctx.String("tail")
-		if tailstr == "@tail" {
+		if cctx.IsSet("from") {
+			tail, err = api.ChainGetTipSetAfterHeight(ctx, abi.ChainEpoch(cctx.Int64("from")), head.Key())
+			if err != nil {
+				return fmt.Errorf("getting tipset at --from epoch: %w", err)
+			}
+		} else if tailstr == "@tail" {
 			tail, err = api.ChainGetGenesis(ctx)
 			if err != nil {
 				return err
@@ -1589,6 +1615,310 @@ func createExportFile(app *cli.App, path string) (io.WriteCloser, error) {
 	return fi, nil
 }
 
+var ChainGCCmd = &cli.Command{
+	Name:  "gc",
+	Usage: "run online garbage collection and compaction on the chainstore",
+	Description: `Compacts the chainstore and rewrites the badger value log files with more than
+   the threshold fraction of garbage, while the node keeps running. With the splitstore, the
+   hotstore is collected. Use --throttle to pause between value log rewrites and limit the IO
+   load on the node.`,
+	Flags: []cli.Flag{
+		&cli.Float64Flag{
+			Name:  "threshold",
+			Value: 0.01,
+			Usage: "fraction of garbage in a value log file above which it is rewritten",
+		},
+		&cli.DurationFlag{
+			Name:  "throttle",
+			Usage: "pause between value log rewrites",
+		},
+		&cli.BoolFlag{
+			Name:  "detach",
+			Usage: "start gc and return without waiting for it to finish",
+		},
+	},
+	Subcommands: []*cli.Command{
+		chainGCStatusCmd,
+		chainGCCancelCmd,
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainGC(ctx, lapi.ChainGCOpts{
+			Threshold: cctx.Float64("threshold"),
+			Throttle:  cctx.Duration("throttle"),
+		})
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Started chainstore gc (threshold %f, throttle %s)\n", st.Threshold, st.Throttle)
+		if cctx.Bool("detach") {
+			return nil
+		}
+
+		for st.Running {
+			select {
+			case <-ctx.Done():
+				afmt.Println("Stopped waiting; gc continues in the background, see 'lotus chain gc status'")
+				return nil
+			case <-time.After(5 * time.Second):
+			}
+
+			st, err = api.ChainGCStatus(ctx)
+			if err != nil {
+				return err
+			}
+			afmt.Printf("\rcompacted: %t, value log rewrites: %d", st.Compacted, st.Rewrites)
+		}
+		afmt.Println()
+
+		printChainGCStatus(afmt, st)
+		if st.Error != "" {
+			return xerrors.Errorf("chainstore gc failed: %s", st.Error)
+		}
+		return nil
+	},
+}
+
+var chainGCStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "show the progress of the last chainstore gc",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		st, err := api.ChainGCStatus(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		printChainGCStatus(NewAppFmt(cctx.App), st)
+		return nil
+	},
+}
+
+var chainGCCancelCmd = &cli.Command{
+	Name:  "cancel",
+	Usage: "cancel the running chainstore gc",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		_, err = api.ChainGCCancel(ReqContext(cctx))
+		return err
+	},
+}
+
+func printChainGCStatus(afmt *AppFmt, st *lapi.ChainGCStatus) {
+	afmt.Printf("Running: %t\n", st.Running)
+	afmt.Printf("Started: %s\n", st.Started.Format(time.RFC3339))
+	if !st.Finished.IsZero() {
+		afmt.Printf("Finished: %s (took %s)\n", st.Finished.Format(time.RFC3339), st.Finished.Sub(st.Started).Truncate(time.Second))
+	}
+	afmt.Printf("Threshold: %f\n", st.Threshold)
+	afmt.Printf("Throttle: %s\n", st.Throttle)
+	afmt.Printf("Compacted: %t\n", st.Compacted)
+	afmt.Printf("Value log rewrites: %d\n", st.Rewrites)
+	if st.SizeBefore > 0 {
+		afmt.Printf("Size before: %s\n", types.SizeStr(types.NewInt(uint64(st.SizeBefore))))
+	}
+	if st.SizeAfter > 0 {
+		afmt.Printf("Size after: %s\n", types.SizeStr(types.NewInt(uint64(st.SizeAfter))))
+	}
+	if st.Error != "" {
+		afmt.Printf("Error: %s\n", st.Error)
+	}
+}
+
+var ChainBackfillCmd = &cli.Command{
+	Name:  "backfill",
+	Usage: "show the earliest available state and the progress of the archival state backfill",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		st, err := api.StateBackfillStatus(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Earliest state: %d\n", st.EarliestState)
+		afmt.Printf("Backfilling: %t\n", st.Running)
+		if !st.Started.IsZero() {
+			afmt.Printf("Started: %s\n", st.Started.Format(time.RFC3339))
+			afmt.Printf("Backfilled epochs: %d\n", st.Epochs)
+			if elapsed := time.Since(st.Started); st.Running && st.Epochs > 0 && elapsed > 0 {
+				rate := float64(st.Epochs) / elapsed.Seconds()
+				afmt.Printf("Rate: %.2f epochs/s, ETA: %s\n", rate, time.Duration(float64(st.EarliestState)/rate*float64(time.Second)).Truncate(time.Second))
+			}
+		}
+		if st.Error != "" {
+			afmt.Printf("Error: %s\n", st.Error)
+		}
+		return nil
+	},
+}
+
+var ChainBeaconStatusCmd = &cli.Command{
+	Name:  "beacon-status",
+	Usage: "show the drand endpoint groups of the node and which one serves randomness",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		st, err := api.StateBeaconStatus(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		for _, bs := range st {
+			afmt.Printf("Beacon from epoch %d:\n", bs.Start)
+			for i, g := range bs.Groups {
+				active := ""
+				if g.Active {
+					active = " (active)"
+				}
+				afmt.Printf("  Group %d%s: %s\n", i, active, strings.Join(g.Servers, ", "))
+				afmt.Printf("    Failures: %d\n", g.Failures)
+				if g.LastError != "" {
+					afmt.Printf("    Last error: %s: %s\n", g.LastErrorAt.Format(time.RFC3339), g.LastError)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+var ChainImportOnlineCmd = &cli.Command{
+	Name:      "import-online",
+	Usage:     "import a snapshot into the running node",
+	ArgsUsage: "<url or path>",
+	Description: `Imports a snapshot, optionally zstd compressed, from an http(s) URL or a path on the
+   node, and switches the head to the root of the snapshot once its state has been computed.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "detach",
+			Usage: "start the import and return without waiting for it to finish",
+		},
+	},
+	Subcommands: []*cli.Command{
+		chainImportOnlineStatusCmd,
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainImportOnline(ctx, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Started importing %s\n", st.Source)
+		if cctx.Bool("detach") {
+			return nil
+		}
+
+		for st.Running {
+			select {
+			case <-ctx.Done():
+				afmt.Println("Stopped waiting; the import continues in the background, see 'lotus chain import-online status'")
+				return nil
+			case <-time.After(5 * time.Second):
+			}
+
+			st, err = api.ChainImportStatus(ctx)
+			if err != nil {
+				return err
+			}
+			if p := st.Progress; p != nil {
+				afmt.Printf("\r%d blocks, %s read", p.Blocks, types.SizeStr(types.NewInt(uint64(p.Bytes))))
+			}
+		}
+		afmt.Println()
+
+		printChainImportStatus(afmt, st)
+		if st.Error != "" {
+			return xerrors.Errorf("chain import failed: %s", st.Error)
+		}
+		return nil
+	},
+}
+
+var chainImportOnlineStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "show the progress of the last online chain import",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		st, err := api.ChainImportStatus(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		printChainImportStatus(NewAppFmt(cctx.App), st)
+		return nil
+	},
+}
+
+func printChainImportStatus(afmt *AppFmt, st *lapi.ChainImportStatus) {
+	afmt.Printf("Source: %s\n", st.Source)
+	afmt.Printf("Running: %t\n", st.Running)
+	afmt.Printf("Started: %s\n", st.Started.Format(time.RFC3339))
+	if !st.Finished.IsZero() {
+		afmt.Printf("Finished: %s (took %s)\n", st.Finished.Format(time.RFC3339), st.Finished.Sub(st.Started).Truncate(time.Second))
+	}
+	if p := st.Progress; p != nil {
+		afmt.Printf("Blocks: %d (%.0f/s)\n", p.Blocks, p.BlocksPerSec)
+		afmt.Printf("Read: %s (%s/s)\n", types.SizeStr(types.NewInt(uint64(p.Bytes))), types.SizeStr(types.NewInt(uint64(p.BytesPerSec))))
+		if p.Size > 0 {
+			afmt.Printf("Size: %s\n", types.SizeStr(types.NewInt(uint64(p.Size))))
+		}
+		if p.ETA > 0 {
+			afmt.Printf("ETA: %s\n", p.ETA.Truncate(time.Second))
+		}
+	}
+	if !st.TipSet.IsEmpty() {
+		afmt.Printf("Head: %s (%d)\n", st.TipSet, st.Height)
+	}
+	if st.Error != "" {
+		afmt.Printf("Error: %s\n", st.Error)
+	}
+}
+
 var ChainPruneCmd = &cli.Command{
 	Name:  "prune",
 	Usage: "splitstore gc",
@@ -1596,6 +1926,37 @@ var ChainPruneCmd = &cli.Command{
 		chainPruneColdCmd,
 		chainPruneHotGCCmd,
 		chainPruneHotMovingGCCmd,
+		chainPruneStatusCmd,
+	},
+}
+
+var chainPruneStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "show the splitstore compaction and prune status",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		info, err := api.ChainBlockstoreInfo(ctx)
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(info))
+		for k := range info {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		afmt := NewAppFmt(cctx.App)
+		for _, k := range keys {
+			afmt.Printf("%s: %v\n", k, info[k])
+		}
+		return nil
 	},
 }
 
@@ -1,11 +1,15 @@
 package cli
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	stdbig "math/big"
 	"sort"
 	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
@@ -34,6 +38,14 @@ var MpoolCmd = &cli.Command{
 		MpoolFindCmd,
 		MpoolConfig,
 		MpoolGasPerfCmd,
+		MpoolFeeHistoryCmd,
+		MpoolBaseFeeForecastCmd,
+		MpoolGasOracleCmd,
+		MpoolPersistedCmd,
+		MpoolRBFPolicyCmd,
+		MpoolFixNonceCmd,
+		MpoolHistoryCmd,
+		MpoolScheduledCmd,
 		mpoolManage,
 	},
 }
@@ -174,8 +186,22 @@ var MpoolClear = &cli.Command{
 var MpoolSub = &cli.Command{
 	Name:  "sub",
 	Usage: "Subscribe to mpool changes",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "from",
+			Usage: "only show the changes of the messages sent by these addresses",
+		},
+		&cli.StringSliceFlag{
+			Name:  "to",
+			Usage: "only show the changes of the messages sent to these addresses",
+		},
+		&cli.Int64SliceFlag{
+			Name:  "method",
+			Usage: "only show the changes of the messages calling these methods",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -183,14 +209,45 @@ var MpoolSub = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		sub, err := api.MpoolSub(ctx)
+		var f lapi.MpoolUpdateFilter
+		for _, s := range cctx.StringSlice("from") {
+			addr, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing from address: %w", err)
+			}
+			f.From = append(f.From, addr)
+		}
+		for _, s := range cctx.StringSlice("to") {
+			addr, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing to address: %w", err)
+			}
+			f.To = append(f.To, addr)
+		}
+		for _, m := range cctx.Int64Slice("method") {
+			if m < 0 {
+				return xerrors.Errorf("invalid method number %d", m)
+			}
+			f.Methods = append(f.Methods, abi.MethodNum(m))
+		}
+
+		var sub <-chan lapi.MpoolUpdate
+		if len(f.From) == 0 && len(f.To) == 0 && len(f.Methods) == 0 {
+			sub, err = api.MpoolSub(ctx)
+		} else {
+			// filter the updates on the node, rather than receiving all of them
+			sub, err = api.MpoolSubFiltered(ctx, f)
+		}
 		if err != nil {
 			return err
 		}
 
 		for {
 			select {
-			case update := <-sub:
+			case update, ok := <-sub:
+				if !ok {
+					return nil
+				}
 				out, err := json.MarshalIndent(update, "", "  ")
 				if err != nil {
 					return err
@@ -394,7 +451,7 @@ var MpoolReplaceCmd = &cli.Command{
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
 
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -488,6 +545,12 @@ var MpoolReplaceCmd = &cli.Command{
 			}
 
 			msg.GasPremium = big.Max(retm.GasPremium, defaultRBF)
+
+			// the node may require a greater premium from the sender than the network does
+			if p, err := api.MpoolGetRBFPolicy(ctx, msg.From); err == nil {
+				rbf := messagepool.RBFPolicy{MinPercent: p.MinPercent, MinIncrease: p.MinIncrease}
+				msg.GasPremium = big.Max(msg.GasPremium, rbf.MinPremium(found.Message.GasPremium))
+			}
 			msg.GasFeeCap = big.Max(retm.GasFeeCap, msg.GasPremium)
 
 			mff := func() (abi.TokenAmount, error) {
@@ -610,6 +673,225 @@ var MpoolFindCmd = &cli.Command{
 	},
 }
 
+var MpoolPersistedCmd = &cli.Command{
+	Name:  "persisted",
+	Usage: "Manage the local messages persisted by the mpool, which are loaded back on restart",
+	Subcommands: []*cli.Command{
+		MpoolPersistedListCmd,
+		MpoolPersistedClearCmd,
+	},
+}
+
+var MpoolPersistedListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the persisted local messages",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "cids",
+			Usage: "only print cids of messages in output",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		msgs, err := api.MpoolPersistedLocal(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		for _, msg := range msgs {
+			if cctx.Bool("cids") {
+				afmt.Println(msg.Cid())
+				continue
+			}
+
+			out, err := json.MarshalIndent(msg, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(out))
+		}
+
+		return nil
+	},
+}
+
+var MpoolPersistedClearCmd = &cli.Command{
+	Name:  "clear",
+	Usage: "Remove the persisted local messages, leaving the pending messages of the mpool alone",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "must be specified for the action to take effect",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Bool("really-do-it") {
+			return xerrors.Errorf("--really-do-it must be specified for this action to have an effect; you have been warned")
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		removed, err := api.MpoolClearPersistedLocal(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Removed %d persisted messages\n", removed)
+		return nil
+	},
+}
+
+var MpoolRBFPolicyCmd = &cli.Command{
+	Name:      "rbf-policy",
+	Usage:     "Show the replace-by-fee policy the messages of a sender must satisfy",
+	ArgsUsage: "[from]",
+	Action: func(cctx *cli.Context) error {
+		from := address.Undef
+		if cctx.Args().Present() {
+			var err error
+			if from, err = address.NewFromString(cctx.Args().First()); err != nil {
+				return xerrors.Errorf("parsing from address: %w", err)
+			}
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		p, err := api.MpoolGetRBFPolicy(ReqContext(cctx), from)
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Minimum premium: %d%% of the replaced premium\n", p.MinPercent)
+		afmt.Printf("Minimum premium increase: %s attoFIL/gas\n", p.MinIncrease)
+		return nil
+	},
+}
+
+var MpoolFixNonceCmd = &cli.Command{
+	Name:      "fix-nonce",
+	Usage:     "Find the nonce gaps holding back the pending messages of a sender, and optionally fill them",
+	ArgsUsage: "<address>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "fill",
+			Usage: "fill the gaps with zero-value messages from the sender to itself",
+		},
+		&cli.StringFlag{
+			Name:  "gas-feecap",
+			Usage: "gas feecap of the filling messages (attoFIL/GasUnit), estimated when unset",
+		},
+		&cli.StringFlag{
+			Name:  "gas-premium",
+			Usage: "gas premium of the filling messages (attoFIL/GasUnit), estimated when unset",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing address: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ng, err := api.MpoolNonceGaps(ctx, addr)
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("State nonce: %d\n", ng.StateNonce)
+		if len(ng.Gaps) == 0 {
+			afmt.Println("No nonce gaps")
+			return nil
+		}
+
+		var missing []uint64
+		for _, g := range ng.Gaps {
+			afmt.Printf("Gap: nonces %d to %d (%d messages)\n", g.Start, g.End-1, g.End-g.Start)
+			for n := g.Start; n < g.End; n++ {
+				missing = append(missing, n)
+			}
+		}
+
+		if !cctx.Bool("fill") {
+			afmt.Println("Run with --fill to fill the gaps")
+			return nil
+		}
+
+		tmpl, err := api.GasEstimateMessageGas(ctx, &types.Message{
+			From:  addr,
+			To:    addr,
+			Value: big.Zero(),
+		}, nil, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("estimating gas: %w", err)
+		}
+
+		if cctx.IsSet("gas-premium") {
+			if tmpl.GasPremium, err = types.BigFromString(cctx.String("gas-premium")); err != nil {
+				return xerrors.Errorf("parsing gas-premium: %w", err)
+			}
+		}
+		if cctx.IsSet("gas-feecap") {
+			if tmpl.GasFeeCap, err = types.BigFromString(cctx.String("gas-feecap")); err != nil {
+				return xerrors.Errorf("parsing gas-feecap: %w", err)
+			}
+		}
+		if tmpl.GasFeeCap.LessThan(tmpl.GasPremium) {
+			return xerrors.Errorf("gas feecap %s is lower than the gas premium %s", tmpl.GasFeeCap, tmpl.GasPremium)
+		}
+
+		smsgs := make([]*types.SignedMessage, 0, len(missing))
+		for _, n := range missing {
+			msg := *tmpl
+			msg.Nonce = n
+
+			smsg, err := api.WalletSignMessage(ctx, addr, &msg)
+			if err != nil {
+				return xerrors.Errorf("signing message with nonce %d: %w", n, err)
+			}
+			smsgs = append(smsgs, smsg)
+		}
+
+		// the batch is pushed atomically, so a failure leaves no new gaps behind
+		cids, err := api.MpoolBatchPush(ctx, smsgs)
+		if err != nil {
+			return xerrors.Errorf("pushing the filling messages: %w", err)
+		}
+
+		for i, c := range cids {
+			afmt.Printf("Nonce %d: %s\n", smsgs[i].Message.Nonce, c)
+		}
+		return nil
+	},
+}
+
 var MpoolConfig = &cli.Command{
 	Name:      "config",
 	Usage:     "get or set current mpool configuration",
@@ -657,6 +939,154 @@ var MpoolConfig = &cli.Command{
 	},
 }
 
+var MpoolFeeHistoryCmd = &cli.Command{
+	Name:  "fee-history",
+	Usage: "Show the base fees and gas premium percentiles of recent tipsets",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "tipsets",
+			Usage: "number of recent tipsets",
+			Value: 20,
+		},
+		&cli.StringFlag{
+			Name:  "percentiles",
+			Usage: "comma-separated gas premium percentiles, weighted by gas limit, in ascending order",
+			Value: "25,50,75",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		var percentiles []float64
+		var header []string
+		for _, ps := range strings.Split(cctx.String("percentiles"), ",") {
+			p, err := strconv.ParseFloat(strings.TrimSpace(ps), 64)
+			if err != nil {
+				return xerrors.Errorf("parsing percentile: %w", err)
+			}
+			percentiles = append(percentiles, p)
+			header = append(header, fmt.Sprintf("P%v", p))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		h, err := api.GasFeeHistory(ReqContext(cctx), cctx.Int("tipsets"), percentiles)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Epoch\tBaseFee\tGasLimitRatio\t%s\n", strings.Join(header, "\t"))
+		for i, epoch := range h.Epochs {
+			premiums := make([]string, 0, len(h.Premiums[i]))
+			for _, p := range h.Premiums[i] {
+				premiums = append(premiums, p.String())
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%.3f\t%s\n", epoch, h.BaseFees[i], h.GasLimitRatios[i], strings.Join(premiums, "\t"))
+		}
+		return tw.Flush()
+	},
+}
+
+var MpoolBaseFeeForecastCmd = &cli.Command{
+	Name:  "basefee-forecast",
+	Usage: "Project the base fee over the next epochs from the recent trend of block fullness",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "epochs",
+			Usage: "number of epochs to project the base fee over",
+			Value: 20,
+		},
+		&cli.IntFlag{
+			Name:  "window",
+			Usage: "number of recent tipsets the trend of block fullness is computed over",
+			Value: 20,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		f, err := api.GasBaseFeeForecast(ReqContext(cctx), cctx.Int("epochs"), cctx.Int("window"))
+		if err != nil {
+			return err
+		}
+
+		lowest := 0
+		for i, bf := range f.BaseFees {
+			if bf.LessThan(f.BaseFees[lowest]) {
+				lowest = i
+			}
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Epoch\tBaseFee\tGasPerBlock\t")
+		for i, epoch := range f.Epochs {
+			mark := ""
+			if i == lowest {
+				mark = "lowest"
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\t%s\n", epoch, f.BaseFees[i], f.GasPerBlock[i], mark)
+		}
+		return tw.Flush()
+	},
+}
+
+var MpoolGasOracleCmd = &cli.Command{
+	Name:  "gas-oracle",
+	Usage: "Recommend gas premiums for a low, medium and high inclusion speed from recent tipsets",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "window",
+			Usage: "number of recent tipsets the recommendations are computed over",
+			Value: 20,
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the recommendations as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		o, err := api.GasPriceOracle(ReqContext(cctx), cctx.Int("window"))
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		if cctx.Bool("json") {
+			b, err := json.MarshalIndent(o, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(b))
+			return nil
+		}
+
+		afmt.Printf("Epoch: %d, next base fee: %s\n", o.Epoch, types.FIL(o.BaseFee))
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Speed\tGasPremium\tExpected Delay (epochs)")
+		for _, r := range []struct {
+			speed string
+			rec   lapi.GasPremiumRecommendation
+		}{{"low", o.Low}, {"medium", o.Medium}, {"high", o.High}} {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%.1f\n", r.speed, r.rec.GasPremium, r.rec.InclusionDelay)
+		}
+		return tw.Flush()
+	},
+}
+
 var MpoolGasPerfCmd = &cli.Command{
 	Name:  "gas-perf",
 	Usage: "Check gas performance of messages in mempool",
@@ -741,3 +1171,193 @@ var MpoolGasPerfCmd = &cli.Command{
 		return nil
 	},
 }
+
+var MpoolHistoryCmd = &cli.Command{
+	Name:      "history",
+	Usage:     "Show the messages pushed through the node from a sender, with their replacements and outcome on chain",
+	ArgsUsage: "<address>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the history as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing address: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		records, err := api.MpoolHistory(ReqContext(cctx), addr)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			b, err := json.MarshalIndent(records, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt := NewAppFmt(cctx.App)
+			afmt.Println(string(b))
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Nonce\tCid\tMethod\tValue\tPremium\tPushed\tStatus\tEpoch\tExit\tGas Used")
+		for _, r := range records {
+			for i, a := range r.Attempts {
+				status, epoch, exit, gasUsed := "pending", "", "", ""
+				switch {
+				case r.Outcome != nil && a.Cid == r.Outcome.Cid:
+					status = "landed"
+					epoch = fmt.Sprint(r.Outcome.Epoch)
+					exit = r.Outcome.ExitCode.String()
+					gasUsed = fmt.Sprint(r.Outcome.GasUsed)
+				case r.Outcome != nil:
+					status = "dropped"
+				case i < len(r.Attempts)-1:
+					status = "replaced"
+				}
+
+				m := a.Message.Message
+				_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					r.Nonce, a.Cid, m.Method, types.FIL(m.Value), m.GasPremium, a.Pushed.Format(time.RFC3339),
+					status, epoch, exit, gasUsed)
+			}
+
+			// the nonce was used by a message not pushed through this node
+			switch {
+			case r.Outcome == nil || r.Landed() != nil:
+			case r.Outcome.Cid.Defined():
+				_, _ = fmt.Fprintf(tw, "%d\t%s\t\t\t\t\tlanded\t%d\t%s\t%d\n",
+					r.Nonce, r.Outcome.Cid, r.Outcome.Epoch, r.Outcome.ExitCode, r.Outcome.GasUsed)
+			default:
+				_, _ = fmt.Fprintf(tw, "%d\tunknown\t\t\t\t\tlanded\t\t\t\n", r.Nonce)
+			}
+		}
+		return tw.Flush()
+	},
+}
+
+var MpoolScheduledCmd = &cli.Command{
+	Name:  "scheduled",
+	Usage: "Manage the signed messages scheduled for submission at an epoch",
+	Subcommands: []*cli.Command{
+		MpoolScheduledListCmd,
+		MpoolScheduledAddCmd,
+		MpoolScheduledCancelCmd,
+	},
+}
+
+var MpoolScheduledListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the scheduled messages",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		scheduled, err := api.MpoolScheduled(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Epoch\tCid\tFrom\tNonce\tTo\tMethod\tValue\tLast Error")
+		for _, sm := range scheduled {
+			m := sm.Message.Message
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%d\t%s\t%s\n",
+				sm.Epoch, sm.Cid, m.From, m.Nonce, m.To, m.Method, types.FIL(m.Value), sm.LastError)
+		}
+		return tw.Flush()
+	},
+}
+
+var MpoolScheduledAddCmd = &cli.Command{
+	Name:      "add",
+	Usage:     "Schedule a signed message for submission at or after an epoch",
+	ArgsUsage: "<epoch> <signed message as json or hex encoded cbor>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		epoch, err := strconv.ParseInt(cctx.Args().Get(0), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing epoch: %w", err)
+		}
+
+		var sm *types.SignedMessage
+		if arg := strings.TrimSpace(cctx.Args().Get(1)); strings.HasPrefix(arg, "{") {
+			if err := json.Unmarshal([]byte(arg), &sm); err != nil {
+				return xerrors.Errorf("decoding json signed message: %w", err)
+			}
+		} else {
+			b, err := hex.DecodeString(arg)
+			if err != nil {
+				return xerrors.Errorf("decoding hex signed message: %w", err)
+			}
+			if sm, err = types.DecodeSignedMessage(b); err != nil {
+				return xerrors.Errorf("decoding cbor signed message: %w", err)
+			}
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		c, err := api.MpoolSchedule(ReqContext(cctx), sm, abi.ChainEpoch(epoch))
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Scheduled message %s at epoch %d\n", c, epoch)
+		return nil
+	},
+}
+
+var MpoolScheduledCancelCmd = &cli.Command{
+	Name:      "cancel",
+	Usage:     "Cancel a scheduled message",
+	ArgsUsage: "<message cid>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		c, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing message cid: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := api.MpoolCancelScheduled(ReqContext(cctx), c); err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Cancelled scheduled message %s\n", c)
+		return nil
+	},
+}
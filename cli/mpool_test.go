@@ -303,6 +303,10 @@ func TestReplace(t *testing.T) {
 			// use gomock.any to match the message in expected api calls
 			// since the replace function modifies the message between calls, it would be pointless to try to match the exact argument
 			mockApi.EXPECT().GasEstimateMessageGas(ctx, gomock.Any(), &mss, types.EmptyTSK).Return(&sm.Message, nil),
+			mockApi.EXPECT().MpoolGetRBFPolicy(ctx, sm.Message.From).Return(&api.RBFPolicy{
+				MinPercent:  messagepool.ReplaceByFeePercentageMinimum,
+				MinIncrease: abi.NewTokenAmount(0),
+			}, nil),
 			mockApi.EXPECT().WalletSignMessage(ctx, sm.Message.From, gomock.Any()).Return(sm, nil),
 			mockApi.EXPECT().MpoolPush(ctx, sm).Return(sm.Cid(), nil),
 		)
@@ -348,6 +352,10 @@ func TestReplace(t *testing.T) {
 			// use gomock.any to match the message in expected api calls
 			// since the replace function modifies the message between calls, it would be pointless to try to match the exact argument
 			mockApi.EXPECT().GasEstimateMessageGas(ctx, gomock.Any(), &mss, types.EmptyTSK).Return(&sm.Message, nil),
+			mockApi.EXPECT().MpoolGetRBFPolicy(ctx, sm.Message.From).Return(&api.RBFPolicy{
+				MinPercent:  messagepool.ReplaceByFeePercentageMinimum,
+				MinIncrease: abi.NewTokenAmount(0),
+			}, nil),
 			mockApi.EXPECT().WalletSignMessage(ctx, sm.Message.From, gomock.Any()).Return(sm, nil),
 			mockApi.EXPECT().MpoolPush(ctx, sm).Return(sm.Cid(), nil),
 		)
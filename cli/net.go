@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -25,6 +26,7 @@ import (
 	atypes "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/addrutil"
+	"github.com/filecoin-project/lotus/node/modules/tracer"
 )
 
 var NetCmd = &cli.Command{
@@ -47,6 +49,7 @@ var NetCmd = &cli.Command{
 		NetProtectAdd,
 		NetProtectRemove,
 		NetProtectList,
+		NetPubsubTraceCmd,
 	},
 }
 
@@ -209,6 +212,10 @@ var NetScores = &cli.Command{
 			Aliases: []string{"x"},
 			Usage:   "print extended peer scores in json",
 		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "export the score table in csv, lowest scores first; topic deliveries are first/mesh/invalid",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetAPI(cctx)
@@ -222,6 +229,38 @@ var NetScores = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("csv") {
+			sort.Slice(scores, func(i, j int) bool {
+				return scores[i].Score.Score < scores[j].Score.Score
+			})
+
+			w := csv.NewWriter(cctx.App.Writer)
+			if err := w.Write([]string{"peer", "score", "app_specific", "ip_colocation", "behaviour_penalty", "topic_deliveries"}); err != nil {
+				return err
+			}
+			for _, peer := range scores {
+				topics := make([]string, 0, len(peer.Score.Topics))
+				for topic, ts := range peer.Score.Topics {
+					topics = append(topics, fmt.Sprintf("%s:%f/%f/%f", topic, ts.FirstMessageDeliveries, ts.MeshMessageDeliveries, ts.InvalidMessageDeliveries))
+				}
+				sort.Strings(topics)
+
+				err := w.Write([]string{
+					peer.ID.String(),
+					fmt.Sprintf("%f", peer.Score.Score),
+					fmt.Sprintf("%f", peer.Score.AppSpecificScore),
+					fmt.Sprintf("%f", peer.Score.IPColocationFactor),
+					fmt.Sprintf("%f", peer.Score.BehaviourPenalty),
+					strings.Join(topics, ";"),
+				})
+				if err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		}
+
 		if cctx.Bool("extended") {
 			enc := json.NewEncoder(os.Stdout)
 			for _, peer := range scores {
@@ -243,6 +282,16 @@ var NetScores = &cli.Command{
 var NetListen = &cli.Command{
 	Name:  "listen",
 	Usage: "List listen addresses",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "transport",
+			Usage: fmt.Sprintf("only list the addresses of a transport (%s)", strings.Join(addrutil.Transports, ", ")),
+		},
+		&cli.BoolFlag{
+			Name:  "by-transport",
+			Usage: "group the addresses by transport",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetAPI(cctx)
 		if err != nil {
@@ -256,7 +305,33 @@ var NetListen = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("by-transport") {
+			byTransport := map[string][]string{}
+			for _, addr := range addrs.Addrs {
+				t := addrutil.Transport(addr)
+				if t == "" {
+					t = "other"
+				}
+				byTransport[t] = append(byTransport[t], fmt.Sprintf("%s/p2p/%s", addr, addrs.ID))
+			}
+
+			transports := append([]string{}, addrutil.Transports...)
+			for _, t := range append(transports, "other") {
+				if len(byTransport[t]) == 0 {
+					continue
+				}
+				fmt.Printf("%s:\n", t)
+				for _, addr := range byTransport[t] {
+					fmt.Printf("  %s\n", addr)
+				}
+			}
+			return nil
+		}
+
 		for _, peer := range addrs.Addrs {
+			if t := cctx.String("transport"); t != "" && addrutil.Transport(peer) != t {
+				continue
+			}
 			fmt.Printf("%s/p2p/%s\n", peer, addrs.ID)
 		}
 		return nil
@@ -448,6 +523,24 @@ var NetReachability = &cli.Command{
 		if i.PublicAddr != "" {
 			fmt.Println("Public address: ", i.PublicAddr)
 		}
+
+		// addresses reserved on relays by the relay client, when enabled
+		addrs, err := api.NetAddrsListen(ctx)
+		if err != nil {
+			return err
+		}
+		var relayed []string
+		for _, addr := range addrs.Addrs {
+			if _, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT); err == nil {
+				relayed = append(relayed, addr.String())
+			}
+		}
+		if len(relayed) > 0 {
+			fmt.Println("Relay addresses:")
+			for _, addr := range relayed {
+				fmt.Println("  ", addr)
+			}
+		}
 		return nil
 	},
 }
@@ -1026,3 +1119,104 @@ var NetProtectList = &cli.Command{
 		return nil
 	},
 }
+
+var NetPubsubTraceCmd = &cli.Command{
+	Name:  "pubsub-trace",
+	Usage: "Manage the trace of pubsub message deliveries, duplicates and rejections (full node only)",
+	Subcommands: []*cli.Command{
+		netPubsubTraceStatusCmd,
+		netPubsubTraceStartCmd,
+		netPubsubTraceStopCmd,
+	},
+}
+
+var netPubsubTraceStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Show the status of the pubsub delivery trace",
+	Action: func(cctx *cli.Context) error {
+		return netPubsubTrace(cctx, func(ctx context.Context, api atypes.FullNode) (*tracer.DeliveryTraceStatus, error) {
+			return api.NetPubsubTraceStatus(ctx)
+		})
+	},
+}
+
+var netPubsubTraceStartCmd = &cli.Command{
+	Name:  "start",
+	Usage: "Start tracing pubsub deliveries to a file or a remote collector",
+	Description: `Writes the deliveries, duplicates and rejections of the pubsub messages of all topics as
+   JSON lines, either to a file rotated as it grows, or POSTed in batches to an http(s) endpoint.
+   The trace replaces the current one, and lasts until stopped or until the node restarts.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "file",
+			Usage: "path of the trace file on the node",
+		},
+		&cli.Int64Flag{
+			Name:  "max-size",
+			Usage: "size in bytes at which the trace file is rotated",
+			Value: tracer.DefaultDeliveryTraceMaxFileSize,
+		},
+		&cli.IntFlag{
+			Name:  "max-files",
+			Usage: "number of rotated trace files kept",
+			Value: tracer.DefaultDeliveryTraceMaxFiles,
+		},
+		&cli.StringFlag{
+			Name:  "endpoint",
+			Usage: "URL of a collector to post the trace to",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		cfg := tracer.DeliveryTraceConfig{
+			File:        cctx.String("file"),
+			MaxFileSize: cctx.Int64("max-size"),
+			MaxFiles:    cctx.Int("max-files"),
+			Endpoint:    cctx.String("endpoint"),
+		}
+		return netPubsubTrace(cctx, func(ctx context.Context, api atypes.FullNode) (*tracer.DeliveryTraceStatus, error) {
+			return api.NetPubsubTraceStart(ctx, cfg)
+		})
+	},
+}
+
+var netPubsubTraceStopCmd = &cli.Command{
+	Name:  "stop",
+	Usage: "Stop the pubsub delivery trace",
+	Action: func(cctx *cli.Context) error {
+		return netPubsubTrace(cctx, func(ctx context.Context, api atypes.FullNode) (*tracer.DeliveryTraceStatus, error) {
+			return api.NetPubsubTraceStop(ctx)
+		})
+	},
+}
+
+func netPubsubTrace(cctx *cli.Context, call func(context.Context, atypes.FullNode) (*tracer.DeliveryTraceStatus, error)) error {
+	api, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	st, err := call(ReqContext(cctx), api)
+	if err != nil {
+		return err
+	}
+
+	afmt := NewAppFmt(cctx.App)
+	if !st.Enabled {
+		afmt.Println("Tracing: no")
+		return nil
+	}
+
+	afmt.Println("Tracing: yes")
+	if st.Config.File != "" {
+		afmt.Printf("File: %s (rotated every %s, keeping %d)\n", st.Config.File, types.SizeStr(types.NewInt(uint64(st.Config.MaxFileSize))), st.Config.MaxFiles)
+	} else {
+		afmt.Printf("Endpoint: %s\n", st.Config.Endpoint)
+	}
+	afmt.Printf("Since: %s\n", st.Since.Format(time.RFC3339))
+	afmt.Printf("Events: %d written, %d dropped, %d failed\n", st.Written, st.Dropped, st.Failed)
+	if st.LastError != "" {
+		afmt.Printf("Last error: %s\n", st.LastError)
+	}
+	return nil
+}
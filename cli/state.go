@@ -63,6 +63,7 @@ var StateCmd = &cli.Command{
 		StateListActorsCmd,
 		StateListMinersCmd,
 		StateCircSupplyCmd,
+		StateCircSupplyRangeCmd,
 		StateSectorCmd,
 		StateGetActorCmd,
 		StateLookupIDCmd,
@@ -72,15 +73,39 @@ var StateCmd = &cli.Command{
 		StateListMessagesCmd,
 		StateComputeStateCmd,
 		StateCallCmd,
+		StateCallBatchCmd,
 		StateGetDealSetCmd,
 		StateWaitMsgCmd,
 		StateSearchMsgCmd,
 		StateMinerInfo,
 		StateMarketCmd,
 		StateExecTraceCmd,
+		StateGasProfileCmd,
+		StateCronTraceCmd,
+		StateGasStatsCmd,
 		StateNtwkVersionCmd,
 		StateMinerProvingDeadlineCmd,
 		StateSysActorCIDsCmd,
+		StatePurgeCacheCmd,
+	},
+}
+
+var StatePurgeCacheCmd = &cli.Command{
+	Name:  "purge-cache",
+	Usage: "Drop the tipset states kept in the persistent state cache",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if err := api.StatePurgePersistentCache(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Persistent state cache purged")
+		return nil
 	},
 }
 
@@ -546,6 +571,26 @@ var StateReplayCmd = &cli.Command{
 			Name:  "detailed-gas",
 			Usage: "print out detailed gas costs for given message",
 		},
+		&cli.BoolFlag{
+			Name:  "trace-frames",
+			Usage: "print out the execution trace as call frames, with decoded params and returns",
+		},
+		&cli.Int64Flag{
+			Name:  "gas-limit",
+			Usage: "replay the message with the given gas limit",
+		},
+		&cli.StringFlag{
+			Name:  "gas-feecap",
+			Usage: "replay the message with the given gas fee cap, in attoFIL",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "replay the message with the given value, in FIL",
+		},
+		&cli.StringFlag{
+			Name:  "params",
+			Usage: "replay the message with the given hex-encoded params",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 1 {
@@ -557,7 +602,7 @@ var StateReplayCmd = &cli.Command{
 			return fmt.Errorf("message cid was invalid: %s", err)
 		}
 
-		fapi, closer, err := GetFullNodeAPI(cctx)
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -565,9 +610,53 @@ var StateReplayCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		res, err := fapi.StateReplay(ctx, types.EmptyTSK, mcid)
-		if err != nil {
-			return xerrors.Errorf("replay call failed: %w", err)
+		var opts api.ReplayOptions
+		if cctx.Bool("trace-frames") {
+			opts.TraceFormat = api.TraceFormatFrames
+		}
+		if cctx.IsSet("gas-limit") || cctx.IsSet("gas-feecap") || cctx.IsSet("value") || cctx.IsSet("params") {
+			opts.Overrides = new(api.MessageOverrides)
+		}
+		if cctx.IsSet("gas-limit") {
+			gasLimit := cctx.Int64("gas-limit")
+			opts.Overrides.GasLimit = &gasLimit
+		}
+		if cctx.IsSet("gas-feecap") {
+			feeCap, err := types.BigFromString(cctx.String("gas-feecap"))
+			if err != nil {
+				return xerrors.Errorf("parsing gas fee cap: %w", err)
+			}
+			opts.Overrides.GasFeeCap = &feeCap
+		}
+		if cctx.IsSet("value") {
+			value, err := types.ParseFIL(cctx.String("value"))
+			if err != nil {
+				return xerrors.Errorf("parsing value: %w", err)
+			}
+			v := abi.TokenAmount(value)
+			opts.Overrides.Value = &v
+		}
+		if cctx.IsSet("params") {
+			params, err := hex.DecodeString(cctx.String("params"))
+			if err != nil {
+				return xerrors.Errorf("decoding params: %w", err)
+			}
+			opts.Overrides.Params = params
+		}
+
+		var res *api.InvocResult
+		var frames []api.TraceFrame
+		if opts != (api.ReplayOptions{}) {
+			rr, err := fapi.StateReplayEx(ctx, types.EmptyTSK, mcid, opts)
+			if err != nil {
+				return xerrors.Errorf("replay call failed: %w", err)
+			}
+			res, frames = rr.InvocResult, rr.Frames
+		} else {
+			res, err = fapi.StateReplay(ctx, types.EmptyTSK, mcid)
+			if err != nil {
+				return xerrors.Errorf("replay call failed: %w", err)
+			}
 		}
 
 		fmt.Println("Replay receipt:")
@@ -593,10 +682,159 @@ var StateReplayCmd = &cli.Command{
 			printInternalExecutions("\t", res.ExecutionTrace.Subcalls)
 		}
 
+		for _, f := range frames {
+			params, ret := string(f.ParamsDecoded), string(f.ReturnDecoded)
+			if params == "" {
+				params = fmt.Sprintf("%x", f.Params)
+			}
+			if ret == "" {
+				ret = fmt.Sprintf("%x", f.Return)
+			}
+			fmt.Printf("%s%s\t%s\t%s\t%d\t%s\t%d\t%s\t%d\n", strings.Repeat("\t", f.Depth), f.From, f.To, f.Value, f.Method, params, f.ExitCode, ret, f.GasCharged)
+		}
+
+		return nil
+	},
+}
+
+var StateGasProfileCmd = &cli.Command{
+	Name:  "gas-profile",
+	Usage: "Break the gas charged while executing a tipset down by actor and method",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "only print the given number of most expensive entries",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		profile, err := api.StateGasProfile(ctx, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		entries := profile.Entries
+		if top := cctx.Int("top"); top > 0 && top < len(entries) {
+			entries = entries[:top]
+		}
+
+		fmt.Printf("Total gas charged: %d\n", profile.TotalGasCharged)
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "\nActor\tMethod\tCalls\tGas\tShare\t")
+		for _, e := range entries {
+			actor, method := "<deleted>", fmt.Sprint(e.Method)
+			if e.Code.Defined() {
+				actor = builtin.ActorNameByCode(e.Code)
+				if name := getMethod(e.Code, e.Method); name != "" {
+					method = name
+				}
+			}
+			var share float64
+			if profile.TotalGasCharged > 0 {
+				share = float64(e.GasCharged) * 100 / float64(profile.TotalGasCharged)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.2f%%\t\n", actor, method, e.Calls, e.GasCharged, share)
+		}
+		return tw.Flush()
+	},
+}
+
+var StateCronTraceCmd = &cli.Command{
+	Name:  "cron-trace",
+	Usage: "Get the execution traces of the cron ticks run while executing a tipset",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "show-trace",
+			Usage: "print out the full execution trace of each tick",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		ticks, err := api.StateCronTrace(ctx, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		for i, tick := range ticks {
+			fmt.Printf("Tick %d: exit code %d, gas used %d, %d calls, took %s\n", i, tick.MsgRct.ExitCode, tick.MsgRct.GasUsed, len(tick.ExecutionTrace.Subcalls), tick.Duration)
+			if tick.Error != "" {
+				fmt.Printf("\tError: %s\n", tick.Error)
+			}
+			if cctx.Bool("show-trace") {
+				printInternalExecutions("\t", tick.ExecutionTrace.Subcalls)
+			}
+		}
 		return nil
 	},
 }
 
+var StateGasStatsCmd = &cli.Command{
+	Name:  "gas-stats",
+	Usage: "Print the gas usage and fee statistics of a range of tipsets",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first epoch of the range; defaults to the epoch of the last tipset",
+			Value: -1,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		from := abi.ChainEpoch(cctx.Int64("from"))
+		if from < 0 {
+			from = ts.Height()
+		}
+
+		stats, err := api.StateGasStats(ctx, from, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Height\tBlocks\tMessages\tGas Used\tGas Limit\tUtilization\tOverestimation\tBase Fee\t")
+		for _, st := range stats {
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%.2f%%\t%.2f\t%s\t\n", st.Height, st.Blocks, st.Messages, st.GasUsed, st.GasLimit, st.Utilization*100, st.OverEstimation, types.FIL(st.BaseFee).Short())
+		}
+		return tw.Flush()
+	},
+}
+
 var StateGetDealSetCmd = &cli.Command{
 	Name:      "get-deal",
 	Usage:     "View on-chain deal info",
@@ -1039,6 +1277,10 @@ var StateComputeStateCmd = &cli.Command{
 			Name:  "show-trace",
 			Usage: "print out full execution trace for given tipset",
 		},
+		&cli.BoolFlag{
+			Name:  "show-diff",
+			Usage: "print out the actors changed by the applied messages",
+		},
 		&cli.BoolFlag{
 			Name:  "html",
 			Usage: "generate html report",
@@ -1057,7 +1299,7 @@ var StateComputeStateCmd = &cli.Command{
 		},
 	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -1088,6 +1330,7 @@ var StateComputeStateCmd = &cli.Command{
 		}
 
 		var stout *lapi.ComputeStateOutput
+		var changes []lapi.ActorChange
 		if csofile := cctx.String("compute-state-output"); csofile != "" {
 			data, err := os.ReadFile(csofile)
 			if err != nil {
@@ -1100,6 +1343,13 @@ var StateComputeStateCmd = &cli.Command{
 			}
 
 			stout = &o
+		} else if cctx.Bool("show-diff") {
+			o, err := api.StateComputeWithDiff(ctx, h, msgs, ts.Key())
+			if err != nil {
+				return err
+			}
+
+			stout, changes = &o.ComputeStateOutput, o.Changes
 		} else {
 			o, err := api.StateCompute(ctx, h, msgs, ts.Key())
 			if err != nil {
@@ -1151,6 +1401,18 @@ var StateComputeStateCmd = &cli.Command{
 				printInternalExecutions("\t", ir.ExecutionTrace.Subcalls)
 			}
 		}
+		if cctx.Bool("show-diff") {
+			for _, c := range changes {
+				switch {
+				case c.Old == nil:
+					fmt.Printf("%s\tcreated\tnonce %d\tbalance %s\n", c.Address, c.New.Nonce, types.FIL(c.New.Balance))
+				case c.New == nil:
+					fmt.Printf("%s\tdeleted\n", c.Address)
+				default:
+					fmt.Printf("%s\tmodified\tnonce %d -> %d\tbalance %s -> %s\n", c.Address, c.Old.Nonce, c.New.Nonce, types.FIL(c.Old.Balance), types.FIL(c.New.Balance))
+				}
+			}
+		}
 		return nil
 	},
 }
@@ -1557,13 +1819,30 @@ var StateCallCmd = &cli.Command{
 			Value: "base64",
 			Usage: "specify params encoding to parse (base64, hex)",
 		},
+		&cli.StringSliceFlag{
+			Name:  "override-balance",
+			Usage: "override the balance of an actor for the call, as <address>=<FIL>",
+		},
+		&cli.StringSliceFlag{
+			Name:  "override-nonce",
+			Usage: "override the nonce of an actor for the call, as <address>=<nonce>",
+		},
+		&cli.StringSliceFlag{
+			Name:  "override-head",
+			Usage: "override the state head of an actor for the call, as <address>=<cid>",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() < 2 {
 			return ShowHelp(cctx, fmt.Errorf("must specify at least actor and method to invoke"))
 		}
 
-		api, closer, err := GetFullNodeAPI(cctx)
+		overrides, err := parseActorOverrides(cctx)
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -1615,13 +1894,20 @@ var StateCallCmd = &cli.Command{
 			}
 		}
 
-		ret, err := api.StateCall(ctx, &types.Message{
+		msg := &types.Message{
 			From:   froma,
 			To:     toa,
 			Value:  types.BigInt(value),
 			Method: abi.MethodNum(method),
 			Params: params,
-		}, ts.Key())
+		}
+
+		var ret *lapi.InvocResult
+		if len(overrides) > 0 {
+			ret, err = api.StateCallWithOverrides(ctx, msg, ts.Key(), overrides)
+		} else {
+			ret, err = api.StateCall(ctx, msg, ts.Key())
+		}
 		if err != nil {
 			return fmt.Errorf("state call failed: %w", err)
 		}
@@ -1659,6 +1945,93 @@ var StateCallCmd = &cli.Command{
 	},
 }
 
+var StateCallBatchCmd = &cli.Command{
+	Name:      "call-batch",
+	Usage:     "Invoke a sequence of messages locally, each observing the effects of the previous ones",
+	ArgsUsage: "<messages JSON file>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		b, err := os.ReadFile(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("reading messages: %w", err)
+		}
+		var msgs []*types.Message
+		if err := json.Unmarshal(b, &msgs); err != nil {
+			return xerrors.Errorf("decoding messages: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		res, err := api.StateCallBatch(ctx, msgs, ts.Key())
+		if err != nil {
+			return fmt.Errorf("state call failed: %w", err)
+		}
+
+		for i, r := range res {
+			fmt.Printf("%d: exit code %d, gas used %d, return %x\n", i, r.MsgRct.ExitCode, r.MsgRct.GasUsed, r.MsgRct.Return)
+			if r.Error != "" {
+				fmt.Printf("\terror: %s\n", r.Error)
+			}
+		}
+		return nil
+	},
+}
+
+// parseActorOverrides parses the --override-* flags of the call command.
+func parseActorOverrides(cctx *cli.Context) (map[address.Address]lapi.ActorOverride, error) {
+	overrides := make(map[address.Address]lapi.ActorOverride)
+	for _, flag := range []string{"override-balance", "override-nonce", "override-head"} {
+		for _, o := range cctx.StringSlice(flag) {
+			as, vs, ok := strings.Cut(o, "=")
+			if !ok {
+				return nil, xerrors.Errorf("invalid --%s %q: expected <address>=<value>", flag, o)
+			}
+			addr, err := address.NewFromString(as)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid --%s address %q: %w", flag, as, err)
+			}
+
+			ov := overrides[addr]
+			switch flag {
+			case "override-balance":
+				bal, err := types.ParseFIL(vs)
+				if err != nil {
+					return nil, xerrors.Errorf("invalid --%s balance %q: %w", flag, vs, err)
+				}
+				ov.Balance = (*abi.TokenAmount)(&bal)
+			case "override-nonce":
+				nonce, err := strconv.ParseUint(vs, 10, 64)
+				if err != nil {
+					return nil, xerrors.Errorf("invalid --%s nonce %q: %w", flag, vs, err)
+				}
+				ov.Nonce = &nonce
+			case "override-head":
+				head, err := cid.Decode(vs)
+				if err != nil {
+					return nil, xerrors.Errorf("invalid --%s head %q: %w", flag, vs, err)
+				}
+				ov.Head = &head
+			}
+			overrides[addr] = ov
+		}
+	}
+	return overrides, nil
+}
+
 var StateCircSupplyCmd = &cli.Command{
 	Name:  "circulating-supply",
 	Usage: "Get the exact current circulating supply of Filecoin",
@@ -1708,6 +2081,49 @@ var StateCircSupplyCmd = &cli.Command{
 	},
 }
 
+var StateCircSupplyRangeCmd = &cli.Command{
+	Name:  "circulating-supply-range",
+	Usage: "Get the approximation of the circulating supply used internally by the VM over a range of epochs",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:     "from",
+			Usage:    "first epoch of the range",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:  "interval",
+			Usage: "number of epochs between samples",
+			Value: int64(builtin.EpochsInDay),
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		supply, err := api.StateCirculatingSupplyRange(ctx, abi.ChainEpoch(cctx.Int64("from")), ts.Key(), abi.ChainEpoch(cctx.Int64("interval")))
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Height\tCirculating\tMined\tVested\tBurnt\tLocked\t")
+		for _, cs := range supply {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t\n", cs.Height, types.FIL(cs.FilCirculating), types.FIL(cs.FilMined), types.FIL(cs.FilVested), types.FIL(cs.FilBurnt), types.FIL(cs.FilLocked))
+		}
+		return tw.Flush()
+	},
+}
+
 var StateSectorCmd = &cli.Command{
 	Name:      "sector",
 	Aliases:   []string{"sector-info"},
@@ -3,16 +3,20 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
@@ -35,7 +39,7 @@ var SyncStatusCmd = &cli.Command{
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
 
-		apic, closer, err := GetFullNodeAPI(cctx)
+		apic, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -69,6 +73,15 @@ var SyncStatusCmd = &cli.Command{
 			afmt.Printf("\tHeight diff:\t%d\n", heightDiff)
 			afmt.Printf("\tStage: %s\n", ss.Stage)
 			afmt.Printf("\tHeight: %d\n", ss.Height)
+			if ss.EpochsPerSec > 0 {
+				afmt.Printf("\tRate: %.2f epochs/s\n", ss.EpochsPerSec)
+			}
+			if ss.ETA > 0 {
+				afmt.Printf("\tETA: %s\n", ss.ETA.Round(time.Second))
+			}
+			if ss.ValidationTime+ss.ExecutionTime > 0 {
+				afmt.Printf("\tValidation: %s; Execution: %s\n", ss.ValidationTime.Round(time.Millisecond), ss.ExecutionTime.Round(time.Millisecond))
+			}
 			if ss.End.IsZero() {
 				if !ss.Start.IsZero() {
 					afmt.Printf("\tElapsed: %s\n", time.Since(ss.Start))
@@ -80,6 +93,30 @@ var SyncStatusCmd = &cli.Command{
 				afmt.Printf("\tError: %s\n", ss.Message)
 			}
 		}
+
+		progress, err := apic.SyncComputeProgress(ctx)
+		if err != nil {
+			return err
+		}
+		if len(progress) > 0 {
+			afmt.Println("executing:")
+			for _, p := range progress {
+				afmt.Printf("\t%s (%d): %d/%d messages, %d gas, %s\n", p.TipSet, p.Height, p.MessagesApplied, p.MessagesTotal, p.GasUsed, time.Since(p.Started).Round(time.Millisecond))
+			}
+		}
+
+		imp, err := apic.SyncImportProgress(ctx)
+		if err != nil {
+			return err
+		}
+		if imp != nil && imp.Running {
+			afmt.Println("importing:")
+			afmt.Printf("\t%d blocks, %s read (%s/s)", imp.Blocks, types.SizeStr(types.NewInt(uint64(imp.Bytes))), types.SizeStr(types.NewInt(uint64(imp.BytesPerSec))))
+			if imp.ETA > 0 {
+				afmt.Printf(", ETA %s", imp.ETA.Round(time.Second))
+			}
+			afmt.Println()
+		}
 		return nil
 	},
 }
@@ -213,6 +250,12 @@ var SyncCheckpointCmd = &cli.Command{
 			Usage: "checkpoint the tipset at the given epoch",
 		},
 	},
+	Subcommands: []*cli.Command{
+		syncCheckpointAddCmd,
+		syncCheckpointListCmd,
+		syncCheckpointRemoveCmd,
+		syncCheckpointExportCmd,
+	},
 	Action: func(cctx *cli.Context) error {
 		napi, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -245,6 +288,186 @@ var SyncCheckpointCmd = &cli.Command{
 	},
 }
 
+var syncCheckpointAddCmd = &cli.Command{
+	Name:  "add",
+	Usage: "add trusted checkpoints; the node will refuse to sync chains not going through them",
+	Description: `Adds the tipset given by its block cids, or the tipset of the current chain at --epoch,
+   to the trusted checkpoints. A tipset which the node doesn't have yet, such as one ahead of
+   its head, can be added by passing both its block cids and --epoch. With --file, all the
+   checkpoints of a checkpoints file are added.`,
+	ArgsUsage: "[blockCid ...]",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "epoch",
+			Usage: "epoch of the checkpoint",
+		},
+		&cli.StringFlag{
+			Name:  "file",
+			Usage: "add the checkpoints listed in a checkpoints file",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		var cps []api.TrustedCheckpoint
+		if cctx.IsSet("file") {
+			if cctx.Args().Present() {
+				return xerrors.Errorf("--file can't be used with block cids")
+			}
+
+			fcps, err := store.ReadCheckpointsFile(cctx.String("file"))
+			if err != nil {
+				return err
+			}
+			cps = fcps
+		} else {
+			cp, err := parseTrustedCheckpoint(cctx)
+			if err != nil {
+				return err
+			}
+			cps = []api.TrustedCheckpoint{cp}
+		}
+
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := napi.SyncAddTrustedCheckpoints(ReqContext(cctx), cps); err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		for _, cp := range cps {
+			afmt.Printf("Trusting %s at epoch %d\n", cp.TipSet, cp.Epoch)
+		}
+		return nil
+	},
+}
+
+func parseTrustedCheckpoint(cctx *cli.Context) (api.TrustedCheckpoint, error) {
+	switch {
+	case cctx.Args().Present() && cctx.IsSet("epoch"):
+		var cids []cid.Cid
+		for _, s := range cctx.Args().Slice() {
+			c, err := cid.Parse(s)
+			if err != nil {
+				return api.TrustedCheckpoint{}, xerrors.Errorf("parsing block cid %q: %w", s, err)
+			}
+			cids = append(cids, c)
+		}
+		return api.TrustedCheckpoint{Epoch: abi.ChainEpoch(cctx.Int64("epoch")), TipSet: types.NewTipSetKey(cids...)}, nil
+	case cctx.Args().Present() || cctx.IsSet("epoch"):
+		napi, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return api.TrustedCheckpoint{}, err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var ts *types.TipSet
+		if cctx.IsSet("epoch") {
+			ts, err = napi.ChainGetTipSetByHeight(ctx, abi.ChainEpoch(cctx.Int64("epoch")), types.EmptyTSK)
+		} else {
+			ts, err = parseTipSet(ctx, napi, cctx.Args().Slice())
+		}
+		if err != nil {
+			return api.TrustedCheckpoint{}, err
+		}
+		if cctx.IsSet("epoch") && ts.Height() != abi.ChainEpoch(cctx.Int64("epoch")) {
+			return api.TrustedCheckpoint{}, xerrors.Errorf("epoch %d is a null round", cctx.Int64("epoch"))
+		}
+		return api.TrustedCheckpoint{Epoch: ts.Height(), TipSet: ts.Key()}, nil
+	default:
+		return api.TrustedCheckpoint{}, xerrors.Errorf("must pass the block cids of the checkpoint, --epoch or --file")
+	}
+}
+
+var syncCheckpointListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the trusted checkpoints",
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		cps, err := napi.SyncTrustedCheckpoints(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		for _, cp := range cps {
+			afmt.Printf("%d: %s\n", cp.Epoch, cp.TipSet)
+		}
+		return nil
+	},
+}
+
+var syncCheckpointRemoveCmd = &cli.Command{
+	Name:      "remove",
+	Usage:     "remove the trusted checkpoint at an epoch",
+	ArgsUsage: "<epoch>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		epoch, err := strconv.ParseInt(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing epoch: %w", err)
+		}
+
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return napi.SyncRemoveTrustedCheckpoint(ReqContext(cctx), abi.ChainEpoch(epoch))
+	},
+}
+
+var syncCheckpointExportCmd = &cli.Command{
+	Name:      "export",
+	Usage:     "write the trusted checkpoints to a checkpoints file",
+	ArgsUsage: "[outputPath]",
+	Description: `Writes the trusted checkpoints as a checkpoints file, to the standard output if no path
+   is given. The file can be loaded by other nodes with 'lotus sync checkpoint add --file' or
+   the Chainstore.CheckpointsFile config option.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		cps, err := napi.SyncTrustedCheckpoints(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		if !cctx.Args().Present() {
+			return store.WriteCheckpoints(cctx.App.Writer, cps)
+		}
+
+		f, err := os.Create(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		if err := store.WriteCheckpoints(f, cps); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	},
+}
+
 func SyncWait(ctx context.Context, napi v0api.FullNode, watch bool) error {
 	tick := time.Second / 4
 
@@ -321,6 +544,16 @@ func SyncWait(ctx context.Context, napi v0api.FullNode, watch bool) error {
 		fmt.Printf("State: %s; Current Epoch: %d; Todo: %d\n", ss.Stage, ss.Height, theight-ss.Height)
 		lastLines = 2
 
+		if ss.EpochsPerSec > 0 {
+			eta := "unknown"
+			if ss.ETA > 0 {
+				eta = ss.ETA.Round(time.Second).String()
+			}
+			fmt.Printf("Rate: %.2f epochs/s; ETA: %s; Validation: %s; Execution: %s\n", ss.EpochsPerSec, eta,
+				ss.ValidationTime.Round(time.Second), ss.ExecutionTime.Round(time.Second))
+			lastLines++
+		}
+
 		if i%samples == 0 {
 			lastApp = app
 			app = state.VMApplied - firstApp
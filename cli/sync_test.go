@@ -40,11 +40,33 @@ func TestSyncStatus(t *testing.T) {
 			Start:    start,
 			End:      end,
 			Message:  "whatever",
+
+			EpochsPerSec:   2.5,
+			ETA:            90 * time.Second,
+			ValidationTime: 3 * time.Second,
+			ExecutionTime:  12 * time.Second,
 		}},
 		VMApplied: 0,
 	}
 
 	mockApi.EXPECT().SyncState(ctx).Return(state, nil)
+	mockApi.EXPECT().SyncComputeProgress(ctx).Return([]api.ComputeProgress{{
+		TipSet:          ts2.Key(),
+		Height:          ts2.Height(),
+		Started:         start,
+		MessagesApplied: 3,
+		MessagesTotal:   10,
+		GasUsed:         1234,
+	}}, nil)
+	mockApi.EXPECT().SyncImportProgress(ctx).Return(&api.ImportProgress{
+		Running:      true,
+		Started:      start,
+		Blocks:       100,
+		Bytes:        2048,
+		BytesPerSec:  1024,
+		BlocksPerSec: 50,
+		ETA:          30 * time.Second,
+	}, nil)
 
 	//stm: @CLI_SYNC_STATUS_001
 	err := app.Run([]string{"sync", "status"})
@@ -59,6 +81,11 @@ func TestSyncStatus(t *testing.T) {
 	assert.Contains(t, out, "Stage: message sync")
 	assert.Contains(t, out, "Height: 0")
 	assert.Contains(t, out, "Elapsed: 1m0s")
+	assert.Contains(t, out, "Rate: 2.50 epochs/s")
+	assert.Contains(t, out, "ETA: 1m30s")
+	assert.Contains(t, out, "Validation: 3s; Execution: 12s")
+	assert.Contains(t, out, fmt.Sprintf("%s (%d): 3/10 messages, 1234 gas", ts2.Key(), ts2.Height()))
+	assert.Contains(t, out, "100 blocks, 2 KiB read (1 KiB/s), ETA 30s")
 }
 
 func TestSyncMarkBad(t *testing.T) {
@@ -38,6 +38,10 @@ var walletCmd = &cli.Command{
 		walletVerify,
 		walletDelete,
 		walletMarket,
+		walletHD,
+		walletEncrypt,
+		walletUnlock,
+		walletLock,
 	},
 }
 
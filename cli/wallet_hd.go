@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var walletHD = &cli.Command{
+	Name:  "hd",
+	Usage: "Manage the addresses derived from a mnemonic (BIP39 seed phrase, m/44'/461'/0'/0/index paths)",
+	Subcommands: []*cli.Command{
+		walletHDNew,
+		walletHDDerive,
+		walletHDRestore,
+	},
+}
+
+var walletHDPassphraseFlag = &cli.BoolFlag{
+	Name:  "passphrase",
+	Usage: "prompt for a passphrase protecting the mnemonic",
+}
+
+var walletHDNew = &cli.Command{
+	Name:  "new",
+	Usage: "Generate a mnemonic as the HD seed of the wallet, and add its first address",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "words",
+			Usage: "number of words of the mnemonic: 12, 15, 18, 21 or 24",
+			Value: 24,
+		},
+		walletHDPassphraseFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		passphrase, err := walletHDPassphrase(cctx, bufio.NewReader(cctx.App.Reader))
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		hm, err := api.WalletHDNew(ReqContext(cctx), cctx.Int("words"), passphrase)
+		if err != nil {
+			return err
+		}
+
+		afmt.Println("Write down the mnemonic below and keep it safe: it isn't stored, and it's the only")
+		afmt.Println("way to restore the addresses derived from it.")
+		afmt.Println()
+		afmt.Println(hm.Mnemonic)
+		afmt.Println()
+		afmt.Printf("%s\t%s\n", hm.Key.Address, hm.Key.Path)
+		return nil
+	},
+}
+
+var walletHDDerive = &cli.Command{
+	Name:      "derive",
+	Usage:     "Add an address derived from the HD seed of the wallet",
+	ArgsUsage: "[index (optional, the lowest index not in the wallet if omitted)]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		index := int64(-1)
+		if cctx.Args().Present() {
+			i, err := strconv.ParseUint(cctx.Args().First(), 10, 31)
+			if err != nil {
+				return xerrors.Errorf("parsing index: %w", err)
+			}
+			index = int64(i)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		k, err := api.WalletHDDerive(ReqContext(cctx), index)
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("%s\t%s\n", k.Address, k.Path)
+		return nil
+	},
+}
+
+var walletHDRestore = &cli.Command{
+	Name:  "restore",
+	Usage: "Restore the addresses derived from a mnemonic, read from stdin",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "count",
+			Usage: "number of addresses to restore, from index 0",
+			Value: 5,
+		},
+		walletHDPassphraseFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		// a single reader, as it may buffer the passphrase after the mnemonic
+		reader := bufio.NewReader(cctx.App.Reader)
+
+		afmt.Print("Enter mnemonic: ")
+		mnemonic, err := reader.ReadString('\n')
+		if err != nil {
+			return xerrors.Errorf("reading mnemonic: %w", err)
+		}
+
+		passphrase, err := walletHDPassphrase(cctx, reader)
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		keys, err := api.WalletHDRestore(ReqContext(cctx), strings.TrimSpace(mnemonic), passphrase, cctx.Int("count"))
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			afmt.Printf("%s\t%s\n", k.Address, k.Path)
+		}
+		return nil
+	},
+}
+
+func walletHDPassphrase(cctx *cli.Context, reader *bufio.Reader) (string, error) {
+	if !cctx.Bool("passphrase") {
+		return "", nil
+	}
+
+	afmt := NewAppFmt(cctx.App)
+	afmt.Print("Enter passphrase: ")
+	passphrase, err := reader.ReadString('\n')
+	if err != nil {
+		return "", xerrors.Errorf("reading passphrase: %w", err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(passphrase, "\n"), "\r"), nil
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bufio"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var walletEncrypt = &cli.Command{
+	Name:  "encrypt",
+	Usage: "Encrypt the keys of the wallet with a passphrase, read from stdin",
+	Description: `Encrypts the keys stored in the keystore of the wallet, which is then unlocked until
+'lotus wallet lock' is run or the node restarts. A locked wallet can't sign messages nor add keys;
+unlock it with 'lotus wallet unlock'. An interrupted encryption is resumed by running the command
+again with the same passphrase.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 0 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		reader := bufio.NewReader(cctx.App.Reader)
+
+		passphrase, err := readPassphrase(cctx, reader, "Enter passphrase: ")
+		if err != nil {
+			return err
+		}
+		confirm, err := readPassphrase(cctx, reader, "Confirm passphrase: ")
+		if err != nil {
+			return err
+		}
+		if passphrase != confirm {
+			return xerrors.Errorf("passphrases don't match")
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		if err := api.WalletEncrypt(ctx, passphrase); err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Println("The wallet keys are encrypted. The wallet stays unlocked until locked or restarted.")
+		return nil
+	},
+}
+
+var walletUnlock = &cli.Command{
+	Name:  "unlock",
+	Usage: "Unlock the encrypted keys of the wallet with the passphrase, read from stdin",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "lock the wallet again after this long, 0 to keep it unlocked until locked",
+			Value: 15 * time.Minute,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 0 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		passphrase, err := readPassphrase(cctx, bufio.NewReader(cctx.App.Reader), "Enter passphrase: ")
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		return api.WalletUnlock(ctx, passphrase, cctx.Duration("timeout"))
+	},
+}
+
+var walletLock = &cli.Command{
+	Name:  "lock",
+	Usage: "Lock the encrypted keys of the wallet",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "status",
+			Usage: "print whether the wallet is encrypted and locked instead",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 0 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		if !cctx.Bool("status") {
+			return api.WalletLock(ctx)
+		}
+
+		status, err := api.WalletLockStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		switch {
+		case !status.Encrypted:
+			afmt.Println("not encrypted")
+		case status.Locked:
+			afmt.Println("locked")
+		case status.LockAt.IsZero():
+			afmt.Println("unlocked")
+		default:
+			afmt.Printf("unlocked until %s\n", status.LockAt.Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+func readPassphrase(cctx *cli.Context, reader *bufio.Reader, prompt string) (string, error) {
+	afmt := NewAppFmt(cctx.App)
+	afmt.Print(prompt)
+	passphrase, err := reader.ReadString('\n')
+	if err != nil {
+		return "", xerrors.Errorf("reading passphrase: %w", err)
+	}
+	passphrase = strings.TrimSuffix(strings.TrimSuffix(passphrase, "\n"), "\r")
+	if passphrase == "" {
+		return "", xerrors.Errorf("empty passphrase")
+	}
+	return passphrase, nil
+}
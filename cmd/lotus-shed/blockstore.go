@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ipfs/boxo/bitswap"
+	bsnet "github.com/ipfs/boxo/bitswap/network"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p"
+	routinghelpers "github.com/libp2p/go-libp2p-routing-helpers"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/addrutil"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var blockstoreCmd = &cli.Command{
+	Name:        "blockstore",
+	Description: "blockstore utilities",
+	Subcommands: []*cli.Command{
+		blockstoreScrubCmd,
+	},
+}
+
+var blockstoreScrubCmd = &cli.Command{
+	Name:  "scrub",
+	Usage: "verify the integrity of the blockstore of a repo (requires node to be offline)",
+	Description: `Walks the chain from the given tipset down to the genesis, reading all the block headers
+and messages, and the state trees and receipts of the recent tipsets, and verifies that each
+block hashes to its CID. Missing and corrupted blocks are listed.
+
+With --repair, missing and corrupted blocks are fetched from the network over bitswap, from
+the peers given with --peer or else the bootstrap peers of the network, and written back to
+the blockstore.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+		&cli.StringFlag{
+			Name:  "tipset",
+			Usage: "tipset to scrub from, defaults to the head of the repo",
+		},
+		&cli.Int64Flag{
+			Name:  "state-epochs",
+			Usage: "number of epochs whose state trees and receipts are verified, -1 for all",
+			Value: int64(build.Finality),
+		},
+		&cli.BoolFlag{
+			Name:  "repair",
+			Usage: "fetch missing and corrupted blocks from the network",
+		},
+		&cli.StringSliceFlag{
+			Name:  "peer",
+			Usage: "multiaddr of a peer to fetch blocks from with --repair",
+		},
+		&cli.DurationFlag{
+			Name:  "fetch-timeout",
+			Usage: "how long to try fetching each block with --repair",
+			Value: time.Minute,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return xerrors.Errorf("opening fs repo: %w", err)
+		}
+
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("lotus repo doesn't exist")
+		}
+
+		lr, err := r.Lock(repo.FullNode)
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		cfg, err := lr.Config()
+		if err != nil {
+			return xerrors.Errorf("error getting config: %w", err)
+		}
+		fncfg, ok := cfg.(*config.FullNode)
+		if !ok {
+			return xerrors.Errorf("wrong config type: %T", cfg)
+		}
+
+		bs, err := lr.Blockstore(ctx, repo.UniversalBlockstore)
+		if err != nil {
+			return xerrors.Errorf("failed to open blockstore: %w", err)
+		}
+		defer closeBlockstore(bs)
+
+		if fncfg.Chainstore.EnableSplitstore {
+			// recent blocks are in the hotstore
+			hot, err := lr.Blockstore(ctx, repo.HotBlockstore)
+			if err != nil {
+				return xerrors.Errorf("failed to open hotstore: %w", err)
+			}
+			defer closeBlockstore(hot)
+
+			bs = bstore.Union(hot, bs)
+		}
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return err
+		}
+
+		cs := store.NewChainStore(bs, bs, mds, nil, nil)
+		defer cs.Close() //nolint:errcheck
+
+		if err := cs.Load(ctx); err != nil {
+			return err
+		}
+
+		ts, err := lcli.ParseTipSetRefOffline(ctx, cs, cctx.String("tipset"))
+		if err != nil {
+			return err
+		}
+
+		opts := store.ScrubOptions{
+			StateEpochs: abi.ChainEpoch(cctx.Int64("state-epochs")),
+			OnBad: func(c cid.Cid, corrupted, repaired bool) {
+				status := "missing"
+				if corrupted {
+					status = "corrupted"
+				}
+				if repaired {
+					status += ", repaired"
+				}
+				fmt.Printf("%s: %s\n", c, status)
+			},
+		}
+
+		if cctx.Bool("repair") {
+			fetch, closer, err := bitswapFetcher(ctx, cctx.StringSlice("peer"), cctx.Duration("fetch-timeout"))
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			opts.Fetch = fetch
+		}
+
+		fmt.Printf("scrubbing the blockstore from %s at epoch %d\n", ts.Key(), ts.Height())
+		res, err := cs.Scrub(ctx, bs, ts, opts)
+		fmt.Printf("checked %d blocks: %d missing, %d corrupted, %d repaired\n", res.Checked, res.Missing, res.Corrupted, res.Repaired)
+		if err != nil {
+			return xerrors.Errorf("scrub failed: %w", err)
+		}
+
+		if res.Missing+res.Corrupted > res.Repaired {
+			return xerrors.Errorf("the blockstore has %d unrepaired blocks", res.Missing+res.Corrupted-res.Repaired)
+		}
+		return nil
+	},
+}
+
+// bitswapFetcher starts a libp2p host connected to the given peers, or to the bootstrap peers of
+// the network if there are none, and returns a function fetching blocks from them over the chain
+// bitswap protocol.
+func bitswapFetcher(ctx context.Context, peers []string, timeout time.Duration) (func(context.Context, cid.Cid) (blocks.Block, error), func(), error) {
+	var pis []peer.AddrInfo
+	var err error
+	if len(peers) > 0 {
+		pis, err = addrutil.ParseAddresses(ctx, peers)
+	} else {
+		pis, err = build.BuiltinBootstrap()
+	}
+	if err != nil {
+		return nil, nil, xerrors.Errorf("parsing peer addresses: %w", err)
+	}
+	if len(pis) == 0 {
+		return nil, nil, xerrors.Errorf("no peers to fetch blocks from")
+	}
+
+	h, err := libp2p.New()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("starting libp2p host: %w", err)
+	}
+
+	var connected int
+	for _, pi := range pis {
+		if err := h.Connect(ctx, pi); err != nil {
+			log.Warnw("failed to connect to peer", "peer", pi.ID, "error", err)
+			continue
+		}
+		connected++
+	}
+	if connected == 0 {
+		_ = h.Close()
+		return nil, nil, xerrors.Errorf("failed to connect to any peer")
+	}
+
+	// same protocol as the node, see modules.ChainBitswap
+	network := bsnet.NewFromIpfsHost(h, routinghelpers.Null{}, bsnet.Prefix("/chain"))
+	exch := bitswap.New(ctx, network, bstore.NewMemorySync(), bitswap.ProvideEnabled(false))
+
+	fetch := func(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return exch.GetBlock(ctx, c)
+	}
+	closer := func() {
+		_ = exch.Close()
+		_ = h.Close()
+	}
+	return fetch, closer, nil
+}
+
+func closeBlockstore(bs bstore.Blockstore) {
+	if c, ok := bs.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Warnf("failed to close blockstore: %s", err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+	"github.com/filecoin-project/test-vectors/schema"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/conformance"
+)
+
+var execMsgCmd = &cli.Command{
+	Name:  "exec-msg",
+	Usage: "execute a single message against a state root exported to a CAR file, without a chainstore",
+	Description: `Loads the blocks of the given CAR file (e.g. produced by 'lotus chain export-range' or
+'lotus-shed export-car') in memory and applies the message, given either as the CID of a message in
+the CAR file or as the path of a JSON-encoded message, at the given state root and epoch.
+Randomness is fixed, and lookback state is the given state root, so only messages which don't
+depend on them reproduce exactly.`,
+	ArgsUsage: "[stateRootCid epoch messageCid|messageJsonFile]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "car",
+			Usage:    "path of the CAR file holding the state",
+			Required: true,
+		},
+		&cli.UintFlag{
+			Name:     "network-version",
+			Usage:    "network version to execute the message at",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "base-fee",
+			Usage: "base fee, in attoFIL",
+			Value: "100",
+		},
+		&cli.StringFlag{
+			Name:  "circulating-supply",
+			Usage: "circulating supply, in attoFIL",
+			Value: "0",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := context.TODO()
+
+		if cctx.NArg() != 3 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		root, err := cid.Decode(cctx.Args().Get(0))
+		if err != nil {
+			return xerrors.Errorf("parsing state root: %w", err)
+		}
+		epoch, err := strconv.ParseInt(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing epoch: %w", err)
+		}
+		baseFee, err := types.BigFromString(cctx.String("base-fee"))
+		if err != nil {
+			return xerrors.Errorf("parsing base fee: %w", err)
+		}
+		circSupply, err := types.BigFromString(cctx.String("circulating-supply"))
+		if err != nil {
+			return xerrors.Errorf("parsing circulating supply: %w", err)
+		}
+
+		bs := blockstore.NewMemory()
+		if err := loadCarInto(ctx, bs, cctx.String("car")); err != nil {
+			return err
+		}
+
+		msg, err := loadExecMessage(ctx, bs, cctx.Args().Get(2))
+		if err != nil {
+			return err
+		}
+
+		driver := conformance.NewDriver(ctx, schema.Selector{}, conformance.DriverOpts{})
+		ret, postRoot, err := driver.ExecuteMessage(bs, conformance.ExecuteMessageParams{
+			Preroot:        root,
+			Epoch:          abi.ChainEpoch(epoch),
+			Message:        msg,
+			CircSupply:     circSupply,
+			BaseFee:        baseFee,
+			NetworkVersion: network.Version(cctx.Uint("network-version")),
+		})
+		if err != nil {
+			return xerrors.Errorf("executing message: %w", err)
+		}
+
+		out := struct {
+			MsgCid         cid.Cid
+			PostStateRoot  cid.Cid
+			Receipt        types.MessageReceipt
+			Error          string `json:",omitempty"`
+			ExecutionTrace types.ExecutionTrace
+		}{
+			MsgCid:         msg.Cid(),
+			PostStateRoot:  postRoot,
+			Receipt:        ret.MessageReceipt,
+			ExecutionTrace: ret.ExecutionTrace,
+		}
+		if ret.ActorErr != nil {
+			out.Error = ret.ActorErr.Error()
+		}
+
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+func loadCarInto(ctx context.Context, bs blockstore.Blockstore, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return xerrors.Errorf("opening the car file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	cr, err := car.NewCarReader(f)
+	if err != nil {
+		return xerrors.Errorf("reading the car file: %w", err)
+	}
+	for {
+		blk, err := cr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return xerrors.Errorf("reading the car file: %w", err)
+		}
+		if err := bs.Put(ctx, blk); err != nil {
+			return xerrors.Errorf("put %s: %w", blk.Cid(), err)
+		}
+	}
+}
+
+// loadExecMessage loads the message with the given CID from bs or, if arg isn't a CID, decodes
+// the JSON-encoded message in the file at arg.
+func loadExecMessage(ctx context.Context, bs blockstore.Blockstore, arg string) (*types.Message, error) {
+	if mcid, err := cid.Decode(arg); err == nil {
+		blk, err := bs.Get(ctx, mcid)
+		if err != nil {
+			return nil, xerrors.Errorf("loading message %s from the car file: %w", mcid, err)
+		}
+		if smsg, err := types.DecodeSignedMessage(blk.RawData()); err == nil {
+			return &smsg.Message, nil
+		}
+		return types.DecodeMessage(blk.RawData())
+	}
+
+	b, err := os.ReadFile(arg)
+	if err != nil {
+		return nil, xerrors.Errorf("reading message file: %w", err)
+	}
+	var msg types.Message
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return nil, xerrors.Errorf("decoding message file: %w", err)
+	}
+	return &msg, nil
+}
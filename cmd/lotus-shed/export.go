@@ -58,6 +58,23 @@ var exportChainCmd = &cli.Command{
 		&cli.BoolFlag{
 			Name: "skip-old-msgs",
 		},
+		&cli.BoolFlag{
+			Name:  "include-receipts",
+			Usage: "include the message receipts of the tipsets whose state roots are exported",
+		},
+		&cli.StringFlag{
+			Name:  "diff-from",
+			Usage: "only export the blocks added since the snapshot taken at this tipset, with the same parameters",
+		},
+		&cli.BoolFlag{
+			Name:  "lite",
+			Usage: "export a minimal snapshot with the headers, the messages and receipts of the last --recent-tipsets epochs, and the state trees of the last --recent-stateroots epochs (default 1)",
+		},
+		&cli.Int64Flag{
+			Name:  "recent-tipsets",
+			Usage: "number of recent tipsets whose messages and receipts are included in a --lite export",
+			Value: 2880,
+		},
 	},
 	Subcommands: []*cli.Command{
 		exportRawCmd,
@@ -133,7 +150,35 @@ var exportChainCmd = &cli.Command{
 			nroots = ts.Height() + 1
 		}
 
-		if err := cs.Export(ctx, ts, nroots, skipoldmsgs, fi); err != nil {
+		if cctx.Bool("lite") {
+			if fullstate || skipoldmsgs || cctx.IsSet("diff-from") || cctx.IsSet("include-receipts") {
+				return xerrors.Errorf("--lite cannot be combined with --full-state, --skip-old-msgs, --include-receipts or --diff-from")
+			}
+			if !cctx.IsSet("recent-stateroots") {
+				nroots = 1
+			}
+
+			if err := cs.ExportLite(ctx, ts, abi.ChainEpoch(cctx.Int64("recent-tipsets")), nroots, fi); err != nil {
+				return xerrors.Errorf("lite export failed: %w", err)
+			}
+
+			return nil
+		}
+
+		if cctx.IsSet("diff-from") {
+			base, err := lcli.ParseTipSetRefOffline(ctx, cs, cctx.String("diff-from"))
+			if err != nil {
+				return xerrors.Errorf("parsing --diff-from: %w", err)
+			}
+
+			if err := cs.ExportDiff(ctx, ts, base, nroots, skipoldmsgs, fi); err != nil {
+				return xerrors.Errorf("differential export failed: %w", err)
+			}
+
+			return nil
+		}
+
+		if err := cs.ExportWithReceipts(ctx, ts, nroots, skipoldmsgs, cctx.Bool("include-receipts"), fi); err != nil {
 			return xerrors.Errorf("export failed: %w", err)
 		}
 
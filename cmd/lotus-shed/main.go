@@ -71,12 +71,14 @@ func main() {
 		minerPeeridCmd,
 		minerMultisigsCmd,
 		splitstoreCmd,
+		blockstoreCmd,
 		fr32Cmd,
 		chainCmd,
 		balancerCmd,
 		sendCsvCmd,
 		terminationsCmd,
 		migrationsCmd,
+		migrateNvCmd,
 		diffCmd,
 		itestdCmd,
 		msigCmd,
@@ -84,6 +86,7 @@ func main() {
 		invariantsCmd,
 		gasTraceCmd,
 		replayOfflineCmd,
+		execMsgCmd,
 		msgindexCmd,
 		FevmAnalyticsCmd,
 		mismatchesCmd,
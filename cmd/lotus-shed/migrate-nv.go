@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	mutil "github.com/filecoin-project/go-state-types/migration"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	lbuiltin "github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/vm"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/repo"
+	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
+)
+
+var migrateNvCmd = &cli.Command{
+	Name:  "migrate-nv",
+	Usage: "run the next scheduled network upgrade migration against the current head state",
+	Description: `Runs the state migration of the next network upgrade in the node's upgrade schedule, as if
+the upgrade happened right after the current head, and reports how long it took, the peak heap
+usage and the actors it changed. The migrated state is written to memory only, so the host needs
+enough memory to hold it on top of the migration itself.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+		&cli.BoolFlag{
+			Name:     "dry-run",
+			Usage:    "don't persist the migrated state; currently required",
+			Required: true,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := context.TODO()
+
+		if cctx.NArg() != 0 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+		if !cctx.Bool("dry-run") {
+			return xerrors.Errorf("only dry runs are supported")
+		}
+
+		fsrepo, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return err
+		}
+		lkrepo, err := fsrepo.Lock(repo.FullNode)
+		if err != nil {
+			return err
+		}
+		defer lkrepo.Close() //nolint:errcheck
+
+		ss, mds, err := openMigrationBlockstore(ctx, lkrepo)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := ss.Close(); err != nil {
+				log.Warnf("failed to close blockstore: %s", err)
+			}
+		}()
+
+		// Everything the migration writes stays in memory.
+		bs := blockstore.NewTieredBstore(ss, blockstore.NewMemorySync())
+
+		cs := store.NewChainStore(bs, bs, mds, filcns.Weight, nil)
+		defer cs.Close() //nolint:errcheck
+		if err := cs.Load(ctx); err != nil {
+			return xerrors.Errorf("loading chain: %w", err)
+		}
+
+		sm, err := stmgr.NewStateManager(cs, consensus.NewTipSetExecutor(filcns.RewardFunc), vm.Syscalls(ffiwrapper.ProofVerifier), filcns.DefaultUpgradeSchedule(), nil, datastore.NewMapDatastore(), index.DummyMsgIndex)
+		if err != nil {
+			return err
+		}
+
+		head := cs.GetHeaviestTipSet()
+		var upgrade *stmgr.Upgrade
+		for _, u := range filcns.DefaultUpgradeSchedule() {
+			u := u
+			if u.Height > head.Height() && u.Migration != nil && (upgrade == nil || u.Height < upgrade.Height) {
+				upgrade = &u
+			}
+		}
+		if upgrade == nil {
+			return xerrors.Errorf("no network upgrade with a migration is scheduled after epoch %d", head.Height())
+		}
+
+		parent, err := cs.LoadTipSet(ctx, head.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading parent of head: %w", err)
+		}
+
+		fmt.Printf("migrating to nv%d (scheduled at epoch %d) from the state at epoch %d (%s)\n", upgrade.Network, upgrade.Height, head.Height()-1, head.ParentState())
+
+		peak := trackPeakHeap()
+		start := time.Now()
+		newRoot, err := upgrade.Migration(ctx, sm, mutil.NewMemMigrationCache(), nil, head.ParentState(), head.Height()-1, parent)
+		took := time.Since(start)
+		peakHeap := peak()
+		if err != nil {
+			return xerrors.Errorf("migration failed after %s: %w", took, err)
+		}
+
+		fmt.Printf("new state root: %s\n", newRoot)
+		fmt.Printf("took: %s\n", took)
+		fmt.Printf("peak heap in use: %s\n", humanize.IBytes(peakHeap))
+
+		return printMigrationDiff(ctx, bs, head.ParentState(), newRoot)
+	},
+}
+
+// trackPeakHeap samples the heap until the returned function is called, which returns the largest
+// heap in use observed.
+func trackPeakHeap() func() uint64 {
+	var (
+		lk   sync.Mutex
+		peak uint64
+		done = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+	sample := func() {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		lk.Lock()
+		if ms.HeapInuse > peak {
+			peak = ms.HeapInuse
+		}
+		lk.Unlock()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				sample()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() uint64 {
+		close(done)
+		wg.Wait()
+		sample()
+		return peak
+	}
+}
+
+func printMigrationDiff(ctx context.Context, bs blockstore.Blockstore, oldRoot, newRoot cid.Cid) error {
+	cst := cbor.NewCborStore(bs)
+	oldTree, err := state.LoadStateTree(cst, oldRoot)
+	if err != nil {
+		return xerrors.Errorf("loading old state tree: %w", err)
+	}
+	newTree, err := state.LoadStateTree(cst, newRoot)
+	if err != nil {
+		return xerrors.Errorf("loading new state tree: %w", err)
+	}
+
+	changes, err := state.DiffActors(ctx, oldTree, newTree)
+	if err != nil {
+		return xerrors.Errorf("diffing state trees: %w", err)
+	}
+
+	type counts struct{ created, modified, deleted int }
+	byActor := make(map[string]*counts)
+	for _, c := range changes {
+		var name string
+		var kind *int
+		if c.New != nil {
+			name = lbuiltin.ActorNameByCode(c.New.Code)
+		} else {
+			name = lbuiltin.ActorNameByCode(c.Old.Code)
+		}
+		cnt, ok := byActor[name]
+		if !ok {
+			cnt = &counts{}
+			byActor[name] = cnt
+		}
+		switch {
+		case c.Old == nil:
+			kind = &cnt.created
+		case c.New == nil:
+			kind = &cnt.deleted
+		default:
+			kind = &cnt.modified
+		}
+		*kind++
+	}
+
+	names := make([]string, 0, len(byActor))
+	for name := range byActor {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("actors changed: %d\n", len(changes))
+	tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "actor\tcreated\tmodified\tdeleted")
+	for _, name := range names {
+		c := byActor[name]
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", name, c.created, c.modified, c.deleted)
+	}
+	return tw.Flush()
+}
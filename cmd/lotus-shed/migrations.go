@@ -107,41 +107,7 @@ var migrationsCmd = &cli.Command{
 
 		defer lkrepo.Close() //nolint:errcheck
 
-		cold, err := lkrepo.Blockstore(ctx, repo.UniversalBlockstore)
-		if err != nil {
-			return fmt.Errorf("failed to open universal blockstore %w", err)
-		}
-
-		path, err := lkrepo.SplitstorePath()
-		if err != nil {
-			return err
-		}
-
-		path = filepath.Join(path, "hot.badger")
-		if err := os.MkdirAll(path, 0755); err != nil {
-			return err
-		}
-
-		opts, err := repo.BadgerBlockstoreOptions(repo.HotBlockstore, path, lkrepo.Readonly())
-		if err != nil {
-			return err
-		}
-
-		hot, err := badgerbs.Open(opts)
-		if err != nil {
-			return err
-		}
-
-		mds, err := lkrepo.Datastore(context.Background(), "/metadata")
-		if err != nil {
-			return err
-		}
-
-		cfg := &splitstore.Config{
-			MarkSetType:       "map",
-			DiscardColdBlocks: true,
-		}
-		ss, err := splitstore.Open(path, mds, hot, cold, cfg)
+		ss, mds, err := openMigrationBlockstore(ctx, lkrepo)
 		if err != nil {
 			return err
 		}
@@ -235,6 +201,49 @@ var migrationsCmd = &cli.Command{
 	},
 }
 
+// openMigrationBlockstore opens the repo's splitstore and metadata datastore.
+func openMigrationBlockstore(ctx context.Context, lkrepo repo.LockedRepo) (*splitstore.SplitStore, datastore.Batching, error) {
+	cold, err := lkrepo.Blockstore(ctx, repo.UniversalBlockstore)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open universal blockstore %w", err)
+	}
+
+	path, err := lkrepo.SplitstorePath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path = filepath.Join(path, "hot.badger")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	opts, err := repo.BadgerBlockstoreOptions(repo.HotBlockstore, path, lkrepo.Readonly())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hot, err := badgerbs.Open(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mds, err := lkrepo.Datastore(context.Background(), "/metadata")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &splitstore.Config{
+		MarkSetType:       "map",
+		DiscardColdBlocks: true,
+	}
+	ss, err := splitstore.Open(path, mds, hot, cold, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ss, mds, nil
+}
+
 func getMigrationFuncsForNetwork(nv network.Version) (UpgradeActorsFunc, PreUpgradeActorsFunc, CheckInvariantsFunc, error) {
 	switch nv {
 	case network.Version17:
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"path"
+	"strings"
 
 	"github.com/ipfs/go-cid"
 	_ "github.com/mattn/go-sqlite3"
@@ -13,6 +16,8 @@ import (
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 )
 
@@ -22,6 +27,7 @@ var msgindexCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		msgindexBackfillCmd,
 		msgindexPruneCmd,
+		msgindexVerifyCmd,
 	},
 }
 
@@ -88,15 +94,15 @@ var msgindexBackfillCmd = &cli.Command{
 			return err
 		}
 
-		insertStmt, err := tx.Prepare("INSERT INTO messages VALUES (?, ?, ?)")
+		insertStmt, err := tx.Prepare("INSERT INTO messages (cid, tipset_cid, epoch, receipt_index, block_cid, exec_tipset) VALUES (?, ?, ?, ?, ?, ?)")
 		if err != nil {
 			return err
 		}
 
-		insertMsg := func(cid, tsCid cid.Cid, epoch abi.ChainEpoch) error {
+		insertMsg := func(cid, tsCid, blockCid cid.Cid, epoch abi.ChainEpoch, receiptIndex int, execTs []byte) error {
 			key := cid.String()
 			tskey := tsCid.String()
-			if _, err := insertStmt.Exec(key, tskey, int64(epoch)); err != nil {
+			if _, err := insertStmt.Exec(key, tskey, int64(epoch), receiptIndex, blockCid.String(), execTs); err != nil {
 				return err
 			}
 
@@ -108,6 +114,19 @@ var msgindexBackfillCmd = &cli.Command{
 			}
 		}
 
+		// the messages of a tipset are executed in its child, which is the previously processed
+		// tipset; the child of the first one has to be looked up
+		var execTs []byte
+		if startHeight < int64(curTs.Height()) {
+			child, err := api.ChainGetTipSetAfterHeight(ctx, abi.ChainEpoch(startHeight+1), curTs.Key())
+			if err != nil {
+				rollback()
+				return err
+			}
+			execTs = child.Key().Bytes()
+		}
+
+		var prevTs types.TipSetKey
 		for i := 0; i < epochs; i++ {
 			epoch := abi.ChainEpoch(startHeight - int64(i))
 
@@ -117,6 +136,12 @@ var msgindexBackfillCmd = &cli.Command{
 				return err
 			}
 
+			// null rounds resolve to the previous tipset, which has already been indexed
+			if ts.Key() == prevTs {
+				continue
+			}
+			prevTs = ts.Key()
+
 			tsCid, err := ts.Key().Cid()
 			if err != nil {
 				rollback()
@@ -129,12 +154,20 @@ var msgindexBackfillCmd = &cli.Command{
 				return err
 			}
 
-			for _, msg := range msgs {
-				if err := insertMsg(msg.Cid, tsCid, epoch); err != nil {
+			blocks, err := messageBlocks(ctx, api, ts)
+			if err != nil {
+				rollback()
+				return err
+			}
+
+			for i, msg := range msgs {
+				if err := insertMsg(msg.Cid, tsCid, blocks[msg.Cid], ts.Height(), i, execTs); err != nil {
 					rollback()
 					return err
 				}
 			}
+
+			execTs = ts.Key().Bytes()
 		}
 
 		if err := tx.Commit(); err != nil {
@@ -219,3 +252,182 @@ var msgindexPruneCmd = &cli.Command{
 		return nil
 	},
 }
+
+var msgindexVerifyCmd = &cli.Command{
+	Name:  "verify",
+	Usage: "Check the message index against the chain for a number of epochs starting from a specified height",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "from",
+			Value: 0,
+			Usage: "height to start the check; uses the current head if omitted",
+		},
+		&cli.IntFlag{
+			Name:  "epochs",
+			Value: 1800,
+			Usage: "number of epochs to check; defaults to 1800 (2 finalities)",
+		},
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+			Usage: "path to the repo",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		curTs, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		startHeight := int64(cctx.Int("from"))
+		if startHeight == 0 {
+			startHeight = int64(curTs.Height()) - 1
+		}
+		epochs := cctx.Int("epochs")
+
+		basePath, err := homedir.Expand(cctx.String("repo"))
+		if err != nil {
+			return err
+		}
+
+		dbPath := path.Join(basePath, "sqlite", "msgindex.db")
+		db, err := sql.Open("sqlite3", dbPath+"?mode=ro")
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			err := db.Close()
+			if err != nil {
+				fmt.Printf("ERROR: closing db: %s", err)
+			}
+		}()
+
+		getStmt, err := db.Prepare("SELECT tipset_cid, epoch, receipt_index, block_cid, exec_tipset FROM messages WHERE cid = ?")
+		if err != nil {
+			return err
+		}
+		defer getStmt.Close() //nolint:errcheck
+
+		var execTs types.TipSetKey
+		if startHeight < int64(curTs.Height()) {
+			child, err := api.ChainGetTipSetAfterHeight(ctx, abi.ChainEpoch(startHeight+1), curTs.Key())
+			if err != nil {
+				return err
+			}
+			execTs = child.Key()
+		}
+
+		var checked, missing, mismatched int
+		var prevTs types.TipSetKey
+		for i := 0; i < epochs; i++ {
+			epoch := abi.ChainEpoch(startHeight - int64(i))
+			if epoch < 0 {
+				break
+			}
+
+			ts, err := api.ChainGetTipSetByHeight(ctx, epoch, curTs.Key())
+			if err != nil {
+				return err
+			}
+
+			if ts.Key() == prevTs {
+				continue
+			}
+			prevTs = ts.Key()
+
+			tsCid, err := ts.Key().Cid()
+			if err != nil {
+				return err
+			}
+
+			msgs, err := api.ChainGetMessagesInTipset(ctx, ts.Key())
+			if err != nil {
+				return err
+			}
+
+			blocks, err := messageBlocks(ctx, api, ts)
+			if err != nil {
+				return err
+			}
+
+			for i, msg := range msgs {
+				checked++
+
+				var (
+					tipset     string
+					msgEpoch   int64
+					receiptIdx int64
+					block      string
+					execTipset []byte
+				)
+				err := getStmt.QueryRow(msg.Cid.String()).Scan(&tipset, &msgEpoch, &receiptIdx, &block, &execTipset)
+				if err == sql.ErrNoRows {
+					missing++
+					fmt.Printf("%d: message %s is not indexed\n", ts.Height(), msg.Cid)
+					continue
+				}
+				if err != nil {
+					return xerrors.Errorf("querying message %s: %w", msg.Cid, err)
+				}
+
+				var problems []string
+				if tipset != tsCid.String() {
+					problems = append(problems, fmt.Sprintf("tipset %s, expected %s", tipset, tsCid))
+				}
+				if abi.ChainEpoch(msgEpoch) != ts.Height() {
+					problems = append(problems, fmt.Sprintf("epoch %d, expected %d", msgEpoch, ts.Height()))
+				}
+				if receiptIdx >= 0 && receiptIdx != int64(i) {
+					problems = append(problems, fmt.Sprintf("receipt index %d, expected %d", receiptIdx, i))
+				}
+				if block != "" && block != blocks[msg.Cid].String() {
+					problems = append(problems, fmt.Sprintf("block %s, expected %s", block, blocks[msg.Cid]))
+				}
+				if execTipset != nil && execTs != types.EmptyTSK && !bytes.Equal(execTipset, execTs.Bytes()) {
+					problems = append(problems, "execution tipset mismatch")
+				}
+
+				if len(problems) > 0 {
+					mismatched++
+					fmt.Printf("%d: message %s: %s\n", ts.Height(), msg.Cid, strings.Join(problems, "; "))
+				}
+			}
+
+			execTs = ts.Key()
+		}
+
+		fmt.Printf("checked %d messages: %d missing, %d mismatched\n", checked, missing, mismatched)
+		if missing > 0 || mismatched > 0 {
+			return xerrors.Errorf("message index is inconsistent with the chain")
+		}
+
+		return nil
+	},
+}
+
+// messageBlocks maps the messages of a tipset to the first block including them.
+func messageBlocks(ctx context.Context, api v0api.FullNode, ts *types.TipSet) (map[cid.Cid]cid.Cid, error) {
+	blocks := make(map[cid.Cid]cid.Cid)
+	for _, blk := range ts.Blocks() {
+		bmsgs, err := api.ChainGetBlockMessages(ctx, blk.Cid())
+		if err != nil {
+			return nil, xerrors.Errorf("getting messages of block %s: %w", blk.Cid(), err)
+		}
+		for _, c := range bmsgs.Cids {
+			if _, ok := blocks[c]; !ok {
+				blocks[c] = blk.Cid()
+			}
+		}
+	}
+
+	return blocks, nil
+}
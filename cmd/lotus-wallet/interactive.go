@@ -10,6 +10,7 @@ import (
 	gobig "math/big"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
@@ -194,6 +195,73 @@ func (c *InteractiveWallet) WalletDelete(ctx context.Context, addr address.Addre
 	return c.under.WalletDelete(ctx, addr)
 }
 
+func (c *InteractiveWallet) WalletHDNew(ctx context.Context, words int, passphrase string) (*api.HDMnemonic, error) {
+	err := c.accept(func() error {
+		fmt.Println("-----")
+		fmt.Println("ACTION: WalletHDNew - Generate a new HD seed")
+		fmt.Printf("WORDS: %d\n", words)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.under.WalletHDNew(ctx, words, passphrase)
+}
+
+func (c *InteractiveWallet) WalletHDDerive(ctx context.Context, index int64) (*api.HDKey, error) {
+	err := c.accept(func() error {
+		fmt.Println("-----")
+		fmt.Println("ACTION: WalletHDDerive - Add an address derived from the HD seed")
+		fmt.Printf("INDEX: %d\n", index)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.under.WalletHDDerive(ctx, index)
+}
+
+func (c *InteractiveWallet) WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]api.HDKey, error) {
+	err := c.accept(func() error {
+		fmt.Println("-----")
+		fmt.Println("ACTION: WalletHDRestore - Restore an HD seed from a mnemonic")
+		fmt.Printf("COUNT: %d\n", count)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.under.WalletHDRestore(ctx, mnemonic, passphrase, count)
+}
+
+func (c *InteractiveWallet) WalletEncrypt(ctx context.Context, passphrase string) error {
+	err := c.accept(func() error {
+		fmt.Println("-----")
+		fmt.Println("ACTION: WalletEncrypt - Encrypt the keystore with a passphrase")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.under.WalletEncrypt(ctx, passphrase)
+}
+
+func (c *InteractiveWallet) WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error {
+	return c.under.WalletUnlock(ctx, passphrase, timeout)
+}
+
+func (c *InteractiveWallet) WalletLock(ctx context.Context) error {
+	return c.under.WalletLock(ctx)
+}
+
+func (c *InteractiveWallet) WalletLockStatus(ctx context.Context) (*api.LockStatus, error) {
+	return c.under.WalletLockStatus(ctx)
+}
+
 func (c *InteractiveWallet) accept(prompt func() error) error {
 	c.lk.Lock()
 	defer c.lk.Unlock()
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
@@ -87,3 +88,45 @@ func (c *LoggedWallet) WalletDelete(ctx context.Context, addr address.Address) e
 
 	return c.under.WalletDelete(ctx, addr)
 }
+
+func (c *LoggedWallet) WalletHDNew(ctx context.Context, words int, passphrase string) (*api.HDMnemonic, error) {
+	log.Infow("WalletHDNew", "words", words)
+
+	return c.under.WalletHDNew(ctx, words, passphrase)
+}
+
+func (c *LoggedWallet) WalletHDDerive(ctx context.Context, index int64) (*api.HDKey, error) {
+	log.Infow("WalletHDDerive", "index", index)
+
+	return c.under.WalletHDDerive(ctx, index)
+}
+
+func (c *LoggedWallet) WalletHDRestore(ctx context.Context, mnemonic, passphrase string, count int) ([]api.HDKey, error) {
+	log.Infow("WalletHDRestore", "count", count)
+
+	return c.under.WalletHDRestore(ctx, mnemonic, passphrase, count)
+}
+
+func (c *LoggedWallet) WalletEncrypt(ctx context.Context, passphrase string) error {
+	log.Infow("WalletEncrypt")
+
+	return c.under.WalletEncrypt(ctx, passphrase)
+}
+
+func (c *LoggedWallet) WalletUnlock(ctx context.Context, passphrase string, timeout time.Duration) error {
+	log.Infow("WalletUnlock", "timeout", timeout)
+
+	return c.under.WalletUnlock(ctx, passphrase, timeout)
+}
+
+func (c *LoggedWallet) WalletLock(ctx context.Context) error {
+	log.Infow("WalletLock")
+
+	return c.under.WalletLock(ctx)
+}
+
+func (c *LoggedWallet) WalletLockStatus(ctx context.Context) (*api.LockStatus, error) {
+	log.Infow("WalletLockStatus")
+
+	return c.under.WalletLockStatus(ctx)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net"
@@ -10,12 +11,14 @@ import (
 
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/gorilla/mux"
+	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/urfave/cli/v2"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-jsonrpc"
 	"github.com/filecoin-project/go-jsonrpc/auth"
 
@@ -25,6 +28,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/wallet"
 	ledgerwallet "github.com/filecoin-project/lotus/chain/wallet/ledger"
+	"github.com/filecoin-project/lotus/chain/wallet/remotesigner"
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/lib/lotuslog"
 	"github.com/filecoin-project/lotus/metrics"
@@ -63,7 +67,16 @@ To configure your lotus node to use a remote wallet:
   * Find the '[Wallet]' section
   * Set 'RemoteBackend' to '[api key]:http://[wallet ip]:[wallet port]'
     (the default port is 1777)
-* Start (or restart) the lotus daemon`,
+* Start (or restart) the lotus daemon
+
+The 'lotus wallet hd' commands manage the addresses derived from a mnemonic in the lotus-wallet
+repo when FULLNODE_API_INFO is set to '[api key]:/ip4/[wallet ip]/tcp/[wallet port]/http', and
+so do the 'lotus wallet encrypt', 'lotus wallet unlock' and 'lotus wallet lock' commands, which
+manage the encryption of its keystore.
+
+With --remote-signer, lotus-wallet keeps no key and forwards signing requests to a remote signing
+service over HTTPS, authenticated with a TLS client certificate and signed with a shared secret;
+see the documentation of the chain/wallet/remotesigner package for the protocol.`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    FlagWalletRepo,
@@ -130,13 +143,39 @@ var runCmd = &cli.Command{
 			Name:  "ledger",
 			Usage: "use a ledger device instead of an on-disk wallet",
 		},
+		&cli.StringSliceFlag{
+			Name:  "remote-signer",
+			Usage: "forward signing to the remote signing service at these https URLs, tried in order, instead of keeping keys on disk",
+		},
+		&cli.StringFlag{
+			Name:  "remote-signer-cert",
+			Usage: "PEM client certificate authenticating to the remote signer",
+		},
+		&cli.StringFlag{
+			Name:  "remote-signer-key",
+			Usage: "PEM key of the client certificate",
+		},
+		&cli.StringFlag{
+			Name:  "remote-signer-ca",
+			Usage: "PEM bundle of the authorities of the remote signer certificate (default: the system roots)",
+		},
+		&cli.StringFlag{
+			Name:    "remote-signer-secret",
+			Usage:   "file holding the secret the requests to the remote signer are signed with",
+			EnvVars: []string{"LOTUS_WALLET_REMOTE_SIGNER_SECRET_FILE"},
+		},
+		&cli.IntFlag{
+			Name:  "remote-signer-rounds",
+			Usage: "number of times all the remote signer URLs are tried before a signing request fails",
+			Value: 3,
+		},
 		&cli.BoolFlag{
 			Name:  "interactive",
 			Usage: "prompt before performing actions (DO NOT USE FOR MINER WORKER ADDRESS)",
 		},
 		&cli.BoolFlag{
 			Name:  "offline",
-			Usage: "don't query chain state in interactive mode",
+			Usage: "don't query chain state in interactive mode, or to decode the messages signed with a ledger",
 		},
 		&cli.BoolFlag{
 			Name:   "disable-auth",
@@ -175,15 +214,55 @@ var runCmd = &cli.Command{
 		}
 
 		var w api.Wallet = lw
+		if cctx.IsSet("remote-signer") {
+			if cctx.Bool("ledger") {
+				return xerrors.Errorf("--ledger and --remote-signer can't be used together")
+			}
+
+			secret, err := os.ReadFile(cctx.String("remote-signer-secret"))
+			if err != nil {
+				return xerrors.Errorf("reading remote signer secret: %w", err)
+			}
+
+			w, err = remotesigner.New(remotesigner.Config{
+				Endpoints: cctx.StringSlice("remote-signer"),
+				CertFile:  cctx.String("remote-signer-cert"),
+				KeyFile:   cctx.String("remote-signer-key"),
+				CAFile:    cctx.String("remote-signer-ca"),
+				Secret:    bytes.TrimSpace(secret),
+				Rounds:    cctx.Int("remote-signer-rounds"),
+			})
+			if err != nil {
+				return xerrors.Errorf("setting up remote signer: %w", err)
+			}
+		}
 		if cctx.Bool("ledger") {
 			ds, err := lr.Datastore(context.Background(), "/metadata")
 			if err != nil {
 				return err
 			}
 
+			lgw := ledgerwallet.NewWallet(ds)
+			if !cctx.Bool("offline") {
+				// decode the messages sent to the ledger with the actor codes of the chain node
+				lgw = ledgerwallet.NewWalletWithActorCodes(ds, func(ctx context.Context, addr address.Address) (cid.Cid, error) {
+					napi, closer, err := lcli.GetFullNodeAPI(cctx)
+					if err != nil {
+						return cid.Undef, xerrors.Errorf("getting node api: %w", err)
+					}
+					defer closer()
+
+					act, err := napi.StateGetActor(ctx, addr, types.EmptyTSK)
+					if err != nil {
+						return cid.Undef, err
+					}
+					return act.Code, nil
+				})
+			}
+
 			w = wallet.MultiWallet{
 				Local:  lw,
-				Ledger: ledgerwallet.NewWallet(ds),
+				Ledger: lgw,
 			}
 		}
 
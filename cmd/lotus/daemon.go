@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/DataDog/zstd"
+	dstore "github.com/ipfs/go-datastore"
 	metricsprom "github.com/ipfs/go-metrics-prometheus"
 	"github.com/mitchellh/go-homedir"
 	"github.com/multiformats/go-multiaddr"
@@ -119,6 +120,10 @@ var DaemonCmd = &cli.Command{
 			Name:  "import-snapshot",
 			Usage: "import chain state from a given chain export file or url",
 		},
+		&cli.StringFlag{
+			Name:  "import-snapshot-base",
+			Usage: "treat the snapshot as a differential export on top of the snapshot taken at the given tipset key, which must already be imported",
+		},
 		&cli.BoolFlag{
 			Name:  "halt-after-import",
 			Usage: "halt the process after importing chain from file",
@@ -275,13 +280,36 @@ var DaemonCmd = &cli.Command{
 				issnapshot = true
 			}
 
-			if err := ImportChain(ctx, r, chainfile, issnapshot); err != nil {
+			var diffBase types.TipSetKey
+			if s := cctx.String("import-snapshot-base"); s != "" {
+				if !issnapshot {
+					return fmt.Errorf("'import-snapshot-base' requires 'import-snapshot'")
+				}
+				cids, err := lcli.ParseTipSetString(s)
+				if err != nil {
+					return xerrors.Errorf("parsing 'import-snapshot-base': %w", err)
+				}
+				diffBase = types.NewTipSetKey(cids...)
+			}
+
+			if err := ImportChain(ctx, r, chainfile, issnapshot, diffBase, types.EmptyTSK); err != nil {
 				return err
 			}
 			if cctx.Bool("halt-after-import") {
 				fmt.Println("Chain import complete, halting as requested...")
 				return nil
 			}
+		} else {
+			url, root, err := bootstrapSnapshot(ctx, r)
+			if err != nil {
+				return xerrors.Errorf("checking for a bootstrap snapshot: %w", err)
+			}
+			if url != "" {
+				log.Infow("repo has no chain, importing the bootstrap snapshot", "url", url, "tipset", root)
+				if err := ImportChain(ctx, r, url, true, types.EmptyTSK, root); err != nil {
+					return xerrors.Errorf("importing bootstrap snapshot: %w", err)
+				}
+			}
 		}
 
 		genesis := node.Options()
@@ -430,7 +458,54 @@ func importKey(ctx context.Context, api lapi.FullNode, f string) error {
 	return nil
 }
 
-func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool) (err error) {
+// bootstrapSnapshot returns the snapshot configured in Sync.BootstrapSnapshotURL, and the tipset
+// it must be rooted at, if the repo has no chain yet; the url is empty otherwise.
+func bootstrapSnapshot(ctx context.Context, r repo.Repo) (string, types.TipSetKey, error) {
+	lr, err := r.Lock(repo.FullNode)
+	if err != nil {
+		return "", types.EmptyTSK, err
+	}
+	defer lr.Close() //nolint:errcheck
+
+	c, err := lr.Config()
+	if err != nil {
+		return "", types.EmptyTSK, err
+	}
+	cfg, ok := c.(*config.FullNode)
+	if !ok {
+		return "", types.EmptyTSK, xerrors.Errorf("invalid config for repo, got: %T", c)
+	}
+	if cfg.Sync.BootstrapSnapshotURL == "" {
+		return "", types.EmptyTSK, nil
+	}
+	if cfg.Sync.BootstrapSnapshotTipSet == "" {
+		return "", types.EmptyTSK, xerrors.Errorf("Sync.BootstrapSnapshotURL requires Sync.BootstrapSnapshotTipSet")
+	}
+	cids, err := lcli.ParseTipSetString(cfg.Sync.BootstrapSnapshotTipSet)
+	if err != nil {
+		return "", types.EmptyTSK, xerrors.Errorf("parsing Sync.BootstrapSnapshotTipSet: %w", err)
+	}
+
+	mds, err := lr.Datastore(ctx, "/metadata")
+	if err != nil {
+		return "", types.EmptyTSK, err
+	}
+	// also retries bootstrap imports which didn't complete
+	has, err := mds.Has(ctx, dstore.NewKey("head"))
+	if err != nil {
+		return "", types.EmptyTSK, xerrors.Errorf("checking for a chain head: %w", err)
+	}
+	if has {
+		return "", types.EmptyTSK, nil
+	}
+
+	return cfg.Sync.BootstrapSnapshotURL, types.NewTipSetKey(cids...), nil
+}
+
+// ImportChain imports the chain from the given file or url. If diffBase isn't empty, the file is
+// a differential export on top of the already imported snapshot of diffBase. If root isn't empty,
+// the chain must be rooted at that tipset.
+func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool, diffBase, root types.TipSetKey) (err error) {
 	var rd io.Reader
 	var l int64
 	if strings.HasPrefix(fname, "http://") || strings.HasPrefix(fname, "https://") {
@@ -504,7 +579,10 @@ func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool)
 
 	var ir io.Reader = br
 
+	// the size of the CAR is only known when it isn't compressed
+	opts := store.ImportOptions{Size: l, Root: root}
 	if string(header[1:]) == "\xB5\x2F\xFD" { // zstd
+		opts.Size = 0
 		zr := zstd.NewReader(br)
 		defer func() {
 			if err := zr.Close(); err != nil {
@@ -515,12 +593,20 @@ func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool)
 	}
 
 	bar.Start()
-	ts, err := cst.Import(ctx, ir)
+	var ts *types.TipSet
+	if diffBase.IsEmpty() {
+		ts, err = cst.ImportWithOptions(ctx, ir, opts)
+	} else {
+		ts, err = cst.ImportDiff(ctx, ir, diffBase)
+	}
 	bar.Finish()
 
 	if err != nil {
 		return xerrors.Errorf("importing chain failed: %w", err)
 	}
+	if !root.IsEmpty() && ts.Key() != root {
+		return xerrors.Errorf("imported chain is rooted at %s, expected %s", ts.Key(), root)
+	}
 
 	if err := cst.FlushValidationCache(ctx); err != nil {
 		return xerrors.Errorf("flushing validation cache failed: %w", err)
@@ -554,6 +640,26 @@ func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool)
 		return err
 	}
 
+	// lite snapshots only have the state of their last epochs; record where it starts, so that
+	// queries below fail clearly and the archival backfill knows where to resume from
+	if snapshot {
+		earliest, err := cst.RecordStateBoundary(ctx, ts)
+		if err != nil {
+			return xerrors.Errorf("looking for the earliest imported state: %w", err)
+		}
+		if earliest > 0 {
+			log.Infof("snapshot includes the state from epoch %d", earliest)
+		}
+	}
+
+	// Receipts are looked up through the headers of the child tipsets, so if the snapshot
+	// included them, the state of the imported tipsets won't have to be recomputed.
+	if has, err := cst.ChainBlockstore().Has(ctx, ts.Blocks()[0].ParentMessageReceipts); err != nil {
+		return xerrors.Errorf("checking for imported receipts: %w", err)
+	} else if has {
+		log.Info("snapshot includes message receipts")
+	}
+
 	// populate the message index if user has EnableMsgIndex enabled
 	//
 	c, err := lr.Config()
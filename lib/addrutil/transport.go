@@ -0,0 +1,38 @@
+package addrutil
+
+import (
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+const (
+	TransportTCP          = "tcp"
+	TransportQUIC         = "quic"
+	TransportWebTransport = "webtransport"
+	TransportWebsocket    = "websocket"
+)
+
+// Transports are the names of the libp2p transports lotus can run.
+var Transports = []string{TransportTCP, TransportQUIC, TransportWebTransport, TransportWebsocket}
+
+// Transport returns the name of the libp2p transport an address is dialed or listened on over,
+// or an empty string when it is none of Transports.
+func Transport(addr ma.Multiaddr) string {
+	has := func(code int) bool {
+		_, err := addr.ValueForProtocol(code)
+		return err == nil
+	}
+
+	// the transports layered over others first
+	switch {
+	case has(ma.P_WEBTRANSPORT):
+		return TransportWebTransport
+	case has(ma.P_WS), has(ma.P_WSS):
+		return TransportWebsocket
+	case has(ma.P_QUIC), has(ma.P_QUIC_V1):
+		return TransportQUIC
+	case has(ma.P_TCP):
+		return TransportTCP
+	default:
+		return ""
+	}
+}
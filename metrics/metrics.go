@@ -102,6 +102,10 @@ var (
 	MpoolAddTsDuration                  = stats.Float64("mpool/addts_ms", "Duration of addTs in mpool", stats.UnitMilliseconds)
 	MpoolAddDuration                    = stats.Float64("mpool/add_ms", "Duration of Add in mpool", stats.UnitMilliseconds)
 	MpoolPushDuration                   = stats.Float64("mpool/push_ms", "Duration of Push in mpool", stats.UnitMilliseconds)
+	MpoolLimitRejected                  = stats.Int64("mpool/limit_rejected", "Counter for messages rejected by the mpool for exceeding the pending message cap or the nonce gap of their sender, or its memory budget", stats.UnitDimensionless)
+	MpoolEvicted                        = stats.Int64("mpool/evicted", "Counter for pending messages evicted from the mpool to stay within its memory budget", stats.UnitDimensionless)
+	MpoolEvictedBytes                   = stats.Int64("mpool/evicted_bytes", "Counter for the estimated memory freed by mpool evictions", stats.UnitBytes)
+	MpoolMemory                         = stats.Int64("mpool/memory", "Estimated memory held by the pending messages of the mpool", stats.UnitBytes)
 	BlockPublished                      = stats.Int64("block/published", "Counter for total locally published blocks", stats.UnitDimensionless)
 	BlockReceived                       = stats.Int64("block/received", "Counter for total received blocks", stats.UnitDimensionless)
 	BlockValidationFailure              = stats.Int64("block/failure", "Counter for block validation failures", stats.UnitDimensionless)
@@ -185,6 +189,15 @@ var (
 	RcmgrAllowMem       = stats.Int64("rcmgr/allow_mem", "Number of allowed memory reservations", stats.UnitDimensionless)
 	RcmgrBlockMem       = stats.Int64("rcmgr/block_mem", "Number of blocked memory reservations", stats.UnitDimensionless)
 
+	// drand
+	DrandRequestDuration = stats.Float64("drand/request_ms", "Duration of successful requests to drand endpoints", stats.UnitMilliseconds)
+	DrandRequestFailure  = stats.Int64("drand/request_failure", "Counter for failed requests to drand endpoints", stats.UnitDimensionless)
+	DrandActiveGroup     = stats.Int64("drand/active_group", "Index of the drand endpoint group serving randomness", stats.UnitDimensionless)
+
+	// bitswap
+	BitswapBlocksServed = stats.Int64("bitswap/blocks_served", "Counter for blocks served over bitswap", stats.UnitDimensionless)
+	BitswapBytesServed  = stats.Int64("bitswap/bytes_served", "Counter for bytes of blocks served over bitswap", stats.UnitBytes)
+
 	// gateway rate limit
 	RateLimitCount = stats.Int64("ratelimit/limited", "rate limited connections", stats.UnitDimensionless)
 )
@@ -295,6 +308,23 @@ var (
 		Measure:     MpoolPushDuration,
 		Aggregation: defaultMillisecondsDistribution,
 	}
+	MpoolLimitRejectedView = &view.View{
+		Measure:     MpoolLimitRejected,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{FailureType},
+	}
+	MpoolEvictedView = &view.View{
+		Measure:     MpoolEvicted,
+		Aggregation: view.Count(),
+	}
+	MpoolEvictedBytesView = &view.View{
+		Measure:     MpoolEvictedBytes,
+		Aggregation: view.Sum(),
+	}
+	MpoolMemoryView = &view.View{
+		Measure:     MpoolMemory,
+		Aggregation: view.LastValue(),
+	}
 	PeerCountView = &view.View{
 		Measure:     PeerCount,
 		Aggregation: view.LastValue(),
@@ -654,6 +684,30 @@ var (
 		Measure:     RcmgrBlockMem,
 		Aggregation: view.Count(),
 	}
+	DrandRequestDurationView = &view.View{
+		Measure:     DrandRequestDuration,
+		Aggregation: defaultMillisecondsDistribution,
+		TagKeys:     []tag.Key{Endpoint},
+	}
+	DrandRequestFailureView = &view.View{
+		Measure:     DrandRequestFailure,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Endpoint},
+	}
+	DrandActiveGroupView = &view.View{
+		Measure:     DrandActiveGroup,
+		Aggregation: view.LastValue(),
+	}
+	BitswapBlocksServedView = &view.View{
+		Measure:     BitswapBlocksServed,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{PeerID},
+	}
+	BitswapBytesServedView = &view.View{
+		Measure:     BitswapBytesServed,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{PeerID},
+	}
 	RateLimitedView = &view.View{
 		Measure:     RateLimitCount,
 		Aggregation: view.Count(),
@@ -721,6 +775,10 @@ var ChainNodeViews = append([]*view.View{
 	MpoolAddTsDurationView,
 	MpoolAddDurationView,
 	MpoolPushDurationView,
+	MpoolLimitRejectedView,
+	MpoolEvictedView,
+	MpoolEvictedBytesView,
+	MpoolMemoryView,
 	PubsubPublishMessageView,
 	PubsubDeliverMessageView,
 	PubsubRejectMessageView,
@@ -728,6 +786,11 @@ var ChainNodeViews = append([]*view.View{
 	PubsubRecvRPCView,
 	PubsubSendRPCView,
 	PubsubDropRPCView,
+	BitswapBlocksServedView,
+	BitswapBytesServedView,
+	DrandRequestDurationView,
+	DrandRequestFailureView,
+	DrandActiveGroupView,
 	VMFlushCopyCountView,
 	VMFlushCopyDurationView,
 	SplitstoreMissView,
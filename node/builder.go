@@ -42,6 +42,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 	"github.com/filecoin-project/lotus/node/modules/lp2p"
 	"github.com/filecoin-project/lotus/node/modules/testing"
+	"github.com/filecoin-project/lotus/node/modules/tracer"
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage/paths"
 	"github.com/filecoin-project/lotus/system"
@@ -94,9 +95,11 @@ const (
 	PstoreAddSelfKeysKey
 	StartListeningKey
 	BootstrapKey
+	ConnectProtectedPeersKey
 
 	// filecoin
 	SetGenesisKey
+	LoadTrustedCheckpointsKey
 
 	RunHelloKey
 	RunChainExchangeKey
@@ -130,6 +133,13 @@ const (
 
 	StoreEventsKey
 
+	RunStatePrunerKey
+	RunStateBackfillKey
+	RunFollowUpstreamKey
+	RunConsensusFaultReporterKey
+	RunLocalMessageTrackerKey
+	RunStateCacheSnapshotKey
+
 	_nInvokes // keep this last
 )
 
@@ -216,6 +226,7 @@ var LibP2P = Options(
 
 	// Services (pubsub)
 	Override(new(*dtypes.ScoreKeeper), lp2p.ScoreKeeper),
+	Override(new(*tracer.DeliveryTracer), lp2p.DeliveryTracer),
 	Override(new(*pubsub.PubSub), lp2p.GossipSub),
 	Override(new(*config.Pubsub), func(bs dtypes.Bootstrapper) *config.Pubsub {
 		return &config.Pubsub{
@@ -229,7 +240,7 @@ var LibP2P = Options(
 	Override(ConnGaterKey, lp2p.ConnGaterOption),
 
 	// Services (resource management)
-	Override(new(network.ResourceManager), lp2p.ResourceManager(200)),
+	Override(new(network.ResourceManager), lp2p.ResourceManager(&config.Libp2p{ConnMgrHigh: 200})),
 	Override(ResourceManagerKey, lp2p.ResourceManagerOption),
 )
 
@@ -287,13 +298,17 @@ func ConfigCommon(cfg *config.Common, enableLibp2pNode bool) Option {
 		If(enableLibp2pNode,
 			Override(new(api.Net), From(new(net.NetAPI))),
 			Override(new(api.Common), From(new(common.CommonAPI))),
-			Override(StartListeningKey, lp2p.StartListening(cfg.Libp2p.ListenAddresses)),
+			Override(StartListeningKey, lp2p.StartListening(lp2p.ListenAddresses(&cfg.Libp2p))),
+			Override(DefaultTransportsKey, lp2p.Transports(cfg.Libp2p.Transports)),
+			Override(SecurityKey, lp2p.Security(true, cfg.Libp2p.PreferTLS)),
 			Override(ConnectionManagerKey, lp2p.ConnectionManager(
 				cfg.Libp2p.ConnMgrLow,
 				cfg.Libp2p.ConnMgrHigh,
 				time.Duration(cfg.Libp2p.ConnMgrGrace),
 				cfg.Libp2p.ProtectedPeers)),
-			Override(new(network.ResourceManager), lp2p.ResourceManager(cfg.Libp2p.ConnMgrHigh)),
+			Override(new(dtypes.ProtectedPeers), lp2p.ConfigProtectedPeers(cfg.Libp2p.ProtectedPeers)),
+			Override(ConnectProtectedPeersKey, lp2p.ConnectProtectedPeers),
+			Override(new(network.ResourceManager), lp2p.ResourceManager(&cfg.Libp2p)),
 			Override(new(*pubsub.PubSub), lp2p.GossipSub),
 			Override(new(*config.Pubsub), &cfg.Pubsub),
 
@@ -306,6 +321,7 @@ func ConfigCommon(cfg *config.Common, enableLibp2pNode bool) Option {
 				cfg.Libp2p.NoAnnounceAddresses)),
 
 			If(!cfg.Libp2p.DisableNatPortMap, Override(NatPortMapKey, lp2p.NatPortMap)),
+			Override(RelayKey, lp2p.Relay(&cfg.Libp2p)),
 		),
 		Override(new(dtypes.MetadataDS), modules.Datastore(cfg.Backup.DisableMetadataLog)),
 	)
@@ -392,6 +408,7 @@ func Test() Option {
 		Override(new(beacon.Schedule), testing.RandomBeacon),
 		Override(new(*storageadapter.DealPublisher), storageadapter.NewDealPublisher(nil, storageadapter.PublishMsgConfig{})),
 		Override(new(index.MsgIndex), modules.DummyMsgIndex),
+		Override(new(index.AddrIndex), modules.DummyAddrIndex),
 	)
 }
 
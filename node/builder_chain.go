@@ -2,6 +2,7 @@ package node
 
 import (
 	"os"
+	"time"
 
 	gorpc "github.com/libp2p/go-libp2p-gorpc"
 	"go.uber.org/fx"
@@ -11,6 +12,7 @@ import (
 	discoveryimpl "github.com/filecoin-project/go-fil-markets/discovery/impl"
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain"
@@ -29,6 +31,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/chain/wallet"
+	hsmwallet "github.com/filecoin-project/lotus/chain/wallet/hsm"
 	ledgerwallet "github.com/filecoin-project/lotus/chain/wallet/ledger"
 	"github.com/filecoin-project/lotus/chain/wallet/remotewallet"
 	raftcns "github.com/filecoin-project/lotus/lib/consensus/raft"
@@ -61,7 +64,7 @@ var ChainNode = Options(
 	Override(new(modules.Genesis), modules.ErrorGenesis),
 	Override(new(dtypes.AfterGenesisSet), modules.SetGenesis),
 	Override(SetGenesisKey, modules.DoSetGenesis),
-	Override(new(beacon.Schedule), modules.RandomSchedule),
+	Override(new(beacon.Schedule), modules.RandomSchedule(time.Duration(config.DefaultFullNode().Beacon.EntryCacheHorizon))),
 
 	// Network bootstrap
 	Override(new(dtypes.BootstrapPeers), modules.BuiltinBootstrap),
@@ -81,7 +84,7 @@ var ChainNode = Options(
 	Override(new(consensus.Consensus), filcns.NewFilecoinExpectedConsensus),
 	Override(new(*store.ChainStore), modules.ChainStore),
 	Override(new(*stmgr.StateManager), modules.StateManager),
-	Override(new(dtypes.ChainBitswap), modules.ChainBitswap),
+	Override(new(dtypes.ChainBitswap), modules.ChainBitswap(config.DefaultFullNode().Bitswap)),
 	Override(new(dtypes.ChainBlockService), modules.ChainBlockService), // todo: unused
 
 	// Consensus: Chain sync
@@ -102,7 +105,7 @@ var ChainNode = Options(
 
 	// Service: Message Pool
 	Override(new(dtypes.DefaultMaxFeeFunc), modules.NewDefaultMaxFeeFunc),
-	Override(new(*messagepool.MessagePool), modules.MessagePool),
+	Override(new(*messagepool.MessagePool), modules.MessagePool(config.DefaultFullNode().Fees, config.DefaultFullNode().Mpool)),
 	Override(new(*dtypes.MpoolLocker), new(dtypes.MpoolLocker)),
 
 	// Shared graphsync (markets, serving chain)
@@ -140,7 +143,9 @@ var ChainNode = Options(
 	Override(new(storagemarket.StorageClientNode), storageadapter.NewClientNodeAdapter),
 	Override(HandleMigrateClientFundsKey, modules.HandleMigrateClientFunds),
 
-	Override(new(*full.GasPriceCache), full.NewGasPriceCache),
+	Override(new(*full.GasPriceCache), modules.GasPriceCache(config.DefaultFullNode().Fees)),
+	Override(new(*full.ChainImporter), full.NewChainImporter),
+	Override(new(*full.ChainGCRunner), full.NewChainGCRunner),
 
 	Override(RelayIndexerMessagesKey, modules.RelayIndexerMessages),
 
@@ -188,12 +193,20 @@ func ConfigFullNode(c interface{}) Option {
 		ConfigCommon(&cfg.Common, enableLibp2pNode),
 
 		Override(new(dtypes.UniversalBlockstore), modules.UniversalBlockstore),
+		Override(new(stmgr.UpgradeSchedule), modules.ConfiguredUpgradeSchedule(&cfg.Migration)),
+		Override(new(*stmgr.StateManager), modules.ConfiguredStateManager(&cfg.Chainstore)),
+		Override(new(dtypes.DrandSchedule), modules.ConfiguredDrandConfig(cfg.Beacon.FallbackServers)),
+		Override(new(beacon.Schedule), modules.RandomSchedule(time.Duration(cfg.Beacon.EntryCacheHorizon))),
 
 		If(cfg.Chainstore.EnableSplitstore,
 			If(cfg.Chainstore.Splitstore.ColdStoreType == "universal" || cfg.Chainstore.Splitstore.ColdStoreType == "messages",
 				Override(new(dtypes.ColdBlockstore), From(new(dtypes.UniversalBlockstore)))),
 			If(cfg.Chainstore.Splitstore.ColdStoreType == "discard",
 				Override(new(dtypes.ColdBlockstore), modules.DiscardColdBlockstore)),
+			If(cfg.Chainstore.Splitstore.ColdStoreType == "s3",
+				Override(new(dtypes.ColdBlockstore), modules.S3ColdBlockstore(&cfg.Chainstore.Splitstore.ColdStoreS3))),
+			If(cfg.Chainstore.Splitstore.ColdStoreType == "remote",
+				Override(new(dtypes.ColdBlockstore), modules.RemoteColdBlockstore(cfg.Chainstore.Splitstore.ColdStoreRemoteAPI))),
 			If(cfg.Chainstore.Splitstore.HotStoreType == "badger",
 				Override(new(dtypes.HotBlockstore), modules.BadgerHotBlockstore)),
 			Override(new(dtypes.SplitBlockstore), modules.SplitBlockstore(&cfg.Chainstore)),
@@ -202,6 +215,8 @@ func ConfigFullNode(c interface{}) Option {
 			Override(new(dtypes.BaseBlockstore), From(new(dtypes.SplitBlockstore))),
 			Override(new(dtypes.ExposedBlockstore), modules.ExposedSplitBlockstore),
 			Override(new(dtypes.GCReferenceProtector), modules.SplitBlockstoreGCReferenceProtector),
+			If(cfg.Chainstore.ArchivalBackfill,
+				Error(xerrors.Errorf("the archival backfill can't be used with the splitstore"))),
 		),
 		If(!cfg.Chainstore.EnableSplitstore,
 			Override(new(dtypes.BasicChainBlockstore), modules.ChainFlatBlockstore),
@@ -209,11 +224,45 @@ func ConfigFullNode(c interface{}) Option {
 			Override(new(dtypes.BaseBlockstore), From(new(dtypes.UniversalBlockstore))),
 			Override(new(dtypes.ExposedBlockstore), From(new(dtypes.UniversalBlockstore))),
 			Override(new(dtypes.GCReferenceProtector), modules.NoopGCReferenceProtector),
+			If(cfg.Chainstore.StateRetentionEpochs > 0,
+				Override(new(dtypes.GCReferenceProtector), modules.ChainStoreGCReferenceProtector),
+				Override(RunStatePrunerKey, modules.StatePruner(abi.ChainEpoch(cfg.Chainstore.StateRetentionEpochs))),
+			),
+			If(cfg.Chainstore.ArchivalBackfill,
+				If(cfg.Chainstore.StateRetentionEpochs > 0,
+					Error(xerrors.Errorf("the archival backfill can't be used with StateRetentionEpochs"))),
+				Override(RunStateBackfillKey, modules.StateBackfill),
+			),
 		),
 
 		Override(new(dtypes.ChainBlockstore), From(new(dtypes.BasicChainBlockstore))),
 		Override(new(dtypes.StateBlockstore), From(new(dtypes.BasicStateBlockstore))),
 
+		If(cfg.Chainstore.ChainCacheSize > 0,
+			Override(new(dtypes.ChainBlockstore), modules.CachedChainBlockstore(cfg.Chainstore.ChainCacheSize))),
+		If(cfg.Chainstore.StateCacheSize > 0,
+			Override(new(dtypes.StateBlockstore), modules.CachedStateBlockstore(cfg.Chainstore.StateCacheSize))),
+
+		If(cfg.Chainstore.StateCacheSnapshot,
+			Override(RunStateCacheSnapshotKey, modules.StateCacheSnapshot)),
+
+		If(cfg.Chainstore.CheckpointsFile != "",
+			Override(LoadTrustedCheckpointsKey, modules.LoadTrustedCheckpoints(cfg.Chainstore.CheckpointsFile))),
+
+		If(cfg.Sync.TrustedUpstream != "",
+			Override(new(*consensus.Follower), modules.FollowerConsensus),
+			Override(new(consensus.Consensus), From(new(*consensus.Follower))),
+			Override(RunFollowUpstreamKey, modules.FollowUpstream(cfg.Sync.TrustedUpstream)),
+		),
+
+		If(cfg.Mpool.LocalHistory,
+			Override(RunLocalMessageTrackerKey, modules.LocalMessageTracker),
+		),
+
+		If(cfg.FaultReporter.EnableConsensusFaultReporter,
+			Override(RunConsensusFaultReporterKey, modules.RunConsensusFaultReporter(cfg.FaultReporter)),
+		),
+
 		If(os.Getenv("LOTUS_ENABLE_CHAINSTORE_FALLBACK") == "1",
 			Override(new(dtypes.ChainBlockstore), modules.FallbackChainBlockstore),
 			Override(new(dtypes.StateBlockstore), modules.FallbackStateBlockstore),
@@ -236,7 +285,10 @@ func ConfigFullNode(c interface{}) Option {
 				Override(new(retrievalmarket.BlockstoreAccessor), modules.IpfsRetrievalBlockstoreAccessor),
 			),
 		),
+		Override(new(*messagepool.MessagePool), modules.MessagePool(cfg.Fees, cfg.Mpool)),
+		Override(new(*full.GasPriceCache), modules.GasPriceCache(cfg.Fees)),
 		Override(new(dtypes.Graphsync), modules.Graphsync(cfg.Client.SimultaneousTransfersForStorage, cfg.Client.SimultaneousTransfersForRetrieval)),
+		Override(new(dtypes.ChainBitswap), modules.ChainBitswap(cfg.Bitswap)),
 
 		Override(new(retrievalmarket.RetrievalClient), modules.RetrievalClient(cfg.Client.OffChainRetrieval)),
 
@@ -244,7 +296,13 @@ func ConfigFullNode(c interface{}) Option {
 			Override(new(*remotewallet.RemoteWallet), remotewallet.SetupRemoteWallet(cfg.Wallet.RemoteBackend)),
 		),
 		If(cfg.Wallet.EnableLedger,
-			Override(new(*ledgerwallet.LedgerWallet), ledgerwallet.NewWallet),
+			Override(new(*ledgerwallet.LedgerWallet), modules.LedgerWallet),
+		),
+		If(cfg.Wallet.Vault.Address != "",
+			Override(new(*wallet.LocalWallet), modules.VaultWallet(cfg.Wallet.Vault)),
+		),
+		If(cfg.Wallet.HSM.Backend != "",
+			Override(new(*hsmwallet.Wallet), modules.HSMWallet(cfg.Wallet.HSM)),
 		),
 		If(cfg.Wallet.DisableLocal,
 			Unset(new(*wallet.LocalWallet)),
@@ -280,6 +338,8 @@ func ConfigFullNode(c interface{}) Option {
 		// enable message index for full node when configured by the user, otherwise use dummy.
 		If(cfg.Index.EnableMsgIndex, Override(new(index.MsgIndex), modules.MsgIndex)),
 		If(!cfg.Index.EnableMsgIndex, Override(new(index.MsgIndex), modules.DummyMsgIndex)),
+		If(cfg.Index.EnableAddressIndex, Override(new(index.AddrIndex), modules.AddrIndex)),
+		If(!cfg.Index.EnableAddressIndex, Override(new(index.AddrIndex), modules.DummyAddrIndex)),
 	)
 }
 
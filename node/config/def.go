@@ -58,8 +58,11 @@ func defCommon() Common {
 				"/ip4/0.0.0.0/tcp/0",
 				"/ip6/::/tcp/0",
 			},
-			AnnounceAddresses:   []string{},
-			NoAnnounceAddresses: []string{},
+			QUICListenAddresses:         []string{},
+			WebTransportListenAddresses: []string{},
+			AnnounceAddresses:           []string{},
+			NoAnnounceAddresses:         []string{},
+			StaticRelays:                []string{},
 
 			ConnMgrLow:   150,
 			ConnMgrHigh:  180,
@@ -81,15 +84,32 @@ var (
 func DefaultFullNode() *FullNode {
 	return &FullNode{
 		Common: defCommon(),
+		Wallet: Wallet{
+			Vault: VaultKeystore{
+				KVMount:      "secret",
+				KVPath:       "lotus/wallet",
+				TransitMount: "transit",
+				AppRoleMount: "approle",
+			},
+			HSM: HSMSigner{
+				Keys: []string{},
+			},
+		},
 		Fees: FeeConfig{
-			DefaultMaxFee: DefaultDefaultMaxFee,
+			DefaultMaxFee:          DefaultDefaultMaxFee,
+			ReplaceByFeeMinPercent: 110,
+			ReplaceByFeeOverrides:  []string{},
+			GasPremiumEstimator:    "median",
+			GasPremiumPercentile:   60,
+			GasPremiumWindow:       20,
 		},
 		Client: Client{
 			SimultaneousTransfersForStorage:   DefaultSimultaneousTransfers,
 			SimultaneousTransfersForRetrieval: DefaultSimultaneousTransfers,
 		},
 		Chainstore: Chainstore{
-			EnableSplitstore: true,
+			EnableSplitstore:  true,
+			BlockstoreBackend: "badger",
 			Splitstore: Splitstore{
 				ColdStoreType: "discard",
 				HotStoreType:  "badger",
@@ -99,6 +119,8 @@ func DefaultFullNode() *FullNode {
 				HotStoreMaxSpaceTarget:       650_000_000_000,
 				HotStoreMaxSpaceThreshold:    150_000_000_000,
 				HotstoreMaxSpaceSafetyBuffer: 50_000_000_000,
+
+				ColdStoreGCRetainState: -1,
 			},
 		},
 		Cluster: *DefaultUserRaftConfig(),
@@ -114,6 +136,26 @@ func DefaultFullNode() *FullNode {
 				MaxFilterHeightRange:     2880, // conservative limit of one day
 			},
 		},
+		Migration: MigrationConfig{
+			JobQueueSize:    1000,
+			ResultQueueSize: 100,
+		},
+		Bitswap: BitswapConfig{
+			MaxServeTasks: 8,
+		},
+		Beacon: BeaconConfig{
+			EntryCacheHorizon: Duration(24 * time.Hour),
+		},
+		Mpool: MpoolConfig{
+			PrioritySenders:                  []string{},
+			MaxActorPendingMessages:          1000,
+			MaxUntrustedActorPendingMessages: 10,
+			MaxNonceGap:                      4,
+			SelectionStrategy:                "default",
+			SelectionMaxPerSender:            50,
+			LocalHistory:                     true,
+			MemoryBudget:                     0,
+		},
 	}
 }
 
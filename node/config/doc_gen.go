@@ -55,6 +55,48 @@ your node if metadata log is disabled`,
 			Comment: ``,
 		},
 	},
+	"BeaconConfig": []DocField{
+		{
+			Name: "FallbackServers",
+			Type: "[]string",
+
+			Comment: `FallbackServers are groups of drand http endpoints of the current drand network, failed over
+to in order when none of the builtin endpoints can serve randomness, e.g. during an outage of
+the drand relays. Each entry is a group of comma separated URLs. The status of the groups can
+be inspected with 'lotus chain beacon-status'.`,
+		},
+		{
+			Name: "EntryCacheHorizon",
+			Type: "Duration",
+
+			Comment: `EntryCacheHorizon is how long validated beacon entries are kept in the metadata datastore,
+so that they don't need to be fetched again after a restart. 0 disables the persistent cache.`,
+		},
+	},
+	"BitswapConfig": []DocField{
+		{
+			Name: "MaxServeTasks",
+			Type: "int",
+
+			Comment: `MaxServeTasks is the maximum number of bitswap requests of other peers served concurrently.
+Lower values bound the disk load of peers fetching chain blocks from this node.`,
+		},
+		{
+			Name: "PeerBandwidthLimit",
+			Type: "int64",
+
+			Comment: `PeerBandwidthLimit caps the average rate, in bytes per second, at which blocks are served
+over bitswap to any single peer. The wants of a peer over its cap are refused until it is
+back under it. 0 disables the cap.`,
+		},
+		{
+			Name: "PeerBandwidthBurst",
+			Type: "int64",
+
+			Comment: `PeerBandwidthBurst is the number of bytes a peer can be served at once before its
+bandwidth cap applies. When 0, it is one second worth of PeerBandwidthLimit.`,
+		},
+	},
 	"Chainstore": []DocField{
 		{
 			Name: "EnableSplitstore",
@@ -68,6 +110,120 @@ your node if metadata log is disabled`,
 
 			Comment: ``,
 		},
+		{
+			Name: "BlockstoreBackend",
+			Type: "string",
+
+			Comment: `BlockstoreBackend selects the key-value store backing the chain blockstore. The only
+built-in backend is "badger"; others can be registered with repo.RegisterBlockstoreBackend.
+The backend is recorded when the blockstore is created, and an existing blockstore must be
+converted before it can be opened with a different backend.`,
+		},
+		{
+			Name: "ChainCacheSize",
+			Type: "uint64",
+
+			Comment: `ChainCacheSize is the memory budget, in bytes, of a read-through cache of the blocks read
+from the chain blockstore (block headers and messages). 0 disables the cache.`,
+		},
+		{
+			Name: "StateCacheSize",
+			Type: "uint64",
+
+			Comment: `StateCacheSize is the memory budget, in bytes, of a read-through cache of the blocks read
+from the state blockstore, which saves repeatedly loading the same state tree nodes when
+serving state queries. 0 disables the cache.`,
+		},
+		{
+			Name: "StateRetentionEpochs",
+			Type: "int64",
+
+			Comment: `StateRetentionEpochs is the number of epochs of state trees kept by a node not running the
+splitstore; older state trees and receipts are pruned as the chain advances, while all block
+headers and messages are kept. State queries below the retention window fail with a "state
+pruned" error. It must be at least a finality (900 epochs); 0 keeps all state.`,
+		},
+		{
+			Name: "CheckpointsFile",
+			Type: "string",
+
+			Comment: `CheckpointsFile is the path of a JSON file listing trusted checkpoints, as written by
+'lotus sync checkpoint export': the tipsets the chain must go through at given epochs. The
+checkpoints are added to those already stored on startup, and the node refuses to sync any
+chain conflicting with them.`,
+		},
+		{
+			Name: "ArchivalBackfill",
+			Type: "bool",
+
+			Comment: `ArchivalBackfill makes a node whose state doesn't go back to the genesis, such as a node
+imported from a lite snapshot, fetch the missing historical state from the network in the
+background, one epoch at a time going backwards, verifying each epoch by executing it, until
+the node is archival. Recent state is served meanwhile. It can't be used with the splitstore
+or StateRetentionEpochs.`,
+		},
+		{
+			Name: "TipSetStateCacheSize",
+			Type: "int",
+
+			Comment: `TipSetStateCacheSize bounds the number of computed tipset states (state and receipt roots)
+kept in memory, evicting the least recently used ones past it. 0 keeps them all.`,
+		},
+		{
+			Name: "ExecutionBreakerThreshold",
+			Type: "int",
+
+			Comment: `ExecutionBreakerThreshold is the number of consecutive failures to execute a tipset within
+five minutes after which its execution is refused for a minute, so that a tipset that
+deterministically fails doesn't keep the node busy. Once the minute has passed, one execution
+is let through again: its success resets the count, while its failure refuses executions for
+another minute. This also applies to the tipsets being synced. 0, the default, disables the
+breaker.`,
+		},
+		{
+			Name: "PersistentStateCacheSize",
+			Type: "int",
+
+			Comment: `PersistentStateCacheSize is the number of computed tipset states kept in the metadata
+datastore, so that they survive restarts and recently executed tipsets don't have to be
+executed again after one. The cache can be purged with 'lotus state purge-cache'. 0 disables
+the persistent cache.`,
+		},
+		{
+			Name: "SpeculativeExecution",
+			Type: "bool",
+
+			Comment: `SpeculativeExecution makes the node execute incoming tipsets in the background before they
+are validated, so that their state is already computed by the time they become the head.
+An invalid tipset only wastes the work; one tipset is executed at a time.`,
+		},
+		{
+			Name: "StateCacheSnapshot",
+			Type: "bool",
+
+			Comment: `StateCacheSnapshot makes the node write the most recently used computed tipset states to the
+metadata datastore when it stops, including on SIGTERM, and load them back when it starts,
+saving their re-execution after a restart. Writing the snapshot is bounded in time.`,
+		},
+		{
+			Name: "ExecutionTraceMaxNodes",
+			Type: "int",
+
+			Comment: `ExecutionTraceMaxNodes bounds the number of execution trace nodes (calls and subcalls) kept
+when tracing the messages of a tipset, as done by StateCompute and the execution trace APIs.
+Past it, further subcalls are omitted, depth first, and the results are marked as truncated.
+0 means unlimited.`,
+		},
+		{
+			Name: "ParallelExecutionWorkers",
+			Type: "int",
+
+			Comment: `ParallelExecutionWorkers lets replays of past tipsets (the archival backfill, receipt
+verification and state forwarding) execute the messages of a tipset on up to that many VMs
+concurrently, falling back to serial execution when the messages turn out not to be
+independent. Blocks are always validated with serial execution. Tipsets before network
+version 15 are always executed serially. 0 disables parallel execution.`,
+		},
 	},
 	"Client": []DocField{
 		{
@@ -394,6 +550,37 @@ the database must already exist and be writeable. If a relative path is provided
 relative to the CWD (current working directory).`,
 		},
 	},
+	"FaultReporterConfig": []DocField{
+		{
+			Name: "EnableConsensusFaultReporter",
+			Type: "bool",
+
+			Comment: `EnableConsensusFaultReporter runs a consensus fault reporter in the node: the blocks it
+receives are checked for consensus faults (double-fork mining, time-offset mining and
+parent-grinding), which are reported to the miner actor of the faulty miner, in exchange
+for a reward.`,
+		},
+		{
+			Name: "ConsensusFaultReporterAddress",
+			Type: "string",
+
+			Comment: `ConsensusFaultReporterAddress is the wallet address the reports are sent from. When
+empty, the default wallet address is used.`,
+		},
+		{
+			Name: "DryRun",
+			Type: "bool",
+
+			Comment: `DryRun only logs the consensus faults found, without reporting them.`,
+		},
+		{
+			Name: "TrackRewards",
+			Type: "bool",
+
+			Comment: `TrackRewards waits for the reports sent to land on chain, and logs the rewards they
+earned along with their gas cost.`,
+		},
+	},
 	"FeeConfig": []DocField{
 		{
 			Name: "DefaultMaxFee",
@@ -401,6 +588,50 @@ relative to the CWD (current working directory).`,
 
 			Comment: ``,
 		},
+		{
+			Name: "ReplaceByFeeMinPercent",
+			Type: "int64",
+
+			Comment: `ReplaceByFeeMinPercent is the minimum gas premium of a message replacing a pending message
+with the same nonce, in percent of the gas premium of the replaced message. Values below
+the 110 percent of the network are refused, as such replacements wouldn't propagate.`,
+		},
+		{
+			Name: "ReplaceByFeeMinIncrease",
+			Type: "int64",
+
+			Comment: `ReplaceByFeeMinIncrease is the minimum increase of the gas premium of a message replacing a
+pending message, in attoFIL per gas unit.`,
+		},
+		{
+			Name: "ReplaceByFeeOverrides",
+			Type: "[]string",
+
+			Comment: `ReplaceByFeeOverrides override the replace-by-fee policy for the messages of some senders,
+as '<address>:<min percent>[:<min increase>]' entries. The policy applying to a sender
+can be inspected with 'lotus mpool rbf-policy'.`,
+		},
+		{
+			Name: "GasPremiumEstimator",
+			Type: "string",
+
+			Comment: `GasPremiumEstimator selects how gas premiums are estimated: 'median' estimates them from
+the premiums of the recent messages filling half the gas target of the blocks, and
+'percentile' as the median over GasPremiumWindow tipsets of the GasPremiumPercentile
+percentile of the premiums of their messages, weighted by gas limit.`,
+		},
+		{
+			Name: "GasPremiumPercentile",
+			Type: "int",
+
+			Comment: `GasPremiumPercentile is the percentile, between 1 and 100, of the 'percentile' estimator.`,
+		},
+		{
+			Name: "GasPremiumWindow",
+			Type: "int",
+
+			Comment: `GasPremiumWindow is the number of recent tipsets, up to 1024, of the 'percentile' estimator.`,
+		},
 	},
 	"FevmConfig": []DocField{
 		{
@@ -467,6 +698,95 @@ Set to 0 to keep all mappings`,
 
 			Comment: ``,
 		},
+		{
+			Name: "Migration",
+			Type: "MigrationConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "Sync",
+			Type: "SyncConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "Bitswap",
+			Type: "BitswapConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "Beacon",
+			Type: "BeaconConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "FaultReporter",
+			Type: "FaultReporterConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "Mpool",
+			Type: "MpoolConfig",
+
+			Comment: ``,
+		},
+	},
+	"HSMSigner": []DocField{
+		{
+			Name: "Backend",
+			Type: "string",
+
+			Comment: `Backend is "kms" to sign with AWS KMS, or "pkcs11" to sign with a PKCS#11 HSM through the
+OpenSC pkcs11-tool; empty disables HSM signing.`,
+		},
+		{
+			Name: "Keys",
+			Type: "[]string",
+
+			Comment: `Keys maps addresses to the keys signing for them, as '<address>=<key id>' entries. Key ids
+are the ARNs, ids or aliases of KMS keys, or the hex encoded CKA_ID of PKCS#11 keys.`,
+		},
+		{
+			Name: "KMSRegion",
+			Type: "string",
+
+			Comment: `KMSRegion is the AWS region of the KMS keys. The credentials are read from the
+AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.`,
+		},
+		{
+			Name: "KMSEndpoint",
+			Type: "string",
+
+			Comment: `KMSEndpoint overrides the KMS endpoint of the region, e.g. for VPC endpoints.`,
+		},
+		{
+			Name: "PKCS11Module",
+			Type: "string",
+
+			Comment: `PKCS11Module is the path of the PKCS#11 library of the HSM.`,
+		},
+		{
+			Name: "PKCS11TokenLabel",
+			Type: "string",
+
+			Comment: `PKCS11TokenLabel is the label of the token holding the keys, the first token when empty.`,
+		},
+		{
+			Name: "PKCS11PINFile",
+			Type: "string",
+
+			Comment: `PKCS11PINFile is the path of the file holding the user PIN of the token.`,
+		},
+		{
+			Name: "PKCS11Tool",
+			Type: "string",
+
+			Comment: `PKCS11Tool is the path of the pkcs11-tool binary, looked up in PATH when empty.`,
+		},
 	},
 	"IndexConfig": []DocField{
 		{
@@ -475,6 +795,14 @@ Set to 0 to keep all mappings`,
 
 			Comment: `EnableMsgIndex enables indexing of messages on chain.`,
 		},
+		{
+			Name: "EnableAddressIndex",
+			Type: "bool",
+
+			Comment: `EnableAddressIndex enables indexing of messages by sender and recipient address, used by
+StateListMessages instead of walking the chain. New tipsets are indexed as they are applied,
+and the chain is backfilled in the background.`,
+		},
 	},
 	"IndexProviderConfig": []DocField{
 		{
@@ -528,6 +856,37 @@ datastore if any is present.`,
 
 			Comment: `Binding address for the libp2p host - 0 means random port.
 Format: multiaddress; see https://multiformats.io/multiaddr/`,
+		},
+		{
+			Name: "QUICListenAddresses",
+			Type: "[]string",
+
+			Comment: `QUICListenAddresses are listen addresses of the QUIC transport, used along with
+ListenAddresses. On some networks QUIC performs much better than TCP.
+Format: multiaddress, e.g. /ip4/0.0.0.0/udp/0/quic-v1`,
+		},
+		{
+			Name: "WebTransportListenAddresses",
+			Type: "[]string",
+
+			Comment: `WebTransportListenAddresses are listen addresses of the WebTransport transport, used along
+with ListenAddresses.
+Format: multiaddress, e.g. /ip4/0.0.0.0/udp/0/quic-v1/webtransport`,
+		},
+		{
+			Name: "Transports",
+			Type: "[]string",
+
+			Comment: `Transports are the transports the node dials and listens over, among "tcp", "quic",
+"webtransport" and "websocket"; all of them are enabled when empty. The listen addresses of
+other transports are ignored.`,
+		},
+		{
+			Name: "PreferTLS",
+			Type: "bool",
+
+			Comment: `PreferTLS makes TLS preferred over Noise when securing TCP and websocket connections.
+QUIC and WebTransport connections are always secured with TLS.`,
 		},
 		{
 			Name: "AnnounceAddresses",
@@ -554,7 +913,11 @@ Format: multiaddress`,
 			Name: "ProtectedPeers",
 			Type: "[]string",
 
-			Comment: ``,
+			Comment: `ProtectedPeers are peers the connection manager never prunes, given a high pubsub score so that
+they aren't scored into disconnection. Entries are either peer IDs or multiaddrs ending with
+/p2p/<peer ID>; the peers given with addresses are dialed on startup and redialed whenever
+they disconnect.
+Format: multiaddress or peer ID`,
 		},
 		{
 			Name: "DisableNatPortMap",
@@ -564,6 +927,35 @@ Format: multiaddress`,
 open up an external port and forward it to the port lotus is running on.
 When this works (i.e., when your router supports NAT port forwarding),
 it makes the local lotus node accessible from the public internet`,
+		},
+		{
+			Name: "EnableRelayClient",
+			Type: "bool",
+
+			Comment: `EnableRelayClient makes the node reachable through StaticRelays when it is behind a NAT, and
+lets it dial peers only reachable through relays. Relayed connections are limited in time and
+bandwidth, and are upgraded to direct ones when EnableHolePunching is set.`,
+		},
+		{
+			Name: "StaticRelays",
+			Type: "[]string",
+
+			Comment: `StaticRelays are the circuit relay v2 servers the relay client reserves a slot on.
+Format: multiaddress ending with /p2p/<peer ID>`,
+		},
+		{
+			Name: "EnableRelayService",
+			Type: "bool",
+
+			Comment: `EnableRelayService makes the node act as a circuit relay v2 server for other peers when it is
+publicly reachable, within the default resource limits of the relay service.`,
+		},
+		{
+			Name: "EnableHolePunching",
+			Type: "bool",
+
+			Comment: `EnableHolePunching upgrades relayed connections to direct connections through hole punching,
+so that nodes behind NATs can connect to each other without port forwarding.`,
 		},
 		{
 			Name: "ConnMgrLow",
@@ -587,6 +979,35 @@ count towards this limit.`,
 			Comment: `ConnMgrGrace is a time duration that new connections are immune from being
 closed by the connection manager.`,
 		},
+		{
+			Name: "SystemLimits",
+			Type: "ResourceLimits",
+
+			Comment: `SystemLimits are the limits of the libp2p resource manager for the whole node; connections
+and streams exceeding them are refused or reset. Zero values keep the defaults, which scale
+with the available memory and file descriptors and with ConnMgrHigh, and limits.json in the
+repo overrides both. The usage and limits of each scope can be inspected with 'lotus net stat'
+and 'lotus net limit'.`,
+		},
+		{
+			Name: "TransientLimits",
+			Type: "ResourceLimits",
+
+			Comment: `TransientLimits are the limits of the connections and streams not yet attached to a peer or
+protocol.`,
+		},
+		{
+			Name: "ProtocolDefaultLimits",
+			Type: "ResourceLimits",
+
+			Comment: `ProtocolDefaultLimits are the limits of each protocol.`,
+		},
+		{
+			Name: "PeerDefaultLimits",
+			Type: "ResourceLimits",
+
+			Comment: `PeerDefaultLimits are the limits of each peer.`,
+		},
 	},
 	"Logging": []DocField{
 		{
@@ -596,6 +1017,37 @@ closed by the connection manager.`,
 			Comment: `SubsystemLevels specify per-subsystem log levels`,
 		},
 	},
+	"MigrationConfig": []DocField{
+		{
+			Name: "MaxWorkers",
+			Type: "int",
+
+			Comment: `MaxWorkers is the number of workers used to migrate state at network upgrades. Pre-migrations
+use half of them. When 0, one worker per CPU is used, or LOTUS_MIGRATION_MAX_WORKER_COUNT
+if set.`,
+		},
+		{
+			Name: "JobQueueSize",
+			Type: "uint",
+
+			Comment: `JobQueueSize is the number of actor migration jobs buffered in memory during a migration.
+Lower it, together with ResultQueueSize, on memory constrained machines.`,
+		},
+		{
+			Name: "ResultQueueSize",
+			Type: "uint",
+
+			Comment: `ResultQueueSize is the number of actor migration results buffered in memory during a migration.`,
+		},
+		{
+			Name: "PreMigrationLookahead",
+			Type: "int64",
+
+			Comment: `PreMigrationLookahead is the number of epochs before a network upgrade at which the first
+pre-migration is started. Larger values give slow machines more time to pre-compute the
+migration. When 0, the default of each upgrade is used.`,
+		},
+	},
 	"MinerAddressConfig": []DocField{
 		{
 			Name: "PreCommitControl",
@@ -726,6 +1178,72 @@ over the worker address if this flag is set.`,
 			Comment: ``,
 		},
 	},
+	"MpoolConfig": []DocField{
+		{
+			Name: "PrioritySenders",
+			Type: "[]string",
+
+			Comment: `PrioritySenders are addresses whose messages are exempt from the caps on the number of
+pending messages of an actor, are never pruned, and are selected first when building
+blocks; e.g. the hot wallets of exchanges or payout services sending bursts of messages.`,
+		},
+		{
+			Name: "MaxActorPendingMessages",
+			Type: "int",
+
+			Comment: `MaxActorPendingMessages is the maximum number of pending messages of a sender, between 1
+and 100000. Further messages are refused until some are included on chain.`,
+		},
+		{
+			Name: "MaxUntrustedActorPendingMessages",
+			Type: "int",
+
+			Comment: `MaxUntrustedActorPendingMessages is the maximum number of pending messages of a sender
+received from the network, between 1 and MaxActorPendingMessages.`,
+		},
+		{
+			Name: "MaxNonceGap",
+			Type: "uint64",
+
+			Comment: `MaxNonceGap is the maximum gap, up to 100, between the nonce of a message published by
+this node and the next nonce of its sender. Messages received from the network can't
+have any gap.`,
+		},
+		{
+			Name: "SelectionStrategy",
+			Type: "string",
+
+			Comment: `SelectionStrategy selects the messages of the blocks mined on top of this node: 'default'
+selects them greedily or optimally depending on the ticket quality of the block, 'greedy'
+and 'optimal' always do, and 'fair' is like 'default' but selects at most
+SelectionMaxPerSender messages from each sender but the priority ones. Custom builds can
+register further strategies.`,
+		},
+		{
+			Name: "SelectionMaxPerSender",
+			Type: "int",
+
+			Comment: `SelectionMaxPerSender is the maximum number of messages of a sender in a block of the
+'fair' selection strategy.`,
+		},
+		{
+			Name: "LocalHistory",
+			Type: "bool",
+
+			Comment: `LocalHistory records the messages pushed through this node, with the messages replacing
+them, and tracks their outcome on chain, for 'lotus mpool history'.`,
+		},
+		{
+			Name: "MemoryBudget",
+			Type: "int64",
+
+			Comment: `MemoryBudget is the estimated memory, in bytes, the pending messages may hold; 0 disables
+it. Above it, the last pending messages of the senders are evicted, lowest gas premium
+first, then furthest ahead of the state nonce of their sender first, sparing local and
+priority senders. Nodes relaying gossip to the public should set it to bound their memory
+during spam.`,
+		},
+	},
 	"ProvingConfig": []DocField{
 		{
 			Name: "ParallelCheckLimit",
@@ -912,6 +1430,73 @@ This property is used only if ElasticSearchTracer propery is set.`,
 
 			Comment: `Auth token that will be passed with logs to elasticsearch - used for weighted peers score.`,
 		},
+		{
+			Name: "DeliveryTraceFile",
+			Type: "string",
+
+			Comment: `DeliveryTraceFile is a file the deliveries, duplicates and rejections of the pubsub messages
+of all topics are written to as JSON lines from startup; it is rotated as it grows. The trace
+can also be started and stopped at runtime with 'lotus net pubsub-trace'.
+Format: file path`,
+		},
+		{
+			Name: "DeliveryTraceEndpoint",
+			Type: "string",
+
+			Comment: `DeliveryTraceEndpoint is a URL batches of the delivery trace events are POSTed to, as JSON
+lines, instead of a file.
+Format: http(s) URL`,
+		},
+	},
+	"ResourceLimits": []DocField{
+		{
+			Name: "Memory",
+			Type: "int64",
+
+			Comment: `Memory is the maximum memory reserved in the scope, in bytes.`,
+		},
+		{
+			Name: "Streams",
+			Type: "int",
+
+			Comment: `Streams is the maximum number of streams.`,
+		},
+		{
+			Name: "StreamsInbound",
+			Type: "int",
+
+			Comment: `StreamsInbound is the maximum number of inbound streams.`,
+		},
+		{
+			Name: "StreamsOutbound",
+			Type: "int",
+
+			Comment: `StreamsOutbound is the maximum number of outbound streams.`,
+		},
+		{
+			Name: "Conns",
+			Type: "int",
+
+			Comment: `Conns is the maximum number of connections.`,
+		},
+		{
+			Name: "ConnsInbound",
+			Type: "int",
+
+			Comment: `ConnsInbound is the maximum number of inbound connections.`,
+		},
+		{
+			Name: "ConnsOutbound",
+			Type: "int",
+
+			Comment: `ConnsOutbound is the maximum number of outbound connections.`,
+		},
+		{
+			Name: "FD",
+			Type: "int",
+
+			Comment: `FD is the maximum number of file descriptors used by connections.`,
+		},
 	},
 	"RetrievalPricing": []DocField{
 		{
@@ -953,6 +1538,39 @@ default value is true`,
 This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "external".`,
 		},
 	},
+	"S3ColdStore": []DocField{
+		{
+			Name: "Endpoint",
+			Type: "string",
+
+			Comment: `Endpoint is the base URL of the object store, e.g. https://s3.us-east-1.amazonaws.com.`,
+		},
+		{
+			Name: "Region",
+			Type: "string",
+
+			Comment: `Region is the region requests are signed for.`,
+		},
+		{
+			Name: "Bucket",
+			Type: "string",
+
+			Comment: `Bucket is the bucket blocks are stored in; it must exist.`,
+		},
+		{
+			Name: "Prefix",
+			Type: "string",
+
+			Comment: `Prefix is prepended to the object keys of blocks.`,
+		},
+		{
+			Name: "PathStyle",
+			Type: "bool",
+
+			Comment: `PathStyle addresses the bucket as a path of the endpoint rather than as a subdomain,
+as most self-hosted object stores require.`,
+		},
+	},
 	"SealerConfig": []DocField{
 		{
 			Name: "ParallelFetchLimit",
@@ -1279,7 +1897,9 @@ Submitting a smaller number of prove commits per epoch would reduce the possibil
 			Type: "string",
 
 			Comment: `ColdStoreType specifies the type of the coldstore.
-It can be "messages" (default) to store only messages, "universal" to store all chain state or "discard" for discarding cold blocks.`,
+It can be "messages" (default) to store only messages, "universal" to store all chain state or "discard" for discarding cold blocks.
+It can also be "s3" to store all chain state in the S3-compatible object store configured in ColdStoreS3,
+or "remote" to read cold blocks from the node configured in ColdStoreRemoteAPI.`,
 		},
 		{
 			Name: "HotStoreType",
@@ -1339,6 +1959,45 @@ exceeds HotstoreMaxSpaceTarget - HotstoreMaxSpaceThreshold`,
 is set.  Moving GC will not occur when total moving size exceeds
 HotstoreMaxSpaceTarget - HotstoreMaxSpaceSafetyBuffer`,
 		},
+		{
+			Name: "ColdStoreGCInterval",
+			Type: "Duration",
+
+			Comment: `ColdStoreGCInterval sets the interval at which the coldstore is pruned automatically,
+as with ` + "`" + `lotus chain prune compact-cold` + "`" + `. A value of 0 (default) disables scheduled pruning.`,
+		},
+		{
+			Name: "ColdStoreMaxSpaceTarget",
+			Type: "uint64",
+
+			Comment: `ColdStoreMaxSpaceTarget sets a target max disk size for the coldstore. When it is
+exceeded the coldstore is pruned automatically, with moving GC.
+A value of 0 (default) disables it.`,
+		},
+		{
+			Name: "ColdStoreGCRetainState",
+			Type: "int64",
+
+			Comment: `ColdStoreGCRetainState sets how many finalities of state past the compaction boundary
+automatic prunes retain in the coldstore; -1 (default) retains all state reachable from
+the chain, and 0 retains none.`,
+		},
+		{
+			Name: "ColdStoreRemoteAPI",
+			Type: "string",
+
+			Comment: `ColdStoreRemoteAPI is the API info, in the TOKEN:MULTIADDR form of FULLNODE_API_INFO, of
+the lotus node serving the blockstore of the "remote" coldstore type. With it, several nodes
+share the cold data of a single node, read through its /rest/v0/chain/blockstore endpoint,
+while keeping their own hotstores; cold blocks are discarded rather than written to it.
+The token needs the read permission.`,
+		},
+		{
+			Name: "ColdStoreS3",
+			Type: "S3ColdStore",
+
+			Comment: `ColdStoreS3 configures the object store of the "s3" coldstore type.`,
+		},
 	},
 	"StorageMiner": []DocField{
 		{
@@ -1396,6 +2055,31 @@ HotstoreMaxSpaceTarget - HotstoreMaxSpaceSafetyBuffer`,
 			Comment: ``,
 		},
 	},
+	"SyncConfig": []DocField{
+		{
+			Name: "BootstrapSnapshotURL",
+			Type: "string",
+
+			Comment: `BootstrapSnapshotURL is the URL of a snapshot imported when the node starts with a repo
+holding no chain yet, so that a new node syncs from the snapshot without manual import
+steps. BootstrapSnapshotTipSet must be set with it.`,
+		},
+		{
+			Name: "BootstrapSnapshotTipSet",
+			Type: "string",
+
+			Comment: `BootstrapSnapshotTipSet is the key of the tipset the bootstrap snapshot must be rooted at,
+as a comma separated list of block CIDs; snapshots rooted anywhere else are refused.`,
+		},
+		{
+			Name: "TrustedUpstream",
+			Type: "string",
+
+			Comment: `TrustedUpstream is the API info (token:multiaddr) of a lotus node this node follows. When
+set, the node only syncs the heads announced by the upstream, and instead of validating
+their blocks only executes them to check their state; useful for read replicas.`,
+		},
+	},
 	"UserRaftConfig": []DocField{
 		{
 			Name: "ClusterModeEnabled",
@@ -1458,6 +2142,77 @@ copies that we keep as backups (renaming) after cleanup.`,
 			Comment: `Tracing enables propagation of contexts across binary boundaries.`,
 		},
 	},
+	"VaultKeystore": []DocField{
+		{
+			Name: "Address",
+			Type: "string",
+
+			Comment: `Address is the URL of the Vault server, e.g. https://vault.example.com:8200.`,
+		},
+		{
+			Name: "Namespace",
+			Type: "string",
+
+			Comment: `Namespace is the Vault Enterprise namespace of the engines, if any.`,
+		},
+		{
+			Name: "CACert",
+			Type: "string",
+
+			Comment: `CACert is the path of the PEM bundle the certificate of the server is verified with,
+the system roots when empty.`,
+		},
+		{
+			Name: "KVMount",
+			Type: "string",
+
+			Comment: `KVMount is the mount path of the KV version 2 secrets engine the keys are stored in.`,
+		},
+		{
+			Name: "KVPath",
+			Type: "string",
+
+			Comment: `KVPath is the path of the keys in the KV engine.`,
+		},
+		{
+			Name: "TransitMount",
+			Type: "string",
+
+			Comment: `TransitMount is the mount path of the transit secrets engine.`,
+		},
+		{
+			Name: "TransitKey",
+			Type: "string",
+
+			Comment: `TransitKey is the transit key the keys are encrypted with before being stored in the KV
+engine; they are stored as they are when empty.`,
+		},
+		{
+			Name: "Token",
+			Type: "string",
+
+			Comment: `Token authenticates to Vault when AppRoleID is empty. Prefer setting it with the
+LOTUS_WALLET_VAULT_TOKEN environment variable, to keep it out of the config file.`,
+		},
+		{
+			Name: "AppRoleMount",
+			Type: "string",
+
+			Comment: `AppRoleMount is the mount path of the AppRole auth method.`,
+		},
+		{
+			Name: "AppRoleID",
+			Type: "string",
+
+			Comment: `AppRoleID is the role id to log in with AppRole, instead of using Token.`,
+		},
+		{
+			Name: "AppRoleSecretIDFile",
+			Type: "string",
+
+			Comment: `AppRoleSecretIDFile is the path of the file holding the secret id of the AppRole login.`,
+		},
+	},
 	"Wallet": []DocField{
 		{
 			Name: "RemoteBackend",
@@ -1477,5 +2232,18 @@ copies that we keep as backups (renaming) after cleanup.`,
 
 			Comment: ``,
 		},
+		{
+			Name: "Vault",
+			Type: "VaultKeystore",
+
+			Comment: `Vault stores the keys of the local wallet in HashiCorp Vault instead of the keystore of
+the repo, when its Address is set.`,
+		},
+		{
+			Name: "HSM",
+			Type: "HSMSigner",
+
+			Comment: `HSM signs for some secp256k1 addresses with keys held in AWS KMS or in a PKCS#11 HSM.`,
+		},
 	},
 }
@@ -29,6 +29,13 @@ type FullNode struct {
 	Cluster    UserRaftConfig
 	Fevm       FevmConfig
 	Index      IndexConfig
+	Migration  MigrationConfig
+	Sync       SyncConfig
+	Bitswap    BitswapConfig
+	Beacon     BeaconConfig
+
+	FaultReporter FaultReporterConfig
+	Mpool         MpoolConfig
 }
 
 // // Common
@@ -531,6 +538,21 @@ type Libp2p struct {
 	// Binding address for the libp2p host - 0 means random port.
 	// Format: multiaddress; see https://multiformats.io/multiaddr/
 	ListenAddresses []string
+	// QUICListenAddresses are listen addresses of the QUIC transport, used along with
+	// ListenAddresses. On some networks QUIC performs much better than TCP.
+	// Format: multiaddress, e.g. /ip4/0.0.0.0/udp/0/quic-v1
+	QUICListenAddresses []string
+	// WebTransportListenAddresses are listen addresses of the WebTransport transport, used along
+	// with ListenAddresses.
+	// Format: multiaddress, e.g. /ip4/0.0.0.0/udp/0/quic-v1/webtransport
+	WebTransportListenAddresses []string
+	// Transports are the transports the node dials and listens over, among "tcp", "quic",
+	// "webtransport" and "websocket"; all of them are enabled when empty. The listen addresses of
+	// other transports are ignored.
+	Transports []string
+	// PreferTLS makes TLS preferred over Noise when securing TCP and websocket connections.
+	// QUIC and WebTransport connections are always secured with TLS.
+	PreferTLS bool
 	// Addresses to explicitally announce to other peers. If not specified,
 	// all interface addresses are announced
 	// Format: multiaddress
@@ -539,7 +561,13 @@ type Libp2p struct {
 	// Format: multiaddress
 	NoAnnounceAddresses []string
 	BootstrapPeers      []string
-	ProtectedPeers      []string
+
+	// ProtectedPeers are peers the connection manager never prunes, given a high pubsub score so that
+	// they aren't scored into disconnection. Entries are either peer IDs or multiaddrs ending with
+	// /p2p/<peer ID>; the peers given with addresses are dialed on startup and redialed whenever
+	// they disconnect.
+	// Format: multiaddress or peer ID
+	ProtectedPeers []string
 
 	// When not disabled (default), lotus asks NAT devices (e.g., routers), to
 	// open up an external port and forward it to the port lotus is running on.
@@ -547,6 +575,23 @@ type Libp2p struct {
 	// it makes the local lotus node accessible from the public internet
 	DisableNatPortMap bool
 
+	// EnableRelayClient makes the node reachable through StaticRelays when it is behind a NAT, and
+	// lets it dial peers only reachable through relays. Relayed connections are limited in time and
+	// bandwidth, and are upgraded to direct ones when EnableHolePunching is set.
+	EnableRelayClient bool
+
+	// StaticRelays are the circuit relay v2 servers the relay client reserves a slot on.
+	// Format: multiaddress ending with /p2p/<peer ID>
+	StaticRelays []string
+
+	// EnableRelayService makes the node act as a circuit relay v2 server for other peers when it is
+	// publicly reachable, within the default resource limits of the relay service.
+	EnableRelayService bool
+
+	// EnableHolePunching upgrades relayed connections to direct connections through hole punching,
+	// so that nodes behind NATs can connect to each other without port forwarding.
+	EnableHolePunching bool
+
 	// ConnMgrLow is the number of connections that the basic connection manager
 	// will trim down to.
 	ConnMgrLow uint
@@ -557,6 +602,50 @@ type Libp2p struct {
 	// ConnMgrGrace is a time duration that new connections are immune from being
 	// closed by the connection manager.
 	ConnMgrGrace Duration
+
+	// SystemLimits are the limits of the libp2p resource manager for the whole node; connections
+	// and streams exceeding them are refused or reset. Zero values keep the defaults, which scale
+	// with the available memory and file descriptors and with ConnMgrHigh, and limits.json in the
+	// repo overrides both. The usage and limits of each scope can be inspected with 'lotus net stat'
+	// and 'lotus net limit'.
+	SystemLimits ResourceLimits
+
+	// TransientLimits are the limits of the connections and streams not yet attached to a peer or
+	// protocol.
+	TransientLimits ResourceLimits
+
+	// ProtocolDefaultLimits are the limits of each protocol.
+	ProtocolDefaultLimits ResourceLimits
+
+	// PeerDefaultLimits are the limits of each peer.
+	PeerDefaultLimits ResourceLimits
+}
+
+// ResourceLimits are the limits of a libp2p resource manager scope; zero values keep the defaults.
+type ResourceLimits struct {
+	// Memory is the maximum memory reserved in the scope, in bytes.
+	Memory int64
+
+	// Streams is the maximum number of streams.
+	Streams int
+
+	// StreamsInbound is the maximum number of inbound streams.
+	StreamsInbound int
+
+	// StreamsOutbound is the maximum number of outbound streams.
+	StreamsOutbound int
+
+	// Conns is the maximum number of connections.
+	Conns int
+
+	// ConnsInbound is the maximum number of inbound connections.
+	ConnsInbound int
+
+	// ConnsOutbound is the maximum number of outbound connections.
+	ConnsOutbound int
+
+	// FD is the maximum number of file descriptors used by connections.
+	FD int
 }
 
 type Pubsub struct {
@@ -584,16 +673,103 @@ type Pubsub struct {
 	ElasticSearchIndex string
 	// Auth token that will be passed with logs to elasticsearch - used for weighted peers score.
 	TracerSourceAuth string
+
+	// DeliveryTraceFile is a file the deliveries, duplicates and rejections of the pubsub messages
+	// of all topics are written to as JSON lines from startup; it is rotated as it grows. The trace
+	// can also be started and stopped at runtime with 'lotus net pubsub-trace'.
+	// Format: file path
+	DeliveryTraceFile string
+
+	// DeliveryTraceEndpoint is a URL batches of the delivery trace events are POSTed to, as JSON
+	// lines, instead of a file.
+	// Format: http(s) URL
+	DeliveryTraceEndpoint string
 }
 
 type Chainstore struct {
 	EnableSplitstore bool
 	Splitstore       Splitstore
+
+	// BlockstoreBackend selects the key-value store backing the chain blockstore. The only
+	// built-in backend is "badger"; others can be registered with repo.RegisterBlockstoreBackend.
+	// The backend is recorded when the blockstore is created, and an existing blockstore must be
+	// converted before it can be opened with a different backend.
+	BlockstoreBackend string
+
+	// ChainCacheSize is the memory budget, in bytes, of a read-through cache of the blocks read
+	// from the chain blockstore (block headers and messages). 0 disables the cache.
+	ChainCacheSize uint64
+	// StateCacheSize is the memory budget, in bytes, of a read-through cache of the blocks read
+	// from the state blockstore, which saves repeatedly loading the same state tree nodes when
+	// serving state queries. 0 disables the cache.
+	StateCacheSize uint64
+
+	// StateRetentionEpochs is the number of epochs of state trees kept by a node not running the
+	// splitstore; older state trees and receipts are pruned as the chain advances, while all block
+	// headers and messages are kept. State queries below the retention window fail with a "state
+	// pruned" error. It must be at least a finality (900 epochs); 0 keeps all state.
+	StateRetentionEpochs int64
+
+	// CheckpointsFile is the path of a JSON file listing trusted checkpoints, as written by
+	// 'lotus sync checkpoint export': the tipsets the chain must go through at given epochs. The
+	// checkpoints are added to those already stored on startup, and the node refuses to sync any
+	// chain conflicting with them.
+	CheckpointsFile string
+
+	// ArchivalBackfill makes a node whose state doesn't go back to the genesis, such as a node
+	// imported from a lite snapshot, fetch the missing historical state from the network in the
+	// background, one epoch at a time going backwards, verifying each epoch by executing it, until
+	// the node is archival. Recent state is served meanwhile. It can't be used with the splitstore
+	// or StateRetentionEpochs.
+	ArchivalBackfill bool
+
+	// TipSetStateCacheSize bounds the number of computed tipset states (state and receipt roots)
+	// kept in memory, evicting the least recently used ones past it. 0 keeps them all.
+	TipSetStateCacheSize int
+
+	// ExecutionBreakerThreshold is the number of consecutive failures to execute a tipset within
+	// five minutes after which its execution is refused for a minute, so that a tipset that
+	// deterministically fails doesn't keep the node busy. Once the minute has passed, one execution
+	// is let through again: its success resets the count, while its failure refuses executions for
+	// another minute. This also applies to the tipsets being synced. 0, the default, disables the
+	// breaker.
+	ExecutionBreakerThreshold int
+
+	// PersistentStateCacheSize is the number of computed tipset states kept in the metadata
+	// datastore, so that they survive restarts and recently executed tipsets don't have to be
+	// executed again after one. The cache can be purged with 'lotus state purge-cache'. 0 disables
+	// the persistent cache.
+	PersistentStateCacheSize int
+
+	// SpeculativeExecution makes the node execute incoming tipsets in the background before they
+	// are validated, so that their state is already computed by the time they become the head.
+	// An invalid tipset only wastes the work; one tipset is executed at a time.
+	SpeculativeExecution bool
+
+	// StateCacheSnapshot makes the node write the most recently used computed tipset states to the
+	// metadata datastore when it stops, including on SIGTERM, and load them back when it starts,
+	// saving their re-execution after a restart. Writing the snapshot is bounded in time.
+	StateCacheSnapshot bool
+
+	// ExecutionTraceMaxNodes bounds the number of execution trace nodes (calls and subcalls) kept
+	// when tracing the messages of a tipset, as done by StateCompute and the execution trace APIs.
+	// Past it, further subcalls are omitted, depth first, and the results are marked as truncated.
+	// 0 means unlimited.
+	ExecutionTraceMaxNodes int
+
+	// ParallelExecutionWorkers lets replays of past tipsets (the archival backfill, receipt
+	// verification and state forwarding) execute the messages of a tipset on up to that many VMs
+	// concurrently, falling back to serial execution when the messages turn out not to be
+	// independent. Blocks are always validated with serial execution. Tipsets before network
+	// version 15 are always executed serially. 0 disables parallel execution.
+	ParallelExecutionWorkers int
 }
 
 type Splitstore struct {
 	// ColdStoreType specifies the type of the coldstore.
 	// It can be "messages" (default) to store only messages, "universal" to store all chain state or "discard" for discarding cold blocks.
+	// It can also be "s3" to store all chain state in the S3-compatible object store configured in ColdStoreS3,
+	// or "remote" to read cold blocks from the node configured in ColdStoreRemoteAPI.
 	ColdStoreType string
 	// HotStoreType specifies the type of the hotstore.
 	// Only currently supported value is "badger".
@@ -628,6 +804,44 @@ type Splitstore struct {
 	// is set.  Moving GC will not occur when total moving size exceeds
 	// HotstoreMaxSpaceTarget - HotstoreMaxSpaceSafetyBuffer
 	HotstoreMaxSpaceSafetyBuffer uint64
+
+	// ColdStoreGCInterval sets the interval at which the coldstore is pruned automatically,
+	// as with `lotus chain prune compact-cold`. A value of 0 (default) disables scheduled pruning.
+	ColdStoreGCInterval Duration
+	// ColdStoreMaxSpaceTarget sets a target max disk size for the coldstore. When it is
+	// exceeded the coldstore is pruned automatically, with moving GC.
+	// A value of 0 (default) disables it.
+	ColdStoreMaxSpaceTarget uint64
+	// ColdStoreGCRetainState sets how many finalities of state past the compaction boundary
+	// automatic prunes retain in the coldstore; -1 (default) retains all state reachable from
+	// the chain, and 0 retains none.
+	ColdStoreGCRetainState int64
+
+	// ColdStoreRemoteAPI is the API info, in the TOKEN:MULTIADDR form of FULLNODE_API_INFO, of
+	// the lotus node serving the blockstore of the "remote" coldstore type. With it, several nodes
+	// share the cold data of a single node, read through its /rest/v0/chain/blockstore endpoint,
+	// while keeping their own hotstores; cold blocks are discarded rather than written to it.
+	// The token needs the read permission.
+	ColdStoreRemoteAPI string
+
+	// ColdStoreS3 configures the object store of the "s3" coldstore type.
+	ColdStoreS3 S3ColdStore
+}
+
+type S3ColdStore struct {
+	// Endpoint is the base URL of the object store, e.g. https://s3.us-east-1.amazonaws.com.
+	Endpoint string
+	// Region is the region requests are signed for.
+	Region string
+	// Bucket is the bucket blocks are stored in; it must exist.
+	Bucket string
+	// Prefix is prepended to the object keys of blocks.
+	Prefix string
+	// PathStyle addresses the bucket as a path of the endpoint rather than as a subdomain,
+	// as most self-hosted object stores require.
+	PathStyle bool
+	// Credentials are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment
+	// variables.
 }
 
 // // Full Node
@@ -653,10 +867,97 @@ type Wallet struct {
 	RemoteBackend string
 	EnableLedger  bool
 	DisableLocal  bool
+
+	// Vault stores the keys of the local wallet in HashiCorp Vault instead of the keystore of
+	// the repo, when its Address is set.
+	Vault VaultKeystore
+
+	// HSM signs for some secp256k1 addresses with keys held in AWS KMS or in a PKCS#11 HSM.
+	HSM HSMSigner
+}
+
+type HSMSigner struct {
+	// Backend is "kms" to sign with AWS KMS, or "pkcs11" to sign with a PKCS#11 HSM through the
+	// OpenSC pkcs11-tool; empty disables HSM signing.
+	Backend string
+	// Keys maps addresses to the keys signing for them, as '<address>=<key id>' entries. Key ids
+	// are the ARNs, ids or aliases of KMS keys, or the hex encoded CKA_ID of PKCS#11 keys.
+	Keys []string
+
+	// KMSRegion is the AWS region of the KMS keys. The credentials are read from the
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+	KMSRegion string
+	// KMSEndpoint overrides the KMS endpoint of the region, e.g. for VPC endpoints.
+	KMSEndpoint string
+
+	// PKCS11Module is the path of the PKCS#11 library of the HSM.
+	PKCS11Module string
+	// PKCS11TokenLabel is the label of the token holding the keys, the first token when empty.
+	PKCS11TokenLabel string
+	// PKCS11PINFile is the path of the file holding the user PIN of the token.
+	PKCS11PINFile string
+	// PKCS11Tool is the path of the pkcs11-tool binary, looked up in PATH when empty.
+	PKCS11Tool string
+}
+
+type VaultKeystore struct {
+	// Address is the URL of the Vault server, e.g. https://vault.example.com:8200.
+	Address string
+	// Namespace is the Vault Enterprise namespace of the engines, if any.
+	Namespace string
+	// CACert is the path of the PEM bundle the certificate of the server is verified with,
+	// the system roots when empty.
+	CACert string
+
+	// KVMount is the mount path of the KV version 2 secrets engine the keys are stored in.
+	KVMount string
+	// KVPath is the path of the keys in the KV engine.
+	KVPath string
+	// TransitMount is the mount path of the transit secrets engine.
+	TransitMount string
+	// TransitKey is the transit key the keys are encrypted with before being stored in the KV
+	// engine; they are stored as they are when empty.
+	TransitKey string
+
+	// Token authenticates to Vault when AppRoleID is empty. Prefer setting it with the
+	// LOTUS_WALLET_VAULT_TOKEN environment variable, to keep it out of the config file.
+	Token string
+	// AppRoleMount is the mount path of the AppRole auth method.
+	AppRoleMount string
+	// AppRoleID is the role id to log in with AppRole, instead of using Token.
+	AppRoleID string
+	// AppRoleSecretIDFile is the path of the file holding the secret id of the AppRole login.
+	AppRoleSecretIDFile string
 }
 
 type FeeConfig struct {
 	DefaultMaxFee types.FIL
+
+	// ReplaceByFeeMinPercent is the minimum gas premium of a message replacing a pending message
+	// with the same nonce, in percent of the gas premium of the replaced message. Values below
+	// the 110 percent of the network are refused, as such replacements wouldn't propagate.
+	ReplaceByFeeMinPercent int64
+
+	// ReplaceByFeeMinIncrease is the minimum increase of the gas premium of a message replacing a
+	// pending message, in attoFIL per gas unit.
+	ReplaceByFeeMinIncrease int64
+
+	// ReplaceByFeeOverrides override the replace-by-fee policy for the messages of some senders,
+	// as '<address>:<min percent>[:<min increase>]' entries. The policy applying to a sender
+	// can be inspected with 'lotus mpool rbf-policy'.
+	ReplaceByFeeOverrides []string
+
+	// GasPremiumEstimator selects how gas premiums are estimated: 'median' estimates them from
+	// the premiums of the recent messages filling half the gas target of the blocks, and
+	// 'percentile' as the median over GasPremiumWindow tipsets of the GasPremiumPercentile
+	// percentile of the premiums of their messages, weighted by gas limit.
+	GasPremiumEstimator string
+
+	// GasPremiumPercentile is the percentile, between 1 and 100, of the 'percentile' estimator.
+	GasPremiumPercentile int
+
+	// GasPremiumWindow is the number of recent tipsets, up to 1024, of the 'percentile' estimator.
+	GasPremiumWindow int
 }
 
 type UserRaftConfig struct {
@@ -738,4 +1039,132 @@ type Events struct {
 type IndexConfig struct {
 	// EnableMsgIndex enables indexing of messages on chain.
 	EnableMsgIndex bool
+
+	// EnableAddressIndex enables indexing of messages by sender and recipient address, used by
+	// StateListMessages instead of walking the chain. New tipsets are indexed as they are applied,
+	// and the chain is backfilled in the background.
+	EnableAddressIndex bool
+}
+
+type MigrationConfig struct {
+	// MaxWorkers is the number of workers used to migrate state at network upgrades. Pre-migrations
+	// use half of them. When 0, one worker per CPU is used, or LOTUS_MIGRATION_MAX_WORKER_COUNT
+	// if set.
+	MaxWorkers int
+
+	// JobQueueSize is the number of actor migration jobs buffered in memory during a migration.
+	// Lower it, together with ResultQueueSize, on memory constrained machines.
+	JobQueueSize uint
+
+	// ResultQueueSize is the number of actor migration results buffered in memory during a migration.
+	ResultQueueSize uint
+
+	// PreMigrationLookahead is the number of epochs before a network upgrade at which the first
+	// pre-migration is started. Larger values give slow machines more time to pre-compute the
+	// migration. When 0, the default of each upgrade is used.
+	PreMigrationLookahead int64
+}
+
+type SyncConfig struct {
+	// BootstrapSnapshotURL is the URL of a snapshot imported when the node starts with a repo
+	// holding no chain yet, so that a new node syncs from the snapshot without manual import
+	// steps. BootstrapSnapshotTipSet must be set with it.
+	BootstrapSnapshotURL string
+
+	// BootstrapSnapshotTipSet is the key of the tipset the bootstrap snapshot must be rooted at,
+	// as a comma separated list of block CIDs; snapshots rooted anywhere else are refused.
+	BootstrapSnapshotTipSet string
+
+	// TrustedUpstream is the API info (token:multiaddr) of a lotus node this node follows. When
+	// set, the node only syncs the heads announced by the upstream, and instead of validating
+	// their blocks only executes them to check their state; useful for read replicas.
+	TrustedUpstream string
+}
+
+type BeaconConfig struct {
+	// FallbackServers are groups of drand http endpoints of the current drand network, failed over
+	// to in order when none of the builtin endpoints can serve randomness, e.g. during an outage of
+	// the drand relays. Each entry is a group of comma separated URLs. The status of the groups can
+	// be inspected with 'lotus chain beacon-status'.
+	FallbackServers []string
+
+	// EntryCacheHorizon is how long validated beacon entries are kept in the metadata datastore,
+	// so that they don't need to be fetched again after a restart. 0 disables the persistent cache.
+	EntryCacheHorizon Duration
+}
+
+type MpoolConfig struct {
+	// PrioritySenders are addresses whose messages are exempt from the caps on the number of
+	// pending messages of an actor, are never pruned, and are selected first when building
+	// blocks; e.g. the hot wallets of exchanges or payout services sending bursts of messages.
+	PrioritySenders []string
+
+	// MaxActorPendingMessages is the maximum number of pending messages of a sender, between 1
+	// and 100000. Further messages are refused until some are included on chain.
+	MaxActorPendingMessages int
+
+	// MaxUntrustedActorPendingMessages is the maximum number of pending messages of a sender
+	// received from the network, between 1 and MaxActorPendingMessages.
+	MaxUntrustedActorPendingMessages int
+
+	// MaxNonceGap is the maximum gap, up to 100, between the nonce of a message published by
+	// this node and the next nonce of its sender. Messages received from the network can't
+	// have any gap.
+	MaxNonceGap uint64
+
+	// SelectionStrategy selects the messages of the blocks mined on top of this node: 'default'
+	// selects them greedily or optimally depending on the ticket quality of the block, 'greedy'
+	// and 'optimal' always do, and 'fair' is like 'default' but selects at most
+	// SelectionMaxPerSender messages from each sender but the priority ones. Custom builds can
+	// register further strategies.
+	SelectionStrategy string
+
+	// SelectionMaxPerSender is the maximum number of messages of a sender in a block of the
+	// 'fair' selection strategy.
+	SelectionMaxPerSender int
+
+	// LocalHistory records the messages pushed through this node, with the messages replacing
+	// them, and tracks their outcome on chain, for 'lotus mpool history'.
+	LocalHistory bool
+
+	// MemoryBudget is the estimated memory, in bytes, the pending messages may hold; 0 disables
+	// it. Above it, the last pending messages of the senders are evicted, lowest gas premium
+	// first, then furthest ahead of the state nonce of their sender first, sparing local and
+	// priority senders. Nodes relaying gossip to the public should set it to bound their memory
+	// during spam.
+	MemoryBudget int64
+}
+
+type FaultReporterConfig struct {
+	// EnableConsensusFaultReporter runs a consensus fault reporter in the node: the blocks it
+	// receives are checked for consensus faults (double-fork mining, time-offset mining and
+	// parent-grinding), which are reported to the miner actor of the faulty miner, in exchange
+	// for a reward.
+	EnableConsensusFaultReporter bool
+
+	// ConsensusFaultReporterAddress is the wallet address the reports are sent from. When
+	// empty, the default wallet address is used.
+	ConsensusFaultReporterAddress string
+
+	// DryRun only logs the consensus faults found, without reporting them.
+	DryRun bool
+
+	// TrackRewards waits for the reports sent to land on chain, and logs the rewards they
+	// earned along with their gas cost.
+	TrackRewards bool
+}
+
+type BitswapConfig struct {
+	// MaxServeTasks is the maximum number of bitswap requests of other peers served concurrently.
+	// Lower values bound the disk load of peers fetching chain blocks from this node.
+	MaxServeTasks int
+
+	// PeerBandwidthLimit caps the average rate, in bytes per second, at which blocks are served
+	// over bitswap to any single peer. The wants of a peer over its cap are refused until it is
+	// back under it. 0 disables the cap.
+	PeerBandwidthLimit int64
+
+	// PeerBandwidthBurst is the number of bytes a peer can be served at once before its
+	// bandwidth cap applies. When 0, it is one second worth of PeerBandwidthLimit.
+	PeerBandwidthBurst int64
 }
@@ -96,6 +96,9 @@ type ChainAPI struct {
 	BaseBlockstore dtypes.BaseBlockstore
 
 	Repo repo.LockedRepo
+
+	Importer *ChainImporter
+	GC       *ChainGCRunner
 }
 
 func (m *ChainModule) ChainNotify(ctx context.Context) (<-chan []*api.HeadChange, error) {
@@ -727,6 +730,37 @@ func (a *ChainAPI) ChainGetEvents(ctx context.Context, root cid.Cid) ([]types.Ev
 	return ret, err
 }
 
+func (a *ChainAPI) ChainImportOnline(ctx context.Context, source string) (*api.ChainImportStatus, error) {
+	if source == "" {
+		return nil, xerrors.Errorf("no snapshot source given")
+	}
+	return a.Importer.Start(source)
+}
+
+func (a *ChainAPI) ChainImportStatus(ctx context.Context) (*api.ChainImportStatus, error) {
+	st, ok := a.Importer.Status()
+	if !ok {
+		return nil, xerrors.Errorf("no chain import was started")
+	}
+	return st, nil
+}
+
+func (a *ChainAPI) ChainGC(ctx context.Context, opts api.ChainGCOpts) (*api.ChainGCStatus, error) {
+	return a.GC.Start(opts)
+}
+
+func (a *ChainAPI) ChainGCStatus(ctx context.Context) (*api.ChainGCStatus, error) {
+	st, ok := a.GC.Status()
+	if !ok {
+		return nil, xerrors.Errorf("no chainstore gc was started")
+	}
+	return st, nil
+}
+
+func (a *ChainAPI) ChainGCCancel(ctx context.Context) (*api.ChainGCStatus, error) {
+	return a.GC.Cancel()
+}
+
 func (a *ChainAPI) ChainPrune(ctx context.Context, opts api.PruneOpts) error {
 	pruner, ok := a.BaseBlockstore.(interface {
 		PruneChain(opts api.PruneOpts) error
@@ -0,0 +1,141 @@
+package full
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+// defaultChainGCThreshold is the fraction of garbage in a value log file above which it is
+// rewritten, unless the request sets one.
+const defaultChainGCThreshold = 0.01
+
+// ChainGCRunner runs online GC on the chainstore of the running node, one at a time, and keeps
+// the status of the last run.
+type ChainGCRunner struct {
+	bs dtypes.BaseBlockstore
+
+	lk     sync.Mutex
+	last   *api.ChainGCStatus
+	cancel context.CancelFunc
+}
+
+func NewChainGCRunner(bs dtypes.BaseBlockstore) *ChainGCRunner {
+	return &ChainGCRunner{bs: bs}
+}
+
+// Start compacts the store and rewrites value log files one at a time in the background,
+// pausing for the throttle duration between rewrites to bound the IO load.
+func (g *ChainGCRunner) Start(opts api.ChainGCOpts) (*api.ChainGCStatus, error) {
+	if _, ok := g.bs.(bstore.BlockstoreGC); !ok {
+		return nil, xerrors.Errorf("chainstore doesn't support online gc: %T", g.bs)
+	}
+
+	threshold := opts.Threshold
+	if threshold == 0 {
+		threshold = defaultChainGCThreshold
+	}
+	if threshold <= 0 || threshold >= 1 {
+		return nil, xerrors.Errorf("invalid threshold %f, must be between 0 and 1", threshold)
+	}
+	if opts.Throttle < 0 {
+		return nil, xerrors.Errorf("invalid throttle duration %s", opts.Throttle)
+	}
+
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	if g.last != nil && g.last.Running {
+		return nil, xerrors.Errorf("a chainstore gc is already running")
+	}
+
+	st := &api.ChainGCStatus{
+		Running:    true,
+		Started:    build.Clock.Now(),
+		Threshold:  threshold,
+		Throttle:   opts.Throttle,
+		SizeBefore: g.size(),
+	}
+	g.last = st
+
+	ctx, stop := context.WithCancel(context.Background())
+	g.cancel = stop
+	go func() {
+		defer stop()
+		g.run(ctx, st)
+	}()
+
+	out := *st
+	return &out, nil
+}
+
+// Status returns the status of the running GC, or of the last one; the second return value is
+// false if no GC was started.
+func (g *ChainGCRunner) Status() (*api.ChainGCStatus, bool) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+
+	if g.last == nil {
+		return nil, false
+	}
+	out := *g.last
+	return &out, true
+}
+
+// Cancel stops the running GC.
+func (g *ChainGCRunner) Cancel() (*api.ChainGCStatus, error) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+
+	if g.last == nil || !g.last.Running {
+		return nil, xerrors.Errorf("no chainstore gc is running")
+	}
+	g.cancel()
+	out := *g.last
+	return &out, nil
+}
+
+func (g *ChainGCRunner) run(ctx context.Context, st *api.ChainGCStatus) {
+	gc := g.bs.(bstore.BlockstoreGC)
+	err := gc.CollectGarbage(ctx,
+		bstore.WithThreshold(st.Threshold),
+		bstore.WithThrottle(st.Throttle),
+		bstore.WithProgress(func(p bstore.BlockstoreGCProgress) {
+			g.lk.Lock()
+			defer g.lk.Unlock()
+			st.Compacted = p.Compacted
+			st.Rewrites = p.Rewrites
+		}),
+	)
+	after := g.size()
+
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	st.Running = false
+	st.Finished = build.Clock.Now()
+	st.SizeAfter = after
+	if err != nil {
+		log.Errorw("online chainstore gc failed", "error", err)
+		st.Error = err.Error()
+		return
+	}
+	log.Infow("online chainstore gc done", "rewrites", st.Rewrites, "took", st.Finished.Sub(st.Started))
+}
+
+func (g *ChainGCRunner) size() int64 {
+	sz, ok := g.bs.(bstore.BlockstoreSize)
+	if !ok {
+		return 0
+	}
+	n, err := sz.Size()
+	if err != nil {
+		log.Warnf("getting chainstore size: %s", err)
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,143 @@
+package full
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/zstd"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/httpreader"
+)
+
+// ChainImporter imports snapshots into the running node, one at a time, and keeps the status of
+// the last import.
+type ChainImporter struct {
+	cs *store.ChainStore
+	sm *stmgr.StateManager
+
+	lk   sync.Mutex
+	last *api.ChainImportStatus
+}
+
+func NewChainImporter(cs *store.ChainStore, sm *stmgr.StateManager) *ChainImporter {
+	return &ChainImporter{cs: cs, sm: sm}
+}
+
+// Start starts importing the snapshot at source, a URL or a path on the node, in the background.
+func (ci *ChainImporter) Start(source string) (*api.ChainImportStatus, error) {
+	ci.lk.Lock()
+	defer ci.lk.Unlock()
+
+	if ci.last != nil && ci.last.Running {
+		return nil, xerrors.Errorf("an import from %s is already running", ci.last.Source)
+	}
+
+	st := &api.ChainImportStatus{Source: source, Running: true, Started: build.Clock.Now()}
+	ci.last = st
+	go ci.run(context.Background(), st)
+
+	out := *st
+	return &out, nil
+}
+
+// Status returns the status of the running import, or of the last one; the second return value
+// is false if no import was started.
+func (ci *ChainImporter) Status() (*api.ChainImportStatus, bool) {
+	ci.lk.Lock()
+	defer ci.lk.Unlock()
+
+	if ci.last == nil {
+		return nil, false
+	}
+	if ci.last.Running {
+		ci.last.Progress, _ = importProgress(ci.cs)
+	}
+	out := *ci.last
+	return &out, true
+}
+
+func (ci *ChainImporter) run(ctx context.Context, st *api.ChainImportStatus) {
+	ts, err := ci.importFrom(ctx, st.Source)
+
+	ci.lk.Lock()
+	defer ci.lk.Unlock()
+	st.Running = false
+	st.Finished = build.Clock.Now()
+	st.Progress, _ = importProgress(ci.cs)
+	if err != nil {
+		log.Errorw("online chain import failed", "source", st.Source, "error", err)
+		st.Error = err.Error()
+		return
+	}
+	log.Infow("online chain import done", "source", st.Source, "tipset", ts.Key(), "height", ts.Height())
+	st.TipSet, st.Height = ts.Key(), ts.Height()
+}
+
+func (ci *ChainImporter) importFrom(ctx context.Context, source string) (*types.TipSet, error) {
+	var r io.Reader
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		rd, err := httpreader.NewResumableReader(ctx, source)
+		if err != nil {
+			return nil, xerrors.Errorf("fetching chain CAR: %w", err)
+		}
+		r = rd
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, xerrors.Errorf("opening chain CAR: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		r = f
+	}
+
+	bufr := bufio.NewReaderSize(r, 1<<20)
+	header, err := bufr.Peek(4)
+	if err != nil {
+		return nil, xerrors.Errorf("peek header: %w", err)
+	}
+
+	var ir io.Reader = bufr
+	if string(header[1:]) == "\xB5\x2F\xFD" { // zstd
+		zr := zstd.NewReader(bufr)
+		defer zr.Close() //nolint:errcheck
+		ir = zr
+	}
+
+	// The head is only switched once the state of the root tipset has been computed.
+	return ci.cs.ImportOnline(ctx, ir, func(ctx context.Context, ts *types.TipSet) error {
+		_, _, err := ci.sm.TipSetState(ctx, ts)
+		return err
+	})
+}
+
+// importProgress returns the progress of the running chain import, or of the last one, with its
+// throughput and, when the size of the CAR is known, the estimated remaining time.
+func importProgress(cs *store.ChainStore) (*api.ImportProgress, bool) {
+	p, ok := cs.ImportProgress()
+	if !ok {
+		return nil, false
+	}
+
+	out := &api.ImportProgress{
+		Running: p.Running,
+		Started: p.Started,
+		Blocks:  p.Blocks,
+		Bytes:   p.Bytes,
+		Size:    p.Size,
+	}
+	out.BytesPerSec, out.BlocksPerSec = p.Rate()
+	if p.Running {
+		out.ETA, _ = p.ETA()
+	}
+	return out, true
+}
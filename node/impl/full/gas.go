@@ -62,7 +62,11 @@ type GasAPI struct {
 
 func NewGasPriceCache() *GasPriceCache {
 	// 50 because we usually won't access more than 40
-	c, err := lru.New2Q[types.TipSetKey, []GasMeta](50)
+	return newGasPriceCache(50)
+}
+
+func newGasPriceCache(size int) *GasPriceCache {
+	c, err := lru.New2Q[types.TipSetKey, []GasMeta](size)
 	if err != nil {
 		// err only if parameter is bad
 		panic(err)
@@ -75,6 +79,8 @@ func NewGasPriceCache() *GasPriceCache {
 
 type GasPriceCache struct {
 	c *lru.TwoQueueCache[types.TipSetKey, []GasMeta]
+
+	estimator GasPremiumEstimator
 }
 
 type GasMeta struct {
@@ -189,32 +195,40 @@ func gasEstimateGasPremium(ctx context.Context, cstore *store.ChainStore, cache
 		nblocksincl = 1
 	}
 
-	var prices []GasMeta
-	var blocks int
-
-	ts := cstore.GetHeaviestTipSet()
-	for i := uint64(0); i < nblocksincl*2; i++ {
-		if ts.Height() == 0 {
-			break // genesis
-		}
-
-		pts, err := cstore.LoadTipSet(ctx, ts.Parents())
-		if err != nil {
+	var premium abi.TokenAmount
+	if cache.estimator.Percentile > 0 {
+		var err error
+		if premium, err = percentileGasPremium(ctx, cstore, cache); err != nil {
 			return types.BigInt{}, err
 		}
+	} else {
+		var prices []GasMeta
+		var blocks int
+
+		ts := cstore.GetHeaviestTipSet()
+		for i := uint64(0); i < nblocksincl*2; i++ {
+			if ts.Height() == 0 {
+				break // genesis
+			}
 
-		blocks += len(pts.Blocks())
-		meta, err := cache.GetTSGasStats(ctx, cstore, pts)
-		if err != nil {
-			return types.BigInt{}, err
+			pts, err := cstore.LoadTipSet(ctx, ts.Parents())
+			if err != nil {
+				return types.BigInt{}, err
+			}
+
+			blocks += len(pts.Blocks())
+			meta, err := cache.GetTSGasStats(ctx, cstore, pts)
+			if err != nil {
+				return types.BigInt{}, err
+			}
+			prices = append(prices, meta...)
+
+			ts = pts
 		}
-		prices = append(prices, meta...)
 
-		ts = pts
+		premium = medianGasPremium(prices, blocks)
 	}
 
-	premium := medianGasPremium(prices, blocks)
-
 	if types.BigCmp(premium, types.NewInt(MinGasPremium)) < 0 {
 		switch nblocksincl {
 		case 1:
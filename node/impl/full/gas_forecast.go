@@ -0,0 +1,95 @@
+package full
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+// MaxBaseFeeForecastEpochs is the maximum number of epochs of a base fee forecast.
+const MaxBaseFeeForecastEpochs = 2880
+
+func (a *GasAPI) GasBaseFeeForecast(ctx context.Context, epochs, window int) (*api.BaseFeeForecast, error) {
+	return gasBaseFeeForecast(ctx, a.Chain, a.PriceCache, epochs, window)
+}
+
+func gasBaseFeeForecast(ctx context.Context, cstore *store.ChainStore, cache *GasPriceCache, epochs, window int) (*api.BaseFeeForecast, error) {
+	if epochs < 1 || epochs > MaxBaseFeeForecastEpochs {
+		return nil, xerrors.Errorf("number of epochs %d isn't between 1 and %d", epochs, MaxBaseFeeForecastEpochs)
+	}
+
+	h, err := gasFeeHistory(ctx, cstore, cache, window, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPerBlock := make([]float64, 0, len(h.GasLimitRatios))
+	for _, r := range h.GasLimitRatios {
+		gasPerBlock = append(gasPerBlock, r*float64(build.BlockGasLimit))
+	}
+
+	// the base fee of the next epoch is known from the heaviest tipset
+	head := cstore.GetHeaviestTipSet()
+	next, err := cstore.ComputeBaseFee(ctx, head)
+	if err != nil {
+		return nil, xerrors.Errorf("computing next base fee: %w", err)
+	}
+
+	return forecastBaseFees(next, head.Height()+1, gasPerBlock, epochs), nil
+}
+
+// forecastBaseFees projects the base fee over a number of epochs from the base fee of the first
+// one, extrapolating the gas limit per block of past tipsets, oldest first, linearly.
+func forecastBaseFees(baseFee abi.TokenAmount, epoch abi.ChainEpoch, gasPerBlock []float64, epochs int) *api.BaseFeeForecast {
+	// least squares fit of the gas per block over the index of the tipsets
+	var slope, mean float64
+	if n := float64(len(gasPerBlock)); n > 0 {
+		var sx, sy, sxy, sxx float64
+		for i, g := range gasPerBlock {
+			x := float64(i)
+			sx += x
+			sy += g
+			sxy += x * g
+			sxx += x * x
+		}
+		mean = sy / n
+		if d := n*sxx - sx*sx; d != 0 {
+			slope = (n*sxy - sx*sy) / d
+		}
+	}
+	at := func(i int) int64 {
+		// the fitted line goes through the mean at the middle index
+		g := mean + slope*(float64(i)-float64(len(gasPerBlock)-1)/2)
+		if g < 0 {
+			return 0
+		}
+		if g > float64(build.BlockGasLimit) {
+			return build.BlockGasLimit
+		}
+		return int64(g)
+	}
+
+	f := &api.BaseFeeForecast{
+		Epochs:      make([]abi.ChainEpoch, 0, epochs),
+		BaseFees:    make([]abi.TokenAmount, 0, epochs),
+		GasPerBlock: make([]int64, 0, epochs),
+	}
+	for i := 0; i < epochs; i++ {
+		gas := at(len(gasPerBlock) + i)
+
+		f.Epochs = append(f.Epochs, epoch)
+		f.BaseFees = append(f.BaseFees, baseFee)
+		f.GasPerBlock = append(f.GasPerBlock, gas)
+
+		baseFee = store.ComputeNextBaseFee(baseFee, gas, 1, epoch)
+		epoch++
+	}
+
+	return f
+}
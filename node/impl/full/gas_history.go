@@ -0,0 +1,174 @@
+package full
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+// MaxFeeHistoryTipsets is the maximum number of tipsets of a fee history.
+const MaxFeeHistoryTipsets = 1024
+
+// GasPremiumEstimator selects how gas premiums are estimated.
+type GasPremiumEstimator struct {
+	// Percentile of the gas premiums of the messages of each recent tipset, weighted by their gas
+	// limits, the estimates are the median of; the default heuristic is used when 0.
+	Percentile float64
+	// Window is the number of recent tipsets the estimates are computed over.
+	Window int
+}
+
+func (e GasPremiumEstimator) validate() error {
+	if e.Percentile == 0 {
+		return nil
+	}
+	if e.Percentile < 0 || e.Percentile > 100 {
+		return xerrors.Errorf("gas premium percentile %v isn't between 0 and 100", e.Percentile)
+	}
+	if e.Window < 1 || e.Window > MaxFeeHistoryTipsets {
+		return xerrors.Errorf("gas premium window %d isn't between 1 and %d", e.Window, MaxFeeHistoryTipsets)
+	}
+	return nil
+}
+
+// NewGasPriceCacheWithEstimator returns a gas price cache estimating gas premiums with the given
+// estimator, large enough to hold the gas stats of its window.
+func NewGasPriceCacheWithEstimator(e GasPremiumEstimator) (*GasPriceCache, error) {
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+
+	size := 50
+	if e.Window+10 > size {
+		size = e.Window + 10
+	}
+
+	c := newGasPriceCache(size)
+	c.estimator = e
+	return c, nil
+}
+
+func (a *GasAPI) GasFeeHistory(ctx context.Context, tipsets int, percentiles []float64) (*api.FeeHistory, error) {
+	return gasFeeHistory(ctx, a.Chain, a.PriceCache, tipsets, percentiles)
+}
+
+func gasFeeHistory(ctx context.Context, cstore *store.ChainStore, cache *GasPriceCache, tipsets int, percentiles []float64) (*api.FeeHistory, error) {
+	if tipsets < 1 || tipsets > MaxFeeHistoryTipsets {
+		return nil, xerrors.Errorf("number of tipsets %d isn't between 1 and %d", tipsets, MaxFeeHistoryTipsets)
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, xerrors.Errorf("percentile %v isn't between 0 and 100", p)
+		}
+		if i > 0 && p < percentiles[i-1] {
+			return nil, xerrors.Errorf("percentiles aren't in ascending order")
+		}
+	}
+
+	var h api.FeeHistory
+
+	// the messages of a tipset are only executed in its children, so start at the parent of the
+	// heaviest tipset like the premium estimation does
+	ts := cstore.GetHeaviestTipSet()
+	for i := 0; i < tipsets; i++ {
+		if ts.Height() == 0 {
+			break // genesis
+		}
+
+		pts, err := cstore.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, err
+		}
+
+		meta, err := cache.GetTSGasStats(ctx, cstore, pts)
+		if err != nil {
+			return nil, err
+		}
+
+		var gasLimit int64
+		for _, m := range meta {
+			gasLimit += m.Limit
+		}
+
+		premiums := make([]abi.TokenAmount, 0, len(percentiles))
+		for _, p := range percentiles {
+			premiums = append(premiums, premiumPercentile(meta, p))
+		}
+
+		h.Epochs = append(h.Epochs, pts.Height())
+		h.BaseFees = append(h.BaseFees, pts.Blocks()[0].ParentBaseFee)
+		h.GasLimitRatios = append(h.GasLimitRatios, float64(gasLimit)/float64(build.BlockGasLimit*int64(len(pts.Blocks()))))
+		h.Premiums = append(h.Premiums, premiums)
+
+		ts = pts
+	}
+
+	// oldest first
+	for i, j := 0, len(h.Epochs)-1; i < j; i, j = i+1, j-1 {
+		h.Epochs[i], h.Epochs[j] = h.Epochs[j], h.Epochs[i]
+		h.BaseFees[i], h.BaseFees[j] = h.BaseFees[j], h.BaseFees[i]
+		h.GasLimitRatios[i], h.GasLimitRatios[j] = h.GasLimitRatios[j], h.GasLimitRatios[i]
+		h.Premiums[i], h.Premiums[j] = h.Premiums[j], h.Premiums[i]
+	}
+
+	return &h, nil
+}
+
+// premiumPercentile returns the p-th percentile of the gas premiums of messages, weighted by
+// their gas limits, or zero without messages.
+func premiumPercentile(prices []GasMeta, p float64) abi.TokenAmount {
+	if len(prices) == 0 {
+		return big.Zero()
+	}
+
+	sorted := make([]GasMeta, len(prices))
+	copy(sorted, prices)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Price.LessThan(sorted[j].Price)
+	})
+
+	var total int64
+	for _, m := range sorted {
+		total += m.Limit
+	}
+
+	at := int64(float64(total) * p / 100)
+	var cum int64
+	for _, m := range sorted {
+		cum += m.Limit
+		if cum >= at {
+			return m.Price
+		}
+	}
+	return sorted[len(sorted)-1].Price
+}
+
+// percentileGasPremium estimates a gas premium as the median over the window of the estimator
+// of the percentile of the gas premiums of each tipset.
+func percentileGasPremium(ctx context.Context, cstore *store.ChainStore, cache *GasPriceCache) (abi.TokenAmount, error) {
+	h, err := gasFeeHistory(ctx, cstore, cache, cache.estimator.Window, []float64{cache.estimator.Percentile})
+	if err != nil {
+		return big.Zero(), err
+	}
+	if len(h.Premiums) == 0 {
+		return big.Zero(), nil
+	}
+
+	premiums := make([]abi.TokenAmount, 0, len(h.Premiums))
+	for _, p := range h.Premiums {
+		premiums = append(premiums, p[0])
+	}
+	sort.Slice(premiums, func(i, j int) bool {
+		return premiums[i].LessThan(premiums[j])
+	})
+
+	return premiums[len(premiums)/2], nil
+}
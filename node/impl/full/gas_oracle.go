@@ -0,0 +1,100 @@
+package full
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// clearingPercentile is the percentile of the gas premiums of a tipset, weighted by gas limit,
+// taken as the lowest premium the tipset included messages at; the very lowest premiums are
+// skipped as they are mostly paid by messages selected for their sender.
+const clearingPercentile = 5
+
+// The fractions of recent tipsets whose clearing premium the low, medium and high
+// recommendations pay at least.
+const (
+	oracleLowInclusion    = 0.25
+	oracleMediumInclusion = 0.6
+	oracleHighInclusion   = 0.9
+)
+
+func (a *GasAPI) GasPriceOracle(ctx context.Context, window int) (*api.GasPriceOracle, error) {
+	return gasPriceOracle(ctx, a.Chain, a.PriceCache, window)
+}
+
+func gasPriceOracle(ctx context.Context, cstore *store.ChainStore, cache *GasPriceCache, window int) (*api.GasPriceOracle, error) {
+	h, err := gasFeeHistory(ctx, cstore, cache, window, []float64{clearingPercentile})
+	if err != nil {
+		return nil, err
+	}
+
+	clearing := make([]abi.TokenAmount, 0, len(h.Premiums))
+	for i, p := range h.Premiums {
+		if h.GasLimitRatios[i]*float64(build.BlockGasLimit) < float64(build.BlockGasTarget) {
+			// the blocks had room left, so they would have included any premium
+			clearing = append(clearing, big.Zero())
+			continue
+		}
+		clearing = append(clearing, p[0])
+	}
+
+	head := cstore.GetHeaviestTipSet()
+	baseFee, err := cstore.ComputeBaseFee(ctx, head)
+	if err != nil {
+		return nil, xerrors.Errorf("computing next base fee: %w", err)
+	}
+
+	return &api.GasPriceOracle{
+		Epoch:   head.Height(),
+		BaseFee: baseFee,
+		Low:     recommendPremium(clearing, oracleLowInclusion, types.NewInt(MinGasPremium)),
+		Medium:  recommendPremium(clearing, oracleMediumInclusion, types.NewInt(1.5*MinGasPremium)),
+		High:    recommendPremium(clearing, oracleHighInclusion, types.NewInt(2*MinGasPremium)),
+	}, nil
+}
+
+// recommendPremium recommends the lowest premium, but no less than floor, at least the given
+// fraction of the clearing premiums of recent tipsets are at most. The inclusion delay assumes
+// each epoch includes the premium independently with the probability observed over the tipsets.
+func recommendPremium(clearing []abi.TokenAmount, inclusion float64, floor abi.TokenAmount) api.GasPremiumRecommendation {
+	if len(clearing) == 0 {
+		return api.GasPremiumRecommendation{GasPremium: floor, InclusionDelay: 1}
+	}
+
+	sorted := make([]abi.TokenAmount, len(clearing))
+	copy(sorted, clearing)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].LessThan(sorted[j])
+	})
+
+	at := int(inclusion*float64(len(sorted))+0.5) - 1
+	if at < 0 {
+		at = 0
+	}
+	premium := sorted[at]
+	if premium.LessThan(floor) {
+		premium = floor
+	}
+
+	var included int
+	for _, c := range sorted {
+		if c.LessThanEqual(premium) {
+			included++
+		}
+	}
+
+	return api.GasPremiumRecommendation{
+		GasPremium:     premium,
+		InclusionDelay: float64(len(sorted)) / float64(included),
+	}
+}
@@ -40,3 +40,82 @@ func TestMedian(t *testing.T) {
 		{big.NewInt(30), build.BlockGasTarget / 2},
 	}, 2))
 }
+
+func TestPremiumPercentile(t *testing.T) {
+	require.Equal(t, types.NewInt(0), premiumPercentile(nil, 50))
+
+	prices := []GasMeta{
+		{big.NewInt(30), 1000},
+		{big.NewInt(10), 1000},
+		{big.NewInt(20), 2000},
+	}
+
+	require.Equal(t, types.NewInt(10), premiumPercentile(prices, 0))
+	require.Equal(t, types.NewInt(10), premiumPercentile(prices, 25))
+	require.Equal(t, types.NewInt(20), premiumPercentile(prices, 50))
+	require.Equal(t, types.NewInt(20), premiumPercentile(prices, 75))
+	require.Equal(t, types.NewInt(30), premiumPercentile(prices, 90))
+
+	// the prices aren't reordered
+	require.Equal(t, types.NewInt(30), prices[0].Price)
+
+	require.Error(t, GasPremiumEstimator{Percentile: 101, Window: 10}.validate())
+	require.Error(t, GasPremiumEstimator{Percentile: 50}.validate())
+	require.NoError(t, GasPremiumEstimator{}.validate())
+}
+
+func TestForecastBaseFees(t *testing.T) {
+	const epoch = 1_000_000
+	baseFee := types.NewInt(1000)
+
+	// blocks at the target keep the base fee steady
+	target := float64(build.BlockGasTarget)
+	f := forecastBaseFees(baseFee, epoch, []float64{target, target, target}, 5)
+	require.Len(t, f.BaseFees, 5)
+	for i, bf := range f.BaseFees {
+		require.Equal(t, baseFee, bf)
+		require.Equal(t, build.BlockGasTarget, f.GasPerBlock[i])
+		require.Equal(t, epoch+i, int(f.Epochs[i]))
+	}
+
+	// empty blocks lower it
+	f = forecastBaseFees(baseFee, epoch, []float64{0, 0}, 3)
+	require.Equal(t, baseFee, f.BaseFees[0])
+	require.True(t, f.BaseFees[1].LessThan(f.BaseFees[0]))
+	require.True(t, f.BaseFees[2].LessThan(f.BaseFees[1]))
+
+	// filling blocks raise it, following the trend
+	f = forecastBaseFees(baseFee, epoch, []float64{target, target * 1.5}, 3)
+	require.Equal(t, build.BlockGasLimit, f.GasPerBlock[0])
+	require.True(t, f.BaseFees[1].GreaterThan(f.BaseFees[0]))
+}
+
+func TestRecommendPremium(t *testing.T) {
+	floor := types.NewInt(MinGasPremium)
+
+	var clearing []big.Int
+	for _, p := range []int64{0, 0, 200_000, 300_000, 400_000, 500_000, 600_000, 700_000, 800_000, 1_000_000} {
+		clearing = append(clearing, big.NewInt(p))
+	}
+
+	r := recommendPremium(clearing, oracleLowInclusion, floor)
+	require.Equal(t, big.NewInt(200_000), r.GasPremium)
+	require.InDelta(t, 10.0/3, r.InclusionDelay, 1e-9)
+
+	r = recommendPremium(clearing, oracleMediumInclusion, floor)
+	require.Equal(t, big.NewInt(500_000), r.GasPremium)
+	require.InDelta(t, 10.0/6, r.InclusionDelay, 1e-9)
+
+	r = recommendPremium(clearing, oracleHighInclusion, floor)
+	require.Equal(t, big.NewInt(800_000), r.GasPremium)
+	require.InDelta(t, 10.0/9, r.InclusionDelay, 1e-9)
+
+	// uncongested tipsets include the floor right away
+	r = recommendPremium([]big.Int{big.Zero(), big.Zero()}, oracleHighInclusion, floor)
+	require.Equal(t, floor, r.GasPremium)
+	require.Equal(t, 1.0, r.InclusionDelay)
+
+	// without history, fall back to the floor
+	r = recommendPremium(nil, oracleLowInclusion, floor)
+	require.Equal(t, floor, r.GasPremium)
+}
@@ -10,6 +10,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 
 	"github.com/filecoin-project/lotus/api"
@@ -59,6 +60,43 @@ func (a *MpoolAPI) MpoolSetConfig(ctx context.Context, cfg *types.MpoolConfig) e
 	return a.Mpool.SetConfig(ctx, cfg)
 }
 
+func (a *MpoolAPI) MpoolPersistedLocal(ctx context.Context) ([]*types.SignedMessage, error) {
+	return a.Mpool.PersistedLocal(ctx)
+}
+
+func (a *MpoolAPI) MpoolClearPersistedLocal(ctx context.Context) (int, error) {
+	return a.Mpool.ClearPersistedLocal(ctx)
+}
+
+func (a *MpoolAPI) MpoolGetRBFPolicy(ctx context.Context, from address.Address) (*api.RBFPolicy, error) {
+	p := a.Mpool.RBFPolicy(from)
+	return &api.RBFPolicy{MinPercent: p.MinPercent, MinIncrease: p.MinIncrease}, nil
+}
+
+func (a *MpoolAPI) MpoolNonceGaps(ctx context.Context, addr address.Address) (*api.NonceGaps, error) {
+	snonce, gaps, err := a.Mpool.NonceGaps(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &api.NonceGaps{StateNonce: snonce, Gaps: gaps}, nil
+}
+
+func (a *MpoolAPI) MpoolHistory(ctx context.Context, addr address.Address) ([]*api.LocalMessageRecord, error) {
+	return a.Mpool.LocalHistory(ctx, addr)
+}
+
+func (a *MpoolAPI) MpoolScheduled(ctx context.Context) ([]*api.ScheduledMessage, error) {
+	return a.Mpool.Scheduled(ctx)
+}
+
+func (a *MpoolAPI) MpoolSchedule(ctx context.Context, smsg *types.SignedMessage, epoch abi.ChainEpoch) (cid.Cid, error) {
+	return a.Mpool.Schedule(ctx, smsg, epoch)
+}
+
+func (a *MpoolAPI) MpoolCancelScheduled(ctx context.Context, c cid.Cid) error {
+	return a.Mpool.CancelScheduled(ctx, c)
+}
+
 func (a *MpoolAPI) MpoolSelect(ctx context.Context, tsk types.TipSetKey, ticketQuality float64) ([]*types.SignedMessage, error) {
 	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -239,27 +277,11 @@ func (a *MpoolAPI) MpoolPushMessage(ctx context.Context, msg *types.Message, spe
 }
 
 func (a *MpoolAPI) MpoolBatchPush(ctx context.Context, smsgs []*types.SignedMessage) ([]cid.Cid, error) {
-	var messageCids []cid.Cid
-	for _, smsg := range smsgs {
-		smsgCid, err := a.Mpool.Push(ctx, smsg, true)
-		if err != nil {
-			return messageCids, err
-		}
-		messageCids = append(messageCids, smsgCid)
-	}
-	return messageCids, nil
+	return a.Mpool.PushBatch(ctx, smsgs, false, true)
 }
 
 func (a *MpoolAPI) MpoolBatchPushUntrusted(ctx context.Context, smsgs []*types.SignedMessage) ([]cid.Cid, error) {
-	var messageCids []cid.Cid
-	for _, smsg := range smsgs {
-		smsgCid, err := a.Mpool.PushUntrusted(ctx, smsg)
-		if err != nil {
-			return messageCids, err
-		}
-		messageCids = append(messageCids, smsgCid)
-	}
-	return messageCids, nil
+	return a.Mpool.PushBatch(ctx, smsgs, true, true)
 }
 
 func (a *MpoolAPI) MpoolBatchPushMessage(ctx context.Context, msgs []*types.Message, spec *api.MessageSendSpec) ([]*types.SignedMessage, error) {
@@ -293,3 +315,7 @@ func (a *MpoolAPI) MpoolGetNonce(ctx context.Context, addr address.Address) (uin
 func (a *MpoolAPI) MpoolSub(ctx context.Context) (<-chan api.MpoolUpdate, error) {
 	return a.Mpool.Updates(ctx)
 }
+
+func (a *MpoolAPI) MpoolSubFiltered(ctx context.Context, f api.MpoolUpdateFilter) (<-chan api.MpoolUpdate, error) {
+	return a.Mpool.FilteredUpdates(ctx, messagepool.UpdateFilter(f))
+}
@@ -41,7 +41,9 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin/verifreg"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/beacon"
+	"github.com/filecoin-project/lotus/chain/beacon/drand"
 	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/index"
 	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
@@ -103,6 +105,7 @@ type StateAPI struct {
 	Beacon        beacon.Schedule
 	Consensus     consensus.Consensus
 	TsExec        stmgr.Executor
+	AddrIndex     index.AddrIndex
 }
 
 func (a *StateAPI) StateNetworkName(ctx context.Context) (dtypes.NetworkName, error) {
@@ -419,36 +422,47 @@ func (a *StateAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.
 	return res, err
 }
 
-func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.InvocResult, error) {
-	msgToReplay := mc
-	var ts *types.TipSet
-	var err error
-	if tsk == types.EmptyTSK {
-		mlkp, err := a.StateSearchMsg(ctx, types.EmptyTSK, mc, stmgr.LookbackNoLimit, true)
-		if err != nil {
-			return nil, xerrors.Errorf("searching for msg %s: %w", mc, err)
-		}
-		if mlkp == nil {
-			return nil, xerrors.Errorf("didn't find msg %s", mc)
+func (a *StateAPI) StateCallWithOverrides(ctx context.Context, msg *types.Message, tsk types.TipSetKey, overrides map[address.Address]api.ActorOverride) (res *api.InvocResult, err error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	for {
+		res, err = a.StateManager.CallWithStateOverrides(ctx, msg, ts, overrides)
+		if err != stmgr.ErrExpensiveFork {
+			break
 		}
-
-		msgToReplay = mlkp.Message
-
-		executionTs, err := a.Chain.GetTipSetFromKey(ctx, mlkp.TipSet)
+		ts, err = a.Chain.GetTipSetFromKey(ctx, ts.Parents())
 		if err != nil {
-			return nil, xerrors.Errorf("loading tipset %s: %w", mlkp.TipSet, err)
+			return nil, xerrors.Errorf("getting parent tipset: %w", err)
 		}
+	}
+	return res, err
+}
 
-		ts, err = a.Chain.LoadTipSet(ctx, executionTs.Parents())
-		if err != nil {
-			return nil, xerrors.Errorf("loading parent tipset %s: %w", mlkp.TipSet, err)
+func (a *StateAPI) StateCallBatch(ctx context.Context, msgs []*types.Message, tsk types.TipSetKey) (res []*api.InvocResult, err error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	for {
+		res, err = a.StateManager.CallBatch(ctx, msgs, ts)
+		if err != stmgr.ErrExpensiveFork {
+			break
 		}
-	} else {
-		ts, err = a.Chain.LoadTipSet(ctx, tsk)
+		ts, err = a.Chain.GetTipSetFromKey(ctx, ts.Parents())
 		if err != nil {
-			return nil, xerrors.Errorf("loading specified tipset %s: %w", tsk, err)
+			return nil, xerrors.Errorf("getting parent tipset: %w", err)
 		}
 	}
+	return res, err
+}
+
+func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.InvocResult, error) {
+	ts, msgToReplay, err := a.replayTipSet(ctx, tsk, mc)
+	if err != nil {
+		return nil, err
+	}
 
 	m, r, err := a.StateManager.Replay(ctx, ts, msgToReplay)
 	if err != nil {
@@ -471,6 +485,138 @@ func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.
 	}, nil
 }
 
+func (a *StateAPI) StateReplayEx(ctx context.Context, tsk types.TipSetKey, mc cid.Cid, opts api.ReplayOptions) (*api.ReplayResult, error) {
+	switch opts.TraceFormat {
+	case "", api.TraceFormatFrames:
+	default:
+		return nil, xerrors.Errorf("unknown trace format %q", opts.TraceFormat)
+	}
+
+	ts, msgToReplay, err := a.replayTipSet(ctx, tsk, mc)
+	if err != nil {
+		return nil, err
+	}
+
+	var ir *api.InvocResult
+	if opts.Overrides != nil {
+		ir, err = a.StateManager.ReplayWithOverrides(ctx, ts, msgToReplay, *opts.Overrides)
+	} else {
+		ir, err = a.StateReplay(ctx, ts.Key(), msgToReplay)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.ReplayResult{InvocResult: ir}
+	if opts.TraceFormat == api.TraceFormatFrames {
+		st, _, err := a.StateManager.TipSetState(ctx, ts)
+		if err != nil {
+			return nil, xerrors.Errorf("computing tipset state: %w", err)
+		}
+		out.Frames, err = a.StateManager.TraceFrames(ctx, st, []*api.InvocResult{ir})
+		if err != nil {
+			return nil, xerrors.Errorf("flattening execution trace: %w", err)
+		}
+		ir.ExecutionTrace = types.ExecutionTrace{}
+	}
+	return out, nil
+}
+
+func (a *StateAPI) StateGasProfile(ctx context.Context, tsk types.TipSetKey) (*api.GasProfile, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	return a.StateManager.GasProfile(ctx, ts)
+}
+
+func (a *StateAPI) StateImplicitTrace(ctx context.Context, tsk types.TipSetKey) ([]*api.InvocResult, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	return a.StateManager.ImplicitTrace(ctx, ts)
+}
+
+func (a *StateAPI) StateCronTrace(ctx context.Context, tsk types.TipSetKey) ([]*api.InvocResult, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	return a.StateManager.CronTrace(ctx, ts)
+}
+
+// maxGasStatsRange is the largest epoch range StateGasStats accepts.
+const maxGasStatsRange = builtin.EpochsInDay
+
+func (a *StateAPI) StateGasStats(ctx context.Context, from abi.ChainEpoch, to types.TipSetKey) ([]*api.EpochGasStats, error) {
+	toTs, err := a.Chain.GetTipSetFromKey(ctx, to)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", to, err)
+	}
+	if from > toTs.Height() {
+		return nil, xerrors.Errorf("range end %d is below range start %d", toTs.Height(), from)
+	}
+	if toTs.Height()-from > maxGasStatsRange {
+		return nil, xerrors.Errorf("range of %d epochs exceeds the maximum of %d", toTs.Height()-from, maxGasStatsRange)
+	}
+	fromTs, err := a.Chain.GetTipsetByHeight(ctx, from, toTs, false)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset at height %d: %w", from, err)
+	}
+	return a.StateManager.EpochGasStats(ctx, fromTs, toTs)
+}
+
+// maxCirculatingSupplySamples is the largest number of samples StateCirculatingSupplyRange returns.
+const maxCirculatingSupplySamples = 10000
+
+func (a *StateAPI) StateCirculatingSupplyRange(ctx context.Context, from abi.ChainEpoch, to types.TipSetKey, interval abi.ChainEpoch) ([]api.CirculatingSupplyAt, error) {
+	toTs, err := a.Chain.GetTipSetFromKey(ctx, to)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", to, err)
+	}
+	if interval > 0 && from <= toTs.Height() && (toTs.Height()-from)/interval >= maxCirculatingSupplySamples {
+		return nil, xerrors.Errorf("range of %d epochs sampled every %d epochs exceeds the maximum of %d samples", toTs.Height()-from, interval, maxCirculatingSupplySamples)
+	}
+	return a.StateManager.CirculatingSupplyRange(ctx, from, toTs, interval)
+}
+
+func (a *StateAPI) StateBackfillStatus(ctx context.Context) (*api.StateBackfillStatus, error) {
+	st := a.StateManager.StateBackfillStatus()
+	return &st, nil
+}
+
+// replayTipSet returns the tipset in which the message mc should be replayed, and the message to
+// replay; see StateReplay.
+func (a *StateAPI) replayTipSet(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*types.TipSet, cid.Cid, error) {
+	if tsk != types.EmptyTSK {
+		ts, err := a.Chain.LoadTipSet(ctx, tsk)
+		if err != nil {
+			return nil, cid.Undef, xerrors.Errorf("loading specified tipset %s: %w", tsk, err)
+		}
+		return ts, mc, nil
+	}
+
+	mlkp, err := a.StateSearchMsg(ctx, types.EmptyTSK, mc, stmgr.LookbackNoLimit, true)
+	if err != nil {
+		return nil, cid.Undef, xerrors.Errorf("searching for msg %s: %w", mc, err)
+	}
+	if mlkp == nil {
+		return nil, cid.Undef, xerrors.Errorf("didn't find msg %s", mc)
+	}
+
+	executionTs, err := a.Chain.GetTipSetFromKey(ctx, mlkp.TipSet)
+	if err != nil {
+		return nil, cid.Undef, xerrors.Errorf("loading tipset %s: %w", mlkp.TipSet, err)
+	}
+
+	ts, err := a.Chain.LoadTipSet(ctx, executionTs.Parents())
+	if err != nil {
+		return nil, cid.Undef, xerrors.Errorf("loading parent tipset %s: %w", mlkp.TipSet, err)
+	}
+	return ts, mlkp.Message, nil
+}
+
 func (m *StateModule) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (a *types.Actor, err error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -640,6 +786,7 @@ func (m *StateModule) StateWaitMsg(ctx context.Context, msg cid.Cid, confidence
 		ReturnDec: returndec,
 		TipSet:    ts.Key(),
 		Height:    ts.Height(),
+		Replaced:  found != msg,
 	}, nil
 }
 
@@ -656,10 +803,11 @@ func (m *StateModule) StateSearchMsg(ctx context.Context, tsk types.TipSetKey, m
 
 	if ts != nil {
 		return &api.MsgLookup{
-			Message: found,
-			Receipt: *recpt,
-			TipSet:  ts.Key(),
-			Height:  ts.Height(),
+			Message:  found,
+			Receipt:  *recpt,
+			TipSet:   ts.Key(),
+			Height:   ts.Height(),
+			Replaced: found != msg,
 		}, nil
 	}
 	return nil, nil
@@ -1134,7 +1282,14 @@ func (a *StateAPI) StateListMessages(ctx context.Context, match *api.MessageMatc
 		return true
 	}
 
-	var out []cid.Cid
+	out, err := a.listIndexedMessages(ctx, match, ts, toheight)
+	switch {
+	case err == nil:
+		return out, nil
+	case !xerrors.Is(err, index.ErrNotIndexed):
+		return nil, xerrors.Errorf("listing messages through the address index: %w", err)
+	}
+
 	for ts.Height() >= toheight {
 		msgs, err := a.Chain.MessagesForTipset(ctx, ts)
 		if err != nil {
@@ -1162,6 +1317,40 @@ func (a *StateAPI) StateListMessages(ctx context.Context, match *api.MessageMatc
 	return out, nil
 }
 
+// listIndexedMessages lists the messages matching match through the address index. It returns
+// index.ErrNotIndexed when the index doesn't cover the range.
+func (a *StateAPI) listIndexedMessages(ctx context.Context, match *api.MessageMatch, ts *types.TipSet, toheight abi.ChainEpoch) ([]cid.Cid, error) {
+	addr, dir := match.From, index.AddrMsgFrom
+	if addr == address.Undef {
+		addr, dir = match.To, index.AddrMsgTo
+	}
+
+	msgs, err := a.AddrIndex.ListMessages(ctx, addr, dir, ts, toheight)
+	if err != nil {
+		return nil, err
+	}
+	if match.From == address.Undef || match.To == address.Undef {
+		return msgs, nil
+	}
+
+	received, err := a.AddrIndex.ListMessages(ctx, match.To, index.AddrMsgTo, ts, toheight)
+	if err != nil {
+		return nil, err
+	}
+	to := make(map[cid.Cid]struct{}, len(received))
+	for _, c := range received {
+		to[c] = struct{}{}
+	}
+
+	var out []cid.Cid
+	for _, c := range msgs {
+		if _, ok := to[c]; ok {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
 func (a *StateAPI) StateCompute(ctx context.Context, height abi.ChainEpoch, msgs []*types.Message, tsk types.TipSetKey) (*api.ComputeStateOutput, error) {
 	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -1172,10 +1361,55 @@ func (a *StateAPI) StateCompute(ctx context.Context, height abi.ChainEpoch, msgs
 		return nil, err
 	}
 
-	return &api.ComputeStateOutput{
+	out := &api.ComputeStateOutput{
 		Root:  st,
 		Trace: t,
-	}, nil
+	}
+	for _, ir := range t {
+		if ir != nil && ir.Truncated {
+			out.Truncated = true
+			out.OmittedNodes += ir.OmittedNodes
+		}
+	}
+	return out, nil
+}
+
+func (a *StateAPI) StateComputeWithDiff(ctx context.Context, height abi.ChainEpoch, msgs []*types.Message, tsk types.TipSetKey) (*api.ComputeStateDiffOutput, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	st, t, changes, err := stmgr.ComputeStateWithDiff(ctx, a.StateManager, height, msgs, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.ComputeStateDiffOutput{
+		ComputeStateOutput: api.ComputeStateOutput{
+			Root:  st,
+			Trace: t,
+		},
+		Changes: make([]api.ActorChange, 0, len(changes)),
+	}
+	for _, ir := range t {
+		if ir != nil && ir.Truncated {
+			out.Truncated = true
+			out.OmittedNodes += ir.OmittedNodes
+		}
+	}
+	for _, c := range changes {
+		out.Changes = append(out.Changes, api.ActorChange{
+			Address: c.Address,
+			Old:     c.Old,
+			New:     c.New,
+		})
+	}
+	return out, nil
+}
+
+func (a *StateAPI) StatePurgePersistentCache(ctx context.Context) error {
+	a.StateManager.PurgePersistentStateCache()
+	return nil
 }
 
 func (m *StateModule) MsigGetAvailableBalance(ctx context.Context, addr address.Address, tsk types.TipSetKey) (types.BigInt, error) {
@@ -1773,6 +2007,19 @@ func (a *StateAPI) StateGetBeaconEntry(ctx context.Context, epoch abi.ChainEpoch
 	}
 }
 
+func (a *StateAPI) StateBeaconStatus(ctx context.Context) ([]api.BeaconStatus, error) {
+	out := make([]api.BeaconStatus, 0, len(a.Beacon))
+	for _, bp := range a.Beacon {
+		db, ok := bp.Beacon.(*drand.DrandBeacon)
+		if !ok {
+			// e.g. the mock beacon of devnets
+			continue
+		}
+		out = append(out, api.BeaconStatus{Start: bp.Start, Groups: db.Status()})
+	}
+	return out, nil
+}
+
 func (a *StateAPI) StateGetNetworkParams(ctx context.Context) (*api.NetworkParams, error) {
 	networkName, err := a.StateNetworkName(ctx)
 	if err != nil {
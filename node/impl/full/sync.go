@@ -10,10 +10,13 @@ import (
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -22,10 +25,11 @@ import (
 type SyncAPI struct {
 	fx.In
 
-	SlashFilter *slashfilter.SlashFilter `optional:"true"`
-	Syncer      *chain.Syncer
-	PubSub      *pubsub.PubSub
-	NetName     dtypes.NetworkName
+	SlashFilter  *slashfilter.SlashFilter `optional:"true"`
+	Syncer       *chain.Syncer
+	StateManager *stmgr.StateManager
+	PubSub       *pubsub.PubSub
+	NetName      dtypes.NetworkName
 }
 
 func (a *SyncAPI) SyncState(ctx context.Context) (*api.SyncState, error) {
@@ -46,11 +50,38 @@ func (a *SyncAPI) SyncState(ctx context.Context) (*api.SyncState, error) {
 			Start:    ss.Start,
 			End:      ss.End,
 			Message:  ss.Message,
+
+			EpochsPerSec:   ss.EpochsPerSec,
+			ETA:            ss.ETA,
+			ValidationTime: ss.ValidationTime,
+			ExecutionTime:  ss.ExecutionTime,
 		})
 	}
 	return out, nil
 }
 
+func (a *SyncAPI) SyncComputeProgress(ctx context.Context) ([]api.ComputeProgress, error) {
+	progress := a.StateManager.ExecutionProgress()
+
+	out := make([]api.ComputeProgress, len(progress))
+	for i, p := range progress {
+		out[i] = api.ComputeProgress{
+			TipSet:          p.TipSet,
+			Height:          p.Height,
+			Started:         p.Started,
+			MessagesApplied: p.MessagesApplied,
+			MessagesTotal:   p.MessagesTotal,
+			GasUsed:         p.GasUsed,
+		}
+	}
+	return out, nil
+}
+
+func (a *SyncAPI) SyncImportProgress(ctx context.Context) (*api.ImportProgress, error) {
+	p, _ := importProgress(a.Syncer.ChainStore())
+	return p, nil
+}
+
 func (a *SyncAPI) SyncSubmitBlock(ctx context.Context, blk *types.BlockMsg) error {
 	parent, err := a.Syncer.ChainStore().GetBlock(ctx, blk.Header.Parents[0])
 	if err != nil {
@@ -110,6 +141,23 @@ func (a *SyncAPI) SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error
 	return a.Syncer.SyncCheckpoint(ctx, tsk)
 }
 
+func (a *SyncAPI) SyncTrustedCheckpoints(ctx context.Context) ([]api.TrustedCheckpoint, error) {
+	return a.Syncer.ChainStore().TrustedCheckpoints(), nil
+}
+
+func (a *SyncAPI) SyncAddTrustedCheckpoints(ctx context.Context, cps []api.TrustedCheckpoint) error {
+	for _, cp := range cps {
+		if err := a.Syncer.ChainStore().AddTrustedCheckpoint(ctx, cp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *SyncAPI) SyncRemoveTrustedCheckpoint(ctx context.Context, epoch abi.ChainEpoch) error {
+	return a.Syncer.ChainStore().RemoveTrustedCheckpoint(ctx, epoch)
+}
+
 func (a *SyncAPI) SyncMarkBad(ctx context.Context, bcid cid.Cid) error {
 	log.Warnf("Marking block %s as bad", bcid)
 	a.Syncer.MarkBad(bcid)
@@ -22,6 +22,7 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/lp2p"
+	"github.com/filecoin-project/lotus/node/modules/tracer"
 )
 
 type NetAPI struct {
@@ -34,6 +35,7 @@ type NetAPI struct {
 	ResourceManager network.ResourceManager
 	Reporter        metrics.Reporter
 	Sk              *dtypes.ScoreKeeper
+	DeliveryTracer  *tracer.DeliveryTracer `optional:"true"`
 }
 
 func (a *NetAPI) ID(context.Context) (peer.ID, error) {
@@ -195,4 +197,34 @@ func (a *NetAPI) NetBandwidthStatsByProtocol(ctx context.Context) (map[protocol.
 	return a.Reporter.GetBandwidthByProtocol(), nil
 }
 
+func (a *NetAPI) NetPubsubTraceStatus(ctx context.Context) (*tracer.DeliveryTraceStatus, error) {
+	if a.DeliveryTracer == nil {
+		return nil, xerrors.Errorf("pubsub tracing is not available on this node")
+	}
+	st := a.DeliveryTracer.Status()
+	return &st, nil
+}
+
+func (a *NetAPI) NetPubsubTraceStart(ctx context.Context, cfg tracer.DeliveryTraceConfig) (*tracer.DeliveryTraceStatus, error) {
+	if a.DeliveryTracer == nil {
+		return nil, xerrors.Errorf("pubsub tracing is not available on this node")
+	}
+	if err := a.DeliveryTracer.Start(cfg); err != nil {
+		return nil, err
+	}
+	st := a.DeliveryTracer.Status()
+	return &st, nil
+}
+
+func (a *NetAPI) NetPubsubTraceStop(ctx context.Context) (*tracer.DeliveryTraceStatus, error) {
+	if a.DeliveryTracer == nil {
+		return nil, xerrors.Errorf("pubsub tracing is not available on this node")
+	}
+	if err := a.DeliveryTracer.Stop(); err != nil {
+		return nil, err
+	}
+	st := a.DeliveryTracer.Status()
+	return &st, nil
+}
+
 var _ api.Net = &NetAPI{}
@@ -0,0 +1,116 @@
+package modules
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	bsmsg "github.com/ipfs/boxo/bitswap/message"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// bitswapLimiterSweep is how often the buckets of peers back under their bandwidth cap are dropped.
+var bitswapLimiterSweep = time.Minute
+
+// bitswapServeLimiter caps the bandwidth at which blocks are served to each peer with a token
+// bucket per peer. Blocks are accounted once sent, so a bucket can go into debt; the wants of a
+// peer are refused while its bucket is in debt.
+type bitswapServeLimiter struct {
+	rate  float64 // bytes per second
+	burst float64
+
+	lk        sync.Mutex
+	peers     map[peer.ID]*serveBucket
+	lastSweep time.Time
+}
+
+type serveBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newBitswapServeLimiter(rate, burst int64) *bitswapServeLimiter {
+	if burst <= 0 {
+		burst = rate
+	}
+	return &bitswapServeLimiter{
+		rate:      float64(rate),
+		burst:     float64(burst),
+		peers:     make(map[peer.ID]*serveBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *bitswapServeLimiter) refill(b *serveBucket, now time.Time) {
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+}
+
+// allow is the bitswap server request filter.
+func (l *bitswapServeLimiter) allow(p peer.ID, _ cid.Cid) bool {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	b, ok := l.peers[p]
+	if !ok {
+		return true
+	}
+	l.refill(b, time.Now())
+	return b.tokens > 0
+}
+
+func (l *bitswapServeLimiter) served(p peer.ID, size int, now time.Time) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	b, ok := l.peers[p]
+	if !ok {
+		b = &serveBucket{tokens: l.burst, last: now}
+		l.peers[p] = b
+	}
+	l.refill(b, now)
+	b.tokens -= float64(size)
+
+	if now.Sub(l.lastSweep) > bitswapLimiterSweep {
+		for p, b := range l.peers {
+			l.refill(b, now)
+			if b.tokens >= l.burst {
+				delete(l.peers, p)
+			}
+		}
+		l.lastSweep = now
+	}
+}
+
+// bitswapServeTracer accounts the blocks sent to each peer, in the serving metrics and in the
+// bandwidth limiter, if any.
+type bitswapServeTracer struct {
+	limiter *bitswapServeLimiter
+}
+
+func (t *bitswapServeTracer) MessageReceived(peer.ID, bsmsg.BitSwapMessage) {}
+
+func (t *bitswapServeTracer) MessageSent(p peer.ID, msg bsmsg.BitSwapMessage) {
+	blks := msg.Blocks()
+	if len(blks) == 0 {
+		return
+	}
+
+	var size int
+	for _, blk := range blks {
+		size += len(blk.RawData())
+	}
+
+	if t.limiter != nil {
+		t.limiter.served(p, size, time.Now())
+	}
+
+	ctx, _ := tag.New(context.Background(), tag.Upsert(metrics.PeerID, p.String()))
+	stats.Record(ctx, metrics.BitswapBlocksServed.M(int64(len(blks))), metrics.BitswapBytesServed.M(int64(size)))
+}
@@ -0,0 +1,34 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitswapServeLimiter(t *testing.T) {
+	l := newBitswapServeLimiter(1000, 2000)
+	a, b := peer.ID("a"), peer.ID("b")
+	now := time.Now()
+
+	// peers start with a full burst
+	l.served(a, 1500, now)
+	require.True(t, l.allow(a, cid.Undef))
+
+	// wants are refused while in debt
+	l.served(a, 1000, now)
+	require.False(t, l.allow(a, cid.Undef))
+	require.True(t, l.allow(b, cid.Undef))
+
+	// the debt is paid back over time
+	l.served(b, 2500, now.Add(-time.Second))
+	require.True(t, l.allow(b, cid.Undef))
+
+	// peers back under their cap are forgotten
+	l.served(b, 0, now.Add(2*bitswapLimiterSweep))
+	require.NotContains(t, l.peers, b)
+	require.NotContains(t, l.peers, a)
+}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	bstore "github.com/ipfs/go-ipfs-blockstore"
 	"go.uber.org/fx"
@@ -12,7 +14,9 @@ import (
 
 	"github.com/filecoin-project/lotus/blockstore"
 	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	s3bs "github.com/filecoin-project/lotus/blockstore/s3"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
@@ -45,6 +49,46 @@ func DiscardColdBlockstore(lc fx.Lifecycle, bs dtypes.UniversalBlockstore) (dtyp
 	return blockstore.NewDiscardStore(bs), nil
 }
 
+// S3ColdBlockstore opens the S3-compatible object store configured for the "s3" coldstore type.
+func S3ColdBlockstore(cfg *config.S3ColdStore) func(lc fx.Lifecycle, mctx helpers.MetricsCtx) (dtypes.ColdBlockstore, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx) (dtypes.ColdBlockstore, error) {
+		return s3bs.Open(helpers.LifecycleCtx(mctx, lc), s3bs.Options{
+			Endpoint:  cfg.Endpoint,
+			Region:    cfg.Region,
+			Bucket:    cfg.Bucket,
+			Prefix:    cfg.Prefix,
+			PathStyle: cfg.PathStyle,
+			AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		})
+	}
+}
+
+// RemoteColdBlockstore reads cold blocks from the chain blockstore of the node with the given API
+// info, for the "remote" coldstore type. Cold blocks are discarded, as the remote blockstore is
+// read-only.
+func RemoteColdBlockstore(apiInfo string) func(lc fx.Lifecycle) (dtypes.ColdBlockstore, error) {
+	return func(lc fx.Lifecycle) (dtypes.ColdBlockstore, error) {
+		info := cliutil.ParseApiInfo(apiInfo)
+		addr, err := info.DialArgs("v0")
+		if err != nil {
+			return nil, xerrors.Errorf("parsing remote coldstore api info: %w", err)
+		}
+		addr = strings.TrimSuffix(addr, "/rpc/v0") + "/rest/v0/chain/blockstore"
+		if strings.HasPrefix(addr, "http") {
+			addr = "ws" + strings.TrimPrefix(addr, "http")
+		}
+
+		rbs := blockstore.NewRemoteStore(addr, info.AuthHeader())
+		lc.Append(fx.Hook{
+			OnStop: func(_ context.Context) error {
+				return rbs.Close()
+			},
+		})
+		return blockstore.NewDiscardStore(rbs), nil
+	}
+}
+
 func BadgerHotBlockstore(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.HotBlockstore, error) {
 	path, err := r.SplitstorePath()
 	if err != nil {
@@ -83,13 +127,16 @@ func SplitBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.Locked
 
 		cfg := &splitstore.Config{
 			MarkSetType:                  cfg.Splitstore.MarkSetType,
-			DiscardColdBlocks:            cfg.Splitstore.ColdStoreType == "discard",
-			UniversalColdBlocks:          cfg.Splitstore.ColdStoreType == "universal",
+			DiscardColdBlocks:            cfg.Splitstore.ColdStoreType == "discard" || cfg.Splitstore.ColdStoreType == "remote",
+			UniversalColdBlocks:          cfg.Splitstore.ColdStoreType == "universal" || cfg.Splitstore.ColdStoreType == "s3",
 			HotStoreMessageRetention:     cfg.Splitstore.HotStoreMessageRetention,
 			HotStoreFullGCFrequency:      cfg.Splitstore.HotStoreFullGCFrequency,
 			HotstoreMaxSpaceTarget:       cfg.Splitstore.HotStoreMaxSpaceTarget,
 			HotstoreMaxSpaceThreshold:    cfg.Splitstore.HotStoreMaxSpaceThreshold,
 			HotstoreMaxSpaceSafetyBuffer: cfg.Splitstore.HotstoreMaxSpaceSafetyBuffer,
+			ColdStoreGCInterval:          time.Duration(cfg.Splitstore.ColdStoreGCInterval),
+			ColdStoreMaxSpaceTarget:      cfg.Splitstore.ColdStoreMaxSpaceTarget,
+			ColdStoreGCRetainState:       cfg.Splitstore.ColdStoreGCRetainState,
 		}
 		ss, err := splitstore.Open(path, ds, hot, cold, cfg)
 		if err != nil {
@@ -133,6 +180,31 @@ func ChainSplitBlockstore(_ fx.Lifecycle, _ helpers.MetricsCtx, bs dtypes.SplitB
 	return bs, nil
 }
 
+// CachedChainBlockstore wraps the chain blockstore with a read-through cache of at most size bytes.
+func CachedChainBlockstore(size uint64) func(lc fx.Lifecycle, cbs dtypes.BasicChainBlockstore) dtypes.ChainBlockstore {
+	return func(lc fx.Lifecycle, cbs dtypes.BasicChainBlockstore) dtypes.ChainBlockstore {
+		return cachedBlockstore(lc, "chain", cbs, size)
+	}
+}
+
+// CachedStateBlockstore wraps the state blockstore with a read-through cache of at most size bytes.
+func CachedStateBlockstore(size uint64) func(lc fx.Lifecycle, sbs dtypes.BasicStateBlockstore) dtypes.StateBlockstore {
+	return func(lc fx.Lifecycle, sbs dtypes.BasicStateBlockstore) dtypes.StateBlockstore {
+		return cachedBlockstore(lc, "state", sbs, size)
+	}
+}
+
+func cachedBlockstore(lc fx.Lifecycle, name string, bs blockstore.Blockstore, size uint64) blockstore.Blockstore {
+	cbs := blockstore.NewCachedBlockstore(name, bs, int64(size))
+	lc.Append(fx.Hook{
+		OnStop: func(_ context.Context) error {
+			cbs.Stop()
+			return nil
+		},
+	})
+	return cbs
+}
+
 func FallbackChainBlockstore(cbs dtypes.BasicChainBlockstore) dtypes.ChainBlockstore {
 	return &blockstore.FallbackStore{Blockstore: cbs}
 }
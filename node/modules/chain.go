@@ -2,16 +2,27 @@ package modules
 
 import (
 	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ipfs/boxo/bitswap"
 	"github.com/ipfs/boxo/bitswap/network"
+	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/routing"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/build"
@@ -25,53 +36,194 @@ import (
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
+	"github.com/filecoin-project/lotus/chain/wallet"
+	hsmwallet "github.com/filecoin-project/lotus/chain/wallet/hsm"
+	ledgerwallet "github.com/filecoin-project/lotus/chain/wallet/ledger"
+	"github.com/filecoin-project/lotus/chain/wallet/vault"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/impl/full"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
 
-// ChainBitswap uses a blockstore that bypasses all caches.
-func ChainBitswap(lc fx.Lifecycle, mctx helpers.MetricsCtx, host host.Host, rt routing.Routing, bs dtypes.ExposedBlockstore) dtypes.ChainBitswap {
-	// prefix protocol for chain bitswap
-	// (so bitswap uses /chain/ipfs/bitswap/1.0.0 internally for chain sync stuff)
-	bitswapNetwork := network.NewFromIpfsHost(host, rt, network.Prefix("/chain"))
-	bitswapOptions := []bitswap.Option{bitswap.ProvideEnabled(false)}
+// ChainBitswap uses a blockstore that bypasses all caches. The blocks it serves to other peers are
+// accounted per peer, and throttled as configured.
+func ChainBitswap(cfg config.BitswapConfig) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, host host.Host, rt routing.Routing, bs dtypes.ExposedBlockstore) dtypes.ChainBitswap {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, host host.Host, rt routing.Routing, bs dtypes.ExposedBlockstore) dtypes.ChainBitswap {
+		// prefix protocol for chain bitswap
+		// (so bitswap uses /chain/ipfs/bitswap/1.0.0 internally for chain sync stuff)
+		bitswapNetwork := network.NewFromIpfsHost(host, rt, network.Prefix("/chain"))
+		bitswapOptions := []bitswap.Option{bitswap.ProvideEnabled(false)}
 
-	// Write all incoming bitswap blocks into a temporary blockstore for two
-	// block times. If they validate, they'll be persisted later.
-	cache := blockstore.NewTimedCacheBlockstore(2 * time.Duration(build.BlockDelaySecs) * time.Second)
-	lc.Append(fx.Hook{OnStop: cache.Stop, OnStart: cache.Start})
+		if cfg.MaxServeTasks > 0 {
+			bitswapOptions = append(bitswapOptions, bitswap.EngineTaskWorkerCount(cfg.MaxServeTasks))
+		}
 
-	bitswapBs := blockstore.NewTieredBstore(bs, cache)
+		tracer := &bitswapServeTracer{}
+		if cfg.PeerBandwidthLimit > 0 {
+			tracer.limiter = newBitswapServeLimiter(cfg.PeerBandwidthLimit, cfg.PeerBandwidthBurst)
+			bitswapOptions = append(bitswapOptions, bitswap.WithPeerBlockRequestFilter(tracer.limiter.allow))
+		}
+		bitswapOptions = append(bitswapOptions, bitswap.WithTracer(tracer))
 
-	// Use just exch.Close(), closing the context is not needed
-	exch := bitswap.New(mctx, bitswapNetwork, bitswapBs, bitswapOptions...)
-	lc.Append(fx.Hook{
-		OnStop: func(ctx context.Context) error {
-			return exch.Close()
-		},
-	})
+		// Write all incoming bitswap blocks into a temporary blockstore for two
+		// block times. If they validate, they'll be persisted later.
+		cache := blockstore.NewTimedCacheBlockstore(2 * time.Duration(build.BlockDelaySecs) * time.Second)
+		lc.Append(fx.Hook{OnStop: cache.Stop, OnStart: cache.Start})
+
+		bitswapBs := blockstore.NewTieredBstore(bs, cache)
+
+		// Use just exch.Close(), closing the context is not needed
+		exch := bitswap.New(mctx, bitswapNetwork, bitswapBs, bitswapOptions...)
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return exch.Close()
+			},
+		})
 
-	return exch
+		return exch
+	}
 }
 
 func ChainBlockService(bs dtypes.ExposedBlockstore, rem dtypes.ChainBitswap) dtypes.ChainBlockService {
 	return blockservice.New(bs, rem)
 }
 
-func MessagePool(lc fx.Lifecycle, mctx helpers.MetricsCtx, us stmgr.UpgradeSchedule, mpp messagepool.Provider, ds dtypes.MetadataDS, nn dtypes.NetworkName, j journal.Journal, protector dtypes.GCReferenceProtector) (*messagepool.MessagePool, error) {
-	mp, err := messagepool.New(helpers.LifecycleCtx(mctx, lc), mpp, ds, us, nn, j)
-	if err != nil {
-		return nil, xerrors.Errorf("constructing mpool: %w", err)
+// mpoolSelector returns the configured message selection strategy.
+func mpoolSelector(mpcfg config.MpoolConfig) (messagepool.Selector, error) {
+	switch mpcfg.SelectionStrategy {
+	case "":
+		return messagepool.DefaultSelector, nil
+	case "fair":
+		s, err := messagepool.NewFairSelector(mpcfg.SelectionMaxPerSender)
+		if err != nil {
+			return nil, xerrors.Errorf("creating fair message selector: %w", err)
+		}
+		return s, nil
+	}
+
+	s, ok := messagepool.SelectorByName(mpcfg.SelectionStrategy)
+	if !ok {
+		return nil, xerrors.Errorf("unknown message selection strategy %q, expected 'fair' or one of %v", mpcfg.SelectionStrategy, messagepool.Selectors())
+	}
+	return s, nil
+}
+
+// GasPriceCache returns the gas price cache of the gas estimation API, which estimates gas premiums
+// with the configured estimator.
+func GasPriceCache(fees config.FeeConfig) func() (*full.GasPriceCache, error) {
+	return func() (*full.GasPriceCache, error) {
+		var e full.GasPremiumEstimator
+		switch fees.GasPremiumEstimator {
+		case "", "median":
+		case "percentile":
+			if fees.GasPremiumPercentile < 1 {
+				return nil, xerrors.Errorf("gas premium percentile %d isn't between 1 and 100", fees.GasPremiumPercentile)
+			}
+			e = full.GasPremiumEstimator{
+				Percentile: float64(fees.GasPremiumPercentile),
+				Window:     fees.GasPremiumWindow,
+			}
+		default:
+			return nil, xerrors.Errorf("unknown gas premium estimator %q", fees.GasPremiumEstimator)
+		}
+
+		return full.NewGasPriceCacheWithEstimator(e)
+	}
+}
+
+func MessagePool(fees config.FeeConfig, mpcfg config.MpoolConfig) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, us stmgr.UpgradeSchedule, mpp messagepool.Provider, ds dtypes.MetadataDS, nn dtypes.NetworkName, j journal.Journal, protector dtypes.GCReferenceProtector) (*messagepool.MessagePool, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, us stmgr.UpgradeSchedule, mpp messagepool.Provider, ds dtypes.MetadataDS, nn dtypes.NetworkName, j journal.Journal, protector dtypes.GCReferenceProtector) (*messagepool.MessagePool, error) {
+		rbf, overrides, err := rbfPolicy(fees)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing replace-by-fee policy: %w", err)
+		}
+
+		prioritySenders := make([]address.Address, 0, len(mpcfg.PrioritySenders))
+		for _, ps := range mpcfg.PrioritySenders {
+			addr, err := address.NewFromString(ps)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing priority sender %q: %w", ps, err)
+			}
+			prioritySenders = append(prioritySenders, addr)
+		}
+
+		mp, err := messagepool.New(helpers.LifecycleCtx(mctx, lc), mpp, ds, us, nn, j)
+		if err != nil {
+			return nil, xerrors.Errorf("constructing mpool: %w", err)
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(_ context.Context) error {
+				return mp.Close()
+			},
+		})
+		if err := mp.SetRBFPolicy(rbf, overrides); err != nil {
+			return nil, xerrors.Errorf("setting replace-by-fee policy: %w", err)
+		}
+		mp.SetPrioritySenders(prioritySenders)
+		err = mp.SetPendingLimits(messagepool.PendingLimits{
+			MaxActorPending:          mpcfg.MaxActorPendingMessages,
+			MaxUntrustedActorPending: mpcfg.MaxUntrustedActorPendingMessages,
+			MaxNonceGap:              mpcfg.MaxNonceGap,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("setting mpool pending limits: %w", err)
+		}
+
+		selector, err := mpoolSelector(mpcfg)
+		if err != nil {
+			return nil, err
+		}
+		mp.SetSelector(selector)
+		mp.SetLocalHistory(mpcfg.LocalHistory)
+		if err := mp.SetMemoryBudget(mpcfg.MemoryBudget); err != nil {
+			return nil, xerrors.Errorf("setting mpool memory budget: %w", err)
+		}
+
+		protector.AddProtector(mp.TryForEachPendingMessage)
+		return mp, nil
 	}
-	lc.Append(fx.Hook{
-		OnStop: func(_ context.Context) error {
-			return mp.Close()
-		},
-	})
-	protector.AddProtector(mp.TryForEachPendingMessage)
-	return mp, nil
+}
+
+// rbfPolicy returns the replace-by-fee policy of the fee config, and its overrides by sender.
+func rbfPolicy(fees config.FeeConfig) (messagepool.RBFPolicy, map[address.Address]messagepool.RBFPolicy, error) {
+	def := messagepool.RBFPolicy{
+		MinPercent:  types.Percent(fees.ReplaceByFeeMinPercent),
+		MinIncrease: big.NewInt(fees.ReplaceByFeeMinIncrease),
+	}
+
+	overrides := make(map[address.Address]messagepool.RBFPolicy, len(fees.ReplaceByFeeOverrides))
+	for _, o := range fees.ReplaceByFeeOverrides {
+		parts := strings.Split(o, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return messagepool.RBFPolicy{}, nil, xerrors.Errorf("override %q: expected '<address>:<min percent>[:<min increase>]'", o)
+		}
+
+		addr, err := address.NewFromString(parts[0])
+		if err != nil {
+			return messagepool.RBFPolicy{}, nil, xerrors.Errorf("override %q: parsing address: %w", o, err)
+		}
+
+		pct, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return messagepool.RBFPolicy{}, nil, xerrors.Errorf("override %q: parsing min percent: %w", o, err)
+		}
+
+		p := messagepool.RBFPolicy{MinPercent: types.Percent(pct), MinIncrease: big.Zero()}
+		if len(parts) == 3 {
+			inc, err := strconv.ParseInt(parts[2], 10, 64)
+			if err != nil {
+				return messagepool.RBFPolicy{}, nil, xerrors.Errorf("override %q: parsing min increase: %w", o, err)
+			}
+			p.MinIncrease = big.NewInt(inc)
+		}
+		overrides[addr] = p
+	}
+
+	return def, overrides, nil
 }
 
 func ChainStore(lc fx.Lifecycle,
@@ -183,6 +335,308 @@ func UpgradeSchedule() stmgr.UpgradeSchedule {
 	return filcns.DefaultUpgradeSchedule()
 }
 
+// ConfiguredUpgradeSchedule applies the migration settings from the node config and returns the
+// default upgrade schedule with the configured pre-migration lookahead.
+func ConfiguredUpgradeSchedule(cfg *config.MigrationConfig) func() (stmgr.UpgradeSchedule, error) {
+	return func() (stmgr.UpgradeSchedule, error) {
+		filcns.SetMigrationConfig(filcns.MigrationConfig{
+			MaxWorkers:      cfg.MaxWorkers,
+			JobQueueSize:    cfg.JobQueueSize,
+			ResultQueueSize: cfg.ResultQueueSize,
+		})
+
+		us := filcns.DefaultUpgradeSchedule()
+		if cfg.PreMigrationLookahead > 0 {
+			return us.WithPreMigrationLookahead(abi.ChainEpoch(cfg.PreMigrationLookahead))
+		}
+		return us, nil
+	}
+}
+
 func EnableStoringEvents(cs *store.ChainStore) {
 	cs.StoreEvents(true)
 }
+
+// ChainStoreGCReferenceProtector protects the objects registered with the GCReferenceProtector,
+// such as pending messages, from being deleted by state pruning.
+func ChainStoreGCReferenceProtector(cs *store.ChainStore) dtypes.GCReferenceProtector {
+	return cs
+}
+
+// StatePruneInterval is the number of epochs the chain advances between state prunes.
+var StatePruneInterval = build.Finality
+
+// StatePruner prunes the state trees older than retention epochs from the chainstore as the chain
+// advances, and garbage collects the blockstore after each prune to reclaim the space.
+func StatePruner(retention abi.ChainEpoch) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, bs dtypes.BaseBlockstore) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, bs dtypes.BaseBlockstore) error {
+		// keep enough state to follow reorgs
+		if retention < build.Finality {
+			return xerrors.Errorf("state retention of %d epochs is below the finality of %d epochs", retention, build.Finality)
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		var running atomic.Bool
+		prune := func() {
+			defer running.Store(false)
+
+			deleted, err := cs.PruneState(ctx, bs, retention)
+			if err != nil {
+				log.Errorf("error pruning state: %s", err)
+				return
+			}
+			if deleted == 0 {
+				return
+			}
+
+			if gc, ok := bs.(blockstore.BlockstoreGC); ok {
+				if err := gc.CollectGarbage(ctx); err != nil {
+					log.Warnf("error garbage collecting the blockstore after pruning state: %s", err)
+				}
+			}
+		}
+
+		cs.SubscribeHeadChanges(func(_, app []*types.TipSet) error {
+			if len(app) == 0 {
+				return nil
+			}
+
+			head := app[len(app)-1].Height()
+			if head-retention+1-cs.StatePrunedBefore() < StatePruneInterval {
+				return nil
+			}
+			if running.CompareAndSwap(false, true) {
+				go prune()
+			}
+			return nil
+		})
+
+		return nil
+	}
+}
+
+// LocalMessageTracker records the outcome on chain of the messages pushed by the node in the
+// local message history of the message pool, once they have MessageConfidence confirmations.
+func LocalMessageTracker(mctx helpers.MetricsCtx, lc fx.Lifecycle, mp *messagepool.MessagePool, sm *stmgr.StateManager) error {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	cs := sm.ChainStore()
+
+	var running atomic.Bool
+	resolve := func() {
+		defer running.Store(false)
+
+		records, err := mp.UnresolvedLocalHistory(ctx)
+		if err != nil {
+			log.Errorf("error listing unresolved local messages: %s", err)
+			return
+		}
+
+		head := cs.GetHeaviestTipSet()
+		for _, r := range records {
+			if len(r.Attempts) == 0 {
+				continue
+			}
+
+			act, err := sm.LoadActor(ctx, r.From, head)
+			if err != nil || act.Nonce <= r.Nonce {
+				continue // still pending
+			}
+
+			last := r.Attempts[len(r.Attempts)-1]
+			ts, rct, found, err := sm.SearchForMessage(ctx, head, last.Cid, stmgr.LookbackNoLimit, true)
+			if err != nil {
+				log.Warnf("error searching local message %s: %s", last.Cid, err)
+				continue
+			}
+
+			var o api.LocalMessageOutcome
+			if ts != nil {
+				if head.Height() < ts.Height()+abi.ChainEpoch(build.MessageConfidence) {
+					continue // wait for confirmations, the message may still be reorged out
+				}
+				o = api.LocalMessageOutcome{
+					Cid:      found,
+					TipSet:   ts.Key(),
+					Epoch:    ts.Height(),
+					ExitCode: rct.ExitCode,
+					GasUsed:  rct.GasUsed,
+				}
+			}
+
+			if err := mp.SetLocalOutcome(ctx, r.From, r.Nonce, o); err != nil {
+				log.Errorf("error recording outcome of local message %s: %s", last.Cid, err)
+			}
+		}
+	}
+
+	cs.SubscribeHeadChanges(func(_, app []*types.TipSet) error {
+		if len(app) == 0 {
+			return nil
+		}
+		if running.CompareAndSwap(false, true) {
+			go resolve()
+		}
+		return nil
+	})
+
+	return nil
+}
+
+// LoadTrustedCheckpoints adds the trusted checkpoints listed in a checkpoints file to the
+// chainstore; startup fails if one of them conflicts with the current chain or with an already
+// trusted checkpoint.
+func LoadTrustedCheckpoints(path string) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, _ dtypes.AfterGenesisSet) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, _ dtypes.AfterGenesisSet) error {
+		cps, err := store.ReadCheckpointsFile(path)
+		if err != nil {
+			return xerrors.Errorf("reading checkpoints file: %w", err)
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		for _, cp := range cps {
+			if err := cs.AddTrustedCheckpoint(ctx, cp); err != nil {
+				return xerrors.Errorf("adding trusted checkpoint from %s: %w", path, err)
+			}
+		}
+
+		log.Infow("loaded trusted checkpoints", "file", path, "count", len(cps))
+		return nil
+	}
+}
+
+// StateBackfillFetchTimeout bounds the time to fetch a batch of blocks during the state backfill.
+var StateBackfillFetchTimeout = 5 * time.Minute
+
+// StateBackfillRetryDelay is the time the state backfill waits before resuming after a failure.
+var StateBackfillRetryDelay = time.Minute
+
+// StateBackfill fills in the historical state missing from the chainstore in the background,
+// fetching it over the chain bitswap, until the node is archival.
+func StateBackfill(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, sm *stmgr.StateManager, rem dtypes.ChainBitswap) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+
+	fetch := func(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+		ctx, cancel := context.WithTimeout(ctx, StateBackfillFetchTimeout)
+		defer cancel()
+
+		ch, err := rem.GetBlocks(ctx, cids)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]blocks.Block, 0, len(cids))
+		for b := range ch {
+			out = append(out, b)
+		}
+		if len(out) != len(cids) {
+			return nil, xerrors.Errorf("fetched %d of %d blocks: %w", len(out), len(cids), ctx.Err())
+		}
+		return out, nil
+	}
+
+	run := func() {
+		// the boundary isn't recorded for lite snapshots imported before it was
+		if cs.StatePrunedBefore() == 0 {
+			log.Info("looking for the earliest available state")
+			earliest, err := cs.RecordStateBoundary(ctx, cs.GetHeaviestTipSet())
+			if err != nil {
+				log.Errorf("error looking for the earliest available state: %s", err)
+				return
+			}
+			log.Infow("found the earliest available state", "epoch", earliest)
+		}
+
+		for {
+			err := sm.RunStateBackfill(ctx, fetch)
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+			log.Errorf("error backfilling state, retrying in %s: %s", StateBackfillRetryDelay, err)
+
+			select {
+			case <-time.After(StateBackfillRetryDelay):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go run()
+			return nil
+		},
+	})
+}
+
+// LedgerWallet returns a ledger wallet decoding the messages it signs with the actor codes of the
+// heaviest tipset.
+func LedgerWallet(ds dtypes.MetadataDS, sm *stmgr.StateManager) *ledgerwallet.LedgerWallet {
+	return ledgerwallet.NewWalletWithActorCodes(ds, func(ctx context.Context, addr address.Address) (cid.Cid, error) {
+		act, err := sm.LoadActorTsk(ctx, addr, types.EmptyTSK)
+		if err != nil {
+			return cid.Undef, err
+		}
+		return act.Code, nil
+	})
+}
+
+// VaultWallet returns a local wallet storing its keys in Vault rather than in the keystore of
+// the repo.
+func VaultWallet(cfg config.VaultKeystore) func() (*wallet.LocalWallet, error) {
+	return func() (*wallet.LocalWallet, error) {
+		ks, err := vault.NewKeyStore(vault.Config{
+			Address:             cfg.Address,
+			Namespace:           cfg.Namespace,
+			CACert:              cfg.CACert,
+			KVMount:             cfg.KVMount,
+			KVPath:              cfg.KVPath,
+			TransitMount:        cfg.TransitMount,
+			TransitKey:          cfg.TransitKey,
+			Token:               cfg.Token,
+			AppRoleMount:        cfg.AppRoleMount,
+			AppRoleID:           cfg.AppRoleID,
+			AppRoleSecretIDFile: cfg.AppRoleSecretIDFile,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("setting up vault keystore: %w", err)
+		}
+		return wallet.NewWallet(ks)
+	}
+}
+
+// HSMWallet returns a wallet signing with the HSM keys of the config.
+func HSMWallet(cfg config.HSMSigner) func() (*hsmwallet.Wallet, error) {
+	return func() (*hsmwallet.Wallet, error) {
+		keys, err := hsmwallet.ParseKeys(cfg.Keys)
+		if err != nil {
+			return nil, err
+		}
+
+		var backend hsmwallet.Backend
+		switch cfg.Backend {
+		case "kms":
+			backend, err = hsmwallet.NewKMS(hsmwallet.KMSConfig{
+				Region:       cfg.KMSRegion,
+				Endpoint:     cfg.KMSEndpoint,
+				AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+				SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+				SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			})
+		case "pkcs11":
+			backend, err = hsmwallet.NewPKCS11(hsmwallet.PKCS11Config{
+				Tool:       cfg.PKCS11Tool,
+				Module:     cfg.PKCS11Module,
+				TokenLabel: cfg.PKCS11TokenLabel,
+				PINFile:    cfg.PKCS11PINFile,
+			})
+		default:
+			return nil, xerrors.Errorf("unknown HSM backend %q", cfg.Backend)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("setting up %s HSM backend: %w", cfg.Backend, err)
+		}
+
+		return hsmwallet.NewWallet(backend, keys), nil
+	}
+}
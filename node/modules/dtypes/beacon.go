@@ -13,4 +13,8 @@ type DrandConfig struct {
 	Servers       []string
 	Relays        []string
 	ChainInfoJSON string
+
+	// FallbackServers are groups of http endpoints failed over to, in order, when Servers can't
+	// serve randomness.
+	FallbackServers [][]string
 }
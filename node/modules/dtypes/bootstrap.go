@@ -5,4 +5,7 @@ import "github.com/libp2p/go-libp2p/core/peer"
 type BootstrapPeers []peer.AddrInfo
 type DrandBootstrap []peer.AddrInfo
 
+// ProtectedPeers are the peers configured in Libp2p.ProtectedPeers, with their addresses if given.
+type ProtectedPeers []peer.AddrInfo
+
 type Bootstrapper bool
@@ -0,0 +1,94 @@
+package modules
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/impl/full"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+type consensusReporterModules struct {
+	fx.In
+
+	Chain  full.ChainAPI
+	Mpool  full.MpoolAPI
+	State  full.StateAPI
+	Sync   full.SyncAPI
+	Wallet full.WalletAPI
+}
+
+var _ slashsvc.ConsensusSlasherApi = &consensusReporterModules{}
+
+func (m *consensusReporterModules) ChainGetBlock(ctx context.Context, c cid.Cid) (*types.BlockHeader, error) {
+	return m.Chain.ChainGetBlock(ctx, c)
+}
+
+func (m *consensusReporterModules) ChainGetTipSet(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	return m.Chain.ChainGetTipSet(ctx, tsk)
+}
+
+func (m *consensusReporterModules) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	return m.Mpool.MpoolPushMessage(ctx, msg, spec)
+}
+
+func (m *consensusReporterModules) StateLookupID(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
+	return m.State.StateLookupID(ctx, addr, tsk)
+}
+
+func (m *consensusReporterModules) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.InvocResult, error) {
+	return m.State.StateReplay(ctx, tsk, mc)
+}
+
+func (m *consensusReporterModules) StateWaitMsg(ctx context.Context, mc cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	return m.State.StateWaitMsg(ctx, mc, confidence, limit, allowReplaced)
+}
+
+func (m *consensusReporterModules) SyncIncomingBlocks(ctx context.Context) (<-chan *types.BlockHeader, error) {
+	return m.Sync.SyncIncomingBlocks(ctx)
+}
+
+func (m *consensusReporterModules) WalletDefaultAddress(ctx context.Context) (address.Address, error) {
+	return m.Wallet.WalletDefaultAddress(ctx)
+}
+
+// RunConsensusFaultReporter runs the consensus fault reporter over the blocks received by the
+// node, recording them in the metadata datastore.
+func RunConsensusFaultReporter(cfg config.FaultReporterConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, mod consensusReporterModules) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, mod consensusReporterModules) error {
+		opts := slashsvc.Options{
+			DryRun:       cfg.DryRun,
+			TrackRewards: cfg.TrackRewards,
+		}
+		if cfg.ConsensusFaultReporterAddress != "" {
+			from, err := address.NewFromString(cfg.ConsensusFaultReporterAddress)
+			if err != nil {
+				return xerrors.Errorf("parsing consensus fault reporter address: %w", err)
+			}
+			opts.From = from
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		fds := namespace.Wrap(ds, datastore.NewKey("/faultreport"))
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				return slashsvc.SlashConsensus(ctx, &mod, fds, opts)
+			},
+		})
+		return nil
+	}
+}
@@ -0,0 +1,138 @@
+package modules
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api/client"
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/beacon"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// FollowUpstreamRetryDelay is the time to wait before reconnecting to the trusted upstream.
+var FollowUpstreamRetryDelay = 10 * time.Second
+
+// FollowerConsensus wraps the expected consensus in a Follower, which trusts the chain of the
+// upstream.
+func FollowerConsensus(sm *stmgr.StateManager, beacon beacon.Schedule, verifier storiface.Verifier, genesis chain.Genesis) *consensus.Follower {
+	return consensus.NewFollower(filcns.NewFilecoinExpectedConsensus(sm, beacon, verifier, genesis), sm)
+}
+
+// FollowUpstream subscribes to the head changes of the trusted upstream, and syncs the heads it
+// announces, fetching them from the upstream.
+func FollowUpstream(apiInfo string) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, syncer *chain.Syncer, follower *consensus.Follower) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, syncer *chain.Syncer, follower *consensus.Follower) error {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		info := cliutil.ParseApiInfo(apiInfo)
+		addr, err := info.DialArgs("v1")
+		if err != nil {
+			return xerrors.Errorf("parsing trusted upstream api info: %w", err)
+		}
+
+		upstream, closer, err := client.NewFullNodeRPCV1(ctx, addr, info.AuthHeader())
+		if err != nil {
+			return xerrors.Errorf("connecting to the trusted upstream: %w", err)
+		}
+
+		run := func() {
+			for {
+				err := followUpstream(ctx, h, syncer, follower, upstream)
+				if ctx.Err() != nil {
+					return
+				}
+				log.Errorf("error following the trusted upstream, retrying in %s: %s", FollowUpstreamRetryDelay, err)
+
+				select {
+				case <-time.After(FollowUpstreamRetryDelay):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go run()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				closer()
+				return nil
+			},
+		})
+		return nil
+	}
+}
+
+func followUpstream(ctx context.Context, h host.Host, syncer *chain.Syncer, follower *consensus.Follower, upstream v1api.FullNode) error {
+	// connect to the upstream, so that the chains it announces can be fetched from it
+	pid, err := upstream.ID(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting the upstream peer id: %w", err)
+	}
+	ai, err := upstream.NetAddrsListen(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting the upstream addresses: %w", err)
+	}
+	if err := h.Connect(ctx, peer.AddrInfo{ID: pid, Addrs: ai.Addrs}); err != nil {
+		return xerrors.Errorf("connecting to the upstream peer: %w", err)
+	}
+	h.ConnManager().Protect(pid, "trusted-upstream")
+
+	notifs, err := upstream.ChainNotify(ctx)
+	if err != nil {
+		return xerrors.Errorf("subscribing to the upstream head changes: %w", err)
+	}
+
+	log.Infow("following the trusted upstream", "peer", pid)
+	for changes := range notifs {
+		var head *types.TipSet
+		for _, change := range changes {
+			if change.Type == store.HCApply || change.Type == store.HCCurrent {
+				head = change.Val
+			}
+		}
+		if head == nil {
+			continue
+		}
+
+		fts, err := upstreamFullTipSet(ctx, upstream, head)
+		if err != nil {
+			return xerrors.Errorf("loading upstream head %s: %w", head.Key(), err)
+		}
+
+		follower.TrustHead(head)
+		syncer.InformNewHead(pid, fts)
+	}
+	return xerrors.Errorf("upstream head change subscription closed")
+}
+
+func upstreamFullTipSet(ctx context.Context, upstream v1api.FullNode, ts *types.TipSet) (*store.FullTipSet, error) {
+	blks := make([]*types.FullBlock, 0, len(ts.Blocks()))
+	for _, b := range ts.Blocks() {
+		msgs, err := upstream.ChainGetBlockMessages(ctx, b.Cid())
+		if err != nil {
+			return nil, xerrors.Errorf("getting messages of block %s: %w", b.Cid(), err)
+		}
+		blks = append(blks, &types.FullBlock{
+			Header:        b,
+			BlsMessages:   msgs.BlsMessages,
+			SecpkMessages: msgs.SecpkMessages,
+		})
+	}
+	return store.NewFullTipSet(blks), nil
+}
@@ -74,13 +74,12 @@ func ConnectionManager(low, high uint, grace time.Duration, protected []string)
 			return Libp2pOpts{}, err
 		}
 
-		for _, p := range protected {
-			pid, err := peer.Decode(p)
-			if err != nil {
-				return Libp2pOpts{}, xerrors.Errorf("failed to parse peer ID in protected peers array: %w", err)
-			}
-
-			cm.Protect(pid, "config-prot")
+		pp, err := ParseProtectedPeers(protected)
+		if err != nil {
+			return Libp2pOpts{}, err
+		}
+		for _, pi := range pp {
+			cm.Protect(pi.ID, "config-prot")
 		}
 
 		infos, err := build.BuiltinBootstrap()
@@ -0,0 +1,105 @@
+package lp2p
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// ProtectedPeersRedialInterval is how often the connections to the protected peers are checked.
+var ProtectedPeersRedialInterval = 30 * time.Second
+
+// ParseProtectedPeers parses the Libp2p.ProtectedPeers entries, which are either peer IDs or
+// multiaddrs ending with /p2p/<peer ID>.
+func ParseProtectedPeers(entries []string) (dtypes.ProtectedPeers, error) {
+	var out dtypes.ProtectedPeers
+	idx := make(map[peer.ID]int)
+	for _, e := range entries {
+		var pi *peer.AddrInfo
+		if pid, err := peer.Decode(e); err == nil {
+			pi = &peer.AddrInfo{ID: pid}
+		} else {
+			a, err := ma.NewMultiaddr(e)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to parse protected peer %q, neither a peer ID nor a multiaddr: %w", e, err)
+			}
+			pi, err = peer.AddrInfoFromP2pAddr(a)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to parse protected peer %q: %w", e, err)
+			}
+		}
+
+		if i, ok := idx[pi.ID]; ok {
+			out[i].Addrs = append(out[i].Addrs, pi.Addrs...)
+			continue
+		}
+		idx[pi.ID] = len(out)
+		out = append(out, *pi)
+	}
+	return out, nil
+}
+
+func ConfigProtectedPeers(entries []string) func() (dtypes.ProtectedPeers, error) {
+	return func() (dtypes.ProtectedPeers, error) {
+		return ParseProtectedPeers(entries)
+	}
+}
+
+// ConnectProtectedPeers keeps the node connected to the protected peers given with addresses,
+// redialing them when they disconnect.
+func ConnectProtectedPeers(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, pp dtypes.ProtectedPeers) {
+	var dial []peer.AddrInfo
+	for _, pi := range pp {
+		if len(pi.Addrs) == 0 {
+			continue
+		}
+		h.Peerstore().AddAddrs(pi.ID, pi.Addrs, peerstore.PermanentAddrTTL)
+		dial = append(dial, pi)
+	}
+	if len(dial) == 0 {
+		return
+	}
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	run := func() {
+		ticker := time.NewTicker(ProtectedPeersRedialInterval)
+		defer ticker.Stop()
+
+		for {
+			for _, pi := range dial {
+				if h.Network().Connectedness(pi.ID) == network.Connected {
+					continue
+				}
+
+				dctx, cancel := context.WithTimeout(ctx, ProtectedPeersRedialInterval)
+				if err := h.Connect(dctx, pi); err != nil && ctx.Err() == nil {
+					log.Warnw("failed to connect to protected peer", "peer", pi.ID, "error", err)
+				}
+				cancel()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go run()
+			return nil
+		},
+	})
+}
@@ -50,6 +50,28 @@ func ScoreKeeper() *dtypes.ScoreKeeper {
 	return new(dtypes.ScoreKeeper)
 }
 
+// DeliveryTracer provides the pubsub delivery tracer, started on startup if a file or an endpoint
+// is configured.
+func DeliveryTracer(lc fx.Lifecycle, cfg *config.Pubsub) (*tracer.DeliveryTracer, error) {
+	dt := tracer.NewDeliveryTracer()
+	if cfg.DeliveryTraceFile != "" || cfg.DeliveryTraceEndpoint != "" {
+		err := dt.Start(tracer.DeliveryTraceConfig{
+			File:     cfg.DeliveryTraceFile,
+			Endpoint: cfg.DeliveryTraceEndpoint,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("starting the pubsub delivery trace: %w", err)
+		}
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return dt.Stop()
+		},
+	})
+	return dt, nil
+}
+
 type PeerScoreTracker interface {
 	UpdatePeerScore(scores map[peer.ID]*pubsub.PeerScoreSnapshot)
 }
@@ -85,6 +107,8 @@ type GossipIn struct {
 	Cfg  *config.Pubsub
 	Sk   *dtypes.ScoreKeeper
 	Dr   dtypes.DrandSchedule
+	Pp   dtypes.ProtectedPeers  `optional:"true"`
+	Dt   *tracer.DeliveryTracer `optional:"true"`
 }
 
 func getDrandTopic(chainInfoJSON string) (string, error) {
@@ -108,6 +132,11 @@ func GossipSub(in GossipIn) (service *pubsub.PubSub, err error) {
 		drandBootstrappers[pi.ID] = struct{}{}
 	}
 
+	protectedPeers := make(map[peer.ID]struct{})
+	for _, pi := range in.Pp {
+		protectedPeers[pi.ID] = struct{}{}
+	}
+
 	isBootstrapNode := in.Cfg.Bootstrapper
 
 	drandTopicParams := &pubsub.TopicScoreParams{
@@ -283,6 +312,11 @@ func GossipSub(in GossipIn) (service *pubsub.PubSub, err error) {
 						return 1500
 					}
 
+					// peers protected by the operator are never scored into disconnection
+					if _, ok := protectedPeers[p]; ok {
+						return 2500
+					}
+
 					// TODO: we want to  plug the application specific score to the node itself in order
 					//       to provide feedback to the pubsub system based on observed behaviour
 					return 0
@@ -424,13 +458,13 @@ func GossipSub(in GossipIn) (service *pubsub.PubSub, err error) {
 		}
 
 		pst := newPeerScoreTracker(lt, in.Sk)
-		trw := newTracerWrapper(tr, lt, build.BlocksTopic(in.Nn))
+		trw := newTracerWrapper(tr, lt, in.Dt, build.BlocksTopic(in.Nn))
 
 		options = append(options, pubsub.WithEventTracer(trw))
 		options = append(options, pubsub.WithPeerScoreInspect(pst.UpdatePeerScore, 10*time.Second))
 	} else {
 		// still instantiate a tracer for collecting metrics
-		trw := newTracerWrapper(nil, lt)
+		trw := newTracerWrapper(nil, lt, in.Dt)
 		options = append(options, pubsub.WithEventTracer(trw))
 
 		pst := newPeerScoreTracker(lt, in.Sk)
@@ -448,6 +482,7 @@ func HashMsgId(m *pubsub_pb.Message) string {
 func newTracerWrapper(
 	lp2pTracer pubsub.EventTracer,
 	lotusTracer pubsub.EventTracer,
+	deliveryTracer *tracer.DeliveryTracer,
 	topics ...string,
 ) pubsub.EventTracer {
 	var topicsMap map[string]struct{}
@@ -458,13 +493,14 @@ func newTracerWrapper(
 		}
 	}
 
-	return &tracerWrapper{lp2pTracer: lp2pTracer, lotusTracer: lotusTracer, topics: topicsMap}
+	return &tracerWrapper{lp2pTracer: lp2pTracer, lotusTracer: lotusTracer, deliveryTracer: deliveryTracer, topics: topicsMap}
 }
 
 type tracerWrapper struct {
-	lp2pTracer  pubsub.EventTracer
-	lotusTracer pubsub.EventTracer
-	topics      map[string]struct{}
+	lp2pTracer     pubsub.EventTracer
+	lotusTracer    pubsub.EventTracer
+	deliveryTracer *tracer.DeliveryTracer
+	topics         map[string]struct{}
 }
 
 func (trw *tracerWrapper) traceMessage(topic string) bool {
@@ -479,6 +515,12 @@ func (trw *tracerWrapper) Trace(evt *pubsub_pb.TraceEvent) {
 	// distributions.
 	// Furthermore, we only trace message publication and deliveries for specified topics
 	// (here just the blocks topic).
+	// The delivery tracer, when started, records the deliveries, duplicates and rejections of all
+	// topics.
+	if trw.deliveryTracer != nil {
+		trw.deliveryTracer.Trace(evt)
+	}
+
 	switch evt.GetType() {
 	case pubsub_pb.TraceEvent_PUBLISH_MESSAGE:
 		stats.Record(context.TODO(), metrics.PubsubPublishMessage.M(1))
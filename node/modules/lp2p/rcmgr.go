@@ -22,12 +22,13 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/filecoin-project/lotus/metrics"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/repo"
 )
 
 var rcmgrMetricsOnce sync.Once
 
-func ResourceManager(connMgrHi uint) func(lc fx.Lifecycle, repo repo.LockedRepo) (network.ResourceManager, error) {
+func ResourceManager(cfg *config.Libp2p) func(lc fx.Lifecycle, repo repo.LockedRepo) (network.ResourceManager, error) {
 	return func(lc fx.Lifecycle, repo repo.LockedRepo) (network.ResourceManager, error) {
 		isFullNode := repo.RepoType().Type() == "FullNode"
 		envvar := os.Getenv("LOTUS_RCMGR")
@@ -62,7 +63,7 @@ func ResourceManager(connMgrHi uint) func(lc fx.Lifecycle, repo repo.LockedRepo)
 			changes.System.Memory = 4 << 30
 		}
 
-		maxconns := int(connMgrHi)
+		maxconns := int(cfg.ConnMgrHigh)
 		if rcmgr.LimitVal(2*maxconns) > defaultLimitConfig.ToPartialLimitConfig().System.ConnsInbound {
 			// adjust conns to 2x to allow for two conns per peer (TCP+QUIC)
 			changes.System.ConnsInbound = rcmgr.LimitVal(logScale(2 * maxconns))
@@ -88,6 +89,12 @@ func ResourceManager(connMgrHi uint) func(lc fx.Lifecycle, repo repo.LockedRepo)
 			log.Info("adjusted default resource manager limits")
 		}
 
+		// limits set in the config take precedence over the adjusted defaults
+		setLimits(&changes.System, cfg.SystemLimits)
+		setLimits(&changes.Transient, cfg.TransientLimits)
+		setLimits(&changes.ProtocolDefault, cfg.ProtocolDefaultLimits)
+		setLimits(&changes.PeerDefault, cfg.PeerDefaultLimits)
+
 		changedLimitConfig := changes.Build(defaultLimitConfig)
 		// initialize
 		var limiter rcmgr.Limiter
@@ -148,6 +155,25 @@ func ResourceManager(connMgrHi uint) func(lc fx.Lifecycle, repo repo.LockedRepo)
 	}
 }
 
+func setLimits(l *rcmgr.ResourceLimits, cfg config.ResourceLimits) {
+	set := func(l *rcmgr.LimitVal, v int) {
+		if v != 0 {
+			*l = rcmgr.LimitVal(v)
+		}
+	}
+
+	if cfg.Memory != 0 {
+		l.Memory = rcmgr.LimitVal64(cfg.Memory)
+	}
+	set(&l.Streams, cfg.Streams)
+	set(&l.StreamsInbound, cfg.StreamsInbound)
+	set(&l.StreamsOutbound, cfg.StreamsOutbound)
+	set(&l.Conns, cfg.Conns)
+	set(&l.ConnsInbound, cfg.ConnsInbound)
+	set(&l.ConnsOutbound, cfg.ConnsOutbound)
+	set(&l.FD, cfg.FD)
+}
+
 func logScale(val int) int {
 	bitlen := bits.Len(uint(val))
 	return 1 << bitlen
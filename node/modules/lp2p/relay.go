@@ -1,22 +1,60 @@
 package lp2p
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/libp2p/go-libp2p"
 	coredisc "github.com/libp2p/go-libp2p/core/discovery"
 	"github.com/libp2p/go-libp2p/core/routing"
 	routingdisc "github.com/libp2p/go-libp2p/p2p/discovery/routing"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/addrutil"
+	"github.com/filecoin-project/lotus/node/config"
 )
 
 func NoRelay() func() (opts Libp2pOpts, err error) {
 	return func() (opts Libp2pOpts, err error) {
-		// always disabled, it's an eclipse attack vector
+		// disabled unless configured, it's an eclipse attack vector
 		opts.Opts = append(opts.Opts, libp2p.DisableRelay())
 		return
 	}
 }
 
+// Relay enables the circuit relay v2 client, service and hole punching as configured, and
+// disables relaying entirely when neither the client nor the service is enabled.
+func Relay(cfg *config.Libp2p) func() (opts Libp2pOpts, err error) {
+	return func() (opts Libp2pOpts, err error) {
+		if !cfg.EnableRelayClient && !cfg.EnableRelayService {
+			return NoRelay()()
+		}
+
+		opts.Opts = append(opts.Opts, libp2p.EnableRelay())
+
+		if cfg.EnableRelayClient {
+			relays, err := addrutil.ParseAddresses(context.TODO(), cfg.StaticRelays)
+			if err != nil {
+				return opts, xerrors.Errorf("parsing static relays: %w", err)
+			}
+			if len(relays) == 0 {
+				return opts, xerrors.Errorf("the relay client requires StaticRelays")
+			}
+			opts.Opts = append(opts.Opts, libp2p.EnableAutoRelayWithStaticRelays(relays))
+		}
+
+		if cfg.EnableRelayService {
+			opts.Opts = append(opts.Opts, libp2p.EnableRelayService())
+		}
+
+		if cfg.EnableHolePunching {
+			opts.Opts = append(opts.Opts, libp2p.EnableHolePunching())
+		}
+
+		return opts, nil
+	}
+}
+
 // TODO: should be use baseRouting or can we use higher level router here?
 func Discovery(router BaseIpfsRouting) (coredisc.Discovery, error) {
 	crouter, ok := router.(routing.ContentRouting)
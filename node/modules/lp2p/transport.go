@@ -6,11 +6,73 @@ import (
 	noise "github.com/libp2p/go-libp2p/p2p/security/noise"
 	tls "github.com/libp2p/go-libp2p/p2p/security/tls"
 	libp2pquic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	"github.com/libp2p/go-libp2p/p2p/transport/websocket"
+	libp2pwebtransport "github.com/libp2p/go-libp2p/p2p/transport/webtransport"
+	ma "github.com/multiformats/go-multiaddr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/addrutil"
+	"github.com/filecoin-project/lotus/node/config"
 )
 
 var DefaultTransports = simpleOpt(libp2p.DefaultTransports)
 var QUIC = simpleOpt(libp2p.Transport(libp2pquic.NewTransport))
 
+var transportOptions = map[string]libp2p.Option{
+	addrutil.TransportTCP:          libp2p.Transport(tcp.NewTCPTransport),
+	addrutil.TransportQUIC:         libp2p.Transport(libp2pquic.NewTransport),
+	addrutil.TransportWebTransport: libp2p.Transport(libp2pwebtransport.New),
+	addrutil.TransportWebsocket:    libp2p.Transport(websocket.New),
+}
+
+// Transports enables the named transports, or all of them when none are named.
+func Transports(enabled []string) func() (opts Libp2pOpts, err error) {
+	return func() (opts Libp2pOpts, err error) {
+		if len(enabled) == 0 {
+			opts.Opts = append(opts.Opts, libp2p.DefaultTransports)
+			return opts, nil
+		}
+
+		for _, name := range enabled {
+			opt, ok := transportOptions[name]
+			if !ok {
+				return opts, xerrors.Errorf("unknown libp2p transport %q, expected one of %v", name, addrutil.Transports)
+			}
+			opts.Opts = append(opts.Opts, opt)
+		}
+		return opts, nil
+	}
+}
+
+// ListenAddresses returns the listen addresses of the transports enabled in the config. The
+// addresses of disabled transports are left out with a warning rather than failing to listen.
+func ListenAddresses(cfg *config.Libp2p) []string {
+	enabled := map[string]bool{}
+	for _, name := range cfg.Transports {
+		enabled[name] = true
+	}
+
+	var out []string
+	for _, addrs := range [][]string{cfg.ListenAddresses, cfg.QUICListenAddresses, cfg.WebTransportListenAddresses} {
+		for _, addr := range addrs {
+			maddr, err := ma.NewMultiaddr(addr)
+			if err != nil {
+				// reported when starting to listen
+				out = append(out, addr)
+				continue
+			}
+
+			if t := addrutil.Transport(maddr); t != "" && len(enabled) > 0 && !enabled[t] {
+				log.Warnf("not listening on %s: the %s transport is not enabled", addr, t)
+				continue
+			}
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
 func Security(enabled, preferTLS bool) interface{} {
 	if !enabled {
 		return func() (opts Libp2pOpts) {
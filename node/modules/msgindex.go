@@ -34,3 +34,27 @@ func MsgIndex(lc fx.Lifecycle, mctx helpers.MetricsCtx, cs *store.ChainStore, r
 func DummyMsgIndex() index.MsgIndex {
 	return index.DummyMsgIndex
 }
+
+func AddrIndex(lc fx.Lifecycle, mctx helpers.MetricsCtx, cs *store.ChainStore, r repo.LockedRepo) (index.AddrIndex, error) {
+	basePath, err := r.SqlitePath()
+	if err != nil {
+		return nil, err
+	}
+
+	addrIndex, err := index.NewAddrIndex(helpers.LifecycleCtx(mctx, lc), basePath, cs)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(_ context.Context) error {
+			return addrIndex.Close()
+		},
+	})
+
+	return addrIndex, nil
+}
+
+func DummyAddrIndex() index.AddrIndex {
+	return index.DummyAddrIndex
+}
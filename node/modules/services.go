@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ipfs/go-datastore"
@@ -253,28 +254,58 @@ type RandomBeaconParams struct {
 	PubSub      *pubsub.PubSub `optional:"true"`
 	Cs          *store.ChainStore
 	DrandConfig dtypes.DrandSchedule
+	MetadataDS  dtypes.MetadataDS
 }
 
 func BuiltinDrandConfig() dtypes.DrandSchedule {
 	return build.DrandConfigSchedule()
 }
 
-func RandomSchedule(lc fx.Lifecycle, mctx helpers.MetricsCtx, p RandomBeaconParams, _ dtypes.AfterGenesisSet) (beacon.Schedule, error) {
-	gen, err := p.Cs.GetGenesis(helpers.LifecycleCtx(mctx, lc))
-	if err != nil {
-		return nil, err
+// ConfiguredDrandConfig is the builtin drand schedule, with the configured fallback endpoint
+// groups of the current drand network. Each group is a comma separated list of URLs.
+func ConfiguredDrandConfig(fallbacks []string) func() dtypes.DrandSchedule {
+	return func() dtypes.DrandSchedule {
+		ds := build.DrandConfigSchedule()
+
+		var groups [][]string
+		for _, fallback := range fallbacks {
+			var group []string
+			for _, url := range strings.Split(fallback, ",") {
+				if url = strings.TrimSpace(url); url != "" {
+					group = append(group, url)
+				}
+			}
+			if len(group) > 0 {
+				groups = append(groups, group)
+			}
+		}
+		ds[len(ds)-1].Config.FallbackServers = groups
+
+		return ds
 	}
+}
 
-	shd := beacon.Schedule{}
-	for _, dc := range p.DrandConfig {
-		bc, err := drand.NewDrandBeacon(gen.Timestamp, build.BlockDelaySecs, p.PubSub, dc.Config)
+// RandomSchedule creates the drand beacons of the schedule, persisting their validated entries
+// for entryCacheHorizon.
+func RandomSchedule(entryCacheHorizon time.Duration) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, p RandomBeaconParams, _ dtypes.AfterGenesisSet) (beacon.Schedule, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, p RandomBeaconParams, _ dtypes.AfterGenesisSet) (beacon.Schedule, error) {
+		gen, err := p.Cs.GetGenesis(helpers.LifecycleCtx(mctx, lc))
 		if err != nil {
-			return nil, xerrors.Errorf("creating drand beacon: %w", err)
+			return nil, err
+		}
+
+		shd := beacon.Schedule{}
+		for _, dc := range p.DrandConfig {
+			bc, err := drand.NewDrandBeacon(gen.Timestamp, build.BlockDelaySecs, p.PubSub, dc.Config)
+			if err != nil {
+				return nil, xerrors.Errorf("creating drand beacon: %w", err)
+			}
+			bc.PersistEntries(p.MetadataDS, entryCacheHorizon)
+			shd = append(shd, beacon.BeaconPoint{Start: dc.Start, Beacon: bc})
 		}
-		shd = append(shd, beacon.BeaconPoint{Start: dc.Start, Beacon: bc})
-	}
 
-	return shd, nil
+		return shd, nil
+	}
 }
 
 func OpenFilesystemJournal(lr repo.LockedRepo, lc fx.Lifecycle, disabled journal.DisabledEvents) (journal.Journal, error) {
@@ -1,14 +1,20 @@
 package modules
 
 import (
+	"context"
+	"time"
+
 	"go.uber.org/fx"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/index"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/vm"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
 
 func StateManager(lc fx.Lifecycle, cs *store.ChainStore, exec stmgr.Executor, sys vm.SyscallBuilder, us stmgr.UpgradeSchedule, b beacon.Schedule, metadataDs dtypes.MetadataDS, msgIndex index.MsgIndex) (*stmgr.StateManager, error) {
@@ -22,3 +28,56 @@ func StateManager(lc fx.Lifecycle, cs *store.ChainStore, exec stmgr.Executor, sy
 	})
 	return sm, nil
 }
+
+// ConfiguredStateManager builds the StateManager with the state caches and execution settings
+// from the Chainstore config.
+func ConfiguredStateManager(cfg *config.Chainstore) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, exec stmgr.Executor, sys vm.SyscallBuilder, us stmgr.UpgradeSchedule, b beacon.Schedule, metadataDs dtypes.MetadataDS, msgIndex index.MsgIndex) (*stmgr.StateManager, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, exec stmgr.Executor, sys vm.SyscallBuilder, us stmgr.UpgradeSchedule, b beacon.Schedule, metadataDs dtypes.MetadataDS, msgIndex index.MsgIndex) (*stmgr.StateManager, error) {
+		sm, err := StateManager(lc, cs, exec, sys, us, b, metadataDs, msgIndex)
+		if err != nil {
+			return nil, err
+		}
+		sm.SetStateCacheSize(cfg.TipSetStateCacheSize)
+		sm.SetExecBreakerThreshold(cfg.ExecutionBreakerThreshold)
+		sm.SetSpeculativeExecution(cfg.SpeculativeExecution)
+		sm.SetExecutionTraceMaxNodes(cfg.ExecutionTraceMaxNodes)
+		sm.SetParallelExecutionWorkers(cfg.ParallelExecutionWorkers)
+		if err := sm.EnablePersistentStateCache(helpers.LifecycleCtx(mctx, lc), cfg.PersistentStateCacheSize); err != nil {
+			return nil, err
+		}
+		return sm, nil
+	}
+}
+
+// StateCacheSnapshotFlushTimeout bounds the time spent writing the tipset state cache snapshot
+// when the node stops, so that it doesn't hold up the shutdown.
+var StateCacheSnapshotFlushTimeout = 10 * time.Second
+
+// StateCacheSnapshot warms the tipset state cache from the snapshot written when the node last
+// stopped, and writes a new snapshot when it stops. The daemon stops the node on SIGTERM and
+// SIGINT, so the snapshot is also written on those.
+func StateCacheSnapshot(lc fx.Lifecycle, sm *stmgr.StateManager) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			n, err := sm.LoadStateCacheSnapshot(ctx)
+			if err != nil {
+				// A stale or damaged snapshot only costs re-execution.
+				log.Errorw("failed to load state cache snapshot", "error", err)
+				return nil
+			}
+			log.Infow("loaded state cache snapshot", "entries", n)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, StateCacheSnapshotFlushTimeout)
+			defer cancel()
+
+			n, err := sm.FlushStateCache(ctx)
+			if err != nil {
+				return xerrors.Errorf("flushing state cache: %w", err)
+			}
+			log.Infow("flushed state cache snapshot", "entries", n)
+			return nil
+		},
+	})
+}
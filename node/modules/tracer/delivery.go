@@ -0,0 +1,254 @@
+package tracer
+
+import (
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pubsub_pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DeliveryEventDeliver   = "deliver"
+	DeliveryEventDuplicate = "duplicate"
+	DeliveryEventReject    = "reject"
+)
+
+var (
+	// DefaultDeliveryTraceMaxFileSize is the size at which delivery trace files are rotated.
+	DefaultDeliveryTraceMaxFileSize int64 = 256 << 20
+	// DefaultDeliveryTraceMaxFiles is the number of rotated delivery trace files kept.
+	DefaultDeliveryTraceMaxFiles = 4
+
+	deliveryTraceQueue = 16 << 10
+	deliveryTraceBatch = 1024
+	deliveryTraceFlush = time.Second
+)
+
+// DeliveryEvent is a structured record of a pubsub message delivered, received again or rejected.
+type DeliveryEvent struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	Topic     string    `json:"topic"`
+	MessageID string    `json:"messageID"`
+	From      peer.ID   `json:"from"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// DeliveryTraceConfig selects where delivery events are written; exactly one of File and Endpoint
+// must be set.
+type DeliveryTraceConfig struct {
+	// File is appended the events as JSON lines, and rotated every MaxFileSize bytes, keeping
+	// MaxFiles rotated files named File.1 (the newest) to File.<MaxFiles>.
+	File        string `json:",omitempty"`
+	MaxFileSize int64  `json:",omitempty"`
+	MaxFiles    int    `json:",omitempty"`
+
+	// Endpoint is the URL batches of events are POSTed to, as JSON lines.
+	Endpoint string `json:",omitempty"`
+}
+
+type DeliveryTraceStatus struct {
+	Enabled bool
+	Config  DeliveryTraceConfig
+	Since   time.Time
+
+	Written   uint64 // events written to the sink
+	Dropped   uint64 // events dropped as the sink couldn't keep up
+	Failed    uint64 // events lost to sink errors
+	LastError string `json:",omitempty"`
+}
+
+// DeliveryTracer writes the deliveries, duplicates and rejections of the pubsub messages of all
+// topics to a file or a remote collector. It can be started and stopped at runtime, and costs
+// nothing but a lock while stopped.
+type DeliveryTracer struct {
+	lk  sync.RWMutex
+	run *deliveryTraceRun
+}
+
+type deliveryTraceRun struct {
+	cfg    DeliveryTraceConfig
+	since  time.Time
+	sink   deliverySink
+	events chan DeliveryEvent
+	done   chan struct{}
+
+	written, dropped, failed uint64
+
+	errLk   sync.Mutex
+	lastErr string
+}
+
+type deliverySink interface {
+	Write(events []DeliveryEvent) error
+	Close() error
+}
+
+func NewDeliveryTracer() *DeliveryTracer {
+	return &DeliveryTracer{}
+}
+
+// Start starts tracing to the configured sink, replacing the current one if tracing.
+func (dt *DeliveryTracer) Start(cfg DeliveryTraceConfig) error {
+	var sink deliverySink
+	var err error
+	switch {
+	case cfg.File != "" && cfg.Endpoint != "":
+		return xerrors.Errorf("only one of a file and an endpoint can be traced to")
+	case cfg.File != "":
+		if cfg.MaxFileSize <= 0 {
+			cfg.MaxFileSize = DefaultDeliveryTraceMaxFileSize
+		}
+		if cfg.MaxFiles < 0 {
+			return xerrors.Errorf("negative number of rotated files")
+		}
+		if cfg.MaxFiles == 0 {
+			cfg.MaxFiles = DefaultDeliveryTraceMaxFiles
+		}
+		sink, err = newRotatingFileSink(cfg.File, cfg.MaxFileSize, cfg.MaxFiles)
+	case cfg.Endpoint != "":
+		sink, err = newHTTPSink(cfg.Endpoint)
+	default:
+		return xerrors.Errorf("a file or an endpoint to trace to is required")
+	}
+	if err != nil {
+		return xerrors.Errorf("opening delivery trace sink: %w", err)
+	}
+
+	if err := dt.Stop(); err != nil {
+		log.Warnf("error stopping the previous delivery trace: %s", err)
+	}
+
+	r := &deliveryTraceRun{
+		cfg:    cfg,
+		since:  time.Now(),
+		sink:   sink,
+		events: make(chan DeliveryEvent, deliveryTraceQueue),
+		done:   make(chan struct{}),
+	}
+	go r.loop()
+
+	dt.lk.Lock()
+	dt.run = r
+	dt.lk.Unlock()
+
+	log.Infow("started pubsub delivery trace", "file", cfg.File, "endpoint", cfg.Endpoint)
+	return nil
+}
+
+// Stop stops tracing, flushing the queued events.
+func (dt *DeliveryTracer) Stop() error {
+	dt.lk.Lock()
+	r := dt.run
+	dt.run = nil
+	if r != nil {
+		close(r.events)
+	}
+	dt.lk.Unlock()
+
+	if r == nil {
+		return nil
+	}
+	<-r.done
+	log.Info("stopped pubsub delivery trace")
+	return r.sink.Close()
+}
+
+func (dt *DeliveryTracer) Status() DeliveryTraceStatus {
+	dt.lk.RLock()
+	r := dt.run
+	dt.lk.RUnlock()
+
+	if r == nil {
+		return DeliveryTraceStatus{}
+	}
+
+	r.errLk.Lock()
+	lastErr := r.lastErr
+	r.errLk.Unlock()
+
+	return DeliveryTraceStatus{
+		Enabled:   true,
+		Config:    r.cfg,
+		Since:     r.since,
+		Written:   atomic.LoadUint64(&r.written),
+		Dropped:   atomic.LoadUint64(&r.dropped),
+		Failed:    atomic.LoadUint64(&r.failed),
+		LastError: lastErr,
+	}
+}
+
+// Trace queues the delivery events among the pubsub trace events. It never blocks; events are
+// dropped when the sink can't keep up.
+func (dt *DeliveryTracer) Trace(evt *pubsub_pb.TraceEvent) {
+	var e DeliveryEvent
+	switch evt.GetType() {
+	case pubsub_pb.TraceEvent_DELIVER_MESSAGE:
+		m := evt.GetDeliverMessage()
+		e = DeliveryEvent{Type: DeliveryEventDeliver, Topic: m.GetTopic(), MessageID: hex.EncodeToString(m.GetMessageID()), From: peer.ID(m.GetReceivedFrom())}
+	case pubsub_pb.TraceEvent_DUPLICATE_MESSAGE:
+		m := evt.GetDuplicateMessage()
+		e = DeliveryEvent{Type: DeliveryEventDuplicate, Topic: m.GetTopic(), MessageID: hex.EncodeToString(m.GetMessageID()), From: peer.ID(m.GetReceivedFrom())}
+	case pubsub_pb.TraceEvent_REJECT_MESSAGE:
+		m := evt.GetRejectMessage()
+		e = DeliveryEvent{Type: DeliveryEventReject, Topic: m.GetTopic(), MessageID: hex.EncodeToString(m.GetMessageID()), From: peer.ID(m.GetReceivedFrom()), Reason: m.GetReason()}
+	default:
+		return
+	}
+	e.Time = time.Unix(0, evt.GetTimestamp())
+
+	dt.lk.RLock()
+	defer dt.lk.RUnlock()
+	if dt.run == nil {
+		return
+	}
+
+	select {
+	case dt.run.events <- e:
+	default:
+		atomic.AddUint64(&dt.run.dropped, 1)
+	}
+}
+
+func (r *deliveryTraceRun) loop() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(deliveryTraceFlush)
+	defer ticker.Stop()
+
+	batch := make([]DeliveryEvent, 0, deliveryTraceBatch)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.sink.Write(batch); err != nil {
+			atomic.AddUint64(&r.failed, uint64(len(batch)))
+			r.errLk.Lock()
+			r.lastErr = err.Error()
+			r.errLk.Unlock()
+		} else {
+			atomic.AddUint64(&r.written, uint64(len(batch)))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case e, ok := <-r.events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= deliveryTraceBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type rotatingFileSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	f    *os.File
+	size int64
+}
+
+func newRotatingFileSink(path string, maxSize int64, maxFiles int) (*rotatingFileSink, error) {
+	s := &rotatingFileSink{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *rotatingFileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0660)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.f, s.size = f, st.Size()
+	return nil
+}
+
+func (s *rotatingFileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	for i := s.maxFiles - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return s.open()
+}
+
+func (s *rotatingFileSink) Write(events []DeliveryEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range events {
+		line := buf.Len()
+		if err := enc.Encode(&events[i]); err != nil {
+			return xerrors.Errorf("marshaling delivery event: %w", err)
+		}
+
+		if s.size+int64(buf.Len()) > s.maxSize && s.size+int64(line) > 0 {
+			// write out the lines preceding the one not fitting in the current file
+			if _, err := s.f.Write(buf.Bytes()[:line]); err != nil {
+				return err
+			}
+			buf.Next(line)
+			if err := s.rotate(); err != nil {
+				return xerrors.Errorf("rotating delivery trace file: %w", err)
+			}
+		}
+	}
+
+	n, err := s.f.Write(buf.Bytes())
+	s.size += int64(n)
+	return err
+}
+
+func (s *rotatingFileSink) Close() error {
+	return s.f.Close()
+}
+
+// httpSinkTimeout bounds the time to post a batch of events to a remote collector.
+var httpSinkTimeout = 10 * time.Second
+
+type httpSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newHTTPSink(endpoint string) (*httpSink, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid endpoint: %w", err)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, xerrors.Errorf("invalid endpoint %s: only http and https are supported", endpoint)
+	}
+
+	return &httpSink{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: httpSinkTimeout},
+	}, nil
+}
+
+func (s *httpSink) Write(events []DeliveryEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return xerrors.Errorf("marshaling delivery event: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, s.endpoint, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return xerrors.Errorf("posting delivery events: http %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
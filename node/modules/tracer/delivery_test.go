@@ -0,0 +1,91 @@
+package tracer
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pubsub_pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryTracer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.json")
+	dt := NewDeliveryTracer()
+
+	deliver := func(id string) *pubsub_pb.TraceEvent {
+		topic := "topicA"
+		return &pubsub_pb.TraceEvent{
+			Type: pubsub_pb.TraceEvent_DELIVER_MESSAGE.Enum(),
+			DeliverMessage: &pubsub_pb.TraceEvent_DeliverMessage{
+				MessageID:    []byte(id),
+				Topic:        &topic,
+				ReceivedFrom: []byte(peerIDA),
+			},
+		}
+	}
+	reject := func(id, reason string) *pubsub_pb.TraceEvent {
+		topic := "topicB"
+		return &pubsub_pb.TraceEvent{
+			Type: pubsub_pb.TraceEvent_REJECT_MESSAGE.Enum(),
+			RejectMessage: &pubsub_pb.TraceEvent_RejectMessage{
+				MessageID:    []byte(id),
+				Topic:        &topic,
+				ReceivedFrom: []byte(peerIDA),
+				Reason:       &reason,
+			},
+		}
+	}
+
+	// nothing is traced while stopped
+	dt.Trace(deliver("before"))
+	require.False(t, dt.Status().Enabled)
+
+	require.Error(t, dt.Start(DeliveryTraceConfig{}))
+	require.NoError(t, dt.Start(DeliveryTraceConfig{File: path, MaxFileSize: 300, MaxFiles: 2}))
+	require.True(t, dt.Status().Enabled)
+
+	for i := 0; i < 10; i++ {
+		dt.Trace(deliver("message"))
+	}
+	dt.Trace(reject("spam", "validation failed"))
+	dt.Trace(&pubsub_pb.TraceEvent{Type: pubsub_pb.TraceEvent_GRAFT.Enum()})
+
+	require.NoError(t, dt.Stop())
+	require.False(t, dt.Status().Enabled)
+
+	// the events were written to the file and its rotations, oldest first
+	var events []DeliveryEvent
+	for _, p := range []string{path + ".2", path + ".1", path} {
+		f, err := os.Open(p)
+		require.NoError(t, err)
+
+		st, err := f.Stat()
+		require.NoError(t, err)
+		require.LessOrEqual(t, st.Size(), int64(300))
+
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var e DeliveryEvent
+			require.NoError(t, json.Unmarshal(sc.Bytes(), &e))
+			events = append(events, e)
+		}
+		require.NoError(t, sc.Err())
+		require.NoError(t, f.Close())
+	}
+	_, err := os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+
+	require.NotEmpty(t, events)
+	last := events[len(events)-1]
+	require.Equal(t, DeliveryEventReject, last.Type)
+	require.Equal(t, "topicB", last.Topic)
+	require.Equal(t, "validation failed", last.Reason)
+	require.Equal(t, peerIDA, last.From)
+	for _, e := range events[:len(events)-1] {
+		require.Equal(t, DeliveryEventDeliver, e.Type)
+		require.Equal(t, "topicA", e.Topic)
+	}
+}
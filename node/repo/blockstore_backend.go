@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/system"
+)
+
+// DefaultBlockstoreBackend is the backend of repos which don't record one.
+const DefaultBlockstoreBackend = "badger"
+
+// fsBlockstoreBackend is the file, in the blockstore directory, recording the backend it was
+// created with.
+const fsBlockstoreBackend = "backend"
+
+// BlockstoreBackend opens a blockstore for the given domain, stored at path.
+type BlockstoreBackend func(ctx context.Context, domain BlockstoreDomain, path string, readonly bool) (blockstore.Blockstore, error)
+
+var (
+	backendsLk sync.Mutex
+	backends   = map[string]BlockstoreBackend{
+		DefaultBlockstoreBackend: openBadgerBlockstore,
+	}
+)
+
+// RegisterBlockstoreBackend makes a blockstore backend selectable by name through the
+// Chainstore.BlockstoreBackend config option.
+func RegisterBlockstoreBackend(name string, backend BlockstoreBackend) {
+	backendsLk.Lock()
+	defer backendsLk.Unlock()
+	backends[name] = backend
+}
+
+func blockstoreBackend(name string) (BlockstoreBackend, error) {
+	backendsLk.Lock()
+	defer backendsLk.Unlock()
+
+	backend, ok := backends[name]
+	if !ok {
+		var known []string
+		for k := range backends {
+			known = append(known, k)
+		}
+		return nil, xerrors.Errorf("unknown blockstore backend %q (known backends: %s)", name, strings.Join(known, ", "))
+	}
+	return backend, nil
+}
+
+func openBadgerBlockstore(_ context.Context, domain BlockstoreDomain, path string, readonly bool) (blockstore.Blockstore, error) {
+	opts, err := BadgerBlockstoreOptions(domain, path, readonly)
+	if err != nil {
+		return nil, err
+	}
+
+	if system.BadgerFsyncDisable {
+		opts.SyncWrites = false
+	}
+
+	return badgerbs.Open(opts)
+}
+
+// resolveBlockstoreBackend returns the backend of the blockstore at path. A blockstore keeps the
+// backend it was created with, which is recorded next to it; existing blockstores which predate
+// the record are badger ones. New blockstores use the configured backend.
+func (fsr *fsLockedRepo) resolveBlockstoreBackend(path string) (string, error) {
+	recordPath := filepath.Join(path, fsBlockstoreBackend)
+
+	configured := DefaultBlockstoreBackend
+	if fsr.repoType == FullNode {
+		c, err := fsr.Config()
+		if err != nil {
+			return "", xerrors.Errorf("loading config: %w", err)
+		}
+		if cfg, ok := c.(*config.FullNode); ok && cfg.Chainstore.BlockstoreBackend != "" {
+			configured = cfg.Chainstore.BlockstoreBackend
+		}
+	}
+
+	b, err := os.ReadFile(recordPath)
+	switch {
+	case err == nil:
+		recorded := strings.TrimSpace(string(b))
+		if recorded != configured {
+			return "", xerrors.Errorf("blockstore at %s uses the %q backend, but %q is configured; the blockstore must be converted before switching backends", path, recorded, configured)
+		}
+		return recorded, nil
+	case !errors.Is(err, os.ErrNotExist):
+		return "", xerrors.Errorf("reading blockstore backend: %w", err)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	backend := configured
+	if len(entries) > 0 {
+		backend = DefaultBlockstoreBackend
+		if configured != backend {
+			return "", xerrors.Errorf("blockstore at %s uses the %q backend, but %q is configured; the blockstore must be converted before switching backends", path, backend, configured)
+		}
+	}
+
+	if !fsr.readonly {
+		if err := os.WriteFile(recordPath, []byte(backend), 0644); err != nil {
+			return "", xerrors.Errorf("recording blockstore backend: %w", err)
+		}
+	}
+	return backend, nil
+}
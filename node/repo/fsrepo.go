@@ -21,12 +21,10 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/blockstore"
-	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
-	"github.com/filecoin-project/lotus/system"
 )
 
 const (
@@ -473,19 +471,21 @@ func (fsr *fsLockedRepo) Blockstore(ctx context.Context, domain BlockstoreDomain
 			return
 		}
 
-		opts, err := BadgerBlockstoreOptions(domain, path, readonly)
+		name, err := fsr.resolveBlockstoreBackend(path)
 		if err != nil {
 			fsr.bsErr = err
 			return
 		}
 
-		if system.BadgerFsyncDisable {
-			opts.SyncWrites = false
+		backend, err := blockstoreBackend(name)
+		if err != nil {
+			fsr.bsErr = err
+			return
 		}
 
-		bs, err := badgerbs.Open(opts)
+		bs, err := backend(ctx, domain, path, readonly)
 		if err != nil {
-			fsr.bsErr = err
+			fsr.bsErr = xerrors.Errorf("opening %s blockstore: %w", name, err)
 			return
 		}
 		fsr.bs = blockstore.WrapIDStore(bs)
@@ -102,6 +102,8 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	handleImportFunc := handleImport(a.(*impl.FullNodeAPI))
 	handleExportFunc := handleExport(a.(*impl.FullNodeAPI))
 	handleRemoteStoreFunc := handleRemoteStore(a.(*impl.FullNodeAPI))
+	handleChainSnapshotFunc := handleChainSnapshot(a.(*impl.FullNodeAPI))
+	handleChainBlockstoreFunc := handleChainBlockstore(a.(*impl.FullNodeAPI))
 	if permissioned {
 		importAH := &auth.Handler{
 			Verify: a.AuthVerify,
@@ -113,6 +115,16 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 			Next:   handleExportFunc,
 		}
 		m.Handle("/rest/v0/export", exportAH)
+		snapshotAH := &auth.Handler{
+			Verify: a.AuthVerify,
+			Next:   handleChainSnapshotFunc,
+		}
+		m.Handle("/rest/v0/chain/snapshot", snapshotAH)
+		chainBlockstoreAH := &auth.Handler{
+			Verify: a.AuthVerify,
+			Next:   handleChainBlockstoreFunc,
+		}
+		m.Handle("/rest/v0/chain/blockstore", chainBlockstoreAH)
 
 		storeAH := &auth.Handler{
 			Verify: a.AuthVerify,
@@ -122,6 +134,8 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	} else {
 		m.HandleFunc("/rest/v0/import", handleImportFunc)
 		m.HandleFunc("/rest/v0/export", handleExportFunc)
+		m.HandleFunc("/rest/v0/chain/snapshot", handleChainSnapshotFunc)
+		m.HandleFunc("/rest/v0/chain/blockstore", handleChainBlockstoreFunc)
 		m.HandleFunc("/rest/v0/store/{uuid}", handleRemoteStoreFunc)
 	}
 
@@ -303,3 +317,23 @@ func handleRemoteStore(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.
 		}
 	}
 }
+
+// handleChainBlockstore serves the chain blockstore, read-only, over a websocket, for nodes using
+// it as a shared coldstore (see blockstore.RemoteStore).
+func handleChainBlockstore(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.HasPerm(r.Context(), nil, api.PermRead) {
+			http.Error(w, "unauthorized: missing read permission", http.StatusUnauthorized)
+			return
+		}
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+
+		rpclog.Infow("serving chain blockstore", "remote", r.RemoteAddr)
+		bstore.HandleNetBstoreWS(context.Background(), bstore.NewReadOnlyStore(a.ChainAPI.ExposedBlockstore), c)
+	}
+}
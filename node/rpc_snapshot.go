@@ -0,0 +1,257 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/impl"
+)
+
+// errRangeDone aborts a snapshot export once the requested range has been written.
+var errRangeDone = errors.New("requested range written")
+
+// rangeWriter forwards the bytes of the inclusive [start, end] range of what is written to it,
+// and fails with errRangeDone once past the end.
+type rangeWriter struct {
+	w          io.Writer
+	start, end int64
+	off        int64
+}
+
+func (rw *rangeWriter) Write(p []byte) (int, error) {
+	lo := rw.off
+	rw.off += int64(len(p))
+	if lo > rw.end {
+		return 0, errRangeDone
+	}
+
+	from, to := rw.start-lo, int64(len(p))
+	if from < 0 {
+		from = 0
+	}
+	if rw.end+1-lo < to {
+		to = rw.end + 1 - lo
+	}
+	if from < to {
+		if _, err := rw.w.Write(p[from:to]); err != nil {
+			return 0, err
+		}
+	}
+	if rw.off > rw.end {
+		return len(p), errRangeDone
+	}
+	return len(p), nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
+
+// snapshotSizes remembers the size of recently served snapshots, so that resuming one only
+// requires walking it once more.
+type snapshotSizes struct {
+	lk    sync.Mutex
+	sizes map[string]int64
+}
+
+const maxSnapshotSizes = 16
+
+func (s *snapshotSizes) get(etag string) (int64, bool) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	n, ok := s.sizes[etag]
+	return n, ok
+}
+
+func (s *snapshotSizes) put(etag string, n int64) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if len(s.sizes) >= maxSnapshotSizes {
+		for k := range s.sizes {
+			delete(s.sizes, k)
+			break
+		}
+	}
+	s.sizes[etag] = n
+}
+
+// parseByteRange parses a single range of a Range header against a snapshot of the given size.
+func parseByteRange(h string, size int64) (int64, int64, error) {
+	spec := strings.TrimPrefix(h, "bytes=")
+	if spec == h || strings.Contains(spec, ",") {
+		return 0, 0, xerrors.Errorf("only a single byte range is supported")
+	}
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, xerrors.Errorf("malformed range %q", h)
+	}
+
+	if startStr == "" {
+		// suffix range: the last n bytes
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, xerrors.Errorf("malformed range %q", h)
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, xerrors.Errorf("malformed range %q", h)
+	}
+	end := size - 1
+	if endStr != "" {
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+			return 0, 0, xerrors.Errorf("malformed range %q", h)
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return 0, 0, xerrors.Errorf("range %q starts beyond the snapshot size %d", h, size)
+	}
+	return start, end, nil
+}
+
+// handleChainSnapshot serves a snapshot of the chain at a tipset as a CAR file. The parameters
+// are those of ChainExport: 'tipset' (defaults to the current head), 'recent-stateroots' and
+// 'skip-old-msgs', plus 'include-receipts'.
+//
+// Exports are deterministic for a given tipset and parameters, so interrupted downloads can be
+// resumed with a Range request against the same ETag: the snapshot is walked again and the bytes
+// before the requested offset are discarded.
+func handleChainSnapshot(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.Request) {
+	sizes := &snapshotSizes{sizes: make(map[string]int64)}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(404)
+			return
+		}
+		if !auth.HasPerm(r.Context(), nil, api.PermRead) {
+			http.Error(w, "unauthorized: missing read permission", http.StatusUnauthorized)
+			return
+		}
+		ctx := r.Context()
+		cs := a.ChainAPI.Chain
+
+		ts := cs.GetHeaviestTipSet()
+		if s := r.FormValue("tipset"); s != "" {
+			var err error
+			if ts, err = parseSnapshotTipSet(ctx, cs, s); err != nil {
+				http.Error(w, fmt.Sprintf("parsing tipset: %s", err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		nroots := build.Finality
+		if s := r.FormValue("recent-stateroots"); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || abi.ChainEpoch(n) < build.Finality {
+				http.Error(w, fmt.Sprintf("recent-stateroots must be a number of epochs of at least %d", build.Finality), http.StatusBadRequest)
+				return
+			}
+			nroots = abi.ChainEpoch(n)
+		}
+		skipOldMsgs := r.FormValue("skip-old-msgs") == "true"
+		inclReceipts := r.FormValue("include-receipts") == "true"
+
+		tsk := ts.Key()
+		etag := fmt.Sprintf(`"%s-%d-%t-%t"`, tsk, nroots, skipOldMsgs, inclReceipts)
+		export := func(ctx context.Context, out io.Writer) error {
+			return cs.ExportWithReceipts(ctx, ts, nroots, skipOldMsgs, inclReceipts, out)
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.ipld.car")
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("X-Lotus-Snapshot-Tipset", tsk.String())
+
+		rh := r.Header.Get("Range")
+		if ir := r.Header.Get("If-Range"); ir != "" && ir != etag {
+			rh = ""
+		}
+
+		if rh == "" {
+			cw := &countingWriter{}
+			if err := export(ctx, io.MultiWriter(w, cw)); err != nil {
+				rpclog.Errorw("serving chain snapshot", "tipset", tsk, "error", err)
+				return
+			}
+			sizes.put(etag, cw.n)
+			return
+		}
+
+		size, ok := sizes.get(etag)
+		if !ok {
+			cw := &countingWriter{}
+			if err := export(ctx, cw); err != nil {
+				http.Error(w, fmt.Sprintf("sizing snapshot: %s", err), http.StatusInternalServerError)
+				return
+			}
+			size = cw.n
+			sizes.put(etag, size)
+		}
+
+		start, end, err := parseByteRange(rh, size)
+		if err != nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+		w.WriteHeader(http.StatusPartialContent)
+		if err := export(ctx, &rangeWriter{w: w, start: start, end: end}); err != nil && !errors.Is(err, errRangeDone) {
+			rpclog.Errorw("serving chain snapshot range", "tipset", tsk, "start", start, "end", end, "error", err)
+		}
+	}
+}
+
+// parseSnapshotTipSet resolves either a comma separated list of block CIDs or a height on the
+// current chain, written as '@<height>'.
+func parseSnapshotTipSet(ctx context.Context, cs *store.ChainStore, s string) (*types.TipSet, error) {
+	if strings.HasPrefix(s, "@") {
+		h, err := strconv.ParseInt(s[1:], 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing height: %w", err)
+		}
+		return cs.GetTipsetByHeight(ctx, abi.ChainEpoch(h), cs.GetHeaviestTipSet(), true)
+	}
+
+	var cids []cid.Cid
+	for _, bs := range strings.Split(s, ",") {
+		c, err := cid.Decode(strings.TrimSpace(bs))
+		if err != nil {
+			return nil, xerrors.Errorf("parsing block cid %q: %w", bs, err)
+		}
+		cids = append(cids, c)
+	}
+	return cs.LoadTipSet(ctx, types.NewTipSetKey(cids...))
+}